@@ -10,8 +10,10 @@ import (
 	"syscall"
 	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/config"
 	"api-gateway/internal/handler"
+	"api-gateway/internal/middleware/auth"
 	"api-gateway/internal/repository"
 	"api-gateway/pkg/logger"
 	redisclient "api-gateway/pkg/redis"
@@ -39,6 +41,17 @@ func main() {
 		"version", "1.0.0",
 		"config", *configPath)
 
+	// 監査ログの初期化（有効な場合）
+	var auditLog *audit.Logger
+	if cfg.Audit.Enabled {
+		auditLog, err = audit.Open(cfg.Audit.Output)
+		if err != nil {
+			log.Error("failed to open audit log", "error", err)
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+	}
+
 	// Redis設定の確認
 	if cfg.Redis.Host == "" {
 		log.Error("redis host is not configured")
@@ -54,6 +67,7 @@ func main() {
 		DialTimeout:  cfg.Redis.DialTimeout,
 		ReadTimeout:  cfg.Redis.ReadTimeout,
 		WriteTimeout: cfg.Redis.WriteTimeout,
+		Keyspace:     redisclient.Keyspace{Namespace: cfg.Redis.Keyspace.Namespace, Version: cfg.Redis.Keyspace.Version},
 	})
 	if err != nil {
 		log.Error("failed to connect to redis", "error", err)
@@ -66,36 +80,85 @@ func main() {
 	// セッションリポジトリの初期化
 	sessionRepo := repository.NewRedisSessionRepository(redisClient, cfg.Redis.KeyPrefix)
 
-	// APIキーの取得（環境変数から）
-	apiKey := os.Getenv("ADMIN_API_KEY")
-	if apiKey == "" {
+	// APIキーの取得（ADMIN_API_KEY はカンマ区切りで複数指定でき、ADMIN_API_KEY_FILE は定期的に読み直す）
+	keysConfig := handler.AdminKeysConfigFromEnv(os.Getenv)
+	if len(keysConfig.Keys) == 0 && keysConfig.File == "" {
 		// デフォルト値（開発環境用）
-		apiKey = "dev-admin-api-key-change-me-in-production"
+		keysConfig.Keys = []string{"dev-admin-api-key-change-me-in-production"}
 		log.Warn("ADMIN_API_KEY environment variable not set, using default key (INSECURE for production)")
 	}
+	keysConfig.Logger = log
+	apiKeys, err := handler.NewAdminKeys(keysConfig)
+	if err != nil {
+		log.Error("failed to load admin api keys", "error", err)
+		os.Exit(1)
+	}
+	keysCtx, stopKeys := context.WithCancel(context.Background())
+	defer stopKeys()
+	apiKeys.Start(keysCtx)
+	log.Info("admin api keys loaded", "keys", apiKeys.Len(), "file", keysConfig.File)
+
+	// 管理者のJWTを検証するミドルウェア（admin.jwt が有効な場合）
+	// 公開鍵ファイルとJWKSはゲートウェイと同じ jwt の設定を使う
+	var adminJWT *auth.JWTMiddleware
+	if cfg.Admin.JWT.Enabled {
+		publicKeys, err := auth.LoadPublicKeysFromFiles(cfg.JWT.PublicKeyFiles)
+		if err != nil {
+			log.Error("failed to load jwt public keys", "error", err)
+			os.Exit(1)
+		}
+
+		var jwks *auth.JWKSCache
+		if cfg.JWT.JWKS.URL != "" {
+			jwks = auth.NewJWKSCache(auth.JWKSConfig{
+				URL:             cfg.JWT.JWKS.URL,
+				RefreshInterval: cfg.JWT.JWKS.RefreshInterval,
+				StaleTolerance:  cfg.JWT.JWKS.StaleTolerance,
+				Timeout:         cfg.JWT.JWKS.Timeout,
+				Logger:          log,
+			})
+			jwksCtx, stopJWKS := context.WithCancel(context.Background())
+			defer stopJWKS()
+			if err := jwks.Start(jwksCtx); err != nil {
+				log.Warn("failed to fetch jwks, retrying in background", "error", err)
+			}
+		}
+
+		adminJWT = auth.NewJWTMiddleware(auth.JWTConfig{PublicKeys: publicKeys, JWKS: jwks})
+		log.Info("admin jwt authentication enabled", "public_keys", len(publicKeys), "jwks", cfg.JWT.JWKS.URL)
+	}
 
 	// AdminRevokeハンドラの初期化
 	adminRevokeHandler := handler.NewAdminRevokeHandler(handler.AdminRevokeConfig{
 		Repository:    sessionRepo,
-		APIKey:        apiKey,
+		APIKeys:       apiKeys,
+		JWT:           adminJWT,
+		AdminRole:     cfg.Admin.JWT.Role,
 		JWTExpiration: 10 * time.Hour,
 		Logger:        log,
+		Audit:         auditLog,
 	})
 
-	// HTTPマルチプレクサの設定
-	mux := http.NewServeMux()
-	mux.Handle("/v1/revoke", adminRevokeHandler)
+	// Logoutハンドラの初期化（cmd/logout と同じ設定）
+	logoutHandler := handler.NewLogoutHandler(handler.LogoutConfig{
+		Repository:    sessionRepo,
+		UserIDClaim:   "sub",
+		JWTExpiration: 10 * time.Hour,
+		Logger:        log,
+		Audit:         auditLog,
+	})
 
-	// ヘルスチェックエンドポイント
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+	// ログアウト、強制失効、失効の取り消し、失効状態の確認とヘルスチェックを1つのリスナーで提供する
+	adminServerHandler := handler.NewAdminServerHandler(handler.AdminServerConfig{
+		Logout: logoutHandler,
+		Revoke: adminRevokeHandler,
+		Logger: log,
 	})
 
 	// HTTPサーバーの設定
 	server := &http.Server{
 		Addr:         cfg.Server.Address(),
-		Handler:      mux,
+		Handler:      adminServerHandler,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
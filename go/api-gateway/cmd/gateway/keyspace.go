@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"api-gateway/internal/config"
+	"api-gateway/pkg/redis"
+)
+
+// runKeyspace は Redis のキーの名前空間を操作するサブコマンドを実行し、終了コードを返す
+//
+//	gateway keyspace migrate -config gateway.yaml -from-version 1   古い名前空間のキーを設定の名前空間へ複製する
+//	gateway keyspace purge -config gateway.yaml -version 1          古い名前空間のキーを削除する
+//
+// キーの形式を変える場合は、新しいバージョンを設定したゲートウェイを展開する前に migrate し、
+// 古いバージョンのゲートウェイが全て無くなった後に purge する
+func runKeyspace(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: gateway keyspace migrate|purge [flags]")
+		return 2
+	}
+	switch args[0] {
+	case "migrate":
+		return runKeyspaceMigrate(args[1:], stdout, stderr)
+	case "purge":
+		return runKeyspacePurge(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown keyspace command %q\n", args[0])
+		return 2
+	}
+}
+
+// runKeyspaceMigrate は -from-namespace・-from-version の名前空間のキーを設定の名前空間（redis.keyspace）へ複製する
+func runKeyspaceMigrate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("keyspace migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "configs/gateway.yaml", "path to gateway config file (the destination keyspace is redis.keyspace)")
+	fromNamespace := fs.String("from-namespace", "", "namespace to migrate keys from")
+	fromVersion := fs.Int("from-version", 0, "key format version to migrate keys from")
+	match := fs.String("match", "*", "pattern of keys to migrate, relative to the keyspace")
+	dryRun := fs.Bool("dry-run", false, "count the keys to migrate without writing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client, code := newKeyspaceClient(*configPath, stderr)
+	if client == nil {
+		return code
+	}
+	defer client.Close()
+
+	from := redis.Keyspace{Namespace: *fromNamespace, Version: *fromVersion}
+	if err := from.Validate(); err != nil {
+		fmt.Fprintf(stderr, "Invalid source keyspace: %v\n", err)
+		return 2
+	}
+	result, err := client.Migrate(context.Background(), from, client.Keyspace(), redis.MigrateOptions{
+		Match:  *match,
+		DryRun: *dryRun,
+	})
+	fmt.Fprintf(stdout, "%s -> %s: %d copied, %d skipped\n", from, client.Keyspace(), result.Copied, result.Skipped)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to migrate keys: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runKeyspacePurge は -namespace・-version の名前空間のキーを削除する
+// 設定の名前空間（使用中のキー）は削除できない
+func runKeyspacePurge(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("keyspace purge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "configs/gateway.yaml", "path to gateway config file (used for the redis connection)")
+	namespace := fs.String("namespace", "", "namespace to purge")
+	version := fs.Int("version", 0, "key format version to purge")
+	match := fs.String("match", "*", "pattern of keys to purge, relative to the keyspace")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client, code := newKeyspaceClient(*configPath, stderr)
+	if client == nil {
+		return code
+	}
+	defer client.Close()
+
+	keyspace := redis.Keyspace{Namespace: *namespace, Version: *version}
+	if err := keyspace.Validate(); err != nil {
+		fmt.Fprintf(stderr, "Invalid keyspace: %v\n", err)
+		return 2
+	}
+	if keyspace == client.Keyspace() {
+		fmt.Fprintf(stderr, "Refusing to purge the configured keyspace %s\n", keyspace)
+		return 2
+	}
+	purged, err := client.Purge(context.Background(), keyspace, *match)
+	fmt.Fprintf(stdout, "%s: %d purged\n", keyspace, purged)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to purge keys: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newKeyspaceClient は設定ファイルのRedisに接続する
+// 接続できない場合は nil と終了コードを返す
+func newKeyspaceClient(configPath string, stderr io.Writer) (*redis.Client, int) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return nil, 1
+	}
+	if cfg.Redis.Host == "" {
+		fmt.Fprintln(stderr, "redis.host is not configured")
+		return nil, 1
+	}
+	client, err := redis.NewClient(redis.Config{
+		Host:         cfg.Redis.Host,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+		Keyspace:     redis.Keyspace{Namespace: cfg.Redis.Keyspace.Namespace, Version: cfg.Redis.Keyspace.Version},
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to connect to redis: %v\n", err)
+		return nil, 1
+	}
+	return client, 0
+}
@@ -1,30 +1,62 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"crypto/rsa"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
+	"time"
 
+	"api-gateway/internal/audit"
+	"api-gateway/internal/buffer"
+	"api-gateway/internal/cache"
 	"api-gateway/internal/config"
+	"api-gateway/internal/discovery"
+	"api-gateway/internal/featureflag"
+	"api-gateway/internal/forwarded"
 	"api-gateway/internal/handler"
+	"api-gateway/internal/healthcheck"
+	"api-gateway/internal/journal"
+	"api-gateway/internal/kubernetes"
+	"api-gateway/internal/metrics"
 	"api-gateway/internal/middleware"
 	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/openapi"
+	"api-gateway/internal/portal"
 	"api-gateway/internal/repository"
 	"api-gateway/internal/routing"
+	"api-gateway/internal/startup"
 	"api-gateway/internal/transport"
 	"api-gateway/pkg/logger"
 	"api-gateway/pkg/redis"
+	"api-gateway/pkg/signature"
 )
 
 func main() {
+	// ルーティング設定のテストモード（gateway test -routes routing.yaml -cases cases.yaml）
+	if len(os.Args) > 1 && os.Args[1] == "test" {
+		os.Exit(runRouteTest(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// Redisのキーの名前空間の移行（gateway keyspace migrate -config gateway.yaml -from-version 1）
+	if len(os.Args) > 1 && os.Args[1] == "keyspace" {
+		os.Exit(runKeyspace(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// コマンドライン引数のパース
 	configPath := flag.String("config", "configs/gateway.yaml", "path to config file")
+	waitForDeps := flag.Bool("wait-for-deps", false, "wait for the routing file, Redis and JWKS to become available before serving (up to startup.wait_timeout)")
 	flag.Parse()
 
 	// 設定ファイルの読み込み
@@ -34,10 +66,47 @@ func main() {
 		os.Exit(1)
 	}
 
-	// ロガーの初期化
+	// ログの送信（ログ収集のサイドカーが無い環境で、標準出力に加えてOTLPコレクターまたはLokiに送信する）
+	var logExport slog.Handler
+	if export := cfg.Logging.Export; export.Endpoint != "" {
+		exportLog, err := logger.NewExportHandler(logger.ExportConfig{
+			Protocol:      logger.ExportProtocol(export.Protocol),
+			Endpoint:      export.Endpoint,
+			Headers:       export.Headers,
+			ServiceName:   cmp.Or(export.ServiceName, "api-gateway"),
+			Labels:        export.Labels,
+			BatchSize:     export.BatchSize,
+			FlushInterval: export.FlushInterval,
+			QueueSize:     export.QueueSize,
+			Timeout:       export.Timeout,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to configure log export: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			exportLog.Close(ctx)
+		}()
+		metrics.NewCounterFunc("gateway_log_export_dropped_total",
+			"Number of log records dropped because the log export queue was full.",
+			exportLog.Dropped)
+		metrics.NewCounterFunc("gateway_log_export_rejected_total",
+			"Number of log records dropped because the log export endpoint rejected them.",
+			exportLog.Rejected)
+		metrics.NewCounterFunc("gateway_log_export_failures_total",
+			"Number of failed log export requests.",
+			exportLog.Failures)
+		logExport = exportLog
+	}
+
+	// ロガーの初期化（全てのログでヘッダーの値を伏せる）
+	logger.SetSensitiveHeaders(cfg.Logging.SensitiveHeaders)
 	log := logger.New(logger.Config{
 		Level:  logger.LogLevel(cfg.Logging.Level),
 		Format: cfg.Logging.Format,
+		Export: logExport,
 	})
 
 	log.Info("Starting API Gateway",
@@ -45,50 +114,149 @@ func main() {
 		slog.String("host", cfg.Server.Host),
 		slog.Int("port", cfg.Server.Port),
 	)
+	if logExport != nil {
+		log.Info("Log export enabled",
+			slog.String("protocol", cmp.Or(cfg.Logging.Export.Protocol, string(logger.ExportOTLP))),
+			slog.String("endpoint", cfg.Logging.Export.Endpoint))
+	}
 
-	// ルーティング設定の読み込み
-	routingCfg, err := config.LoadRoutingConfig(cfg.Routing.ConfigFile)
-	if err != nil {
-		log.Error("Failed to load routing config", slog.String("error", err.Error()))
-		os.Exit(1)
+	// 監査ログの初期化（有効な場合）
+	var auditLog *audit.Logger
+	if cfg.Audit.Enabled {
+		auditLog, err = audit.Open(cfg.Audit.Output)
+		if err != nil {
+			log.Error("Failed to open audit log", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+	}
+
+	// 起動時の依存先（ルーティング設定ファイル、Redis、JWKS）の再試行
+	// --wait-for-deps の場合は回数に関わらず startup.wait_timeout まで待つ
+	deps := startup.New(startup.Config{
+		Retries:    cfg.Startup.Retries,
+		Backoff:    cfg.Startup.Backoff,
+		MaxBackoff: cfg.Startup.MaxBackoff,
+		Wait:       *waitForDeps,
+		Logger:     log,
+	})
+	depsCtx, cancelDeps := context.WithCancel(context.Background())
+	if *waitForDeps && cfg.Startup.WaitTimeout > 0 {
+		depsCtx, cancelDeps = context.WithTimeout(context.Background(), cfg.Startup.WaitTimeout)
+	}
+	defer cancelDeps()
+
+	// リクエストのジャーナルの初期化（有効な場合）
+	var requestJournal *journal.Journal
+	if cfg.Journal.Enabled {
+		requestJournal, err = openJournal(cfg.Journal, log)
+		if err != nil {
+			log.Error("Failed to open request journal", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer func() {
+			if err := requestJournal.Close(); err != nil {
+				log.Error("Failed to close request journal", slog.String("error", err.Error()))
+			}
+		}()
+		log.Info("Request journal enabled", slog.String("sink", cfg.Journal.Sink))
+	}
+
+	// ルーティング設定の読み込み（ファイルがまだ無い場合のみ再試行する）
+	// source: kubernetes ではファイルを省略できる
+	routingCfg := &config.RoutingFileConfig{}
+	if cfg.Routing.ConfigFile != "" {
+		err = deps.Wait(depsCtx, "routing config", func(context.Context) error {
+			routingCfg, err = config.LoadRoutingConfig(cfg.Routing.ConfigFile)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return startup.Permanent(err)
+			}
+			return err
+		})
+		if err != nil {
+			log.Error("Failed to load routing config", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	// KubernetesのHTTPRoute・Ingressから変換したルートを加える（source: kubernetes の場合）
+	// 変更の監視はリスナーの初期化後に開始し、変更があるたびに全てのリスナーのルートを置き換える
+	var routesSource *kubernetes.Source
+	if cfg.Routing.Source == config.RoutingSourceKubernetes {
+		routesSource, err = kubernetesSource(cfg.Routing.Kubernetes, routingCfg, log)
+		if err != nil {
+			log.Error("Failed to initialize kubernetes routing", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		err = deps.Wait(depsCtx, "kubernetes routes", func(ctx context.Context) error {
+			routingCfg, err = routesSource.Load(ctx)
+			return err
+		})
+		if err != nil {
+			log.Error("Failed to load kubernetes routes", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
 	}
 
 	// ルーターの初期化
 	router := routing.NewRouter()
+	router.SetTrailingSlashPolicy(routing.TrailingSlashPolicy(cfg.Routing.TrailingSlash))
 	if err := router.LoadFromConfig(routingCfg); err != nil {
 		log.Error("Failed to load routes", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
+	// 読み込んだルートを差分として記録する（起動時は全てのルートが追加）
 	routes := router.GetAllRoutes()
-	log.Info("Routes loaded", slog.Int("count", len(routes)))
+	routingDiff := config.DiffRoutingConfig(nil, routingCfg)
+	log.Info("Routes loaded", slog.Int("count", len(routes)), slog.Any("diff", routingDiff))
+	reloadStatus := handler.NewReloadStatus()
+	reloadStatus.Record(handler.ReloadResult{Time: time.Now(), Success: true, Routes: len(routes), Diff: routingDiff})
+	reloader := newRouteReloader(router, routingCfg, reloadStatus, log)
 
 	// Redisクライアントの初期化（設定がある場合）
 	var sessionRepo repository.SessionRepository
+	var oidcSessions repository.OIDCSessionRepository
+	var revokeCache *auth.RevokedTimeCache
+	var redisClient *redis.Client
 	if cfg.Redis.Host != "" {
-		redisClient, err := redis.NewClient(redis.Config{
-			Host:         cfg.Redis.Host,
-			Password:     cfg.Redis.Password,
-			DB:           cfg.Redis.DB,
-			PoolSize:     cfg.Redis.PoolSize,
-			DialTimeout:  cfg.Redis.DialTimeout,
-			ReadTimeout:  cfg.Redis.ReadTimeout,
-			WriteTimeout: cfg.Redis.WriteTimeout,
+		// 接続を確認できるまで再試行し、できなかった場合は起動を中止する
+		err = deps.Wait(depsCtx, "redis", func(context.Context) error {
+			redisClient, err = redis.NewClient(redis.Config{
+				Host:         cfg.Redis.Host,
+				Password:     cfg.Redis.Password,
+				DB:           cfg.Redis.DB,
+				PoolSize:     cfg.Redis.PoolSize,
+				DialTimeout:  cfg.Redis.DialTimeout,
+				ReadTimeout:  cfg.Redis.ReadTimeout,
+				WriteTimeout: cfg.Redis.WriteTimeout,
+				Keyspace:     redis.Keyspace{Namespace: cfg.Redis.Keyspace.Namespace, Version: cfg.Redis.Keyspace.Version},
+			})
+			return err
 		})
 		if err != nil {
 			log.Error("Failed to initialize Redis client", slog.String("error", err.Error()))
 			os.Exit(1)
 		}
-
-		// Redis接続確認
-		if err := redisClient.Ping(context.Background()); err != nil {
-			log.Warn("Redis ping failed", slog.String("error", err.Error()))
-		} else {
-			log.Info("Redis connected successfully")
-		}
+		log.Info("Redis connected successfully", slog.String("keyspace", redisClient.Keyspace().String()))
 
 		// セッションリポジトリの初期化
-		sessionRepo = repository.NewRedisSessionRepository(redisClient, cfg.Redis.KeyPrefix)
+		redisSessions := repository.NewRedisSessionRepository(redisClient, cfg.Redis.KeyPrefix)
+		sessionRepo = redisSessions
+		oidcSessions = repository.NewRedisOIDCSessionRepository(redisClient, "")
+
+		// 失効時刻のローカルキャッシュ（設定がある場合）
+		// logout・管理APIで失効時刻を変更すると全てのレプリカへpub/subで通知され、該当ユーザーのキャッシュを破棄する
+		if cfg.Redis.RevokeCache.Size > 0 {
+			revokeCache = auth.NewRevokedTimeCache(cfg.Redis.RevokeCache.Size, cfg.Redis.RevokeCache.TTL)
+			revocationsCtx, stopRevocations := context.WithCancel(context.Background())
+			defer stopRevocations()
+			// 購読できない間もキャッシュはTTLで期限切れになる。購読し直した時はキャッシュを全て破棄する
+			go redisSessions.SubscribeRevocations(revocationsCtx, revokeCache.Listener(log))
+			log.Info("Revoke cache enabled",
+				slog.Int("size", cfg.Redis.RevokeCache.Size),
+				slog.Duration("ttl", cmp.Or(cfg.Redis.RevokeCache.TTL, auth.DefaultRevokeCacheTTL)))
+		}
 	}
 
 	// JWT公開鍵の読み込み（設定がある場合）
@@ -103,34 +271,430 @@ func main() {
 		log.Info("JWT public keys loaded", slog.Int("count", len(keys)))
 	}
 
+	// 署名鍵の読み込み（設定がある場合）
+	// パッシブな鍵を含む全ての公開鍵を、ゲートウェイ自身の検証にも使う
+	var signingKeys *auth.SigningKeySet
+	if len(cfg.JWT.Signing.PrivateKeyFiles) > 0 {
+		privateKeys, err := auth.LoadPrivateKeysFromFiles(cfg.JWT.Signing.PrivateKeyFiles)
+		if err != nil {
+			log.Error("Failed to load JWT signing keys", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		signingKeys, err = auth.NewSigningKeySet(privateKeys, cfg.JWT.Signing.ActiveKID)
+		if err != nil {
+			log.Error("Failed to initialize JWT signing keys", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		if jwtPublicKeys == nil {
+			jwtPublicKeys = make(map[string]*rsa.PublicKey)
+		}
+		for kid, key := range signingKeys.PublicKeys() {
+			jwtPublicKeys[kid] = key
+		}
+		log.Info("JWT signing keys loaded",
+			slog.String("active_kid", signingKeys.ActiveKID()),
+			slog.Int("count", len(privateKeys)))
+	}
+	// 公開鍵はSIGHUP・管理APIで設定を読み込み直した時に入れ替える（作成済みのミドルウェアにも反映される）
+	jwtKeys := auth.NewPublicKeySet(jwtPublicKeys)
+
+	// JWKSキャッシュの初期化（設定がある場合）
+	// 初回取得に失敗しても起動は続け、バックグラウンドで再試行する（--wait-for-deps の場合は起動を中止する）
+	var jwks *auth.JWKSCache
+	if cfg.JWT.JWKS.URL != "" {
+		jwks = auth.NewJWKSCache(auth.JWKSConfig{
+			URL:             cfg.JWT.JWKS.URL,
+			RefreshInterval: cfg.JWT.JWKS.RefreshInterval,
+			StaleTolerance:  cfg.JWT.JWKS.StaleTolerance,
+			Timeout:         cfg.JWT.JWKS.Timeout,
+			Logger:          log,
+		})
+		if err := deps.Wait(depsCtx, "jwks", jwks.Refresh); err != nil && *waitForDeps {
+			log.Error("Failed to fetch JWKS", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		jwksCtx, stopJWKS := context.WithCancel(context.Background())
+		defer stopJWKS()
+		if err := jwks.Start(jwksCtx); err != nil {
+			log.Warn("Failed to fetch JWKS, retrying in background", slog.String("error", err.Error()))
+		} else {
+			log.Info("JWKS loaded", slog.String("url", cfg.JWT.JWKS.URL))
+		}
+	}
+
+	// レスポンスキャッシュの保存先の初期化
+	var cacheStore cache.Store
+	if cfg.Cache.Store == "redis" {
+		cacheStore = cache.NewRedisStore(redisClient, cfg.Cache.KeyPrefix)
+	} else {
+		cacheStore = cache.NewMemoryStore(cfg.Cache.MaxEntries)
+	}
+
 	// ミドルウェアファクトリーの初期化
 	middlewareFactory := middleware.NewFactory(middleware.FactoryConfig{
-		JWTPublicKeys: jwtPublicKeys,
-		SessionRepo:   sessionRepo,
-		Logger:        log,
+		JWTKeys:      jwtKeys,
+		JWKS:         jwks,
+		SessionRepo:  sessionRepo,
+		RevokeCache:  revokeCache,
+		OIDCSessions: oidcSessions,
+		CacheStore:   cacheStore,
+		Logger:       log,
+		Audit:        auditLog,
 	})
 
 	// トランスポーターの初期化
 	transporter := transport.NewHTTPTransporter()
+	transporter.Buffer = buffer.Config{
+		MemoryLimit: cfg.Buffer.MemoryLimit,
+		TempDir:     cfg.Buffer.TempDir,
+	}
+	transporter.Logger = log
+	grpcTransporter := transport.NewGRPCTransporter()
 
 	// Gatewayハンドラの初期化
-	gateway := handler.NewGateway(router, transporter, middlewareFactory, log)
+	// 非同期ログが有効な場合、アクセスログの書き込みはバックグラウンドで行う
+	gatewayLog := log
+	if cfg.Logging.Async.Enabled {
+		var asyncLog *logger.AsyncHandler
+		gatewayLog, asyncLog = logger.NewAsync(logger.Config{
+			Level:  logger.LogLevel(cfg.Logging.Level),
+			Format: cfg.Logging.Format,
+			Export: logExport,
+		}, cfg.Logging.Async.QueueSize)
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			asyncLog.Close(ctx)
+		}()
+		metrics.NewCounterFunc("gateway_log_dropped_total",
+			"Number of log records dropped because the async log queue was full.",
+			asyncLog.Dropped)
+		log.Info("Async access logging enabled")
+	}
+	// ログのサンプリングと件数の制限（エラーのアクセスログとWARN以上は間引かない）
+	if sampling := cfg.Logging.Sampling; sampling.Every > 1 || sampling.RateLimit > 0 {
+		var sampler *logger.SamplingHandler
+		gatewayLog, sampler = logger.WithSampling(gatewayLog, logger.SamplingConfig{
+			Every:     sampling.Every,
+			Keep:      handler.KeepAccessLog,
+			RateLimit: sampling.RateLimit,
+			Interval:  sampling.Interval,
+		})
+		metrics.NewCounterFunc("gateway_log_sampled_total",
+			"Number of log records dropped by log sampling.",
+			sampler.Sampled)
+		metrics.NewCounterFunc("gateway_log_rate_limited_total",
+			"Number of log records dropped because the log rate limit was exceeded.",
+			sampler.RateLimited)
+		log.Info("Log sampling enabled",
+			slog.Int("every", sampling.Every),
+			slog.Int("rate_limit", sampling.RateLimit))
+	}
 
-	// HTTPサーバの設定
-	server := &http.Server{
-		Addr:         cfg.Server.Address(),
-		Handler:      gateway,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
+	// X-Forwarded-* ヘッダーを信頼する前段のプロキシ（設定の検証済み）
+	trustedProxies, err := forwarded.NewTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Error("Invalid trusted proxies", slog.String("error", err.Error()))
+		os.Exit(1)
 	}
 
-	// サーバの起動
-	go func() {
-		log.Info("Server starting", slog.String("address", server.Addr))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Error("Server failed", slog.String("error", err.Error()))
+	// 転送するリクエストへの署名（sign_requests が有効なルートのみ、アクティブな署名鍵を使う）
+	var requestSigner *signature.Signer
+	if signingKeys != nil {
+		requestSigner = signature.NewSigner(signingKeys.Active)
+	} else {
+		for _, route := range routes {
+			if route.Backend.SignRequests {
+				log.Error("Route requires request signing but jwt.signing is not configured", slog.String("path", route.Path))
+				os.Exit(1)
+			}
+		}
+	}
+
+	// ルートの enabled_when で参照するフィーチャーフラグ（file を指定した場合は定期的に読み直す）
+	featureFlags, err := featureflag.New(featureflag.Config{
+		Flags:           cfg.FeatureFlags.Flags,
+		File:            cfg.FeatureFlags.File,
+		RefreshInterval: cfg.FeatureFlags.RefreshInterval,
+		Logger:          log,
+	})
+	if err != nil {
+		log.Error("Failed to load feature flags", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	flagsCtx, stopFlags := context.WithCancel(context.Background())
+	defer stopFlags()
+	featureFlags.Start(flagsCtx)
+
+	// backend.service を指定したルートのサービスのインスタンスを登録先から取得し、変更を監視する
+	// service_discovery を設定した場合は、再読み込みで追加されたルートのサービスも監視できるよう起動時のルートで使っていなくても作成する
+	services := routeServices(routes)
+	var serviceCatalog *discovery.Catalog
+	discoveryCtx, stopDiscovery := context.WithCancel(context.Background())
+	defer stopDiscovery()
+	if source, err := discoverySource(cfg.ServiceDiscovery); err == nil {
+		serviceCatalog = discovery.New(discovery.Config{
+			Source:        source,
+			RetryInterval: cfg.ServiceDiscovery.RetryInterval,
+			Logger:        log,
+		})
+		serviceCatalog.Start(discoveryCtx, services...)
+		log.Info("Service discovery enabled", slog.String("provider", cfg.ServiceDiscovery.Provider), slog.Any("services", services))
+	} else if len(services) > 0 {
+		log.Error("Route requires service discovery", slog.Any("services", services), slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// 再読み込みしたルートも起動時と同じく転送できるか確認し、新しいサービスの監視を開始してから置き換える
+	reloader.setBackends(requestSigner != nil, func(names ...string) error {
+		if serviceCatalog == nil {
+			return fmt.Errorf("service_discovery is not configured")
+		}
+		serviceCatalog.Start(discoveryCtx, names...)
+		return nil
+	})
+
+	// バックエンドのアクティブヘルスチェック（有効な場合）
+	var healthChecker *healthcheck.Checker
+	if active := cfg.Health.ActiveChecks; active.Enabled {
+		healthChecker = healthcheck.New(healthcheck.Config{
+			Path:               active.Path,
+			Interval:           active.Interval,
+			Timeout:            active.Timeout,
+			UnhealthyThreshold: active.UnhealthyThreshold,
+			HealthyThreshold:   active.HealthyThreshold,
+			Logger:             log,
+		}, healthCheckTargets(routes))
+		healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+		defer stopHealthChecks()
+		healthChecker.Start(healthCtx)
+		log.Info("Backend health checks enabled", slog.Int("backends", len(healthChecker.Statuses())))
+	}
+
+	// バックエンドの外れ値検出（有効な場合）。転送したリクエストの結果をトランスポーターで記録する
+	var outlierDetector *healthcheck.OutlierDetector
+	if outlier := cfg.Health.OutlierDetection; outlier.Enabled {
+		outlierDetector = healthcheck.NewOutlierDetector(healthcheck.OutlierConfig{
+			Interval:          outlier.Interval,
+			MinRequests:       outlier.MinRequests,
+			MaxErrorPercent:   outlier.MaxErrorPercent,
+			MaxTimeoutPercent: outlier.MaxTimeoutPercent,
+			EjectionDuration:  outlier.EjectionDuration,
+			Logger:            log,
+		})
+		transporter.Outliers = outlierDetector
+		log.Info("Backend outlier detection enabled")
+	}
+
+	// ヘルスチェック（/healthz は生存確認のみ、/readyz は依存先も確認する）
+	baseChecks := []handler.HealthCheck{{
+		Name: "routing",
+		Check: func(ctx context.Context) error {
+			if len(router.GetAllRoutes()) == 0 {
+				return fmt.Errorf("no routes loaded")
+			}
+			return nil
+		},
+	}}
+	if redisClient != nil {
+		baseChecks = append(baseChecks, handler.HealthCheck{Name: "redis", Check: redisClient.Ping})
+	}
+	readinessChecks := func(routes []*routing.Route) []handler.HealthCheck {
+		checks := slices.Clone(baseChecks)
+		if cfg.Health.CheckBackends {
+			checks = append(checks, handler.BackendHealthChecks(routes)...)
+		}
+		return checks
+	}
+	livenessHandler := handler.NewLivenessHandler()
+	readinessHandler := handler.NewReadinessHandler(readinessChecks(routes), cfg.Health.Timeout, log)
+
+	// ルートを読み込み直した場合は、ルートのバックエンドから組み立てたヘルスチェックと /readyz の確認も置き換える
+	reloader.onReplace(func(routes []*routing.Route) {
+		healthChecker.SetBackends(healthCheckTargets(routes))
+		readinessHandler.SetChecks(readinessChecks(routes))
+	})
+
+	// 統合OpenAPIドキュメントは管理APIと開発者ポータルで共有する
+	openAPIHandler := handler.NewOpenAPIHandler(openapi.NewAggregator(routingCfg.OpenAPI, router, log), log)
+
+	// SIGHUP・管理APIで gateway.yaml を読み込み直す（ログレベル・ルート・JWT公開鍵のみを反映し、それ以外の変更は報告のみ）
+	// source: kubernetes の場合、ルートは監視で反映するためルーティング設定ファイルは読み込み直さない
+	configs := &configReloader{path: *configPath, keys: jwtKeys, log: log, current: cfg}
+	if routesSource == nil {
+		configs.routes = reloader
+	}
+	if signingKeys != nil {
+		configs.signingKeys = signingKeys.PublicKeys()
+	}
+
+	// 管理API（admin を提供するリスナーの /admin 配下に公開する）
+	var adminHandler http.Handler
+	if cfg.Admin.Enabled {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/admin/openapi", openAPIHandler)
+		adminMux.Handle("/admin/cache/purge", handler.NewCachePurgeHandler(cacheStore, log))
+		adminMux.Handle("/admin/log-level", handler.NewLogLevelHandler(log))
+		adminMux.Handle("/admin/metrics", handler.NewMetricsHandler(metrics.Default, log))
+		adminMux.Handle("/admin/routes", handler.NewRoutesHandler(router, log))
+		adminMux.Handle("/admin/routes/match", handler.NewRouteMatchHandler(router, log))
+		adminMux.Handle("/admin/routes/canary", handler.NewCanaryHandler(router, log))
+		adminMux.Handle("/admin/backends/health", handler.NewBackendHealthHandler(healthChecker, log))
+		adminMux.Handle("/admin/stats", handler.NewStatsHandler(router, reloadStatus, log))
+		adminMux.Handle("/admin/config/schema", handler.NewConfigSchemaHandler(log))
+		adminMux.Handle("/admin/config/reload", handler.NewConfigReloadHandler(configs.reload, log))
+		if signingKeys != nil {
+			adminMux.Handle("/admin/keys/rotate", handler.NewKeyRotationHandler(signingKeys, log))
+		}
+		adminKeys, err := adminAPIKeys(log)
+		if err != nil {
+			log.Error("Failed to load admin API keys", slog.String("error", err.Error()))
 			os.Exit(1)
 		}
+		adminKeysCtx, stopAdminKeys := context.WithCancel(context.Background())
+		defer stopAdminKeys()
+		adminKeys.Start(adminKeysCtx)
+		adminAuth := handler.AdminAuthConfig{APIKeys: adminKeys, Audit: auditLog}
+		if cfg.Admin.JWT.Enabled {
+			// 管理者のJWTはルートと同じ公開鍵・JWKSで検証する（失敗は RequireAdmin が監査ログに記録する）
+			adminAuth.JWT = auth.NewJWTMiddleware(auth.JWTConfig{KeySet: jwtKeys, JWKS: jwks})
+			adminAuth.Role = cfg.Admin.JWT.Role
+		}
+		if basic := cfg.Admin.BasicAuth; basic.Enabled {
+			adminAuth.Basic, err = auth.NewBasicAuthMiddleware(auth.BasicAuthConfig{Realm: basic.Realm, Users: basic.Users})
+			if err != nil {
+				log.Error("Failed to initialize admin basic auth", slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+		}
+		adminHandler = handler.RequireAdmin(adminAuth, adminMux)
+		log.Info("Admin API enabled",
+			slog.Bool("jwt", cfg.Admin.JWT.Enabled),
+			slog.Bool("basic_auth", cfg.Admin.BasicAuth.Enabled),
+			slog.Int("api_keys", adminKeys.Len()))
+	}
+
+	var portalHandler http.Handler
+	if cfg.Portal.Enabled {
+		portalHandler, err = portal.NewHandler(cfg.Portal.Title, openAPIHandler)
+		if err != nil {
+			log.Error("Failed to initialize developer portal", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		log.Info("Developer portal enabled", slog.String("path", portal.Prefix))
+	}
+
+	// 同時に転送するリクエスト数の上限は全てのリスナーで共有する
+	var concurrencyLimiter *routing.ConcurrencyLimiter
+	if limit := cfg.Server.MaxConcurrentRequests; limit > 0 {
+		concurrencyLimiter = routing.NewConcurrencyLimiter(limit, routing.ConcurrencyQueue{})
+		log.Info("Concurrency limit enabled", slog.Int("max_concurrent_requests", limit))
+	}
+
+	// HTTPサーバの設定（リスナーごとに公開するルートと機能を分ける）
+	// シャットダウン時は全てのリスナーの処理中のリクエストを数えて完了を待ち、期限を過ぎたリクエストはベースコンテキストのキャンセルで中断する
+	drainer := handler.NewDrainer(nil)
+	requestCtx, abortRequests := context.WithCancel(context.Background())
+	defer abortRequests()
+
+	var servers []*http.Server
+	for _, listener := range cfg.Server.ListenerConfigs() {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", livenessHandler)
+		mux.Handle("/readyz", readinessHandler)
+
+		if listener.Serves(config.ServeGateway) {
+			// 読み込み直した場合に名前の参照を展開し直せるよう、reloader には設定のまま渡す
+			listenerMiddleware, err := routingCfg.ResolveMiddleware(listener.Middleware)
+			if err != nil {
+				log.Error("Failed to resolve listener middleware", slog.String("listener", listener.Name), slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+			listenerRouter, err := router.Subset(listener.Groups, listenerMiddleware)
+			if err != nil {
+				log.Error("Failed to build listener routes", slog.String("listener", listener.Name), slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+			reloader.addListener(listenerRouter, listener.Groups, listener.Middleware)
+			gateway := handler.NewGateway(listenerRouter, transporter, middlewareFactory, gatewayLog)
+			gateway.SetRequestSigner(requestSigner)
+			gateway.SetHealthChecker(healthChecker)
+			gateway.SetOutlierDetector(outlierDetector)
+			gateway.SetTrustedProxies(trustedProxies)
+			gateway.SetGRPCTransporter(grpcTransporter)
+			gateway.SetJournal(requestJournal)
+			gateway.SetFeatureFlags(featureFlags)
+			gateway.SetConcurrencyLimiter(concurrencyLimiter)
+			gateway.SetServiceDiscovery(serviceCatalog)
+			gateway.SetSlowRequestThreshold(cfg.Logging.SlowRequestThreshold)
+			mux.Handle("/", gateway)
+
+			if signingKeys != nil {
+				mux.Handle(handler.JWKSPath, handler.NewJWKSHandler(signingKeys, log))
+			}
+			if portalHandler != nil {
+				mux.Handle(portal.Prefix, portalHandler)
+				mux.Handle("/docs", http.RedirectHandler(portal.Prefix, http.StatusMovedPermanently))
+			}
+		}
+		if adminHandler != nil && listener.Serves(config.ServeAdmin) {
+			mux.Handle("/admin/", adminHandler)
+		}
+
+		server := &http.Server{
+			Addr:         listener.Address(),
+			Handler:      drainer.Wrap(mux),
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			BaseContext:  func(net.Listener) context.Context { return requestCtx },
+		}
+		// gRPCのクライアントが平文のHTTP/2（prior knowledge）で接続できるようにする
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+		servers = append(servers, server)
+
+		// サーバの起動
+		go func() {
+			log.Info("Server starting",
+				slog.String("listener", listener.Name),
+				slog.String("address", server.Addr),
+				slog.Any("serve", listener.Serve))
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("Server failed", slog.String("listener", listener.Name), slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if routesSource != nil {
+		routesCtx, stopRoutes := context.WithCancel(context.Background())
+		defer stopRoutes()
+		routesSource.Watch(routesCtx, reloader.reload)
+		log.Info("Watching kubernetes routes", slog.String("namespace", cmp.Or(cfg.Routing.Kubernetes.Namespace, "*")))
+	}
+
+	// SIGHUP で設定を読み込み直す（失敗した場合は以前の設定のまま動作する）
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			configs.reload()
+		}
+	}()
+
+	// SIGUSR1 でdebugログを切り替え、もう一度受け取ると設定ファイルのレベルに戻す（障害調査時に再起動せず有効化するため）
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			level, err := logger.ToggleDebug(configs.logLevel())
+			if err != nil {
+				log.Error("Failed to change log level", slog.String("error", err.Error()))
+				continue
+			}
+			// 変更後のレベルに関わらず記録されるようWarnで出力する
+			log.Warn("Log level changed by signal", slog.String("level", string(level)))
+		}
 	}()
 
 	// グレースフルシャットダウンの設定
@@ -138,15 +702,149 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Info("Shutting down server...")
+	log.Info("Shutting down server...", slog.Int("in_flight_requests", drainer.InFlight()))
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Error("Server forced to shutdown", slog.String("error", err.Error()))
+	// 新しいリクエストを断り、リスナーを閉じてから処理中のリクエストの完了を待つ
+	// server.Shutdown はUpgrade後の接続（WebSocketなど）を待たないため、Drainerでも待つ
+	drainer.BeginDrain()
+	shutdownErrs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shutdownErrs[i] = server.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	aborted := drainer.Wait(ctx)
+	if shutdownErr := errors.Join(shutdownErrs...); aborted > 0 || shutdownErr != nil {
+		abortRequests()
+		for _, server := range servers {
+			server.Close()
+		}
+		log.Error("Server forced to shutdown",
+			slog.Int("aborted_requests", aborted),
+			slog.Duration("shutdown_timeout", cfg.Server.ShutdownTimeout))
 		os.Exit(1)
 	}
 
 	log.Info("Server exited")
 }
+
+// adminAPIKeys は管理APIのAPIキーを環境変数 ADMIN_API_KEY（カンマ区切りで複数指定可）と
+// ADMIN_API_KEY_FILE（定期的に読み直す）から読み込む
+func adminAPIKeys(log *slog.Logger) (*handler.AdminKeys, error) {
+	keysConfig := handler.AdminKeysConfigFromEnv(os.Getenv)
+	if len(keysConfig.Keys) == 0 && keysConfig.File == "" {
+		// デフォルト値（開発環境用）
+		keysConfig.Keys = []string{"dev-admin-api-key-change-me-in-production"}
+		log.Warn("ADMIN_API_KEY environment variable not set, using default key (INSECURE for production)")
+	}
+	keysConfig.Logger = log
+	return handler.NewAdminKeys(keysConfig)
+}
+
+// openJournal は設定に応じた出力先でリクエストのジャーナルを開く
+func openJournal(cfg config.JournalConfig, log *slog.Logger) (*journal.Journal, error) {
+	var sink journal.Sink
+	switch cfg.Sink {
+	case "s3":
+		bucketURL, err := url.Parse(cfg.S3.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid journal s3 url: %w", err)
+		}
+		signer, err := routing.NewAWSSigner(config.AWSSigV4Config{
+			Service:     "s3",
+			Region:      cfg.S3.Region,
+			Credentials: cfg.S3.Credentials,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid journal s3 signer: %w", err)
+		}
+		sink = journal.NewObjectSink(journal.ObjectSinkConfig{
+			URL:           bucketURL,
+			Prefix:        cfg.S3.Prefix,
+			Signer:        signer,
+			LegalHold:     cfg.S3.LegalHold,
+			FlushInterval: cfg.S3.FlushInterval,
+			MaxEntries:    cfg.S3.MaxEntries,
+			Logger:        log,
+		})
+	default:
+		fileSink, err := journal.OpenFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		sink = fileSink
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	j, err := journal.Open(ctx, sink)
+	if err != nil {
+		sink.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// discoverySource は設定に応じたサービスディスカバリの登録先を返す（設定が無い場合はエラー）
+func discoverySource(cfg config.ServiceDiscoveryConfig) (discovery.Source, error) {
+	switch cfg.Provider {
+	case config.DiscoveryConsul:
+		return discovery.NewConsulSource(discovery.ConsulConfig{
+			Address:    cfg.Consul.Address,
+			Token:      cfg.Consul.Token,
+			Datacenter: cfg.Consul.Datacenter,
+			WaitTime:   cfg.Consul.WaitTime,
+		}), nil
+	case config.DiscoveryEtcd:
+		return discovery.NewEtcdSource(discovery.EtcdConfig{
+			Endpoints:    cfg.Etcd.Endpoints,
+			Prefix:       cfg.Etcd.Prefix,
+			PollInterval: cfg.Etcd.PollInterval,
+		}), nil
+	default:
+		return nil, fmt.Errorf("service_discovery is not configured")
+	}
+}
+
+// healthCheckTargets はアクティブヘルスチェックで確認するルートのバックエンドを返す
+func healthCheckTargets(routes []*routing.Route) []*url.URL {
+	backendURLs := make([]*url.URL, 0, len(routes))
+	for _, route := range routes {
+		// サービスディスカバリのルートの url のホストはインスタンスのアドレスで置き換えるため確認しない
+		// 固定のレスポンスを返すルートと複数のバックエンドをまとめるルートには url が無い
+		if route.Backend.Service == "" && route.Backend.URL.Host != "" {
+			backendURLs = append(backendURLs, route.Backend.URL)
+		}
+	}
+	return backendURLs
+}
+
+// kubernetesSource は設定に応じたKubernetesのリソースの監視を作成する（変更は reloader で反映する）
+func kubernetesSource(cfg config.KubernetesRoutingConfig, base *config.RoutingFileConfig, log *slog.Logger) (*kubernetes.Source, error) {
+	client, err := kubernetes.NewClient(kubernetes.ClientConfig{
+		APIServer: cfg.APIServer,
+		TokenFile: cfg.TokenFile,
+		CAFile:    cfg.CAFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewSource(kubernetes.SourceConfig{
+		Client:        client,
+		Namespace:     cfg.Namespace,
+		Resources:     cfg.Resources,
+		GatewayName:   cfg.GatewayName,
+		IngressClass:  cfg.IngressClass,
+		ClusterDomain: cfg.ClusterDomain,
+		Timeout:       cfg.Timeout,
+		Base:          base,
+		Logger:        log,
+	}), nil
+}
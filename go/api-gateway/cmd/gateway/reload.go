@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/handler"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/routing"
+	"api-gateway/pkg/logger"
+)
+
+// routeReloader は実行中にルーティング設定を読み込み直し、全体のルーターとリスナーごとのルーターのルートを置き換える
+type routeReloader struct {
+	router *routing.Router
+	status *handler.ReloadStatus
+	log    *slog.Logger
+
+	mu sync.Mutex
+	// current は最後に読み込んだルーティング設定（差分の記録に使う）
+	current   *config.RoutingFileConfig
+	listeners []listenerRoutes
+	// canSign は jwt.signing が設定され、sign_requests のルートを転送できるか
+	canSign bool
+	// watchServices は backend.service のサービスの監視を開始する（サービスディスカバリが無い場合はエラー）
+	watchServices func(names ...string) error
+	// replaced はルートを置き換えた後に、ルートから組み立てた確認（ヘルスチェックなど）を更新する
+	replaced func(routes []*routing.Route)
+}
+
+// listenerRoutes はリスナーごとのルーターと、全体のルーターから公開するルートを選ぶ条件
+type listenerRoutes struct {
+	router *routing.Router
+	groups []string
+	// middleware はリスナーの設定のデフォルトのミドルウェア
+	// middlewares の名前の参照は、定義の変更を反映するため読み込み直したルーティング設定で展開する
+	middleware []config.MiddlewareConfig
+}
+
+// newRouteReloader は current を読み込んだ router のルートを置き換えるrouteReloaderを作成する
+func newRouteReloader(router *routing.Router, current *config.RoutingFileConfig, status *handler.ReloadStatus, log *slog.Logger) *routeReloader {
+	return &routeReloader{router: router, current: current, status: status, log: log}
+}
+
+// addListener はルートを置き換えるリスナーのルーターを登録する
+func (r *routeReloader) addListener(router *routing.Router, groups []string, middleware []config.MiddlewareConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listenerRoutes{router: router, groups: groups, middleware: middleware})
+}
+
+// onReplace はルートを置き換えた後に呼ぶ関数を登録する
+func (r *routeReloader) onReplace(replaced func(routes []*routing.Route)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replaced = replaced
+}
+
+// setBackends は置き換える前に確認する、転送先の設定を登録する
+func (r *routeReloader) setBackends(canSign bool, watchServices func(names ...string) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.canSign = canSign
+	r.watchServices = watchServices
+}
+
+// reload は next のルートに置き換え、結果を記録する
+// ルートが不正な場合は以前のルートのまま動作する
+func (r *routeReloader) reload(next *config.RoutingFileConfig) {
+	r.apply(next)
+}
+
+// apply は next のルートに置き換えて結果を記録し、以前の設定からの差分を返す
+func (r *routeReloader) apply(next *config.RoutingFileConfig) (config.RoutingDiff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.replace(next); err != nil {
+		r.log.Error("Failed to reload routes", slog.String("error", err.Error()))
+		r.status.Record(handler.ReloadResult{Time: time.Now(), Success: false, Error: err.Error(), Routes: len(r.router.GetAllRoutes())})
+		return config.RoutingDiff{}, err
+	}
+
+	diff := config.DiffRoutingConfig(r.current, next)
+	r.current = next
+	routes := len(r.router.GetAllRoutes())
+	r.log.Info("Routes reloaded", slog.Int("count", routes), slog.Any("diff", diff))
+	r.status.Record(handler.ReloadResult{Time: time.Now(), Success: true, Routes: routes, Diff: diff})
+	return diff, nil
+}
+
+// replace は全体のルーターとリスナーごとのルーターのルートを next のルートに置き換える
+func (r *routeReloader) replace(next *config.RoutingFileConfig) error {
+	loaded := routing.NewRouter()
+	if err := loaded.LoadFromConfig(next); err != nil {
+		return err
+	}
+	if err := r.checkBackends(loaded.GetAllRoutes()); err != nil {
+		return err
+	}
+
+	// リスナーのルートを先に組み立て、全て組み立てられた場合のみ置き換える
+	subsets := make([]*routing.Router, len(r.listeners))
+	for i, listener := range r.listeners {
+		middleware, err := next.ResolveMiddleware(listener.middleware)
+		if err != nil {
+			return fmt.Errorf("failed to resolve listener middleware: %w", err)
+		}
+		subset, err := loaded.Subset(listener.groups, middleware)
+		if err != nil {
+			return err
+		}
+		subsets[i] = subset
+	}
+
+	if err := r.router.ReplaceAll(loaded.GetAllRoutes()); err != nil {
+		return err
+	}
+	for i, listener := range r.listeners {
+		if err := listener.router.ReplaceAll(subsets[i].GetAllRoutes()); err != nil {
+			return err
+		}
+	}
+	if r.replaced != nil {
+		r.replaced(loaded.GetAllRoutes())
+	}
+	return nil
+}
+
+// checkBackends は起動時に確認したものと同じく、実行中のプロセスでルートを転送できるか確認する
+// 新しい backend.service のサービスは監視を開始し、最初の取得を待つ（監視できない場合はエラー）
+func (r *routeReloader) checkBackends(routes []*routing.Route) error {
+	for _, route := range routes {
+		if route.Backend.SignRequests && !r.canSign {
+			return fmt.Errorf("route %s requires request signing but jwt.signing is not configured", route.Path)
+		}
+	}
+	if services := routeServices(routes); len(services) > 0 && r.watchServices != nil {
+		if err := r.watchServices(services...); err != nil {
+			return fmt.Errorf("routes require service discovery %v: %w", services, err)
+		}
+	}
+	return nil
+}
+
+// routeServices はルートの backend.service のサービスを重複を除いて返す
+func routeServices(routes []*routing.Route) []string {
+	var services []string
+	for _, route := range routes {
+		if route.Backend.Service != "" && !slices.Contains(services, route.Backend.Service) {
+			services = append(services, route.Backend.Service)
+		}
+	}
+	return services
+}
+
+// configReloader は gateway.yaml を読み込み直し、再起動せずに反映できる設定（ログレベル・ルート・JWT公開鍵）のみを反映する
+// それ以外のフィールドの変更は反映せず、再起動が必要な変更として報告する
+type configReloader struct {
+	path string
+	// routes はルーティング設定ファイルのルートを置き換える（source: kubernetes の場合は nil。ルートは監視で反映する）
+	routes *routeReloader
+	keys   *auth.PublicKeySet
+	// signingKeys は署名鍵の公開鍵（読み込み直さず、読み込んだ公開鍵に常に加える）
+	signingKeys map[string]*rsa.PublicKey
+	log         *slog.Logger
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+// reload は gateway.yaml とルーティング設定ファイル、JWT公開鍵のファイルを読み込み直して反映し、結果を返す
+// 反映する前に全て読み込み、1つでも失敗した場合は何も反映せずに以前の設定のまま動作する
+func (c *configReloader) reload() (handler.ConfigReloadResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next, err := config.LoadConfig(c.path)
+	if err != nil {
+		return c.fail(fmt.Errorf("failed to load config: %w", err))
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	if len(next.JWT.PublicKeyFiles) > 0 {
+		keys, err = auth.LoadPublicKeysFromFiles(next.JWT.PublicKeyFiles)
+		if err != nil {
+			return c.fail(fmt.Errorf("failed to load JWT public keys: %w", err))
+		}
+	}
+	maps.Copy(keys, c.signingKeys)
+
+	var routingCfg *config.RoutingFileConfig
+	if c.routes != nil && next.Routing.ConfigFile != "" {
+		routingCfg, err = config.LoadRoutingConfig(next.Routing.ConfigFile)
+		if err != nil {
+			return c.fail(fmt.Errorf("failed to load routing config: %w", err))
+		}
+	}
+
+	result := handler.ConfigReloadResult{Time: time.Now(), Config: config.DiffConfig(c.current, next)}
+	// ルートの組み立てに失敗する可能性があるため、ルートを最初に置き換える
+	if routingCfg != nil {
+		routesDiff, err := c.routes.apply(routingCfg)
+		if err != nil {
+			return c.fail(fmt.Errorf("failed to reload routes: %w", err))
+		}
+		result.Routes = &routesDiff
+	}
+	result.Keys = c.keys.Replace(keys)
+	// 管理APIで変更したログレベルは、設定ファイルのレベルが変わった場合のみ上書きする
+	if next.Logging.Level != c.current.Logging.Level {
+		if err := logger.SetLevel(logger.LogLevel(next.Logging.Level)); err != nil {
+			c.log.Error("Failed to change log level", slog.String("error", err.Error()))
+		}
+	}
+	result.LogLevel = logger.GetLevel()
+	c.current = next
+
+	// 変更後のレベルに関わらず記録されるようWarnで出力する
+	c.log.Warn("Config reloaded",
+		slog.Any("config", result.Config),
+		slog.String("log_level", string(result.LogLevel)),
+		slog.Any("keys", result.Keys))
+	if len(result.Config.RestartRequired) > 0 {
+		c.log.Warn("Config changes require restart", slog.Any("fields", result.Config.RestartRequired))
+	}
+	return result, nil
+}
+
+// logLevel は最後に読み込んだ設定ファイルのログレベルを返す
+func (c *configReloader) logLevel() logger.LogLevel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return logger.LogLevel(c.current.Logging.Level)
+}
+
+// fail は再読み込みの失敗を記録してエラーを返す
+func (c *configReloader) fail(err error) (handler.ConfigReloadResult, error) {
+	c.log.Error("Failed to reload config", slog.String("path", c.path), slog.String("error", err.Error()))
+	return handler.ConfigReloadResult{}, err
+}
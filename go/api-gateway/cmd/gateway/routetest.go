@@ -0,0 +1,91 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rsa"
+	"flag"
+	"fmt"
+	"io"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/routetest"
+	"api-gateway/internal/routing"
+)
+
+// runRouteTest は `gateway test -routes routing.yaml -cases cases.yaml` を実行し、終了コードを返す
+// 実際のバックエンドへは転送せず、ルーティングとミドルウェアの結果をケースの期待値と比較する
+func runRouteTest(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	routesPath := fs.String("routes", "configs/routing.yaml", "path to routing config file")
+	casesPath := fs.String("cases", "", "path to test cases file")
+	configPath := fs.String("config", "", "path to gateway config file (optional; used for trailing_slash and JWT public keys)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *casesPath == "" {
+		fmt.Fprintln(stderr, "-cases is required")
+		fs.Usage()
+		return 2
+	}
+
+	router := routing.NewRouter()
+	var jwtPublicKeys map[string]*rsa.PublicKey
+	if *configPath != "" {
+		cfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+			return 1
+		}
+		router.SetTrailingSlashPolicy(routing.TrailingSlashPolicy(cfg.Routing.TrailingSlash))
+		if len(cfg.JWT.PublicKeyFiles) > 0 {
+			jwtPublicKeys, err = auth.LoadPublicKeysFromFiles(cfg.JWT.PublicKeyFiles)
+			if err != nil {
+				fmt.Fprintf(stderr, "Failed to load JWT public keys: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	routingCfg, err := config.LoadRoutingConfig(*routesPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load routing config: %v\n", err)
+		return 1
+	}
+	if err := router.LoadFromConfig(routingCfg); err != nil {
+		fmt.Fprintf(stderr, "Failed to load routes: %v\n", err)
+		return 1
+	}
+
+	cases, err := routetest.LoadFile(*casesPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load test cases: %v\n", err)
+		return 1
+	}
+
+	results := routetest.NewRunner(router, jwtPublicKeys).Run(context.Background(), cases.Cases)
+	failed := 0
+	for _, result := range results {
+		name := result.Case.Name
+		if name == "" {
+			name = fmt.Sprintf("%s %s", cmp.Or(result.Case.Request.Method, "GET"), result.Case.Request.Path)
+		}
+		if result.Passed() {
+			fmt.Fprintf(stdout, "PASS  %s\n", name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(stdout, "FAIL  %s\n", name)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(stdout, "      %s\n", failure)
+		}
+	}
+
+	fmt.Fprintf(stdout, "\n%d passed, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
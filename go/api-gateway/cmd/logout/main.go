@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/config"
 	"api-gateway/internal/handler"
 	"api-gateway/internal/repository"
@@ -39,6 +40,17 @@ func main() {
 		"version", "1.0.0",
 		"config", *configPath)
 
+	// 監査ログの初期化（有効な場合）
+	var auditLog *audit.Logger
+	if cfg.Audit.Enabled {
+		auditLog, err = audit.Open(cfg.Audit.Output)
+		if err != nil {
+			log.Error("failed to open audit log", "error", err)
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+	}
+
 	// Redis設定の確認
 	if cfg.Redis.Host == "" {
 		log.Error("redis host is not configured")
@@ -54,6 +66,7 @@ func main() {
 		DialTimeout:  cfg.Redis.DialTimeout,
 		ReadTimeout:  cfg.Redis.ReadTimeout,
 		WriteTimeout: cfg.Redis.WriteTimeout,
+		Keyspace:     redisclient.Keyspace{Namespace: cfg.Redis.Keyspace.Namespace, Version: cfg.Redis.Keyspace.Version},
 	})
 	if err != nil {
 		log.Error("failed to connect to redis", "error", err)
@@ -72,6 +85,7 @@ func main() {
 		UserIDClaim:   "sub", // 設定可能にする場合は cfg に追加
 		JWTExpiration: 10 * time.Hour,
 		Logger:        log,
+		Audit:         auditLog,
 	})
 
 	// HTTPサーバーの設定
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"api-gateway/pkg/logger"
+)
+
+// EventType は監査イベントの種類
+type EventType string
+
+const (
+	// EventAuthFailure は認証の失敗（JWTの検証失敗、管理APIキーの不一致など）
+	EventAuthFailure EventType = "auth_failure"
+	// EventAuthzDenied は認証済みトークンの拒否（失効済みトークンなど）
+	EventAuthzDenied EventType = "authz_denied"
+	// EventTokenRevoked はユーザー自身のログアウトによるトークン失効
+	EventTokenRevoked EventType = "token_revoked"
+	// EventAdminRevoke は管理者による強制失効の呼び出し
+	EventAdminRevoke EventType = "admin_revoke"
+	// EventAdminRestore は管理者による失効の取り消し
+	EventAdminRestore EventType = "admin_restore"
+	// EventAdminAccess はJWTで認証した管理者による管理APIへのアクセス
+	EventAdminAccess EventType = "admin_access"
+)
+
+// Outcome は監査イベントの結果
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event は1件の監査イベント
+type Event struct {
+	Type    EventType
+	Outcome Outcome
+	// UserID は対象のユーザーID（特定できない場合は空）
+	UserID string
+	// Actor は操作した管理者（JWTで認証した場合の sub。APIキーの場合は空）
+	Actor string
+	// Reason は失敗・拒否の理由
+	Reason string
+}
+
+// Logger は監査イベントを通常のログとは別のストリームへJSON Linesで出力する
+// SIEMへ取り込めるよう、ログレベルの設定に関わらず常に出力する
+// nil の Logger は何も出力しない（監査ログが無効の場合）
+type Logger struct {
+	logger *slog.Logger
+	closer io.Closer
+}
+
+// New はwへ出力する監査ロガーを作成する
+func New(w io.Writer) *Logger {
+	// リクエストIDなどの相関IDと http グループはコンテキストから付与する
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})
+	return &Logger{
+		logger: slog.New(logger.NewCorrelationHandler(logger.NewHandler(handler, logger.HandlerOptions{}))),
+	}
+}
+
+// Open は出力先（stdout, stderr またはファイルパス）を開いて監査ロガーを作成する
+// ファイルは追記モードで開き、Close で閉じる
+func Open(output string) (*Logger, error) {
+	switch output {
+	case "", "stdout":
+		return New(os.Stdout), nil
+	case "stderr":
+		return New(os.Stderr), nil
+	}
+
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	l := New(f)
+	l.closer = f
+	return l, nil
+}
+
+// Close は出力先のファイルを閉じる
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// Log は監査イベントを出力する
+func (l *Logger) Log(ctx context.Context, e Event) {
+	if l == nil {
+		return
+	}
+
+	level := slog.LevelInfo
+	if e.Outcome == OutcomeFailure {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("event", string(e.Type)),
+		slog.String("outcome", string(e.Outcome)),
+	}
+	if e.UserID != "" {
+		attrs = append(attrs, slog.String("user_id", e.UserID))
+	}
+	if e.Actor != "" {
+		attrs = append(attrs, slog.String("actor", e.Actor))
+	}
+	if route, ok := RouteFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("route", route))
+	}
+	if e.Reason != "" {
+		attrs = append(attrs, slog.String("reason", e.Reason))
+	}
+
+	l.logger.LogAttrs(ctx, level, "audit", attrs...)
+}
+
+type routeKey struct{}
+
+// WithRoute はマッチしたルートのパスをコンテキストに保存する
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFromContext はコンテキストからルートのパスを取得する
+func RouteFromContext(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeKey{}).(string)
+	return route, ok
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"api-gateway/pkg/logger"
+)
+
+func TestLogger_Log(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/revoke", nil)
+	ctx := logger.WithCorrelation(context.Background(), logger.Correlation{RequestID: "req-1"})
+	ctx = logger.WithHTTPRequest(ctx, req)
+	ctx = WithRoute(ctx, "/api/v1/users/{id}")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		event     Event
+		wantLevel string
+		want      map[string]any
+		wantNone  []string
+	}{
+		{
+			name: "failure with route and correlation",
+			ctx:  ctx,
+			event: Event{
+				Type:    EventAuthFailure,
+				Outcome: OutcomeFailure,
+				UserID:  "user123",
+				Reason:  "token is not valid",
+			},
+			wantLevel: "WARN",
+			want: map[string]any{
+				"msg":        "audit",
+				"event":      "auth_failure",
+				"outcome":    "failure",
+				"user_id":    "user123",
+				"route":      "/api/v1/users/{id}",
+				"reason":     "token is not valid",
+				"request_id": "req-1",
+			},
+		},
+		{
+			name: "success omits empty fields",
+			ctx:  context.Background(),
+			event: Event{
+				Type:    EventAdminRevoke,
+				Outcome: OutcomeSuccess,
+				UserID:  "user123",
+			},
+			wantLevel: "INFO",
+			want: map[string]any{
+				"event":   "admin_revoke",
+				"outcome": "success",
+				"user_id": "user123",
+			},
+			wantNone: []string{"route", "reason", "request_id", "actor", logger.HTTPGroup},
+		},
+		{
+			name: "admin action with actor",
+			ctx:  context.Background(),
+			event: Event{
+				Type:    EventAdminRevoke,
+				Outcome: OutcomeSuccess,
+				UserID:  "user123",
+				Actor:   "admin@example.com",
+			},
+			wantLevel: "INFO",
+			want: map[string]any{
+				"event":   "admin_revoke",
+				"user_id": "user123",
+				"actor":   "admin@example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			New(&buf).Log(tt.ctx, tt.event)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse audit output: %v", err)
+			}
+			if entry["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %v", entry["level"], tt.wantLevel)
+			}
+			for key, want := range tt.want {
+				if entry[key] != want {
+					t.Errorf("%s = %v, want %v", key, entry[key], want)
+				}
+			}
+			for _, key := range tt.wantNone {
+				if _, ok := entry[key]; ok {
+					t.Errorf("%s = %v, want none", key, entry[key])
+				}
+			}
+		})
+	}
+}
+
+func TestLogger_Nil(t *testing.T) {
+	var l *Logger
+
+	// 監査ログが無効（nil）の場合は何もしない
+	l.Log(context.Background(), Event{Type: EventAuthFailure, Outcome: OutcomeFailure})
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestOpen_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for range 2 {
+		l, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		l.Log(context.Background(), Event{Type: EventTokenRevoked, Outcome: OutcomeSuccess, UserID: "user123"})
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	// 再オープンしても追記される
+	if n := bytes.Count(data, []byte("\n")); n != 2 {
+		t.Errorf("got %d lines, want 2: %s", n, data)
+	}
+}
+
+func TestOpen_InvalidPath(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing", "audit.log")); err == nil {
+		t.Error("Open() error = nil, want error")
+	}
+}
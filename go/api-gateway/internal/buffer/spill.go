@@ -0,0 +1,125 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultMemoryLimit はメモリに保持する上限バイト数のデフォルト値
+const DefaultMemoryLimit = 1 << 20 // 1MiB
+
+// Config はSpillBufferの設定
+type Config struct {
+	// MemoryLimit はメモリに保持する上限バイト数（0はDefaultMemoryLimit）
+	MemoryLimit int64
+	// TempDir は上限を超えた分を書き出す一時ファイルのディレクトリ（空はOSのデフォルト）
+	TempDir string
+}
+
+// SpillBuffer はレスポンスボディ全体を必要とする処理のためのバッファ
+// MemoryLimit までメモリに保持し、超えた時点で内容を一時ファイルへ移して以降はファイルに書き込む
+// 使い終わったら Close で一時ファイルを削除する
+type SpillBuffer struct {
+	config Config
+	mem    bytes.Buffer
+	file   *os.File
+	size   int64
+}
+
+// New は新しいSpillBufferを作成する
+func New(config Config) *SpillBuffer {
+	if config.MemoryLimit <= 0 {
+		config.MemoryLimit = DefaultMemoryLimit
+	}
+	return &SpillBuffer{config: config}
+}
+
+// Write はデータを書き込み、メモリの上限を超える場合は一時ファイルへ切り替える
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && int64(b.mem.Len()+len(p)) > b.config.MemoryLimit {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("failed to write buffer: %w", err)
+	}
+	return n, nil
+}
+
+// spill はメモリ上の内容を一時ファイルへ移す
+func (b *SpillBuffer) spill() error {
+	f, err := os.CreateTemp(b.config.TempDir, "gateway-body-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := f.Write(b.mem.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	b.file = f
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// Len は書き込まれたバイト数を返す
+func (b *SpillBuffer) Len() int64 {
+	return b.size
+}
+
+// Spilled は一時ファイルへ書き出したか確認する
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Reader は書き込まれた内容を先頭から読み込むReaderを返す
+// 返したReaderを Close するとバッファも Close される
+func (b *SpillBuffer) Reader() (io.ReadCloser, error) {
+	if b.file == nil {
+		return &reader{Reader: bytes.NewReader(b.mem.Bytes()), buf: b}, nil
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek temp file: %w", err)
+	}
+	return &reader{Reader: b.file, buf: b}, nil
+}
+
+// Close は一時ファイルを削除し、メモリ上の内容を破棄する
+func (b *SpillBuffer) Close() error {
+	b.mem = bytes.Buffer{}
+	if b.file == nil {
+		return nil
+	}
+	f := b.file
+	b.file = nil
+	closeErr := f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return fmt.Errorf("failed to remove temp file: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close temp file: %w", closeErr)
+	}
+	return nil
+}
+
+// reader はSpillBufferの内容を読み込み、Close時にバッファを解放する
+type reader struct {
+	io.Reader
+	buf *SpillBuffer
+}
+
+func (r *reader) Close() error {
+	return r.buf.Close()
+}
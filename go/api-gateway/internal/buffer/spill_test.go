@@ -0,0 +1,88 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpillBuffer(t *testing.T) {
+	tests := []struct {
+		name        string
+		memoryLimit int64
+		writes      []string
+		wantSpilled bool
+	}{
+		{name: "within memory limit", memoryLimit: 10, writes: []string{"hello", "world"}},
+		{name: "spills when exceeding limit", memoryLimit: 8, writes: []string{"hello", "world"}, wantSpilled: true},
+		{name: "single large write", memoryLimit: 4, writes: []string{"hello world"}, wantSpilled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			b := New(Config{MemoryLimit: tt.memoryLimit, TempDir: dir})
+
+			var want bytes.Buffer
+			for _, w := range tt.writes {
+				if _, err := b.Write([]byte(w)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				want.WriteString(w)
+			}
+
+			if b.Spilled() != tt.wantSpilled {
+				t.Errorf("Spilled() = %v, want %v", b.Spilled(), tt.wantSpilled)
+			}
+			if b.Len() != int64(want.Len()) {
+				t.Errorf("Len() = %d, want %d", b.Len(), want.Len())
+			}
+
+			r, err := b.Reader()
+			if err != nil {
+				t.Fatalf("Reader() error = %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != want.String() {
+				t.Errorf("content = %q, want %q", got, want.String())
+			}
+
+			// Close で一時ファイルが削除されること
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			files, _ := filepath.Glob(filepath.Join(dir, "*"))
+			if len(files) != 0 {
+				t.Errorf("temp files remain after Close: %v", files)
+			}
+		})
+	}
+}
+
+func TestSpillBuffer_DefaultMemoryLimit(t *testing.T) {
+	b := New(Config{TempDir: t.TempDir()})
+	defer b.Close()
+
+	if _, err := b.Write(make([]byte, DefaultMemoryLimit)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if b.Spilled() {
+		t.Error("should not spill at DefaultMemoryLimit")
+	}
+}
+
+func TestSpillBuffer_TempDirError(t *testing.T) {
+	b := New(Config{MemoryLimit: 1, TempDir: filepath.Join(t.TempDir(), "missing")})
+
+	if _, err := b.Write([]byte("hello")); err == nil {
+		t.Error("expected error when temp dir does not exist")
+	}
+	if _, err := os.Stat(b.config.TempDir); !os.IsNotExist(err) {
+		t.Errorf("temp dir should not be created: %v", err)
+	}
+}
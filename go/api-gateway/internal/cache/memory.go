@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries はMemoryStoreの既定の最大エントリ数
+const DefaultMaxEntries = 1000
+
+// MemoryStore はプロセス内のLRUキャッシュ
+// 最大エントリ数を超えると最も長く参照されていないエントリから破棄する
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List // 先頭が最近参照されたエントリ
+	now        func() time.Time
+}
+
+type memoryItem struct {
+	key       string
+	entry     *Entry
+	expiresAt time.Time
+}
+
+// NewMemoryStore は新しいMemoryStoreを作成する
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get はキーに対応するエントリを取得する
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	item := elem.Value.(*memoryItem)
+	if !s.now().Before(item.expiresAt) {
+		s.remove(elem)
+		return nil, ErrNotFound
+	}
+
+	s.order.MoveToFront(elem)
+	return item.entry, nil
+}
+
+// Set はエントリをTTL付きで保存する
+func (s *MemoryStore) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := &memoryItem{key: key, entry: entry, expiresAt: s.now().Add(ttl)}
+	if elem, ok := s.items[key]; ok {
+		elem.Value = item
+		s.order.MoveToFront(elem)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(item)
+	for s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Purge は指定したプレフィックスで始まるキーのエントリを削除する
+func (s *MemoryStore) Purge(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for key, elem := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(elem)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Len は保持しているエントリ数を返す
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// remove はエントリを削除する（呼び出し側でロックを取得していること）
+func (s *MemoryStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.items, elem.Value.(*memoryItem).key)
+}
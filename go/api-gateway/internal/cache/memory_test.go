@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_GetSet(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+
+	if _, err := store.Get(ctx, "/a?x"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on empty store error = %v, want ErrNotFound", err)
+	}
+
+	entry := &Entry{StatusCode: 200, Body: []byte("hello")}
+	if err := store.Set(ctx, "/a?x", entry, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, err := store.Get(ctx, "/a?x")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got.Body) != "hello" {
+		t.Errorf("Body = %s, want hello", got.Body)
+	}
+
+	// TTLが0以下の場合は保存しない
+	if err := store.Set(ctx, "/b?x", entry, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", store.Len())
+	}
+}
+
+func TestMemoryStore_Expiration(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	if err := store.Set(ctx, "/a?x", &Entry{StatusCode: 200}, 30*time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	now = now.Add(29 * time.Second)
+	if _, err := store.Get(ctx, "/a?x"); err != nil {
+		t.Errorf("Get() before expiration error = %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := store.Get(ctx, "/a?x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after expiration error = %v, want ErrNotFound", err)
+	}
+	if store.Len() != 0 {
+		t.Errorf("expired entry should be removed, Len() = %d", store.Len())
+	}
+}
+
+func TestMemoryStore_Eviction(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	store.Set(ctx, "a", &Entry{}, time.Minute)
+	store.Set(ctx, "b", &Entry{}, time.Minute)
+
+	// a を参照して b を最も古いエントリにする
+	if _, err := store.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	store.Set(ctx, "c", &Entry{}, time.Minute)
+
+	if store.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", store.Len())
+	}
+	if _, err := store.Get(ctx, "b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("least recently used entry should be evicted, error = %v", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := store.Get(ctx, key); err != nil {
+			t.Errorf("Get(%s) error = %v", key, err)
+		}
+	}
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+
+	for _, key := range []string{"/users?1", "/users?2", "/users/1?1", "/orders?1"} {
+		store.Set(ctx, key, &Entry{}, time.Minute)
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   int
+	}{
+		{name: "exact path", prefix: PathPrefix("/users"), want: 2},
+		{name: "path prefix", prefix: "/users", want: 1},
+		{name: "no match", prefix: "/products", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Purge(ctx, tt.prefix)
+			if err != nil {
+				t.Fatalf("Purge() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Purge() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if store.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", store.Len())
+	}
+}
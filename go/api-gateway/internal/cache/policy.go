@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key はリクエストのキャッシュキーを生成する
+// パス部分はパージで前方一致させるため平文のまま残し、クエリとVaryヘッダーはハッシュにまとめる
+func Key(req *http.Request, varyHeaders []string) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.Query().Encode())) // Encodeはキー順にソートされる
+	for _, name := range varyHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(http.CanonicalHeaderKey(name)))
+		h.Write([]byte{':'})
+		h.Write([]byte(strings.Join(req.Header.Values(name), ",")))
+	}
+	return req.URL.Path + "?" + hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// PathPrefix はパスに完全一致するキーのプレフィックスを返す
+func PathPrefix(path string) string {
+	return path + "?"
+}
+
+// CacheControl はCache-Controlヘッダーのディレクティブ
+type CacheControl struct {
+	NoStore bool
+	NoCache bool
+	Private bool
+	Public  bool
+	MaxAge  time.Duration // -1 は指定なし
+	SMaxAge time.Duration // -1 は指定なし
+}
+
+// ParseCacheControl はCache-Controlヘッダーを解析する
+func ParseCacheControl(header http.Header) CacheControl {
+	cc := CacheControl{MaxAge: -1, SMaxAge: -1}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-store":
+				cc.NoStore = true
+			case "no-cache":
+				cc.NoCache = true
+			case "private":
+				cc.Private = true
+			case "public":
+				cc.Public = true
+			case "max-age":
+				cc.MaxAge = parseSeconds(arg)
+			case "s-maxage":
+				cc.SMaxAge = parseSeconds(arg)
+			}
+		}
+	}
+	return cc
+}
+
+func parseSeconds(s string) time.Duration {
+	n, err := strconv.Atoi(strings.Trim(s, `"`))
+	if err != nil || n < 0 {
+		return -1
+	}
+	return time.Duration(n) * time.Second
+}
+
+// varyCovered はレスポンスの Vary のヘッダーが全て varyHeaders に含まれるか確認する（Vary: * は含まれない）
+func varyCovered(header http.Header, varyHeaders []string) bool {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !slices.ContainsFunc(varyHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cacheableStatus は既定でキャッシュ可能なステータスコード（RFC 9110 15.1）
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+}
+
+// ResponseTTL は共有キャッシュとしてレスポンスを保存してよい期間を返す（0は保存しない）
+// defaultTTL はレスポンスに有効期限の指定が無い場合に使う
+// varyHeaders はキャッシュキーに含めたリクエストヘッダー。レスポンスの Vary がそれ以外のヘッダーを含む場合、
+// キーが同じでも別の表現を返すリクエストがあるため保存しない
+func ResponseTTL(req *http.Request, statusCode int, header http.Header, defaultTTL time.Duration, varyHeaders []string) time.Duration {
+	if !cacheableStatus[statusCode] {
+		return 0
+	}
+	if header.Get("Set-Cookie") != "" || !varyCovered(header, varyHeaders) {
+		return 0
+	}
+
+	cc := ParseCacheControl(header)
+	if cc.NoStore || cc.NoCache || cc.Private {
+		return 0
+	}
+	// 認証付きリクエストのレスポンスは明示的に許可された場合のみ共有キャッシュに保存できる（RFC 9111 3.5）
+	if req.Header.Get("Authorization") != "" && !cc.Public && cc.SMaxAge < 0 {
+		return 0
+	}
+
+	switch {
+	case cc.SMaxAge >= 0:
+		return cc.SMaxAge
+	case cc.MaxAge >= 0:
+		return cc.MaxAge
+	default:
+		return defaultTTL
+	}
+}
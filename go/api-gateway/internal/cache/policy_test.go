@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	newRequest := func(target string, header map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+	vary := []string{"Accept-Language"}
+
+	base := Key(newRequest("/users?a=1&b=2", map[string]string{"Accept-Language": "ja"}), vary)
+
+	if !strings.HasPrefix(base, PathPrefix("/users")) {
+		t.Errorf("Key() = %s, want prefix %s", base, PathPrefix("/users"))
+	}
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		wantSame bool
+	}{
+		{
+			name:     "query order does not matter",
+			req:      newRequest("/users?b=2&a=1", map[string]string{"Accept-Language": "ja"}),
+			wantSame: true,
+		},
+		{
+			name:     "headers not in vary are ignored",
+			req:      newRequest("/users?a=1&b=2", map[string]string{"Accept-Language": "ja", "User-Agent": "test"}),
+			wantSame: true,
+		},
+		{
+			name:     "different query",
+			req:      newRequest("/users?a=1&b=3", map[string]string{"Accept-Language": "ja"}),
+			wantSame: false,
+		},
+		{
+			name:     "different vary header",
+			req:      newRequest("/users?a=1&b=2", map[string]string{"Accept-Language": "en"}),
+			wantSame: false,
+		},
+		{
+			name:     "different path",
+			req:      newRequest("/orders?a=1&b=2", map[string]string{"Accept-Language": "ja"}),
+			wantSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Key(tt.req, vary)
+			if (got == base) != tt.wantSame {
+				t.Errorf("Key() = %s, base = %s, wantSame %v", got, base, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	header := http.Header{}
+	header.Add("Cache-Control", "public, max-age=60")
+	header.Add("Cache-Control", `s-maxage="120", No-Store`)
+
+	got := ParseCacheControl(header)
+	want := CacheControl{NoStore: true, Public: true, MaxAge: 60 * time.Second, SMaxAge: 120 * time.Second}
+	if got != want {
+		t.Errorf("ParseCacheControl() = %+v, want %+v", got, want)
+	}
+
+	if got := ParseCacheControl(http.Header{"Cache-Control": {"max-age=invalid"}}); got.MaxAge != -1 {
+		t.Errorf("invalid max-age = %v, want -1", got.MaxAge)
+	}
+}
+
+func TestResponseTTL(t *testing.T) {
+	const defaultTTL = time.Minute
+
+	tests := []struct {
+		name          string
+		status        int
+		cacheControl  string
+		header        map[string]string
+		authorization bool
+		want          time.Duration
+	}{
+		{name: "default ttl", status: http.StatusOK, want: defaultTTL},
+		{name: "max-age", status: http.StatusOK, cacheControl: "max-age=30", want: 30 * time.Second},
+		{name: "s-maxage takes precedence", status: http.StatusOK, cacheControl: "max-age=30, s-maxage=90", want: 90 * time.Second},
+		{name: "max-age=0", status: http.StatusOK, cacheControl: "max-age=0", want: 0},
+		{name: "non-cacheable status", status: http.StatusInternalServerError, want: 0},
+		{name: "cacheable error status", status: http.StatusNotFound, want: defaultTTL},
+		{name: "no-store", status: http.StatusOK, cacheControl: "no-store", want: 0},
+		{name: "no-cache", status: http.StatusOK, cacheControl: "no-cache", want: 0},
+		{name: "private", status: http.StatusOK, cacheControl: "private, max-age=30", want: 0},
+		{name: "set-cookie", status: http.StatusOK, header: map[string]string{"Set-Cookie": "session=1"}, want: 0},
+		{name: "vary *", status: http.StatusOK, header: map[string]string{"Vary": "*"}, want: 0},
+		{name: "vary header in cache key", status: http.StatusOK, header: map[string]string{"Vary": "accept-language"}, want: defaultTTL},
+		{name: "vary header not in cache key", status: http.StatusOK, header: map[string]string{"Vary": "Accept-Language, Accept-Encoding"}, want: 0},
+		{name: "authorized request", status: http.StatusOK, authorization: true, want: 0},
+		{name: "authorized request with public", status: http.StatusOK, cacheControl: "public", authorization: true, want: defaultTTL},
+		{name: "authorized request with s-maxage", status: http.StatusOK, cacheControl: "s-maxage=10", authorization: true, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.authorization {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			header := http.Header{}
+			if tt.cacheControl != "" {
+				header.Set("Cache-Control", tt.cacheControl)
+			}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+
+			if got := ResponseTTL(req, tt.status, header, defaultTTL, []string{"Accept-Language"}); got != tt.want {
+				t.Errorf("ResponseTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	redisclient "api-gateway/pkg/redis"
+)
+
+// purgeScanCount はPurge時にSCANで1回に走査するキー数の目安
+const purgeScanCount = 500
+
+// RedisStore はRedisを使用したキャッシュストア
+// 複数のGatewayインスタンスでキャッシュを共有できる
+type RedisStore struct {
+	client    *redisclient.Client
+	keyPrefix string
+}
+
+// NewRedisStore は新しいRedisStoreを作成する
+func NewRedisStore(client *redisclient.Client, keyPrefix string) *RedisStore {
+	if keyPrefix == "" {
+		keyPrefix = "cache:" // デフォルトプレフィックス
+	}
+	return &RedisStore{
+		client: client,
+		// 全てのキーをクライアントの名前空間の中に作る
+		keyPrefix: client.Key(keyPrefix),
+	}
+}
+
+// Get はキーに対応するエントリを取得する
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	value, err := s.client.Get(ctx, s.keyPrefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache entry %s: %w", key, err)
+	}
+	if value == "" {
+		return nil, ErrNotFound
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+	}
+	return &entry, nil
+}
+
+// Set はエントリをTTL付きで保存する
+func (s *RedisStore) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %s: %w", key, err)
+	}
+	if err := s.client.Set(ctx, s.keyPrefix+key, string(data), ttl); err != nil {
+		return fmt.Errorf("failed to set cache entry %s: %w", key, err)
+	}
+	return nil
+}
+
+// Purge は指定したプレフィックスで始まるキーのエントリを削除する
+// KEYSはRedisをブロックするため、SCANで少しずつ走査する
+func (s *RedisStore) Purge(ctx context.Context, prefix string) (int, error) {
+	rdb := s.client.GetClient()
+	pattern := escapeGlob(s.keyPrefix+prefix) + "*"
+
+	purged := 0
+	var cursor uint64
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, pattern, purgeScanCount).Result()
+		if err != nil {
+			return purged, fmt.Errorf("failed to scan cache keys: %w", err)
+		}
+		if len(keys) > 0 {
+			n, err := rdb.Del(ctx, keys...).Result()
+			if err != nil {
+				return purged, fmt.Errorf("failed to delete cache keys: %w", err)
+			}
+			purged += int(n)
+		}
+		if next == 0 {
+			return purged, nil
+		}
+		cursor = next
+	}
+}
+
+// escapeGlob はSCANのMATCHパターンで特別な意味を持つ文字をエスケープする
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"api-gateway/internal/cache"
+	redisclient "api-gateway/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisStore(t *testing.T) (*cache.RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	client, err := redisclient.NewClient(redisclient.Config{
+		Host: mr.Addr(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewRedisStore(client, ""), mr
+}
+
+func TestRedisStore_GetSet(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestRedisStore(t)
+
+	if _, err := store.Get(ctx, "/users?abc"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Get() on missing key error = %v, want ErrNotFound", err)
+	}
+
+	entry := &cache.Entry{
+		StatusCode: 200,
+		Header:     map[string][]string{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"users":[]}`),
+		StoredAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	if err := store.Set(ctx, "/users?abc", entry, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// デフォルトプレフィックスで保存され、TTLが設定されていること
+	if !mr.Exists("cache:/users?abc") {
+		t.Fatal("key cache:/users?abc not found in redis")
+	}
+	if ttl := mr.TTL("cache:/users?abc"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want %v", ttl, time.Minute)
+	}
+
+	got, err := store.Get(ctx, "/users?abc")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.StatusCode != entry.StatusCode || string(got.Body) != string(entry.Body) ||
+		got.Header.Get("Content-Type") != "application/json" || !got.StoredAt.Equal(entry.StoredAt) {
+		t.Errorf("Get() = %+v, want %+v", got, entry)
+	}
+
+	mr.FastForward(time.Minute)
+	if _, err := store.Get(ctx, "/users?abc"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Get() after expiration error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRedisStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestRedisStore(t)
+
+	for _, key := range []string{"/users?1", "/users?2", "/users/1?1", "/u*?1", "/orders?1"} {
+		if err := store.Set(ctx, key, &cache.Entry{StatusCode: 200}, time.Minute); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	// キャッシュ以外のキーは削除しない
+	mr.Set("revoke:/users", "1")
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   int
+	}{
+		{name: "glob characters are literal", prefix: cache.PathPrefix("/u*"), want: 1},
+		{name: "exact path", prefix: cache.PathPrefix("/users"), want: 2},
+		{name: "path prefix", prefix: "/users", want: 1},
+		{name: "no match", prefix: "/products", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Purge(ctx, tt.prefix)
+			if err != nil {
+				t.Fatalf("Purge() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Purge() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if !mr.Exists("cache:/orders?1") || !mr.Exists("revoke:/users") {
+		t.Error("unrelated keys should not be purged")
+	}
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound はキャッシュにエントリが存在しない場合のエラー
+var ErrNotFound = errors.New("cache entry not found")
+
+// Entry はキャッシュされたレスポンス
+type Entry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// Store はレスポンスキャッシュの保存先
+type Store interface {
+	// Get はキーに対応するエントリを取得する
+	// エントリが存在しない（期限切れを含む）場合は ErrNotFound を返す
+	Get(ctx context.Context, key string) (*Entry, error)
+
+	// Set はエントリをTTL付きで保存する
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+
+	// Purge は指定したプレフィックスで始まるキーのエントリを削除し、削除件数を返す
+	Purge(ctx context.Context, prefix string) (int, error)
+}
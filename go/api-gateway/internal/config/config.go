@@ -2,19 +2,33 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"api-gateway/internal/forwarded"
 )
 
 // Config はAPI Gatewayの設定全体
 type Config struct {
-	Server  ServerConfig  `yaml:"server"`
-	Logging LoggingConfig `yaml:"logging"`
-	Routing RoutingConfig `yaml:"routing"`
-	Redis   RedisConfig   `yaml:"redis,omitempty"`
-	JWT     JWTConfig     `yaml:"jwt,omitempty"`
+	Server       ServerConfig       `yaml:"server"`
+	Logging      LoggingConfig      `yaml:"logging"`
+	Routing      RoutingConfig      `yaml:"routing"`
+	Redis        RedisConfig        `yaml:"redis,omitempty"`
+	JWT          JWTConfig          `yaml:"jwt,omitempty"`
+	Admin        AdminConfig        `yaml:"admin,omitempty"`
+	Portal       PortalConfig       `yaml:"portal,omitempty"`
+	Cache        CacheConfig        `yaml:"cache,omitempty"`
+	Buffer       BufferConfig       `yaml:"buffer,omitempty"`
+	Audit        AuditConfig        `yaml:"audit,omitempty"`
+	Health       HealthConfig       `yaml:"health,omitempty"`
+	Journal      JournalConfig      `yaml:"journal,omitempty"`
+	FeatureFlags FeatureFlagsConfig `yaml:"feature_flags,omitempty"`
+	Startup      StartupConfig      `yaml:"startup,omitempty"`
+	// ServiceDiscovery はルートの backend.service で参照するサービスのインスタンスを取得する登録先の設定
+	ServiceDiscovery ServiceDiscoveryConfig `yaml:"service_discovery,omitempty"`
 }
 
 // ServerConfig はHTTPサーバの設定
@@ -24,18 +38,137 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `yaml:"read_timeout"`
 	WriteTimeout    time.Duration `yaml:"write_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// Listeners は待ち受けるアドレスごとの設定。指定した場合は host / port の代わりに使う
+	Listeners []ListenerConfig `yaml:"listeners,omitempty"`
+	// TrustedProxies は X-Forwarded-For / X-Forwarded-Proto を信頼する前段のプロキシ（IPアドレスまたはCIDR）
+	// それ以外の接続元から受け取ったこれらのヘッダーは削除する
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
+	// MaxConcurrentRequests は全てのリスナーで同時にバックエンドへ転送するリクエスト数の上限（0は無制限）
+	// 上限に達している間のリクエストには Retry-After を付けて503を返す
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests,omitempty"`
+}
+
+// リスナーで提供する機能
+const (
+	ServeGateway = "gateway" // ルーティング設定のルートへの転送（JWKS・開発者ポータルを含む）
+	ServeAdmin   = "admin"   // 管理API（/admin/）
+)
+
+// ListenerConfig は1つのリスナーの設定
+// /healthz と /readyz は全てのリスナーで提供する
+type ListenerConfig struct {
+	Name string `yaml:"name,omitempty"`
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// Serve はこのリスナーで提供する機能（gateway, admin）。省略時は gateway のみ
+	Serve []string `yaml:"serve,omitempty"`
+	// Groups はこのリスナーで公開するルートのグループ（省略時は全てのルート）
+	Groups []string `yaml:"groups,omitempty"`
+	// Middleware は middleware を指定していないルートに適用するミドルウェア（ルーティング設定の middlewares の名前でも指定できる）
+	// ゲートウェイのルートにのみ適用し、/admin（管理API）には適用しない。管理APIのBasic認証は admin.basic_auth で設定する
+	Middleware []MiddlewareConfig `yaml:"middleware,omitempty"`
 }
 
 // LoggingConfig はログの設定
 type LoggingConfig struct {
 	Level  string `yaml:"level"`  // debug, info, warn, error
-	Format string `yaml:"format"` // json, text
+	Format string `yaml:"format"` // json, text, pretty
+	// Async はゲートウェイのアクセスログをバックグラウンドで書き込む設定
+	Async AsyncLoggingConfig `yaml:"async,omitempty"`
+	// SensitiveHeaders は Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-API-Key に加えて、ログで値を伏せるヘッダー
+	SensitiveHeaders []string `yaml:"sensitive_headers,omitempty"`
+	// SlowRequestThreshold はこの時間以上かかったリクエストをWARNで記録し gateway_slow_requests_total に数える（0は無効）
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold,omitempty"`
+	// Sampling はゲートウェイのアクセスログとリクエストのログを間引く設定
+	Sampling SamplingLoggingConfig `yaml:"sampling,omitempty"`
+	// Export は標準出力に加えてログをOTLPコレクターまたはLokiに直接送信する設定（endpoint が空の場合は送信しない）
+	Export ExportLoggingConfig `yaml:"export,omitempty"`
+}
+
+// ExportLoggingConfig はログの送信の設定
+// ログ収集のサイドカーやエージェントが無い環境で、ゲートウェイから直接ログを送信するために使う
+// 送信の失敗は標準エラー出力に記録し、捨てたログと失敗した送信は gateway_log_export_dropped_total・gateway_log_export_failures_total に数える
+type ExportLoggingConfig struct {
+	// Protocol は送信するプロトコル（otlp: OTLP/HTTPのJSONエンコーディング, loki: Loki push API）。デフォルトは otlp
+	Protocol string `yaml:"protocol,omitempty"`
+	// Endpoint は送信先のURL（http://otel-collector:4318/v1/logs、http://loki:3100/loki/api/v1/push など）
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Headers は送信するリクエストに付与するヘッダー（認証トークンは ${LOG_EXPORT_TOKEN} のように環境変数から設定する）
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// ServiceName はOTLPのリソース属性 service.name（Lokiでは service_name ラベル）。デフォルトは api-gateway
+	ServiceName string `yaml:"service_name,omitempty"`
+	// Labels はOTLPのリソース属性（Lokiではストリームのラベル）に追加する値（env: production など）
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// BatchSize は1回のリクエストで送信する最大のログ数（0は512）
+	BatchSize int `yaml:"batch_size,omitempty"`
+	// FlushInterval はバッファしたログを送信する間隔（0は2秒）
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty"`
+	// QueueSize は送信を待つログ数の上限（0は8192）。送信先の障害時に超えた場合は新しいログを捨てる
+	QueueSize int `yaml:"queue_size,omitempty"`
+	// Timeout は1回の送信のタイムアウト（0は10秒）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
 }
 
+// SamplingLoggingConfig はログのサンプリングと件数の制限の設定（レベルとメッセージの組ごとに interval の期間で数える）
+// 間引いたログは gateway_log_sampled_total と gateway_log_rate_limited_total に数える
+type SamplingLoggingConfig struct {
+	// Every は成功したリクエストのアクセスログとINFO以下のログを every 件に1件だけ記録する（0・1は全て記録）
+	// ステータスコードが400以上のアクセスログとWARN以上のログは間引かない
+	Every int `yaml:"every,omitempty"`
+	// RateLimit は interval あたりに記録する件数の上限（0は無制限）。エラーを含む全てのログに適用する
+	RateLimit int `yaml:"rate_limit,omitempty"`
+	// Interval は数える期間（0は1秒）
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// AsyncLoggingConfig は非同期ログの設定
+type AsyncLoggingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// QueueSize は書き込み待ちのログの上限（0はデフォルト）。一杯の場合は古いログから捨てる
+	QueueSize int `yaml:"queue_size,omitempty"`
+}
+
+// ルートの読み込み元
+const (
+	RoutingSourceFile       = "file"
+	RoutingSourceKubernetes = "kubernetes"
+)
+
 // RoutingConfig はルーティングの設定
 type RoutingConfig struct {
+	// ConfigFile はルーティング設定ファイル（source: kubernetes では省略でき、指定した場合は middlewares などとルートを引き継ぐ）
 	ConfigFile      string `yaml:"config_file"`
 	EnableHotReload bool   `yaml:"enable_hot_reload"`
+	// TrailingSlash は末尾スラッシュの扱いのデフォルト（merge, strict, redirect）。ルートごとに上書きできる
+	TrailingSlash string `yaml:"trailing_slash,omitempty"`
+	// Source はルートの読み込み元（file, kubernetes。空は file）
+	// kubernetes はクラスタの HTTPRoute・Ingress を監視してルートに変換し、変更を再起動せずに反映する
+	Source string `yaml:"source,omitempty"`
+	// Kubernetes は source: kubernetes の設定
+	Kubernetes KubernetesRoutingConfig `yaml:"kubernetes,omitempty"`
+}
+
+// KubernetesRoutingConfig はKubernetesのリソースからルートを読み込む設定
+// ルートのバックエンドは "http://<サービス名>.<名前空間>.svc.<cluster_domain>:<ポート>" になる
+type KubernetesRoutingConfig struct {
+	// APIServer はAPIサーバのURL（空はPod内の環境変数 KUBERNETES_SERVICE_HOST / KUBERNETES_SERVICE_PORT）
+	APIServer string `yaml:"api_server,omitempty"`
+	// TokenFile はサービスアカウントのトークンのファイル（空はPodにマウントされたトークン。client-go が定期的に読み直す）
+	TokenFile string `yaml:"token_file,omitempty"`
+	// CAFile はAPIサーバの証明書を検証するCA証明書のファイル（空はPodにマウントされたCA証明書）
+	CAFile string `yaml:"ca_file,omitempty"`
+	// Namespace は監視する名前空間（空は全ての名前空間）
+	Namespace string `yaml:"namespace,omitempty"`
+	// Resources は監視するリソース（httproute, ingress。省略時は両方）
+	Resources []string `yaml:"resources,omitempty"`
+	// GatewayName は HTTPRoute の parentRefs で参照されている場合のみ読み込む Gateway の名前（空は全ての HTTPRoute）
+	GatewayName string `yaml:"gateway_name,omitempty"`
+	// IngressClass は読み込む Ingress のクラス（spec.ingressClassName。空は "api-gateway"）
+	IngressClass string `yaml:"ingress_class,omitempty"`
+	// ClusterDomain はサービスのDNS名のドメイン（空は "cluster.local"）
+	ClusterDomain string `yaml:"cluster_domain,omitempty"`
+	// Timeout はバックエンドのタイムアウトのデフォルト（0は30秒）。HTTPRoute の timeouts.request で上書きできる
+	Timeout time.Duration `yaml:"timeout,omitempty"`
 }
 
 // RedisConfig はRedisの設定
@@ -48,6 +181,29 @@ type RedisConfig struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
 	KeyPrefix    string        `yaml:"key_prefix"` // Revoke情報のキープレフィックス
+	// RevokeCache は revoke ミドルウェアが失効時刻を保持するローカルキャッシュの設定
+	RevokeCache RevokeCacheConfig `yaml:"revoke_cache,omitempty"`
+	// Keyspace は全てのキー（失効時刻、OIDCのセッション、レスポンスキャッシュ）の名前空間
+	Keyspace RedisKeyspaceConfig `yaml:"keyspace,omitempty"`
+}
+
+// RedisKeyspaceConfig はRedisのキーの名前空間の設定
+// 複数のゲートウェイが同じRedisを共有する場合に、キーの先頭に "<namespace>:v<version>:" を付けて分ける
+// キーの形式を互換性の無い形に変える場合は version を上げ、`gateway keyspace migrate` で移行する
+type RedisKeyspaceConfig struct {
+	// Namespace はデプロイメントの名前（空の場合は付けない）
+	Namespace string `yaml:"namespace,omitempty"`
+	// Version はキーの形式のバージョン（0の場合は付けない）
+	Version int `yaml:"version,omitempty"`
+}
+
+// RevokeCacheConfig は失効時刻のローカルキャッシュの設定
+// 失効の変更はRedisのpub/subで通知され、キャッシュから破棄される
+type RevokeCacheConfig struct {
+	// Size はキャッシュするユーザー数の上限（0の場合はキャッシュしない）
+	Size int `yaml:"size,omitempty"`
+	// TTL はキャッシュの保存期間（0の場合は5秒）。通知を受け取れなかった場合の最大の遅延になる
+	TTL time.Duration `yaml:"ttl,omitempty"`
 }
 
 // JWTConfig はJWT検証の設定
@@ -56,35 +212,657 @@ type JWTConfig struct {
 	PublicKeyFiles map[string]string `yaml:"public_key_files,omitempty"`
 	// SkipValidation は検証をスキップするか（開発環境用）
 	SkipValidation bool `yaml:"skip_validation,omitempty"`
+	// JWKS はIdPのJWKSエンドポイントから公開鍵を取得する設定
+	JWKS JWKSConfig `yaml:"jwks,omitempty"`
+	// Signing はゲートウェイが発行するトークンの署名鍵の設定
+	Signing SigningConfig `yaml:"signing,omitempty"`
+}
+
+// SigningConfig はゲートウェイが発行するトークンの署名鍵の設定
+// アクティブな鍵で署名し、それ以外（パッシブ）の鍵は検証用として /.well-known/jwks.json で公開する
+type SigningConfig struct {
+	// ActiveKID は署名に使う鍵のkid
+	ActiveKID string `yaml:"active_kid,omitempty"`
+	// PrivateKeyFiles は秘密鍵ファイルのパス (kid → ファイルパス)
+	PrivateKeyFiles map[string]string `yaml:"private_key_files,omitempty"`
+}
+
+// JWKSConfig はJWKSエンドポイントの設定
+type JWKSConfig struct {
+	// URL はJWKSエンドポイント（空の場合は使用しない）
+	URL string `yaml:"url,omitempty"`
+	// RefreshInterval はCache-Controlのmax-ageが無い場合の更新間隔（0は5分）
+	// 鍵セットに無いkidのトークンを受け取った場合は、間隔を待たずに再取得する（30秒に1回まで）
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"`
+	// StaleTolerance はIdPの障害時に期限切れの鍵を使い続ける猶予（0は1時間）
+	StaleTolerance time.Duration `yaml:"stale_tolerance,omitempty"`
+	// Timeout は1回の取得のタイムアウト（0は10秒）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// FeatureFlagsConfig はルートの enabled_when で参照するフィーチャーフラグの設定
+type FeatureFlagsConfig struct {
+	// Flags はフラグの値（フラグ名 → true/false）
+	Flags map[string]bool `yaml:"flags,omitempty"`
+	// File はフラグの値を読み込むYAMLファイル（flags を上書きする）。refresh_interval ごとに読み直す
+	File string `yaml:"file,omitempty"`
+	// RefreshInterval は file を読み直す間隔（0は10秒）
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"`
+}
+
+// サービスディスカバリの登録先
+const (
+	DiscoveryConsul = "consul"
+	DiscoveryEtcd   = "etcd"
+)
+
+// ServiceDiscoveryConfig はサービスディスカバリの設定
+// backend.service を指定したルートは、登録先から取得したインスタンスへ転送する（インスタンスの変更は監視して反映する）
+type ServiceDiscoveryConfig struct {
+	// Provider は登録先（consul, etcd。空は無効）
+	Provider string `yaml:"provider,omitempty"`
+	// Consul はConsulのカタログの設定（provider: consul）
+	Consul ConsulDiscoveryConfig `yaml:"consul,omitempty"`
+	// Etcd はetcdの設定（provider: etcd）
+	Etcd EtcdDiscoveryConfig `yaml:"etcd,omitempty"`
+	// RetryInterval は取得に失敗した場合に再試行するまでの間隔（0は5秒）。失敗している間は直前のインスタンスを使う
+	RetryInterval time.Duration `yaml:"retry_interval,omitempty"`
+}
+
+// ConsulDiscoveryConfig はConsulのカタログからヘルスチェックを通過したインスタンスを取得する設定
+type ConsulDiscoveryConfig struct {
+	// Address はConsulのHTTP APIのURL
+	Address string `yaml:"address"`
+	// Token はACLトークン
+	Token string `yaml:"token,omitempty"`
+	// Datacenter は参照するデータセンター（空はエージェントのデータセンター）
+	Datacenter string `yaml:"datacenter,omitempty"`
+	// WaitTime はブロッキングクエリでカタログの変更を待つ時間（0は5分）
+	WaitTime time.Duration `yaml:"wait_time,omitempty"`
+}
+
+// EtcdDiscoveryConfig はetcdからインスタンスを取得する設定
+// インスタンスは "<prefix><サービス名>/<任意のID>" のキーに host:port またはURLを値として登録する
+type EtcdDiscoveryConfig struct {
+	// Endpoints はetcdのクライアントURL
+	Endpoints []string `yaml:"endpoints"`
+	// Prefix はキーの接頭辞（空は "/services/"）
+	Prefix string `yaml:"prefix,omitempty"`
+	// PollInterval は取得し直す間隔（0は10秒）
+	PollInterval time.Duration `yaml:"poll_interval,omitempty"`
+}
+
+// AdminConfig は管理APIの設定
+type AdminConfig struct {
+	// Enabled は /admin 配下の管理APIを公開するか
+	// APIキーは環境変数 ADMIN_API_KEY（カンマ区切りで複数指定可）と ADMIN_API_KEY_FILE から読み込む
+	Enabled bool `yaml:"enabled"`
+	// JWT はAPIキーの代わりに、管理者ロールを持つJWTでのアクセスを許可する設定
+	JWT AdminJWTConfig `yaml:"jwt,omitempty"`
+	// BasicAuth はAPIキーの代わりに、Basic認証でのアクセスを許可する設定
+	BasicAuth AdminBasicAuthConfig `yaml:"basic_auth,omitempty"`
+}
+
+// AdminJWTConfig は管理APIのJWT認証の設定
+// トークンは jwt の公開鍵・JWKSで検証し、操作した管理者（sub）を監査ログに記録する
+type AdminJWTConfig struct {
+	// Enabled は Authorization: Bearer のJWTでの管理APIへのアクセスを許可するか
+	Enabled bool `yaml:"enabled"`
+	// Role は必要なロール（roles, role クレームまたは scope, scp クレームに含まれること。空は "admin"）
+	Role string `yaml:"role,omitempty"`
+}
+
+// AdminBasicAuthConfig は管理APIのBasic認証の設定
+// IdPを用意せずに /admin/metrics などを取得する場合（Prometheus の basic_auth など）に使い、認証したユーザーを監査ログの actor に記録する
+// 設定したユーザーは全ての管理APIを操作できる
+type AdminBasicAuthConfig struct {
+	// Enabled は Authorization: Basic での管理APIへのアクセスを許可するか
+	Enabled bool `yaml:"enabled"`
+	// Realm は WWW-Authenticate で返す realm（空は "Restricted"）
+	Realm string `yaml:"realm,omitempty"`
+	// Users はユーザー名とパスワードの bcrypt ハッシュ（htpasswd -nbB <user> <password> で生成できる）
+	Users map[string]string `yaml:"users,omitempty"`
+}
+
+// PortalConfig は開発者ポータルの設定
+type PortalConfig struct {
+	// Enabled は /docs で開発者ポータルを公開するか
+	// ポータルは認証なしで統合済みOpenAPIドキュメントを返すため、公開範囲に注意する
+	Enabled bool `yaml:"enabled"`
+	// Title はポータルのページタイトル
+	Title string `yaml:"title,omitempty"`
+}
+
+// CacheConfig はレスポンスキャッシュの保存先の設定
+// キャッシュの対象・TTLはルートごとに cache ミドルウェアで設定する
+type CacheConfig struct {
+	// Store は保存先（memory, redis）。redis の場合は redis.host の設定が必要
+	Store string `yaml:"store"`
+	// MaxEntries は memory の場合の最大エントリ数
+	MaxEntries int `yaml:"max_entries,omitempty"`
+	// KeyPrefix は redis の場合のキープレフィックス
+	KeyPrefix string `yaml:"key_prefix,omitempty"`
+}
+
+// BufferConfig はレスポンスボディ全体を読み込む処理（サイズ上限の判定など）のバッファ設定
+type BufferConfig struct {
+	// MemoryLimit はメモリに保持する上限バイト数（0は1MiB）。超えた分は一時ファイルへ書き出す
+	MemoryLimit int64 `yaml:"memory_limit,omitempty"`
+	// TempDir は一時ファイルのディレクトリ（空はOSのデフォルト）
+	TempDir string `yaml:"temp_dir,omitempty"`
+}
+
+// AuditConfig は認証・失効イベントの監査ログ設定
+type AuditConfig struct {
+	// Enabled はtrueの場合、監査イベントを通常のログとは別に出力する
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Output は出力先（stdout, stderr またはファイルパス。空はstdout）
+	Output string `yaml:"output,omitempty"`
+}
+
+// JournalConfig はリーガルホールドのためのリクエストのジャーナルの設定
+// journal: true のルートのリクエストのメタデータ（ボディは含まない）をハッシュチェーンで追記する
+type JournalConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Sink は出力先（file, s3）。file はエントリを fsync してからレスポンスを完了し（同時のリクエストは1回の fsync にまとめる）、
+	// s3 はバッファして一定間隔でアップロードするため、異常終了時にアップロード前のエントリが失われる
+	Sink string `yaml:"sink,omitempty"`
+	// File はジャーナルのファイルパス（sink: file）
+	File string `yaml:"file,omitempty"`
+	// S3 はS3互換のオブジェクトストレージの設定（sink: s3）
+	S3 JournalS3Config `yaml:"s3,omitempty"`
+}
+
+// JournalS3Config はジャーナルをアップロードするオブジェクトストレージの設定
+type JournalS3Config struct {
+	// URL はバケットのURL（https://bucket.s3.ap-northeast-1.amazonaws.com など）
+	URL    string `yaml:"url"`
+	Prefix string `yaml:"prefix,omitempty"`
+	Region string `yaml:"region"`
+	// Credentials は署名に使う認証情報の取得元（省略時は環境変数）
+	Credentials AWSCredentialsConfig `yaml:"credentials,omitempty"`
+	// LegalHold はアップロードしたオブジェクトに S3 Object Lock のリーガルホールドを設定するか（バケットでObject Lockの有効化が必要）
+	LegalHold bool `yaml:"legal_hold,omitempty"`
+	// FlushInterval はバッファしたエントリをアップロードする間隔（省略時は1分）
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty"`
+	// MaxEntries は1つのオブジェクトに含める最大のエントリ数（省略時は1000）
+	MaxEntries int `yaml:"max_entries,omitempty"`
+}
+
+// HealthConfig は /healthz と /readyz の設定
+type HealthConfig struct {
+	// CheckBackends はtrueの場合、/readyz でバックエンドへ接続できるかも確認する
+	CheckBackends bool `yaml:"check_backends,omitempty"`
+	// Timeout は依存先ごとの確認のタイムアウト（0は2秒）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// ActiveChecks はバックエンドを定期的に確認し、異常なバックエンドへの転送を止める設定
+	ActiveChecks ActiveHealthCheckConfig `yaml:"active_checks,omitempty"`
+	// OutlierDetection は実際のリクエストで5xxやタイムアウトの多いバックエンドを一定時間除外する設定
+	OutlierDetection OutlierDetectionConfig `yaml:"outlier_detection,omitempty"`
+}
+
+// StartupConfig は起動時に依存先（ルーティング設定ファイル、Redis、JWKS）へ接続できない場合の再試行の設定
+// 再試行を使い切った場合、ルーティング設定ファイルとRedisは起動を中止し、JWKSはバックグラウンドで再試行を続ける
+// --wait-for-deps を指定した場合は回数に関わらず WaitTimeout まで全ての依存先を待ち、待てなかった場合は起動を中止する
+type StartupConfig struct {
+	// Retries は依存先ごとの再試行回数（0は再試行しない）
+	Retries int `yaml:"retries,omitempty"`
+	// Backoff は最初の再試行までの待ち時間（0は1秒）。再試行ごとに倍にする
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+	// MaxBackoff は再試行の待ち時間の上限（0は30秒）
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"`
+	// WaitTimeout は --wait-for-deps で依存先を待つ時間の上限（0は無制限）
+	WaitTimeout time.Duration `yaml:"wait_timeout,omitempty"`
+}
+
+// ActiveHealthCheckConfig はバックエンドのアクティブヘルスチェックの設定
+type ActiveHealthCheckConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Path はバックエンドのホストに対して確認するパス（空は /health）
+	Path string `yaml:"path,omitempty"`
+	// Interval は確認の間隔（0は10秒）
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// Timeout は1回の確認のタイムアウト（0は2秒）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// UnhealthyThreshold は異常とみなす連続失敗回数（0は3）
+	UnhealthyThreshold int `yaml:"unhealthy_threshold,omitempty"`
+	// HealthyThreshold は正常に戻す連続成功回数（0は2）
+	HealthyThreshold int `yaml:"healthy_threshold,omitempty"`
+}
+
+// OutlierDetectionConfig はバックエンドの外れ値検出（パッシブヘルスチェック）の設定
+// interval の間のリクエストのうち5xx（接続できない場合を含む）またはタイムアウトの割合がしきい値以上のバックエンドを ejection_duration の間除外する
+type OutlierDetectionConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Interval は割合を集計する期間（0は10秒）
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// MinRequests は判定に必要な期間内のリクエスト数（0は10）
+	MinRequests int `yaml:"min_requests,omitempty"`
+	// MaxErrorPercent は除外する5xxの割合（0〜100のパーセント。0は50）
+	MaxErrorPercent float64 `yaml:"max_error_percent,omitempty"`
+	// MaxTimeoutPercent は除外するタイムアウトの割合（0〜100のパーセント。0は50）
+	MaxTimeoutPercent float64 `yaml:"max_timeout_percent,omitempty"`
+	// EjectionDuration は除外する時間（0は30秒）
+	EjectionDuration time.Duration `yaml:"ejection_duration,omitempty"`
 }
 
 // Route はルーティング設定の1つのルート
 type Route struct {
-	Path       string             `yaml:"path"`
-	Methods    []string           `yaml:"methods"`
-	Backend    BackendConfig      `yaml:"backend"`
+	Path    string        `yaml:"path"`
+	Methods []string      `yaml:"methods"`
+	Backend BackendConfig `yaml:"backend"`
+	// Response はバックエンドへ転送せずにゲートウェイが返す固定のレスポンス（backend とはどちらか一方を指定する）
+	Response *StaticResponseConfig `yaml:"response,omitempty"`
+	// Aggregate は複数のバックエンドを並行に呼び出し、JSONのレスポンスを1つにまとめて返す設定（backend とはどちらか一方を指定する）
+	Aggregate *AggregateConfig `yaml:"aggregate,omitempty"`
+	// Middleware はルートに適用するミドルウェア（記述した順に実行し、名前の参照はその位置に展開する。cors は常に最も外側で適用する）
 	Middleware []MiddlewareConfig `yaml:"middleware,omitempty"`
-	Priority   int                `yaml:"priority"`
+	// Priority はパスが重なるルートの優先度（値が大きいほど優先する）。同じ優先度ではパスの具体的なルート（静的なセグメント > 正規表現 > :param > ワイルドカード）を優先する
+	// 優先度とパラメータ名以外のパスと match が同じルートは、どちらを使うか決められないためエラーになる
+	Priority int `yaml:"priority"`
+	// Match はパス以外にリクエストが満たす条件（ヘッダーとクエリパラメータ）。同じパスで条件の異なるルートを定義できる
+	Match *MatchConfig `yaml:"match,omitempty"`
+	// Group はOpenAPIドキュメント集約時に所属するグループ名
+	Group string `yaml:"group,omitempty"`
+	// MaxRequestBody はリクエストボディの上限バイト数（0は無制限）
+	MaxRequestBody int64 `yaml:"max_request_body,omitempty"`
+	// MaxResponseBody はバックエンドのレスポンスボディの上限バイト数（0は無制限）
+	MaxResponseBody int64 `yaml:"max_response_body,omitempty"`
+	// MaxConcurrentRequests はルートで同時にバックエンドへ転送するリクエスト数の上限（0は無制限）
+	// 上限に達している間のリクエストには Retry-After を付けて429を返す（concurrency_queue を設定した場合は待たせる）
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests,omitempty"`
+	// ConcurrencyQueue は max_concurrent_requests に達した場合に、すぐに断らずリクエストを待たせる設定
+	ConcurrencyQueue *ConcurrencyQueueConfig `yaml:"concurrency_queue,omitempty"`
+	// RequestHeaders はバックエンドへ転送するリクエストヘッダーの変換ルール
+	RequestHeaders HeaderRulesConfig `yaml:"request_headers,omitempty"`
+	// ResponseHeaders はクライアントへ返すレスポンスヘッダーの変換ルール
+	ResponseHeaders HeaderRulesConfig `yaml:"response_headers,omitempty"`
+	// TrailingSlash は末尾スラッシュの扱い（merge, strict, redirect）。空は routing.trailing_slash に従う
+	TrailingSlash string `yaml:"trailing_slash,omitempty"`
+	// Residency はリクエストをリージョンごとのバックエンドへ振り分け、リージョンをまたぐ転送を拒否する設定
+	Residency *ResidencyConfig `yaml:"residency,omitempty"`
+	// Canary はリクエストの一部をカナリアのバックエンドへ振り分ける設定（residency とは併用できない）
+	Canary *CanaryConfig `yaml:"canary,omitempty"`
+	// ResponseMasking はレスポンスに含まれる個人情報（PII）をマスクする設定
+	ResponseMasking *ResponseMaskingConfig `yaml:"response_masking,omitempty"`
+	// Contract はルートのOpenAPIドキュメント（API契約）でリクエストとバックエンドのレスポンスを検証する設定
+	Contract *ContractConfig `yaml:"contract,omitempty"`
+	// Journal はリクエストのメタデータをジャーナルへ記録するか（gateway.yaml の journal の設定が必要）
+	Journal bool `yaml:"journal,omitempty"`
+	// EnabledWhen はルートを公開する条件のフィーチャーフラグ（"flags.new_checkout"、否定は "!flags.new_checkout"）
+	EnabledWhen string `yaml:"enabled_when,omitempty"`
+	// WhenDisabled はフラグが無効な場合の応答（省略時は404）
+	WhenDisabled *WhenDisabledConfig `yaml:"when_disabled,omitempty"`
+}
+
+// StaticResponseConfig はルートが返す固定のレスポンスの設定
+// robots.txt やメンテナンスページ、バックエンドの開発中のモックを返すために使う。ミドルウェアとレスポンスヘッダーの変換は通常のルートと同じく適用する
+type StaticResponseConfig struct {
+	// Status はステータスコード（省略時は200）
+	Status int `yaml:"status,omitempty"`
+	// Headers はレスポンスヘッダー。Content-Type を省略した場合は file の拡張子かボディの内容から決める
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Body はレスポンスボディ（file とはどちらか一方を指定する）
+	Body string `yaml:"body,omitempty"`
+	// File はレスポンスボディにするファイル。ルートの読み込み時（ホットリロードを含む）に読み込む
+	File string `yaml:"file,omitempty"`
+}
+
+// AggregateConfig は複数のバックエンドのレスポンスを1つのJSONにまとめる設定（BFF）
+// 各バックエンドのレスポンスを key をキーとするJSONオブジェクトにまとめて200で返す
+type AggregateConfig struct {
+	// Timeout は全てのバックエンドの呼び出しをまとめた期限（省略時は10秒）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Backends は並行に呼び出すバックエンド
+	Backends []AggregateBackendConfig `yaml:"backends"`
+}
+
+// AggregateBackendConfig はまとめるバックエンドの1つの設定
+type AggregateBackendConfig struct {
+	// Key はまとめたJSONでレスポンスを格納するキー
+	Key string `yaml:"key"`
+	// URL は呼び出すURL。{name} はルートのパスパラメータの値に置き換え、クライアントのクエリを付けてGETで呼び出す
+	URL string `yaml:"url"`
+	// Required は失敗した場合にリクエスト全体を失敗（502、期限切れは504）にするか
+	// 省略時は null を格納し、失敗したキーを X-Gateway-Aggregate-Failed ヘッダーで返す
+	Required bool `yaml:"required,omitempty"`
+}
+
+// MatchConfig はルートにマッチするリクエストの条件
+// 指定したホスト、全てのヘッダーとクエリパラメータの値が一致するリクエストのみマッチする
+// 同じパスで優先度（priority）が同じルートでは条件を満たすルートのうち条件の多いものを優先し、条件の無いルートは他に一致しない場合に使う
+type MatchConfig struct {
+	// Host はリクエストのホスト名（ポートを除き、大文字と小文字を区別しない）。"*.example.com" は1つ以上のラベルのサブドメインにマッチする
+	Host string `yaml:"host,omitempty"`
+	// Headers はリクエストヘッダーの名前と値（X-Version: "2"）。値は完全一致で比較する
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Query はクエリパラメータの名前と値（beta: "true"）。値は完全一致で比較する
+	Query map[string]string `yaml:"query,omitempty"`
+}
+
+// ConcurrencyQueueConfig は同時リクエスト数の上限に達したルートでリクエストを待たせる設定
+// 待っているリクエストは到着した順に、処理中のリクエストが終わった枠を引き継ぐ
+type ConcurrencyQueueConfig struct {
+	// MaxDepth は待たせるリクエスト数の上限。超えた分は Retry-After を付けて429を返す
+	MaxDepth int `yaml:"max_depth"`
+	// MaxWait は待つ時間の上限。過ぎた場合は Retry-After を付けて503を返す
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+// CanaryConfig はカナリアのバックエンドへの振り分けの設定
+// カナリアへのリクエストにも backend の timeout などの設定を使い、レスポンスはキャッシュしない
+type CanaryConfig struct {
+	// URL はカナリアのバックエンドのURL
+	URL string `yaml:"url"`
+	// Weight はカナリアへ送るリクエストの割合（0〜100のパーセント。0.01刻み）。管理API（/admin/routes/canary）で実行中に変更できる
+	Weight float64 `yaml:"weight"`
+	// StickyClaim は振り分けをユーザーごとに固定するJWTのクレーム（"sub" など）。省略時、またはクレームが無い場合はリクエストごとに振り分ける
+	StickyClaim string `yaml:"sticky_claim,omitempty"`
+}
+
+// WhenDisabledConfig はフィーチャーフラグが無効なルートへのリクエストの扱い
+// status と backend はどちらか一方を指定する
+type WhenDisabledConfig struct {
+	// Status は返すステータス（404, 503。省略時は404）
+	Status int `yaml:"status,omitempty"`
+	// Backend は代わりに転送するバックエンド（移行前のバックエンドなど）。ルートの canary と residency は適用しない
+	Backend *BackendConfig `yaml:"backend,omitempty"`
+}
+
+// ResponseMaskingConfig はレスポンスの個人情報のマスクの設定
+// JSONのレスポンスは fields と patterns、テキストのレスポンスは patterns でマスクする
+type ResponseMaskingConfig struct {
+	// Fields はマスクするJSONのフィールド（"user.email" のようにドットで区切る。配列は全ての要素が対象）
+	Fields []string `yaml:"fields,omitempty"`
+	// Patterns は文字列の値からマスクする部分のパターン
+	Patterns []MaskPatternConfig `yaml:"patterns,omitempty"`
+	// Replacement はマスクした値の置き換え後の文字列（省略時は "****"）
+	Replacement string `yaml:"replacement,omitempty"`
+	// MaxBody はマスクするボディの上限バイト数（省略時は10MiB。超えた場合は502を返す）
+	MaxBody int64 `yaml:"max_body,omitempty"`
+}
+
+// ContractConfig はルートのOpenAPIドキュメント（API契約）による検証の設定
+// ドキュメントのパスはバックエンドへ転送するパス（backend の url のパスとリクエストのパスをつないだもの）と照合し、記載の無いオペレーションは検証しない
+type ContractConfig struct {
+	// Spec はOpenAPI 3のドキュメント（YAMLまたはJSON）のパス。ルートの読み込み時に読み込む
+	Spec string `yaml:"spec"`
+	// Requests はクライアントのリクエスト（パラメータとボディ）の検証（off, audit, enforce）。省略時は off
+	// audit は違反をログとメトリクスに記録して転送し、enforce は違反したリクエストを転送せずに400（Problem Details）を返す
+	Requests string `yaml:"requests,omitempty"`
+	// Responses はバックエンドのレスポンスの検証（off, audit, enforce）。省略時は off
+	// audit は違反をログとメトリクスに記録してそのまま返し、enforce は違反したレスポンスの代わりに502を返す
+	Responses string `yaml:"responses,omitempty"`
+	// MaxBody は検証するリクエスト・レスポンスのボディの上限バイト数（省略時は10MiB。超えた場合 audit は検証せずに転送し、enforce はリクエストには413、レスポンスには502を返す）
+	MaxBody int64 `yaml:"max_body,omitempty"`
+}
+
+// MaskPatternConfig はマスクするパターンの設定
+type MaskPatternConfig struct {
+	// Name はメトリクスのラベルに使う名前。regex を省略した場合は組み込みのパターン（credit_card, email）を使う
+	Name string `yaml:"name"`
+	// Regex はマスクする部分の正規表現
+	Regex string `yaml:"regex,omitempty"`
+}
+
+// ResidencyConfig はデータレジデンシー（リージョンの固定）の設定
+// region・claim・header のうち指定したものの値が全て一致しない場合は 403 で拒否する
+type ResidencyConfig struct {
+	// Region はルートを固定するリージョン（省略時は claim や header で決める）
+	Region string `yaml:"region,omitempty"`
+	// Claim はテナントのリージョンを表すJWTのクレーム名（jwt ミドルウェアが必要）。指定した場合はクレームの無いトークンのリクエストを拒否する
+	Claim string `yaml:"claim,omitempty"`
+	// Header はクライアントが指定するリージョンのヘッダー名
+	Header string `yaml:"header,omitempty"`
+	// Backends はリージョンごとのバックエンドのURL（timeout などは backend の設定を使う）
+	Backends map[string]string `yaml:"backends"`
+}
+
+// HeaderRulesConfig はヘッダー変換ルールの設定
+// remove → set → add の順に適用する。値には {client_ip} などのプレースホルダーを使える
+type HeaderRulesConfig struct {
+	// Add は既存の値を残したまま追加するヘッダー
+	Add map[string]string `yaml:"add,omitempty"`
+	// Set は既存の値を置き換えるヘッダー
+	Set map[string]string `yaml:"set,omitempty"`
+	// Remove は削除するヘッダー名
+	Remove []string `yaml:"remove,omitempty"`
 }
 
 // BackendConfig はバックエンドの設定
 type BackendConfig struct {
 	URL     string        `yaml:"url"`
 	Timeout time.Duration `yaml:"timeout"`
+	// Service はサービスディスカバリに登録されたサービス名。指定した場合は url のホストの代わりに
+	// service_discovery から取得したインスタンスへラウンドロビンで転送する（url のスキームとパスはそのまま使う）
+	Service string `yaml:"service,omitempty"`
+	// Protocol はバックエンドとの通信プロトコル（"", http1, h2, h2c）
+	Protocol string `yaml:"protocol,omitempty"`
+	// ConnectionPool はバックエンドへの接続プールの設定（同じ protocol と設定のバックエンドで接続プールを共有する）
+	ConnectionPool ConnectionPoolConfig `yaml:"connection_pool,omitempty"`
+	// SignRequests は転送するリクエストに X-Gateway-Signature ヘッダーで署名するか（jwt.signing の鍵を使う）
+	SignRequests bool `yaml:"sign_requests,omitempty"`
+	// Retry はバックエンドが502/504を返した場合の再試行の設定
+	Retry RetryConfig `yaml:"retry,omitempty"`
+	// OAuth2 はバックエンドへのリクエストに付けるアクセストークンをクライアントクレデンシャルフローで取得する設定
+	OAuth2 *OAuth2ClientConfig `yaml:"oauth2,omitempty"`
+	// AWSSigV4 は転送するリクエストにAWS SigV4で署名する設定（API Gateway・Lambda関数URL・OpenSearchなど）
+	AWSSigV4 *AWSSigV4Config `yaml:"aws_sigv4,omitempty"`
+	// Shadow は移行先のバックエンドにも同じリクエストを送り、レスポンスの差分をログに記録する設定
+	Shadow *ShadowConfig `yaml:"shadow,omitempty"`
+	// Mirror はリクエストの複製をミラーのバックエンドへ非同期に送る設定（レスポンスは読み捨てる）
+	Mirror *MirrorConfig `yaml:"mirror,omitempty"`
+	// GRPC はバックエンドをgRPCのサービスとして転送する設定（HTTP/2で転送し、エラーは gRPC のステータスで返す）
+	GRPC *GRPCConfig `yaml:"grpc,omitempty"`
+	// DecompressResponses はクライアントが gzip を受け付けない場合に gzip のレスポンスを展開し、Content-Length を設定する
+	// max_response_body は展開後のサイズに適用する
+	DecompressResponses bool `yaml:"decompress_responses,omitempty"`
+	// ObjectStorage はS3互換のオブジェクトストレージのオブジェクトを配信する設定（url はバケットのURL）
+	ObjectStorage *ObjectStorageConfig `yaml:"object_storage,omitempty"`
+	// SOAP はJSONのリクエストをSOAPのエンベロープに変換してレガシーなバックエンドへ転送する設定
+	SOAP *SOAPConfig `yaml:"soap,omitempty"`
+	// LongPoll はロングポーリングのクライアントをSSE・WebSocketのバックエンドへ中継する設定（url はストリームのURL）
+	LongPoll *LongPollConfig `yaml:"long_poll,omitempty"`
+}
+
+// ConnectionPoolConfig はバックエンドへの接続プールの設定
+type ConnectionPoolConfig struct {
+	// MaxIdleConns はバックエンドのホストごとに保持するアイドル接続の上限（0は100）
+	MaxIdleConns int `yaml:"max_idle_conns,omitempty"`
+	// MaxConnsPerHost はバックエンドのホストごとの接続数の上限（0は無制限）。上限に達したリクエストは接続が空くまで待つ
+	MaxConnsPerHost int `yaml:"max_conns_per_host,omitempty"`
+	// IdleConnTimeout はアイドル接続を閉じるまでの時間（0は90秒）
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout,omitempty"`
+	// DisableKeepAlives はリクエストごとに接続を閉じるか（接続を再利用できないバックエンド向け）
+	DisableKeepAlives bool `yaml:"disable_keepalives,omitempty"`
+}
+
+// LongPollConfig はロングポーリングの中継の設定
+// ゲートウェイがバックエンドのストリームを購読し、最初のイベントを200で返すか、timeout までにイベントが無ければ204を返す
+type LongPollConfig struct {
+	// Protocol はバックエンドのプロトコル（sse, websocket）
+	Protocol string `yaml:"protocol"`
+	// Timeout はイベントを待つ時間（省略時は30秒）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// CursorParam はSSEの Last-Event-ID として送るクエリパラメータ（省略時は last_event_id）
+	CursorParam string `yaml:"cursor_param,omitempty"`
+	// ContentType はイベントを返すレスポンスのContent-Type（省略時は application/json）
+	ContentType string `yaml:"content_type,omitempty"`
+	// MaxMessage はイベント1件の上限バイト数（省略時は1MiB）
+	MaxMessage int64 `yaml:"max_message,omitempty"`
+}
+
+// GRPCConfig はgRPCのバックエンドの設定
+// ルートのパスは "/package.Service/*" のように gRPC のメソッドのパスに合わせる
+type GRPCConfig struct {
+	// MethodTimeouts はメソッドごとのタイムアウト（省略したメソッドはバックエンドの timeout）
+	// キーは "/package.Service/Method"、サービスの全てのメソッドに適用する場合は "/package.Service/*"
+	MethodTimeouts map[string]time.Duration `yaml:"method_timeouts,omitempty"`
+}
+
+// ObjectStorageConfig はオブジェクトストレージの配信の設定
+// ルートのパスのワイルドカード・パラメータ以降がオブジェクトのキーになる。バケットへの認証は aws_sigv4（service: s3）で行う
+type ObjectStorageConfig struct {
+	// Prefix はオブジェクトのキーの接頭辞（"public/" など）
+	Prefix string `yaml:"prefix,omitempty"`
+	// SignedURLSecret は署名付きURL（?expires=&signature=）の検証に使う鍵（省略時は署名付きURLを要求しない）
+	SignedURLSecret string `yaml:"signed_url_secret,omitempty"`
+}
+
+// SOAPConfig はSOAPのバックエンドの1つのオペレーションへ転送する設定（値はWSDLから転記する）
+type SOAPConfig struct {
+	// Version はSOAPのバージョン（1.1, 1.2。省略時は1.1）
+	Version string `yaml:"version,omitempty"`
+	// Operation はオペレーション名（リクエストの要素名）
+	Operation string `yaml:"operation"`
+	// Namespace はオペレーションの要素の名前空間（WSDLの targetNamespace）
+	Namespace string `yaml:"namespace"`
+	// Action はSOAPAction
+	Action string `yaml:"action,omitempty"`
+	// Template は soap:Body の中身のテンプレート（text/template。省略時はJSONのフィールドを子要素にする）
+	Template string `yaml:"template,omitempty"`
+	// MaxBody は変換するボディの上限バイト数（省略時は10MiB）
+	MaxBody int64 `yaml:"max_body,omitempty"`
+}
+
+// ShadowConfig はバックエンドの移行を検証するためのシャドー比較の設定
+// クライアントには url（移行元）のレスポンスを返し、移行先のレスポンスとの差分をログに記録する
+type ShadowConfig struct {
+	// URL は移行先のバックエンドのURL
+	URL string `yaml:"url"`
+	// Timeout は移行先へのリクエストのタイムアウト（省略時はバックエンドの timeout）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Methods は移行先にも送るメソッド（省略時は GET と HEAD）
+	Methods []string `yaml:"methods,omitempty"`
+	// IgnoreHeaders は比較しないレスポンスヘッダー（Date, Content-Length などは常に比較しない）
+	IgnoreHeaders []string `yaml:"ignore_headers,omitempty"`
+	// IgnoreFields は比較しないJSONボディのフィールド（"meta.request_id" のようにドットで区切る）
+	IgnoreFields []string `yaml:"ignore_fields,omitempty"`
+	// MaxBody は比較するボディの上限バイト数（省略時は1MiB）
+	MaxBody int64 `yaml:"max_body,omitempty"`
+}
+
+// MirrorConfig はミラーのバックエンドへのリクエストの複製の設定
+// 新しいバージョンのサービスに本番のトラフィックで負荷をかける用途に使う。ミラーの応答や障害はクライアントに影響しない
+// 送った件数は gateway_mirror_requests_total{route,result} で確認できる
+type MirrorConfig struct {
+	// URL はミラーのバックエンドのURL
+	URL string `yaml:"url"`
+	// Timeout はミラーへのリクエストのタイムアウト（省略時はバックエンドの timeout）
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Methods はミラーへ送るメソッド（省略時は全てのメソッド）
+	Methods []string `yaml:"methods,omitempty"`
+	// MaxBody はミラーへ送るリクエストボディの上限バイト数（省略時は1MiB）。超えるリクエストは送らない
+	MaxBody int64 `yaml:"max_body,omitempty"`
+	// MaxInFlight は同時にミラーへ送るリクエスト数の上限（省略時は100）。上限に達している間のリクエストは送らない
+	MaxInFlight int `yaml:"max_in_flight,omitempty"`
+}
+
+// AWSSigV4Config はAWS SigV4の署名の設定
+type AWSSigV4Config struct {
+	// Service は署名の対象のサービス名（execute-api, lambda, es, s3 など）
+	Service string `yaml:"service"`
+	Region  string `yaml:"region"`
+	// Credentials は署名に使う認証情報の取得元（省略時は環境変数）
+	Credentials AWSCredentialsConfig `yaml:"credentials,omitempty"`
+}
+
+// AWSCredentialsConfig はAWSの認証情報の取得元の設定
+type AWSCredentialsConfig struct {
+	// Provider は env（AWS_ACCESS_KEY_ID などの環境変数）または static（この設定の値）
+	Provider string `yaml:"provider,omitempty"`
+	// AccessKeyID などは ${AWS_ACCESS_KEY_ID} のように環境変数から設定する（provider が static の場合のみ）
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key,omitempty"`
+	SessionToken    string `yaml:"session_token,omitempty"`
+}
+
+// OAuth2ClientConfig はバックエンド認証に使うOAuth2クライアントの設定
+// 取得したトークンは有効期限まで使い回し、クライアントの Authorization ヘッダーを置き換えて転送する
+type OAuth2ClientConfig struct {
+	TokenURL string `yaml:"token_url"`
+	ClientID string `yaml:"client_id"`
+	// ClientSecret は ${BACKEND_CLIENT_SECRET} のように環境変数から設定する
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	Audience     string   `yaml:"audience,omitempty"`
+	// RefreshBefore は有効期限のどれだけ前にトークンを更新するか（デフォルト30s）
+	RefreshBefore time.Duration `yaml:"refresh_before,omitempty"`
+}
+
+// RetryConfig は再試行の設定
+// 再試行するのはボディの無い冪等なリクエスト（GET, HEAD, OPTIONS, PUT, DELETE）のみ
+type RetryConfig struct {
+	// Attempts は最初のリクエストに加えて再試行する最大回数（0は再試行しない）
+	Attempts int `yaml:"attempts,omitempty"`
+	// Budget はルートごとの再試行の予算
+	Budget RetryBudgetConfig `yaml:"budget,omitempty"`
+}
+
+// RetryBudgetConfig は再試行の予算の設定
+// 期間ごとに min_retries 回とリクエスト数の ratio 倍までの再試行を許可し、超えた場合は再試行せずに応答を返す
+type RetryBudgetConfig struct {
+	// Ratio はリクエスト数に対する再試行の割合の上限（デフォルト0.1）
+	Ratio float64 `yaml:"ratio,omitempty"`
+	// MinRetries はリクエスト数が少ない場合にも許可する再試行の回数（デフォルト3）
+	MinRetries int `yaml:"min_retries,omitempty"`
+	// Window は予算を数える期間（デフォルト10s）
+	Window time.Duration `yaml:"window,omitempty"`
 }
 
 // MiddlewareConfig はミドルウェアの設定
+// ルートの middleware には設定の代わりに middlewares に定義した名前を書ける
 type MiddlewareConfig struct {
 	Type   string         `yaml:"type"`
 	Config map[string]any `yaml:"config,omitempty"`
+
+	// ref は名前で参照している場合の定義名
+	ref string
+}
+
+// OpenAPIConfig はバックエンドのOpenAPIドキュメント集約の設定
+type OpenAPIConfig struct {
+	Title    string               `yaml:"title"`
+	Version  string               `yaml:"version"`
+	CacheTTL time.Duration        `yaml:"cache_ttl,omitempty"`
+	Groups   []OpenAPIGroupConfig `yaml:"groups,omitempty"`
+}
+
+// OpenAPIGroupConfig はルートグループごとのOpenAPIドキュメント取得先
+type OpenAPIGroupConfig struct {
+	Name    string `yaml:"name"`
+	SpecURL string `yaml:"spec_url"`
 }
 
 // RoutingFileConfig はルーティング設定ファイルの構造
 type RoutingFileConfig struct {
-	Routes []Route `yaml:"routes"`
+	// Middlewares は名前を付けたミドルウェアの定義（ルートの middleware から名前で参照する）
+	Middlewares map[string]MiddlewareConfig `yaml:"middlewares,omitempty"`
+	Routes      []Route                     `yaml:"routes"`
+	OpenAPI     OpenAPIConfig               `yaml:"openapi,omitempty"`
+}
+
+// validate はKubernetesからルートを読み込む設定を検証する
+func (k KubernetesRoutingConfig) validate() error {
+	if k.APIServer != "" {
+		u, err := url.Parse(k.APIServer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid routing kubernetes api_server: %s", k.APIServer)
+		}
+	}
+	for _, resource := range k.Resources {
+		if resource != "httproute" && resource != "ingress" {
+			return fmt.Errorf("invalid routing kubernetes resource: %s", resource)
+		}
+	}
+	if k.Timeout < 0 {
+		return fmt.Errorf("routing kubernetes timeout must be non-negative")
+	}
+	return nil
 }
 
 // LoadConfig は設定ファイルを読み込む
+// 値の ${VAR} / ${VAR:-default} は環境変数で置き換え、GATEWAY_ から始まる環境変数で個別に上書きできる
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -92,10 +870,15 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := decodeStrict(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// 秘密情報をYAMLに書かずに済むよう、GATEWAY_ から始まる環境変数で上書きする
+	if err := applyEnvOverrides(&cfg, os.Environ()); err != nil {
+		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
@@ -111,10 +894,14 @@ func LoadRoutingConfig(path string) (*RoutingFileConfig, error) {
 	}
 
 	var cfg RoutingFileConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := decodeStrict(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal routing config: %w", err)
 	}
 
+	if err := cfg.resolveMiddleware(); err != nil {
+		return nil, fmt.Errorf("invalid routing config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
@@ -132,8 +919,34 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("write_timeout must be positive")
 	}
 
-	if c.Routing.ConfigFile == "" {
-		return fmt.Errorf("routing config_file is required")
+	if err := c.validateListeners(); err != nil {
+		return err
+	}
+
+	if _, err := forwarded.NewTrustedProxies(c.Server.TrustedProxies); err != nil {
+		return err
+	}
+
+	if c.Server.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("max_concurrent_requests must not be negative")
+	}
+
+	switch c.Routing.Source {
+	case "", RoutingSourceFile:
+		if c.Routing.ConfigFile == "" {
+			return fmt.Errorf("routing config_file is required")
+		}
+	case RoutingSourceKubernetes:
+		if err := c.Routing.Kubernetes.validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid routing source: %s", c.Routing.Source)
+	}
+
+	validTrailingSlash := map[string]bool{"": true, "merge": true, "strict": true, "redirect": true}
+	if !validTrailingSlash[c.Routing.TrailingSlash] {
+		return fmt.Errorf("invalid routing trailing_slash: %s", c.Routing.TrailingSlash)
 	}
 
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
@@ -141,11 +954,33 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log level: %s", c.Logging.Level)
 	}
 
-	validFormats := map[string]bool{"json": true, "text": true}
+	validFormats := map[string]bool{"json": true, "text": true, "pretty": true}
 	if !validFormats[c.Logging.Format] {
 		return fmt.Errorf("invalid log format: %s", c.Logging.Format)
 	}
 
+	if c.Logging.Async.QueueSize < 0 {
+		return fmt.Errorf("logging async queue_size must not be negative")
+	}
+	if c.Logging.SlowRequestThreshold < 0 {
+		return fmt.Errorf("logging slow_request_threshold must not be negative")
+	}
+	if sampling := c.Logging.Sampling; sampling.Every < 0 || sampling.RateLimit < 0 || sampling.Interval < 0 {
+		return fmt.Errorf("logging sampling every, rate_limit and interval must not be negative")
+	}
+	if export := c.Logging.Export; export.Endpoint != "" || export.Protocol != "" {
+		if export.Protocol != "" && export.Protocol != "otlp" && export.Protocol != "loki" {
+			return fmt.Errorf("invalid logging export protocol: %s", export.Protocol)
+		}
+		u, err := url.Parse(export.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid logging export endpoint: %s", export.Endpoint)
+		}
+		if export.BatchSize < 0 || export.QueueSize < 0 || export.FlushInterval < 0 || export.Timeout < 0 {
+			return fmt.Errorf("logging export batch_size, queue_size, flush_interval and timeout must not be negative")
+		}
+	}
+
 	// Redis設定のバリデーション（オプション）
 	if c.Redis.Host != "" {
 		if c.Redis.DB < 0 {
@@ -163,12 +998,218 @@ func (c *Config) Validate() error {
 		if c.Redis.WriteTimeout < 0 {
 			return fmt.Errorf("redis write_timeout must be non-negative")
 		}
+		if c.Redis.RevokeCache.Size < 0 {
+			return fmt.Errorf("redis revoke_cache size must be non-negative")
+		}
+		if c.Redis.RevokeCache.TTL < 0 {
+			return fmt.Errorf("redis revoke_cache ttl must be non-negative")
+		}
+		if c.Redis.Keyspace.Version < 0 {
+			return fmt.Errorf("redis keyspace version must be non-negative")
+		}
+		if strings.ContainsAny(c.Redis.Keyspace.Namespace, ":*?[]\\") {
+			return fmt.Errorf("redis keyspace namespace must not contain ':' or glob characters")
+		}
+	}
+
+	// キャッシュ設定のバリデーション（オプション）
+	switch c.Cache.Store {
+	case "", "memory":
+	case "redis":
+		if c.Redis.Host == "" {
+			return fmt.Errorf("cache store redis requires redis host")
+		}
+	default:
+		return fmt.Errorf("invalid cache store: %s", c.Cache.Store)
+	}
+	if c.Cache.MaxEntries < 0 {
+		return fmt.Errorf("cache max_entries must be non-negative")
+	}
+
+	// JWKS設定のバリデーション（オプション）
+	if jwks := c.JWT.JWKS; jwks.URL != "" {
+		u, err := url.Parse(jwks.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid jwt jwks url: %s", jwks.URL)
+		}
+		if jwks.RefreshInterval < 0 || jwks.StaleTolerance < 0 || jwks.Timeout < 0 {
+			return fmt.Errorf("jwt jwks durations must be non-negative")
+		}
+	}
+
+	// 署名鍵設定のバリデーション（オプション）
+	if signing := c.JWT.Signing; len(signing.PrivateKeyFiles) > 0 || signing.ActiveKID != "" {
+		if _, ok := signing.PrivateKeyFiles[signing.ActiveKID]; !ok {
+			return fmt.Errorf("jwt signing active_kid must be one of private_key_files: %s", signing.ActiveKID)
+		}
+	}
+
+	// 管理APIのJWT認証は検証に使う鍵が必要で、検証のスキップは許可しない
+	if c.Admin.JWT.Enabled {
+		if c.JWT.SkipValidation {
+			return fmt.Errorf("admin jwt cannot be used with jwt skip_validation")
+		}
+		if len(c.JWT.PublicKeyFiles) == 0 && c.JWT.JWKS.URL == "" && len(c.JWT.Signing.PrivateKeyFiles) == 0 {
+			return fmt.Errorf("admin jwt requires jwt public_key_files, jwks or signing keys")
+		}
+	}
+
+	if c.Admin.BasicAuth.Enabled && len(c.Admin.BasicAuth.Users) == 0 {
+		return fmt.Errorf("admin basic_auth requires at least one user")
+	}
+
+	if c.Health.Timeout < 0 {
+		return fmt.Errorf("health timeout must be non-negative")
+	}
+	if c.Startup.Retries < 0 || c.Startup.Backoff < 0 || c.Startup.MaxBackoff < 0 || c.Startup.WaitTimeout < 0 {
+		return fmt.Errorf("startup retries, backoff, max_backoff and wait_timeout must be non-negative")
+	}
+	if active := c.Health.ActiveChecks; active.Enabled {
+		if active.Path != "" && !strings.HasPrefix(active.Path, "/") {
+			return fmt.Errorf("health active_checks path must start with /: %s", active.Path)
+		}
+		if active.Interval < 0 || active.Timeout < 0 {
+			return fmt.Errorf("health active_checks durations must be non-negative")
+		}
+		if active.UnhealthyThreshold < 0 || active.HealthyThreshold < 0 {
+			return fmt.Errorf("health active_checks thresholds must be non-negative")
+		}
+	}
+	if outlier := c.Health.OutlierDetection; outlier.Enabled {
+		if outlier.Interval < 0 || outlier.EjectionDuration < 0 {
+			return fmt.Errorf("health outlier_detection durations must be non-negative")
+		}
+		if outlier.MinRequests < 0 {
+			return fmt.Errorf("health outlier_detection min_requests must be non-negative")
+		}
+		if outlier.MaxErrorPercent < 0 || outlier.MaxErrorPercent > 100 || outlier.MaxTimeoutPercent < 0 || outlier.MaxTimeoutPercent > 100 {
+			return fmt.Errorf("health outlier_detection percentages must be between 0 and 100")
+		}
+	}
+
+	if c.Buffer.MemoryLimit < 0 {
+		return fmt.Errorf("buffer memory_limit must be non-negative")
+	}
+
+	if c.FeatureFlags.RefreshInterval < 0 {
+		return fmt.Errorf("feature_flags refresh_interval must be non-negative")
+	}
+
+	// サービスディスカバリ設定のバリデーション（オプション）
+	if discovery := c.ServiceDiscovery; discovery.Provider != "" {
+		switch discovery.Provider {
+		case DiscoveryConsul:
+			u, err := url.Parse(discovery.Consul.Address)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid service_discovery consul address: %s", discovery.Consul.Address)
+			}
+			if discovery.Consul.WaitTime < 0 {
+				return fmt.Errorf("service_discovery consul wait_time must be non-negative")
+			}
+		case DiscoveryEtcd:
+			if len(discovery.Etcd.Endpoints) == 0 {
+				return fmt.Errorf("service_discovery etcd endpoints are required")
+			}
+			for _, endpoint := range discovery.Etcd.Endpoints {
+				u, err := url.Parse(endpoint)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid service_discovery etcd endpoint: %s", endpoint)
+				}
+			}
+			if discovery.Etcd.PollInterval < 0 {
+				return fmt.Errorf("service_discovery etcd poll_interval must be non-negative")
+			}
+		default:
+			return fmt.Errorf("invalid service_discovery provider: %s", discovery.Provider)
+		}
+		if discovery.RetryInterval < 0 {
+			return fmt.Errorf("service_discovery retry_interval must be non-negative")
+		}
+	}
+
+	// ジャーナル設定のバリデーション（オプション）
+	if journal := c.Journal; journal.Enabled {
+		switch journal.Sink {
+		case "file":
+			if journal.File == "" {
+				return fmt.Errorf("journal sink file requires file")
+			}
+		case "s3":
+			u, err := url.Parse(journal.S3.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid journal s3 url: %s", journal.S3.URL)
+			}
+			if journal.S3.Region == "" {
+				return fmt.Errorf("journal s3 region is required")
+			}
+			if journal.S3.FlushInterval < 0 || journal.S3.MaxEntries < 0 {
+				return fmt.Errorf("journal s3 flush_interval and max_entries must be non-negative")
+			}
+		default:
+			return fmt.Errorf("invalid journal sink: %s", journal.Sink)
+		}
 	}
 
 	return nil
 }
 
+// validateListeners はリスナーの設定を検証する
+func (c *Config) validateListeners() error {
+	addresses := make(map[string]bool)
+	admin := false
+	for i, l := range c.Server.Listeners {
+		if l.Port <= 0 || l.Port > 65535 {
+			return fmt.Errorf("listeners[%d]: invalid port: %d", i, l.Port)
+		}
+		if addresses[l.Address()] {
+			return fmt.Errorf("listeners[%d]: duplicate address: %s", i, l.Address())
+		}
+		addresses[l.Address()] = true
+
+		for _, feature := range l.Serve {
+			if feature != ServeGateway && feature != ServeAdmin {
+				return fmt.Errorf("listeners[%d]: invalid serve: %s", i, feature)
+			}
+		}
+		if l.Serves(ServeAdmin) {
+			admin = true
+		}
+	}
+
+	if len(c.Server.Listeners) > 0 && c.Admin.Enabled && !admin {
+		return fmt.Errorf("admin is enabled but no listener serves admin")
+	}
+	return nil
+}
+
 // Address はサーバのアドレスを返す
 func (s *ServerConfig) Address() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
 }
+
+// ListenerConfigs は待ち受けるリスナーの一覧を返す
+// listeners を指定していない場合は host / port で全ての機能を提供する1つのリスナーを返す
+func (s *ServerConfig) ListenerConfigs() []ListenerConfig {
+	if len(s.Listeners) > 0 {
+		return s.Listeners
+	}
+	return []ListenerConfig{{
+		Name:  "default",
+		Host:  s.Host,
+		Port:  s.Port,
+		Serve: []string{ServeGateway, ServeAdmin},
+	}}
+}
+
+// Address はリスナーのアドレスを返す
+func (l ListenerConfig) Address() string {
+	return fmt.Sprintf("%s:%d", l.Host, l.Port)
+}
+
+// Serves はリスナーが機能を提供するか返す
+func (l ListenerConfig) Serves(feature string) bool {
+	if len(l.Serve) == 0 {
+		return feature == ServeGateway
+	}
+	return slices.Contains(l.Serve, feature)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -286,6 +287,643 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid cache store",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Cache: CacheConfig{Store: "disk"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "redis cache store without redis host",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Cache: CacheConfig{Store: "redis"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "redis cache store",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Redis: RedisConfig{Host: "localhost:6379"},
+				Cache: CacheConfig{Store: "redis"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative buffer memory limit",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Buffer: BufferConfig{MemoryLimit: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative feature flags refresh interval",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				FeatureFlags: FeatureFlagsConfig{RefreshInterval: -time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative redis revoke cache size",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Redis: RedisConfig{Host: "localhost:6379", RevokeCache: RevokeCacheConfig{Size: -1}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid redis keyspace namespace",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Redis: RedisConfig{Host: "localhost:6379", Keyspace: RedisKeyspaceConfig{Namespace: "gw:blue"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin jwt with jwks",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Admin: AdminConfig{Enabled: true, JWT: AdminJWTConfig{Enabled: true}},
+				JWT:   JWTConfig{JWKS: JWKSConfig{URL: "https://idp.example.com/jwks.json"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "admin jwt without keys",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Admin: AdminConfig{Enabled: true, JWT: AdminJWTConfig{Enabled: true}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin basic auth without users",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Admin: AdminConfig{Enabled: true, BasicAuth: AdminBasicAuthConfig{Enabled: true}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin jwt with skip validation",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Admin: AdminConfig{Enabled: true, JWT: AdminJWTConfig{Enabled: true}},
+				JWT:   JWTConfig{SkipValidation: true, PublicKeyFiles: map[string]string{"k1": "k1.pem"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "journal file sink",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Journal: JournalConfig{Enabled: true, Sink: "file", File: "journal.jsonl"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "journal file sink without file",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Journal: JournalConfig{Enabled: true, Sink: "file"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "journal s3 sink without region",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Journal: JournalConfig{Enabled: true, Sink: "s3", S3: JournalS3Config{URL: "https://journal.s3.amazonaws.com"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid journal sink",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Journal: JournalConfig{Enabled: true, Sink: "kafka"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid consul service discovery",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				ServiceDiscovery: ServiceDiscoveryConfig{Provider: "consul", Consul: ConsulDiscoveryConfig{Address: "http://127.0.0.1:8500"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "etcd service discovery without endpoints",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				ServiceDiscovery: ServiceDiscoveryConfig{Provider: "etcd"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid service discovery provider",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				ServiceDiscovery: ServiceDiscoveryConfig{Provider: "zookeeper"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "kubernetes routing without config file",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					Source:     "kubernetes",
+					Kubernetes: KubernetesRoutingConfig{Namespace: "apps", Resources: []string{"httproute"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid kubernetes routing resource",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					Source:     "kubernetes",
+					Kubernetes: KubernetesRoutingConfig{Resources: []string{"service"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative slow request threshold",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:                "info",
+					Format:               "json",
+					SlowRequestThreshold: -time.Second,
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid logging export endpoint",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+					Export: ExportLoggingConfig{Protocol: "loki", Endpoint: "loki:3100"},
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid routing source",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+					Source:     "consul",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "signing active kid not in private key files",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				JWT: JWTConfig{
+					Signing: SigningConfig{
+						ActiveKID:       "gw-2",
+						PrivateKeyFiles: map[string]string{"gw-1": "gw-1.pem"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid jwks url",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				JWT: JWTConfig{
+					JWKS: JWKSConfig{URL: "idp.example.com/jwks.json"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trailing slash policy",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile:    "routing.yaml",
+					TrailingSlash: "ignore",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trusted proxy",
+			config: Config{
+				Server: ServerConfig{
+					Port:           8080,
+					ReadTimeout:    30 * time.Second,
+					WriteTimeout:   30 * time.Second,
+					TrustedProxies: []string{"10.0.0.0/8", "not-an-ip"},
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid listeners",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+					Listeners: []ListenerConfig{
+						{Name: "public", Port: 8080, Groups: []string{"public"}},
+						{Name: "internal", Host: "127.0.0.1", Port: 9090, Serve: []string{ServeGateway, ServeAdmin}},
+					},
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Admin: AdminConfig{
+					Enabled: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "listeners with duplicate address",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+					Listeners: []ListenerConfig{
+						{Name: "public", Port: 8080},
+						{Name: "internal", Port: 8080},
+					},
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "listener with invalid serve",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+					Listeners: []ListenerConfig{
+						{Name: "public", Port: 8080, Serve: []string{"metrics"}},
+					},
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin enabled without admin listener",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+					Listeners: []ListenerConfig{
+						{Name: "public", Port: 8080},
+					},
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Admin: AdminConfig{
+					Enabled: true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative startup backoff",
+			config: Config{
+				Server: ServerConfig{
+					Port:         8080,
+					ReadTimeout:  30 * time.Second,
+					WriteTimeout: 30 * time.Second,
+				},
+				Logging: LoggingConfig{
+					Level:  "info",
+					Format: "json",
+				},
+				Routing: RoutingConfig{
+					ConfigFile: "routing.yaml",
+				},
+				Startup: StartupConfig{
+					Retries: 3,
+					Backoff: -time.Second,
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -340,6 +978,41 @@ func TestServerConfigAddress(t *testing.T) {
 	}
 }
 
+func TestServerConfigListenerConfigs(t *testing.T) {
+	t.Run("listeners未指定の場合はhost:portで全ての機能を提供する", func(t *testing.T) {
+		config := ServerConfig{Host: "0.0.0.0", Port: 8080}
+
+		listeners := config.ListenerConfigs()
+		if len(listeners) != 1 {
+			t.Fatalf("ListenerConfigs() returned %d listeners, want 1", len(listeners))
+		}
+		if got := listeners[0].Address(); got != "0.0.0.0:8080" {
+			t.Errorf("Address() = %s, want 0.0.0.0:8080", got)
+		}
+		if !listeners[0].Serves(ServeGateway) || !listeners[0].Serves(ServeAdmin) {
+			t.Errorf("default listener should serve gateway and admin, got %v", listeners[0].Serve)
+		}
+	})
+
+	t.Run("serve未指定のリスナーはgatewayのみ提供する", func(t *testing.T) {
+		config := ServerConfig{
+			Port:      8080,
+			Listeners: []ListenerConfig{{Name: "public", Port: 8081}},
+		}
+
+		listeners := config.ListenerConfigs()
+		if len(listeners) != 1 || listeners[0].Name != "public" {
+			t.Fatalf("ListenerConfigs() = %+v, want configured listeners", listeners)
+		}
+		if !listeners[0].Serves(ServeGateway) {
+			t.Error("listener should serve gateway")
+		}
+		if listeners[0].Serves(ServeAdmin) {
+			t.Error("listener should not serve admin")
+		}
+	})
+}
+
 func TestLoadRoutingConfig(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -417,3 +1090,40 @@ func TestLoadRoutingConfig_FileNotFound(t *testing.T) {
 		t.Error("LoadRoutingConfig() expected error for nonexistent file, got nil")
 	}
 }
+
+// FuzzLoadRoutingConfig は任意のYAMLで LoadRoutingConfig がパニックせず、短時間で終わることを確認する
+// エイリアスを使った指数的な展開（billion laughs）は yaml.v3 がエラーにする
+// go test -fuzz FuzzLoadRoutingConfig ./internal/config/
+func FuzzLoadRoutingConfig(f *testing.F) {
+	f.Add([]byte(`
+routes:
+  - path: "/api/v1/users"
+    methods: ["GET", "POST"]
+    backend:
+      url: "https://user-service.example.com"
+      timeout: 30s
+    priority: 10
+`))
+	f.Add([]byte(``))
+	f.Add([]byte(`routes: [{path: "${UNSET:-/x}", unknown: 1}]`))
+	f.Add([]byte(`routes: {a: b}`))
+	f.Add([]byte("a: &a [x, x, x, x, x, x, x, x, x]\nb: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]\nc: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]\nd: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]\nroutes: [*d, *d, *d, *d, *d, *d, *d, *d, *d]\n"))
+	f.Add([]byte(strings.Repeat("[", 10000)))
+
+	routingPath := filepath.Join(f.TempDir(), "routing.yaml")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := os.WriteFile(routingPath, data, 0644); err != nil {
+			t.Fatalf("failed to write test routing config: %v", err)
+		}
+
+		start := time.Now()
+		cfg, err := LoadRoutingConfig(routingPath)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("LoadRoutingConfig() took %v", elapsed)
+		}
+		if err == nil && cfg == nil {
+			t.Error("LoadRoutingConfig() returned nil config without error")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// configdoc は設定構造体のフィールドのコメントを読み取り、設定のリファレンス（config.Reference）で使う説明とデフォルトを生成する
+//
+// リファレンスの項目・型・環境変数は実行時にリフレクションで取得するが、コメントは実行時に参照できないため生成する。
+// internal/config で go generate を実行すると fielddocs_gen.go を更新する
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the config package")
+	out := flag.String("out", "fielddocs_gen.go", "output file name (relative to -dir)")
+	flag.Parse()
+
+	src, err := generate(*dir, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configdoc: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *out), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "configdoc: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fieldDoc は1つのフィールドの説明とデフォルト
+type fieldDoc struct {
+	key         string // "型名.フィールド名"
+	description string
+	defaultText string
+}
+
+// generate は dir のパッケージ（テストと out を除く）の構造体のフィールドのコメントから fieldDocs を定義するソースを生成する
+func generate(dir, out string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != out
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", dir, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var pkgName string
+	var docs []fieldDoc
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, file := range pkg.Files {
+			docs = append(docs, fileDocs(file)...)
+		}
+	}
+	slices.SortFunc(docs, func(a, b fieldDoc) int { return cmp.Compare(a.key, b.key) })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by configdoc; DO NOT EDIT.\n\npackage %s\n\n", pkgName)
+	b.WriteString("// fieldDocs は設定構造体のフィールドのコメント（\"型名.フィールド名\" → 説明とデフォルト）\n")
+	b.WriteString("var fieldDocs = map[string]fieldDoc{\n")
+	for _, doc := range docs {
+		if doc.defaultText == "" {
+			fmt.Fprintf(&b, "%q: {Description: %q},\n", doc.key, doc.description)
+			continue
+		}
+		fmt.Fprintf(&b, "%q: {Description: %q, Default: %q},\n", doc.key, doc.description, doc.defaultText)
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
+
+// fileDocs はファイル内の構造体の yaml タグのあるフィールドのうち、コメントのあるものを返す
+// コメントはフィールドの前の行（Doc）と行末（Comment）のどちらでもよい
+func fileDocs(file *ast.File) []fieldDoc {
+	var docs []fieldDoc
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		for _, field := range st.Fields.List {
+			// 設定ファイルの項目（yaml タグのあるフィールド）だけを対象にする
+			if field.Tag == nil || !strings.Contains(field.Tag.Value, `yaml:"`) {
+				continue
+			}
+			text := field.Doc.Text()
+			if text == "" {
+				text = field.Comment.Text()
+			}
+			description := strings.Join(strings.Fields(text), " ")
+			if description == "" {
+				continue
+			}
+			for _, name := range field.Names {
+				docs = append(docs, fieldDoc{
+					key:         spec.Name.Name + "." + name.Name,
+					description: description,
+					defaultText: extractDefault(description),
+				})
+			}
+		}
+		return false
+	})
+	return docs
+}
+
+// defaultPatterns はコメントの中でデフォルトを書く表現
+// 「（0は5分）」「（0の場合は5秒）」「（空は /health）」「（省略時は1分）」「（デフォルト: 0）」
+var defaultPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[（。](?:0|空)(?:の場合)?は\s*([^）。]+)`),
+	regexp.MustCompile(`省略時は\s*([^）。]+)`),
+	regexp.MustCompile(`デフォルト:\s*([^）。]+)`),
+}
+
+// extractDefault はコメントから省略した場合の値・挙動を取り出す（書かれていない場合は空）
+func extractDefault(description string) string {
+	for _, pattern := range defaultPatterns {
+		if m := pattern.FindStringSubmatch(description); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerate_InSync は生成済みの fielddocs_gen.go が設定構造体のコメントと一致していることを確認する
+// 失敗した場合は internal/config で go generate を実行する
+func TestGenerate_InSync(t *testing.T) {
+	dir := filepath.Join("..")
+	want, err := generate(dir, "fielddocs_gen.go")
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "fielddocs_gen.go"))
+	if err != nil {
+		t.Fatalf("failed to read fielddocs_gen.go: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("fielddocs_gen.go is out of date; run go generate ./internal/config")
+	}
+}
+
+func TestExtractDefault(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{name: "0は", description: "RefreshInterval は更新間隔（0は5分）", want: "5分"},
+		{name: "0の場合は", description: "TTL は保存期間（0の場合は5秒）。通知を受け取れなかった場合の最大の遅延になる", want: "5秒"},
+		{name: "空は", description: "Path は確認するパス（空は /health）", want: "/health"},
+		{name: "句点の後の空は", description: "Output は出力先（stdout, stderr またはファイルパス。空はstdout）", want: "stdout"},
+		{name: "省略時は", description: "FlushInterval はアップロードする間隔（省略時は1分）", want: "1分"},
+		{name: "デフォルト:", description: "ClockSkew は許容するずれ（デフォルト: 0）", want: "0"},
+		{name: "デフォルトの記載なし", description: "Host は接続先", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractDefault(tt.description); got != tt.want {
+				t.Errorf("extractDefault(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RoutingDiff はルーティング設定の再読み込み前後の差分
+// 変更されたフィールドは名前のみを記録し、値（クライアントシークレットなど）は含めない
+type RoutingDiff struct {
+	// Added は追加されたルート（"GET,POST /api/v1/users" の形式）
+	Added []string `json:"added"`
+	// Removed は削除されたルート
+	Removed []string `json:"removed"`
+	// Changed は設定が変更されたルート
+	Changed []RouteChange `json:"changed"`
+}
+
+// RouteChange は1つのルートの設定の変更
+type RouteChange struct {
+	Route string `json:"route"`
+	// Fields は変更されたフィールド（"backend.timeout" のようにYAMLのキーをドットで区切る）
+	Fields []string `json:"fields,omitempty"`
+	// MiddlewareAdded は追加されたミドルウェアの種類
+	MiddlewareAdded []string `json:"middleware_added,omitempty"`
+	// MiddlewareRemoved は削除されたミドルウェアの種類
+	MiddlewareRemoved []string `json:"middleware_removed,omitempty"`
+	// MiddlewareChanged は設定または順序が変更されたミドルウェアの種類
+	MiddlewareChanged []string `json:"middleware_changed,omitempty"`
+}
+
+// Empty は差分が無いか確認する
+func (d RoutingDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// LogValue は差分を構造化ログの属性として出力する
+func (d RoutingDiff) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("added", d.Added),
+		slog.Any("removed", d.Removed),
+		slog.Any("changed", d.Changed),
+	)
+}
+
+// ConfigDiff はゲートウェイの設定（gateway.yaml）の再読み込み前後の差分
+// RoutingDiff と同様に、変更されたフィールドは名前のみを記録する
+type ConfigDiff struct {
+	// Reloaded は再起動せずに反映したフィールド
+	Reloaded []string `json:"reloaded"`
+	// RestartRequired は変更されたが再起動するまで反映されないフィールド
+	RestartRequired []string `json:"restart_required"`
+}
+
+// ReloadableFields は再起動せずに反映できるフィールド（配下のフィールドを含む）
+// routing.config_file は source: kubernetes の場合は反映できない（ファイルのルートを監視の起点に使うため）
+var ReloadableFields = []string{"logging.level", "routing.config_file", "jwt.public_key_files"}
+
+// Empty は差分が無いか確認する
+func (d ConfigDiff) Empty() bool {
+	return len(d.Reloaded) == 0 && len(d.RestartRequired) == 0
+}
+
+// LogValue は差分を構造化ログの属性として出力する
+func (d ConfigDiff) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("reloaded", d.Reloaded),
+		slog.Any("restart_required", d.RestartRequired),
+	)
+}
+
+// DiffConfig はゲートウェイの設定の差分を求め、再起動せずに反映できるフィールドとそれ以外に分ける
+func DiffConfig(oldCfg, newCfg *Config) ConfigDiff {
+	// gateway.yaml のセクションの順に比較し、省略されたセクションも空のセクションとしてフィールドごとに記録する
+	var fields []string
+	oldValue, newValue := reflect.ValueOf(*oldCfg), reflect.ValueOf(*newCfg)
+	for i := range oldValue.NumField() {
+		name, _, _ := strings.Cut(oldValue.Type().Field(i).Tag.Get("yaml"), ",")
+		fields = append(fields, diffFields(name, toYAMLValue(oldValue.Field(i).Interface()), toYAMLValue(newValue.Field(i).Interface()))...)
+	}
+
+	var diff ConfigDiff
+	for _, field := range fields {
+		if reloadableField(field, newCfg) {
+			diff.Reloaded = append(diff.Reloaded, field)
+		} else {
+			diff.RestartRequired = append(diff.RestartRequired, field)
+		}
+	}
+	return diff
+}
+
+// reloadableField は field が再起動せずに反映できるフィールドか確認する
+func reloadableField(field string, cfg *Config) bool {
+	for _, reloadable := range ReloadableFields {
+		if field != reloadable && !strings.HasPrefix(field, reloadable+".") {
+			continue
+		}
+		return reloadable != "routing.config_file" || cfg.Routing.Source != RoutingSourceKubernetes
+	}
+	return false
+}
+
+// RouteKey はルートを識別するキー（メソッドとパス、match の条件）を返す
+func RouteKey(route Route) string {
+	methods := slices.Clone(route.Methods)
+	for i, method := range methods {
+		methods[i] = strings.ToUpper(method)
+	}
+	slices.Sort(methods)
+	if len(methods) == 0 {
+		methods = []string{"*"}
+	}
+	key := strings.Join(methods, ",") + " " + route.Path
+	if match := route.Match.String(); match != "" {
+		key += " " + match
+	}
+	return key
+}
+
+// DiffRoutingConfig はルーティング設定の差分を求める
+// ルートはメソッドとパスで対応付けるため、メソッドを変更したルートは削除と追加として扱う
+// oldCfg が nil の場合（起動時の読み込み）は全てのルートを追加として扱う
+func DiffRoutingConfig(oldCfg, newCfg *RoutingFileConfig) RoutingDiff {
+	oldRoutes := routesByKey(oldCfg)
+	newRoutes := routesByKey(newCfg)
+
+	var diff RoutingDiff
+	for _, key := range slices.Sorted(maps.Keys(newRoutes)) {
+		oldRoute, ok := oldRoutes[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+			continue
+		}
+		if change, changed := diffRoute(key, oldRoute, newRoutes[key]); changed {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(oldRoutes)) {
+		if _, ok := newRoutes[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	return diff
+}
+
+// routesByKey はルートをキーごとにまとめる
+func routesByKey(cfg *RoutingFileConfig) map[string]Route {
+	routes := make(map[string]Route)
+	if cfg == nil {
+		return routes
+	}
+	for _, route := range cfg.Routes {
+		routes[RouteKey(route)] = route
+	}
+	return routes
+}
+
+// diffRoute は同じキーのルートの設定を比較する
+func diffRoute(key string, oldRoute, newRoute Route) (RouteChange, bool) {
+	change := RouteChange{Route: key}
+
+	oldMiddleware, newMiddleware := oldRoute.Middleware, newRoute.Middleware
+	oldRoute.Middleware, newRoute.Middleware = nil, nil
+	change.Fields = diffFields("", toYAMLValue(oldRoute), toYAMLValue(newRoute))
+
+	oldByType, newByType := middlewareByType(oldMiddleware), middlewareByType(newMiddleware)
+	for _, name := range slices.Sorted(maps.Keys(newByType)) {
+		oldEntry, ok := oldByType[name]
+		switch {
+		case !ok:
+			change.MiddlewareAdded = append(change.MiddlewareAdded, name)
+		case oldEntry.index != newByType[name].index || !reflect.DeepEqual(toYAMLValue(oldEntry.config), toYAMLValue(newByType[name].config)):
+			change.MiddlewareChanged = append(change.MiddlewareChanged, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(oldByType)) {
+		if _, ok := newByType[name]; !ok {
+			change.MiddlewareRemoved = append(change.MiddlewareRemoved, name)
+		}
+	}
+
+	changed := len(change.Fields) > 0 || len(change.MiddlewareAdded) > 0 || len(change.MiddlewareRemoved) > 0 || len(change.MiddlewareChanged) > 0
+	return change, changed
+}
+
+type middlewareEntry struct {
+	index  int
+	config MiddlewareConfig
+}
+
+// middlewareByType はミドルウェアを種類ごとにまとめる（同じ種類が複数ある場合は "cors#2" のように番号を付ける）
+func middlewareByType(middleware []MiddlewareConfig) map[string]middlewareEntry {
+	entries := make(map[string]middlewareEntry, len(middleware))
+	counts := make(map[string]int)
+	for i, m := range middleware {
+		counts[m.Type]++
+		name := m.Type
+		if counts[m.Type] > 1 {
+			name = fmt.Sprintf("%s#%d", m.Type, counts[m.Type])
+		}
+		entries[name] = middlewareEntry{index: i, config: m}
+	}
+	return entries
+}
+
+// toYAMLValue は設定をYAMLのキーで比較できる値に変換する
+func toYAMLValue(v any) any {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// diffFields は2つの値を比較し、異なるフィールドのパスを返す
+// マップはキーごとに比較し、配列などそれ以外の値は全体で比較する
+func diffFields(prefix string, oldValue, newValue any) []string {
+	oldMap, oldIsMap := oldValue.(map[string]any)
+	newMap, newIsMap := newValue.(map[string]any)
+	if !oldIsMap || !newIsMap {
+		if reflect.DeepEqual(oldValue, newValue) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	keys := slices.Collect(maps.Keys(oldMap))
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var fields []string
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fields = append(fields, diffFields(path, oldMap[key], newMap[key])...)
+	}
+	return fields
+}
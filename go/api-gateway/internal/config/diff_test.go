@@ -0,0 +1,161 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffRoutingConfig(t *testing.T) {
+	users := Route{
+		Path:    "/api/v1/users",
+		Methods: []string{"POST", "get"},
+		Backend: BackendConfig{URL: "https://user-service.example.com", Timeout: 30 * time.Second},
+		Middleware: []MiddlewareConfig{
+			{Type: "jwt", Config: map[string]any{"required_claims": []any{"sub"}}},
+			{Type: "cors", Config: map[string]any{"allowed_origins": []any{"*"}}},
+		},
+		Priority: 10,
+	}
+	orders := Route{
+		Path:    "/api/v1/orders",
+		Methods: []string{"GET"},
+		Backend: BackendConfig{URL: "https://order-service.example.com"},
+	}
+
+	changedUsers := users
+	changedUsers.Backend.Timeout = 10 * time.Second
+	changedUsers.Backend.OAuth2 = &OAuth2ClientConfig{TokenURL: "https://idp.example.com/token", ClientID: "gateway", ClientSecret: "secret"}
+	changedUsers.Middleware = []MiddlewareConfig{
+		{Type: "jwt", Config: map[string]any{"required_claims": []any{"sub", "tenant"}}},
+		{Type: "timeout", Config: map[string]any{"timeout": "5s"}},
+	}
+
+	tests := []struct {
+		name   string
+		oldCfg *RoutingFileConfig
+		newCfg *RoutingFileConfig
+		want   RoutingDiff
+	}{
+		{
+			name:   "起動時は全てのルートが追加",
+			newCfg: &RoutingFileConfig{Routes: []Route{users, orders}},
+			want:   RoutingDiff{Added: []string{"GET /api/v1/orders", "GET,POST /api/v1/users"}},
+		},
+		{
+			name:   "変更なし",
+			oldCfg: &RoutingFileConfig{Routes: []Route{users, orders}},
+			newCfg: &RoutingFileConfig{Routes: []Route{orders, users}},
+		},
+		{
+			name:   "追加・削除・変更",
+			oldCfg: &RoutingFileConfig{Routes: []Route{users, orders}},
+			newCfg: &RoutingFileConfig{Routes: []Route{changedUsers, {Path: "/health", Methods: []string{"GET"}}}},
+			want: RoutingDiff{
+				Added:   []string{"GET /health"},
+				Removed: []string{"GET /api/v1/orders"},
+				Changed: []RouteChange{{
+					Route:             "GET,POST /api/v1/users",
+					Fields:            []string{"backend.oauth2", "backend.timeout"},
+					MiddlewareAdded:   []string{"timeout"},
+					MiddlewareRemoved: []string{"cors"},
+					MiddlewareChanged: []string{"jwt"},
+				}},
+			},
+		},
+		{
+			name:   "ミドルウェアの順序の変更",
+			oldCfg: &RoutingFileConfig{Routes: []Route{users}},
+			newCfg: &RoutingFileConfig{Routes: []Route{func() Route {
+				r := users
+				r.Middleware = []MiddlewareConfig{users.Middleware[1], users.Middleware[0]}
+				return r
+			}()}},
+			want: RoutingDiff{Changed: []RouteChange{{
+				Route:             "GET,POST /api/v1/users",
+				MiddlewareChanged: []string{"cors", "jwt"},
+			}}},
+		},
+		{
+			name:   "同じパスで match の条件が異なるルートは別のルート",
+			oldCfg: &RoutingFileConfig{Routes: []Route{orders}},
+			newCfg: &RoutingFileConfig{Routes: []Route{orders, func() Route {
+				r := orders
+				r.Match = &MatchConfig{Headers: map[string]string{"x-version": "2"}, Query: map[string]string{"beta": "true"}}
+				return r
+			}()}},
+			want: RoutingDiff{Added: []string{"GET /api/v1/orders [X-Version: 2] ?beta=true"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffRoutingConfig(tt.oldCfg, tt.newCfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffRoutingConfig() = %+v, want %+v", got, tt.want)
+			}
+			if got.Empty() != tt.want.Empty() {
+				t.Errorf("Empty() = %v, want %v", got.Empty(), tt.want.Empty())
+			}
+		})
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	base := Config{
+		Server:  ServerConfig{Port: 8080},
+		Logging: LoggingConfig{Level: "info", Format: "json"},
+		Routing: RoutingConfig{ConfigFile: "configs/routing.yaml"},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   ConfigDiff
+	}{
+		{
+			name:   "変更なし",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "ログレベル・ルーティング設定ファイル・公開鍵は再起動せずに反映する",
+			modify: func(cfg *Config) {
+				cfg.Logging.Level = "debug"
+				cfg.Routing.ConfigFile = "configs/routing.v2.yaml"
+				cfg.JWT.PublicKeyFiles = map[string]string{"idp-1": "/etc/gateway/keys/idp-1.pem"}
+			},
+			want: ConfigDiff{Reloaded: []string{"logging.level", "routing.config_file", "jwt.public_key_files"}},
+		},
+		{
+			name: "それ以外の変更は再起動が必要",
+			modify: func(cfg *Config) {
+				cfg.Server.Port = 9090
+				cfg.Logging.Format = "text"
+				cfg.Redis.Password = "secret"
+			},
+			want: ConfigDiff{RestartRequired: []string{"server.port", "logging.format", "redis.password"}},
+		},
+		{
+			name: "kubernetes ではルーティング設定ファイルの変更に再起動が必要",
+			modify: func(cfg *Config) {
+				cfg.Routing.ConfigFile = "configs/routing.v2.yaml"
+				cfg.Routing.Source = RoutingSourceKubernetes
+			},
+			want: ConfigDiff{RestartRequired: []string{"routing.config_file", "routing.source"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base
+			tt.modify(&next)
+			got := DiffConfig(&base, &next)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffConfig() = %+v, want %+v", got, tt.want)
+			}
+			if got.Empty() != tt.want.Empty() {
+				t.Errorf("Empty() = %v, want %v", got.Empty(), tt.want.Empty())
+			}
+		})
+	}
+}
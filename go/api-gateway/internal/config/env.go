@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix はゲートウェイ設定を上書きする環境変数のプレフィックス
+// 例: GATEWAY_REDIS_PASSWORD は redis.password、GATEWAY_SERVER_PORT は server.port を上書きする
+const EnvPrefix = "GATEWAY_"
+
+// expandEnv はスカラー値の ${VAR} と ${VAR:-default} を環境変数の値に置き換える
+// YAMLの構造を壊さないよう、解析後のノードの値のみを置き換える
+// 未設定の変数（デフォルト値なし）はエラーにする（秘密情報の設定漏れに気付けるようにする）
+func expandEnv(n *yaml.Node, lookup func(string) (string, bool)) error {
+	if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "${") {
+		value, err := expandString(n.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("line %d, column %d: %w", n.Line, n.Column, err)
+		}
+		n.Value = value
+		// 引用符の無い値は置き換え後の値で型を判定し直す（port: ${PORT} を数値として扱う）
+		if n.Style == 0 {
+			n.Tag = ""
+		}
+		return nil
+	}
+
+	for _, c := range n.Content {
+		if err := expandEnv(c, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandString は文字列中の ${VAR} と ${VAR:-default} を置き換える
+func expandString(s string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated environment variable reference: %q", s[start:])
+		}
+		end += start
+
+		b.WriteString(s[:start])
+		name, def, hasDefault := strings.Cut(s[start+2:end], ":-")
+		if name == "" {
+			return "", fmt.Errorf("empty environment variable reference")
+		}
+		value, ok := lookup(name)
+		switch {
+		case ok && value != "":
+			b.WriteString(value)
+		case hasDefault:
+			b.WriteString(def)
+		case ok:
+			// 空文字列が明示的に設定されている場合
+		default:
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		s = s[end+1:]
+	}
+}
+
+// applyEnvOverrides は GATEWAY_ から始まる環境変数で設定を上書きする
+// 変数名は yaml のキーを大文字にして "_" でつないだもの（GATEWAY_SERVER_SHUTDOWN_TIMEOUT など）
+// 文字列・数値・真偽値・時間・文字列のスライス（カンマ区切り）のフィールドのみ対象とし、
+// 設定に対応しない変数は他の用途の可能性があるため無視する
+func applyEnvOverrides(cfg *Config, environ []string) error {
+	root := reflect.ValueOf(cfg).Elem()
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, EnvPrefix) {
+			continue
+		}
+		if _, err := setEnvField(root, strings.TrimPrefix(key, EnvPrefix), value); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// setEnvField は path（大文字の yaml キーを "_" でつないだもの）に対応するフィールドに値を設定する
+// キー自体が "_" を含むため、一致する候補を順に試す
+func setEnvField(v reflect.Value, path, value string) (bool, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		name = strings.ToUpper(name)
+
+		field := v.Field(i)
+		switch {
+		case path == name:
+			if field.Kind() == reflect.Struct || field.Kind() == reflect.Map {
+				continue
+			}
+			return true, setScalar(field, value)
+		case strings.HasPrefix(path, name+"_") && field.Kind() == reflect.Struct:
+			ok, err := setEnvField(field, strings.TrimPrefix(path, name+"_"), value)
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// setScalar は文字列をフィールドの型に変換して設定する
+func setScalar(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type: %s", field.Type())
+		}
+		var items []string
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported field type: %s", field.Type())
+	}
+	return nil
+}
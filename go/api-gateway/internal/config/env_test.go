@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpandString(t *testing.T) {
+	env := map[string]string{"HOST": "redis", "PORT": "6379", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "変数なし", input: "localhost:6379", want: "localhost:6379"},
+		{name: "複数の変数", input: "${HOST}:${PORT}", want: "redis:6379"},
+		{name: "デフォルト値", input: "${MISSING:-fallback}", want: "fallback"},
+		{name: "空の変数はデフォルト値", input: "${EMPTY:-fallback}", want: "fallback"},
+		{name: "デフォルト値の無い空の変数", input: "${EMPTY}", want: ""},
+		{name: "$単体はそのまま", input: "pa$$word", want: "pa$$word"},
+		{name: "未設定の変数", input: "${MISSING}", wantErr: true},
+		{name: "閉じていない参照", input: "${HOST", wantErr: true},
+		{name: "空の参照", input: "${}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandString(tt.input, lookup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	cfg := &Config{}
+	cfg.Redis.Password = "from-yaml"
+
+	err := applyEnvOverrides(cfg, []string{
+		"GATEWAY_REDIS_PASSWORD=secret",
+		"GATEWAY_SERVER_PORT=9090",
+		"GATEWAY_SERVER_SHUTDOWN_TIMEOUT=15s",
+		"GATEWAY_HEALTH_ACTIVE_CHECKS_ENABLED=true",
+		"GATEWAY_CACHE_KEY_PREFIX=gw:cache:",
+		"GATEWAY_UNRELATED=ignored",
+		"PATH=/usr/bin",
+	})
+	if err != nil {
+		t.Fatalf("applyEnvOverrides() error = %v", err)
+	}
+
+	if cfg.Redis.Password != "secret" {
+		t.Errorf("Redis.Password = %q, want secret", cfg.Redis.Password)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("Server.Port = %d, want 9090", cfg.Server.Port)
+	}
+	if cfg.Server.ShutdownTimeout != 15*time.Second {
+		t.Errorf("Server.ShutdownTimeout = %v, want 15s", cfg.Server.ShutdownTimeout)
+	}
+	if !cfg.Health.ActiveChecks.Enabled {
+		t.Error("Health.ActiveChecks.Enabled = false, want true")
+	}
+	if cfg.Cache.KeyPrefix != "gw:cache:" {
+		t.Errorf("Cache.KeyPrefix = %q, want gw:cache:", cfg.Cache.KeyPrefix)
+	}
+
+	if err := applyEnvOverrides(cfg, []string{"GATEWAY_SERVER_PORT=not-a-number"}); err == nil {
+		t.Error("applyEnvOverrides() expected error for invalid value, got nil")
+	}
+}
+
+func TestLoadConfig_Env(t *testing.T) {
+	t.Setenv("TEST_GATEWAY_PORT", "8081")
+	t.Setenv("TEST_REDIS_HOST", "redis.internal:6379")
+	t.Setenv("GATEWAY_REDIS_PASSWORD", "s3cret")
+
+	content := `
+server:
+  host: "0.0.0.0"
+  port: ${TEST_GATEWAY_PORT}
+  read_timeout: 30s
+  write_timeout: 30s
+  shutdown_timeout: ${TEST_SHUTDOWN_TIMEOUT:-10s}
+
+logging:
+  level: "info"
+  format: "json"
+
+routing:
+  config_file: "routes.yaml"
+
+redis:
+  host: "${TEST_REDIS_HOST}"
+  password: "placeholder"
+`
+	configPath := filepath.Join(t.TempDir(), "gateway.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Server.Port != 8081 {
+		t.Errorf("Server.Port = %d, want 8081", cfg.Server.Port)
+	}
+	if cfg.Server.ShutdownTimeout != 10*time.Second {
+		t.Errorf("Server.ShutdownTimeout = %v, want 10s", cfg.Server.ShutdownTimeout)
+	}
+	if cfg.Redis.Host != "redis.internal:6379" {
+		t.Errorf("Redis.Host = %q, want redis.internal:6379", cfg.Redis.Host)
+	}
+	if cfg.Redis.Password != "s3cret" {
+		t.Errorf("Redis.Password = %q, want s3cret", cfg.Redis.Password)
+	}
+
+	// 未設定の変数はエラー
+	if err := os.WriteFile(configPath, []byte("server:\n  host: ${TEST_UNSET_VARIABLE}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("LoadConfig() expected error for unset variable, got nil")
+	}
+}
@@ -0,0 +1,232 @@
+// Code generated by configdoc; DO NOT EDIT.
+
+package config
+
+// fieldDocs は設定構造体のフィールドのコメント（"型名.フィールド名" → 説明とデフォルト）
+var fieldDocs = map[string]fieldDoc{
+	"AWSCredentialsConfig.AccessKeyID":           {Description: "AccessKeyID などは ${AWS_ACCESS_KEY_ID} のように環境変数から設定する（provider が static の場合のみ）"},
+	"AWSCredentialsConfig.Provider":              {Description: "Provider は env（AWS_ACCESS_KEY_ID などの環境変数）または static（この設定の値）"},
+	"AWSSigV4Config.Credentials":                 {Description: "Credentials は署名に使う認証情報の取得元（省略時は環境変数）", Default: "環境変数"},
+	"AWSSigV4Config.Service":                     {Description: "Service は署名の対象のサービス名（execute-api, lambda, es, s3 など）"},
+	"ActiveHealthCheckConfig.HealthyThreshold":   {Description: "HealthyThreshold は正常に戻す連続成功回数（0は2）", Default: "2"},
+	"ActiveHealthCheckConfig.Interval":           {Description: "Interval は確認の間隔（0は10秒）", Default: "10秒"},
+	"ActiveHealthCheckConfig.Path":               {Description: "Path はバックエンドのホストに対して確認するパス（空は /health）", Default: "/health"},
+	"ActiveHealthCheckConfig.Timeout":            {Description: "Timeout は1回の確認のタイムアウト（0は2秒）", Default: "2秒"},
+	"ActiveHealthCheckConfig.UnhealthyThreshold": {Description: "UnhealthyThreshold は異常とみなす連続失敗回数（0は3）", Default: "3"},
+	"AdminBasicAuthConfig.Enabled":               {Description: "Enabled は Authorization: Basic での管理APIへのアクセスを許可するか"},
+	"AdminBasicAuthConfig.Realm":                 {Description: "Realm は WWW-Authenticate で返す realm（空は \"Restricted\"）", Default: "\"Restricted\""},
+	"AdminBasicAuthConfig.Users":                 {Description: "Users はユーザー名とパスワードの bcrypt ハッシュ（htpasswd -nbB <user> <password> で生成できる）"},
+	"AdminConfig.BasicAuth":                      {Description: "BasicAuth はAPIキーの代わりに、Basic認証でのアクセスを許可する設定"},
+	"AdminConfig.Enabled":                        {Description: "Enabled は /admin 配下の管理APIを公開するか APIキーは環境変数 ADMIN_API_KEY（カンマ区切りで複数指定可）と ADMIN_API_KEY_FILE から読み込む"},
+	"AdminConfig.JWT":                            {Description: "JWT はAPIキーの代わりに、管理者ロールを持つJWTでのアクセスを許可する設定"},
+	"AdminJWTConfig.Enabled":                     {Description: "Enabled は Authorization: Bearer のJWTでの管理APIへのアクセスを許可するか"},
+	"AdminJWTConfig.Role":                        {Description: "Role は必要なロール（roles, role クレームまたは scope, scp クレームに含まれること。空は \"admin\"）", Default: "\"admin\""},
+	"AggregateBackendConfig.Key":                 {Description: "Key はまとめたJSONでレスポンスを格納するキー"},
+	"AggregateBackendConfig.Required":            {Description: "Required は失敗した場合にリクエスト全体を失敗（502、期限切れは504）にするか 省略時は null を格納し、失敗したキーを X-Gateway-Aggregate-Failed ヘッダーで返す", Default: "null を格納し、失敗したキーを X-Gateway-Aggregate-Failed ヘッダーで返す"},
+	"AggregateBackendConfig.URL":                 {Description: "URL は呼び出すURL。{name} はルートのパスパラメータの値に置き換え、クライアントのクエリを付けてGETで呼び出す"},
+	"AggregateConfig.Backends":                   {Description: "Backends は並行に呼び出すバックエンド"},
+	"AggregateConfig.Timeout":                    {Description: "Timeout は全てのバックエンドの呼び出しをまとめた期限（省略時は10秒）", Default: "10秒"},
+	"AsyncLoggingConfig.QueueSize":               {Description: "QueueSize は書き込み待ちのログの上限（0はデフォルト）。一杯の場合は古いログから捨てる", Default: "デフォルト"},
+	"AuditConfig.Enabled":                        {Description: "Enabled はtrueの場合、監査イベントを通常のログとは別に出力する"},
+	"AuditConfig.Output":                         {Description: "Output は出力先（stdout, stderr またはファイルパス。空はstdout）", Default: "stdout"},
+	"BackendConfig.AWSSigV4":                     {Description: "AWSSigV4 は転送するリクエストにAWS SigV4で署名する設定（API Gateway・Lambda関数URL・OpenSearchなど）"},
+	"BackendConfig.ConnectionPool":               {Description: "ConnectionPool はバックエンドへの接続プールの設定（同じ protocol と設定のバックエンドで接続プールを共有する）"},
+	"BackendConfig.DecompressResponses":          {Description: "DecompressResponses はクライアントが gzip を受け付けない場合に gzip のレスポンスを展開し、Content-Length を設定する max_response_body は展開後のサイズに適用する"},
+	"BackendConfig.GRPC":                         {Description: "GRPC はバックエンドをgRPCのサービスとして転送する設定（HTTP/2で転送し、エラーは gRPC のステータスで返す）"},
+	"BackendConfig.LongPoll":                     {Description: "LongPoll はロングポーリングのクライアントをSSE・WebSocketのバックエンドへ中継する設定（url はストリームのURL）"},
+	"BackendConfig.Mirror":                       {Description: "Mirror はリクエストの複製をミラーのバックエンドへ非同期に送る設定（レスポンスは読み捨てる）"},
+	"BackendConfig.OAuth2":                       {Description: "OAuth2 はバックエンドへのリクエストに付けるアクセストークンをクライアントクレデンシャルフローで取得する設定"},
+	"BackendConfig.ObjectStorage":                {Description: "ObjectStorage はS3互換のオブジェクトストレージのオブジェクトを配信する設定（url はバケットのURL）"},
+	"BackendConfig.Protocol":                     {Description: "Protocol はバックエンドとの通信プロトコル（\"\", http1, h2, h2c）"},
+	"BackendConfig.Retry":                        {Description: "Retry はバックエンドが502/504を返した場合の再試行の設定"},
+	"BackendConfig.SOAP":                         {Description: "SOAP はJSONのリクエストをSOAPのエンベロープに変換してレガシーなバックエンドへ転送する設定"},
+	"BackendConfig.Service":                      {Description: "Service はサービスディスカバリに登録されたサービス名。指定した場合は url のホストの代わりに service_discovery から取得したインスタンスへラウンドロビンで転送する（url のスキームとパスはそのまま使う）"},
+	"BackendConfig.Shadow":                       {Description: "Shadow は移行先のバックエンドにも同じリクエストを送り、レスポンスの差分をログに記録する設定"},
+	"BackendConfig.SignRequests":                 {Description: "SignRequests は転送するリクエストに X-Gateway-Signature ヘッダーで署名するか（jwt.signing の鍵を使う）"},
+	"BufferConfig.MemoryLimit":                   {Description: "MemoryLimit はメモリに保持する上限バイト数（0は1MiB）。超えた分は一時ファイルへ書き出す", Default: "1MiB"},
+	"BufferConfig.TempDir":                       {Description: "TempDir は一時ファイルのディレクトリ（空はOSのデフォルト）", Default: "OSのデフォルト"},
+	"CacheConfig.KeyPrefix":                      {Description: "KeyPrefix は redis の場合のキープレフィックス"},
+	"CacheConfig.MaxEntries":                     {Description: "MaxEntries は memory の場合の最大エントリ数"},
+	"CacheConfig.Store":                          {Description: "Store は保存先（memory, redis）。redis の場合は redis.host の設定が必要"},
+	"CanaryConfig.StickyClaim":                   {Description: "StickyClaim は振り分けをユーザーごとに固定するJWTのクレーム（\"sub\" など）。省略時、またはクレームが無い場合はリクエストごとに振り分ける"},
+	"CanaryConfig.URL":                           {Description: "URL はカナリアのバックエンドのURL"},
+	"CanaryConfig.Weight":                        {Description: "Weight はカナリアへ送るリクエストの割合（0〜100のパーセント。0.01刻み）。管理API（/admin/routes/canary）で実行中に変更できる"},
+	"ConcurrencyQueueConfig.MaxDepth":            {Description: "MaxDepth は待たせるリクエスト数の上限。超えた分は Retry-After を付けて429を返す"},
+	"ConcurrencyQueueConfig.MaxWait":             {Description: "MaxWait は待つ時間の上限。過ぎた場合は Retry-After を付けて503を返す"},
+	"Config.ServiceDiscovery":                    {Description: "ServiceDiscovery はルートの backend.service で参照するサービスのインスタンスを取得する登録先の設定"},
+	"ConnectionPoolConfig.DisableKeepAlives":     {Description: "DisableKeepAlives はリクエストごとに接続を閉じるか（接続を再利用できないバックエンド向け）"},
+	"ConnectionPoolConfig.IdleConnTimeout":       {Description: "IdleConnTimeout はアイドル接続を閉じるまでの時間（0は90秒）", Default: "90秒"},
+	"ConnectionPoolConfig.MaxConnsPerHost":       {Description: "MaxConnsPerHost はバックエンドのホストごとの接続数の上限（0は無制限）。上限に達したリクエストは接続が空くまで待つ", Default: "無制限"},
+	"ConnectionPoolConfig.MaxIdleConns":          {Description: "MaxIdleConns はバックエンドのホストごとに保持するアイドル接続の上限（0は100）", Default: "100"},
+	"ConsulDiscoveryConfig.Address":              {Description: "Address はConsulのHTTP APIのURL"},
+	"ConsulDiscoveryConfig.Datacenter":           {Description: "Datacenter は参照するデータセンター（空はエージェントのデータセンター）", Default: "エージェントのデータセンター"},
+	"ConsulDiscoveryConfig.Token":                {Description: "Token はACLトークン"},
+	"ConsulDiscoveryConfig.WaitTime":             {Description: "WaitTime はブロッキングクエリでカタログの変更を待つ時間（0は5分）", Default: "5分"},
+	"ContractConfig.MaxBody":                     {Description: "MaxBody は検証するリクエスト・レスポンスのボディの上限バイト数（省略時は10MiB。超えた場合 audit は検証せずに転送し、enforce はリクエストには413、レスポンスには502を返す）", Default: "10MiB"},
+	"ContractConfig.Requests":                    {Description: "Requests はクライアントのリクエスト（パラメータとボディ）の検証（off, audit, enforce）。省略時は off audit は違反をログとメトリクスに記録して転送し、enforce は違反したリクエストを転送せずに400（Problem Details）を返す", Default: "off audit は違反をログとメトリクスに記録して転送し、enforce は違反したリクエストを転送せずに400（Problem Details"},
+	"ContractConfig.Responses":                   {Description: "Responses はバックエンドのレスポンスの検証（off, audit, enforce）。省略時は off audit は違反をログとメトリクスに記録してそのまま返し、enforce は違反したレスポンスの代わりに502を返す", Default: "off audit は違反をログとメトリクスに記録してそのまま返し、enforce は違反したレスポンスの代わりに502を返す"},
+	"ContractConfig.Spec":                        {Description: "Spec はOpenAPI 3のドキュメント（YAMLまたはJSON）のパス。ルートの読み込み時に読み込む"},
+	"EtcdDiscoveryConfig.Endpoints":              {Description: "Endpoints はetcdのクライアントURL"},
+	"EtcdDiscoveryConfig.PollInterval":           {Description: "PollInterval は取得し直す間隔（0は10秒）", Default: "10秒"},
+	"EtcdDiscoveryConfig.Prefix":                 {Description: "Prefix はキーの接頭辞（空は \"/services/\"）", Default: "\"/services/\""},
+	"ExportLoggingConfig.BatchSize":              {Description: "BatchSize は1回のリクエストで送信する最大のログ数（0は512）", Default: "512"},
+	"ExportLoggingConfig.Endpoint":               {Description: "Endpoint は送信先のURL（http://otel-collector:4318/v1/logs、http://loki:3100/loki/api/v1/push など）"},
+	"ExportLoggingConfig.FlushInterval":          {Description: "FlushInterval はバッファしたログを送信する間隔（0は2秒）", Default: "2秒"},
+	"ExportLoggingConfig.Headers":                {Description: "Headers は送信するリクエストに付与するヘッダー（認証トークンは ${LOG_EXPORT_TOKEN} のように環境変数から設定する）"},
+	"ExportLoggingConfig.Labels":                 {Description: "Labels はOTLPのリソース属性（Lokiではストリームのラベル）に追加する値（env: production など）"},
+	"ExportLoggingConfig.Protocol":               {Description: "Protocol は送信するプロトコル（otlp: OTLP/HTTPのJSONエンコーディング, loki: Loki push API）。デフォルトは otlp"},
+	"ExportLoggingConfig.QueueSize":              {Description: "QueueSize は送信を待つログ数の上限（0は8192）。送信先の障害時に超えた場合は新しいログを捨てる", Default: "8192"},
+	"ExportLoggingConfig.ServiceName":            {Description: "ServiceName はOTLPのリソース属性 service.name（Lokiでは service_name ラベル）。デフォルトは api-gateway"},
+	"ExportLoggingConfig.Timeout":                {Description: "Timeout は1回の送信のタイムアウト（0は10秒）", Default: "10秒"},
+	"FeatureFlagsConfig.File":                    {Description: "File はフラグの値を読み込むYAMLファイル（flags を上書きする）。refresh_interval ごとに読み直す"},
+	"FeatureFlagsConfig.Flags":                   {Description: "Flags はフラグの値（フラグ名 → true/false）"},
+	"FeatureFlagsConfig.RefreshInterval":         {Description: "RefreshInterval は file を読み直す間隔（0は10秒）", Default: "10秒"},
+	"GRPCConfig.MethodTimeouts":                  {Description: "MethodTimeouts はメソッドごとのタイムアウト（省略したメソッドはバックエンドの timeout） キーは \"/package.Service/Method\"、サービスの全てのメソッドに適用する場合は \"/package.Service/*\""},
+	"HeaderRulesConfig.Add":                      {Description: "Add は既存の値を残したまま追加するヘッダー"},
+	"HeaderRulesConfig.Remove":                   {Description: "Remove は削除するヘッダー名"},
+	"HeaderRulesConfig.Set":                      {Description: "Set は既存の値を置き換えるヘッダー"},
+	"HealthConfig.ActiveChecks":                  {Description: "ActiveChecks はバックエンドを定期的に確認し、異常なバックエンドへの転送を止める設定"},
+	"HealthConfig.CheckBackends":                 {Description: "CheckBackends はtrueの場合、/readyz でバックエンドへ接続できるかも確認する"},
+	"HealthConfig.OutlierDetection":              {Description: "OutlierDetection は実際のリクエストで5xxやタイムアウトの多いバックエンドを一定時間除外する設定"},
+	"HealthConfig.Timeout":                       {Description: "Timeout は依存先ごとの確認のタイムアウト（0は2秒）", Default: "2秒"},
+	"JWKSConfig.RefreshInterval":                 {Description: "RefreshInterval はCache-Controlのmax-ageが無い場合の更新間隔（0は5分） 鍵セットに無いkidのトークンを受け取った場合は、間隔を待たずに再取得する（30秒に1回まで）", Default: "5分"},
+	"JWKSConfig.StaleTolerance":                  {Description: "StaleTolerance はIdPの障害時に期限切れの鍵を使い続ける猶予（0は1時間）", Default: "1時間"},
+	"JWKSConfig.Timeout":                         {Description: "Timeout は1回の取得のタイムアウト（0は10秒）", Default: "10秒"},
+	"JWKSConfig.URL":                             {Description: "URL はJWKSエンドポイント（空の場合は使用しない）", Default: "使用しない"},
+	"JWTConfig.JWKS":                             {Description: "JWKS はIdPのJWKSエンドポイントから公開鍵を取得する設定"},
+	"JWTConfig.PublicKeyFiles":                   {Description: "PublicKeyFiles は公開鍵ファイルのパス (kid → ファイルパス)"},
+	"JWTConfig.Signing":                          {Description: "Signing はゲートウェイが発行するトークンの署名鍵の設定"},
+	"JWTConfig.SkipValidation":                   {Description: "SkipValidation は検証をスキップするか（開発環境用）"},
+	"JournalConfig.File":                         {Description: "File はジャーナルのファイルパス（sink: file）"},
+	"JournalConfig.S3":                           {Description: "S3 はS3互換のオブジェクトストレージの設定（sink: s3）"},
+	"JournalConfig.Sink":                         {Description: "Sink は出力先（file, s3）。file はエントリを fsync してからレスポンスを完了し（同時のリクエストは1回の fsync にまとめる）、 s3 はバッファして一定間隔でアップロードするため、異常終了時にアップロード前のエントリが失われる"},
+	"JournalS3Config.Credentials":                {Description: "Credentials は署名に使う認証情報の取得元（省略時は環境変数）", Default: "環境変数"},
+	"JournalS3Config.FlushInterval":              {Description: "FlushInterval はバッファしたエントリをアップロードする間隔（省略時は1分）", Default: "1分"},
+	"JournalS3Config.LegalHold":                  {Description: "LegalHold はアップロードしたオブジェクトに S3 Object Lock のリーガルホールドを設定するか（バケットでObject Lockの有効化が必要）"},
+	"JournalS3Config.MaxEntries":                 {Description: "MaxEntries は1つのオブジェクトに含める最大のエントリ数（省略時は1000）", Default: "1000"},
+	"JournalS3Config.URL":                        {Description: "URL はバケットのURL（https://bucket.s3.ap-northeast-1.amazonaws.com など）"},
+	"KubernetesRoutingConfig.APIServer":          {Description: "APIServer はAPIサーバのURL（空はPod内の環境変数 KUBERNETES_SERVICE_HOST / KUBERNETES_SERVICE_PORT）", Default: "Pod内の環境変数 KUBERNETES_SERVICE_HOST / KUBERNETES_SERVICE_PORT"},
+	"KubernetesRoutingConfig.CAFile":             {Description: "CAFile はAPIサーバの証明書を検証するCA証明書のファイル（空はPodにマウントされたCA証明書）", Default: "PodにマウントされたCA証明書"},
+	"KubernetesRoutingConfig.ClusterDomain":      {Description: "ClusterDomain はサービスのDNS名のドメイン（空は \"cluster.local\"）", Default: "\"cluster.local\""},
+	"KubernetesRoutingConfig.GatewayName":        {Description: "GatewayName は HTTPRoute の parentRefs で参照されている場合のみ読み込む Gateway の名前（空は全ての HTTPRoute）", Default: "全ての HTTPRoute"},
+	"KubernetesRoutingConfig.IngressClass":       {Description: "IngressClass は読み込む Ingress のクラス（spec.ingressClassName。空は \"api-gateway\"）", Default: "\"api-gateway\""},
+	"KubernetesRoutingConfig.Namespace":          {Description: "Namespace は監視する名前空間（空は全ての名前空間）", Default: "全ての名前空間"},
+	"KubernetesRoutingConfig.Resources":          {Description: "Resources は監視するリソース（httproute, ingress。省略時は両方）", Default: "両方"},
+	"KubernetesRoutingConfig.Timeout":            {Description: "Timeout はバックエンドのタイムアウトのデフォルト（0は30秒）。HTTPRoute の timeouts.request で上書きできる", Default: "30秒"},
+	"KubernetesRoutingConfig.TokenFile":          {Description: "TokenFile はサービスアカウントのトークンのファイル（空はPodにマウントされたトークン。client-go が定期的に読み直す）", Default: "Podにマウントされたトークン"},
+	"ListenerConfig.Groups":                      {Description: "Groups はこのリスナーで公開するルートのグループ（省略時は全てのルート）", Default: "全てのルート"},
+	"ListenerConfig.Middleware":                  {Description: "Middleware は middleware を指定していないルートに適用するミドルウェア（ルーティング設定の middlewares の名前でも指定できる） ゲートウェイのルートにのみ適用し、/admin（管理API）には適用しない。管理APIのBasic認証は admin.basic_auth で設定する"},
+	"ListenerConfig.Serve":                       {Description: "Serve はこのリスナーで提供する機能（gateway, admin）。省略時は gateway のみ", Default: "gateway のみ"},
+	"LoggingConfig.Async":                        {Description: "Async はゲートウェイのアクセスログをバックグラウンドで書き込む設定"},
+	"LoggingConfig.Export":                       {Description: "Export は標準出力に加えてログをOTLPコレクターまたはLokiに直接送信する設定（endpoint が空の場合は送信しない）"},
+	"LoggingConfig.Format":                       {Description: "json, text, pretty"},
+	"LoggingConfig.Level":                        {Description: "debug, info, warn, error"},
+	"LoggingConfig.Sampling":                     {Description: "Sampling はゲートウェイのアクセスログとリクエストのログを間引く設定"},
+	"LoggingConfig.SensitiveHeaders":             {Description: "SensitiveHeaders は Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-API-Key に加えて、ログで値を伏せるヘッダー"},
+	"LoggingConfig.SlowRequestThreshold":         {Description: "SlowRequestThreshold はこの時間以上かかったリクエストをWARNで記録し gateway_slow_requests_total に数える（0は無効）", Default: "無効"},
+	"LongPollConfig.ContentType":                 {Description: "ContentType はイベントを返すレスポンスのContent-Type（省略時は application/json）", Default: "application/json"},
+	"LongPollConfig.CursorParam":                 {Description: "CursorParam はSSEの Last-Event-ID として送るクエリパラメータ（省略時は last_event_id）", Default: "last_event_id"},
+	"LongPollConfig.MaxMessage":                  {Description: "MaxMessage はイベント1件の上限バイト数（省略時は1MiB）", Default: "1MiB"},
+	"LongPollConfig.Protocol":                    {Description: "Protocol はバックエンドのプロトコル（sse, websocket）"},
+	"LongPollConfig.Timeout":                     {Description: "Timeout はイベントを待つ時間（省略時は30秒）", Default: "30秒"},
+	"MaskPatternConfig.Name":                     {Description: "Name はメトリクスのラベルに使う名前。regex を省略した場合は組み込みのパターン（credit_card, email）を使う"},
+	"MaskPatternConfig.Regex":                    {Description: "Regex はマスクする部分の正規表現"},
+	"MatchConfig.Headers":                        {Description: "Headers はリクエストヘッダーの名前と値（X-Version: \"2\"）。値は完全一致で比較する"},
+	"MatchConfig.Host":                           {Description: "Host はリクエストのホスト名（ポートを除き、大文字と小文字を区別しない）。\"*.example.com\" は1つ以上のラベルのサブドメインにマッチする"},
+	"MatchConfig.Query":                          {Description: "Query はクエリパラメータの名前と値（beta: \"true\"）。値は完全一致で比較する"},
+	"MirrorConfig.MaxBody":                       {Description: "MaxBody はミラーへ送るリクエストボディの上限バイト数（省略時は1MiB）。超えるリクエストは送らない", Default: "1MiB"},
+	"MirrorConfig.MaxInFlight":                   {Description: "MaxInFlight は同時にミラーへ送るリクエスト数の上限（省略時は100）。上限に達している間のリクエストは送らない", Default: "100"},
+	"MirrorConfig.Methods":                       {Description: "Methods はミラーへ送るメソッド（省略時は全てのメソッド）", Default: "全てのメソッド"},
+	"MirrorConfig.Timeout":                       {Description: "Timeout はミラーへのリクエストのタイムアウト（省略時はバックエンドの timeout）", Default: "バックエンドの timeout"},
+	"MirrorConfig.URL":                           {Description: "URL はミラーのバックエンドのURL"},
+	"OAuth2ClientConfig.ClientSecret":            {Description: "ClientSecret は ${BACKEND_CLIENT_SECRET} のように環境変数から設定する"},
+	"OAuth2ClientConfig.RefreshBefore":           {Description: "RefreshBefore は有効期限のどれだけ前にトークンを更新するか（デフォルト30s）"},
+	"ObjectStorageConfig.Prefix":                 {Description: "Prefix はオブジェクトのキーの接頭辞（\"public/\" など）"},
+	"ObjectStorageConfig.SignedURLSecret":        {Description: "SignedURLSecret は署名付きURL（?expires=&signature=）の検証に使う鍵（省略時は署名付きURLを要求しない）", Default: "署名付きURLを要求しない"},
+	"OutlierDetectionConfig.EjectionDuration":    {Description: "EjectionDuration は除外する時間（0は30秒）", Default: "30秒"},
+	"OutlierDetectionConfig.Interval":            {Description: "Interval は割合を集計する期間（0は10秒）", Default: "10秒"},
+	"OutlierDetectionConfig.MaxErrorPercent":     {Description: "MaxErrorPercent は除外する5xxの割合（0〜100のパーセント。0は50）", Default: "50"},
+	"OutlierDetectionConfig.MaxTimeoutPercent":   {Description: "MaxTimeoutPercent は除外するタイムアウトの割合（0〜100のパーセント。0は50）", Default: "50"},
+	"OutlierDetectionConfig.MinRequests":         {Description: "MinRequests は判定に必要な期間内のリクエスト数（0は10）", Default: "10"},
+	"PortalConfig.Enabled":                       {Description: "Enabled は /docs で開発者ポータルを公開するか ポータルは認証なしで統合済みOpenAPIドキュメントを返すため、公開範囲に注意する"},
+	"PortalConfig.Title":                         {Description: "Title はポータルのページタイトル"},
+	"RedisConfig.KeyPrefix":                      {Description: "Revoke情報のキープレフィックス"},
+	"RedisConfig.Keyspace":                       {Description: "Keyspace は全てのキー（失効時刻、OIDCのセッション、レスポンスキャッシュ）の名前空間"},
+	"RedisConfig.RevokeCache":                    {Description: "RevokeCache は revoke ミドルウェアが失効時刻を保持するローカルキャッシュの設定"},
+	"RedisKeyspaceConfig.Namespace":              {Description: "Namespace はデプロイメントの名前（空の場合は付けない）", Default: "付けない"},
+	"RedisKeyspaceConfig.Version":                {Description: "Version はキーの形式のバージョン（0の場合は付けない）", Default: "付けない"},
+	"ResidencyConfig.Backends":                   {Description: "Backends はリージョンごとのバックエンドのURL（timeout などは backend の設定を使う）"},
+	"ResidencyConfig.Claim":                      {Description: "Claim はテナントのリージョンを表すJWTのクレーム名（jwt ミドルウェアが必要）。指定した場合はクレームの無いトークンのリクエストを拒否する"},
+	"ResidencyConfig.Header":                     {Description: "Header はクライアントが指定するリージョンのヘッダー名"},
+	"ResidencyConfig.Region":                     {Description: "Region はルートを固定するリージョン（省略時は claim や header で決める）", Default: "claim や header で決める"},
+	"ResponseMaskingConfig.Fields":               {Description: "Fields はマスクするJSONのフィールド（\"user.email\" のようにドットで区切る。配列は全ての要素が対象）"},
+	"ResponseMaskingConfig.MaxBody":              {Description: "MaxBody はマスクするボディの上限バイト数（省略時は10MiB。超えた場合は502を返す）", Default: "10MiB"},
+	"ResponseMaskingConfig.Patterns":             {Description: "Patterns は文字列の値からマスクする部分のパターン"},
+	"ResponseMaskingConfig.Replacement":          {Description: "Replacement はマスクした値の置き換え後の文字列（省略時は \"****\"）", Default: "\"****\""},
+	"RetryBudgetConfig.MinRetries":               {Description: "MinRetries はリクエスト数が少ない場合にも許可する再試行の回数（デフォルト3）"},
+	"RetryBudgetConfig.Ratio":                    {Description: "Ratio はリクエスト数に対する再試行の割合の上限（デフォルト0.1）"},
+	"RetryBudgetConfig.Window":                   {Description: "Window は予算を数える期間（デフォルト10s）"},
+	"RetryConfig.Attempts":                       {Description: "Attempts は最初のリクエストに加えて再試行する最大回数（0は再試行しない）", Default: "再試行しない"},
+	"RetryConfig.Budget":                         {Description: "Budget はルートごとの再試行の予算"},
+	"RevokeCacheConfig.Size":                     {Description: "Size はキャッシュするユーザー数の上限（0の場合はキャッシュしない）", Default: "キャッシュしない"},
+	"RevokeCacheConfig.TTL":                      {Description: "TTL はキャッシュの保存期間（0の場合は5秒）。通知を受け取れなかった場合の最大の遅延になる", Default: "5秒"},
+	"Route.Aggregate":                            {Description: "Aggregate は複数のバックエンドを並行に呼び出し、JSONのレスポンスを1つにまとめて返す設定（backend とはどちらか一方を指定する）"},
+	"Route.Canary":                               {Description: "Canary はリクエストの一部をカナリアのバックエンドへ振り分ける設定（residency とは併用できない）"},
+	"Route.ConcurrencyQueue":                     {Description: "ConcurrencyQueue は max_concurrent_requests に達した場合に、すぐに断らずリクエストを待たせる設定"},
+	"Route.Contract":                             {Description: "Contract はルートのOpenAPIドキュメント（API契約）でリクエストとバックエンドのレスポンスを検証する設定"},
+	"Route.EnabledWhen":                          {Description: "EnabledWhen はルートを公開する条件のフィーチャーフラグ（\"flags.new_checkout\"、否定は \"!flags.new_checkout\"）"},
+	"Route.Group":                                {Description: "Group はOpenAPIドキュメント集約時に所属するグループ名"},
+	"Route.Journal":                              {Description: "Journal はリクエストのメタデータをジャーナルへ記録するか（gateway.yaml の journal の設定が必要）"},
+	"Route.Match":                                {Description: "Match はパス以外にリクエストが満たす条件（ヘッダーとクエリパラメータ）。同じパスで条件の異なるルートを定義できる"},
+	"Route.MaxConcurrentRequests":                {Description: "MaxConcurrentRequests はルートで同時にバックエンドへ転送するリクエスト数の上限（0は無制限） 上限に達している間のリクエストには Retry-After を付けて429を返す（concurrency_queue を設定した場合は待たせる）", Default: "無制限"},
+	"Route.MaxRequestBody":                       {Description: "MaxRequestBody はリクエストボディの上限バイト数（0は無制限）", Default: "無制限"},
+	"Route.MaxResponseBody":                      {Description: "MaxResponseBody はバックエンドのレスポンスボディの上限バイト数（0は無制限）", Default: "無制限"},
+	"Route.Middleware":                           {Description: "Middleware はルートに適用するミドルウェア（記述した順に実行し、名前の参照はその位置に展開する。cors は常に最も外側で適用する）"},
+	"Route.Priority":                             {Description: "Priority はパスが重なるルートの優先度（値が大きいほど優先する）。同じ優先度ではパスの具体的なルート（静的なセグメント > 正規表現 > :param > ワイルドカード）を優先する 優先度とパラメータ名以外のパスと match が同じルートは、どちらを使うか決められないためエラーになる"},
+	"Route.RequestHeaders":                       {Description: "RequestHeaders はバックエンドへ転送するリクエストヘッダーの変換ルール"},
+	"Route.Residency":                            {Description: "Residency はリクエストをリージョンごとのバックエンドへ振り分け、リージョンをまたぐ転送を拒否する設定"},
+	"Route.Response":                             {Description: "Response はバックエンドへ転送せずにゲートウェイが返す固定のレスポンス（backend とはどちらか一方を指定する）"},
+	"Route.ResponseHeaders":                      {Description: "ResponseHeaders はクライアントへ返すレスポンスヘッダーの変換ルール"},
+	"Route.ResponseMasking":                      {Description: "ResponseMasking はレスポンスに含まれる個人情報（PII）をマスクする設定"},
+	"Route.TrailingSlash":                        {Description: "TrailingSlash は末尾スラッシュの扱い（merge, strict, redirect）。空は routing.trailing_slash に従う", Default: "routing.trailing_slash に従う"},
+	"Route.WhenDisabled":                         {Description: "WhenDisabled はフラグが無効な場合の応答（省略時は404）", Default: "404"},
+	"RoutingConfig.ConfigFile":                   {Description: "ConfigFile はルーティング設定ファイル（source: kubernetes では省略でき、指定した場合は middlewares などとルートを引き継ぐ）"},
+	"RoutingConfig.Kubernetes":                   {Description: "Kubernetes は source: kubernetes の設定"},
+	"RoutingConfig.Source":                       {Description: "Source はルートの読み込み元（file, kubernetes。空は file） kubernetes はクラスタの HTTPRoute・Ingress を監視してルートに変換し、変更を再起動せずに反映する", Default: "file"},
+	"RoutingConfig.TrailingSlash":                {Description: "TrailingSlash は末尾スラッシュの扱いのデフォルト（merge, strict, redirect）。ルートごとに上書きできる"},
+	"RoutingFileConfig.Middlewares":              {Description: "Middlewares は名前を付けたミドルウェアの定義（ルートの middleware から名前で参照する）"},
+	"SOAPConfig.Action":                          {Description: "Action はSOAPAction"},
+	"SOAPConfig.MaxBody":                         {Description: "MaxBody は変換するボディの上限バイト数（省略時は10MiB）", Default: "10MiB"},
+	"SOAPConfig.Namespace":                       {Description: "Namespace はオペレーションの要素の名前空間（WSDLの targetNamespace）"},
+	"SOAPConfig.Operation":                       {Description: "Operation はオペレーション名（リクエストの要素名）"},
+	"SOAPConfig.Template":                        {Description: "Template は soap:Body の中身のテンプレート（text/template。省略時はJSONのフィールドを子要素にする）", Default: "JSONのフィールドを子要素にする"},
+	"SOAPConfig.Version":                         {Description: "Version はSOAPのバージョン（1.1, 1.2。省略時は1.1）", Default: "1.1"},
+	"SamplingLoggingConfig.Every":                {Description: "Every は成功したリクエストのアクセスログとINFO以下のログを every 件に1件だけ記録する（0・1は全て記録） ステータスコードが400以上のアクセスログとWARN以上のログは間引かない"},
+	"SamplingLoggingConfig.Interval":             {Description: "Interval は数える期間（0は1秒）", Default: "1秒"},
+	"SamplingLoggingConfig.RateLimit":            {Description: "RateLimit は interval あたりに記録する件数の上限（0は無制限）。エラーを含む全てのログに適用する", Default: "無制限"},
+	"ServerConfig.Listeners":                     {Description: "Listeners は待ち受けるアドレスごとの設定。指定した場合は host / port の代わりに使う"},
+	"ServerConfig.MaxConcurrentRequests":         {Description: "MaxConcurrentRequests は全てのリスナーで同時にバックエンドへ転送するリクエスト数の上限（0は無制限） 上限に達している間のリクエストには Retry-After を付けて503を返す", Default: "無制限"},
+	"ServerConfig.TrustedProxies":                {Description: "TrustedProxies は X-Forwarded-For / X-Forwarded-Proto を信頼する前段のプロキシ（IPアドレスまたはCIDR） それ以外の接続元から受け取ったこれらのヘッダーは削除する"},
+	"ServiceDiscoveryConfig.Consul":              {Description: "Consul はConsulのカタログの設定（provider: consul）"},
+	"ServiceDiscoveryConfig.Etcd":                {Description: "Etcd はetcdの設定（provider: etcd）"},
+	"ServiceDiscoveryConfig.Provider":            {Description: "Provider は登録先（consul, etcd。空は無効）", Default: "無効"},
+	"ServiceDiscoveryConfig.RetryInterval":       {Description: "RetryInterval は取得に失敗した場合に再試行するまでの間隔（0は5秒）。失敗している間は直前のインスタンスを使う", Default: "5秒"},
+	"ShadowConfig.IgnoreFields":                  {Description: "IgnoreFields は比較しないJSONボディのフィールド（\"meta.request_id\" のようにドットで区切る）"},
+	"ShadowConfig.IgnoreHeaders":                 {Description: "IgnoreHeaders は比較しないレスポンスヘッダー（Date, Content-Length などは常に比較しない）"},
+	"ShadowConfig.MaxBody":                       {Description: "MaxBody は比較するボディの上限バイト数（省略時は1MiB）", Default: "1MiB"},
+	"ShadowConfig.Methods":                       {Description: "Methods は移行先にも送るメソッド（省略時は GET と HEAD）", Default: "GET と HEAD"},
+	"ShadowConfig.Timeout":                       {Description: "Timeout は移行先へのリクエストのタイムアウト（省略時はバックエンドの timeout）", Default: "バックエンドの timeout"},
+	"ShadowConfig.URL":                           {Description: "URL は移行先のバックエンドのURL"},
+	"SigningConfig.ActiveKID":                    {Description: "ActiveKID は署名に使う鍵のkid"},
+	"SigningConfig.PrivateKeyFiles":              {Description: "PrivateKeyFiles は秘密鍵ファイルのパス (kid → ファイルパス)"},
+	"StartupConfig.Backoff":                      {Description: "Backoff は最初の再試行までの待ち時間（0は1秒）。再試行ごとに倍にする", Default: "1秒"},
+	"StartupConfig.MaxBackoff":                   {Description: "MaxBackoff は再試行の待ち時間の上限（0は30秒）", Default: "30秒"},
+	"StartupConfig.Retries":                      {Description: "Retries は依存先ごとの再試行回数（0は再試行しない）", Default: "再試行しない"},
+	"StartupConfig.WaitTimeout":                  {Description: "WaitTimeout は --wait-for-deps で依存先を待つ時間の上限（0は無制限）", Default: "無制限"},
+	"StaticResponseConfig.Body":                  {Description: "Body はレスポンスボディ（file とはどちらか一方を指定する）"},
+	"StaticResponseConfig.File":                  {Description: "File はレスポンスボディにするファイル。ルートの読み込み時（ホットリロードを含む）に読み込む"},
+	"StaticResponseConfig.Headers":               {Description: "Headers はレスポンスヘッダー。Content-Type を省略した場合は file の拡張子かボディの内容から決める"},
+	"StaticResponseConfig.Status":                {Description: "Status はステータスコード（省略時は200）", Default: "200"},
+	"WhenDisabledConfig.Backend":                 {Description: "Backend は代わりに転送するバックエンド（移行前のバックエンドなど）。ルートの canary と residency は適用しない"},
+	"WhenDisabledConfig.Status":                  {Description: "Status は返すステータス（404, 503。省略時は404）", Default: "404"},
+}
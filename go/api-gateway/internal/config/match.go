@@ -0,0 +1,40 @@
+package config
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// String は条件を "api.example.com [X-Version: 2] ?beta=true" の形式で返す（名前の順に並べるため、同じ条件は同じ文字列になる）
+// ホスト名は小文字に、ヘッダー名は正規化する
+func (m *MatchConfig) String() string {
+	if m == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, 3)
+	if m.Host != "" {
+		parts = append(parts, strings.ToLower(m.Host))
+	}
+	if len(m.Headers) > 0 {
+		headers := make(map[string]string, len(m.Headers))
+		for name, value := range m.Headers {
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+		pairs := make([]string, 0, len(headers))
+		for _, name := range slices.Sorted(maps.Keys(headers)) {
+			pairs = append(pairs, name+": "+headers[name])
+		}
+		parts = append(parts, "["+strings.Join(pairs, ", ")+"]")
+	}
+	if len(m.Query) > 0 {
+		pairs := make([]string, 0, len(m.Query))
+		for _, name := range slices.Sorted(maps.Keys(m.Query)) {
+			pairs = append(pairs, name+"="+m.Query[name])
+		}
+		parts = append(parts, "?"+strings.Join(pairs, "&"))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML はミドルウェアの設定を読み込む
+// 文字列（middleware: [auth, ratelimit-strict]）はルーティング設定の middlewares に定義した名前への参照として扱う
+func (m *MiddlewareConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		if value.Value == "" {
+			return fmt.Errorf("line %d: middleware reference must not be empty", value.Line)
+		}
+		*m = MiddlewareConfig{ref: value.Value}
+		return nil
+	}
+
+	// plain は UnmarshalYAML を持たない型（再帰呼び出しを避ける）
+	type plain MiddlewareConfig
+	return value.Decode((*plain)(m))
+}
+
+// Ref は名前で参照している場合の定義名を返す（ResolveMiddleware で展開した後は空）
+func (m MiddlewareConfig) Ref() string {
+	return m.ref
+}
+
+// MiddlewareRef は middlewares に定義した name を参照するミドルウェアを返す（ResolveMiddleware で展開する）
+// ルーティング設定ファイル以外から読み込んだルートで名前の参照を使う場合に使う
+func MiddlewareRef(name string) MiddlewareConfig {
+	return MiddlewareConfig{ref: name}
+}
+
+// resolveMiddleware はルートの名前による参照を middlewares の定義に展開する
+func (c *RoutingFileConfig) resolveMiddleware() error {
+	for name, def := range c.Middlewares {
+		if def.ref != "" {
+			return fmt.Errorf("middleware definition %q must not reference another definition", name)
+		}
+		if def.Type == "" {
+			return fmt.Errorf("middleware definition %q has no type", name)
+		}
+	}
+
+	for i := range c.Routes {
+		resolved, err := c.ResolveMiddleware(c.Routes[i].Middleware)
+		if err != nil {
+			return fmt.Errorf("route %s: %w", c.Routes[i].Path, err)
+		}
+		c.Routes[i].Middleware = resolved
+	}
+	return nil
+}
+
+// ResolveMiddleware は名前による参照を middlewares の定義に展開したミドルウェアのリストを返す
+// 参照はリストの同じ位置に展開するため、ミドルウェアは記述した順に実行される
+// 同じ定義を1つのリストで2回参照した場合はエラーにする（認証などが二重に実行されるのを防ぐ）
+func (c *RoutingFileConfig) ResolveMiddleware(middlewares []MiddlewareConfig) ([]MiddlewareConfig, error) {
+	// 省略（nil）と空のリストはリスナーのデフォルトを適用するかどうかが異なるため、そのまま返す
+	if len(middlewares) == 0 {
+		return middlewares, nil
+	}
+
+	resolved := make([]MiddlewareConfig, 0, len(middlewares))
+	referenced := make(map[string]bool)
+	for _, m := range middlewares {
+		if m.ref == "" {
+			resolved = append(resolved, m)
+			continue
+		}
+		def, ok := c.Middlewares[m.ref]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", m.ref)
+		}
+		if referenced[m.ref] {
+			return nil, fmt.Errorf("middleware %q is referenced more than once", m.ref)
+		}
+		referenced[m.ref] = true
+		resolved = append(resolved, def)
+	}
+	return resolved, nil
+}
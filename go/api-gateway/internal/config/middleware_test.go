@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRoutingConfig_MiddlewareReferences(t *testing.T) {
+	const definitions = `
+middlewares:
+  auth:
+    type: jwt
+    config:
+      required_claims: ["sub"]
+  ratelimit-strict:
+    type: timeout
+    config:
+      timeout: 1s
+`
+
+	tests := []struct {
+		name      string
+		content   string
+		wantTypes [][]string
+		wantErr   string
+	}{
+		{
+			name: "名前の参照を記述した位置に展開する",
+			content: definitions + `
+routes:
+  - path: /a
+    backend: {url: "http://a"}
+    middleware: [ratelimit-strict, {type: cors}, auth]
+  - path: /b
+    backend: {url: "http://b"}
+`,
+			wantTypes: [][]string{{"timeout", "cors", "jwt"}, nil},
+		},
+		{
+			name: "未定義の名前",
+			content: definitions + `
+routes:
+  - path: /a
+    backend: {url: "http://a"}
+    middleware: [auth, unknown]
+`,
+			wantErr: `route /a: unknown middleware "unknown"`,
+		},
+		{
+			name: "同じ定義を2回参照する",
+			content: definitions + `
+routes:
+  - path: /a
+    backend: {url: "http://a"}
+    middleware: [auth, auth]
+`,
+			wantErr: `middleware "auth" is referenced more than once`,
+		},
+		{
+			name: "定義から別の定義を参照する",
+			content: `
+middlewares:
+  auth: jwt
+routes: []
+`,
+			wantErr: `middleware definition "auth" must not reference another definition`,
+		},
+		{
+			name: "定義の未知のフィールド",
+			content: `
+middlewares:
+  auth:
+    typ: jwt
+routes: []
+`,
+			wantErr: `unknown field "typ"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "routing.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadRoutingConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRoutingConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRoutingConfig() error = %v", err)
+			}
+
+			for i, want := range tt.wantTypes {
+				route := cfg.Routes[i]
+				if want == nil {
+					if route.Middleware != nil {
+						t.Errorf("route %s: middleware = %v, want nil", route.Path, route.Middleware)
+					}
+					continue
+				}
+				got := make([]string, 0, len(route.Middleware))
+				for _, m := range route.Middleware {
+					if m.Ref() != "" {
+						t.Errorf("route %s: reference %q is not resolved", route.Path, m.Ref())
+					}
+					got = append(got, m.Type)
+				}
+				if strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("route %s: middleware = %v, want %v", route.Path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoutingFileConfig_ResolveMiddleware(t *testing.T) {
+	cfg := &RoutingFileConfig{Middlewares: map[string]MiddlewareConfig{
+		"auth": {Type: "jwt"},
+	}}
+
+	tests := []struct {
+		name        string
+		middlewares []MiddlewareConfig
+		wantNil     bool
+		wantLen     int
+	}{
+		{name: "省略はnilのまま（リスナーのデフォルトを適用する）", middlewares: nil, wantNil: true},
+		{name: "空のリストは空のまま（デフォルトを適用しない）", middlewares: []MiddlewareConfig{}, wantLen: 0},
+		{name: "参照を展開する", middlewares: []MiddlewareConfig{{ref: "auth"}, {Type: "cors"}}, wantLen: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.ResolveMiddleware(tt.middlewares)
+			if err != nil {
+				t.Fatalf("ResolveMiddleware() error = %v", err)
+			}
+			if (got == nil) != tt.wantNil {
+				t.Errorf("ResolveMiddleware() nil = %v, want %v", got == nil, tt.wantNil)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ResolveMiddleware() len = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
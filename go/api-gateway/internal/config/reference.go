@@ -0,0 +1,154 @@
+package config
+
+//go:generate go run ./configdoc -dir . -out fielddocs_gen.go
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ReferenceField は設定項目のリファレンス（運用者向けのドキュメントと /admin/config/schema で使う）
+type ReferenceField struct {
+	// Path は yaml のキーを "." でつないだもの（配列の要素は "[]" を付ける。例: server.listeners[].port）
+	Path string `json:"path"`
+	// Type は値の型（string, int, bool, duration, []string, object, []object, map[string]string など）
+	Type string `json:"type"`
+	// Default は省略した場合の値・挙動（フィールドのコメントの「0は〜」「省略時は〜」から取り出す）
+	Default string `json:"default,omitempty"`
+	// Env は値を上書きする環境変数（上書きできない項目は空）
+	Env string `json:"env,omitempty"`
+	// Description はフィールドのコメント
+	Description string `json:"description,omitempty"`
+}
+
+// fieldDoc はフィールドのコメントから取り出した説明とデフォルト
+// fielddocs_gen.go に go generate で生成する
+type fieldDoc struct {
+	Description string
+	Default     string
+}
+
+// Reference はゲートウェイ設定（Config）の全ての項目のリファレンスを返す
+func Reference() []ReferenceField {
+	return reference(reflect.TypeOf(Config{}), EnvPrefix)
+}
+
+// RoutingReference はルーティング設定（RoutingFileConfig）の全ての項目のリファレンスを返す
+// ルーティング設定は環境変数で上書きできないため Env は空
+func RoutingReference() []ReferenceField {
+	return reference(reflect.TypeOf(RoutingFileConfig{}), "")
+}
+
+// reference は構造体の項目を定義順に列挙する
+// envPrefix が空の場合は環境変数を設定しない
+func reference(typ reflect.Type, envPrefix string) []ReferenceField {
+	var fields []ReferenceField
+	walkReference(typ, "", envPrefix, func(f ReferenceField) {
+		fields = append(fields, f)
+	})
+	return fields
+}
+
+// walkReference は typ のフィールドを再帰的にたどり、項目ごとに fn を呼び出す
+// 環境変数の名前は applyEnvOverrides と同じ規則（大文字の yaml キーを "_" でつなぐ）で、
+// 配列・マップの中の項目は上書きできないため envPrefix を空にしてたどる
+func walkReference(typ reflect.Type, path, envPrefix string, fn func(ReferenceField)) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		// ポインタのフィールドは setEnvField がたどらないため上書きできない
+		env := ""
+		if envPrefix != "" && f.Type.Kind() != reflect.Pointer {
+			env = envPrefix + strings.ToUpper(name)
+		}
+
+		doc := fieldDocs[typ.Name()+"."+f.Name]
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		field := ReferenceField{
+			Path:        fieldPath,
+			Type:        referenceType(ft),
+			Default:     doc.Default,
+			Description: doc.Description,
+		}
+
+		switch {
+		case isNestedStruct(ft):
+			fn(field)
+			walkReference(ft, fieldPath, nestedEnvPrefix(env), fn)
+		case ft.Kind() == reflect.Slice && isNestedStruct(ft.Elem()):
+			fn(field)
+			walkReference(ft.Elem(), fieldPath+"[]", "", fn)
+		default:
+			if env != "" && envOverridable(ft) {
+				field.Env = env
+			}
+			fn(field)
+		}
+	}
+}
+
+// nestedEnvPrefix は子の項目の環境変数のプレフィックスを返す
+func nestedEnvPrefix(env string) string {
+	if env == "" {
+		return ""
+	}
+	return env + "_"
+}
+
+// isNestedStruct は子の項目をたどる構造体か（time.Duration などの外部型は値として扱う）
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == reflect.TypeOf(Config{}).PkgPath()
+}
+
+// envOverridable は setScalar で環境変数から設定できる型か
+func envOverridable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// referenceType はリファレンスに載せる型名を返す
+func referenceType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return "object"
+	case reflect.Slice:
+		return "[]" + referenceType(t.Elem())
+	case reflect.Map:
+		return "map[" + referenceType(t.Key()) + "]" + referenceType(t.Elem())
+	case reflect.Interface:
+		return "any"
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Float32:
+		return "float64"
+	}
+	return t.Kind().String()
+}
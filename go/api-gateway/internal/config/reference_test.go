@@ -0,0 +1,116 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReference(t *testing.T) {
+	fields := make(map[string]ReferenceField)
+	for _, f := range Reference() {
+		if _, ok := fields[f.Path]; ok {
+			t.Errorf("duplicate path %s", f.Path)
+		}
+		fields[f.Path] = f
+	}
+
+	tests := []struct {
+		name string
+		want ReferenceField
+	}{
+		{
+			name: "数値の項目",
+			want: ReferenceField{Path: "server.port", Type: "int", Env: "GATEWAY_SERVER_PORT"},
+		},
+		{
+			name: "コメントの説明とデフォルト",
+			want: ReferenceField{
+				Path:        "redis.revoke_cache.ttl",
+				Type:        "duration",
+				Default:     "5秒",
+				Env:         "GATEWAY_REDIS_REVOKE_CACHE_TTL",
+				Description: "TTL はキャッシュの保存期間（0の場合は5秒）。通知を受け取れなかった場合の最大の遅延になる",
+			},
+		},
+		{
+			name: "構造体の項目",
+			want: ReferenceField{
+				Path:        "redis.revoke_cache",
+				Type:        "object",
+				Description: "RevokeCache は revoke ミドルウェアが失効時刻を保持するローカルキャッシュの設定",
+			},
+		},
+		{
+			name: "文字列のスライスはカンマ区切りで上書きできる",
+			want: ReferenceField{
+				Path:        "server.trusted_proxies",
+				Type:        "[]string",
+				Env:         "GATEWAY_SERVER_TRUSTED_PROXIES",
+				Description: "TrustedProxies は X-Forwarded-For / X-Forwarded-Proto を信頼する前段のプロキシ（IPアドレスまたはCIDR） それ以外の接続元から受け取ったこれらのヘッダーは削除する",
+			},
+		},
+		{
+			name: "配列の要素は環境変数で上書きできない",
+			want: ReferenceField{Path: "server.listeners[].port", Type: "int"},
+		},
+		{
+			name: "マップは環境変数で上書きできない",
+			want: ReferenceField{
+				Path:        "jwt.public_key_files",
+				Type:        "map[string]string",
+				Description: "PublicKeyFiles は公開鍵ファイルのパス (kid → ファイルパス)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := fields[tt.want.Path]
+			if !ok {
+				t.Fatalf("path %s is not in the reference", tt.want.Path)
+			}
+			if got != tt.want {
+				t.Errorf("Reference()[%s] = %+v, want %+v", tt.want.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReference_EnvOverridable はリファレンスに載せた環境変数で実際に上書きできることを確認する
+func TestReference_EnvOverridable(t *testing.T) {
+	samples := map[string]string{"int": "1", "bool": "true", "duration": "1s", "float64": "0.5"}
+
+	for _, f := range Reference() {
+		if f.Env == "" {
+			continue
+		}
+		value, ok := samples[f.Type]
+		if !ok {
+			value = "a,b"
+		}
+		var cfg Config
+		ok, err := setEnvField(reflect.ValueOf(&cfg).Elem(), strings.TrimPrefix(f.Env, EnvPrefix), value)
+		if err != nil || !ok {
+			t.Errorf("%s (%s) cannot be set from %s=%s: ok = %v, err = %v", f.Path, f.Type, f.Env, value, ok, err)
+		}
+	}
+}
+
+func TestRoutingReference(t *testing.T) {
+	var found bool
+	for _, f := range RoutingReference() {
+		if f.Env != "" {
+			t.Errorf("%s: routing config should not have environment variables, got %s", f.Path, f.Env)
+		}
+		if f.Path == "routes[].when_disabled.status" {
+			found = true
+			if f.Type != "int" || f.Default != "404" {
+				t.Errorf("routes[].when_disabled.status = %+v, want int with default 404", f)
+			}
+		}
+	}
+	if !found {
+		t.Error("routes[].when_disabled.status is not in the routing reference")
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownFieldError は設定ファイルに未知のフィールドが含まれていた場合のエラー
+type UnknownFieldError struct {
+	Field  string
+	Type   string
+	Line   int
+	Column int
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("line %d, column %d: unknown field %q in %s", e.Line, e.Column, e.Field, e.Type)
+}
+
+// decodeStrict はYAMLを未知フィールドを許可せずにデコードする
+// デコードの前に値の ${VAR} を環境変数で置き換える
+// yaml.Unmarshalは未知のフィールドを黙って捨てるため、タイポした設定が
+// 気付かれないまま無視されてしまう。それを防ぐため行・列番号付きのエラーにする
+func decodeStrict(data []byte, out any) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+
+	// 空のドキュメントはゼロ値のまま扱う
+	if root.Kind == 0 {
+		return nil
+	}
+
+	if err := expandEnv(&root, os.LookupEnv); err != nil {
+		return err
+	}
+
+	if err := checkUnknownFields(&root, reflect.TypeOf(out)); err != nil {
+		return err
+	}
+
+	return root.Decode(out)
+}
+
+// checkUnknownFields はノードを構造体定義と照合し、未知のフィールドを検出する
+func checkUnknownFields(n *yaml.Node, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		// エイリアスはアンカーを付けたノードと同じ型として照合する
+		return checkUnknownFields(n.Alias, t)
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			if err := checkUnknownFields(c, t); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := yamlFields(t)
+			pairs := mappingPairs(n)
+			for i := 0; i+1 < len(pairs); i += 2 {
+				key, value := pairs[i], pairs[i+1]
+				fieldType, ok := fields[key.Value]
+				if !ok {
+					return &UnknownFieldError{
+						Field:  key.Value,
+						Type:   t.Name(),
+						Line:   key.Line,
+						Column: key.Column,
+					}
+				}
+				if err := checkUnknownFields(value, fieldType); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			pairs := mappingPairs(n)
+			for i := 1; i < len(pairs); i += 2 {
+				if err := checkUnknownFields(pairs[i], t.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for _, c := range n.Content {
+			if err := checkUnknownFields(c, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mappingPairs はマッピングのキーと値を交互に並べて返す
+// マージキー（<<: *anchor または <<: [*a, *b]）はマージするマッピングのキーと値に展開する
+func mappingPairs(n *yaml.Node) []*yaml.Node {
+	pairs := make([]*yaml.Node, 0, len(n.Content))
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if !isMergeKey(key) {
+			pairs = append(pairs, key, value)
+			continue
+		}
+
+		merged := []*yaml.Node{value}
+		if resolveAlias(value).Kind == yaml.SequenceNode {
+			merged = resolveAlias(value).Content
+		}
+		for _, m := range merged {
+			if m = resolveAlias(m); m.Kind == yaml.MappingNode {
+				pairs = append(pairs, mappingPairs(m)...)
+			}
+		}
+	}
+	return pairs
+}
+
+// isMergeKey はキーがマージキー（<<）か判定する
+func isMergeKey(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && key.Value == "<<" && key.ShortTag() == "!!merge"
+}
+
+// resolveAlias はエイリアスをアンカーを付けたノードに置き換える
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// yamlFields は構造体のyamlタグ名からフィールド型へのマップを返す
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_UnknownField(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		content    string
+		wantField  string
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name: "typo in top level section",
+			content: `server:
+  port: 8080
+  read_timeout: 30s
+  write_timeout: 30s
+loging:
+  level: "info"
+`,
+			wantField:  "loging",
+			wantLine:   5,
+			wantColumn: 1,
+		},
+		{
+			name: "typo in nested field",
+			content: `server:
+  port: 8080
+  read_timeout: 30s
+  writetimeout: 30s
+`,
+			wantField:  "writetimeout",
+			wantLine:   4,
+			wantColumn: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "gateway.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write test config: %v", err)
+			}
+
+			_, err := LoadConfig(path)
+			var fieldErr *UnknownFieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("LoadConfig() error = %v, want UnknownFieldError", err)
+			}
+
+			if fieldErr.Field != tt.wantField {
+				t.Errorf("Field = %s, want %s", fieldErr.Field, tt.wantField)
+			}
+			if fieldErr.Line != tt.wantLine || fieldErr.Column != tt.wantColumn {
+				t.Errorf("position = %d:%d, want %d:%d", fieldErr.Line, fieldErr.Column, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestLoadRoutingConfig_UnknownField(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		content   string
+		wantErr   bool
+		wantField string
+	}{
+		{
+			name: "typo in route field",
+			content: `routes:
+  - path: "/api/v1/users"
+    method: ["GET"]
+    backend:
+      url: "https://example.com"
+`,
+			wantErr:   true,
+			wantField: "method",
+		},
+		{
+			name: "typo in backend field",
+			content: `routes:
+  - path: "/api/v1/users"
+    backend:
+      url: "https://example.com"
+      timout: 5s
+`,
+			wantErr:   true,
+			wantField: "timout",
+		},
+		{
+			name: "middleware config is free-form",
+			content: `routes:
+  - path: "/api/v1/users"
+    backend:
+      url: "https://example.com"
+    middleware:
+      - type: "jwt"
+        config:
+          anything_goes: true
+`,
+			wantErr: false,
+		},
+		{
+			name:    "anchors and merge keys",
+			content: mergeKeyRouting,
+			wantErr: false,
+		},
+		{
+			name: "typo in merged mapping",
+			content: `routes:
+  - path: "/api/v1/users"
+    backend: &backend
+      url: "https://example.com"
+  - path: "/api/v1/orders"
+    backend:
+      <<: [*backend, {timout: 5s}]
+`,
+			wantErr:   true,
+			wantField: "timout",
+		},
+		{
+			name: "typo in aliased mapping",
+			content: `routes:
+  - path: "/api/v1/users"
+    backend:
+      url: "https://example.com"
+    middleware: &auth
+      - type: "jwt"
+        confg: {}
+  - path: "/api/v1/orders"
+    backend:
+      url: "https://example.com"
+    middleware: *auth
+`,
+			wantErr:   true,
+			wantField: "confg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "routing.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write test routing config: %v", err)
+			}
+
+			_, err := LoadRoutingConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadRoutingConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+
+			var fieldErr *UnknownFieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("LoadRoutingConfig() error = %v, want UnknownFieldError", err)
+			}
+			if fieldErr.Field != tt.wantField {
+				t.Errorf("Field = %s, want %s", fieldErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+// mergeKeyRouting はアンカーとマージキー（単一のマッピングとマッピングのシーケンス）を使ったルーティング設定
+const mergeKeyRouting = `routes:
+  - &users
+    path: "/api/v1/users"
+    backend: &backend
+      url: "https://example.com"
+      timeout: 5s
+  - path: "/api/v1/reports"
+    backend: &slow
+      url: "https://reports.example.com"
+      timeout: 30s
+  - <<: *users
+    path: "/api/v1/orders"
+    backend:
+      <<: [*slow, *backend]
+      url: "https://orders.example.com"
+`
+
+func TestLoadRoutingConfig_MergeKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	if err := os.WriteFile(path, []byte(mergeKeyRouting), 0644); err != nil {
+		t.Fatalf("failed to write test routing config: %v", err)
+	}
+
+	cfg, err := LoadRoutingConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRoutingConfig() error = %v", err)
+	}
+	if len(cfg.Routes) != 3 {
+		t.Fatalf("got %d routes, want 3", len(cfg.Routes))
+	}
+
+	// マッピング自身のキーはマージしたキーより優先し、シーケンスでは先に書いたマッピングを優先する
+	orders := cfg.Routes[2]
+	if orders.Path != "/api/v1/orders" || orders.Backend.URL != "https://orders.example.com" || orders.Backend.Timeout != 30*time.Second {
+		t.Errorf("orders route = %+v", orders)
+	}
+}
+
+// TestSchemaInSync は公開しているJSON Schemaと設定構造体のフィールドが一致していることを確認する
+func TestSchemaInSync(t *testing.T) {
+	tests := []struct {
+		file string
+		typ  reflect.Type
+	}{
+		{file: "gateway.schema.json", typ: reflect.TypeOf(Config{})},
+		{file: "routing.schema.json", typ: reflect.TypeOf(RoutingFileConfig{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("..", "..", "configs", "schema", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read schema: %v", err)
+			}
+
+			var schema map[string]any
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatalf("failed to parse schema: %v", err)
+			}
+
+			compareSchema(t, schema, schema, tt.typ, tt.typ.Name())
+		})
+	}
+}
+
+func compareSchema(t *testing.T, root, node map[string]any, typ reflect.Type, path string) {
+	t.Helper()
+
+	node = resolveRef(t, root, node)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if typ.PkgPath() != reflect.TypeOf(Config{}).PkgPath() {
+			return // time.Durationなどの外部型はスカラーとして扱う
+		}
+		props, _ := node["properties"].(map[string]any)
+		if additional, ok := node["additionalProperties"].(bool); !ok || additional {
+			t.Errorf("%s: additionalProperties must be false", path)
+		}
+
+		fields := yamlFields(typ)
+		want := make([]string, 0, len(fields))
+		for name := range fields {
+			want = append(want, name)
+		}
+		got := make([]string, 0, len(props))
+		for name := range props {
+			got = append(got, name)
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: schema properties = %v, want %v", path, got, want)
+			return
+		}
+
+		for name, fieldType := range fields {
+			child, _ := props[name].(map[string]any)
+			compareSchema(t, root, child, fieldType, path+"."+name)
+		}
+	case reflect.Slice:
+		items, _ := node["items"].(map[string]any)
+		if items == nil {
+			t.Errorf("%s: array schema has no items", path)
+			return
+		}
+		compareSchema(t, root, items, typ.Elem(), path+"[]")
+	}
+}
+
+func resolveRef(t *testing.T, root, node map[string]any) map[string]any {
+	t.Helper()
+
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+
+	var current any = root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			t.Fatalf("unresolvable $ref: %s", ref)
+		}
+		current = m[part]
+	}
+
+	resolved, ok := current.(map[string]any)
+	if !ok {
+		t.Fatalf("unresolvable $ref: %s", ref)
+	}
+	return resolved
+}
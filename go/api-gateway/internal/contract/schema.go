@@ -0,0 +1,398 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxRefDepth は参照（$ref）を辿る深さの上限（循環する参照での無限ループを防ぐ）
+const maxRefDepth = 32
+
+// Violation は契約に違反した1つの項目
+type Violation struct {
+	// Field は違反した場所（"body.items[0].id", "query.limit", "header.X-Request-Id" など）
+	Field string `json:"field"`
+	// Message は違反の内容
+	Message string `json:"message"`
+}
+
+// String は "field: message" 形式の文字列を返す
+func (v Violation) String() string {
+	return v.Field + ": " + v.Message
+}
+
+// validator はスキーマで値を検証し、違反を集める
+type validator struct {
+	spec       *Spec
+	violations []Violation
+	depth      int
+}
+
+// add は違反を追加する
+func (v *validator) add(field, message string) {
+	v.violations = append(v.violations, Violation{Field: field, Message: message})
+}
+
+// decodeJSON はJSONをデコードする（数値は精度を保つため json.Number のまま扱う）
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// validate は値をスキーマで検証する
+func (v *validator) validate(schema any, value any, field string) {
+	if v.depth >= maxRefDepth {
+		return
+	}
+	v.depth++
+	defer func() { v.depth-- }()
+
+	s, ok := v.spec.resolve(schema).(map[string]any)
+	if !ok {
+		return
+	}
+
+	if value == nil {
+		if nullable, _ := s["nullable"].(bool); nullable || typeAllows(s["type"], "null") || s["type"] == nil {
+			return
+		}
+		v.add(field, "must not be null")
+		return
+	}
+
+	for _, sub := range asSlice(s["allOf"]) {
+		v.validate(sub, value, field)
+	}
+	if anyOf := asSlice(s["anyOf"]); len(anyOf) > 0 && v.countMatches(anyOf, value, field) == 0 {
+		v.add(field, "must match at least one schema in anyOf")
+	}
+	if oneOf := asSlice(s["oneOf"]); len(oneOf) > 0 {
+		if n := v.countMatches(oneOf, value, field); n != 1 {
+			v.add(field, fmt.Sprintf("must match exactly one schema in oneOf, matched %d", n))
+		}
+	}
+	if enum := asSlice(s["enum"]); len(enum) > 0 && !inEnum(enum, value) {
+		v.add(field, fmt.Sprintf("must be one of %s", formatEnum(enum)))
+		return
+	}
+
+	if t := s["type"]; t != nil && !typeAllows(t, typeOf(value)) {
+		v.add(field, fmt.Sprintf("must be %s", formatType(t)))
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(s, value, field)
+	case json.Number:
+		v.validateNumber(s, value, field)
+	case []any:
+		v.validateArray(s, value, field)
+	case map[string]any:
+		v.validateObject(s, value, field)
+	}
+}
+
+// countMatches は値が一致するスキーマの数を返す（違反は記録しない）
+func (v *validator) countMatches(schemas []any, value any, field string) int {
+	n := 0
+	for _, schema := range schemas {
+		sub := &validator{spec: v.spec, depth: v.depth}
+		sub.validate(schema, value, field)
+		if len(sub.violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (v *validator) validateString(s map[string]any, value string, field string) {
+	length := utf8.RuneCountInString(value)
+	if n, ok := asInt(s["minLength"]); ok && length < n {
+		v.add(field, fmt.Sprintf("must be at least %d characters", n))
+	}
+	if n, ok := asInt(s["maxLength"]); ok && length > n {
+		v.add(field, fmt.Sprintf("must be at most %d characters", n))
+	}
+	if expr, ok := s["pattern"].(string); ok {
+		re, err := v.spec.pattern(expr)
+		if err == nil && !re.MatchString(value) {
+			v.add(field, fmt.Sprintf("must match pattern %s", expr))
+		}
+	}
+	if format, ok := s["format"].(string); ok && !validFormat(format, value) {
+		v.add(field, fmt.Sprintf("must be a valid %s", format))
+	}
+}
+
+func (v *validator) validateNumber(s map[string]any, value json.Number, field string) {
+	n, err := value.Float64()
+	if err != nil {
+		return
+	}
+	// OpenAPI 3.0 は exclusiveMinimum を真偽値、3.1 は数値で表す
+	if minimum, ok := asFloat(s["minimum"]); ok {
+		if exclusive, _ := s["exclusiveMinimum"].(bool); exclusive && n <= minimum {
+			v.add(field, fmt.Sprintf("must be greater than %v", minimum))
+		} else if n < minimum {
+			v.add(field, fmt.Sprintf("must be greater than or equal to %v", minimum))
+		}
+	}
+	if minimum, ok := asFloat(s["exclusiveMinimum"]); ok && n <= minimum {
+		v.add(field, fmt.Sprintf("must be greater than %v", minimum))
+	}
+	if maximum, ok := asFloat(s["maximum"]); ok {
+		if exclusive, _ := s["exclusiveMaximum"].(bool); exclusive && n >= maximum {
+			v.add(field, fmt.Sprintf("must be less than %v", maximum))
+		} else if n > maximum {
+			v.add(field, fmt.Sprintf("must be less than or equal to %v", maximum))
+		}
+	}
+	if maximum, ok := asFloat(s["exclusiveMaximum"]); ok && n >= maximum {
+		v.add(field, fmt.Sprintf("must be less than %v", maximum))
+	}
+}
+
+func (v *validator) validateArray(s map[string]any, value []any, field string) {
+	if n, ok := asInt(s["minItems"]); ok && len(value) < n {
+		v.add(field, fmt.Sprintf("must have at least %d items", n))
+	}
+	if n, ok := asInt(s["maxItems"]); ok && len(value) > n {
+		v.add(field, fmt.Sprintf("must have at most %d items", n))
+	}
+	if items := s["items"]; items != nil {
+		for i, item := range value {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", field, i))
+		}
+	}
+}
+
+func (v *validator) validateObject(s map[string]any, value map[string]any, field string) {
+	for _, name := range asSlice(s["required"]) {
+		name, _ := name.(string)
+		if _, ok := value[name]; !ok {
+			v.add(field+"."+name, "is required")
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	for name, prop := range value {
+		if schema, ok := properties[name]; ok {
+			v.validate(schema, prop, field+"."+name)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.add(field+"."+name, "is not allowed")
+			}
+		case map[string]any:
+			v.validate(additional, prop, field+"."+name)
+		}
+	}
+}
+
+// coerce はパラメータの文字列の値をスキーマの型に変換する
+// 配列は値ごと（?id=1&id=2）またはカンマ区切り（?id=1,2）で受け取る。変換できない値は文字列のまま返し、型の検証で違反にする
+func (v *validator) coerce(schema any, values []string) any {
+	s, _ := v.spec.resolve(schema).(map[string]any)
+	if typeAllows(s["type"], "array") {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]any, len(values))
+		for i, value := range values {
+			items[i] = v.coerce(s["items"], []string{value})
+		}
+		return items
+	}
+
+	value := values[0]
+	t := s["type"]
+	switch {
+	case typeAllows(t, "integer") || typeAllows(t, "number"):
+		// JSONの数値の表記（"1e3" は可、"NaN" や "0x10" は不可）のみ数値にする
+		if _, err := strconv.ParseFloat(value, 64); err == nil && json.Valid([]byte(value)) {
+			return json.Number(value)
+		}
+	case typeAllows(t, "boolean"):
+		if value == "true" || value == "false" {
+			return value == "true"
+		}
+	}
+	return value
+}
+
+// typeOf は値のJSON Schemaの型を返す
+func typeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if isInteger(value) {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// typeAllows はスキーマの type（文字列または配列）が型を許可するか確認する
+// integer の値は number も満たす
+func typeAllows(t any, typ string) bool {
+	for _, allowed := range asSlice(t) {
+		if allowed == typ || (allowed == "number" && typ == "integer") {
+			return true
+		}
+	}
+	if allowed, ok := t.(string); ok {
+		return allowed == typ || (allowed == "number" && typ == "integer")
+	}
+	return false
+}
+
+// formatType はエラーメッセージ用に type を整形する
+func formatType(t any) string {
+	if s, ok := t.(string); ok {
+		return s
+	}
+	return fmt.Sprint(asSlice(t))
+}
+
+// isInteger は値が整数の数値か確認する（1.0 も整数とする）
+func isInteger(value any) bool {
+	n, ok := value.(json.Number)
+	if !ok {
+		return false
+	}
+	if _, err := n.Int64(); err == nil {
+		return true
+	}
+	f, err := n.Float64()
+	return err == nil && f == math.Trunc(f) && !math.IsInf(f, 0)
+}
+
+// inEnum は値が enum に含まれるか確認する（数値は値で比較する）
+func inEnum(enum []any, value any) bool {
+	for _, candidate := range enum {
+		if equalValue(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValue はスキーマの値（YAMLのデコード結果）とJSONの値を比較する
+func equalValue(schemaValue, value any) bool {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		want, isNumber := asFloat(schemaValue)
+		return err == nil && isNumber && f == want
+	}
+	return reflect.DeepEqual(schemaValue, value)
+}
+
+// formatEnum はエラーメッセージ用に enum を整形する
+func formatEnum(enum []any) string {
+	data, err := json.Marshal(enum)
+	if err != nil {
+		return fmt.Sprint(enum)
+	}
+	return string(data)
+}
+
+// validFormat は format の値か確認する（未知の format は検証しない）
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "uuid":
+		return validUUID(value)
+	default:
+		return true
+	}
+}
+
+// validUUID は "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" 形式か確認する
+func validUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i, c := range value {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// asSlice は配列の値を返す（配列でない場合は nil）
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+// asFloat はスキーマの数値（YAMLのデコード結果）を float64 で返す
+func asFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// asInt はスキーマの整数を返す
+func asInt(v any) (int, bool) {
+	f, ok := asFloat(v)
+	if !ok {
+		return 0, false
+	}
+	return int(f), true
+}
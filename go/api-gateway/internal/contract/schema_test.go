@@ -0,0 +1,88 @@
+package contract
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSchemaSpec = `
+openapi: 3.1.0
+info:
+  title: schemas
+  version: "1"
+paths: {}
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        children:
+          type: array
+          items:
+            $ref: '#/components/schemas/Node'
+`
+
+func TestValidator_Validate(t *testing.T) {
+	spec, err := Parse([]byte(testSchemaSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		schema     string
+		value      string
+		wantFields []string
+	}{
+		{name: "型の不一致", schema: `{type: string}`, value: `1`, wantFields: []string{"body"}},
+		{name: "integerは整数値のnumberを許可する", schema: `{type: integer}`, value: `1.0`},
+		{name: "numberはintegerを許可する", schema: `{type: number}`, value: `1`},
+		{name: "integerに小数", schema: `{type: integer}`, value: `1.5`, wantFields: []string{"body"}},
+		{name: "nullable", schema: `{type: string, nullable: true}`, value: `null`},
+		{name: "型の配列でnullを許可", schema: `{type: [string, "null"]}`, value: `null`},
+		{name: "null", schema: `{type: string}`, value: `null`, wantFields: []string{"body"}},
+		{name: "enumの数値", schema: `{enum: [1, 2]}`, value: `2.0`},
+		{name: "enumに無い値", schema: `{enum: [a, b]}`, value: `"c"`, wantFields: []string{"body"}},
+		{name: "文字列の長さと正規表現", schema: `{type: string, minLength: 2, maxLength: 3, pattern: '^[a-z]+$'}`, value: `"ABCD"`, wantFields: []string{"body", "body"}},
+		{name: "文字数はルーンで数える", schema: `{type: string, maxLength: 2}`, value: `"あい"`},
+		{name: "format", schema: `{type: object, properties: {at: {format: date-time}, day: {format: date}, id: {format: uuid}}}`, value: `{"at":"2024-01-02","day":"2024-01-02","id":"x"}`, wantFields: []string{"body.at", "body.id"}},
+		{name: "3.0のexclusiveMinimum", schema: `{type: number, minimum: 0, exclusiveMinimum: true}`, value: `0`, wantFields: []string{"body"}},
+		{name: "3.1のexclusiveMaximum", schema: `{type: number, exclusiveMaximum: 10}`, value: `10`, wantFields: []string{"body"}},
+		{name: "範囲内の数値", schema: `{type: number, minimum: 0, maximum: 10}`, value: `10`},
+		{name: "配列の要素数と要素", schema: `{type: array, maxItems: 1, items: {type: integer}}`, value: `[1, "a"]`, wantFields: []string{"body", "body[1]"}},
+		{name: "必須のプロパティと追加のプロパティ", schema: `{type: object, required: [id], properties: {id: {type: integer}}, additionalProperties: false}`, value: `{"name":"a"}`, wantFields: []string{"body.id", "body.name"}},
+		{name: "追加のプロパティのスキーマ", schema: `{type: object, additionalProperties: {type: string}}`, value: `{"a":"x","b":1}`, wantFields: []string{"body.b"}},
+		{name: "allOf", schema: `{allOf: [{required: [a]}, {required: [b]}]}`, value: `{"a":1}`, wantFields: []string{"body.b"}},
+		{name: "anyOf", schema: `{anyOf: [{type: string}, {type: integer}]}`, value: `true`, wantFields: []string{"body"}},
+		{name: "oneOfに複数一致", schema: `{oneOf: [{type: number}, {type: integer}]}`, value: `1`, wantFields: []string{"body"}},
+		{name: "oneOfに1つ一致", schema: `{oneOf: [{type: string}, {type: integer}]}`, value: `1`},
+		{name: "再帰する参照", schema: `{$ref: '#/components/schemas/Node'}`, value: `{"children":[{"children":[{"children":1}]}]}`, wantFields: []string{"body.children[0].children[0].children"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema any
+			if err := yaml.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			value, err := decodeJSON([]byte(tt.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := &validator{spec: spec}
+			v.validate(normalize(schema), value, "body")
+			var fields []string
+			for _, violation := range v.violations {
+				fields = append(fields, violation.Field)
+			}
+			slices.Sort(fields)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations = %v, want %v", v.violations, tt.wantFields)
+			}
+		})
+	}
+}
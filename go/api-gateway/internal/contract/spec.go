@@ -0,0 +1,419 @@
+// Package contract はOpenAPI 3のドキュメント（API契約）でリクエストとレスポンスを検証する
+// ドキュメントのパスはバックエンドへ転送するパスと照合し、JSONのボディはスキーマ（JSON Schemaの主要なキーワード）で検証する
+package contract
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"api-gateway/internal/metrics"
+)
+
+// 違反の扱い
+const (
+	// ModeOff は検証しない
+	ModeOff = "off"
+	// ModeAudit は違反をログとメトリクスに記録し、リクエスト・レスポンスはそのまま通す
+	ModeAudit = "audit"
+	// ModeEnforce は違反したリクエスト・レスポンスを拒否する
+	ModeEnforce = "enforce"
+)
+
+// violationsTotal は契約に違反したリクエスト・レスポンスの数
+var violationsTotal = metrics.NewCounterVec(
+	"gateway_contract_violations_total",
+	"Number of requests and responses that violated the route's OpenAPI contract, by route, direction and mode.",
+	"route", "direction", "mode",
+)
+
+// RecordViolation は契約に違反したリクエスト（direction="request"）・レスポンス（direction="response"）を数える
+func RecordViolation(route, direction, mode string) {
+	violationsTotal.With(route, direction, mode).Inc()
+}
+
+// ParseMode は違反の扱いを解析する（空は ModeOff）
+func ParseMode(mode string) (string, error) {
+	switch mode {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeAudit, ModeEnforce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("mode must be off, audit or enforce: %q", mode)
+	}
+}
+
+// Spec は読み込んだOpenAPIのドキュメント
+type Spec struct {
+	doc   map[string]any
+	paths []pathTemplate
+
+	// patterns はスキーマの pattern をコンパイルした正規表現のキャッシュ
+	patterns sync.Map
+}
+
+// pathTemplate はドキュメントの1つのパス（"/users/{id}"）
+type pathTemplate struct {
+	path     string
+	segments []string
+	item     map[string]any
+}
+
+// Load はOpenAPIのドキュメント（YAML・JSON）を読み込む
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse はOpenAPIのドキュメント（YAML・JSON）を解析する
+func Parse(data []byte) (*Spec, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	doc, ok := normalize(raw).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("spec is not an object")
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("only OpenAPI 3 documents are supported: openapi = %v", doc["openapi"])
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	spec := &Spec{doc: doc, paths: make([]pathTemplate, 0, len(paths))}
+	for path, item := range paths {
+		item, ok := spec.resolve(item).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("path %s is not an object", path)
+		}
+		spec.paths = append(spec.paths, pathTemplate{path: path, segments: splitPath(path), item: item})
+	}
+	return spec, nil
+}
+
+// Operation はリクエストのメソッドとパスに対応するオペレーション
+type Operation struct {
+	spec *Spec
+	// Path はドキュメントのパス（"/users/{id}"）
+	Path   string
+	Method string
+	// PathParams はパスのパラメータの値
+	PathParams map[string]string
+
+	op         map[string]any
+	parameters []any
+}
+
+// FindOperation はメソッドとパスに対応するオペレーションを返す
+// 複数のパスに一致する場合はパラメータではないセグメントが多いものを使う
+func (s *Spec) FindOperation(method, path string) (*Operation, bool) {
+	segments := splitPath(path)
+	var best *pathTemplate
+	var bestParams map[string]string
+	bestLiterals := -1
+	for i := range s.paths {
+		template := &s.paths[i]
+		params, literals, ok := template.match(segments)
+		if !ok || literals <= bestLiterals {
+			continue
+		}
+		if _, ok := template.item[strings.ToLower(method)].(map[string]any); !ok {
+			continue
+		}
+		best, bestParams, bestLiterals = template, params, literals
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	op := best.item[strings.ToLower(method)].(map[string]any)
+	// Path Item のパラメータにオペレーションのパラメータを加える（同じ名前と場所はオペレーションを優先する）
+	parameters, _ := best.item["parameters"].([]any)
+	ownParameters, _ := op["parameters"].([]any)
+	return &Operation{
+		spec:       s,
+		Path:       best.path,
+		Method:     strings.ToUpper(method),
+		PathParams: bestParams,
+		op:         op,
+		parameters: append(append([]any(nil), parameters...), ownParameters...),
+	}, true
+}
+
+// match はパスのセグメントがテンプレートに一致するか確認し、パラメータの値とパラメータではないセグメントの数を返す
+func (t *pathTemplate) match(segments []string) (map[string]string, int, bool) {
+	if len(segments) != len(t.segments) {
+		return nil, 0, false
+	}
+	params := make(map[string]string)
+	literals := 0
+	for i, segment := range t.segments {
+		if name, ok := strings.CutPrefix(segment, "{"); ok && strings.HasSuffix(name, "}") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			params[strings.TrimSuffix(name, "}")] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, 0, false
+		}
+		literals++
+	}
+	return params, literals, true
+}
+
+// ValidateRequest はリクエストのパラメータ（path, query, header, cookie）とボディを検証する
+// パラメータの値は文字列のため、スキーマの型（integer, number, boolean, array）に変換してから検証する
+func (o *Operation) ValidateRequest(r *http.Request, body []byte) []Violation {
+	v := &validator{spec: o.spec}
+	for _, param := range o.resolveParameters() {
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		field := in + "." + name
+
+		var values []string
+		switch in {
+		case "path":
+			if value, ok := o.PathParams[name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = r.URL.Query()[name]
+		case "header":
+			values = r.Header.Values(name)
+		case "cookie":
+			if cookie, err := r.Cookie(name); err == nil {
+				values = []string{cookie.Value}
+			}
+		default:
+			continue
+		}
+
+		if len(values) == 0 {
+			// パスのパラメータは常に必須
+			if required, _ := param["required"].(bool); required || in == "path" {
+				v.add(field, "is required")
+			}
+			continue
+		}
+		if schema := param["schema"]; schema != nil {
+			v.validate(schema, v.coerce(schema, values), field)
+		}
+	}
+
+	requestBody, _ := o.spec.resolve(o.op["requestBody"]).(map[string]any)
+	if requestBody == nil {
+		return v.violations
+	}
+	if len(body) == 0 {
+		if required, _ := requestBody["required"].(bool); required {
+			v.add("body", "is required")
+		}
+		return v.violations
+	}
+	if content, _ := requestBody["content"].(map[string]any); len(content) > 0 {
+		v.validateBody(content, r.Header.Get("Content-Type"), body)
+	}
+	return v.violations
+}
+
+// HasRequestBody はオペレーションにリクエストボディの定義があるか返す（無い場合はボディを読み込まずに検証できる）
+func (o *Operation) HasRequestBody() bool {
+	return o.op["requestBody"] != nil
+}
+
+// resolveParameters は参照を解決したパラメータを返す
+// Path Item とオペレーションで名前と場所が同じパラメータはオペレーションのものを使う
+func (o *Operation) resolveParameters() []map[string]any {
+	var params []map[string]any
+	index := make(map[string]int)
+	for _, p := range o.parameters {
+		param, ok := o.spec.resolve(p).(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		key := in + "." + strings.ToLower(name)
+		if i, ok := index[key]; ok {
+			params[i] = param
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, param)
+	}
+	return params
+}
+
+// ValidateResponse はレスポンスのステータス・ヘッダー・ボディを検証する
+// JSON以外のボディはContent-Typeがドキュメントに記載されているかのみ確認する
+func (o *Operation) ValidateResponse(status int, header http.Header, body []byte) []Violation {
+	v := &validator{spec: o.spec}
+	responses, _ := o.op["responses"].(map[string]any)
+	response, ok := o.spec.resolve(findResponse(responses, status)).(map[string]any)
+	if !ok {
+		v.add("status", fmt.Sprintf("status %d is not documented", status))
+		return v.violations
+	}
+
+	headers, _ := response["headers"].(map[string]any)
+	for name, h := range headers {
+		h, _ := o.spec.resolve(h).(map[string]any)
+		if required, _ := h["required"].(bool); required && header.Get(name) == "" {
+			v.add("header."+name, "is required")
+		}
+	}
+
+	content, _ := response["content"].(map[string]any)
+	if len(content) == 0 || len(body) == 0 {
+		return v.violations
+	}
+	v.validateBody(content, header.Get("Content-Type"), body)
+	return v.violations
+}
+
+// findResponse はステータスに対応するレスポンスの定義を返す（"200" > "2XX" > "default" の順）
+func findResponse(responses map[string]any, status int) any {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if response, ok := responses[key]; ok {
+			return response
+		}
+	}
+	return nil
+}
+
+// validateBody はボディのContent-Typeが content に記載されているか確認し、JSONの場合はスキーマで検証する
+func (v *validator) validateBody(content map[string]any, contentType string, body []byte) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		v.add("body", fmt.Sprintf("invalid content type %q", contentType))
+		return
+	}
+	media, ok := findMedia(content, mediaType)
+	if !ok {
+		v.add("body", fmt.Sprintf("content type %s is not documented", mediaType))
+		return
+	}
+	if !isJSON(mediaType) {
+		return
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		v.add("body", "is not valid JSON")
+		return
+	}
+	if media, ok := v.spec.resolve(media).(map[string]any); ok && media["schema"] != nil {
+		v.validate(media["schema"], value, "body")
+	}
+}
+
+// findMedia は content からメディアタイプ（"application/json" > "application/*" > "*/*"）の定義を返す
+func findMedia(content map[string]any, mediaType string) (any, bool) {
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, key := range []string{mediaType, major + "/*", "*/*"} {
+		if media, ok := content[key]; ok {
+			return media, true
+		}
+	}
+	return nil, false
+}
+
+// isJSON はJSONのメディアタイプか確認する
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// resolve はドキュメント内の参照（{"$ref": "#/components/..."}）を解決する。参照でない場合はそのまま返す
+func (s *Spec) resolve(v any) any {
+	for range maxRefDepth {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		v = s.lookup(ref)
+	}
+	return nil
+}
+
+// lookup は "#/components/schemas/User" 形式の参照先を返す（見つからない場合は nil）
+func (s *Spec) lookup(ref string) any {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var current any = s.doc
+	for _, part := range strings.Split(pointer, "/") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		current = m[part]
+	}
+	return current
+}
+
+// pattern は pattern のキーワードの正規表現を返す（コンパイル済みのものを再利用する）
+func (s *Spec) pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns.Store(expr, re)
+	return re, nil
+}
+
+// splitPath はパスをセグメントに分割する
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+// normalize はYAMLデコード結果をJSONと同じ形に揃える
+// （レスポンスコードなどの数値キーは map[any]any としてデコードされるため）
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			v[k] = normalize(val)
+		}
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[fmt.Sprint(k)] = normalize(val)
+		}
+		return out
+	case []any:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
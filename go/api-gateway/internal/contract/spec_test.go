@@ -0,0 +1,350 @@
+package contract
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: users
+  version: "1"
+paths:
+  /users/{id}:
+    get:
+      responses:
+        200:
+          description: ok
+          headers:
+            X-Request-Id:
+              required: true
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+        404:
+          $ref: '#/components/responses/NotFound'
+  /users/me:
+    get:
+      responses:
+        2XX:
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+  /health:
+    get:
+      responses:
+        default:
+          description: ok
+          content:
+            text/plain: {}
+components:
+  responses:
+    NotFound:
+      description: not found
+      content:
+        application/problem+json:
+          schema:
+            type: object
+            required: [title]
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+`
+
+func TestSpec_FindOperation(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantPath   string
+		wantParams map[string]string
+		wantFound  bool
+	}{
+		{name: "パラメータを含むパス", method: http.MethodGet, path: "/users/42", wantPath: "/users/{id}", wantParams: map[string]string{"id": "42"}, wantFound: true},
+		{name: "固定のセグメントを優先する", method: http.MethodGet, path: "/users/me", wantPath: "/users/me", wantParams: map[string]string{}, wantFound: true},
+		{name: "末尾のスラッシュ", method: http.MethodGet, path: "/health/", wantPath: "/health", wantParams: map[string]string{}, wantFound: true},
+		{name: "記載の無いメソッド", method: http.MethodDelete, path: "/users/42", wantFound: false},
+		{name: "記載の無いパス", method: http.MethodGet, path: "/orders", wantFound: false},
+		{name: "空のセグメントはパラメータに一致しない", method: http.MethodGet, path: "/users//", wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, found := spec.FindOperation(tt.method, tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("FindOperation() found = %v, want %v", found, tt.wantFound)
+			}
+			if !found {
+				return
+			}
+			if op.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", op.Path, tt.wantPath)
+			}
+			if !reflect.DeepEqual(op.PathParams, tt.wantParams) {
+				t.Errorf("PathParams = %v, want %v", op.PathParams, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestOperation_ValidateResponse(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		status     int
+		header     http.Header
+		body       string
+		wantFields []string
+	}{
+		{
+			name:   "契約どおりのレスポンス",
+			path:   "/users/42",
+			status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/json; charset=utf-8"}, "X-Request-Id": {"abc"}},
+			body:   `{"id":42,"name":"Alice"}`,
+		},
+		{
+			name:       "必須のヘッダーとプロパティの欠落",
+			path:       "/users/42",
+			status:     http.StatusOK,
+			header:     http.Header{"Content-Type": {"application/json"}},
+			body:       `{"id":"42"}`,
+			wantFields: []string{"body.id", "body.name", "header.X-Request-Id"},
+		},
+		{
+			name:       "記載の無いステータス",
+			path:       "/users/42",
+			status:     http.StatusInternalServerError,
+			header:     http.Header{},
+			wantFields: []string{"status"},
+		},
+		{
+			name:   "参照したレスポンス",
+			path:   "/users/42",
+			status: http.StatusNotFound,
+			header: http.Header{"Content-Type": {"application/problem+json"}},
+			body:   `{"title":"Not Found"}`,
+		},
+		{
+			name:       "記載の無いContent-Type",
+			path:       "/users/42",
+			status:     http.StatusNotFound,
+			header:     http.Header{"Content-Type": {"text/html"}},
+			body:       `<h1>Not Found</h1>`,
+			wantFields: []string{"body"},
+		},
+		{
+			name:       "不正なJSON",
+			path:       "/users/me",
+			status:     http.StatusCreated,
+			header:     http.Header{"Content-Type": {"application/json"}},
+			body:       `{"id":`,
+			wantFields: []string{"body"},
+		},
+		{
+			name:   "JSON以外のボディはContent-Typeのみ確認する",
+			path:   "/health",
+			status: http.StatusServiceUnavailable,
+			header: http.Header{"Content-Type": {"text/plain"}},
+			body:   "unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, found := spec.FindOperation(http.MethodGet, tt.path)
+			if !found {
+				t.Fatalf("operation not found: %s", tt.path)
+			}
+			var fields []string
+			for _, v := range op.ValidateResponse(tt.status, tt.header, []byte(tt.body)) {
+				fields = append(fields, v.Field)
+			}
+			slices.Sort(fields)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "openapi.yaml")
+	if err := os.WriteFile(valid, []byte(testSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	swagger := filepath.Join(dir, "swagger.json")
+	if err := os.WriteFile(swagger, []byte(`{"swagger":"2.0","paths":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "OpenAPI 3", path: valid},
+		{name: "Swagger 2.0は未対応", path: swagger, wantErr: true},
+		{name: "存在しないファイル", path: filepath.Join(dir, "missing.yaml"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+const testRequestSpec = `
+openapi: 3.0.3
+info:
+  title: orders
+  version: "1"
+paths:
+  /orders/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    put:
+      parameters:
+        - name: dry_run
+          in: query
+          schema:
+            type: boolean
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [gift, express]
+        - name: X-Idempotency-Key
+          in: header
+          required: true
+          schema:
+            type: string
+            format: uuid
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [quantity]
+              properties:
+                quantity:
+                  type: integer
+                  minimum: 1
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+`
+
+func TestOperation_ValidateRequest(t *testing.T) {
+	spec, err := Parse([]byte(testRequestSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const key = "6f1c2f5e-3b7a-4a8e-9a51-0f3a1d2c4b5e"
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		header     http.Header
+		body       string
+		wantFields []string
+	}{
+		{
+			name:   "契約どおりのリクエスト",
+			method: http.MethodPut,
+			target: "/orders/42?dry_run=true&tags=gift,express",
+			header: http.Header{"Content-Type": {"application/json"}, "X-Idempotency-Key": {key}},
+			body:   `{"quantity":2}`,
+		},
+		{
+			name:       "パラメータの型と必須のヘッダー",
+			method:     http.MethodPut,
+			target:     "/orders/abc?dry_run=yes&tags=gift&tags=later",
+			header:     http.Header{"Content-Type": {"application/json"}},
+			body:       `{"quantity":2}`,
+			wantFields: []string{"header.X-Idempotency-Key", "path.id", "query.dry_run", "query.tags[1]"},
+		},
+		{
+			name:       "ボディのスキーマ",
+			method:     http.MethodPut,
+			target:     "/orders/42",
+			header:     http.Header{"Content-Type": {"application/json"}, "X-Idempotency-Key": {key}},
+			body:       `{"quantity":0}`,
+			wantFields: []string{"body.quantity"},
+		},
+		{
+			name:       "必須のボディ",
+			method:     http.MethodPut,
+			target:     "/orders/42",
+			header:     http.Header{"X-Idempotency-Key": {key}},
+			wantFields: []string{"body"},
+		},
+		{
+			name:   "オペレーションのパラメータを優先する",
+			method: http.MethodGet,
+			target: "/orders/abc",
+			header: http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header = tt.header
+			op, found := spec.FindOperation(req.Method, req.URL.Path)
+			if !found {
+				t.Fatalf("operation not found: %s %s", tt.method, tt.target)
+			}
+			var fields []string
+			for _, v := range op.ValidateRequest(req, []byte(tt.body)) {
+				fields = append(fields, v.Field)
+			}
+			slices.Sort(fields)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConsulWaitTime はConsulのブロッキングクエリで変更を待つ時間のデフォルト値
+const DefaultConsulWaitTime = 5 * time.Minute
+
+// ConsulConfig はConsulのカタログからインスタンスを取得する設定
+type ConsulConfig struct {
+	// Address はConsulのHTTP APIのURL（例: http://127.0.0.1:8500）
+	Address string
+	// Token はACLトークン（X-Consul-Token ヘッダーで送る。空の場合は送らない）
+	Token string
+	// Datacenter は参照するデータセンター（空はエージェントのデータセンター）
+	Datacenter string
+	// WaitTime はブロッキングクエリで変更を待つ時間（0は DefaultConsulWaitTime）
+	WaitTime time.Duration
+	Client   *http.Client
+}
+
+// consulServiceEntry は /v1/health/service のレスポンスの要素のうち使用するフィールド
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+// NewConsulSource はConsulのヘルスチェックを通過しているインスタンスを取得するSourceを作成する
+// 2回目以降の取得はブロッキングクエリでカタログの変更（X-Consul-Index の更新）を待つ
+func NewConsulSource(config ConsulConfig) Source {
+	if config.WaitTime <= 0 {
+		config.WaitTime = DefaultConsulWaitTime
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	address := strings.TrimSuffix(config.Address, "/")
+
+	return func(ctx context.Context, service string, index uint64) ([]string, uint64, error) {
+		query := url.Values{"passing": {"true"}}
+		if config.Datacenter != "" {
+			query.Set("dc", config.Datacenter)
+		}
+		if index > 0 {
+			query.Set("index", strconv.FormatUint(index, 10))
+			query.Set("wait", config.WaitTime.String())
+			// Consul は wait に最大1/16のジッターを加えて応答するため、その分も待つ
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, config.WaitTime+config.WaitTime/16+10*time.Second)
+			defer cancel()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			address+"/v1/health/service/"+url.PathEscape(service)+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, index, fmt.Errorf("failed to create consul request: %w", err)
+		}
+		if config.Token != "" {
+			req.Header.Set("X-Consul-Token", config.Token)
+		}
+
+		resp, err := config.Client.Do(req)
+		if err != nil {
+			return nil, index, fmt.Errorf("failed to query consul: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, index, fmt.Errorf("consul returned status %d", resp.StatusCode)
+		}
+		var entries []consulServiceEntry
+		if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+			return nil, index, fmt.Errorf("failed to decode consul response: %w", err)
+		}
+
+		next, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+		if err != nil {
+			return nil, index, fmt.Errorf("invalid X-Consul-Index: %w", err)
+		}
+		// インデックスが戻った場合（Consulのスナップショットからの復元など）は最初から待ち直す
+		if next < index {
+			next = 0
+		}
+
+		instances := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			host := entry.Service.Address
+			if host == "" {
+				host = entry.Node.Address
+			}
+			instances = append(instances, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)))
+		}
+		return instances, next, nil
+	}
+}
@@ -0,0 +1,71 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestConsulSource(t *testing.T) {
+	var gotQuery, gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/health/service/orders" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		gotToken = r.Header.Get("X-Consul-Token")
+		w.Header().Set("X-Consul-Index", "42")
+		w.Write([]byte(`[
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "", "Port": 8080}},
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "10.1.0.2", "Port": 9090}}
+		]`))
+	}))
+	defer server.Close()
+
+	source := NewConsulSource(ConsulConfig{Address: server.URL, Token: "secret", Datacenter: "dc1"})
+
+	t.Run("最初の取得", func(t *testing.T) {
+		instances, index, err := source(context.Background(), "orders", 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// サービスのアドレスが無い場合はノードのアドレスを使う
+		if !slices.Equal(instances, []string{"10.0.0.1:8080", "10.1.0.2:9090"}) {
+			t.Errorf("instances = %v", instances)
+		}
+		if index != 42 {
+			t.Errorf("index = %d, want 42", index)
+		}
+		if gotQuery != "dc=dc1&passing=true" || gotToken != "secret" {
+			t.Errorf("query = %q, token = %q", gotQuery, gotToken)
+		}
+	})
+
+	t.Run("ブロッキングクエリ", func(t *testing.T) {
+		if _, _, err := source(context.Background(), "orders", 41); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotQuery != "dc=dc1&index=41&passing=true&wait=5m0s" {
+			t.Errorf("query = %q, want blocking query", gotQuery)
+		}
+	})
+
+	t.Run("インデックスが戻った場合は最初から待ち直す", func(t *testing.T) {
+		_, index, err := source(context.Background(), "orders", 100)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if index != 0 {
+			t.Errorf("index = %d, want 0", index)
+		}
+	})
+
+	t.Run("エラーのステータス", func(t *testing.T) {
+		if _, _, err := source(context.Background(), "unknown", 0); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,184 @@
+// Package discovery はサービスディスカバリ（Consul・etcd）に登録されたサービスのインスタンスを監視する
+//
+// ルートのバックエンドに backend.service を指定すると、url のホストの代わりにカタログから選んだインスタンスへ転送する。
+// カタログはサービスごとに登録先を監視し続けるため、インスタンスの追加・削除は再起動や設定のリロードをせずに反映される
+package discovery
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+// DefaultRetryInterval は登録先から取得できなかった場合に再試行するまでの間隔のデフォルト値
+const DefaultRetryInterval = 5 * time.Second
+
+// serviceInstances はサービスごとの転送先のインスタンス数
+var serviceInstances = metrics.NewGaugeVec(
+	"gateway_discovery_instances",
+	"Number of instances discovered for the service.",
+	"service",
+)
+
+// Source はサービスのインスタンスのアドレス（host:port）を取得する
+// index は前回の取得で返した値（初回は0）で、変更を待てる登録先（Consulのブロッキングクエリなど）は
+// index から変更があるまで待って返す。待てない登録先は一定の間隔で取得し直す
+type Source func(ctx context.Context, service string, index uint64) ([]string, uint64, error)
+
+// Config はカタログの設定
+type Config struct {
+	// Source はインスタンスの取得元
+	Source Source
+	// RetryInterval は取得に失敗した場合に再試行するまでの間隔（0は DefaultRetryInterval）
+	RetryInterval time.Duration
+	Logger        *slog.Logger
+}
+
+// Catalog はサービスごとのインスタンスを監視し、転送先のインスタンスを選ぶ
+// 取得に失敗した間は直前に取得したインスタンスを使い続ける
+// nil の Catalog はどのサービスのインスタンスも持たない
+type Catalog struct {
+	config Config
+
+	mu       sync.RWMutex
+	services map[string]*service
+}
+
+// service は1つのサービスのインスタンス
+type service struct {
+	// instances はソートしたインスタンスのアドレス（更新時は置き換えるため、取り出した後は変更しない）
+	instances []string
+	// next はラウンドロビンで次に選ぶインスタンスの位置
+	next atomic.Uint64
+}
+
+// New は新しいCatalogを作成する
+func New(config Config) *Catalog {
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &Catalog{config: config, services: make(map[string]*service)}
+}
+
+// Start は names のサービスのインスタンスを取得し、変更を監視するゴルーチンを開始する
+// 最初の取得は完了を待ってから返す（失敗した場合はログに記録し、バックグラウンドで再試行する）
+// ctx がキャンセルされると監視を停止する
+func (c *Catalog) Start(ctx context.Context, names ...string) {
+	if c == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		c.mu.Lock()
+		_, watching := c.services[name]
+		if !watching {
+			c.services[name] = &service{}
+		}
+		c.mu.Unlock()
+		if watching {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			index, err := c.refresh(ctx, name, 0)
+			wg.Done()
+			c.watch(ctx, name, index, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// watch はサービスのインスタンスの変更を ctx がキャンセルされるまで監視する
+func (c *Catalog) watch(ctx context.Context, name string, index uint64, err error) {
+	for ctx.Err() == nil {
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.config.RetryInterval):
+			}
+		}
+		index, err = c.refresh(ctx, name, index)
+	}
+}
+
+// refresh はサービスのインスタンスを取得して置き換え、次の取得に使う index を返す
+// 失敗した場合はログに記録し、インスタンスを変えずに index をそのまま返す
+func (c *Catalog) refresh(ctx context.Context, name string, index uint64) (uint64, error) {
+	instances, next, err := c.config.Source(ctx, name, index)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.config.Logger.WarnContext(ctx, "Failed to discover service instances",
+				slog.String("service", name),
+				slog.String("error", err.Error()))
+		}
+		return index, err
+	}
+
+	instances = slices.Clone(instances)
+	slices.Sort(instances)
+	instances = slices.Compact(instances)
+
+	c.mu.Lock()
+	svc := c.services[name]
+	changed := !slices.Equal(svc.instances, instances)
+	svc.instances = instances
+	c.mu.Unlock()
+
+	if changed {
+		serviceInstances.With(name).Set(float64(len(instances)))
+		c.config.Logger.InfoContext(ctx, "Service instances updated",
+			slog.String("service", name),
+			slog.Any("instances", instances))
+	}
+	return next, nil
+}
+
+// Instances はサービスの現在のインスタンスのアドレスを返す（監視していないサービスは空）
+func (c *Catalog) Instances(name string) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if svc, ok := c.services[name]; ok {
+		return slices.Clone(svc.instances)
+	}
+	return nil
+}
+
+// Pick はサービスのインスタンスをラウンドロビンで選んでアドレスを返す
+// accept が false を返すインスタンス（異常・除外中など）は飛ばし、転送できるインスタンスが無い場合は false を返す
+func (c *Catalog) Pick(name string, accept func(address string) bool) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.RLock()
+	svc, ok := c.services[name]
+	var instances []string
+	if ok {
+		instances = svc.instances
+	}
+	c.mu.RUnlock()
+	if len(instances) == 0 {
+		return "", false
+	}
+
+	start := svc.next.Add(1) - 1
+	for i := range uint64(len(instances)) {
+		address := instances[(start+i)%uint64(len(instances))]
+		if accept == nil || accept(address) {
+			return address, true
+		}
+	}
+	return "", false
+}
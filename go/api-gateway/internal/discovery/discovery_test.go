@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+// fakeSource は呼び出されるたびに updates の値を順に返すSource（使い切った後は ctx の終了まで待つ）
+type fakeSource struct {
+	mu      sync.Mutex
+	updates []fakeUpdate
+}
+
+type fakeUpdate struct {
+	instances []string
+	err       error
+}
+
+func (s *fakeSource) source(ctx context.Context, service string, index uint64) ([]string, uint64, error) {
+	s.mu.Lock()
+	if len(s.updates) == 0 {
+		s.mu.Unlock()
+		<-ctx.Done()
+		return nil, index, ctx.Err()
+	}
+	update := s.updates[0]
+	s.updates = s.updates[1:]
+	s.mu.Unlock()
+	return update.instances, index + 1, update.err
+}
+
+func TestCatalog_Watch(t *testing.T) {
+	source := &fakeSource{updates: []fakeUpdate{
+		{instances: []string{"10.0.0.2:8080", "10.0.0.1:8080"}},
+		{err: errors.New("connection refused")},
+		{instances: []string{"10.0.0.3:8080"}},
+	}}
+	catalog := New(Config{Source: source.source, RetryInterval: 50 * time.Millisecond, Logger: slog.New(slog.DiscardHandler)})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 最初の取得は Start から返る前に完了する（ソートして保持する）
+	catalog.Start(ctx, "orders")
+	if got := catalog.Instances("orders"); !slices.Equal(got, []string{"10.0.0.1:8080", "10.0.0.2:8080"}) {
+		t.Fatalf("Instances() = %v, want sorted instances", got)
+	}
+
+	// 取得に失敗しても再試行し、変更を反映する
+	deadline := time.Now().Add(time.Second)
+	for !slices.Equal(catalog.Instances("orders"), []string{"10.0.0.3:8080"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Instances() = %v, want updated instances", catalog.Instances("orders"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var b strings.Builder
+	metrics.Default.WriteText(&b)
+	if !strings.Contains(b.String(), `gateway_discovery_instances{service="orders"} 1`) {
+		t.Errorf("metrics should report 1 instance:\n%s", b.String())
+	}
+
+	if got := catalog.Instances("users"); len(got) != 0 {
+		t.Errorf("Instances() for an unwatched service = %v, want empty", got)
+	}
+}
+
+func TestCatalog_Pick(t *testing.T) {
+	source := &fakeSource{updates: []fakeUpdate{{instances: []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"}}}}
+	catalog := New(Config{Source: source.source, Logger: slog.New(slog.DiscardHandler)})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	catalog.Start(ctx, "orders")
+
+	t.Run("ラウンドロビンで選ぶ", func(t *testing.T) {
+		var got []string
+		for range 4 {
+			address, ok := catalog.Pick("orders", nil)
+			if !ok {
+				t.Fatal("Pick() = false, want an instance")
+			}
+			got = append(got, address)
+		}
+		if !slices.Equal(got, []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080", "10.0.0.1:8080"}) {
+			t.Errorf("Pick() = %v, want round robin", got)
+		}
+	})
+
+	t.Run("accept が拒否したインスタンスは飛ばす", func(t *testing.T) {
+		for range 3 {
+			address, ok := catalog.Pick("orders", func(address string) bool { return address == "10.0.0.2:8080" })
+			if !ok || address != "10.0.0.2:8080" {
+				t.Errorf("Pick() = %q, %v, want 10.0.0.2:8080", address, ok)
+			}
+		}
+		if _, ok := catalog.Pick("orders", func(string) bool { return false }); ok {
+			t.Error("Pick() = true, want false when no instance is accepted")
+		}
+	})
+
+	t.Run("インスタンスが無い", func(t *testing.T) {
+		if _, ok := catalog.Pick("users", nil); ok {
+			t.Error("Pick() = true, want false for an unwatched service")
+		}
+		var none *Catalog
+		if _, ok := none.Pick("orders", nil); ok {
+			t.Error("Pick() = true, want false for nil catalog")
+		}
+	})
+}
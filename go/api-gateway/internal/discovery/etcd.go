@@ -0,0 +1,149 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEtcdPrefix はサービスのインスタンスを登録するキーの接頭辞のデフォルト値
+	DefaultEtcdPrefix = "/services/"
+	// DefaultEtcdPollInterval はetcdからインスタンスを取得し直す間隔のデフォルト値
+	DefaultEtcdPollInterval = 10 * time.Second
+)
+
+// EtcdConfig はetcdに登録されたインスタンスを取得する設定
+// インスタンスは "<prefix><サービス名>/<任意のID>" のキーに、アドレス（host:port またはURL）を値として登録する
+type EtcdConfig struct {
+	// Endpoints はetcdのクライアントURL（順に試し、最初に応答したものを使う）
+	Endpoints []string
+	// Prefix はキーの接頭辞（空は DefaultEtcdPrefix）
+	Prefix string
+	// PollInterval は取得し直す間隔（0は DefaultEtcdPollInterval）
+	PollInterval time.Duration
+	Client       *http.Client
+}
+
+// etcdRangeResponse は /v3/kv/range のレスポンスのうち使用するフィールド（int64とbytesはJSONでは文字列）
+type etcdRangeResponse struct {
+	Header struct {
+		Revision string `json:"revision"`
+	} `json:"header"`
+	Kvs []struct {
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+// NewEtcdSource はetcdのv3 APIでサービスのキーの範囲を取得するSourceを作成する
+// 2回目以降の取得は PollInterval だけ待ってから取得し直す
+func NewEtcdSource(config EtcdConfig) Source {
+	if config.Prefix == "" {
+		config.Prefix = DefaultEtcdPrefix
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultEtcdPollInterval
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	return func(ctx context.Context, service string, index uint64) ([]string, uint64, error) {
+		if index > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, index, ctx.Err()
+			case <-time.After(config.PollInterval):
+			}
+		}
+
+		key := config.Prefix + service + "/"
+		body, err := json.Marshal(map[string][]byte{"key": []byte(key), "range_end": prefixRangeEnd(key)})
+		if err != nil {
+			return nil, index, fmt.Errorf("failed to encode etcd request: %w", err)
+		}
+
+		var errs []error
+		for _, endpoint := range config.Endpoints {
+			instances, revision, err := etcdRange(ctx, config.Client, endpoint, body)
+			if err == nil {
+				return instances, revision, nil
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+		}
+		return nil, index, fmt.Errorf("failed to query etcd: %w", errors.Join(errs...))
+	}
+}
+
+// etcdRange は endpoint の /v3/kv/range でキーの範囲を取得し、値のアドレスとリビジョンを返す
+func etcdRange(ctx context.Context, client *http.Client, endpoint string, body []byte) ([]string, uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("etcd returned status %d", resp.StatusCode)
+	}
+	var rangeResp etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rangeResp); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	revision, err := strconv.ParseUint(rangeResp.Header.Revision, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid etcd revision: %w", err)
+	}
+
+	instances := make([]string, 0, len(rangeResp.Kvs))
+	for _, kv := range rangeResp.Kvs {
+		address, err := instanceAddress(string(kv.Value))
+		if err != nil {
+			return nil, 0, err
+		}
+		instances = append(instances, address)
+	}
+	return instances, revision, nil
+}
+
+// instanceAddress は登録された値（host:port またはURL）からアドレスを取り出す
+func instanceAddress(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid instance url: %q", value)
+		}
+		return u.Host, nil
+	}
+	if value == "" {
+		return "", fmt.Errorf("empty instance address")
+	}
+	return value, nil
+}
+
+// prefixRangeEnd は prefix で始まる全てのキーを範囲で指定するための range_end を返す
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// 全てのバイトが0xffの場合は以降の全てのキー
+	return []byte{0}
+}
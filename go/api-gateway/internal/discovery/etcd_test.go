@@ -0,0 +1,67 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestEtcdSource(t *testing.T) {
+	var gotKey, gotRangeEnd string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+		}
+		if r.URL.Path != "/v3/kv/range" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		gotKey, gotRangeEnd = string(req.Key), string(req.RangeEnd)
+		json.NewEncoder(w).Encode(map[string]any{
+			"header": map[string]string{"revision": "7"},
+			"kvs": []map[string][]byte{
+				{"key": []byte("/services/orders/a"), "value": []byte("10.0.0.1:8080")},
+				{"key": []byte("/services/orders/b"), "value": []byte("http://10.0.0.2:8080")},
+			},
+		})
+	}))
+	defer server.Close()
+
+	t.Run("サービスのキーの範囲を取得する", func(t *testing.T) {
+		// 応答しないエンドポイントは飛ばす
+		source := NewEtcdSource(EtcdConfig{Endpoints: []string{"http://127.0.0.1:1", server.URL}})
+		instances, revision, err := source(context.Background(), "orders", 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(instances, []string{"10.0.0.1:8080", "10.0.0.2:8080"}) {
+			t.Errorf("instances = %v", instances)
+		}
+		if revision != 7 {
+			t.Errorf("revision = %d, want 7", revision)
+		}
+		if gotKey != "/services/orders/" || gotRangeEnd != "/services/orders0" {
+			t.Errorf("key = %q, range_end = %q", gotKey, gotRangeEnd)
+		}
+	})
+
+	t.Run("2回目以降は間隔を空けて取得する", func(t *testing.T) {
+		source := NewEtcdSource(EtcdConfig{Endpoints: []string{server.URL}})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, _, err := source(ctx, "orders", 7); err != context.Canceled {
+			t.Errorf("error = %v, want context.Canceled while waiting", err)
+		}
+	})
+
+	t.Run("全てのエンドポイントに失敗", func(t *testing.T) {
+		source := NewEtcdSource(EtcdConfig{Endpoints: []string{"http://127.0.0.1:1"}})
+		if _, _, err := source(context.Background(), "orders", 0); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
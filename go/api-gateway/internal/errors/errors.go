@@ -49,15 +49,55 @@ type ErrorResponse struct {
 
 // ToJSON はエラーをJSON形式に変換する
 func ToJSON(err GatewayError) []byte {
+	return ToLocalizedJSON(err, DefaultLocale)
+}
+
+// ToLocalizedJSON は message を locale の言語にしてエラーをJSON形式に変換する
+func ToLocalizedJSON(err GatewayError, locale Locale) []byte {
 	resp := ErrorResponse{}
 	resp.Error.Code = err.ErrorCode()
-	resp.Error.Message = err.Error()
+	resp.Error.Message = ErrorMessage(locale, err)
 	resp.Error.Details = err.Details()
 
 	data, _ := json.Marshal(resp)
 	return data
 }
 
+// ContentTypeProblemJSON はProblem Details（RFC 9457）のContent-Type
+const ContentTypeProblemJSON = "application/problem+json"
+
+// ProblemDetails はProblem Details（RFC 9457）のJSON構造
+// code と details はゲートウェイ独自の拡張メンバー（ErrorResponse と同じ値）
+type ProblemDetails struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// ToProblemJSON はエラーをProblem Details形式のJSONに変換する
+// instance はエラーが発生したリクエストのパス
+func ToProblemJSON(err GatewayError, instance string) []byte {
+	return ToLocalizedProblemJSON(err, instance, DefaultLocale)
+}
+
+// ToLocalizedProblemJSON は title と detail を locale の言語にしてエラーをProblem Details形式のJSONに変換する
+func ToLocalizedProblemJSON(err GatewayError, instance string, locale Locale) []byte {
+	data, _ := json.Marshal(ProblemDetails{
+		Type:     "about:blank",
+		Title:    ProblemTitle(locale, err.StatusCode()),
+		Status:   err.StatusCode(),
+		Detail:   ErrorMessage(locale, err),
+		Instance: instance,
+		Code:     err.ErrorCode(),
+		Details:  err.Details(),
+	})
+	return data
+}
+
 // NewError はエラーを生成する
 func NewError(statusCode int, errorCode, message string) GatewayError {
 	return &gatewayError{
@@ -100,6 +140,11 @@ func NewNotFoundError(message string) GatewayError {
 	return NewError(http.StatusNotFound, "NOT_FOUND", message)
 }
 
+// NewRequestEntityTooLargeError は413エラーを生成する
+func NewRequestEntityTooLargeError(message string) GatewayError {
+	return NewError(http.StatusRequestEntityTooLarge, "REQUEST_ENTITY_TOO_LARGE", message)
+}
+
 // NewInternalServerError は500エラーを生成する
 func NewInternalServerError(message string) GatewayError {
 	return NewError(http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", message)
@@ -128,6 +173,33 @@ func WrapError(err error, statusCode int, errorCode string) GatewayError {
 	return NewError(statusCode, errorCode, err.Error())
 }
 
+// headerError はエラーレスポンスに設定するヘッダーを伴うGatewayError
+type headerError struct {
+	GatewayError
+	headers http.Header
+}
+
+// WithHeader はエラーレスポンスに設定するヘッダー（WWW-Authenticate など）を追加したエラーを返す
+func WithHeader(err GatewayError, key, value string) GatewayError {
+	headers := ResponseHeaders(err).Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Add(key, value)
+	if he, ok := err.(*headerError); ok {
+		err = he.GatewayError
+	}
+	return &headerError{GatewayError: err, headers: headers}
+}
+
+// ResponseHeaders はエラーレスポンスに設定するヘッダーを返す（無い場合はnil）
+func ResponseHeaders(err GatewayError) http.Header {
+	if he, ok := err.(*headerError); ok {
+		return he.headers
+	}
+	return nil
+}
+
 // IsGatewayError はエラーがGatewayErrorかどうかを判定する
 func IsGatewayError(err error) bool {
 	_, ok := err.(GatewayError)
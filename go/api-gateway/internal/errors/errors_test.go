@@ -117,6 +117,12 @@ func TestPredefinedErrors(t *testing.T) {
 			wantStatus: http.StatusNotFound,
 			wantCode:   "NOT_FOUND",
 		},
+		{
+			name:       "RequestEntityTooLargeError",
+			createErr:  NewRequestEntityTooLargeError,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "REQUEST_ENTITY_TOO_LARGE",
+		},
 		{
 			name:       "InternalServerError",
 			createErr:  NewInternalServerError,
@@ -211,6 +217,109 @@ func TestToJSON(t *testing.T) {
 	}
 }
 
+func TestToProblemJSON(t *testing.T) {
+	err := NewErrorWithDetails(http.StatusForbidden, "DATA_RESIDENCY_VIOLATION", "request crosses regions", map[string]any{"region": "eu"})
+
+	var problem ProblemDetails
+	if jsonErr := json.Unmarshal(ToProblemJSON(err, "/api/v1/orders"), &problem); jsonErr != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", jsonErr)
+	}
+
+	want := ProblemDetails{
+		Type:     "about:blank",
+		Title:    "Forbidden",
+		Status:   http.StatusForbidden,
+		Detail:   "request crosses regions",
+		Instance: "/api/v1/orders",
+		Code:     "DATA_RESIDENCY_VIOLATION",
+		Details:  map[string]any{"region": "eu"},
+	}
+	if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status ||
+		problem.Detail != want.Detail || problem.Instance != want.Instance || problem.Code != want.Code ||
+		problem.Details["region"] != "eu" {
+		t.Errorf("ToProblemJSON() = %+v, want %+v", problem, want)
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           Locale
+	}{
+		{name: "未指定は英語", acceptLanguage: "", want: LocaleEn},
+		{name: "日本語", acceptLanguage: "ja", want: LocaleJa},
+		{name: "地域のサブタグは無視する", acceptLanguage: "ja-JP", want: LocaleJa},
+		{name: "q値の高い言語を優先する", acceptLanguage: "en;q=0.5, ja;q=0.9", want: LocaleJa},
+		{name: "q値が同じ場合は先に書かれた言語", acceptLanguage: "en, ja", want: LocaleEn},
+		{name: "対応しない言語は飛ばす", acceptLanguage: "fr, ja;q=0.8", want: LocaleJa},
+		{name: "q=0は選ばない", acceptLanguage: "ja;q=0", want: LocaleEn},
+		{name: "不正なq値は無視する", acceptLanguage: "ja;q=abc", want: LocaleEn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateLocale(tt.acceptLanguage); got != tt.want {
+				t.Errorf("NegotiateLocale(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToLocalizedProblemJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        GatewayError
+		locale     Locale
+		wantTitle  string
+		wantDetail string
+	}{
+		{name: "英語", err: NewForbiddenError("forbidden"), locale: LocaleEn, wantTitle: "Forbidden", wantDetail: "forbidden"},
+		{name: "日本語", err: NewForbiddenError("forbidden"), locale: LocaleJa, wantTitle: "アクセスが許可されていません", wantDetail: "このリソースへのアクセスは許可されていません"},
+		{name: "翻訳の無いステータスはStatusText", err: NewError(http.StatusTeapot, "TEAPOT", "teapot"), locale: LocaleJa, wantTitle: "I'm a teapot", wantDetail: "teapot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var problem ProblemDetails
+			if err := json.Unmarshal(ToLocalizedProblemJSON(tt.err, "/api", tt.locale), &problem); err != nil {
+				t.Fatalf("failed to unmarshal JSON: %v", err)
+			}
+			if problem.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", problem.Title, tt.wantTitle)
+			}
+			if problem.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", problem.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestToLocalizedJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         GatewayError
+		locale      Locale
+		wantMessage string
+	}{
+		{name: "英語はエラーのメッセージ", err: NewNotFoundError("route not found: GET /api"), locale: LocaleEn, wantMessage: "route not found: GET /api"},
+		{name: "日本語はエラーコードの翻訳", err: NewNotFoundError("route not found: GET /api"), locale: LocaleJa, wantMessage: "リクエストされたパスに一致するルートがありません"},
+		{name: "翻訳の無いエラーコードはエラーのメッセージ", err: NewError(http.StatusTeapot, "TEAPOT", "teapot"), locale: LocaleJa, wantMessage: "teapot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp ErrorResponse
+			if err := json.Unmarshal(ToLocalizedJSON(tt.err, tt.locale), &resp); err != nil {
+				t.Fatalf("failed to unmarshal JSON: %v", err)
+			}
+			if resp.Error.Code != tt.err.ErrorCode() || resp.Error.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want message %q", resp.Error, tt.wantMessage)
+			}
+		})
+	}
+}
+
 func TestWrapError(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -351,3 +460,22 @@ func TestWithContext(t *testing.T) {
 		})
 	}
 }
+
+func TestWithHeader(t *testing.T) {
+	err := NewUnauthorizedError("authentication required")
+	if got := ResponseHeaders(err); got != nil {
+		t.Errorf("ResponseHeaders() = %v, want nil", got)
+	}
+
+	withHeader := WithHeader(WithHeader(err, "WWW-Authenticate", `Basic realm="internal"`), "Cache-Control", "no-store")
+	if withHeader.StatusCode() != http.StatusUnauthorized || withHeader.ErrorCode() != "UNAUTHORIZED" || withHeader.Error() != "authentication required" {
+		t.Errorf("WithHeader() changed the error: %v", withHeader)
+	}
+	headers := ResponseHeaders(withHeader)
+	if headers.Get("WWW-Authenticate") != `Basic realm="internal"` || headers.Get("Cache-Control") != "no-store" {
+		t.Errorf("ResponseHeaders() = %v", headers)
+	}
+	if WrapError(withHeader, http.StatusInternalServerError, "X") != withHeader {
+		t.Error("WrapError() should keep the error with headers")
+	}
+}
@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Locale はクライアントに返すエラーメッセージの言語
+type Locale string
+
+const (
+	LocaleEn Locale = "en"
+	LocaleJa Locale = "ja"
+)
+
+// DefaultLocale は Accept-Language に対応する言語が無い場合に使う言語
+// 既存のクライアントとの互換性のため、title はこれまで通り英語（http.StatusText）を返す
+const DefaultLocale = LocaleEn
+
+// problemTitlesJa は Problem Details の title の日本語
+var problemTitlesJa = map[int]string{
+	http.StatusBadRequest:            "リクエストが不正です",
+	http.StatusUnauthorized:          "認証が必要です",
+	http.StatusForbidden:             "アクセスが許可されていません",
+	http.StatusNotFound:              "リソースが見つかりません",
+	http.StatusMethodNotAllowed:      "許可されていないメソッドです",
+	http.StatusRequestTimeout:        "リクエストがタイムアウトしました",
+	http.StatusRequestEntityTooLarge: "リクエストボディが大きすぎます",
+	http.StatusTooManyRequests:       "リクエストが多すぎます",
+	http.StatusInternalServerError:   "サーバーエラーが発生しました",
+	http.StatusBadGateway:            "バックエンドへの接続に失敗しました",
+	http.StatusServiceUnavailable:    "サービスを利用できません",
+	http.StatusGatewayTimeout:        "バックエンドがタイムアウトしました",
+}
+
+// errorMessagesJa はエラーコードごとのメッセージ（JSON の message と Problem Details の detail）の日本語
+// 英語はエラーのメッセージをそのまま返す。日本語ではバックエンドのアドレスなどの具体的な内容は省き、
+// 診断に必要な値はログと details に残す
+var errorMessagesJa = map[string]string{
+	"BAD_REQUEST":               "リクエストが不正です",
+	"UNAUTHORIZED":              "認証に失敗しました",
+	"FORBIDDEN":                 "このリソースへのアクセスは許可されていません",
+	"NOT_FOUND":                 "リクエストされたパスに一致するルートがありません",
+	"METHOD_NOT_ALLOWED":        "このパスではリクエストされたメソッドを使用できません",
+	"REQUEST_ENTITY_TOO_LARGE":  "リクエストボディが上限を超えています",
+	"UNSUPPORTED_MEDIA_TYPE":    "リクエストの Content-Type に対応していません",
+	"VALIDATION_ERROR":          "リクエストの内容が不正です",
+	"REQUEST_VALIDATION_FAILED": "リクエストがスキーマに一致しません",
+	"CORS_PREFLIGHT_REJECTED":   "CORS のプリフライトリクエストが許可されていません",
+	"MIDDLEWARE_ERROR":          "リクエストが拒否されました",
+	"MIDDLEWARE_SETUP_ERROR":    "ゲートウェイの設定に誤りがあります",
+	"ROUTING_ERROR":             "ルーティング中にエラーが発生しました",
+	"INTERNAL_SERVER_ERROR":     "ゲートウェイでエラーが発生しました",
+	"FEATURE_DISABLED":          "この機能は現在利用できません",
+	"REGION_REQUIRED":           "リクエストのリージョンを特定できません",
+	"REGION_NOT_AVAILABLE":      "リクエストされたリージョンは利用できません",
+	"DATA_RESIDENCY_VIOLATION":  "データの保存先リージョンの制約によりリクエストを転送できません",
+	"SESSION_STORE_UNAVAILABLE": "セッションストアを利用できません",
+	"GRPC_REQUIRED":             "このルートは gRPC のリクエストのみ受け付けます",
+	"GRPC_METHOD_INVALID":       "gRPC のメソッドが不正です",
+	"BAD_GATEWAY":               "バックエンドから不正な応答がありました",
+	"TRANSPORT_ERROR":           "バックエンドへの転送に失敗しました",
+	"SOAP_FAULT":                "バックエンドが SOAP Fault を返しました",
+	"NO_HEALTHY_INSTANCES":      "転送できる正常なバックエンドのインスタンスがありません",
+	"BACKEND_UNHEALTHY":         "バックエンドがヘルスチェックに失敗しています",
+	"BACKEND_EJECTED":           "バックエンドは一時的に転送先から外されています",
+	"ROUTE_CONCURRENCY_LIMITED": "ルートの同時実行数の上限に達しました",
+	"ROUTE_QUEUE_TIMEOUT":       "ルートの実行待ちがタイムアウトしました",
+	"GATEWAY_OVERLOADED":        "ゲートウェイが過負荷のためリクエストを受け付けられません",
+	"SHUTTING_DOWN":             "ゲートウェイは停止処理中です",
+	"GATEWAY_TIMEOUT":           "バックエンドがタイムアウトしました",
+}
+
+// NegotiateLocale は Accept-Language の値から対応する言語のうち最も優先度の高いものを返す
+// 地域のサブタグ（en-US など）は無視して言語で照合し、q値が同じ場合は先に書かれた言語を優先する
+func NegotiateLocale(acceptLanguage string) Locale {
+	best, bestQ := DefaultLocale, 0.0
+	for part := range strings.SplitSeq(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		switch locale := Locale(strings.ToLower(primary)); locale {
+		case LocaleEn, LocaleJa:
+			if q > bestQ {
+				best, bestQ = locale, q
+			}
+		}
+	}
+	return best
+}
+
+// ProblemTitle はステータスコードの title を locale の言語で返す
+// 翻訳が無いステータスコードは http.StatusText を返す
+func ProblemTitle(locale Locale, statusCode int) string {
+	if locale == LocaleJa {
+		if title, ok := problemTitlesJa[statusCode]; ok {
+			return title
+		}
+	}
+	return http.StatusText(statusCode)
+}
+
+// ErrorMessage はエラーのメッセージを locale の言語で返す
+// 翻訳が無いエラーコードと英語はエラーのメッセージをそのまま返す
+func ErrorMessage(locale Locale, err GatewayError) string {
+	if locale == LocaleJa {
+		if message, ok := errorMessagesJa[err.ErrorCode()]; ok {
+			return message
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,134 @@
+// Package featureflag はルートの公開を切り替えるフィーチャーフラグを提供する
+//
+// フラグはゲートウェイ設定の feature_flags.flags で定義し、feature_flags.file を指定した場合は
+// ファイル（フラグ名 → true/false のYAML）の値で上書きする。ファイルは定期的に読み直すため、
+// 再起動や設定のリロードをせずにダークローンチしたルートを公開できる
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRefreshInterval はフラグのファイルを読み直す間隔のデフォルト値
+const DefaultRefreshInterval = 10 * time.Second
+
+// Config はフィーチャーフラグの設定
+type Config struct {
+	// Flags はフラグの初期値
+	Flags map[string]bool
+	// File はフラグの値を読み込むファイル（空の場合は Flags のみ）
+	File string
+	// RefreshInterval は File を読み直す間隔（0は DefaultRefreshInterval）
+	RefreshInterval time.Duration
+	Logger          *slog.Logger
+}
+
+// Provider はフィーチャーフラグの値を返す
+// nil の Provider は全てのフラグを無効とみなす
+type Provider struct {
+	config Config
+
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// New は新しいProviderを作成する。File を指定した場合は読み込み、失敗した場合はエラーを返す
+func New(config Config) (*Provider, error) {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	p := &Provider{config: config, flags: maps.Clone(config.Flags)}
+	if p.flags == nil {
+		p.flags = make(map[string]bool)
+	}
+	if config.File != "" {
+		if err := p.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Enabled はフラグが有効か返す（定義されていないフラグは無効）
+func (p *Provider) Enabled(name string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.flags[name]
+}
+
+// Flags は全てのフラグの値を返す
+func (p *Provider) Flags() map[string]bool {
+	if p == nil {
+		return map[string]bool{}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return maps.Clone(p.flags)
+}
+
+// Start は File を定期的に読み直すゴルーチンを開始する（File が無い場合は何もしない）
+// ctx がキャンセルされると停止する。読み込みに失敗した場合はログに記録し、直前の値を使い続ける
+func (p *Provider) Start(ctx context.Context) {
+	if p == nil || p.config.File == "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(p.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.reload(); err != nil {
+					p.config.Logger.Warn("Failed to reload feature flags",
+						slog.String("file", p.config.File),
+						slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+}
+
+// reload は File を読み込み、設定の初期値に上書きしたフラグに置き換える
+func (p *Provider) reload() error {
+	data, err := os.ReadFile(p.config.File)
+	if err != nil {
+		return fmt.Errorf("failed to read feature flags file: %w", err)
+	}
+	var fileFlags map[string]bool
+	if err := yaml.Unmarshal(data, &fileFlags); err != nil {
+		return fmt.Errorf("failed to parse feature flags file: %w", err)
+	}
+
+	flags := maps.Clone(p.config.Flags)
+	if flags == nil {
+		flags = make(map[string]bool)
+	}
+	maps.Copy(flags, fileFlags)
+
+	p.mu.Lock()
+	changed := !maps.Equal(p.flags, flags)
+	p.flags = flags
+	p.mu.Unlock()
+
+	if changed {
+		p.config.Logger.Info("Feature flags updated", slog.Any("flags", flags))
+	}
+	return nil
+}
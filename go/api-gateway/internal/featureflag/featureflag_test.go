@@ -0,0 +1,103 @@
+package featureflag
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProvider_Enabled(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "flags.yaml")
+	if err := os.WriteFile(file, []byte("new_checkout: true\n"), 0o600); err != nil {
+		t.Fatalf("failed to write flags file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   map[string]bool
+	}{
+		{
+			name:   "設定のフラグ",
+			config: Config{Flags: map[string]bool{"new_checkout": false, "beta": true}},
+			want:   map[string]bool{"new_checkout": false, "beta": true, "undefined": false},
+		},
+		{
+			name:   "ファイルの値で上書きする",
+			config: Config{Flags: map[string]bool{"new_checkout": false, "beta": true}, File: file},
+			want:   map[string]bool{"new_checkout": true, "beta": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Logger = slog.New(slog.DiscardHandler)
+			p, err := New(tt.config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for name, want := range tt.want {
+				if got := p.Enabled(name); got != want {
+					t.Errorf("Enabled(%q) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProvider_NilProvider(t *testing.T) {
+	var p *Provider
+	if p.Enabled("new_checkout") {
+		t.Error("nil provider should treat all flags as disabled")
+	}
+	if len(p.Flags()) != 0 {
+		t.Errorf("Flags() = %v, want empty", p.Flags())
+	}
+	p.Start(context.Background())
+}
+
+func TestNew_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte("new_checkout: [\n"), 0o600)
+
+	for _, file := range []string{filepath.Join(dir, "missing.yaml"), invalid} {
+		if _, err := New(Config{File: file}); err == nil {
+			t.Errorf("New(%s) error = nil, want error", file)
+		}
+	}
+}
+
+func TestProvider_Start(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "flags.yaml")
+	if err := os.WriteFile(file, []byte("new_checkout: false\n"), 0o600); err != nil {
+		t.Fatalf("failed to write flags file: %v", err)
+	}
+	p, err := New(Config{File: file, RefreshInterval: 10 * time.Millisecond, Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+
+	// 読み込みに失敗しても直前の値を使い続ける
+	os.WriteFile(file, []byte("new_checkout: [\n"), 0o600)
+	time.Sleep(30 * time.Millisecond)
+	if p.Enabled("new_checkout") {
+		t.Fatal("flag should keep the previous value after a failed reload")
+	}
+
+	os.WriteFile(file, []byte("new_checkout: true\n"), 0o600)
+	deadline := time.Now().Add(2 * time.Second)
+	for !p.Enabled("new_checkout") {
+		if time.Now().After(deadline) {
+			t.Fatal("flag was not reloaded from the file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
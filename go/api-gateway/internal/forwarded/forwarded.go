@@ -0,0 +1,164 @@
+// Package forwarded は信頼するプロキシの設定に従って X-Forwarded-* ヘッダーを扱い、クライアントの本当のIPアドレスを求める
+package forwarded
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// X-Forwarded-* ヘッダー
+const (
+	HeaderForwardedFor   = "X-Forwarded-For"
+	HeaderForwardedProto = "X-Forwarded-Proto"
+)
+
+// TrustedProxies はゲートウェイの前段にある、X-Forwarded-* ヘッダーを信頼してよいプロキシ（ロードバランサーなど）
+// nil の場合はどのプロキシも信頼しない
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// NewTrustedProxies はIPアドレスまたはCIDR（10.0.0.0/8 など）の一覧から TrustedProxies を作成する
+func NewTrustedProxies(cidrs []string) (*TrustedProxies, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return &TrustedProxies{prefixes: prefixes}, nil
+}
+
+// parsePrefix はIPアドレスまたはCIDRを解析する（IPアドレスは /32 や /128 として扱う）
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// Contains は addr が信頼するプロキシか確認する
+func (p *TrustedProxies) Contains(addr netip.Addr) bool {
+	if p == nil || !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sanitize は接続元が信頼するプロキシでない場合に X-Forwarded-For / X-Forwarded-Proto を削除し、
+// クライアントのIPアドレスをコンテキストに保存したリクエストを返す
+// X-Forwarded-Proto が無い場合はゲートウェイが受けた接続のスキームを設定する
+// 接続元のアドレスは転送時に httputil.ReverseProxy が X-Forwarded-For の末尾に追加する
+func (p *TrustedProxies) Sanitize(r *http.Request) *http.Request {
+	remote := remoteAddr(r)
+	if !p.Contains(remote) {
+		r.Header.Del(HeaderForwardedFor)
+		r.Header.Del(HeaderForwardedProto)
+	}
+	if r.Header.Get(HeaderForwardedProto) == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		r.Header.Set(HeaderForwardedProto, scheme)
+	}
+
+	clientIP := p.clientIP(r, remote)
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey{}, clientIP))
+}
+
+// clientIP は X-Forwarded-For を末尾（接続元に近い側）から辿り、最初に見つかった信頼しないアドレスを返す
+// 全て信頼するプロキシの場合は最も先頭のアドレスを返す
+func (p *TrustedProxies) clientIP(r *http.Request, remote netip.Addr) string {
+	if !remote.IsValid() {
+		return remoteHost(r.RemoteAddr)
+	}
+
+	client := remote
+	if p.Contains(remote) {
+		hops := forwardedFor(r.Header)
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, ok := parseHop(hops[i])
+			if !ok {
+				// 偽装の可能性がある値より先は辿らない
+				break
+			}
+			client = addr
+			if !p.Contains(addr) {
+				break
+			}
+		}
+	}
+	return client.Unmap().String()
+}
+
+// forwardedFor は X-Forwarded-For の全ての値をカンマで区切った一覧を返す
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	for _, value := range h.Values(HeaderForwardedFor) {
+		for hop := range strings.SplitSeq(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseHop は X-Forwarded-For の1要素をIPアドレスとして解析する（ポート付きの値も受け付ける）
+func parseHop(hop string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(hop); err == nil {
+		return addr, true
+	}
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr(), true
+	}
+	return netip.Addr{}, false
+}
+
+// remoteAddr は接続元のIPアドレスを返す（解析できない場合は無効なアドレス）
+func remoteAddr(r *http.Request) netip.Addr {
+	addr, err := netip.ParseAddr(remoteHost(r.RemoteAddr))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// remoteHost は "host:port" 形式の RemoteAddr からホスト部分を返す
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+type clientIPKey struct{}
+
+// ClientIP はクライアントの本当のIPアドレスを返す（ログやレート制限に使う）
+// Sanitize を通していないリクエストの場合は接続元のアドレスを返す
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
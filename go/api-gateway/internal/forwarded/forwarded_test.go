@@ -0,0 +1,182 @@
+package forwarded
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestNewTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		wantErr bool
+	}{
+		{name: "CIDR", cidrs: []string{"10.0.0.0/8", "fd00::/8"}},
+		{name: "IPアドレス", cidrs: []string{"192.168.0.1", "::1"}},
+		{name: "空", cidrs: nil},
+		{name: "不正な値", cidrs: []string{"10.0.0.0/8", "proxy.example.com"}, wantErr: true},
+		{name: "不正なCIDR", cidrs: []string{"10.0.0.0/33"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTrustedProxies(tt.cidrs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrustedProxies_Contains(t *testing.T) {
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/8", "192.168.0.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "10.1.2.3", want: true},
+		{addr: "::ffff:10.1.2.3", want: true},
+		{addr: "192.168.0.1", want: true},
+		{addr: "192.168.0.2", want: false},
+		{addr: "203.0.113.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := proxies.Contains(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nil は何も信頼しない", func(t *testing.T) {
+		var none *TrustedProxies
+		if none.Contains(netip.MustParseAddr("10.1.2.3")) {
+			t.Error("nil TrustedProxies should not trust any address")
+		}
+	})
+}
+
+func TestTrustedProxies_Sanitize(t *testing.T) {
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		proxies       *TrustedProxies
+		remoteAddr    string
+		forwardedFor  []string
+		proto         string
+		wantClientIP  string
+		wantForwarded []string
+		wantProto     string
+	}{
+		{
+			name:         "信頼しないクライアントのヘッダーは削除する",
+			proxies:      proxies,
+			remoteAddr:   "203.0.113.10:54321",
+			forwardedFor: []string{"1.2.3.4"},
+			proto:        "https",
+			wantClientIP: "203.0.113.10",
+			wantProto:    "http",
+		},
+		{
+			name:          "信頼するプロキシのヘッダーは残す",
+			proxies:       proxies,
+			remoteAddr:    "10.0.0.5:54321",
+			forwardedFor:  []string{"203.0.113.10"},
+			proto:         "https",
+			wantClientIP:  "203.0.113.10",
+			wantForwarded: []string{"203.0.113.10"},
+			wantProto:     "https",
+		},
+		{
+			name:          "末尾から信頼するプロキシを読み飛ばす",
+			proxies:       proxies,
+			remoteAddr:    "10.0.0.5:54321",
+			forwardedFor:  []string{"1.2.3.4, 203.0.113.10", "10.0.0.7"},
+			wantClientIP:  "203.0.113.10",
+			wantForwarded: []string{"1.2.3.4, 203.0.113.10", "10.0.0.7"},
+			wantProto:     "http",
+		},
+		{
+			name:          "全て信頼するプロキシの場合は先頭のアドレス",
+			proxies:       proxies,
+			remoteAddr:    "10.0.0.5:54321",
+			forwardedFor:  []string{"10.0.0.9, 10.0.0.7"},
+			wantClientIP:  "10.0.0.9",
+			wantForwarded: []string{"10.0.0.9, 10.0.0.7"},
+			wantProto:     "http",
+		},
+		{
+			name:          "解析できない値より先は辿らない",
+			proxies:       proxies,
+			remoteAddr:    "10.0.0.5:54321",
+			forwardedFor:  []string{"1.2.3.4, unknown, 10.0.0.7"},
+			wantClientIP:  "10.0.0.7",
+			wantForwarded: []string{"1.2.3.4, unknown, 10.0.0.7"},
+			wantProto:     "http",
+		},
+		{
+			name:          "ポート付きの値",
+			proxies:       proxies,
+			remoteAddr:    "10.0.0.5:54321",
+			forwardedFor:  []string{"[2001:db8::1]:443"},
+			wantClientIP:  "2001:db8::1",
+			wantForwarded: []string{"[2001:db8::1]:443"},
+			wantProto:     "http",
+		},
+		{
+			name:         "設定しない場合は全て削除する",
+			remoteAddr:   "10.0.0.5:54321",
+			forwardedFor: []string{"203.0.113.10"},
+			proto:        "https",
+			wantClientIP: "10.0.0.5",
+			wantProto:    "http",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				req.Header.Add(HeaderForwardedFor, v)
+			}
+			if tt.proto != "" {
+				req.Header.Set(HeaderForwardedProto, tt.proto)
+			}
+
+			req = tt.proxies.Sanitize(req)
+
+			if got := ClientIP(req); got != tt.wantClientIP {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.wantClientIP)
+			}
+			if got := req.Header.Values(HeaderForwardedFor); !slices.Equal(got, tt.wantForwarded) {
+				t.Errorf("X-Forwarded-For = %q, want %q", got, tt.wantForwarded)
+			}
+			if got := req.Header.Get(HeaderForwardedProto); got != tt.wantProto {
+				t.Errorf("X-Forwarded-Proto = %q, want %q", got, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestClientIP_WithoutSanitize(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.10:54321"
+	req.Header.Set(HeaderForwardedFor, "1.2.3.4")
+
+	if got := ClientIP(req); got != "203.0.113.10" {
+		t.Errorf("ClientIP() = %q, want 203.0.113.10", got)
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+// statusClientClosedRequest はレスポンスを返す前にクライアントが切断したことを表すステータス（nginxと同じ）
+const statusClientClosedRequest = 499
+
+// accessLogMessage はアクセスログのメッセージ
+const accessLogMessage = "access"
+
+// slowRequestsTotal は slow_request_threshold 以上かかったリクエスト数（ルートにマッチしなかった場合は route=""）
+var slowRequestsTotal = metrics.NewCounterVec(
+	"gateway_slow_requests_total",
+	"Number of requests that took longer than the slow request threshold by route.",
+	"route",
+)
+
+// accessLog はアクセスログに出力するリクエストの処理結果
+// ルートやバックエンドは処理の途中で確定するため、Gatewayが順に埋めていく
+type accessLog struct {
+	start   time.Time
+	route   string
+	backend string
+	region  string
+	aborted bool
+	// matched はルートにマッチした時刻、forwarded はミドルウェアチェーンを抜けて転送の処理を始めた時刻
+	matched   time.Time
+	forwarded time.Time
+	// upstream はバックエンドへの転送（レスポンスの書き込みを含む）にかかった時間
+	upstream time.Duration
+	// journal はリクエストのメタデータをジャーナルへ記録するか
+	journal bool
+	// userID は認証ミドルウェアが確定したユーザー（クレームの sub）
+	// クレームはミドルウェアチェーンの中で ctx に追加されるため、ServeHTTP の ctx からは参照できず転送時に記録する
+	userID string
+}
+
+// accessLogWriter はアクセスログのためにステータスコードと書き込みバイト数を記録するResponseWriter
+type accessLogWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int64
+}
+
+func newAccessLogWriter(w http.ResponseWriter) *accessLogWriter {
+	return &accessLogWriter{ResponseWriter: w}
+}
+
+// WriteHeader は最終レスポンスのステータスコードを記録する
+func (aw *accessLogWriter) WriteHeader(statusCode int) {
+	// 1xxの中間レスポンスは記録しない
+	if aw.statusCode == 0 && statusCode >= http.StatusOK {
+		aw.statusCode = statusCode
+	}
+	aw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write は書き込んだバイト数を記録する
+func (aw *accessLogWriter) Write(b []byte) (int, error) {
+	if aw.statusCode == 0 {
+		aw.statusCode = http.StatusOK
+	}
+	n, err := aw.ResponseWriter.Write(b)
+	aw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap はhttp.ResponseControllerがFlushなどを元のResponseWriterへ委譲するために使う
+func (aw *accessLogWriter) Unwrap() http.ResponseWriter {
+	return aw.ResponseWriter
+}
+
+// logAccess はリクエストごとに1行のアクセスログを出力する
+// method や path などのリクエスト情報はロガーが ctx から http グループとして付与する
+func (g *Gateway) logAccess(ctx context.Context, aw *accessLogWriter, access *accessLog) {
+	statusCode := aw.statusCode
+	switch {
+	case access.aborted:
+		statusCode = statusClientClosedRequest
+	case statusCode == 0:
+		// 何も書き込まずに終了した場合は net/http が 200 を返す
+		statusCode = http.StatusOK
+	}
+
+	duration := time.Since(access.start)
+	attrs := []slog.Attr{
+		slog.Int("status_code", statusCode),
+		slog.Int64("bytes_written", aw.bytes),
+		slog.Duration("duration", duration),
+	}
+	if access.route != "" {
+		attrs = append(attrs, slog.String("route", access.route))
+	}
+	if access.backend != "" {
+		attrs = append(attrs, slog.String("backend", access.backend))
+	}
+	if access.region != "" {
+		attrs = append(attrs, slog.String("region", access.region))
+	}
+
+	g.logger.LogAttrs(ctx, slog.LevelInfo, accessLogMessage, attrs...)
+
+	if g.slowThreshold > 0 && duration >= g.slowThreshold {
+		g.logSlowRequest(ctx, statusCode, duration, access)
+	}
+
+	if access.journal && g.journal != nil {
+		g.recordJournal(ctx, statusCode, aw.bytes, access)
+	}
+}
+
+// KeepAccessLog はステータスコードが400以上のアクセスログか確認する
+// logging.sampling で成功したリクエストのアクセスログのみを間引き、エラーは全て記録するために使う
+func KeepAccessLog(r slog.Record) bool {
+	if r.Message != accessLogMessage {
+		return false
+	}
+	keep := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != "status_code" {
+			return true
+		}
+		keep = a.Value.Int64() >= http.StatusBadRequest
+		return false
+	})
+	return keep
+}
+
+// logSlowRequest は slow_request_threshold 以上かかったリクエストを、所要時間の内訳とともにWARNで記録する
+// middleware_duration はルートのマッチからミドルウェアチェーンを抜けるまで（ミドルウェアが拒否した場合は終了まで）、
+// upstream_duration はバックエンドへの転送にかかった時間
+func (g *Gateway) logSlowRequest(ctx context.Context, statusCode int, duration time.Duration, access *accessLog) {
+	slowRequestsTotal.With(access.route).Inc()
+
+	attrs := []slog.Attr{
+		slog.String("route", access.route),
+		slog.String("backend", access.backend),
+		slog.Int("status_code", statusCode),
+		slog.Duration("duration", duration),
+		slog.Duration("threshold", g.slowThreshold),
+	}
+	if !access.matched.IsZero() {
+		end := access.forwarded
+		if end.IsZero() {
+			end = access.start.Add(duration)
+		}
+		attrs = append(attrs, slog.Duration("middleware_duration", end.Sub(access.matched)))
+	}
+	attrs = append(attrs, slog.Duration("upstream_duration", access.upstream))
+
+	g.logger.LogAttrs(ctx, slog.LevelWarn, "slow request", attrs...)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestKeepAccessLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		status  int
+		want    bool
+	}{
+		{name: "成功したリクエストは間引く", message: accessLogMessage, status: 200, want: false},
+		{name: "クライアントエラーは記録する", message: accessLogMessage, status: 404, want: true},
+		{name: "サーバーエラーは記録する", message: accessLogMessage, status: 502, want: true},
+		{name: "クライアントの切断は記録する", message: accessLogMessage, status: statusClientClosedRequest, want: true},
+		{name: "アクセスログ以外はレベルで判定する", message: "request journal", status: 500, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, tt.message, 0)
+			r.AddAttrs(slog.Int("status_code", tt.status), slog.String("route", "/api"))
+			if got := KeepAccessLog(r); got != tt.want {
+				t.Errorf("KeepAccessLog() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"api-gateway/internal/audit"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/requestctx"
+	"api-gateway/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultAdminRole はJWTで管理APIへアクセスするために必要なロールのデフォルト値
+const DefaultAdminRole = "admin"
+
+// AdminAuthConfig は管理APIの認証の設定
+type AdminAuthConfig struct {
+	// APIKey は X-API-Key ヘッダーで受け付けるAPIキー（APIKeys を指定した場合は使わない）
+	APIKey string
+	// APIKeys は X-API-Key ヘッダーで受け付ける複数のAPIキー（キーのローテーション用）
+	APIKeys *AdminKeys
+	// JWT は Authorization: Bearer のJWTを検証するミドルウェア（nilの場合はAPIキーのみ受け付ける）
+	JWT *auth.JWTMiddleware
+	// Role はJWTに必要なロール（空は DefaultAdminRole）
+	Role string
+	// Basic は Authorization: Basic のユーザー名とパスワードを検証するミドルウェア（nilの場合はBasic認証を受け付けない）
+	// 失敗は RequireAdmin が記録するため、監査ロガーを指定せずに作成する
+	Basic *auth.BasicAuthMiddleware
+	// Audit は拒否したリクエストとJWTでのアクセスを記録する監査ロガー（nilの場合は記録しない）
+	Audit *audit.Logger
+}
+
+// RequireAPIKey は X-API-Key ヘッダーで管理APIへのアクセスを制限する
+// 拒否したリクエストは auditLog に記録する（nilの場合は記録しない）
+func RequireAPIKey(apiKey string, auditLog *audit.Logger, next http.Handler) http.Handler {
+	return RequireAdmin(AdminAuthConfig{APIKey: apiKey, Audit: auditLog}, next)
+}
+
+// RequireAdmin は X-API-Key ヘッダー、管理者ロールを持つJWT、またはBasic認証で管理APIへのアクセスを制限する
+// JWT・Basic認証で認証した場合は管理者（sub）ごとにアクセスを監査ログに記録し、クレームをコンテキストに保存する
+func RequireAdmin(config AdminAuthConfig, next http.Handler) http.Handler {
+	if config.Role == "" {
+		config.Role = DefaultAdminRole
+	}
+	if config.APIKeys == nil {
+		config.APIKeys = NewStaticAdminKeys(config.APIKey)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := logger.WithHTTPRequest(req.Context(), req)
+		principal, err := authenticateAdmin(req, config)
+		if err != nil {
+			eventType := audit.EventAuthFailure
+			if err.StatusCode() == http.StatusForbidden {
+				eventType = audit.EventAuthzDenied
+			}
+			config.Audit.Log(ctx, audit.Event{
+				Type:    eventType,
+				Outcome: audit.OutcomeFailure,
+				Actor:   principal.Actor,
+				Reason:  err.Error(),
+			})
+			writeJSONError(w, err)
+			return
+		}
+
+		if principal.Claims != nil {
+			config.Audit.Log(ctx, audit.Event{
+				Type:    audit.EventAdminAccess,
+				Outcome: audit.OutcomeSuccess,
+				Actor:   principal.Actor,
+			})
+			req = req.WithContext(requestctx.WithClaims(req.Context(), principal.Claims))
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// adminPrincipal は管理APIへのリクエストを認証した結果
+type adminPrincipal struct {
+	// Actor はJWT・Basic認証で認証した管理者（sub）。APIキーの場合は空
+	Actor string
+	// Claims はJWTのクレーム、またはBasic認証のユーザー名の "sub" クレーム（APIキーの場合はnil）
+	Claims jwt.MapClaims
+}
+
+// authenticateAdmin は管理APIへのリクエストを X-API-Key、管理者ロールを持つJWT、またはBasic認証で認証する
+// X-API-Key がある場合はAPIキーで認証する。Basic認証を受け付ける場合、Authorization ヘッダーが無いリクエストには
+// ブラウザが認証情報を入力できるようBasic認証のチャレンジを返す
+// ロールを持たないJWTの場合は、監査ログに記録できるよう Actor を設定した結果と403エラーを返す
+func authenticateAdmin(req *http.Request, config AdminAuthConfig) (adminPrincipal, errors.GatewayError) {
+	key, authorization := req.Header.Get("X-API-Key"), req.Header.Get("Authorization")
+	scheme, _, _ := strings.Cut(authorization, " ")
+	switch {
+	case key == "" && config.Basic != nil && (authorization == "" || strings.EqualFold(scheme, "Basic")):
+		return authenticateAdminBasic(req, config.Basic)
+	case key == "" && config.JWT != nil && authorization != "":
+		return authenticateAdminJWT(req, config.JWT, config.Role)
+	}
+	if !config.APIKeys.Match(key) {
+		return adminPrincipal{}, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid or missing API key")
+	}
+	return adminPrincipal{}, nil
+}
+
+// authenticateAdminJWT は管理者ロールを持つJWTで認証する
+func authenticateAdminJWT(req *http.Request, jwtMiddleware *auth.JWTMiddleware, role string) (adminPrincipal, errors.GatewayError) {
+	ctx, err := jwtMiddleware.Process(req.Context(), req)
+	if err != nil {
+		return adminPrincipal{}, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid admin token")
+	}
+	claims, _ := requestctx.Claims(ctx)
+	actor, _ := claims["sub"].(string)
+	if !auth.HasRole(claims, role) {
+		return adminPrincipal{Actor: actor}, errors.NewError(http.StatusForbidden, "Forbidden", fmt.Sprintf("admin token requires role: %s", role))
+	}
+	return adminPrincipal{Actor: actor, Claims: claims}, nil
+}
+
+// authenticateAdminBasic はBasic認証で認証する（失敗した場合は WWW-Authenticate を付けた401エラー）
+func authenticateAdminBasic(req *http.Request, basic *auth.BasicAuthMiddleware) (adminPrincipal, errors.GatewayError) {
+	ctx, err := basic.Process(req.Context(), req)
+	if err != nil {
+		if gatewayErr, ok := err.(errors.GatewayError); ok {
+			return adminPrincipal{}, gatewayErr
+		}
+		return adminPrincipal{}, errors.NewUnauthorizedError(err.Error())
+	}
+	claims, _ := requestctx.Claims(ctx)
+	actor, _ := claims["sub"].(string)
+	return adminPrincipal{Actor: actor, Claims: claims}, nil
+}
+
+// writeJSONError はエラーレスポンスを書き込む
+func writeJSONError(w http.ResponseWriter, err errors.GatewayError) {
+	for key, values := range errors.ResponseHeaders(err) {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode())
+	w.Write(errors.ToJSON(err))
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAdminKeysRefreshInterval は管理APIのAPIキーのファイルを読み直す間隔のデフォルト値
+const DefaultAdminKeysRefreshInterval = 10 * time.Second
+
+// AdminKeysConfig は管理APIで受け付けるAPIキーの読み込み元の設定
+type AdminKeysConfig struct {
+	// Keys はAPIキー（環境変数などから渡す）
+	Keys []string
+	// File は1行に1つのAPIキーを書いたファイル（空行と # から始まる行は無視する）
+	// Keys に加えて受け付け、RefreshInterval ごとに読み直す
+	File string
+	// RefreshInterval は File を読み直す間隔（0は DefaultAdminKeysRefreshInterval）
+	RefreshInterval time.Duration
+	Logger          *slog.Logger
+}
+
+// AdminKeys は管理APIで受け付けるAPIキーの集合
+// ローテーション中は新旧のキーを両方受け付け、ファイルから古いキーを削除すると以降のリクエストを拒否する
+// キーはハッシュで保持し、長さを含めて比較の時間からキーを推測できないようにする
+type AdminKeys struct {
+	config AdminKeysConfig
+
+	mu     sync.RWMutex
+	hashes [][sha256.Size]byte
+}
+
+// AdminKeysConfigFromEnv は環境変数 ADMIN_API_KEY（カンマ区切りで複数指定可）と
+// ADMIN_API_KEY_FILE から管理APIのAPIキーの設定を作成する
+func AdminKeysConfigFromEnv(getenv func(string) string) AdminKeysConfig {
+	var keys []string
+	for key := range strings.SplitSeq(getenv("ADMIN_API_KEY"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return AdminKeysConfig{Keys: keys, File: getenv("ADMIN_API_KEY_FILE")}
+}
+
+// NewStaticAdminKeys は keys のみを受け付ける AdminKeys を作成する（空のキーは無視する）
+func NewStaticAdminKeys(keys ...string) *AdminKeys {
+	k := &AdminKeys{config: AdminKeysConfig{Keys: keys}}
+	k.hashes = hashAdminKeys(keys)
+	return k
+}
+
+// NewAdminKeys は新しいAdminKeysを作成する。File を指定した場合は読み込み、失敗した場合はエラーを返す
+func NewAdminKeys(config AdminKeysConfig) (*AdminKeys, error) {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultAdminKeysRefreshInterval
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	k := &AdminKeys{config: config, hashes: hashAdminKeys(config.Keys)}
+	if config.File != "" {
+		if err := k.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Match は key が受け付けるAPIキーのいずれかと一致するか返す
+// 一致したキーに関わらず全てのキーと比較し、比較にかかる時間を一定にする
+func (k *AdminKeys) Match(key string) bool {
+	if k == nil || key == "" {
+		return false
+	}
+	hash := sha256.Sum256([]byte(key))
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	match := 0
+	for _, h := range k.hashes {
+		match |= subtle.ConstantTimeCompare(hash[:], h[:])
+	}
+	return match == 1
+}
+
+// Len は受け付けるAPIキーの数を返す
+func (k *AdminKeys) Len() int {
+	if k == nil {
+		return 0
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.hashes)
+}
+
+// Start は File を定期的に読み直すゴルーチンを開始する（File が無い場合は何もしない）
+// ctx がキャンセルされると停止する。読み込みに失敗した場合はログに記録し、直前のキーを使い続ける
+func (k *AdminKeys) Start(ctx context.Context) {
+	if k == nil || k.config.File == "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(k.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.reload(); err != nil {
+					k.config.Logger.Warn("Failed to reload admin API keys",
+						slog.String("file", k.config.File),
+						slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+}
+
+// reload は File を読み込み、Keys と合わせたキーに置き換える
+// ファイルにキーが1つも無い場合は、全てのアクセスを拒否しないようエラーにして直前のキーを使い続ける
+func (k *AdminKeys) reload() error {
+	data, err := os.ReadFile(k.config.File)
+	if err != nil {
+		return fmt.Errorf("failed to read admin API keys file: %w", err)
+	}
+
+	var fileKeys []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fileKeys = append(fileKeys, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to parse admin API keys file: %w", err)
+	}
+	if len(fileKeys) == 0 {
+		return fmt.Errorf("admin API keys file has no keys: %s", k.config.File)
+	}
+
+	hashes := hashAdminKeys(append(fileKeys, k.config.Keys...))
+
+	k.mu.Lock()
+	changed := !slices.Equal(k.hashes, hashes)
+	k.hashes = hashes
+	k.mu.Unlock()
+
+	if changed {
+		k.config.Logger.Info("Admin API keys updated", slog.Int("keys", len(hashes)))
+	}
+	return nil
+}
+
+// hashAdminKeys は空でないキーのハッシュを返す
+func hashAdminKeys(keys []string) [][sha256.Size]byte {
+	hashes := make([][sha256.Size]byte, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			hashes = append(hashes, sha256.Sum256([]byte(key)))
+		}
+	}
+	return hashes
+}
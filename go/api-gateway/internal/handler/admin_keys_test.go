@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAdminKeys_Match(t *testing.T) {
+	keys := NewStaticAdminKeys("new-key", "old-key", "")
+
+	tests := []struct {
+		name string
+		keys *AdminKeys
+		key  string
+		want bool
+	}{
+		{name: "新しいキー", keys: keys, key: "new-key", want: true},
+		{name: "ローテーション中の古いキー", keys: keys, key: "old-key", want: true},
+		{name: "間違ったキー", keys: keys, key: "wrong-key", want: false},
+		{name: "キーの前方一致は拒否", keys: keys, key: "new-key-2", want: false},
+		{name: "空のキーは拒否", keys: keys, key: "", want: false},
+		{name: "nilは全て拒否", keys: nil, key: "new-key", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keys.Match(tt.key); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if got := keys.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestAdminKeys_Reload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "admin-api-keys")
+	writeKeys := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write keys file: %v", err)
+		}
+	}
+	writeKeys("# ローテーション中\nkey-1\n\n  key-2  \n")
+
+	keys, err := NewAdminKeys(AdminKeysConfig{
+		Keys:   []string{"env-key"},
+		File:   file,
+		Logger: slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatalf("NewAdminKeys() error = %v", err)
+	}
+	for _, key := range []string{"key-1", "key-2", "env-key"} {
+		if !keys.Match(key) {
+			t.Errorf("Match(%q) = false, want true", key)
+		}
+	}
+	if keys.Match("# ローテーション中") {
+		t.Error("comment line should not be accepted as a key")
+	}
+
+	// 古いキーをファイルから削除すると以降は拒否する
+	writeKeys("key-2\n")
+	if err := keys.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if keys.Match("key-1") {
+		t.Error("removed key-1 should be rejected after reload")
+	}
+	if !keys.Match("key-2") || !keys.Match("env-key") {
+		t.Error("key-2 and env-key should be accepted after reload")
+	}
+
+	// キーが無いファイルはエラーにし、直前のキーを使い続ける
+	writeKeys("# empty\n")
+	if err := keys.reload(); err == nil {
+		t.Error("reload() error = nil, want error for a file without keys")
+	}
+	if !keys.Match("key-2") {
+		t.Error("key-2 should still be accepted after a failed reload")
+	}
+}
+
+func TestNewAdminKeys_FileNotFound(t *testing.T) {
+	_, err := NewAdminKeys(AdminKeysConfig{File: filepath.Join(t.TempDir(), "missing")})
+	if err == nil {
+		t.Error("NewAdminKeys() error = nil, want error for a missing file")
+	}
+}
+
+func TestAdminKeysConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"ADMIN_API_KEY":      " new-key, old-key ,,",
+		"ADMIN_API_KEY_FILE": "/run/secrets/admin-api-keys",
+	}
+	got := AdminKeysConfigFromEnv(func(name string) string { return env[name] })
+
+	want := AdminKeysConfig{Keys: []string{"new-key", "old-key"}, File: "/run/secrets/admin-api-keys"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AdminKeysConfigFromEnv() = %+v, want %+v", got, want)
+	}
+}
@@ -1,30 +1,48 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
 	"api-gateway/internal/repository"
+	"api-gateway/pkg/logger"
 )
 
 // AdminRevokeConfig はAdminRevokeハンドラの設定
 type AdminRevokeConfig struct {
 	Repository    repository.SessionRepository
-	APIKey        string        // 管理者APIキー
-	JWTExpiration time.Duration // JWTの有効期限（Redis TTL用、デフォルト: 10時間)
+	APIKey        string              // 管理者APIキー（APIKeys を指定した場合は使わない）
+	APIKeys       *AdminKeys          // 管理者APIキー（複数、キーのローテーション用）
+	JWT           *auth.JWTMiddleware // 管理者のJWTを検証するミドルウェア（任意、nilの場合はAPIキーのみ）
+	AdminRole     string              // JWTに必要なロール（デフォルト: admin）
+	JWTExpiration time.Duration       // JWTの有効期限（Redis TTL用、デフォルト: 10時間)
 	Logger        *slog.Logger
+	Audit         *audit.Logger    // 強制失効の呼び出しを記録する監査ロガー（任意）
+	MaxBatchSize  int              // 一括失効で受け付けるユーザー数の上限（デフォルト: 100）
+	Now           func() time.Time // 失効時刻に使う現在時刻を返す（nilの場合は time.Now）
 }
 
+// DefaultMaxBatchSize は一括失効で受け付けるユーザー数の上限のデフォルト値
+const DefaultMaxBatchSize = 100
+
 // AdminRevokeHandler は管理者による強制Revoke処理を行うハンドラ
 type AdminRevokeHandler struct {
 	repository    repository.SessionRepository
-	apiKey        string
+	apiKeys       *AdminKeys
+	adminJWT      *auth.JWTMiddleware
+	adminRole     string
 	jwtExpiration time.Duration
 	logger        *slog.Logger
+	audit         *audit.Logger
+	maxBatchSize  int
+	now           func() time.Time
 }
 
 // RevokeRequest はRevoke APIのリクエストボディ
@@ -32,36 +50,78 @@ type RevokeRequest struct {
 	UserID string `json:"user_id"`
 }
 
+// BatchRevokeRequest は一括Revoke APIのリクエストボディ
+type BatchRevokeRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// BatchRevokeResult は一括Revoke APIのユーザーごとの結果
+type BatchRevokeResult struct {
+	UserID  string `json:"user_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchRevokeResponse は一括Revoke APIのレスポンスボディ
+type BatchRevokeResponse struct {
+	RevokedAt string              `json:"revoked_at"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []BatchRevokeResult `json:"results"`
+}
+
+// RevokeStatusResponse は失効状態の確認APIのレスポンスボディ
+type RevokeStatusResponse struct {
+	UserID  string `json:"user_id"`
+	Revoked bool   `json:"revoked"`
+	// RevokedAt はこの時刻より前に発行されたトークンを拒否する失効時刻（失効していない場合は省略）
+	RevokedAt string `json:"revoked_at,omitempty"`
+	// TTLSeconds は失効時刻を保持する残り秒数（有効期限がない場合は0）
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// ExpiresAt は失効時刻が削除される時刻（有効期限がない場合は省略）
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
 // NewAdminRevokeHandler は新しいAdminRevokeHandlerを作成する
 func NewAdminRevokeHandler(config AdminRevokeConfig) *AdminRevokeHandler {
 	// デフォルト値の設定
 	if config.JWTExpiration == 0 {
 		config.JWTExpiration = 10 * time.Hour
 	}
+	if config.AdminRole == "" {
+		config.AdminRole = DefaultAdminRole
+	}
 	if config.Logger == nil {
 		config.Logger = slog.Default()
 	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if config.APIKeys == nil {
+		config.APIKeys = NewStaticAdminKeys(config.APIKey)
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
 
 	return &AdminRevokeHandler{
 		repository:    config.Repository,
-		apiKey:        config.APIKey,
+		apiKeys:       config.APIKeys,
+		adminJWT:      config.JWT,
+		adminRole:     config.AdminRole,
 		jwtExpiration: config.JWTExpiration,
 		logger:        config.Logger,
+		audit:         config.Audit,
+		maxBatchSize:  config.MaxBatchSize,
+		now:           config.Now,
 	}
 }
 
 // ServeHTTP はHTTPリクエストを処理する
 func (h *AdminRevokeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	// POSTメソッドのみ許可
-	if req.Method != http.MethodPost {
-		h.writeError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only POST method is allowed"))
-		return
-	}
-
-	// APIキー認証
-	if err := h.authenticate(req); err != nil {
-		h.logger.Warn("authentication failed", "error", err)
-		h.writeError(w, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid or missing API key"))
+	ctx := logger.WithHTTPRequest(req.Context(), req)
+	actor, ok := h.authorize(ctx, w, req, http.MethodPost, audit.EventAdminRevoke)
+	if !ok {
 		return
 	}
 
@@ -69,6 +129,7 @@ func (h *AdminRevokeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request)
 	var body RevokeRequest
 	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
 		h.logger.Warn("failed to parse request body", "error", err)
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, "", "invalid request body")
 		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
 		return
 	}
@@ -76,24 +137,28 @@ func (h *AdminRevokeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request)
 	// ユーザーIDのバリデーション
 	if body.UserID == "" {
 		h.logger.Warn("user_id is empty")
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, "", "user_id is required")
 		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "user_id is required"))
 		return
 	}
 
 	// 現在時刻を失効時刻としてRedisに保存
-	revokedTime := time.Now()
+	revokedTime := h.now()
 	expiration := h.jwtExpiration
 
 	if err := h.repository.SetRevokedTime(req.Context(), body.UserID, revokedTime, expiration); err != nil {
 		h.logger.Error("failed to set revoked time", "error", err, "user_id", body.UserID)
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, body.UserID, "failed to set revoked time")
 		h.writeError(w, errors.NewError(http.StatusInternalServerError, "InternalServerError", "failed to process revoke"))
 		return
 	}
 
 	h.logger.Info("user revoked successfully by admin",
 		"user_id", body.UserID,
+		"actor", actor,
 		"revoked_at", revokedTime.Format(time.RFC3339),
 		"expires_at", revokedTime.Add(expiration).Format(time.RFC3339))
+	h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeSuccess, actor, body.UserID, "")
 
 	// 200 OK
 	w.Header().Set("Content-Type", "application/json")
@@ -105,18 +170,215 @@ func (h *AdminRevokeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request)
 	})
 }
 
-// authenticate はAPIキー認証を行う
-func (h *AdminRevokeHandler) authenticate(req *http.Request) error {
-	apiKey := req.Header.Get("X-API-Key")
-	if apiKey == "" {
-		return fmt.Errorf("X-API-Key header is missing")
+// ServeBatch は複数のユーザーを1回のRedisパイプラインで失効させる（POST /v1/revoke/batch）
+// ユーザーごとの失敗はレスポンスの results に含め、リクエスト全体は200を返す
+func (h *AdminRevokeHandler) ServeBatch(w http.ResponseWriter, req *http.Request) {
+	ctx := logger.WithHTTPRequest(req.Context(), req)
+	actor, ok := h.authorize(ctx, w, req, http.MethodPost, audit.EventAdminRevoke)
+	if !ok {
+		return
+	}
+
+	var body BatchRevokeRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		h.logger.Warn("failed to parse request body", "error", err)
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, "", "invalid request body")
+		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
+		return
+	}
+
+	// 同じユーザーIDは1回だけ失効させる（順序は最初に現れた位置を保つ）
+	seen := make(map[string]struct{}, len(body.UserIDs))
+	userIDs := make([]string, 0, len(body.UserIDs))
+	for _, userID := range body.UserIDs {
+		if _, ok := seen[userID]; ok {
+			continue
+		}
+		seen[userID] = struct{}{}
+		userIDs = append(userIDs, userID)
+	}
+
+	if len(userIDs) == 0 {
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, "", "user_ids is required")
+		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "user_ids is required"))
+		return
+	}
+	if len(userIDs) > h.maxBatchSize {
+		reason := fmt.Sprintf("too many user_ids: %d (max %d)", len(userIDs), h.maxBatchSize)
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, "", reason)
+		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", reason))
+		return
+	}
+
+	// 空のユーザーIDはRedisへ送らず、そのユーザーの失敗として返す
+	results := make([]BatchRevokeResult, len(userIDs))
+	targets := make([]string, 0, len(userIDs))
+	for i, userID := range userIDs {
+		results[i].UserID = userID
+		if userID == "" {
+			results[i].Error = "user_id is required"
+			continue
+		}
+		targets = append(targets, userID)
+	}
+
+	revokedTime := h.now()
+	expiration := h.jwtExpiration
+	var errs []error
+	if len(targets) > 0 {
+		errs = h.repository.SetRevokedTimes(req.Context(), targets, revokedTime, expiration)
+	}
+
+	response := BatchRevokeResponse{RevokedAt: revokedTime.Format(time.RFC3339), Results: results}
+	for i := range results {
+		result := &results[i]
+		if result.Error == "" {
+			err := errs[0]
+			errs = errs[1:]
+			if err != nil {
+				h.logger.Error("failed to set revoked time", "error", err, "user_id", result.UserID)
+				result.Error = "failed to process revoke"
+			}
+		}
+
+		if result.Error != "" {
+			response.Failed++
+			h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeFailure, actor, result.UserID, result.Error)
+			continue
+		}
+		result.Success = true
+		response.Succeeded++
+		h.auditLog(ctx, audit.EventAdminRevoke, audit.OutcomeSuccess, actor, result.UserID, "")
+	}
+
+	h.logger.Info("users revoked by admin",
+		"actor", actor,
+		"succeeded", response.Succeeded,
+		"failed", response.Failed,
+		"revoked_at", response.RevokedAt,
+		"expires_at", revokedTime.Add(expiration).Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ServeUser は /v1/revoke/{user_id} へのリクエストをメソッドごとに処理する
+// GET は失効状態の確認（ServeStatus）、DELETE は失効の取り消し（ServeRestore）
+func (h *AdminRevokeHandler) ServeUser(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		h.ServeStatus(w, req)
+	case http.MethodDelete:
+		h.ServeRestore(w, req)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		h.writeError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET and DELETE methods are allowed"))
+	}
+}
+
+// ServeStatus はユーザーの失効時刻と保持する残り時間を返す（GET /v1/revoke/{user_id}）
+// 「なぜこのユーザーがログアウトされたか」の調査に使う読み取り専用の操作のため、監査ログには認証の失敗のみ記録する
+func (h *AdminRevokeHandler) ServeStatus(w http.ResponseWriter, req *http.Request) {
+	ctx := logger.WithHTTPRequest(req.Context(), req)
+	if _, ok := h.authorize(ctx, w, req, http.MethodGet, audit.EventAuthFailure); !ok {
+		return
+	}
+
+	userID := req.PathValue("user_id")
+	if userID == "" {
+		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "user_id is required"))
+		return
+	}
+
+	revocation, err := h.repository.GetRevocation(req.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to get revocation", "error", err, "user_id", userID)
+		h.writeError(w, errors.NewError(http.StatusInternalServerError, "InternalServerError", "failed to get revoke status"))
+		return
+	}
+
+	response := RevokeStatusResponse{UserID: userID, Revoked: !revocation.RevokedAt.IsZero()}
+	if response.Revoked {
+		response.RevokedAt = revocation.RevokedAt.Format(time.RFC3339)
+		if revocation.TTL > 0 {
+			response.TTLSeconds = int64(revocation.TTL.Seconds())
+			response.ExpiresAt = h.now().Add(revocation.TTL).Format(time.RFC3339)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ServeRestore は誤って失効させたユーザーの失効時刻を削除し、発行済みのトークンを再び有効にする（DELETE /v1/revoke/{user_id}）
+// 失効していないユーザーの場合も成功を返す
+func (h *AdminRevokeHandler) ServeRestore(w http.ResponseWriter, req *http.Request) {
+	ctx := logger.WithHTTPRequest(req.Context(), req)
+	actor, ok := h.authorize(ctx, w, req, http.MethodDelete, audit.EventAdminRestore)
+	if !ok {
+		return
+	}
+
+	userID := req.PathValue("user_id")
+	if userID == "" {
+		h.auditLog(ctx, audit.EventAdminRestore, audit.OutcomeFailure, actor, "", "user_id is required")
+		h.writeError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "user_id is required"))
+		return
+	}
+
+	if err := h.repository.DeleteRevokedTime(req.Context(), userID); err != nil {
+		h.logger.Error("failed to delete revoked time", "error", err, "user_id", userID)
+		h.auditLog(ctx, audit.EventAdminRestore, audit.OutcomeFailure, actor, userID, "failed to delete revoked time")
+		h.writeError(w, errors.NewError(http.StatusInternalServerError, "InternalServerError", "failed to process restore"))
+		return
+	}
+
+	h.logger.Info("user revocation restored by admin", "user_id", userID, "actor", actor)
+	h.auditLog(ctx, audit.EventAdminRestore, audit.OutcomeSuccess, actor, userID, "")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"user_id": userID,
+	})
+}
+
+// authorize はメソッドが method であることと、APIキーまたは管理者のJWTによる認証を確認する
+// 拒否した場合はエラーレスポンスを書き込み、認証の失敗を eventType で監査ログに記録して false を返す
+func (h *AdminRevokeHandler) authorize(ctx context.Context, w http.ResponseWriter, req *http.Request, method string, eventType audit.EventType) (string, bool) {
+	if req.Method != method {
+		h.writeError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("only %s method is allowed", method)))
+		return "", false
 	}
 
-	if apiKey != h.apiKey {
-		return fmt.Errorf("invalid API key")
+	principal, authErr := h.authenticate(req)
+	if authErr != nil {
+		h.logger.Warn("authentication failed", "error", authErr)
+		h.auditLog(ctx, eventType, audit.OutcomeFailure, principal.Actor, "", authErr.Error())
+		h.writeError(w, authErr)
+		return "", false
 	}
+	return principal.Actor, true
+}
 
-	return nil
+// authenticate はAPIキーまたは管理者ロールを持つJWTで認証を行う
+func (h *AdminRevokeHandler) authenticate(req *http.Request) (adminPrincipal, errors.GatewayError) {
+	return authenticateAdmin(req, AdminAuthConfig{APIKeys: h.apiKeys, JWT: h.adminJWT, Role: h.adminRole})
+}
+
+// auditLog は強制失効・失効の取り消しの呼び出しを監査ログに記録する
+// actor はJWTで認証した管理者（APIキーの場合は空）
+func (h *AdminRevokeHandler) auditLog(ctx context.Context, eventType audit.EventType, outcome audit.Outcome, actor, userID, reason string) {
+	h.audit.Log(ctx, audit.Event{
+		Type:    eventType,
+		Outcome: outcome,
+		UserID:  userID,
+		Actor:   actor,
+		Reason:  reason,
+	})
 }
 
 // writeError はエラーレスポンスを書き込む
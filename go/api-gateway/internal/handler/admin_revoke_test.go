@@ -5,17 +5,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"api-gateway/internal/repository"
 	"api-gateway/pkg/logger"
 )
 
 // Mock SessionRepository for AdminRevoke tests
 type mockAdminSessionRepository struct {
-	setRevokedTimeFunc func(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error
+	setRevokedTimeFunc    func(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error
+	setRevokedTimesFunc   func(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error
+	getRevocationFunc     func(ctx context.Context, userID string) (repository.Revocation, error)
+	deleteRevokedTimeFunc func(ctx context.Context, userID string) error
 }
 
 func (m *mockAdminSessionRepository) SetRevokedTime(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error {
@@ -25,11 +30,28 @@ func (m *mockAdminSessionRepository) SetRevokedTime(ctx context.Context, userID
 	return nil
 }
 
+func (m *mockAdminSessionRepository) SetRevokedTimes(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error {
+	if m.setRevokedTimesFunc != nil {
+		return m.setRevokedTimesFunc(ctx, userIDs, revokedTime, expiration)
+	}
+	return make([]error, len(userIDs))
+}
+
 func (m *mockAdminSessionRepository) GetRevokedTime(ctx context.Context, userID string) (time.Time, error) {
 	return time.Time{}, nil
 }
 
+func (m *mockAdminSessionRepository) GetRevocation(ctx context.Context, userID string) (repository.Revocation, error) {
+	if m.getRevocationFunc != nil {
+		return m.getRevocationFunc(ctx, userID)
+	}
+	return repository.Revocation{}, nil
+}
+
 func (m *mockAdminSessionRepository) DeleteRevokedTime(ctx context.Context, userID string) error {
+	if m.deleteRevokedTimeFunc != nil {
+		return m.deleteRevokedTimeFunc(ctx, userID)
+	}
 	return nil
 }
 
@@ -49,7 +71,7 @@ func TestNewAdminRevokeHandler(t *testing.T) {
 			},
 			want: &AdminRevokeHandler{
 				repository:    repo,
-				apiKey:        "test-api-key",
+				apiKeys:       NewStaticAdminKeys("test-api-key"),
 				jwtExpiration: 10 * time.Hour,
 			},
 		},
@@ -62,7 +84,7 @@ func TestNewAdminRevokeHandler(t *testing.T) {
 			},
 			want: &AdminRevokeHandler{
 				repository:    repo,
-				apiKey:        "custom-key",
+				apiKeys:       NewStaticAdminKeys("custom-key"),
 				jwtExpiration: 5 * time.Hour,
 			},
 		},
@@ -71,8 +93,8 @@ func TestNewAdminRevokeHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := NewAdminRevokeHandler(tt.config)
-			if got.apiKey != tt.want.apiKey {
-				t.Errorf("apiKey = %v, want %v", got.apiKey, tt.want.apiKey)
+			if !got.apiKeys.Match(tt.config.APIKey) || got.apiKeys.Len() != tt.want.apiKeys.Len() {
+				t.Errorf("apiKeys do not accept only %q", tt.config.APIKey)
 			}
 			if got.jwtExpiration != tt.want.jwtExpiration {
 				t.Errorf("jwtExpiration = %v, want %v", got.jwtExpiration, tt.want.jwtExpiration)
@@ -275,7 +297,7 @@ func TestAdminRevokeHandler_ServeHTTP_InternalServerError(t *testing.T) {
 
 func TestAdminRevokeHandler_authenticate(t *testing.T) {
 	handler := &AdminRevokeHandler{
-		apiKey: "correct-key",
+		apiKeys: NewStaticAdminKeys("correct-key"),
 	}
 
 	tests := []struct {
@@ -307,7 +329,7 @@ func TestAdminRevokeHandler_authenticate(t *testing.T) {
 				req.Header.Set("X-API-Key", tt.apiKey)
 			}
 
-			err := handler.authenticate(req)
+			_, err := handler.authenticate(req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("authenticate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -315,6 +337,318 @@ func TestAdminRevokeHandler_authenticate(t *testing.T) {
 	}
 }
 
+func TestAdminRevokeHandler_ServeBatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		failUserIDs   map[string]bool
+		wantStatus    int
+		wantUserIDs   []string // リポジトリに渡されるユーザーID
+		wantSucceeded int
+		wantFailed    int
+	}{
+		{
+			name:          "全てのユーザーを失効",
+			body:          `{"user_ids":["user_1","user_2","user_3"]}`,
+			wantStatus:    http.StatusOK,
+			wantUserIDs:   []string{"user_1", "user_2", "user_3"},
+			wantSucceeded: 3,
+		},
+		{
+			name:          "重複したユーザーIDは1回だけ失効",
+			body:          `{"user_ids":["user_1","user_2","user_1"]}`,
+			wantStatus:    http.StatusOK,
+			wantUserIDs:   []string{"user_1", "user_2"},
+			wantSucceeded: 2,
+		},
+		{
+			name:          "一部のユーザーの失敗は結果に含める",
+			body:          `{"user_ids":["user_1","","user_2"]}`,
+			failUserIDs:   map[string]bool{"user_2": true},
+			wantStatus:    http.StatusOK,
+			wantUserIDs:   []string{"user_1", "user_2"},
+			wantSucceeded: 1,
+			wantFailed:    2,
+		},
+		{
+			name:       "ユーザーIDが空",
+			body:       `{"user_ids":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "上限を超えるユーザーID",
+			body:       `{"user_ids":["user_1","user_2","user_3","user_4"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "不正なJSON",
+			body:       `{"user_ids":`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedUserIDs []string
+			repo := &mockAdminSessionRepository{
+				setRevokedTimesFunc: func(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error {
+					capturedUserIDs = userIDs
+					errs := make([]error, len(userIDs))
+					for i, userID := range userIDs {
+						if tt.failUserIDs[userID] {
+							errs[i] = fmt.Errorf("redis error")
+						}
+					}
+					return errs
+				},
+			}
+
+			handler := NewAdminRevokeHandler(AdminRevokeConfig{
+				Repository:   repo,
+				APIKey:       "test-api-key",
+				MaxBatchSize: 3,
+				Logger:       logger.New(logger.Config{Level: logger.LevelInfo, Format: "json"}),
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/revoke/batch", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("X-API-Key", "test-api-key")
+			req.Header.Set("Content-Type", "application/json")
+
+			rec := httptest.NewRecorder()
+			handler.ServeBatch(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if capturedUserIDs != nil {
+					t.Errorf("repository called with %v, want no call", capturedUserIDs)
+				}
+				return
+			}
+
+			if fmt.Sprint(capturedUserIDs) != fmt.Sprint(tt.wantUserIDs) {
+				t.Errorf("captured user_ids = %v, want %v", capturedUserIDs, tt.wantUserIDs)
+			}
+
+			var response BatchRevokeResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Succeeded != tt.wantSucceeded || response.Failed != tt.wantFailed {
+				t.Errorf("succeeded, failed = %d, %d, want %d, %d", response.Succeeded, response.Failed, tt.wantSucceeded, tt.wantFailed)
+			}
+			if len(response.Results) != tt.wantSucceeded+tt.wantFailed {
+				t.Fatalf("len(results) = %d, want %d", len(response.Results), tt.wantSucceeded+tt.wantFailed)
+			}
+			for _, result := range response.Results {
+				wantSuccess := result.UserID != "" && !tt.failUserIDs[result.UserID]
+				if result.Success != wantSuccess {
+					t.Errorf("result %q success = %v, want %v", result.UserID, result.Success, wantSuccess)
+				}
+				if !result.Success && result.Error == "" {
+					t.Errorf("result %q error is empty", result.UserID)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminRevokeHandler_ServeBatch_Unauthorized(t *testing.T) {
+	handler := NewAdminRevokeHandler(AdminRevokeConfig{
+		Repository: &mockAdminSessionRepository{},
+		APIKey:     "test-api-key",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/revoke/batch", bytes.NewReader([]byte(`{"user_ids":["user_1"]}`)))
+	req.Header.Set("X-API-Key", "wrong-key")
+
+	rec := httptest.NewRecorder()
+	handler.ServeBatch(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status code = %v, want %v", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAdminRevokeHandler_ServeRestore(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		apiKey     string
+		deleteErr  error
+		wantStatus int
+		wantUserID string // リポジトリに渡されるユーザーID
+	}{
+		{
+			name:       "失効を取り消す",
+			method:     http.MethodDelete,
+			path:       "/v1/revoke/user_123",
+			apiKey:     "test-api-key",
+			wantStatus: http.StatusOK,
+			wantUserID: "user_123",
+		},
+		{
+			name:       "Redisのエラー",
+			method:     http.MethodDelete,
+			path:       "/v1/revoke/user_123",
+			apiKey:     "test-api-key",
+			deleteErr:  fmt.Errorf("redis error"),
+			wantStatus: http.StatusInternalServerError,
+			wantUserID: "user_123",
+		},
+		{
+			name:       "DELETE以外のメソッド",
+			method:     http.MethodPost,
+			path:       "/v1/revoke/user_123",
+			apiKey:     "test-api-key",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "間違ったAPIキー",
+			method:     http.MethodDelete,
+			path:       "/v1/revoke/user_123",
+			apiKey:     "wrong-key",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "batch は一括失効として扱う",
+			method:     http.MethodDelete,
+			path:       "/v1/revoke/batch",
+			apiKey:     "test-api-key",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedUserID string
+			repo := &mockAdminSessionRepository{
+				deleteRevokedTimeFunc: func(ctx context.Context, userID string) error {
+					capturedUserID = userID
+					return tt.deleteErr
+				},
+			}
+			handler := NewAdminRevokeHandler(AdminRevokeConfig{
+				Repository: repo,
+				APIKey:     "test-api-key",
+				Logger:     logger.New(logger.Config{Level: logger.LevelInfo, Format: "json"}),
+			})
+
+			// cmd/admin と同じハンドラで登録する
+			mux := NewAdminServerHandler(AdminServerConfig{Revoke: handler, Logger: slog.New(slog.DiscardHandler)})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-API-Key", tt.apiKey)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if capturedUserID != tt.wantUserID {
+				t.Errorf("captured user_id = %q, want %q", capturedUserID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestAdminRevokeHandler_ServeStatus(t *testing.T) {
+	revokedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		method      string
+		revocation  repository.Revocation
+		getErr      error
+		wantStatus  int
+		wantRevoked bool
+		wantTTL     int64
+		wantAllow   string
+	}{
+		{
+			name:        "失効済みのユーザー",
+			method:      http.MethodGet,
+			revocation:  repository.Revocation{RevokedAt: revokedAt, TTL: 90 * time.Minute},
+			wantStatus:  http.StatusOK,
+			wantRevoked: true,
+			wantTTL:     5400,
+		},
+		{
+			name:       "失効していないユーザー",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Redisのエラー",
+			method:     http.MethodGet,
+			getErr:     fmt.Errorf("redis error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "GET, DELETE以外のメソッド",
+			method:     http.MethodPut,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "GET, DELETE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockAdminSessionRepository{
+				getRevocationFunc: func(ctx context.Context, userID string) (repository.Revocation, error) {
+					if userID != "user_123" {
+						t.Errorf("user_id = %q, want user_123", userID)
+					}
+					return tt.revocation, tt.getErr
+				},
+			}
+			handler := NewAdminRevokeHandler(AdminRevokeConfig{
+				Repository: repo,
+				APIKey:     "test-api-key",
+				Logger:     logger.New(logger.Config{Level: logger.LevelInfo, Format: "json"}),
+			})
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/revoke/{user_id}", handler.ServeUser)
+
+			req := httptest.NewRequest(tt.method, "/v1/revoke/user_123", nil)
+			req.Header.Set("X-API-Key", "test-api-key")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response RevokeStatusResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.UserID != "user_123" || response.Revoked != tt.wantRevoked || response.TTLSeconds != tt.wantTTL {
+				t.Errorf("response = %+v, want revoked %v, ttl %d", response, tt.wantRevoked, tt.wantTTL)
+			}
+			if tt.wantRevoked {
+				if response.RevokedAt != revokedAt.Format(time.RFC3339) {
+					t.Errorf("revoked_at = %q, want %q", response.RevokedAt, revokedAt.Format(time.RFC3339))
+				}
+				if response.ExpiresAt == "" {
+					t.Error("expires_at is empty")
+				}
+			} else if response.RevokedAt != "" || response.ExpiresAt != "" {
+				t.Errorf("response = %+v, want no revoked_at and expires_at", response)
+			}
+		})
+	}
+}
+
 // race検証用のテスト
 func TestAdminRevokeHandler_Race(t *testing.T) {
 	repo := &mockAdminSessionRepository{
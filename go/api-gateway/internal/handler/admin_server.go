@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/pkg/logger"
+)
+
+// AdminServerConfig は管理サーバー（cmd/admin）で提供するハンドラの設定
+type AdminServerConfig struct {
+	Logout *LogoutHandler      // DELETE /v1/logout を処理するハンドラ（nilの場合は公開しない）
+	Revoke *AdminRevokeHandler // /v1/revoke 配下の強制失効・取り消し・状態確認を処理するハンドラ（nilの場合は公開しない）
+	Logger *slog.Logger
+}
+
+// adminServer はログアウトと管理APIを1つのリスナーで提供するハンドラ
+type adminServer struct {
+	mux    *http.ServeMux
+	logger *slog.Logger
+}
+
+// NewAdminServerHandler はログアウト、強制失効（一括を含む）、失効の取り消し、失効状態の確認と
+// ヘルスチェックを1つのハンドラにまとめる
+// 全てのエンドポイントで共通して、パニックを500に変換し、リクエストごとにアクセスログを出力する
+func NewAdminServerHandler(config AdminServerConfig) http.Handler {
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	mux := http.NewServeMux()
+	if config.Logout != nil {
+		mux.Handle("/v1/logout", config.Logout)
+	}
+	if config.Revoke != nil {
+		// /v1/revoke/batch は /v1/revoke/{user_id} より優先される
+		mux.Handle("/v1/revoke", config.Revoke)
+		mux.HandleFunc("/v1/revoke/batch", config.Revoke.ServeBatch)
+		mux.HandleFunc("/v1/revoke/{user_id}", config.Revoke.ServeUser)
+	}
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	return &adminServer{mux: mux, logger: config.Logger}
+}
+
+// ServeHTTP はhttp.Handlerインターフェースの実装
+func (s *adminServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Gatewayから伝搬された相関IDとリクエスト情報をアクセスログに載せる
+	ctx := logger.WithCorrelation(req.Context(), logger.CorrelationFromRequest(req))
+	ctx = logger.WithHTTPRequest(ctx, req)
+
+	start := time.Now()
+	aw := newAccessLogWriter(w)
+	defer func() {
+		if rec := recover(); rec != nil {
+			// クライアントの切断による中断は net/http に任せる
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			s.logger.ErrorContext(ctx, "panic recovered",
+				slog.Any("panic", rec),
+				slog.Any("stack", logger.CallerStack(1)))
+			// レスポンスを書き始めた後はステータスを変更できない
+			if aw.statusCode == 0 {
+				writeJSONError(aw, errors.NewInternalServerError("internal server error"))
+			}
+		}
+
+		statusCode := aw.statusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		s.logger.LogAttrs(ctx, slog.LevelInfo, "access",
+			slog.Int("status_code", statusCode),
+			slog.Int64("bytes_written", aw.bytes),
+			slog.Duration("duration", time.Since(start)))
+	}()
+
+	s.mux.ServeHTTP(aw, req)
+}
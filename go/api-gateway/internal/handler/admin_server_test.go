@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAdminServerHandler(t *testing.T) {
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "user123"})
+	tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		body       string
+		wantStatus int
+		wantUserID string // SetRevokedTime に渡されるユーザーID
+	}{
+		{
+			name:       "ログアウト",
+			method:     http.MethodDelete,
+			path:       "/v1/logout",
+			header:     map[string]string{"Authorization": "Bearer " + tokenString},
+			wantStatus: http.StatusNoContent,
+			wantUserID: "user123",
+		},
+		{
+			name:       "強制失効",
+			method:     http.MethodPost,
+			path:       "/v1/revoke",
+			header:     map[string]string{"X-API-Key": "test-api-key", "Content-Type": "application/json"},
+			body:       `{"user_id":"user456"}`,
+			wantStatus: http.StatusOK,
+			wantUserID: "user456",
+		},
+		{
+			name:       "失効状態の確認",
+			method:     http.MethodGet,
+			path:       "/v1/revoke/user456",
+			header:     map[string]string{"X-API-Key": "test-api-key"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "失効の取り消し",
+			method:     http.MethodDelete,
+			path:       "/v1/revoke/user456",
+			header:     map[string]string{"X-API-Key": "test-api-key"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "ヘルスチェック",
+			method:     http.MethodGet,
+			path:       "/health",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "存在しないパス",
+			method:     http.MethodGet,
+			path:       "/v1/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedUserID string
+			repo := &mockAdminSessionRepository{
+				setRevokedTimeFunc: func(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error {
+					capturedUserID = userID
+					return nil
+				},
+			}
+			discard := slog.New(slog.DiscardHandler)
+			h := NewAdminServerHandler(AdminServerConfig{
+				Logout: NewLogoutHandler(LogoutConfig{Repository: repo, Logger: discard}),
+				Revoke: NewAdminRevokeHandler(AdminRevokeConfig{Repository: repo, APIKey: "test-api-key", Logger: discard}),
+				Logger: discard,
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if capturedUserID != tt.wantUserID {
+				t.Errorf("captured user_id = %q, want %q", capturedUserID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestAdminServerHandler_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewAdminServerHandler(AdminServerConfig{
+		Logger: slog.New(logger.NewCorrelationHandler(slog.NewJSONHandler(&buf, nil))),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(logger.HeaderRequestID, "req-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to parse log output %s: %v", buf.String(), err)
+	}
+	if entry["msg"] != "access" {
+		t.Errorf("msg = %v, want access", entry["msg"])
+	}
+	if entry["status_code"] != float64(http.StatusOK) {
+		t.Errorf("status_code = %v, want %d", entry["status_code"], http.StatusOK)
+	}
+	if entry[logger.FieldRequestID] != "req-123" {
+		t.Errorf("request_id = %v, want req-123", entry[logger.FieldRequestID])
+	}
+}
+
+func TestAdminServerHandler_Recover(t *testing.T) {
+	repo := &mockAdminSessionRepository{
+		setRevokedTimeFunc: func(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error {
+			panic("unexpected")
+		},
+	}
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	h := NewAdminServerHandler(AdminServerConfig{
+		Logout: NewLogoutHandler(LogoutConfig{Repository: repo, Logger: log}),
+		Logger: log,
+	})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "user123"})
+	tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	req := httptest.NewRequest(http.MethodDelete, "/v1/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status code = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(buf.String(), "panic recovered") {
+		t.Errorf("expected panic to be logged, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"status_code":500`) {
+		t.Errorf("expected access log with status 500, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"api-gateway/internal/audit"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/requestctx"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRequireAPIKey(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	var auditBuf bytes.Buffer
+	handler := RequireAPIKey("test-api-key", audit.New(&auditBuf), next)
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantStatus int
+	}{
+		{name: "valid key", apiKey: "test-api-key", wantStatus: http.StatusOK},
+		{name: "invalid key", apiKey: "wrong-key", wantStatus: http.StatusUnauthorized},
+		{name: "missing key", apiKey: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/openapi", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			auditBuf.Reset()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+
+			// 拒否したリクエストのみ監査ログに記録する
+			if tt.wantStatus == http.StatusOK {
+				if auditBuf.Len() != 0 {
+					t.Errorf("unexpected audit event: %s", auditBuf.String())
+				}
+				return
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(auditBuf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse audit output: %v", err)
+			}
+			if entry["event"] != string(audit.EventAuthFailure) {
+				t.Errorf("event = %v, want %v", entry["event"], audit.EventAuthFailure)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin_JWT(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signToken := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "admin-kid"
+		signed, err := token.SignedString(privateKey)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return signed
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	var gotActor any
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := requestctx.Claims(r.Context())
+		gotActor = claims["sub"]
+		w.WriteHeader(http.StatusOK)
+	})
+	var auditBuf bytes.Buffer
+	handler := RequireAdmin(AdminAuthConfig{
+		APIKey: "test-api-key",
+		JWT:    auth.NewJWTMiddleware(auth.JWTConfig{PublicKeys: map[string]*rsa.PublicKey{"admin-kid": &privateKey.PublicKey}}),
+		Audit:  audit.New(&auditBuf),
+	}, next)
+
+	tests := []struct {
+		name          string
+		apiKey        string
+		authorization string
+		wantStatus    int
+		wantEvent     audit.EventType
+		wantActor     string
+	}{
+		{
+			name:          "管理者ロールを持つJWT",
+			authorization: "Bearer " + signToken(jwt.MapClaims{"sub": "alice", "roles": []string{"admin"}, "exp": exp}),
+			wantStatus:    http.StatusOK,
+			wantEvent:     audit.EventAdminAccess,
+			wantActor:     "alice",
+		},
+		{
+			name:          "scopeに管理者ロールを持つJWT",
+			authorization: "Bearer " + signToken(jwt.MapClaims{"sub": "bob", "scope": "openid admin", "exp": exp}),
+			wantStatus:    http.StatusOK,
+			wantEvent:     audit.EventAdminAccess,
+			wantActor:     "bob",
+		},
+		{
+			name:          "管理者ロールを持たないJWT",
+			authorization: "Bearer " + signToken(jwt.MapClaims{"sub": "carol", "roles": []string{"viewer"}, "exp": exp}),
+			wantStatus:    http.StatusForbidden,
+			wantEvent:     audit.EventAuthzDenied,
+			wantActor:     "carol",
+		},
+		{
+			name:          "期限切れのJWT",
+			authorization: "Bearer " + signToken(jwt.MapClaims{"sub": "alice", "roles": []string{"admin"}, "exp": time.Now().Add(-time.Hour).Unix()}),
+			wantStatus:    http.StatusUnauthorized,
+			wantEvent:     audit.EventAuthFailure,
+		},
+		{
+			name:          "APIキーを優先する",
+			apiKey:        "wrong-key",
+			authorization: "Bearer " + signToken(jwt.MapClaims{"sub": "alice", "roles": []string{"admin"}, "exp": exp}),
+			wantStatus:    http.StatusUnauthorized,
+			wantEvent:     audit.EventAuthFailure,
+		},
+		{
+			name:       "APIキー",
+			apiKey:     "test-api-key",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			auditBuf.Reset()
+			gotActor = nil
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && tt.wantActor != "" && gotActor != tt.wantActor {
+				t.Errorf("claims sub = %v, want %v", gotActor, tt.wantActor)
+			}
+
+			// APIキーでのアクセスは記録しない
+			if tt.wantEvent == "" {
+				if auditBuf.Len() != 0 {
+					t.Errorf("unexpected audit event: %s", auditBuf.String())
+				}
+				return
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(auditBuf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse audit output: %v", err)
+			}
+			if entry["event"] != string(tt.wantEvent) {
+				t.Errorf("event = %v, want %v", entry["event"], tt.wantEvent)
+			}
+			if tt.wantActor != "" && entry["actor"] != tt.wantActor {
+				t.Errorf("actor = %v, want %v", entry["actor"], tt.wantActor)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin_BasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("scrape-secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	basic, err := auth.NewBasicAuthMiddleware(auth.BasicAuthConfig{Realm: "admin", Users: map[string]string{"prometheus": string(hash)}})
+	if err != nil {
+		t.Fatalf("NewBasicAuthMiddleware() error = %v", err)
+	}
+
+	var gotActor any
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := requestctx.Claims(r.Context())
+		gotActor = claims["sub"]
+		w.WriteHeader(http.StatusOK)
+	})
+	var auditBuf bytes.Buffer
+	handler := RequireAdmin(AdminAuthConfig{APIKey: "test-api-key", Basic: basic, Audit: audit.New(&auditBuf)}, next)
+
+	tests := []struct {
+		name          string
+		apiKey        string
+		user          string
+		password      string
+		wantStatus    int
+		wantChallenge bool
+		wantEvent     audit.EventType
+		wantActor     string
+	}{
+		{
+			name:       "Basic認証",
+			user:       "prometheus",
+			password:   "scrape-secret",
+			wantStatus: http.StatusOK,
+			wantEvent:  audit.EventAdminAccess,
+			wantActor:  "prometheus",
+		},
+		{
+			name:          "パスワードの誤り",
+			user:          "prometheus",
+			password:      "wrong",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: true,
+			wantEvent:     audit.EventAuthFailure,
+		},
+		{
+			name:          "認証情報が無い場合はBasic認証のチャレンジを返す",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: true,
+			wantEvent:     audit.EventAuthFailure,
+		},
+		{
+			name:       "APIキーも受け付ける",
+			apiKey:     "test-api-key",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/metrics", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			auditBuf.Reset()
+			gotActor = nil
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); (got != "") != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want challenge %v", got, tt.wantChallenge)
+			}
+			if tt.wantActor != "" && gotActor != tt.wantActor {
+				t.Errorf("claims sub = %v, want %v", gotActor, tt.wantActor)
+			}
+
+			if tt.wantEvent == "" {
+				if auditBuf.Len() != 0 {
+					t.Errorf("unexpected audit event: %s", auditBuf.String())
+				}
+				return
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(auditBuf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse audit output: %v", err)
+			}
+			if entry["event"] != string(tt.wantEvent) {
+				t.Errorf("event = %v, want %v", entry["event"], tt.wantEvent)
+			}
+			if tt.wantActor != "" && entry["actor"] != tt.wantActor {
+				t.Errorf("actor = %v, want %v", entry["actor"], tt.wantActor)
+			}
+		})
+	}
+}
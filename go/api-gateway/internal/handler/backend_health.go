@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/healthcheck"
+)
+
+// BackendHealthHandler はアクティブヘルスチェックによるバックエンドの状態を返す管理API
+type BackendHealthHandler struct {
+	checker *healthcheck.Checker
+	logger  *slog.Logger
+}
+
+// BackendHealthResponse はバックエンドの状態APIのレスポンス
+type BackendHealthResponse struct {
+	Backends []healthcheck.Status `json:"backends"`
+}
+
+// NewBackendHealthHandler は新しいBackendHealthHandlerを作成する
+func NewBackendHealthHandler(checker *healthcheck.Checker, logger *slog.Logger) *BackendHealthHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &BackendHealthHandler{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *BackendHealthHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// GETメソッドのみ許可
+	if req.Method != http.MethodGet {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(BackendHealthResponse{Backends: h.checker.Statuses()})
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"api-gateway/internal/healthcheck"
+)
+
+func TestBackendHealthHandler_ServeHTTP(t *testing.T) {
+	backendURL, _ := url.Parse("http://users.internal:8080/api")
+	checker := healthcheck.New(healthcheck.Config{}, []*url.URL{backendURL})
+
+	tests := []struct {
+		name        string
+		checker     *healthcheck.Checker
+		method      string
+		wantStatus  int
+		wantBackend []string
+	}{
+		{name: "バックエンドの状態を返す", checker: checker, method: http.MethodGet, wantStatus: http.StatusOK, wantBackend: []string{"http://users.internal:8080"}},
+		{name: "ヘルスチェックが無効の場合は空", checker: nil, method: http.MethodGet, wantStatus: http.StatusOK, wantBackend: []string{}},
+		{name: "GET以外は405", checker: checker, method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewBackendHealthHandler(tt.checker, nil).ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/backends/health", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBackend == nil {
+				return
+			}
+
+			var resp BackendHealthResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if len(resp.Backends) != len(tt.wantBackend) {
+				t.Fatalf("backends = %+v, want %v", resp.Backends, tt.wantBackend)
+			}
+			for i, backend := range tt.wantBackend {
+				if resp.Backends[i].Backend != backend || !resp.Backends[i].Healthy {
+					t.Errorf("backends[%d] = %+v, want healthy %s", i, resp.Backends[i], backend)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-gateway/internal/cache"
+	"api-gateway/internal/middleware"
+)
+
+// cacheStatusHeader はキャッシュのヒット/ミスをクライアントに伝えるヘッダー
+const cacheStatusHeader = "X-Cache"
+
+// serveFromCache はキャッシュ済みのレスポンスがあれば書き込み、書き込んだかを返す
+func (g *Gateway) serveFromCache(w http.ResponseWriter, r *http.Request, policy *middleware.CachePolicy) bool {
+	entry, err := policy.Store.Get(r.Context(), policy.Key)
+	if err != nil {
+		if !stderrors.Is(err, cache.ErrNotFound) {
+			// キャッシュ障害時はバックエンドへ転送して処理を継続する
+			g.logger.WarnContext(r.Context(), "failed to read response cache", slog.String("error", err.Error()))
+		}
+		return false
+	}
+
+	for key, values := range entry.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+	w.Header().Set(cacheStatusHeader, "HIT")
+	w.WriteHeader(entry.StatusCode)
+	if r.Method != http.MethodHead {
+		w.Write(entry.Body)
+	}
+	return true
+}
+
+// storeResponse はバックエンドのレスポンスをキャッシュに保存する
+func (g *Gateway) storeResponse(ctx context.Context, r *http.Request, rec *cacheRecorder) {
+	// HEADにはボディが無いため、GETのレスポンスのみ保存する
+	if r.Method != http.MethodGet || rec.overflow || rec.header == nil {
+		return
+	}
+
+	ttl := cache.ResponseTTL(r, rec.statusCode, rec.header, rec.policy.TTL, rec.policy.VaryHeaders)
+	if ttl <= 0 {
+		return
+	}
+
+	entry := &cache.Entry{
+		StatusCode: rec.statusCode,
+		Header:     rec.header,
+		Body:       rec.body.Bytes(),
+		StoredAt:   time.Now(),
+	}
+	if err := rec.policy.Store.Set(ctx, rec.policy.Key, entry, ttl); err != nil {
+		g.logger.WarnContext(ctx, "failed to store response cache", slog.String("error", err.Error()))
+	}
+}
+
+// cacheRecorder はクライアントへ書き込みながらレスポンスを記録するResponseWriter
+// ボディが上限を超えた場合は記録をやめ、キャッシュしない
+type cacheRecorder struct {
+	http.ResponseWriter
+	policy     *middleware.CachePolicy
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+	overflow   bool
+}
+
+func newCacheRecorder(w http.ResponseWriter, policy *middleware.CachePolicy) *cacheRecorder {
+	return &cacheRecorder{ResponseWriter: w, policy: policy, statusCode: http.StatusOK}
+}
+
+// WriteHeader はステータスコードとヘッダーを記録する
+func (rec *cacheRecorder) WriteHeader(statusCode int) {
+	if rec.header == nil {
+		rec.statusCode = statusCode
+		rec.header = rec.ResponseWriter.Header().Clone()
+		rec.header.Del(cacheStatusHeader)
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write はボディを記録する
+func (rec *cacheRecorder) Write(b []byte) (int, error) {
+	if rec.header == nil {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if int64(rec.body.Len()+len(b)) > rec.policy.MaxBodySize {
+			rec.overflow = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap はhttp.ResponseControllerがFlushなどを元のResponseWriterへ委譲するために使う
+func (rec *cacheRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
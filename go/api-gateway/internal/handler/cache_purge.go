@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/cache"
+	"api-gateway/internal/errors"
+)
+
+// CachePurgeHandler はレスポンスキャッシュを削除する管理API
+type CachePurgeHandler struct {
+	store  cache.Store
+	logger *slog.Logger
+}
+
+// CachePurgeRequest はキャッシュ削除APIのリクエストボディ
+// Path はパスに完全一致するエントリ（全クエリ・Vary）を、Prefix はパスが前方一致するエントリを削除する
+type CachePurgeRequest struct {
+	Path   string `json:"path,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// NewCachePurgeHandler は新しいCachePurgeHandlerを作成する
+func NewCachePurgeHandler(store cache.Store, logger *slog.Logger) *CachePurgeHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &CachePurgeHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *CachePurgeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// POSTメソッドのみ許可
+	if req.Method != http.MethodPost {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only POST method is allowed"))
+		return
+	}
+
+	var body CachePurgeRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		h.logger.Warn("failed to parse request body", "error", err)
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
+		return
+	}
+
+	var prefix string
+	switch {
+	case body.Path != "" && body.Prefix != "":
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "specify either path or prefix"))
+		return
+	case body.Path != "":
+		prefix = cache.PathPrefix(body.Path)
+	case body.Prefix != "":
+		prefix = body.Prefix
+	default:
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "path or prefix is required"))
+		return
+	}
+
+	purged, err := h.store.Purge(req.Context(), prefix)
+	if err != nil {
+		h.logger.Error("failed to purge cache", "error", err, "prefix", prefix)
+		writeJSONError(w, errors.NewError(http.StatusInternalServerError, "InternalServerError", "failed to purge cache"))
+		return
+	}
+
+	h.logger.Info("cache purged by admin", "prefix", prefix, "purged", purged)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"purged": purged,
+	})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/internal/cache"
+)
+
+func TestCachePurgeHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantPurged int
+		wantKeys   []string
+	}{
+		{
+			name:       "パスに完全一致するエントリを削除",
+			method:     http.MethodPost,
+			body:       `{"path": "/api/v1/users"}`,
+			wantStatus: http.StatusOK,
+			wantPurged: 2,
+			wantKeys:   []string{"/api/v1/users/1?a", "/api/v1/orders?a"},
+		},
+		{
+			name:       "パスが前方一致するエントリを削除",
+			method:     http.MethodPost,
+			body:       `{"prefix": "/api/v1/users"}`,
+			wantStatus: http.StatusOK,
+			wantPurged: 3,
+			wantKeys:   []string{"/api/v1/orders?a"},
+		},
+		{
+			name:       "pathとprefixの両方を指定",
+			method:     http.MethodPost,
+			body:       `{"path": "/api/v1/users", "prefix": "/api"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "pathとprefixのどちらも指定しない",
+			method:     http.MethodPost,
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "不正なJSON",
+			method:     http.MethodPost,
+			body:       `{invalid json}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "POST以外のメソッド",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := cache.NewMemoryStore(0)
+			for _, key := range []string{"/api/v1/users?a", "/api/v1/users?b", "/api/v1/users/1?a", "/api/v1/orders?a"} {
+				store.Set(ctx, key, &cache.Entry{StatusCode: http.StatusOK}, time.Minute)
+			}
+			handler := NewCachePurgeHandler(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			req := httptest.NewRequest(tt.method, "/admin/cache/purge", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp map[string]int
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp["purged"] != tt.wantPurged {
+				t.Errorf("purged = %d, want %d", resp["purged"], tt.wantPurged)
+			}
+			if store.Len() != len(tt.wantKeys) {
+				t.Errorf("remaining entries = %d, want %d", store.Len(), len(tt.wantKeys))
+			}
+			for _, key := range tt.wantKeys {
+				if _, err := store.Get(ctx, key); err != nil {
+					t.Errorf("entry %s should remain: %v", key, err)
+				}
+			}
+		})
+	}
+}
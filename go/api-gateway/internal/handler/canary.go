@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+// CanaryHandler はルートのカナリアへの振り分けの割合を参照・変更する管理API
+// GETでカナリアを設定した全てのルートを返し、PUTで1つのルートの割合を変更する
+// 変更はルーティング設定の再読み込みや再起動で設定ファイルの値に戻る
+type CanaryHandler struct {
+	router *routing.Router
+	logger *slog.Logger
+}
+
+// CanaryRoute はカナリアを設定したルートの振り分けの状態
+type CanaryRoute struct {
+	Route       string  `json:"route"`
+	URL         string  `json:"url"`
+	Weight      float64 `json:"weight"`
+	StickyClaim string  `json:"sticky_claim,omitempty"`
+}
+
+// CanaryWeightRequest はカナリアの割合を変更するAPIのリクエストボディ
+type CanaryWeightRequest struct {
+	Route string `json:"route"`
+	// Weight はカナリアへ送るリクエストの割合（0〜100のパーセント）
+	Weight *float64 `json:"weight"`
+}
+
+// NewCanaryHandler は新しいCanaryHandlerを作成する
+func NewCanaryHandler(router *routing.Router, logger *slog.Logger) *CanaryHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &CanaryHandler{
+		router: router,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *CanaryHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(h.canaryRoutes())
+	case http.MethodPut:
+		h.setWeight(w, req)
+	default:
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET and PUT methods are allowed"))
+	}
+}
+
+// setWeight はリクエストボディのルートのカナリアの割合を変更する
+func (h *CanaryHandler) setWeight(w http.ResponseWriter, req *http.Request) {
+	var body CanaryWeightRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		h.logger.Warn("failed to parse request body", "error", err)
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
+		return
+	}
+	if body.Route == "" || body.Weight == nil {
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "route and weight are required"))
+		return
+	}
+
+	route := h.findRoute(body.Route)
+	if route == nil {
+		writeJSONError(w, errors.NewError(http.StatusNotFound, "NotFound", "no canary is configured for route "+body.Route))
+		return
+	}
+
+	previous := route.Canary.Weight()
+	if err := route.Canary.SetWeight(*body.Weight); err != nil {
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", err.Error()))
+		return
+	}
+	h.logger.Warn("canary weight changed by admin",
+		slog.String("route", route.Path),
+		slog.Float64("from", previous),
+		slog.Float64("to", route.Canary.Weight()),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newCanaryRoute(route))
+}
+
+// findRoute はパスが path でカナリアを設定したルートを返す（無い場合は nil）
+func (h *CanaryHandler) findRoute(path string) *routing.Route {
+	for _, route := range h.router.GetAllRoutes() {
+		if route.Path == path && route.Canary != nil {
+			return route
+		}
+	}
+	return nil
+}
+
+// canaryRoutes はカナリアを設定した全てのルートをパスの順に返す
+func (h *CanaryHandler) canaryRoutes() []CanaryRoute {
+	routes := []CanaryRoute{}
+	for _, route := range h.router.GetAllRoutes() {
+		if route.Canary != nil {
+			routes = append(routes, newCanaryRoute(route))
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Route < routes[j].Route })
+	return routes
+}
+
+// newCanaryRoute はルートのカナリアの状態を返す
+func newCanaryRoute(route *routing.Route) CanaryRoute {
+	return CanaryRoute{
+		Route:       route.Path,
+		URL:         route.Canary.URL.String(),
+		Weight:      route.Canary.Weight(),
+		StickyClaim: route.Canary.StickyClaim,
+	}
+}
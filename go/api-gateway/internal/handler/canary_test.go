@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+func TestCanaryHandler_ServeHTTP(t *testing.T) {
+	newRouter := func(t *testing.T) *routing.Router {
+		t.Helper()
+		canary, err := routing.NewCanary(config.CanaryConfig{URL: "http://users-canary.example.com", Weight: 5, StickyClaim: "sub"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		backendURL, _ := url.Parse("http://users.example.com")
+		router := routing.NewRouter()
+		router.AddRoute(&routing.Route{Path: "/api/v1/users", Backend: &routing.Backend{URL: backendURL}, Canary: canary})
+		router.AddRoute(&routing.Route{Path: "/api/v1/orders", Backend: &routing.Backend{URL: backendURL}})
+		return router
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantWeight float64
+	}{
+		{
+			name:       "カナリアを設定したルートを取得",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantWeight: 5,
+		},
+		{
+			name:       "割合を変更",
+			method:     http.MethodPut,
+			body:       `{"route": "/api/v1/users", "weight": 25}`,
+			wantStatus: http.StatusOK,
+			wantWeight: 25,
+		},
+		{
+			name:       "範囲外の割合",
+			method:     http.MethodPut,
+			body:       `{"route": "/api/v1/users", "weight": 150}`,
+			wantStatus: http.StatusBadRequest,
+			wantWeight: 5,
+		},
+		{
+			name:       "weight が無い",
+			method:     http.MethodPut,
+			body:       `{"route": "/api/v1/users"}`,
+			wantStatus: http.StatusBadRequest,
+			wantWeight: 5,
+		},
+		{
+			name:       "カナリアが無いルート",
+			method:     http.MethodPut,
+			body:       `{"route": "/api/v1/orders", "weight": 25}`,
+			wantStatus: http.StatusNotFound,
+			wantWeight: 5,
+		},
+		{
+			name:       "許可されていないメソッド",
+			method:     http.MethodPost,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantWeight: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRouter(t)
+			h := NewCanaryHandler(router, slog.New(slog.DiscardHandler))
+			req := httptest.NewRequest(tt.method, "/admin/routes/canary", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.method == http.MethodGet {
+				var routes []CanaryRoute
+				if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if len(routes) != 1 || routes[0].Route != "/api/v1/users" || routes[0].StickyClaim != "sub" {
+					t.Errorf("unexpected routes: %+v", routes)
+				}
+			}
+
+			match, err := router.Match(http.MethodGet, "/api/v1/users")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := match.Route.Canary.Weight(); got != tt.wantWeight {
+				t.Errorf("weight = %v, want %v", got, tt.wantWeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/pkg/logger"
+)
+
+// ConfigReloadResult は設定の再読み込み（SIGHUP または管理API）の結果
+type ConfigReloadResult struct {
+	Time time.Time `json:"time"`
+	// Config は gateway.yaml の変更されたフィールド（反映したものと再起動が必要なもの）
+	Config config.ConfigDiff `json:"config"`
+	// LogLevel は反映後のログレベル
+	LogLevel logger.LogLevel `json:"log_level"`
+	// Routes はルーティング設定の差分（source: kubernetes ではルートを監視で反映するため nil）
+	Routes *config.RoutingDiff `json:"routes,omitempty"`
+	// Keys はJWT公開鍵の差分
+	Keys auth.PublicKeysDiff `json:"keys"`
+}
+
+// ConfigReloader は設定を読み込み直して反映し、結果を返す
+// 読み込みに失敗した場合は何も反映せず、以前の設定のまま動作する
+type ConfigReloader func() (ConfigReloadResult, error)
+
+// ConfigReloadHandler は gateway.yaml・ルーティング設定・JWT公開鍵を読み込み直す管理API
+// POSTで読み込み直し、反映した変更と再起動が必要な変更を返す（SIGHUP と同じ処理）
+type ConfigReloadHandler struct {
+	reload ConfigReloader
+	logger *slog.Logger
+}
+
+// NewConfigReloadHandler は新しいConfigReloadHandlerを作成する
+func NewConfigReloadHandler(reload ConfigReloader, log *slog.Logger) *ConfigReloadHandler {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	return &ConfigReloadHandler{
+		reload: reload,
+		logger: log,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *ConfigReloadHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// POSTメソッドのみ許可
+	if req.Method != http.MethodPost {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only POST method is allowed"))
+		return
+	}
+
+	result, err := h.reload()
+	if err != nil {
+		writeJSONError(w, errors.NewError(http.StatusInternalServerError, "InternalServerError", "failed to reload config: "+err.Error()))
+		return
+	}
+
+	h.logger.Info("config reloaded by admin", "config", result.Config)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
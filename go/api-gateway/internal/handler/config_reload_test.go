@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/pkg/logger"
+)
+
+func TestConfigReloadHandler_ServeHTTP(t *testing.T) {
+	reloaded := ConfigReloadResult{
+		Time:     time.Now(),
+		Config:   config.ConfigDiff{Reloaded: []string{"logging.level"}, RestartRequired: []string{"server.port"}},
+		LogLevel: logger.LevelDebug,
+		Routes:   &config.RoutingDiff{Added: []string{"GET /health"}},
+		Keys:     auth.PublicKeysDiff{Added: []string{"idp-2"}},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		reloadErr  error
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "読み込み直して結果を返す", method: http.MethodPost, wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "読み込みに失敗した場合は500", method: http.MethodPost, reloadErr: fmt.Errorf("invalid log level: verbose"), wantStatus: http.StatusInternalServerError, wantCalls: 1},
+		{name: "POST以外は405", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := NewConfigReloadHandler(func() (ConfigReloadResult, error) {
+				calls++
+				if tt.reloadErr != nil {
+					return ConfigReloadResult{}, tt.reloadErr
+				}
+				return reloaded, nil
+			}, nil)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/config/reload", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("reload calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ConfigReloadResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if resp.LogLevel != logger.LevelDebug || len(resp.Config.RestartRequired) != 1 || resp.Routes == nil || len(resp.Keys.Added) != 1 {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/errors"
+)
+
+// ConfigSchemaHandler は設定項目のリファレンス（項目、型、デフォルト、環境変数、説明）を返す管理API
+// 実行中のゲートウェイの設定構造体から作るため、バージョンごとのドキュメントと食い違わない
+type ConfigSchemaHandler struct {
+	resp   ConfigSchemaResponse
+	logger *slog.Logger
+}
+
+// ConfigSchemaResponse は設定項目のリファレンスAPIのレスポンス
+type ConfigSchemaResponse struct {
+	// Gateway はゲートウェイ設定（gateway.yaml）の項目
+	Gateway []config.ReferenceField `json:"gateway"`
+	// Routing はルーティング設定（routing.yaml）の項目
+	Routing []config.ReferenceField `json:"routing"`
+}
+
+// NewConfigSchemaHandler は新しいConfigSchemaHandlerを作成する
+func NewConfigSchemaHandler(logger *slog.Logger) *ConfigSchemaHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &ConfigSchemaHandler{
+		resp: ConfigSchemaResponse{
+			Gateway: config.Reference(),
+			Routing: config.RoutingReference(),
+		},
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *ConfigSchemaHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// GETメソッドのみ許可
+	if req.Method != http.MethodGet {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.resp)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigSchemaHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		wantStatus int
+	}{
+		{name: "設定項目のリファレンスを返す", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "GET以外は405", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewConfigSchemaHandler(nil).ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/config/schema", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp ConfigSchemaResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Gateway) == 0 || len(resp.Routing) == 0 {
+				t.Fatalf("reference should not be empty: gateway=%d routing=%d", len(resp.Gateway), len(resp.Routing))
+			}
+			var found bool
+			for _, f := range resp.Gateway {
+				if f.Path == "redis.password" {
+					found = f.Env == "GATEWAY_REDIS_PASSWORD" && f.Type == "string"
+				}
+			}
+			if !found {
+				t.Error("redis.password with GATEWAY_REDIS_PASSWORD is not in the gateway reference")
+			}
+		})
+	}
+}
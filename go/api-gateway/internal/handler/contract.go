@@ -0,0 +1,65 @@
+package handler
+
+import (
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/contract"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+// validateRequest はリクエストをルートのOpenAPIドキュメントで検証する。path はバックエンドへ転送するパス
+// 違反はログとメトリクスに記録し、enforce では違反した項目ごとのエラーを含む400のProblem Detailsを返して false を返す
+func (g *Gateway) validateRequest(w http.ResponseWriter, r *http.Request, validator *routing.RequestValidator, path string) bool {
+	result, err := validator.Validate(r, path)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			g.handleProblem(w, r, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit)))
+		} else {
+			g.handleProblem(w, r, errors.NewBadRequestError("failed to read request body"))
+		}
+		return false
+	}
+
+	mode := contract.ModeAudit
+	if validator.Enforce {
+		mode = contract.ModeEnforce
+	}
+	if result.Skipped != "" {
+		if validator.Enforce {
+			g.handleProblem(w, r, errors.NewRequestEntityTooLargeError("cannot validate request: "+result.Skipped))
+			return false
+		}
+		g.logger.WarnContext(r.Context(), "request contract not validated",
+			slog.String("route", validator.Route),
+			slog.String("reason", result.Skipped),
+		)
+		return true
+	}
+	if len(result.Violations) == 0 {
+		return true
+	}
+
+	contract.RecordViolation(validator.Route, "request", mode)
+	messages := make([]string, len(result.Violations))
+	for i, violation := range result.Violations {
+		messages[i] = violation.String()
+	}
+	g.logger.WarnContext(r.Context(), "request contract violation",
+		slog.String("route", validator.Route),
+		slog.String("method", r.Method),
+		slog.String("operation", result.Operation),
+		slog.String("mode", mode),
+		slog.Any("violations", messages),
+	)
+	if !validator.Enforce {
+		return true
+	}
+	g.handleProblem(w, r, errors.NewErrorWithDetails(http.StatusBadRequest, "REQUEST_VALIDATION_FAILED",
+		"request does not match the API contract", map[string]any{"errors": result.Violations}))
+	return false
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"api-gateway/internal/errors"
+)
+
+// Drainer は処理中のリクエストを数え、シャットダウン時に新しいリクエストを断りながら完了を待つ
+// ミドルウェアチェーンとバックエンドへの転送（ストリーミングやUpgrade後の接続を含む）が終わるまでを処理中とする
+type Drainer struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{} // 排出中に処理中のリクエストが0になった時点で close する
+}
+
+// NewDrainer は新しいDrainerを作成する
+// Wrap のみを使う場合、next は nil でよい
+func NewDrainer(next http.Handler) *Drainer {
+	return &Drainer{next: next}
+}
+
+// ServeHTTP はhttp.Handlerインターフェースの実装
+func (d *Drainer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.serve(d.next, w, r)
+}
+
+// Wrap は処理中のリクエストをこのDrainerで数えるハンドラを返す
+// 複数のリスナーのリクエストをまとめて排出するために使う
+func (d *Drainer) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.serve(next, w, r)
+	})
+}
+
+// serve は排出中でなければリクエストを数えながら next で処理する
+func (d *Drainer) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		// 同じ接続で次のリクエストが送られないよう、接続を閉じるよう伝える
+		w.Header().Set("Connection", "close")
+		writeJSONError(w, errors.NewError(http.StatusServiceUnavailable, "SHUTTING_DOWN", "server is shutting down"))
+		return
+	}
+	d.inFlight++
+	d.mu.Unlock()
+
+	defer d.done()
+	next.ServeHTTP(w, r)
+}
+
+// done は処理中のリクエストを1つ減らし、排出中に0になった場合は待機中の Wait に知らせる
+func (d *Drainer) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.inFlight--
+	if d.draining && d.inFlight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// BeginDrain は新しいリクエストを503で断り始める
+func (d *Drainer) BeginDrain() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining = true
+}
+
+// InFlight は処理中のリクエスト数を返す
+func (d *Drainer) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// Wait は処理中のリクエストが全て終わるか ctx が終了するまで待ち、完了しなかったリクエスト数を返す
+// BeginDrain の前に呼んだ場合も排出を開始する
+func (d *Drainer) Wait(ctx context.Context) int {
+	d.mu.Lock()
+	d.draining = true
+	if d.inFlight == 0 {
+		d.mu.Unlock()
+		return 0
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+		return d.InFlight()
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainer_ServeHTTP(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d := NewDrainer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	inFlight := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		d.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(finished)
+	}()
+	<-started
+
+	if got := d.InFlight(); got != 1 {
+		t.Fatalf("InFlight() = %d, want 1", got)
+	}
+
+	// 排出中の新しいリクエストは503で断り、接続を閉じる
+	d.BeginDrain()
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Connection"); got != "close" {
+		t.Errorf("Connection = %q, want close", got)
+	}
+
+	// 処理中のリクエストが終わると Wait は0を返す
+	waited := make(chan int)
+	go func() { waited <- d.Wait(context.Background()) }()
+	close(release)
+	<-finished
+
+	select {
+	case aborted := <-waited:
+		if aborted != 0 {
+			t.Errorf("Wait() = %d, want 0", aborted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait() did not return after in-flight request completed")
+	}
+	if inFlight.Code != http.StatusOK {
+		t.Errorf("in-flight request status = %d, want %d", inFlight.Code, http.StatusOK)
+	}
+}
+
+func TestDrainer_Wait_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 2)
+	d := NewDrainer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	for range 2 {
+		go d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		<-started
+	}
+
+	// 期限までに終わらなかったリクエスト数を返す
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if aborted := d.Wait(ctx); aborted != 2 {
+		t.Errorf("Wait() = %d, want 2", aborted)
+	}
+}
+
+func TestDrainer_Wait_Idle(t *testing.T) {
+	d := NewDrainer(http.NotFoundHandler())
+	if aborted := d.Wait(context.Background()); aborted != 0 {
+		t.Errorf("Wait() = %d, want 0", aborted)
+	}
+}
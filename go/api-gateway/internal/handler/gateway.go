@@ -1,22 +1,49 @@
 package handler
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/config"
+	"api-gateway/internal/discovery"
 	"api-gateway/internal/errors"
+	"api-gateway/internal/featureflag"
+	"api-gateway/internal/forwarded"
+	"api-gateway/internal/healthcheck"
+	"api-gateway/internal/journal"
 	"api-gateway/internal/middleware"
+	"api-gateway/internal/requestctx"
 	"api-gateway/internal/routing"
 	"api-gateway/internal/transport"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/signature"
+
+	"github.com/google/uuid"
 )
 
 // Gateway はAPI Gatewayのメインハンドラ
 type Gateway struct {
 	router            *routing.Router
 	transporter       transport.Transporter
+	grpcTransporter   transport.Transporter
 	middlewareFactory *middleware.Factory
+	health            *healthcheck.Checker
+	outliers          *healthcheck.OutlierDetector
+	signer            *signature.Signer
+	trustedProxies    *forwarded.TrustedProxies
+	journal           *journal.Journal
+	flags             *featureflag.Provider
+	concurrency       *routing.ConcurrencyLimiter
+	discovery         *discovery.Catalog
+	recovery          *middleware.RecoveryMiddleware
+	slowThreshold     time.Duration
 	logger            *slog.Logger
 }
 
@@ -30,87 +57,595 @@ func NewGateway(router *routing.Router, transporter transport.Transporter, middl
 		router:            router,
 		transporter:       transporter,
 		middlewareFactory: middlewareFactory,
+		recovery:          middleware.NewRecoveryMiddleware(logger, middleware.RecoveryConfig{EnableStackTrace: true}),
 		logger:            logger,
 	}
 }
 
+// SetHealthChecker はアクティブヘルスチェックで異常とされたバックエンドへの転送を止める
+func (g *Gateway) SetHealthChecker(checker *healthcheck.Checker) {
+	g.health = checker
+}
+
+// SetOutlierDetector は外れ値検出で除外されたバックエンドへの転送を止める
+// 除外されたカナリアのバックエンドへのリクエストは安定版のバックエンドへ転送する
+func (g *Gateway) SetOutlierDetector(detector *healthcheck.OutlierDetector) {
+	g.outliers = detector
+}
+
+// SetRequestSigner は sign_requests が有効なルートで転送するリクエストに署名する
+func (g *Gateway) SetRequestSigner(signer *signature.Signer) {
+	g.signer = signer
+}
+
+// SetGRPCTransporter は grpc を設定したバックエンドへの転送に使うトランスポーターを設定する
+func (g *Gateway) SetGRPCTransporter(transporter transport.Transporter) {
+	g.grpcTransporter = transporter
+}
+
+// SetJournal は journal を設定したルートのリクエストのメタデータを記録するジャーナルを設定する
+func (g *Gateway) SetJournal(j *journal.Journal) {
+	g.journal = j
+}
+
+// SetFeatureFlags は enabled_when を設定したルートの公開を判定するフィーチャーフラグを設定する
+// 設定しない場合は全てのフラグを無効とみなす
+func (g *Gateway) SetFeatureFlags(flags *featureflag.Provider) {
+	g.flags = flags
+}
+
+// SetSlowRequestThreshold は threshold 以上かかったリクエストをWARNで記録し、gateway_slow_requests_total に数える
+// 0 の場合は記録しない
+func (g *Gateway) SetSlowRequestThreshold(threshold time.Duration) {
+	g.slowThreshold = threshold
+}
+
+// SetTrustedProxies は X-Forwarded-For / X-Forwarded-Proto を信頼するプロキシを設定する
+// 設定しない場合は全てのクライアントから受け取ったこれらのヘッダーを削除する
+func (g *Gateway) SetTrustedProxies(proxies *forwarded.TrustedProxies) {
+	g.trustedProxies = proxies
+}
+
+// SetConcurrencyLimiter はゲートウェイ全体で同時にバックエンドへ転送するリクエスト数を制限する
+// 複数のリスナーで同じ limiter を設定すると、全てのリスナーのリクエストの合計を制限する
+func (g *Gateway) SetConcurrencyLimiter(limiter *routing.ConcurrencyLimiter) {
+	g.concurrency = limiter
+}
+
+// SetServiceDiscovery は backend.service を指定したルートの転送先のインスタンスを選ぶカタログを設定する
+// 設定しない場合、それらのルートはインスタンスが無いものとして503を返す
+func (g *Gateway) SetServiceDiscovery(catalog *discovery.Catalog) {
+	g.discovery = catalog
+}
+
 // ServeHTTP はhttp.Handlerインターフェースの実装
 func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// OPTIONSリクエストの処理（CORSプリフライト）
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusNoContent)
+	// 信頼しないクライアントが送った X-Forwarded-* ヘッダーを削除し、クライアントの本当のIPアドレスを求める
+	r = g.trustedProxies.Sanitize(r)
+
+	// 相関IDとリクエスト情報を確定し、以降のログとバックエンドへのリクエストで共有する
+	r = g.withCorrelation(r)
+
+	// 最終的なステータスコードを記録し、処理の終了時にアクセスログを1行出力する
+	access := &accessLog{start: time.Now()}
+	aw := newAccessLogWriter(w)
+	w = aw
+	// リクエストIDと開始時刻はミドルウェアとハンドラで requestctx から参照する
+	correlation, _ := logger.CorrelationFromContext(r.Context())
+	r = r.WithContext(requestctx.With(r.Context(), requestctx.Values{RequestID: correlation.RequestID, StartTime: access.start}))
+	ctx := r.Context()
+	defer func() { g.logAccess(ctx, aw, access) }()
+
+	// リクエストIDをレスポンスにも返す（エラーレスポンスやキャッシュヒットも含む）
+	// ルールはリクエストごとに作成せずプールから取得し、レスポンスを書き終えた後に戻す
+	idRules := acquireRequestIDRules(r)
+	defer releaseRequestIDRules(idRules)
+	w = newHeaderRewriter(w, idRules, r)
+
+	// ミドルウェアとバックエンドへの転送で起きたパニックは、リクエストIDを付けてログに残し500のProblem Detailsにする
+	if err := g.recovery.Recover(ctx, r, func() error {
+		g.serve(w, r, aw, access)
+		return nil
+	}); err != nil {
+		// レスポンスを書き始めた後はステータスを変更できないため、接続を切って不完全なレスポンスであることを伝える
+		if aw.statusCode != 0 {
+			panic(http.ErrAbortHandler)
+		}
+		g.handleProblem(w, r, errors.NewInternalServerError("internal server error"))
+	}
+}
+
+// serve はルーティングの解決からミドルウェアの実行、バックエンドへの転送までを行う
+// w はアクセスログとリクエストIDのヘッダーを設定済みのもの、aw はそのステータスコードを記録する
+func (g *Gateway) serve(w http.ResponseWriter, r *http.Request, aw *accessLogWriter, access *accessLog) {
+	ctx := r.Context()
+
+	// CORSプリフライトはルートのCORSポリシーで応答する（ポリシーが無いルートはバックエンドへ転送する）
+	if middleware.IsPreflight(r) && g.handlePreflight(w, r, access) {
 		return
 	}
 
 	// ルーティング解決
-	matchResult, err := g.router.Match(r.Method, r.URL.Path)
+	matchResult, err := g.router.MatchRequest(r)
 	if err != nil {
-		g.handleError(w, r, errors.WrapError(err, http.StatusNotFound, "ROUTING_ERROR"))
+		g.handleError(w, r, routingError(err))
 		return
 	}
 
-	g.logger.Debug("route matched",
-		slog.String("path", r.URL.Path),
-		slog.String("method", r.Method),
+	matchResult.Route.Stats.RecordHit()
+	access.route = matchResult.Route.Path
+	access.journal = matchResult.Route.Journal
+	access.matched = time.Now()
+	// 監査ログにマッチしたルートを記録する
+	ctx = audit.WithRoute(ctx, matchResult.Route.Path)
+	r = r.WithContext(ctx)
+
+	// フィーチャーフラグが無効なルートは、存在しないルートとして扱うか代わりのバックエンドへ転送する
+	routeBackend := matchResult.Route.Backend
+	if gate := matchResult.Route.FlagGate; gate != nil && !gate.Enabled(g.flags.Enabled) {
+		if gate.Fallback == nil {
+			g.handleError(w, r, gate.DisabledError(r.URL.Path))
+			return
+		}
+		routeBackend = gate.Fallback
+	}
+
+	// 末尾スラッシュを正規形へリダイレクトする（GET/HEAD以外はメソッドとボディを保つため308）
+	if matchResult.RedirectPath != "" {
+		location := matchResult.RedirectPath
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, location, code)
+		return
+	}
+
+	g.logger.DebugContext(r.Context(), "route matched",
 		slog.Any("params", matchResult.Params),
 	)
 
-	// ミドルウェアチェーンの構築と実行
-	ctx := r.Context()
+	// timeout ミドルウェアの期限はミドルウェアチェーンとバックエンドへの転送の全体に適用する
+	// 期限を過ぎると ctx を通じてミドルウェアの処理とバックエンドへのリクエストも中断する
+	var timeout time.Duration
+	if timeout = g.routeTimeout(matchResult.Route); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	// ミドルウェアチェーンの構築（バックエンドへの転送をチェーンの最後のハンドラにする）
+	var next http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.forward(w, r, matchResult, routeBackend, timeout, aw, access)
+	})
 	if len(matchResult.Route.Middleware) > 0 {
-		chain, err := g.buildMiddlewareChain(matchResult.Route.Middleware)
+		chain, err := g.buildMiddlewareChain(matchResult.Route.Path, matchResult.Route.Middleware)
 		if err != nil {
 			g.handleError(w, r, errors.WrapError(err, http.StatusInternalServerError, "MIDDLEWARE_SETUP_ERROR"))
 			return
 		}
+		next = chain.Then(next, func(w http.ResponseWriter, r *http.Request, err error) {
+			if timeout > 0 && stderrors.Is(r.Context().Err(), context.DeadlineExceeded) {
+				g.handleProblem(w, r, timeoutError(timeout))
+				return
+			}
+			g.handleError(w, r, errors.WrapError(err, http.StatusUnauthorized, "MIDDLEWARE_ERROR"))
+		})
+	}
+
+	// リクエストボディサイズの制限（ミドルウェアがボディを読む前に適用する）
+	if limit := matchResult.Route.MaxRequestBody; limit > 0 {
+		next = g.limitRequestBody(limit, next)
+	}
+
+	// レスポンスヘッダーの変換（以降のエラーレスポンスやキャッシュヒットにも適用する）
+	if rules := matchResult.Route.ResponseHeaders; !rules.Empty() {
+		next = rewriteResponseHeaders(rules, next)
+	}
+
+	// CORSヘッダー（ミドルウェアのエラーレスポンスでもブラウザがエラー内容を読めるよう、チェーンの外側で設定する）
+	if policy := g.corsPolicy(matchResult.Route); policy != nil {
+		next = policy.Wrap(next)
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// limitRequestBody はリクエストボディが limit バイトを超える場合に413を返す
+func (g *Gateway) limitRequestBody(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			g.handleError(w, r, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", limit)))
+			return
+		}
+		// Content-Lengthが無い（chunked）場合も読み込み時に上限で打ち切る
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rewriteResponseHeaders はレスポンスヘッダーを rules で変換する
+func rewriteResponseHeaders(rules *routing.HeaderRules, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(newHeaderRewriter(w, rules, r), r)
+	})
+}
+
+// forward はミドルウェアチェーンの後にデータレジデンシーとレスポンスキャッシュを処理し、バックエンドへ転送する
+// r はミドルウェアが更新したcontextを持つ
+func (g *Gateway) forward(w http.ResponseWriter, r *http.Request, matchResult *routing.MatchResult, routeBackend *routing.Backend, timeout time.Duration, aw *accessLogWriter, access *accessLog) {
+	ctx := r.Context()
+	route := matchResult.Route
+	access.forwarded = time.Now()
+	if claims, ok := requestctx.Claims(ctx); ok {
+		access.userID, _ = claims["sub"].(string)
+	}
+
+	// 固定のレスポンスを返すルートはバックエンドへ転送しない
+	if static := routeBackend.Static; static != nil {
+		static.ServeHTTP(w, r)
+		return
+	}
+
+	// フィーチャーフラグが無効で代わりのバックエンドへ転送する場合は、ルートのバックエンドを置き換える設定を適用しない
+	gateFallback := routeBackend != route.Backend
 
-		ctx, err = chain.Execute(ctx, r)
+	// データレジデンシー: リージョンごとのバックエンドを選び、リージョンをまたぐ転送は拒否する
+	// リージョンはJWTのクレームを使うためミドルウェアの実行後、別のリージョンのキャッシュを返さないようキャッシュの参照より前に決める
+	var targetURL *url.URL
+	if residency := route.Residency; residency != nil && !gateFallback {
+		region, backendURL, err := residency.Resolve(r, claimValue(ctx, residency.Claim))
 		if err != nil {
-			g.handleError(w, r, errors.WrapError(err, http.StatusUnauthorized, "MIDDLEWARE_ERROR"))
+			g.handleProblem(w, r, residencyError(err))
 			return
 		}
+		access.region = region
+		targetURL = backendURL
+	}
 
-		r = r.WithContext(ctx)
+	// カナリア: リクエストの一部（sticky_claim がある場合はユーザー単位）をカナリアのバックエンドへ転送する
+	// カナリアのレスポンスを安定版のリクエストに返さないよう、カナリアへのリクエストはキャッシュを使わない
+	// 外れ値検出で除外されたカナリアへ振り分けるリクエストは安定版へ転送する
+	canary := !gateFallback && route.Canary != nil && route.Canary.Pick(claimValue(ctx, route.Canary.StickyClaim)) && !g.outliers.IsEjected(ctx, route.Canary.URL)
+	if canary {
+		targetURL = route.Canary.URL
+	}
+
+	// レスポンスキャッシュの参照（cacheミドルウェアが設定されたルートのみ）
+	var recorder *cacheRecorder
+	if policy, ok := middleware.GetCachePolicy(ctx); ok && !canary {
+		if !policy.Revalidate && g.serveFromCache(w, r, policy) {
+			return
+		}
+		w.Header().Set(cacheStatusHeader, "MISS")
+		recorder = newCacheRecorder(w, policy)
+		w = recorder
+	}
+
+	// クライアントが送った署名はバックエンドへ渡さない（ゲートウェイを経由したように偽装させない）
+	r.Header.Del(signature.Header)
+
+	// リクエストヘッダーの変換
+	if rules := route.RequestHeaders; !rules.Empty() {
+		rules.Apply(r.Header, r)
 	}
 
 	// バックエンドへの転送
-	backend := g.convertToTransportBackend(matchResult.Route.Backend)
-	if err := g.transporter.Transport(ctx, w, r, backend); err != nil {
+	backend := g.convertToTransportBackend(routeBackend)
+	backend.MaxResponseBody = route.MaxResponseBody
+	backend.Masker = route.ResponseMasker
+	backend.Validator = route.ResponseValidator
+	backend.PathParams = matchResult.Params
+	if targetURL != nil {
+		backend.URL = targetURL
+	} else if routeBackend.Service != "" {
+		// サービスディスカバリ: 異常・除外中のインスタンスを飛ばして選んだインスタンスへ転送する
+		instanceURL, ok := g.pickInstance(ctx, routeBackend)
+		if !ok {
+			g.handleError(w, r, errors.NewError(http.StatusServiceUnavailable, "NO_HEALTHY_INSTANCES",
+				fmt.Sprintf("no healthy instances of service %s", routeBackend.Service)))
+			return
+		}
+		backend.URL = instanceURL
+	}
+	access.backend = backend.URL.String()
+
+	// 契約（OpenAPIドキュメント）に違反したリクエストはバックエンドへ転送しない（ドキュメントのパスはバックエンドへ転送するパスと照合する）
+	if validator := route.RequestValidator; validator != nil && !g.validateRequest(w, r, validator, backend.URL.Path+r.URL.Path) {
+		return
+	}
+
+	if routeBackend.SignRequests {
+		if g.signer == nil {
+			g.handleError(w, r, errors.NewInternalServerError("request signing is not configured"))
+			return
+		}
+		backend.Signer = g.signer
+	}
+
+	// 異常なバックエンド、外れ値検出で除外されたバックエンドへは転送せず、タイムアウトを待たずに503を返す
+	if !g.health.IsHealthy(backend.URL) {
+		g.handleError(w, r, errors.NewError(http.StatusServiceUnavailable, "BACKEND_UNHEALTHY", "backend is unhealthy"))
+		return
+	}
+	if g.outliers.IsEjected(ctx, backend.URL) {
+		g.handleError(w, r, errors.NewError(http.StatusServiceUnavailable, "BACKEND_EJECTED", "backend is temporarily ejected due to errors"))
+		return
+	}
+
+	// 同時に転送するリクエスト数の上限に達している場合は、待ち行列で待つか Retry-After を付けて断る
+	release, err := g.acquireConcurrency(ctx, route)
+	if err != nil {
+		var limitErr errors.GatewayError
+		switch {
+		case stderrors.As(err, &limitErr):
+			g.handleProblem(w, r, limitErr)
+		case stderrors.Is(err, context.DeadlineExceeded):
+			g.handleProblem(w, r, timeoutError(timeout))
+		default:
+			// 待っている間にクライアントが切断した
+			access.aborted = true
+		}
+		return
+	}
+	defer release()
+
+	transporter := g.transporter
+	if backend.GRPC != nil {
+		if g.grpcTransporter == nil {
+			g.handleError(w, r, errors.NewInternalServerError("gRPC proxying is not configured"))
+			return
+		}
+		transporter = g.grpcTransporter
+	}
+
+	upstreamStart := time.Now()
+	err = transporter.Transport(ctx, w, r, backend)
+	access.upstream = time.Since(upstreamStart)
+	if err != nil {
+		// クライアントが切断済みの場合はレスポンスを返せないため、ログのみ残す
+		if stderrors.Is(err, transport.ErrClientAborted) {
+			access.aborted = true
+			return
+		}
+		if stderrors.Is(err, transport.ErrRequestTimeout) {
+			// レスポンスを書き始めた後は504を返せないため、接続を切って不完全なレスポンスであることを伝える
+			if aw.statusCode != 0 {
+				panic(http.ErrAbortHandler)
+			}
+			g.handleProblem(w, r, timeoutError(timeout))
+			return
+		}
 		g.handleError(w, r, errors.WrapError(err, http.StatusBadGateway, "TRANSPORT_ERROR"))
 		return
 	}
 
-	g.logger.Debug("request completed successfully",
-		slog.String("path", r.URL.Path),
-		slog.String("backend", backend.URL.String()),
-	)
+	if recorder != nil {
+		g.storeResponse(ctx, r, recorder)
+	}
+}
+
+// pickInstance はバックエンドのサービスのインスタンスをラウンドロビンで選び、url のホストを置き換えたURLを返す
+// アクティブヘルスチェックで異常なインスタンスと外れ値検出で除外されたインスタンスは選ばない
+func (g *Gateway) pickInstance(ctx context.Context, backend *routing.Backend) (*url.URL, bool) {
+	var picked *url.URL
+	_, ok := g.discovery.Pick(backend.Service, func(address string) bool {
+		instanceURL := *backend.URL
+		instanceURL.Host = address
+		if !g.health.IsHealthy(&instanceURL) || g.outliers.IsEjected(ctx, &instanceURL) {
+			return false
+		}
+		picked = &instanceURL
+		return true
+	})
+	return picked, ok
+}
+
+// concurrencyRetryAfter は同時リクエスト数の上限で断ったリクエストに返す Retry-After（秒）
+const concurrencyRetryAfter = "1"
+
+// acquireConcurrency はルートとゲートウェイ全体の同時リクエスト数の枠を確保し、解放する関数を返す
+// ルートの上限に達している場合は待ち行列で待ち、待ち行列が一杯なら429、待つ時間の上限を過ぎたら503を返す
+// ゲートウェイ全体の上限に達している場合は待たずに503を返す（待っている間に枠を占有しないよう、ルートの枠を確保した後に確認する）
+// 待っている間に ctx が終了した場合は ctx.Err() を返す
+func (g *Gateway) acquireConcurrency(ctx context.Context, route *routing.Route) (func(), error) {
+	if err := route.Concurrency.Acquire(ctx); err != nil {
+		switch {
+		case stderrors.Is(err, routing.ErrConcurrencyLimitExceeded):
+			return nil, retryLater(errors.NewError(http.StatusTooManyRequests, "ROUTE_CONCURRENCY_LIMITED",
+				fmt.Sprintf("route concurrency limit of %d requests exceeded", route.Concurrency.Limit())))
+		case stderrors.Is(err, routing.ErrConcurrencyQueueTimeout):
+			return nil, retryLater(errors.NewError(http.StatusServiceUnavailable, "ROUTE_QUEUE_TIMEOUT",
+				fmt.Sprintf("request waited %s for route concurrency limit of %d requests", route.Concurrency.Queue().Timeout, route.Concurrency.Limit())))
+		default:
+			return nil, err
+		}
+	}
+	if !g.concurrency.TryAcquire() {
+		route.Concurrency.Release()
+		return nil, retryLater(errors.NewError(http.StatusServiceUnavailable, "GATEWAY_OVERLOADED",
+			fmt.Sprintf("gateway concurrency limit of %d requests exceeded", g.concurrency.Limit())))
+	}
+	return func() {
+		g.concurrency.Release()
+		route.Concurrency.Release()
+	}, nil
+}
+
+// retryLater は同時リクエスト数の上限で断ったリクエストのエラーに Retry-After を付ける
+func retryLater(err errors.GatewayError) errors.GatewayError {
+	return errors.WithHeader(err, "Retry-After", concurrencyRetryAfter)
+}
+
+// handlePreflight はCORSプリフライトにルートのCORSポリシーで応答する
+// ルートはリクエストするメソッド（Access-Control-Request-Method）で解決する
+// プリフライトには実際のリクエストのヘッダーが含まれないため、ヘッダーの条件（match）を持つルートは同じパスの条件の無いルートで代わりに解決される
+// 解決できない、またはルートにCORSポリシーが無い場合は false を返し、通常のリクエストとして処理させる
+func (g *Gateway) handlePreflight(w http.ResponseWriter, r *http.Request, access *accessLog) bool {
+	actual := r.WithContext(r.Context())
+	actual.Method = r.Header.Get("Access-Control-Request-Method")
+	matchResult, err := g.router.MatchRequest(actual)
+	if err != nil || matchResult.RedirectPath != "" {
+		return false
+	}
+	policy := g.corsPolicy(matchResult.Route)
+	if policy == nil {
+		return false
+	}
+
+	access.route = matchResult.Route.Path
+	headers, ok := policy.Preflight(r)
+	if !ok {
+		g.handleError(w, r, errors.NewError(http.StatusForbidden, "CORS_PREFLIGHT_REJECTED", "CORS preflight request is not allowed"))
+		return true
+	}
+	for name, values := range headers {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// routeTimeout はルートの timeout ミドルウェアの期限を返す（設定が無い場合は0）
+func (g *Gateway) routeTimeout(route *routing.Route) time.Duration {
+	if g.middlewareFactory == nil {
+		return 0
+	}
+	for _, cfg := range route.Middleware {
+		if cfg.Type != "timeout" {
+			continue
+		}
+		m, err := g.middlewareFactory.Create(cfg)
+		if err != nil {
+			return 0
+		}
+		if tm, ok := m.(*middleware.TimeoutMiddleware); ok {
+			return tm.Timeout()
+		}
+	}
+	return 0
+}
+
+// timeoutError はルートの期限を過ぎた場合のエラーを返す
+func timeoutError(timeout time.Duration) errors.GatewayError {
+	return errors.NewGatewayTimeoutError(fmt.Sprintf("request exceeded route timeout of %s", timeout))
+}
+
+// corsPolicy はルートのcorsミドルウェアの設定からCORSポリシーを作成する（設定が無い場合はnil）
+func (g *Gateway) corsPolicy(route *routing.Route) *middleware.CORSMiddleware {
+	if g.middlewareFactory == nil {
+		return nil
+	}
+	for _, cfg := range route.Middleware {
+		if cfg.Type != "cors" {
+			continue
+		}
+		m, err := g.middlewareFactory.Create(cfg)
+		if err != nil {
+			return nil
+		}
+		policy, _ := m.(*middleware.CORSMiddleware)
+		return policy
+	}
+	return nil
+}
+
+// withCorrelation はリクエストに相関IDを割り当て、ログの http グループに出力するリクエスト情報を保存する
+// リクエストIDとトレースIDはクライアントの X-Request-ID / traceparent を引き継ぎ、無ければ新しく生成してバックエンドへ伝搬する
+func (g *Gateway) withCorrelation(r *http.Request) *http.Request {
+	requestID := r.Header.Get(logger.HeaderRequestID)
+	if !logger.ValidRequestID(requestID) {
+		requestID = uuid.New().String()
+	}
+	r.Header.Set(logger.HeaderRequestID, requestID)
+
+	traceID, ok := logger.ParseTraceParent(r.Header.Get(logger.HeaderTraceParent))
+	if !ok {
+		var traceParent string
+		traceParent, traceID = logger.NewTraceParent()
+		r.Header.Set(logger.HeaderTraceParent, traceParent)
+	}
+
+	ctx := logger.WithCorrelation(r.Context(), logger.Correlation{
+		RequestID: requestID,
+		TraceID:   traceID,
+	})
+	info := logger.NewHTTPRequest(r)
+	info.ClientIP = forwarded.ClientIP(r)
+	return r.WithContext(logger.WithHTTPRequestInfo(ctx, info))
+}
+
+// requestIDRulesPool はリクエストIDをレスポンスヘッダーに設定するルールのプール
+var requestIDRulesPool = sync.Pool{
+	New: func() any {
+		return &routing.HeaderRules{
+			Set: http.Header{logger.HeaderRequestID: make([]string, 1)},
+		}
+	},
+}
+
+// acquireRequestIDRules はリクエストIDをレスポンスヘッダーに設定するルールを返す
+// バックエンドが同じヘッダーを返した場合もゲートウェイの値で上書きする
+func acquireRequestIDRules(r *http.Request) *routing.HeaderRules {
+	rules := requestIDRulesPool.Get().(*routing.HeaderRules)
+	rules.Set[logger.HeaderRequestID][0] = r.Header.Get(logger.HeaderRequestID)
+	return rules
+}
+
+// releaseRequestIDRules はルールをプールへ戻す（以降 rules を参照してはならない）
+func releaseRequestIDRules(rules *routing.HeaderRules) {
+	rules.Set[logger.HeaderRequestID][0] = ""
+	requestIDRulesPool.Put(rules)
 }
 
 // buildMiddlewareChain はミドルウェアチェーンを構築する
-func (g *Gateway) buildMiddlewareChain(configs []config.MiddlewareConfig) (*middleware.Chain, error) {
+// 処理時間とエラー数はミドルウェアの種類とルートのパスごとに記録する
+// cors は前のミドルウェアのエラーレスポンスにもヘッダーを設定するため、チェーンには含めず serve でチェーンの外側に適用する
+func (g *Gateway) buildMiddlewareChain(route string, configs []config.MiddlewareConfig) (*middleware.Chain, error) {
 	if g.middlewareFactory == nil {
 		return middleware.NewChain(), nil
 	}
 
-	middlewares := make([]middleware.Middleware, 0, len(configs))
+	middlewares := make([]middleware.Named, 0, len(configs))
 
 	for _, cfg := range configs {
+		if cfg.Type == "cors" {
+			continue
+		}
 		m, err := g.middlewareFactory.Create(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create middleware type=%s: %w", cfg.Type, err)
 		}
-		middlewares = append(middlewares, m)
+		middlewares = append(middlewares, middleware.Named{Type: cfg.Type, Middleware: m})
 	}
 
-	return middleware.NewChain(middlewares...), nil
+	return middleware.NewRouteChain(route, middlewares...), nil
 }
 
 // convertToTransportBackend はrouting.Backendをtransport.Backendに変換する
 func (g *Gateway) convertToTransportBackend(routingBackend *routing.Backend) *transport.Backend {
 	return &transport.Backend{
-		URL:     routingBackend.URL,
-		Timeout: routingBackend.Timeout,
-		Headers: make(map[string]string),
+		URL:         routingBackend.URL,
+		Timeout:     routingBackend.Timeout,
+		Headers:     make(map[string]string),
+		Protocol:    routingBackend.Protocol,
+		Pool:        routingBackend.Pool,
+		Retry:       routingBackend.Retry,
+		Credentials: routingBackend.Credentials,
+		AWSSigner:   routingBackend.AWSSigner,
+		Shadow:      routingBackend.Shadow,
+		Mirror:      routingBackend.Mirror,
+		GRPC:        routingBackend.GRPC,
+		SOAP:        routingBackend.SOAP,
+		Storage:     routingBackend.Storage,
+		LongPoll:    routingBackend.LongPoll,
+		Aggregate:   routingBackend.Aggregate,
+
+		DecompressResponses: routingBackend.DecompressResponses,
 	}
 }
 
@@ -123,14 +658,62 @@ func (g *Gateway) handleError(w http.ResponseWriter, r *http.Request, err error)
 		gatewayErr = errors.NewInternalServerError(err.Error())
 	}
 
-	g.logger.Error("request failed",
-		slog.String("path", r.URL.Path),
-		slog.String("method", r.Method),
+	// ミドルウェアが返すリダイレクト（oidc のログインなど）は失敗として記録しない
+	level := slog.LevelError
+	if gatewayErr.StatusCode() < http.StatusBadRequest {
+		level = slog.LevelDebug
+	}
+	// エラーメッセージにトークンなどのヘッダーの値が含まれていてもログには残さない
+	g.logger.Log(r.Context(), level, "request failed",
 		slog.String("error_code", gatewayErr.ErrorCode()),
-		slog.String("error", gatewayErr.Error()),
+		slog.String("error", logger.ScrubSecrets(gatewayErr.Error(), r.Header)),
 	)
 
+	// gRPCのクライアントにはJSONのボディではなく gRPC のステータスで返す
+	if transport.IsGRPCRequest(r) {
+		transport.WriteGRPCError(w, gatewayErr.StatusCode(), gatewayErr.Error())
+		return
+	}
+
+	for key, values := range errors.ResponseHeaders(gatewayErr) {
+		w.Header()[key] = values
+	}
+	locale := negotiateLocale(w, r)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(gatewayErr.StatusCode())
-	w.Write(errors.ToJSON(gatewayErr))
+	w.Write(errors.ToLocalizedJSON(gatewayErr, locale))
+}
+
+// handleProblem はエラーをProblem Details（RFC 9457）形式で返す
+// 5xx 以外はクライアントの指定が原因のため、ログは警告として残す
+func (g *Gateway) handleProblem(w http.ResponseWriter, r *http.Request, err errors.GatewayError) {
+	level := slog.LevelWarn
+	if err.StatusCode() >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	g.logger.Log(r.Context(), level, "request failed",
+		slog.String("error_code", err.ErrorCode()),
+		slog.String("error", logger.ScrubSecrets(err.Error(), r.Header)),
+	)
+
+	if transport.IsGRPCRequest(r) {
+		transport.WriteGRPCError(w, err.StatusCode(), err.Error())
+		return
+	}
+
+	for key, values := range errors.ResponseHeaders(err) {
+		w.Header()[key] = values
+	}
+	locale := negotiateLocale(w, r)
+	w.Header().Set("Content-Type", errors.ContentTypeProblemJSON)
+	w.WriteHeader(err.StatusCode())
+	w.Write(errors.ToLocalizedProblemJSON(err, r.URL.Path, locale))
+}
+
+// negotiateLocale は Accept-Language からエラーレスポンスの言語を決め、Content-Language と Vary を設定する
+func negotiateLocale(w http.ResponseWriter, r *http.Request) errors.Locale {
+	locale := errors.NegotiateLocale(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
 }
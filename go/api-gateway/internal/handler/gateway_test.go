@@ -1,18 +1,43 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"api-gateway/internal/cache"
 	"api-gateway/internal/config"
+	"api-gateway/internal/discovery"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/featureflag"
+	"api-gateway/internal/forwarded"
+	"api-gateway/internal/healthcheck"
+	"api-gateway/internal/journal"
+	"api-gateway/internal/metrics"
+	"api-gateway/internal/middleware"
 	"api-gateway/internal/routing"
 	"api-gateway/internal/transport"
+	"api-gateway/pkg/logger"
+	"api-gateway/pkg/signature"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // mockTransporter はテスト用のTransporter実装
@@ -69,7 +94,14 @@ func TestNewGatewayWithNilLogger(t *testing.T) {
 }
 
 func TestGateway_ServeHTTP_OptionsRequest(t *testing.T) {
+	// CORSプリフライトでないOPTIONSは通常のリクエストとしてルーティングする
 	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://backend.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL},
+	})
 	transporter := &mockTransporter{}
 	gateway := NewGateway(router, transporter, nil, slog.Default())
 
@@ -78,9 +110,145 @@ func TestGateway_ServeHTTP_OptionsRequest(t *testing.T) {
 
 	gateway.ServeHTTP(w, req)
 
-	if w.Code != http.StatusNoContent {
-		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestGateway_ServeHTTP_CORS(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://backend.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet, http.MethodPut},
+		Backend: &routing.Backend{URL: backendURL},
+		Middleware: []config.MiddlewareConfig{{
+			Type: "cors",
+			Config: map[string]any{
+				"allowed_origins":   []any{"https://app.example.com"},
+				"allowed_methods":   []any{"GET", "PUT"},
+				"allowed_headers":   []any{"Content-Type", "Authorization"},
+				"exposed_headers":   []any{"X-Total-Count"},
+				"allow_credentials": true,
+			},
+		}},
+	})
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/orders",
+		Methods: []string{http.MethodGet, http.MethodOptions},
+		Backend: &routing.Backend{URL: backendURL},
+	})
+
+	var transported int
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			transported++
+			// バックエンドが返したCORSヘッダーはルートのポリシーで上書きされる
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	factory := middleware.NewFactory(middleware.FactoryConfig{CacheStore: cache.NewMemoryStore(0)})
+	gateway := NewGateway(router, transporter, factory, slog.New(slog.DiscardHandler))
+
+	preflight := func(path, origin, method, headers string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name            string
+		req             *http.Request
+		wantStatus      int
+		wantAllowOrigin string
+		wantAllowHeader string
+		wantTransported bool
+	}{
+		{
+			name:            "許可されたプリフライト",
+			req:             preflight("/api/v1/users", "https://app.example.com", http.MethodPut, "content-type, authorization"),
+			wantStatus:      http.StatusNoContent,
+			wantAllowOrigin: "https://app.example.com",
+			wantAllowHeader: "content-type, authorization",
+		},
+		{
+			name:       "許可されていないオリジンのプリフライト",
+			req:        preflight("/api/v1/users", "https://evil.example.com", http.MethodGet, ""),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "許可されていないメソッドのプリフライト",
+			req:        preflight("/api/v1/users", "https://app.example.com", http.MethodDelete, ""),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "許可されていないヘッダーのプリフライト",
+			req:        preflight("/api/v1/users", "https://app.example.com", http.MethodGet, "X-Debug"),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:            "CORSポリシーの無いルートのプリフライトはバックエンドへ転送する",
+			req:             preflight("/api/v1/orders", "https://app.example.com", http.MethodGet, ""),
+			wantStatus:      http.StatusOK,
+			wantAllowOrigin: "*",
+			wantTransported: true,
+		},
+		{
+			name: "実際のリクエストにCORSヘッダーを設定する",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+				req.Header.Set("Origin", "https://app.example.com")
+				return req
+			}(),
+			wantStatus:      http.StatusOK,
+			wantAllowOrigin: "https://app.example.com",
+			wantTransported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transported = 0
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, tt.req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != tt.wantAllowHeader {
+				t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, tt.wantAllowHeader)
+			}
+			if (transported > 0) != tt.wantTransported {
+				t.Errorf("transported = %v, want %v", transported > 0, tt.wantTransported)
+			}
+		})
 	}
+
+	t.Run("実際のリクエストはExpose-HeadersとCredentialsを返す", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+			t.Errorf("Access-Control-Expose-Headers = %q, want X-Total-Count", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+		}
+		if got := w.Header().Values("Vary"); !slices.Contains(got, "Origin") {
+			t.Errorf("Vary = %v, want Origin", got)
+		}
+	})
 }
 
 func TestGateway_ServeHTTP_RouteNotFound(t *testing.T) {
@@ -103,6 +271,66 @@ func TestGateway_ServeHTTP_RouteNotFound(t *testing.T) {
 	}
 }
 
+func TestGateway_ServeHTTP_GRPC(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://inventory.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/inventory.v1.InventoryService/*",
+		Methods: []string{http.MethodPost},
+		Backend: &routing.Backend{URL: backendURL, GRPC: &transport.GRPCOptions{}},
+	})
+
+	newRequest := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+		req.Header.Set("Content-Type", "application/grpc")
+		return req
+	}
+
+	t.Run("grpc のバックエンドは gRPC のトランスポーターで転送する", func(t *testing.T) {
+		var used string
+		gateway := NewGateway(router, &mockTransporter{
+			transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+				used = "http"
+				return nil
+			},
+		}, nil, slog.New(slog.DiscardHandler))
+		gateway.SetGRPCTransporter(&mockTransporter{
+			transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+				used = "grpc"
+				return nil
+			},
+		})
+
+		gateway.ServeHTTP(httptest.NewRecorder(), newRequest("/inventory.v1.InventoryService/GetStock"))
+		if used != "grpc" {
+			t.Errorf("expected gRPC transporter, got %q", used)
+		}
+	})
+
+	t.Run("gRPCのクライアントへのエラーは gRPC のステータスで返す", func(t *testing.T) {
+		gateway := NewGateway(router, &mockTransporter{}, nil, slog.New(slog.DiscardHandler))
+		gateway.SetGRPCTransporter(&mockTransporter{})
+
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, newRequest("/orders.v1.OrderService/GetOrder"))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/grpc" {
+			t.Errorf("expected Content-Type application/grpc, got %q", contentType)
+		}
+		// ルートが無い場合は UNIMPLEMENTED
+		if status := w.Header().Get("Grpc-Status"); status != "12" {
+			t.Errorf("expected grpc-status 12, got %q", status)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("expected empty body, got %s", w.Body.String())
+		}
+	})
+}
+
 func TestGateway_ServeHTTP_Success(t *testing.T) {
 	// ルーターの準備
 	router := routing.NewRouter()
@@ -174,6 +402,9 @@ func TestGateway_ServeHTTP_MethodNotAllowed(t *testing.T) {
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
 	}
+	if got := w.Header().Get("Allow"); got != http.MethodPost {
+		t.Errorf("expected Allow header %q, got %q", http.MethodPost, got)
+	}
 }
 
 func TestGateway_ServeHTTP_TransportError(t *testing.T) {
@@ -211,6 +442,340 @@ func TestGateway_ServeHTTP_TransportError(t *testing.T) {
 	}
 }
 
+func TestGateway_ServeHTTP_ClientAborted(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://backend.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL},
+	})
+
+	// クライアントの切断を検知して中断したトランスポーター
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			return transport.ErrClientAborted
+		},
+	}
+
+	gateway := NewGateway(router, transporter, nil, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	w := httptest.NewRecorder()
+
+	gateway.ServeHTTP(w, req)
+
+	// 切断済みのクライアントにはエラーレスポンスを書き込まない
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("expected no response, got status %d body %q", w.Code, w.Body.String())
+	}
+}
+
+func TestGateway_ServeHTTP_AccessLog(t *testing.T) {
+	backendURL, _ := url.Parse("http://backend.example.com")
+
+	tests := []struct {
+		name         string
+		path         string
+		transportErr error
+		wantStatus   float64
+		wantBytes    float64
+		wantRoute    string
+		wantBackend  string
+	}{
+		{
+			name:        "成功したリクエスト",
+			path:        "/api/v1/users/123",
+			wantStatus:  http.StatusOK,
+			wantBytes:   float64(len(`{"message":"success"}`)),
+			wantRoute:   "/api/v1/users/:id",
+			wantBackend: "http://backend.example.com",
+		},
+		{
+			name:       "ルートが見つからない",
+			path:       "/not-found",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:         "転送エラー",
+			path:         "/api/v1/users/123",
+			transportErr: http.ErrServerClosed,
+			wantStatus:   http.StatusBadGateway,
+			wantRoute:    "/api/v1/users/:id",
+			wantBackend:  "http://backend.example.com",
+		},
+		{
+			name:         "クライアントの切断",
+			path:         "/api/v1/users/123",
+			transportErr: transport.ErrClientAborted,
+			wantStatus:   statusClientClosedRequest,
+			wantRoute:    "/api/v1/users/:id",
+			wantBackend:  "http://backend.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := routing.NewRouter()
+			router.AddRoute(&routing.Route{
+				Path:    "/api/v1/users/:id",
+				Methods: []string{http.MethodGet},
+				Backend: &routing.Backend{URL: backendURL},
+			})
+
+			transporter := &mockTransporter{}
+			if tt.transportErr != nil {
+				transporter.transportFunc = func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+					return tt.transportErr
+				}
+			}
+
+			var buf bytes.Buffer
+			log := slog.New(logger.NewHandler(logger.NewCorrelationHandler(slog.NewJSONHandler(&buf, nil)), logger.HandlerOptions{}))
+			gateway := NewGateway(router, transporter, nil, log)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, req)
+
+			// アクセスログはリクエストごとに1行だけ出力される
+			var access []map[string]any
+			for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+				var entry map[string]any
+				if err := json.Unmarshal(line, &entry); err != nil {
+					t.Fatalf("failed to parse log line %s: %v", line, err)
+				}
+				if entry["msg"] == "access" {
+					access = append(access, entry)
+				}
+			}
+			if len(access) != 1 {
+				t.Fatalf("got %d access log lines, want 1: %s", len(access), buf.String())
+			}
+			entry := access[0]
+
+			if entry["status_code"] != tt.wantStatus {
+				t.Errorf("status_code = %v, want %v", entry["status_code"], tt.wantStatus)
+			}
+			if tt.wantBytes > 0 && entry["bytes_written"] != tt.wantBytes {
+				t.Errorf("bytes_written = %v, want %v", entry["bytes_written"], tt.wantBytes)
+			}
+			if _, ok := entry["duration"].(float64); !ok {
+				t.Errorf("duration = %v, want number", entry["duration"])
+			}
+			if got, _ := entry["route"].(string); got != tt.wantRoute {
+				t.Errorf("route = %q, want %q", got, tt.wantRoute)
+			}
+			if got, _ := entry["backend"].(string); got != tt.wantBackend {
+				t.Errorf("backend = %q, want %q", got, tt.wantBackend)
+			}
+			// Gatewayが割り当てたリクエストID（バックエンドへ伝搬するヘッダーの値）と一致すること
+			if entry[logger.FieldRequestID] != req.Header.Get(logger.HeaderRequestID) {
+				t.Errorf("request_id = %v, want %s", entry[logger.FieldRequestID], req.Header.Get(logger.HeaderRequestID))
+			}
+			if httpGroup, _ := entry[logger.HTTPGroup].(map[string]any); httpGroup["path"] != tt.path {
+				t.Errorf("http.path = %v, want %s", httpGroup["path"], tt.path)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_SlowRequest(t *testing.T) {
+	backendURL, _ := url.Parse("http://backend.example.com")
+	upstreamDelay := 20 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		route     string
+		threshold time.Duration
+		wantSlow  bool
+	}{
+		{name: "しきい値以上かかったリクエストを記録する", route: "/slow/recorded/:id", threshold: 10 * time.Millisecond, wantSlow: true},
+		{name: "しきい値未満は記録しない", route: "/slow/fast/:id", threshold: time.Hour},
+		{name: "しきい値が0の場合は記録しない", route: "/slow/disabled/:id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := routing.NewRouter()
+			router.AddRoute(&routing.Route{
+				Path:    tt.route,
+				Methods: []string{http.MethodGet},
+				Backend: &routing.Backend{URL: backendURL},
+			})
+			transporter := &mockTransporter{
+				transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+					time.Sleep(upstreamDelay)
+					w.WriteHeader(http.StatusOK)
+					return nil
+				},
+			}
+
+			var buf bytes.Buffer
+			log := slog.New(logger.NewHandler(slog.NewJSONHandler(&buf, nil), logger.HandlerOptions{}))
+			gateway := NewGateway(router, transporter, nil, log)
+			gateway.SetSlowRequestThreshold(tt.threshold)
+
+			req := httptest.NewRequest(http.MethodGet, strings.Replace(tt.route, ":id", "1", 1), nil)
+			gateway.ServeHTTP(httptest.NewRecorder(), req)
+
+			var slow map[string]any
+			for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+				var entry map[string]any
+				if err := json.Unmarshal(line, &entry); err != nil {
+					t.Fatalf("failed to parse log line %s: %v", line, err)
+				}
+				if entry["msg"] == "slow request" {
+					slow = entry
+				}
+			}
+
+			var b strings.Builder
+			metrics.Default.WriteText(&b)
+			counted := strings.Contains(b.String(), fmt.Sprintf(`gateway_slow_requests_total{route=%q} 1`, tt.route))
+			if counted != tt.wantSlow {
+				t.Errorf("gateway_slow_requests_total counted = %v, want %v", counted, tt.wantSlow)
+			}
+			if !tt.wantSlow {
+				if slow != nil {
+					t.Errorf("unexpected slow request log: %v", slow)
+				}
+				return
+			}
+
+			if slow == nil {
+				t.Fatalf("slow request log not found: %s", buf.String())
+			}
+			if slow["level"] != "WARN" {
+				t.Errorf("level = %v, want WARN", slow["level"])
+			}
+			if slow["route"] != tt.route || slow["backend"] != "http://backend.example.com" {
+				t.Errorf("route = %v, backend = %v", slow["route"], slow["backend"])
+			}
+			upstream, _ := slow["upstream_duration"].(float64)
+			if time.Duration(upstream) < upstreamDelay {
+				t.Errorf("upstream_duration = %v, want >= %v", time.Duration(upstream), upstreamDelay)
+			}
+			if _, ok := slow["middleware_duration"].(float64); !ok {
+				t.Errorf("middleware_duration = %v, want number", slow["middleware_duration"])
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_TrailingSlashRedirect(t *testing.T) {
+	router := routing.NewRouter()
+	router.SetTrailingSlashPolicy(routing.TrailingSlashRedirect)
+	backendURL, _ := url.Parse("http://backend.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet, http.MethodPost},
+		Backend: &routing.Backend{URL: backendURL},
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		wantStatus   int
+		wantLocation string
+	}{
+		{name: "GETは301で正規形へ", method: http.MethodGet, path: "/api/v1/users/?page=2", wantStatus: http.StatusMovedPermanently, wantLocation: "/api/v1/users?page=2"},
+		{name: "POSTは308で正規形へ", method: http.MethodPost, path: "/api/v1/users/", wantStatus: http.StatusPermanentRedirect, wantLocation: "/api/v1/users"},
+		{name: "正規形はそのまま転送", method: http.MethodGet, path: "/api/v1/users", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transported := false
+			transporter := &mockTransporter{
+				transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+					transported = true
+					w.WriteHeader(http.StatusOK)
+					return nil
+				},
+			}
+			gateway := NewGateway(router, transporter, nil, slog.Default())
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if transported != (tt.wantLocation == "") {
+				t.Errorf("transported = %v, want %v", transported, tt.wantLocation == "")
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_UnhealthyBackend(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	backendURL, _ := url.Parse(backend.URL)
+	router := routing.NewRouter()
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL},
+	})
+
+	checker := healthcheck.New(healthcheck.Config{
+		UnhealthyThreshold: 1,
+		Logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, []*url.URL{backendURL})
+
+	transported := false
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			transported = true
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gateway.SetHealthChecker(checker)
+
+	tests := []struct {
+		name            string
+		check           bool
+		wantStatus      int
+		wantTransported bool
+	}{
+		{name: "確認前は転送する", check: false, wantStatus: http.StatusOK, wantTransported: true},
+		{name: "異常なバックエンドへは転送せず503", check: true, wantStatus: http.StatusServiceUnavailable, wantTransported: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.check {
+				checker.CheckAll(context.Background())
+			}
+			transported = false
+
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if transported != tt.wantTransported {
+				t.Errorf("transported = %v, want %v", transported, tt.wantTransported)
+			}
+		})
+	}
+}
+
 func TestGateway_ServeHTTP_WithPathParams(t *testing.T) {
 	// パスパラメータを含むルート
 	router := routing.NewRouter()
@@ -276,3 +841,1759 @@ func TestGateway_convertToTransportBackend(t *testing.T) {
 		t.Error("Headers should be initialized")
 	}
 }
+
+func TestGateway_ServeHTTP_Correlation(t *testing.T) {
+	const incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name          string
+		traceParent   string
+		requestID     string
+		wantTraceID   string
+		wantRequestID string
+	}{
+		{
+			name:          "client trace and request id are continued",
+			traceParent:   "00-" + incomingTraceID + "-00f067aa0ba902b7-01",
+			requestID:     "client-req-1",
+			wantTraceID:   incomingTraceID,
+			wantRequestID: "client-req-1",
+		},
+		{
+			name:        "new trace and request id are started",
+			traceParent: "",
+		},
+		{
+			name:      "invalid request id is replaced",
+			requestID: "bad id\r\nX-Injected: 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var backendTraceParent, backendRequestID string
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				backendTraceParent = r.Header.Get(logger.HeaderTraceParent)
+				backendRequestID = r.Header.Get(logger.HeaderRequestID)
+				// バックエンドが返したリクエストIDはゲートウェイの値で上書きされる
+				w.Header().Set(logger.HeaderRequestID, "backend-req")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer backendServer.Close()
+
+			router := routing.NewRouter()
+			backendURL, _ := url.Parse(backendServer.URL)
+			router.AddRoute(&routing.Route{
+				Path:    "/api/v1/users",
+				Methods: []string{http.MethodGet},
+				Backend: &routing.Backend{URL: backendURL, Timeout: 5 * time.Second},
+			})
+
+			var buf bytes.Buffer
+			log := slog.New(logger.NewCorrelationHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+			gateway := NewGateway(router, transport.NewHTTPTransporter(), nil, log)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if tt.traceParent != "" {
+				req.Header.Set(logger.HeaderTraceParent, tt.traceParent)
+			}
+			if tt.requestID != "" {
+				req.Header.Set(logger.HeaderRequestID, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			// バックエンドへ伝搬されたトレースIDとログのトレースIDが一致すること
+			backendTraceID, ok := logger.ParseTraceParent(backendTraceParent)
+			if !ok {
+				t.Fatalf("backend received invalid traceparent %q", backendTraceParent)
+			}
+			if tt.wantTraceID != "" && backendTraceID != tt.wantTraceID {
+				t.Errorf("backend trace_id = %s, want %s", backendTraceID, tt.wantTraceID)
+			}
+
+			var requestID string
+			for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+				var entry map[string]any
+				if err := json.Unmarshal(line, &entry); err != nil {
+					t.Fatalf("failed to parse log line %s: %v", line, err)
+				}
+				if entry[logger.FieldTraceID] != backendTraceID {
+					t.Errorf("log %q trace_id = %v, want %s", entry["msg"], entry[logger.FieldTraceID], backendTraceID)
+				}
+				id, _ := entry[logger.FieldRequestID].(string)
+				if id == "" {
+					t.Errorf("log %q has no request_id", entry["msg"])
+				}
+				if requestID != "" && id != requestID {
+					t.Errorf("log %q request_id = %s, want %s", entry["msg"], id, requestID)
+				}
+				requestID = id
+			}
+			if requestID == "" {
+				t.Fatal("gateway wrote no correlated log lines")
+			}
+
+			// ログ・バックエンド・レスポンスで同じリクエストIDを共有すること
+			if tt.wantRequestID != "" && requestID != tt.wantRequestID {
+				t.Errorf("request_id = %s, want %s", requestID, tt.wantRequestID)
+			}
+			if backendRequestID != requestID {
+				t.Errorf("backend %s = %q, want %s", logger.HeaderRequestID, backendRequestID, requestID)
+			}
+			if got := w.Header().Values(logger.HeaderRequestID); len(got) != 1 || got[0] != requestID {
+				t.Errorf("response %s = %v, want [%s]", logger.HeaderRequestID, got, requestID)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_BodyLimits(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		size := 10
+		if r.URL.Query().Get("large") != "" {
+			size = 100
+		}
+		if r.URL.Query().Get("chunked") != "" {
+			// Flushすることで Content-Length なしの chunked レスポンスにする
+			w.Write([]byte(strings.Repeat("a", size/2)))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(strings.Repeat("a", size-size/2)))
+			return
+		}
+		w.Write([]byte(strings.Repeat("a", size)))
+	}))
+	defer backendServer.Close()
+
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse(backendServer.URL)
+	router.AddRoute(&routing.Route{
+		Path:            "/upload",
+		Methods:         []string{http.MethodPost},
+		Backend:         &routing.Backend{URL: backendURL, Timeout: 5 * time.Second},
+		MaxRequestBody:  20,
+		MaxResponseBody: 50,
+	})
+	gateway := NewGateway(router, transport.NewHTTPTransporter(), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name       string
+		query      string
+		body       io.Reader
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "within limits", body: strings.NewReader("small"), wantStatus: http.StatusOK},
+		{name: "request Content-Length over limit", body: strings.NewReader(strings.Repeat("b", 21)), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked request over limit", body: strings.NewReader(strings.Repeat("b", 100)), chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "response Content-Length over limit", query: "?large=1", body: strings.NewReader("small"), wantStatus: http.StatusBadGateway},
+		{name: "chunked response over limit", query: "?large=1&chunked=1", body: strings.NewReader("small"), wantStatus: http.StatusBadGateway},
+		{name: "chunked response within limit", query: "?chunked=1", body: strings.NewReader("small"), wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/upload"+tt.query, tt.body)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d (body: %s)", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Cache(t *testing.T) {
+	var backendHits int
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendHits++
+		if cc := r.URL.Query().Get("cc"); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits":` + strconv.Itoa(backendHits) + `}`))
+	}))
+	defer backendServer.Close()
+
+	newGateway := func() *Gateway {
+		router := routing.NewRouter()
+		backendURL, _ := url.Parse(backendServer.URL)
+		router.AddRoute(&routing.Route{
+			Path:    "/api/v1/users",
+			Methods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+			Backend: &routing.Backend{URL: backendURL, Timeout: 5 * time.Second},
+			Middleware: []config.MiddlewareConfig{
+				{Type: "cache", Config: map[string]any{"ttl": "1m", "vary": []any{"Accept-Language"}}},
+			},
+		})
+		factory := middleware.NewFactory(middleware.FactoryConfig{CacheStore: cache.NewMemoryStore(0)})
+		return NewGateway(router, transport.NewHTTPTransporter(), factory, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	type request struct {
+		method    string
+		query     string
+		header    map[string]string
+		wantCache string
+		wantHits  int
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "2回目のGETはキャッシュから返す",
+			requests: []request{
+				{method: http.MethodGet, wantCache: "MISS", wantHits: 1},
+				{method: http.MethodGet, wantCache: "HIT", wantHits: 1},
+				{method: http.MethodHead, wantCache: "HIT", wantHits: 1},
+			},
+		},
+		{
+			name: "クエリとVaryヘッダーごとにキャッシュする",
+			requests: []request{
+				{method: http.MethodGet, query: "?page=1", wantCache: "MISS", wantHits: 1},
+				{method: http.MethodGet, query: "?page=2", wantCache: "MISS", wantHits: 2},
+				{method: http.MethodGet, query: "?page=1", header: map[string]string{"Accept-Language": "ja"}, wantCache: "MISS", wantHits: 3},
+				{method: http.MethodGet, query: "?page=1", wantCache: "HIT", wantHits: 3},
+			},
+		},
+		{
+			name: "no-storeのレスポンスはキャッシュしない",
+			requests: []request{
+				{method: http.MethodGet, query: "?cc=no-store", wantCache: "MISS", wantHits: 1},
+				{method: http.MethodGet, query: "?cc=no-store", wantCache: "MISS", wantHits: 2},
+			},
+		},
+		{
+			name: "認証付きリクエストのレスポンスはpublicでなければキャッシュしない",
+			requests: []request{
+				{method: http.MethodGet, header: map[string]string{"Authorization": "Bearer token"}, wantCache: "MISS", wantHits: 1},
+				{method: http.MethodGet, header: map[string]string{"Authorization": "Bearer token"}, wantCache: "MISS", wantHits: 2},
+			},
+		},
+		{
+			name: "クライアントのno-cacheはバックエンドへ転送してキャッシュを更新する",
+			requests: []request{
+				{method: http.MethodGet, wantCache: "MISS", wantHits: 1},
+				{method: http.MethodGet, header: map[string]string{"Cache-Control": "no-cache"}, wantCache: "MISS", wantHits: 2},
+				{method: http.MethodGet, wantCache: "HIT", wantHits: 2},
+			},
+		},
+		{
+			name: "POSTはキャッシュしない",
+			requests: []request{
+				{method: http.MethodPost, wantCache: "", wantHits: 1},
+				{method: http.MethodPost, wantCache: "", wantHits: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backendHits = 0
+			gateway := newGateway()
+
+			// キャッシュキー（クエリ + Vary）ごとに最後にバックエンドから返ったボディ
+			bodies := map[string]string{}
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(r.method, "/api/v1/users"+r.query, nil)
+				for k, v := range r.header {
+					req.Header.Set(k, v)
+				}
+				w := httptest.NewRecorder()
+
+				gateway.ServeHTTP(w, req)
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("request %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+				}
+				if got := w.Header().Get("X-Cache"); got != r.wantCache {
+					t.Errorf("request %d: X-Cache = %q, want %q", i, got, r.wantCache)
+				}
+				if backendHits != r.wantHits {
+					t.Errorf("request %d: backend hits = %d, want %d", i, backendHits, r.wantHits)
+				}
+				if r.wantCache == "HIT" {
+					if w.Header().Get("Content-Type") != "application/json" {
+						t.Errorf("request %d: cached Content-Type = %q", i, w.Header().Get("Content-Type"))
+					}
+					if r.method == http.MethodGet && w.Body.String() != bodies[r.query+r.header["Accept-Language"]] {
+						t.Errorf("request %d: cached body = %s, want %s", i, w.Body.String(), bodies[r.query+r.header["Accept-Language"]])
+					}
+					if r.method == http.MethodHead && w.Body.Len() != 0 {
+						t.Errorf("request %d: HEAD response has body %s", i, w.Body.String())
+					}
+				}
+				if r.wantCache == "MISS" {
+					bodies[r.query+r.header["Accept-Language"]] = w.Body.String()
+				}
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Cache_Vary(t *testing.T) {
+	var backendHits int
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendHits++
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer backendServer.Close()
+
+	// キャッシュキーに Accept-Language を含めないルート
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse(backendServer.URL)
+	router.AddRoute(&routing.Route{
+		Path:       "/api/v1/messages",
+		Methods:    []string{http.MethodGet},
+		Backend:    &routing.Backend{URL: backendURL, Timeout: 5 * time.Second},
+		Middleware: []config.MiddlewareConfig{{Type: "cache", Config: map[string]any{"ttl": "1m"}}},
+	})
+	factory := middleware.NewFactory(middleware.FactoryConfig{CacheStore: cache.NewMemoryStore(0)})
+	gateway := NewGateway(router, transport.NewHTTPTransporter(), factory, slog.New(slog.DiscardHandler))
+
+	// キーに含まないヘッダーで変わるレスポンスは保存せず、別の言語のリクエストに返さない
+	for i, lang := range []string{"ja", "en", "en"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/messages", nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, req)
+
+		if w.Body.String() != lang || w.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("request %d: body = %q, X-Cache = %q, want %q from backend", i, w.Body.String(), w.Header().Get("X-Cache"), lang)
+		}
+	}
+	if backendHits != 3 {
+		t.Errorf("backend hits = %d, want 3", backendHits)
+	}
+}
+
+func TestGateway_ServeHTTP_HeaderRules(t *testing.T) {
+	var backendHeader http.Header
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendHeader = r.Header.Clone()
+		w.Header().Set("X-Powered-By", "backend")
+		w.Header().Set("Server", "backend/1.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	requestHeaders, err := routing.NewHeaderRules(config.HeaderRulesConfig{
+		Set:    map[string]string{"X-Forwarded-Host": "{host}", "X-Gateway": "api-gateway"},
+		Remove: []string{"X-Internal-Token"},
+	})
+	if err != nil {
+		t.Fatalf("NewHeaderRules() error = %v", err)
+	}
+	responseHeaders, err := routing.NewHeaderRules(config.HeaderRulesConfig{
+		Add:    map[string]string{"Strict-Transport-Security": "max-age=31536000"},
+		Remove: []string{"X-Powered-By", "Server"},
+	})
+	if err != nil {
+		t.Fatalf("NewHeaderRules() error = %v", err)
+	}
+
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse(backendServer.URL)
+	router.AddRoute(&routing.Route{
+		Path:            "/api/v1/users",
+		Methods:         []string{http.MethodGet, http.MethodPost},
+		Backend:         &routing.Backend{URL: backendURL, Timeout: 5 * time.Second},
+		MaxRequestBody:  10,
+		RequestHeaders:  requestHeaders,
+		ResponseHeaders: responseHeaders,
+	})
+	gateway := NewGateway(router, transport.NewHTTPTransporter(), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Run("バックエンドへのリクエストとレスポンスのヘッダーを変換する", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/users", nil)
+		req.Header.Set("X-Internal-Token", "secret")
+		w := httptest.NewRecorder()
+
+		gateway.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if got := backendHeader.Get("X-Forwarded-Host"); got != "api.example.com" {
+			t.Errorf("backend X-Forwarded-Host = %q, want api.example.com", got)
+		}
+		if got := backendHeader.Get("X-Gateway"); got != "api-gateway" {
+			t.Errorf("backend X-Gateway = %q, want api-gateway", got)
+		}
+		if got := backendHeader.Get("X-Internal-Token"); got != "" {
+			t.Errorf("backend X-Internal-Token = %q, want removed", got)
+		}
+		if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=31536000" {
+			t.Errorf("Strict-Transport-Security = %q, want max-age=31536000", got)
+		}
+		if w.Header().Get("X-Powered-By") != "" || w.Header().Get("Server") != "" {
+			t.Errorf("backend headers should be removed: %v", w.Header())
+		}
+	})
+
+	t.Run("ゲートウェイのエラーレスポンスにも適用する", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(strings.Repeat("a", 11)))
+		w := httptest.NewRecorder()
+
+		gateway.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+		if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=31536000" {
+			t.Errorf("Strict-Transport-Security = %q, want max-age=31536000", got)
+		}
+	})
+}
+
+func TestGateway_ServeHTTP_RequestSigning(t *testing.T) {
+	keys := newTestSigningKeySet(t, "gw-1", "gw-1")
+	publicKeys := keys.PublicKeys()
+	publicKey := func(kid string) (*rsa.PublicKey, error) {
+		key, ok := publicKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %s", kid)
+		}
+		return key, nil
+	}
+
+	var verifyErr error
+	var gotSignature, gotBody string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(signature.Header)
+		verifyErr = signature.VerifyRequest(r, publicKey, 0)
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	backendURL, _ := url.Parse(backendServer.URL + "/internal")
+	router := routing.NewRouter()
+	router.AddRoute(&routing.Route{
+		Path:    "/signed",
+		Backend: &routing.Backend{URL: backendURL, SignRequests: true},
+	})
+	router.AddRoute(&routing.Route{
+		Path:    "/unsigned",
+		Backend: &routing.Backend{URL: backendURL},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	signed := NewGateway(router, transport.NewHTTPTransporter(), nil, logger)
+	signed.SetRequestSigner(signature.NewSigner(keys.Active))
+	unsigned := NewGateway(router, transport.NewHTTPTransporter(), nil, logger)
+
+	tests := []struct {
+		name          string
+		gateway       *Gateway
+		method        string
+		target        string
+		body          string
+		wantStatus    int
+		wantSignature bool
+	}{
+		{name: "ボディ付きのリクエストに署名する", gateway: signed, method: http.MethodPost, target: "/signed?a=1", body: `{"name":"test"}`, wantStatus: http.StatusOK, wantSignature: true},
+		{name: "ボディ無しのリクエストに署名する", gateway: signed, method: http.MethodGet, target: "/signed", wantStatus: http.StatusOK, wantSignature: true},
+		{name: "署名しないルートではクライアントの署名を削除する", gateway: signed, method: http.MethodGet, target: "/unsigned", wantStatus: http.StatusOK, wantSignature: false},
+		{name: "署名鍵が無い場合は500", gateway: unsigned, method: http.MethodGet, target: "/signed", wantStatus: http.StatusInternalServerError, wantSignature: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifyErr, gotSignature, gotBody = nil, "", ""
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(signature.Header, "kid=gw-1,ts=0,sig=forged")
+			w := httptest.NewRecorder()
+			tt.gateway.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if (gotSignature != "") != tt.wantSignature {
+				t.Fatalf("signature header = %q, want present=%v", gotSignature, tt.wantSignature)
+			}
+			if tt.wantSignature && verifyErr != nil {
+				t.Errorf("signature verification failed: %v", verifyErr)
+			}
+			if gotBody != tt.body {
+				t.Errorf("backend body = %q, want %q", gotBody, tt.body)
+			}
+		})
+	}
+}
+
+// BenchmarkGateway_ServeHTTP はルーティングから転送までの1リクエストあたりの割り当てを計測する
+// go test -bench Gateway_ServeHTTP -benchmem -memprofile mem.out ./internal/handler/
+func BenchmarkGateway_ServeHTTP(b *testing.B) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://backend.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users/:id",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL, Timeout: 30 * time.Second},
+	})
+	gateway := NewGateway(router, &mockTransporter{}, nil, slog.New(slog.DiscardHandler))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/123", nil)
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+}
+
+func TestGateway_ServeHTTP_TrustedProxies(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://backend.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL},
+		RequestHeaders: &routing.HeaderRules{
+			Set: http.Header{"X-Client-Ip": {"{client_ip}"}},
+		},
+	})
+
+	var backendHeader http.Header
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			backendHeader = req.Header.Clone()
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	factory := middleware.NewFactory(middleware.FactoryConfig{CacheStore: cache.NewMemoryStore(0)})
+	gateway := NewGateway(router, transporter, factory, slog.New(slog.DiscardHandler))
+	proxies, err := forwarded.NewTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gateway.SetTrustedProxies(proxies)
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		wantClientIP  string
+		wantForwarded string
+		wantProto     string
+	}{
+		{
+			name:         "信頼しないクライアントの X-Forwarded-* は削除する",
+			remoteAddr:   "203.0.113.10:54321",
+			wantClientIP: "203.0.113.10",
+			wantProto:    "http",
+		},
+		{
+			name:          "信頼するプロキシの X-Forwarded-* は転送する",
+			remoteAddr:    "10.0.0.5:54321",
+			wantClientIP:  "198.51.100.7",
+			wantForwarded: "198.51.100.7",
+			wantProto:     "https",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := backendHeader.Get("X-Forwarded-For"); got != tt.wantForwarded {
+				t.Errorf("X-Forwarded-For = %q, want %q", got, tt.wantForwarded)
+			}
+			if got := backendHeader.Get("X-Forwarded-Proto"); got != tt.wantProto {
+				t.Errorf("X-Forwarded-Proto = %q, want %q", got, tt.wantProto)
+			}
+			if got := backendHeader.Get("X-Client-Ip"); got != tt.wantClientIP {
+				t.Errorf("{client_ip} = %q, want %q", got, tt.wantClientIP)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Residency(t *testing.T) {
+	residency, err := routing.NewResidency(config.ResidencyConfig{
+		Region:   "eu",
+		Header:   "X-Data-Region",
+		Backends: map[string]string{"eu": "http://orders.eu.example.com", "jp": "http://orders.jp.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://orders.example.com")
+	router.AddRoute(&routing.Route{
+		Path:      "/api/v1/orders",
+		Methods:   []string{http.MethodGet},
+		Backend:   &routing.Backend{URL: backendURL},
+		Residency: residency,
+	})
+
+	var transportedTo string
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			transportedTo = backend.URL.String()
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+
+	t.Run("リージョンのバックエンドへ転送する", func(t *testing.T) {
+		transportedTo = ""
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+		req.Header.Set("X-Data-Region", "eu")
+		w := httptest.NewRecorder()
+
+		gateway.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if transportedTo != "http://orders.eu.example.com" {
+			t.Errorf("backend = %q, want http://orders.eu.example.com", transportedTo)
+		}
+	})
+
+	t.Run("リージョンをまたぐリクエストはProblem Detailsで拒否する", func(t *testing.T) {
+		transportedTo = ""
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+		req.Header.Set("X-Data-Region", "jp")
+		w := httptest.NewRecorder()
+
+		gateway.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+		if transportedTo != "" {
+			t.Errorf("request should not be transported, got %q", transportedTo)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != errors.ContentTypeProblemJSON {
+			t.Errorf("Content-Type = %q, want %q", ct, errors.ContentTypeProblemJSON)
+		}
+		var problem errors.ProblemDetails
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if problem.Status != http.StatusForbidden || problem.Code != "DATA_RESIDENCY_VIOLATION" || problem.Instance != "/api/v1/orders" {
+			t.Errorf("unexpected problem details: %+v", problem)
+		}
+	})
+
+	t.Run("Accept-Languageの言語でtitleを返す", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+		req.Header.Set("X-Data-Region", "jp")
+		req.Header.Set("Accept-Language", "ja-JP,en;q=0.8")
+		w := httptest.NewRecorder()
+
+		gateway.ServeHTTP(w, req)
+
+		if cl := w.Header().Get("Content-Language"); cl != "ja" {
+			t.Errorf("Content-Language = %q, want ja", cl)
+		}
+		var problem errors.ProblemDetails
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if problem.Title != "アクセスが許可されていません" {
+			t.Errorf("title = %q, want アクセスが許可されていません", problem.Title)
+		}
+		if problem.Detail != "データの保存先リージョンの制約によりリクエストを転送できません" {
+			t.Errorf("detail = %q, want translated detail", problem.Detail)
+		}
+	})
+}
+
+func TestGateway_ServeHTTP_ErrorLocale(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://users.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL},
+	})
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			return fmt.Errorf("dial tcp %s: connection refused", backend.URL.Host)
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptLanguage string
+		wantStatus     int
+		wantCode       string
+		wantLanguage   string
+		wantMessage    string
+	}{
+		{
+			name:           "ルートが無い場合の404を英語で返す",
+			path:           "/api/v1/unknown",
+			acceptLanguage: "en",
+			wantStatus:     http.StatusNotFound,
+			wantCode:       "NOT_FOUND",
+			wantLanguage:   "en",
+			wantMessage:    "no route found",
+		},
+		{
+			name:           "ルートが無い場合の404を日本語で返す",
+			path:           "/api/v1/unknown",
+			acceptLanguage: "ja",
+			wantStatus:     http.StatusNotFound,
+			wantCode:       "NOT_FOUND",
+			wantLanguage:   "ja",
+			wantMessage:    "リクエストされたパスに一致するルートがありません",
+		},
+		{
+			name:           "転送に失敗した場合の502を英語で返す",
+			path:           "/api/v1/users",
+			acceptLanguage: "en",
+			wantStatus:     http.StatusBadGateway,
+			wantCode:       "TRANSPORT_ERROR",
+			wantLanguage:   "en",
+			wantMessage:    "connection refused",
+		},
+		{
+			name:           "転送に失敗した場合の502を日本語で返す",
+			path:           "/api/v1/users",
+			acceptLanguage: "ja-JP,en;q=0.8",
+			wantStatus:     http.StatusBadGateway,
+			wantCode:       "TRANSPORT_ERROR",
+			wantLanguage:   "ja",
+			wantMessage:    "バックエンドへの転送に失敗しました",
+		},
+		{
+			name:         "Accept-Languageが無い場合は英語",
+			path:         "/api/v1/unknown",
+			wantStatus:   http.StatusNotFound,
+			wantCode:     "NOT_FOUND",
+			wantLanguage: "en",
+			wantMessage:  "no route found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if cl := w.Header().Get("Content-Language"); cl != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", cl, tt.wantLanguage)
+			}
+			if vary := w.Header().Values("Vary"); !slices.Contains(vary, "Accept-Language") {
+				t.Errorf("Vary = %v, want Accept-Language", vary)
+			}
+			var resp errors.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Error.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Error.Code, tt.wantCode)
+			}
+			if !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want to contain %q", resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Canary(t *testing.T) {
+	canary, err := routing.NewCanary(config.CanaryConfig{URL: "http://users-canary.example.com", Weight: 100, StickyClaim: "sub"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://users.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/users",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL},
+		Canary:  canary,
+	})
+
+	var transportedTo string
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			transportedTo = backend.URL.String()
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name        string
+		weight      float64
+		wantBackend string
+	}{
+		{name: "100%はカナリアへ転送する", weight: 100, wantBackend: "http://users-canary.example.com"},
+		{name: "0%は安定版へ転送する", weight: 0, wantBackend: "http://users.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := canary.SetWeight(tt.weight); err != nil {
+				t.Fatalf("SetWeight() error = %v", err)
+			}
+			transportedTo = ""
+			w := httptest.NewRecorder()
+
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if transportedTo != tt.wantBackend {
+				t.Errorf("backend = %q, want %q", transportedTo, tt.wantBackend)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Panic(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport func(w http.ResponseWriter)
+		wantAbort bool // レスポンスを書き始めた後は接続を切る
+	}{
+		{
+			name:      "転送中のパニックは500のProblem Details",
+			transport: func(w http.ResponseWriter) { panic("unexpected") },
+		},
+		{
+			name: "レスポンスを書き始めた後のパニックは接続を切る",
+			transport: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusOK)
+				panic("unexpected")
+			},
+			wantAbort: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := routing.NewRouter()
+			backendURL, _ := url.Parse("http://backend.internal")
+			router.AddRoute(&routing.Route{Path: "/api/v1/users", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: backendURL}})
+			transporter := &mockTransporter{
+				transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+					tt.transport(w)
+					return nil
+				},
+			}
+			var buf bytes.Buffer
+			gateway := NewGateway(router, transporter, nil, slog.New(slog.NewJSONHandler(&buf, nil)))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			req.Header.Set(logger.HeaderRequestID, "req-123")
+			w := httptest.NewRecorder()
+
+			var aborted any
+			func() {
+				defer func() { aborted = recover() }()
+				gateway.ServeHTTP(w, req)
+			}()
+
+			if !strings.Contains(buf.String(), `"msg":"panic recovered"`) || !strings.Contains(buf.String(), `"request_id":"req-123"`) {
+				t.Errorf("expected panic to be logged with request_id, got: %s", buf.String())
+			}
+			if tt.wantAbort {
+				if aborted != http.ErrAbortHandler {
+					t.Errorf("recovered %v, want http.ErrAbortHandler", aborted)
+				}
+				return
+			}
+			if aborted != nil {
+				t.Fatalf("panic escaped the gateway: %v", aborted)
+			}
+
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != errors.ContentTypeProblemJSON {
+				t.Errorf("Content-Type = %q, want %q", ct, errors.ContentTypeProblemJSON)
+			}
+			var problem errors.ProblemDetails
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			// パニックの値はクライアントに返さない
+			if problem.Status != http.StatusInternalServerError || strings.Contains(problem.Detail, "unexpected") {
+				t.Errorf("unexpected problem details: %+v", problem)
+			}
+			if got := w.Header().Get(logger.HeaderRequestID); got != "req-123" {
+				t.Errorf("%s = %q, want req-123", logger.HeaderRequestID, got)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Timeout(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer backendServer.Close()
+
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse(backendServer.URL)
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/slow",
+		Methods: []string{http.MethodGet},
+		// バックエンドの timeout より短いルート全体の期限が優先される
+		Backend: &routing.Backend{URL: backendURL, Timeout: 10 * time.Second},
+		Middleware: []config.MiddlewareConfig{
+			{Type: "timeout", Config: map[string]any{"timeout": "50ms"}},
+		},
+	})
+	factory := middleware.NewFactory(middleware.FactoryConfig{})
+	gateway := NewGateway(router, transport.NewHTTPTransporter(), factory, slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/slow", nil)
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != errors.ContentTypeProblemJSON {
+		t.Errorf("Content-Type = %q, want %q", ct, errors.ContentTypeProblemJSON)
+	}
+	var problem errors.ProblemDetails
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if problem.Status != http.StatusGatewayTimeout || problem.Code != "GATEWAY_TIMEOUT" {
+		t.Errorf("unexpected problem details: %+v", problem)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Error("upstream request was not canceled")
+	}
+}
+
+func TestGateway_ServeHTTP_BasicAuth(t *testing.T) {
+	var gotAuthorization string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuthorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse(backendServer.URL)
+	router.AddRoute(&routing.Route{
+		Path:    "/internal/metrics",
+		Methods: []string{http.MethodGet},
+		Backend: &routing.Backend{URL: backendURL, Timeout: 5 * time.Second},
+		Middleware: []config.MiddlewareConfig{
+			{Type: "basic_auth", Config: map[string]any{"users": map[string]any{"prometheus": string(hash)}}},
+		},
+	})
+	gateway := NewGateway(router, transport.NewHTTPTransporter(), middleware.NewFactory(middleware.FactoryConfig{}), slog.New(slog.DiscardHandler))
+
+	// 認証情報が無い場合は WWW-Authenticate を付けて401を返す
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal/metrics", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != `Basic realm="Restricted", charset="UTF-8"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/internal/metrics", nil)
+	req.SetBasicAuth("prometheus", "s3cret")
+	w = httptest.NewRecorder()
+	gateway.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if gotAuthorization != "" {
+		t.Errorf("backend received Authorization = %q, want none", gotAuthorization)
+	}
+}
+
+func TestGateway_ServeHTTP_StaticResponse(t *testing.T) {
+	route, err := routing.NewRoute(config.Route{
+		Path:    "/robots.txt",
+		Methods: []string{http.MethodGet, http.MethodHead},
+		Response: &config.StaticResponseConfig{
+			Body: "User-agent: *\nDisallow: /\n",
+		},
+		ResponseHeaders: config.HeaderRulesConfig{Set: map[string]string{"Cache-Control": "max-age=86400"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRoute() error = %v", err)
+	}
+	router := routing.NewRouter()
+	router.AddRoute(route)
+
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			t.Error("static response route should not be forwarded to a backend")
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Body.String(); got != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("body = %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	// レスポンスヘッダーの変換は通常のルートと同じく適用する
+	if got := w.Header().Get("Cache-Control"); got != "max-age=86400" {
+		t.Errorf("Cache-Control = %q, want max-age=86400", got)
+	}
+}
+
+func TestGateway_ServeHTTP_Aggregate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	route, err := routing.NewRoute(config.Route{
+		Path:    "/bff/users/:id",
+		Methods: []string{http.MethodGet},
+		Aggregate: &config.AggregateConfig{Backends: []config.AggregateBackendConfig{
+			{Key: "user", URL: server.URL + "/users/{id}", Required: true},
+			{Key: "orders", URL: server.URL + "/users/{id}/orders"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewRoute() error = %v", err)
+	}
+	router := routing.NewRouter()
+	router.AddRoute(route)
+	gateway := NewGateway(router, transport.NewHTTPTransporter(), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bff/users/42", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	want := `{"orders":{"path":"/users/42/orders"},"user":{"path":"/users/42"}}`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestGateway_ServeHTTP_RequestValidation(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.URL.Path+" "+string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// ドキュメントのパスはバックエンドの url のパス（/api）を含む
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(spec, []byte(`
+openapi: 3.0.3
+info: {title: orders, version: "1"}
+paths:
+  /api/orders/{id}:
+    put:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [quantity]
+              properties:
+                quantity: {type: integer, minimum: 1}
+      responses:
+        204: {description: updated}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		target       string
+		body         string
+		wantStatus   int
+		wantErrors   []string
+		wantReceived bool
+	}{
+		{name: "契約どおりのリクエストは転送する", mode: "enforce", target: "/orders/42", body: `{"quantity":2}`, wantStatus: http.StatusNoContent, wantReceived: true},
+		{name: "enforceは違反した項目を400で返す", mode: "enforce", target: "/orders/abc", body: `{"quantity":0}`, wantStatus: http.StatusBadRequest, wantErrors: []string{"body.quantity", "path.id"}},
+		{name: "auditは違反したリクエストも転送する", mode: "audit", target: "/orders/abc", body: `{"quantity":0}`, wantStatus: http.StatusNoContent, wantReceived: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			route, err := routing.NewRoute(config.Route{
+				Path:     "/orders/:id",
+				Backend:  config.BackendConfig{URL: server.URL + "/api"},
+				Contract: &config.ContractConfig{Spec: spec, Requests: tt.mode},
+			})
+			if err != nil {
+				t.Fatalf("NewRoute() error = %v", err)
+			}
+			router := routing.NewRouter()
+			router.AddRoute(route)
+			gateway := NewGateway(router, transport.NewHTTPTransporter(), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			req := httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := len(received) == 1; got != tt.wantReceived {
+				t.Fatalf("received = %v, want forwarded %v", received, tt.wantReceived)
+			}
+			if tt.wantReceived && received[0] != "/api"+tt.target+" "+tt.body {
+				t.Errorf("backend received %q", received[0])
+			}
+			if tt.wantErrors == nil {
+				return
+			}
+
+			if got := w.Header().Get("Content-Type"); got != errors.ContentTypeProblemJSON {
+				t.Errorf("Content-Type = %q", got)
+			}
+			var problem struct {
+				Code    string `json:"code"`
+				Details struct {
+					Errors []struct {
+						Field   string `json:"field"`
+						Message string `json:"message"`
+					} `json:"errors"`
+				} `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != "REQUEST_VALIDATION_FAILED" {
+				t.Errorf("code = %q", problem.Code)
+			}
+			var fields []string
+			for _, e := range problem.Details.Errors {
+				fields = append(fields, e.Field)
+			}
+			slices.Sort(fields)
+			if !slices.Equal(fields, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", problem.Details.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_FeatureFlag(t *testing.T) {
+	newBackend := func(name string) *url.URL {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+		t.Cleanup(server.Close)
+		u, _ := url.Parse(server.URL)
+		return u
+	}
+	checkoutURL, legacyURL, canaryURL := newBackend("checkout"), newBackend("legacy"), newBackend("canary")
+	canary, err := routing.NewCanary(config.CanaryConfig{URL: canaryURL.String(), Weight: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	residency, err := routing.NewResidency(config.ResidencyConfig{Region: "eu", Backends: map[string]string{"eu": newBackend("eu").String()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		flags      map[string]bool
+		gate       *routing.FlagGate
+		canary     *routing.Canary
+		residency  *routing.Residency
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "フラグが有効",
+			flags:      map[string]bool{"new_checkout": true},
+			gate:       &routing.FlagGate{Flag: "new_checkout", DisabledStatus: http.StatusNotFound},
+			wantStatus: http.StatusOK,
+			wantBody:   "checkout",
+		},
+		{
+			name:       "無効な場合は404",
+			gate:       &routing.FlagGate{Flag: "new_checkout", DisabledStatus: http.StatusNotFound},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "無効な場合は503",
+			flags:      map[string]bool{"new_checkout": false},
+			gate:       &routing.FlagGate{Flag: "new_checkout", DisabledStatus: http.StatusServiceUnavailable},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "無効な場合は代わりのバックエンドへ転送する",
+			gate:       &routing.FlagGate{Flag: "new_checkout", Fallback: &routing.Backend{URL: legacyURL, Timeout: 5 * time.Second}},
+			wantStatus: http.StatusOK,
+			wantBody:   "legacy",
+		},
+		{
+			name:       "代わりのバックエンドへ転送する場合はカナリアへ振り分けない",
+			gate:       &routing.FlagGate{Flag: "new_checkout", Fallback: &routing.Backend{URL: legacyURL, Timeout: 5 * time.Second}},
+			canary:     canary,
+			wantStatus: http.StatusOK,
+			wantBody:   "legacy",
+		},
+		{
+			name:       "フラグが有効な場合はカナリアへ振り分ける",
+			flags:      map[string]bool{"new_checkout": true},
+			gate:       &routing.FlagGate{Flag: "new_checkout", Fallback: &routing.Backend{URL: legacyURL, Timeout: 5 * time.Second}},
+			canary:     canary,
+			wantStatus: http.StatusOK,
+			wantBody:   "canary",
+		},
+		{
+			name:       "代わりのバックエンドへ転送する場合はリージョンのバックエンドに置き換えない",
+			gate:       &routing.FlagGate{Flag: "new_checkout", Fallback: &routing.Backend{URL: legacyURL, Timeout: 5 * time.Second}},
+			residency:  residency,
+			wantStatus: http.StatusOK,
+			wantBody:   "legacy",
+		},
+		{
+			name:       "フラグが有効な場合はリージョンのバックエンドへ転送する",
+			flags:      map[string]bool{"new_checkout": true},
+			gate:       &routing.FlagGate{Flag: "new_checkout", Fallback: &routing.Backend{URL: legacyURL, Timeout: 5 * time.Second}},
+			residency:  residency,
+			wantStatus: http.StatusOK,
+			wantBody:   "eu",
+		},
+		{
+			name:       "否定の条件",
+			flags:      map[string]bool{"maintenance": false},
+			gate:       &routing.FlagGate{Flag: "maintenance", Negate: true, DisabledStatus: http.StatusServiceUnavailable},
+			wantStatus: http.StatusOK,
+			wantBody:   "checkout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := routing.NewRouter()
+			router.AddRoute(&routing.Route{
+				Path:      "/api/v2/checkout",
+				Methods:   []string{http.MethodPost},
+				Backend:   &routing.Backend{URL: checkoutURL, Timeout: 5 * time.Second},
+				FlagGate:  tt.gate,
+				Canary:    tt.canary,
+				Residency: tt.residency,
+			})
+			flags, err := featureflag.New(featureflag.Config{Flags: tt.flags})
+			if err != nil {
+				t.Fatalf("featureflag.New() error = %v", err)
+			}
+			gateway := NewGateway(router, transport.NewHTTPTransporter(), nil, slog.New(slog.DiscardHandler))
+			gateway.SetFeatureFlags(flags)
+
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v2/checkout", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_Journal(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://orders.example.com")
+	router.AddRoute(&routing.Route{Path: "/api/v1/orders", Methods: []string{http.MethodPost}, Backend: &routing.Backend{URL: backendURL}, Journal: true})
+	router.AddRoute(&routing.Route{Path: "/api/v1/items", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: backendURL}})
+
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+			return nil
+		},
+	}
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	sink, err := journal.OpenFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j, err := journal.Open(context.Background(), sink)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer j.Close()
+
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+	gateway.SetJournal(j)
+
+	for _, target := range []string{"/api/v1/orders", "/api/v1/items"} {
+		method := http.MethodGet
+		if target == "/api/v1/orders" {
+			method = http.MethodPost
+		}
+		gateway.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader(`{"secret":"body"}`)))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	// journal: true のルートのみ、ボディを含まないメタデータを記録する
+	if count, err := journal.Verify(bytes.NewReader(data)); err != nil || count != 1 {
+		t.Fatalf("Verify() = %d, %v, want 1 entry", count, err)
+	}
+	var entry journal.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("invalid journal entry: %v", err)
+	}
+	if entry.Route != "/api/v1/orders" || entry.Method != http.MethodPost || entry.Status != http.StatusCreated || entry.ResponseBytes != 10 {
+		t.Errorf("entry = %+v", entry)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Error("journal should not contain the request body")
+	}
+}
+
+func TestGateway_ServeHTTP_Journal_UserID(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://orders.example.com")
+	router.AddRoute(&routing.Route{
+		Path:    "/api/v1/orders",
+		Methods: []string{http.MethodPost},
+		Backend: &routing.Backend{URL: backendURL},
+		Middleware: []config.MiddlewareConfig{
+			{Type: "basic_auth", Config: map[string]any{"users": map[string]any{"alice": string(hash)}}},
+		},
+		Journal: true,
+	})
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			w.WriteHeader(http.StatusCreated)
+			return nil
+		},
+	}
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	sink, err := journal.OpenFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j, err := journal.Open(context.Background(), sink)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer j.Close()
+
+	gateway := NewGateway(router, transporter, middleware.NewFactory(middleware.FactoryConfig{}), slog.New(slog.DiscardHandler))
+	gateway.SetJournal(j)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	req.SetBasicAuth("alice", "s3cret")
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	// 認証ミドルウェアが確定したユーザーを記録する
+	var entry journal.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("invalid journal entry: %v", err)
+	}
+	if entry.UserID != "alice" {
+		t.Errorf("UserID = %q, want alice", entry.UserID)
+	}
+}
+
+func TestGateway_ServeHTTP_ConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		routeLimit int
+		gateLimit  int
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "ルートの上限に達している場合は429", routeLimit: 1, wantStatus: http.StatusTooManyRequests, wantCode: "ROUTE_CONCURRENCY_LIMITED"},
+		{name: "ゲートウェイ全体の上限に達している場合は503", gateLimit: 1, wantStatus: http.StatusServiceUnavailable, wantCode: "GATEWAY_OVERLOADED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backendURL, _ := url.Parse("http://report-service:8080")
+			router := routing.NewRouter()
+			route := &routing.Route{
+				Path:    "/api/v1/reports",
+				Methods: []string{http.MethodGet},
+				Backend: &routing.Backend{URL: backendURL},
+			}
+			if tt.routeLimit > 0 {
+				route.Concurrency = routing.NewConcurrencyLimiter(tt.routeLimit, routing.ConcurrencyQueue{})
+			}
+			router.AddRoute(route)
+
+			started := make(chan struct{})
+			unblock := make(chan struct{})
+			transporter := &mockTransporter{
+				transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+					if req.URL.Query().Get("slow") == "true" {
+						close(started)
+						<-unblock
+					}
+					w.WriteHeader(http.StatusOK)
+					return nil
+				},
+			}
+			gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+			if tt.gateLimit > 0 {
+				gateway.SetConcurrencyLimiter(routing.NewConcurrencyLimiter(tt.gateLimit, routing.ConcurrencyQueue{}))
+			}
+
+			// 遅いバックエンドへのリクエストで上限まで埋める
+			done := make(chan int)
+			go func() {
+				w := httptest.NewRecorder()
+				gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports?slow=true", nil))
+				done <- w.Code
+			}()
+			<-started
+
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != "1" {
+				t.Errorf("Retry-After = %q, want %q", got, "1")
+			}
+			if !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("body = %s, want error code %s", w.Body.String(), tt.wantCode)
+			}
+
+			// 処理中のリクエストが終わると枠が解放される
+			close(unblock)
+			if code := <-done; code != http.StatusOK {
+				t.Errorf("slow request status = %d, want %d", code, http.StatusOK)
+			}
+			w = httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status after release = %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_ConcurrencyQueue(t *testing.T) {
+	backendURL, _ := url.Parse("http://report-service:8080")
+	newGateway := func(queue routing.ConcurrencyQueue) (*Gateway, *routing.Route, chan struct{}, chan struct{}) {
+		route := &routing.Route{
+			Path:        "/api/v1/reports",
+			Methods:     []string{http.MethodGet},
+			Backend:     &routing.Backend{URL: backendURL},
+			Concurrency: routing.NewConcurrencyLimiter(1, queue),
+		}
+		router := routing.NewRouter()
+		router.AddRoute(route)
+
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		transporter := &mockTransporter{
+			transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+				if req.URL.Query().Get("slow") == "true" {
+					close(started)
+					<-unblock
+				}
+				w.WriteHeader(http.StatusOK)
+				return nil
+			},
+		}
+		return NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler)), route, started, unblock
+	}
+	serve := func(gateway *Gateway, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("待ち行列で待ち、枠が空くと転送する", func(t *testing.T) {
+		gateway, route, started, unblock := newGateway(routing.ConcurrencyQueue{Route: "/api/v1/reports", Depth: 1, Timeout: 5 * time.Second})
+
+		slow := make(chan int)
+		go func() { slow <- serve(gateway, "/api/v1/reports?slow=true").Code }()
+		<-started
+
+		queued := make(chan int)
+		go func() { queued <- serve(gateway, "/api/v1/reports").Code }()
+		for route.Concurrency.QueueDepth() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		// 待ち行列が一杯の場合は429
+		w := serve(gateway, "/api/v1/reports")
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+			t.Errorf("status = %d, Retry-After = %q, want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+		}
+
+		close(unblock)
+		if code := <-slow; code != http.StatusOK {
+			t.Errorf("slow request status = %d, want %d", code, http.StatusOK)
+		}
+		if code := <-queued; code != http.StatusOK {
+			t.Errorf("queued request status = %d, want %d", code, http.StatusOK)
+		}
+		if depth := route.Concurrency.QueueDepth(); depth != 0 {
+			t.Errorf("QueueDepth() = %d, want 0", depth)
+		}
+	})
+
+	t.Run("待つ時間の上限を過ぎると503", func(t *testing.T) {
+		gateway, _, started, unblock := newGateway(routing.ConcurrencyQueue{Route: "/api/v1/reports", Depth: 1, Timeout: 10 * time.Millisecond})
+		defer close(unblock)
+
+		go serve(gateway, "/api/v1/reports?slow=true")
+		<-started
+
+		w := serve(gateway, "/api/v1/reports")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+		if got := w.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("Retry-After = %q, want %q", got, "1")
+		}
+		if !strings.Contains(w.Body.String(), "ROUTE_QUEUE_TIMEOUT") {
+			t.Errorf("body = %s, want error code ROUTE_QUEUE_TIMEOUT", w.Body.String())
+		}
+	})
+}
+
+func TestGateway_ServeHTTP_OutlierEjection(t *testing.T) {
+	canary, err := routing.NewCanary(config.CanaryConfig{URL: "http://orders-canary.example.com", Weight: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	usersURL, _ := url.Parse("http://users.example.com")
+	ordersURL, _ := url.Parse("http://orders.example.com")
+	router := routing.NewRouter()
+	router.AddRoute(&routing.Route{Path: "/api/v1/users", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: usersURL}})
+	router.AddRoute(&routing.Route{Path: "/api/v1/orders", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: ordersURL}, Canary: canary})
+
+	// users とカナリアのバックエンドを除外する
+	detector := healthcheck.NewOutlierDetector(healthcheck.OutlierConfig{MinRequests: 1, Logger: slog.New(slog.DiscardHandler)})
+	detector.Record(context.Background(), usersURL, healthcheck.OutcomeError)
+	detector.Record(context.Background(), canary.URL, healthcheck.OutcomeTimeout)
+
+	var transportedTo string
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			transportedTo = backend.URL.String()
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+	gateway.SetOutlierDetector(detector)
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantBackend string
+	}{
+		{name: "除外されたバックエンドへは転送せず503", path: "/api/v1/users", wantStatus: http.StatusServiceUnavailable},
+		{name: "除外されたカナリアの代わりに安定版へ転送する", path: "/api/v1/orders", wantStatus: http.StatusOK, wantBackend: "http://orders.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transportedTo = ""
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if transportedTo != tt.wantBackend {
+				t.Errorf("backend = %q, want %q", transportedTo, tt.wantBackend)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_ServiceDiscovery(t *testing.T) {
+	usersURL, _ := url.Parse("http://users/api")
+	ordersURL, _ := url.Parse("http://orders/api")
+	router := routing.NewRouter()
+	router.AddRoute(&routing.Route{Path: "/api/v1/users", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: usersURL, Service: "users"}})
+	router.AddRoute(&routing.Route{Path: "/api/v1/orders", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: ordersURL, Service: "orders"}})
+
+	catalog := discovery.New(discovery.Config{
+		Source: func(ctx context.Context, service string, index uint64) ([]string, uint64, error) {
+			if index > 0 {
+				<-ctx.Done()
+				return nil, index, ctx.Err()
+			}
+			if service == "orders" {
+				return []string{"10.0.0.3:8080"}, 1, nil
+			}
+			return []string{"10.0.0.1:8080", "10.0.0.2:8080"}, 1, nil
+		},
+		Logger: slog.New(slog.DiscardHandler),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	catalog.Start(ctx, "users", "orders")
+
+	// orders の唯一のインスタンスを除外する
+	detector := healthcheck.NewOutlierDetector(healthcheck.OutlierConfig{MinRequests: 1, Logger: slog.New(slog.DiscardHandler)})
+	detector.Record(context.Background(), &url.URL{Scheme: "http", Host: "10.0.0.3:8080"}, healthcheck.OutcomeError)
+
+	var transportedTo string
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			transportedTo = backend.URL.String()
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, nil, slog.New(slog.DiscardHandler))
+	gateway.SetOutlierDetector(detector)
+	gateway.SetServiceDiscovery(catalog)
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantBackend string
+	}{
+		{name: "インスタンスへ順に転送する", path: "/api/v1/users", wantStatus: http.StatusOK, wantBackend: "http://10.0.0.1:8080/api"},
+		{name: "次のインスタンス", path: "/api/v1/users", wantStatus: http.StatusOK, wantBackend: "http://10.0.0.2:8080/api"},
+		{name: "転送できるインスタンスが無い場合は503", path: "/api/v1/orders", wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transportedTo = ""
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if transportedTo != tt.wantBackend {
+				t.Errorf("backend = %q, want %q", transportedTo, tt.wantBackend)
+			}
+		})
+	}
+}
+
+func TestGateway_ServeHTTP_HMACReplay(t *testing.T) {
+	router := routing.NewRouter()
+	router.AddRoute(&routing.Route{
+		Path:    "/webhooks",
+		Methods: []string{http.MethodPost},
+		Backend: &routing.Backend{URL: &url.URL{Scheme: "http", Host: "backend"}},
+		Middleware: []config.MiddlewareConfig{
+			{Type: "hmac", Config: map[string]any{"secrets": map[string]any{"provider": "secret"}}},
+		},
+	})
+	transporter := &mockTransporter{
+		transportFunc: func(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+			w.WriteHeader(http.StatusOK)
+			return nil
+		},
+	}
+	gateway := NewGateway(router, transporter, middleware.NewFactory(middleware.FactoryConfig{}), slog.New(slog.DiscardHandler))
+
+	const body = `{"event":"paid"}`
+	ts := time.Now().Unix()
+	mac := hmac.New(sha256.New, []byte("secret"))
+	fmt.Fprintf(mac, "POST\n/webhooks\n%d\n%s", ts, body)
+	signature := fmt.Sprintf("key=provider,ts=%d,sig=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+
+	// ミドルウェアはリクエストごとに作成されるが、使用済みの署名はファクトリーで共有して検出する
+	for i, wantStatus := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		req.Header.Set("X-Signature", signature)
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, req)
+
+		if w.Code != wantStatus {
+			t.Errorf("request %d: expected status %d, got %d (body: %s)", i+1, wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
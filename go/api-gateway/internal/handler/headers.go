@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"api-gateway/internal/routing"
+)
+
+// headerRewriter はレスポンスヘッダーの送信直前にルートのヘッダー変換ルールを適用するResponseWriter
+// バックエンドのレスポンスだけでなく、キャッシュヒットやゲートウェイのエラーレスポンスにも適用される
+type headerRewriter struct {
+	http.ResponseWriter
+	rules       *routing.HeaderRules
+	req         *http.Request
+	wroteHeader bool
+}
+
+func newHeaderRewriter(w http.ResponseWriter, rules *routing.HeaderRules, req *http.Request) *headerRewriter {
+	return &headerRewriter{ResponseWriter: w, rules: rules, req: req}
+}
+
+// WriteHeader はルールを適用してからステータスコードを書き込む
+func (hw *headerRewriter) WriteHeader(statusCode int) {
+	// 1xxの中間レスポンスは最終レスポンスではないため変換しない
+	if !hw.wroteHeader && statusCode >= http.StatusOK {
+		hw.wroteHeader = true
+		hw.rules.Apply(hw.ResponseWriter.Header(), hw.req)
+	}
+	hw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write はヘッダー未送信の場合にルールを適用してからボディを書き込む
+func (hw *headerRewriter) Write(b []byte) (int, error) {
+	if !hw.wroteHeader {
+		hw.WriteHeader(http.StatusOK)
+	}
+	return hw.ResponseWriter.Write(b)
+}
+
+// Unwrap はhttp.ResponseControllerがFlushなどを元のResponseWriterへ委譲するために使う
+func (hw *headerRewriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
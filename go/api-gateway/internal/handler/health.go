@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+
+	defaultHealthCheckTimeout = 2 * time.Second
+)
+
+// HealthCheck はreadinessで確認する依存先
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthResponse はヘルスチェックのレスポンス
+type HealthResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyHealth `json:"checks,omitempty"`
+}
+
+// DependencyHealth は依存先ごとの確認結果
+type DependencyHealth struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// LivenessHandler はプロセスが応答できることだけを返す（/healthz）
+// 依存先の障害でコンテナが再起動されないよう、依存先は確認しない
+type LivenessHandler struct{}
+
+// NewLivenessHandler は新しいLivenessHandlerを作成する
+func NewLivenessHandler() *LivenessHandler {
+	return &LivenessHandler{}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *LivenessHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	writeHealthResponse(w, http.StatusOK, HealthResponse{Status: healthStatusOK})
+}
+
+// ReadinessHandler は依存先を確認し、リクエストを受け付けられるかを返す（/readyz）
+type ReadinessHandler struct {
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu     sync.RWMutex
+	checks []HealthCheck
+}
+
+// NewReadinessHandler は新しいReadinessHandlerを作成する
+// timeout は各確認のタイムアウト（0は2秒）
+func NewReadinessHandler(checks []HealthCheck, timeout time.Duration, logger *slog.Logger) *ReadinessHandler {
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &ReadinessHandler{
+		checks:  checks,
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// SetChecks は確認する依存先を置き換える（ルートを読み込み直してバックエンドが変わった場合に使う）
+func (h *ReadinessHandler) SetChecks(checks []HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = checks
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(req.Context(), h.timeout)
+	defer cancel()
+
+	resp := HealthResponse{Status: healthStatusOK, Checks: make(map[string]DependencyHealth, len(checks))}
+
+	// 依存先は並行して確認し、遅い依存先があっても全体がタイムアウトを超えないようにする
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			result := DependencyHealth{Status: healthStatusOK, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = healthStatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[check.Name] = result
+			if err != nil {
+				resp.Status = healthStatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	statusCode := http.StatusOK
+	if resp.Status != healthStatusOK {
+		statusCode = http.StatusServiceUnavailable
+		h.logger.WarnContext(req.Context(), "readiness check failed", slog.Any("checks", resp.Checks))
+	}
+	writeHealthResponse(w, statusCode, resp)
+}
+
+// BackendHealthChecks はルートのバックエンドへTCP接続できるかを確認するHealthCheckを返す
+// 同じホストを共有するルートは1つにまとめ、サービスディスカバリのルート（インスタンスが変わる）は確認しない
+func BackendHealthChecks(routes []*routing.Route) []HealthCheck {
+	hosts := make(map[string]struct{})
+	for _, route := range routes {
+		if route.Backend == nil || route.Backend.URL == nil || route.Backend.URL.Host == "" || route.Backend.Service != "" {
+			continue
+		}
+		hosts[backendAddress(route.Backend)] = struct{}{}
+	}
+
+	addresses := make([]string, 0, len(hosts))
+	for address := range hosts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	checks := make([]HealthCheck, 0, len(addresses))
+	for _, address := range addresses {
+		checks = append(checks, HealthCheck{
+			Name: "backend:" + address,
+			Check: func(ctx context.Context) error {
+				var dialer net.Dialer
+				conn, err := dialer.DialContext(ctx, "tcp", address)
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			},
+		})
+	}
+	return checks
+}
+
+// backendAddress はバックエンドURLの host:port を返す（ポートが無い場合はスキームから補う）
+func backendAddress(backend *routing.Backend) string {
+	if backend.URL.Port() != "" {
+		return backend.URL.Host
+	}
+	port := "80"
+	if backend.URL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(backend.URL.Hostname(), port)
+}
+
+// writeHealthResponse はヘルスチェックの結果をJSONで書き込む
+func writeHealthResponse(w http.ResponseWriter, statusCode int, resp HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
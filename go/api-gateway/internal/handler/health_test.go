@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"api-gateway/internal/routing"
+)
+
+func TestLivenessHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		wantStatus int
+	}{
+		{name: "GETでokを返す", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "HEADも許可する", method: http.MethodHead, wantStatus: http.StatusOK},
+		{name: "GET以外は405", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewLivenessHandler().ServeHTTP(rec, httptest.NewRequest(tt.method, "/healthz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReadinessHandler_ServeHTTP(t *testing.T) {
+	ok := HealthCheck{Name: "routing", Check: func(ctx context.Context) error { return nil }}
+	failing := HealthCheck{Name: "redis", Check: func(ctx context.Context) error { return fmt.Errorf("connection refused") }}
+	slow := HealthCheck{Name: "backend:slow:80", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		wantStatus int
+		want       map[string]string
+		wantError  string
+	}{
+		{
+			name:       "全ての依存先が正常",
+			checks:     []HealthCheck{ok},
+			wantStatus: http.StatusOK,
+			want:       map[string]string{"routing": "ok"},
+		},
+		{
+			name:       "失敗した依存先があれば503",
+			checks:     []HealthCheck{ok, failing},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       map[string]string{"routing": "ok", "redis": "fail"},
+			wantError:  "connection refused",
+		},
+		{
+			name:       "タイムアウトした依存先は失敗",
+			checks:     []HealthCheck{ok, slow},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       map[string]string{"routing": "ok", "backend:slow:80": "fail"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewReadinessHandler(tt.checks, 50*time.Millisecond, nil)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+
+			var resp HealthResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if len(resp.Checks) != len(tt.want) {
+				t.Errorf("checks = %v, want %v", resp.Checks, tt.want)
+			}
+			for name, status := range tt.want {
+				if resp.Checks[name].Status != status {
+					t.Errorf("checks[%s].status = %v, want %v", name, resp.Checks[name].Status, status)
+				}
+			}
+			if tt.wantError != "" && resp.Checks["redis"].Error != tt.wantError {
+				t.Errorf("checks[redis].error = %v, want %v", resp.Checks["redis"].Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestReadinessHandler_SetChecks(t *testing.T) {
+	ok := HealthCheck{Name: "routing", Check: func(ctx context.Context) error { return nil }}
+	failing := HealthCheck{Name: "backend:orders:80", Check: func(ctx context.Context) error { return fmt.Errorf("connection refused") }}
+
+	// ルートを読み込み直して追加されたバックエンドも確認する
+	h := NewReadinessHandler([]HealthCheck{ok}, time.Second, nil)
+	h.SetChecks([]HealthCheck{ok, failing})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status code = %v, want %v", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestBackendHealthChecks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// 閉じたポートは接続できない
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	newRoute := func(rawURL string) *routing.Route {
+		u, _ := url.Parse(rawURL)
+		return &routing.Route{Backend: &routing.Backend{URL: u}}
+	}
+	routes := []*routing.Route{
+		newRoute("http://" + listener.Addr().String() + "/users"),
+		newRoute("http://" + listener.Addr().String() + "/orders"),
+		newRoute("http://" + closedAddr),
+	}
+
+	checks := BackendHealthChecks(routes)
+	if len(checks) != 2 {
+		t.Fatalf("got %d checks, want 2 (same host is merged)", len(checks))
+	}
+
+	results := make(map[string]error)
+	for _, check := range checks {
+		results[check.Name] = check.Check(context.Background())
+	}
+	if err := results["backend:"+listener.Addr().String()]; err != nil {
+		t.Errorf("reachable backend error = %v", err)
+	}
+	if err := results["backend:"+closedAddr]; err == nil {
+		t.Error("unreachable backend error = nil, want error")
+	}
+}
+
+func TestBackendAddress(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{rawURL: "http://users:8080", want: "users:8080"},
+		{rawURL: "http://users", want: "users:80"},
+		{rawURL: "https://users", want: "users:443"},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.rawURL)
+		if got := backendAddress(&routing.Backend{URL: u}); got != tt.want {
+			t.Errorf("backendAddress(%s) = %v, want %v", tt.rawURL, got, tt.want)
+		}
+	}
+}
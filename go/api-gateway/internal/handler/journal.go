@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"api-gateway/internal/journal"
+	"api-gateway/pkg/logger"
+)
+
+// recordJournal はリクエストのメタデータをジャーナルへ記録する
+// 記録に失敗してもレスポンスは返し終えているため、エラーログを残す
+func (g *Gateway) recordJournal(ctx context.Context, statusCode int, bytesWritten int64, access *accessLog) {
+	entry := journal.Entry{
+		Time:          access.start.UTC(),
+		Route:         access.route,
+		Status:        statusCode,
+		ResponseBytes: bytesWritten,
+		DurationMS:    time.Since(access.start).Milliseconds(),
+		Backend:       access.backend,
+		UserID:        access.userID,
+	}
+	if correlation, ok := logger.CorrelationFromContext(ctx); ok {
+		entry.RequestID = correlation.RequestID
+	}
+	if info, ok := logger.HTTPRequestFromContext(ctx); ok {
+		entry.Method = info.Method
+		entry.Path = info.Path
+		entry.ClientIP = info.ClientIP
+	}
+
+	if err := g.journal.Record(entry); err != nil {
+		g.logger.ErrorContext(ctx, "failed to record journal entry", slog.String("error", err.Error()))
+	}
+}
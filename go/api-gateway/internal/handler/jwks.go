@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+)
+
+// JWKSPath はゲートウェイが発行するトークンの検証用公開鍵を公開するパス
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler は署名鍵（アクティブとパッシブ）の公開鍵をJWKSとして返す
+// バックエンドはこれを取得してゲートウェイが発行したトークンを検証する
+type JWKSHandler struct {
+	keys   *auth.SigningKeySet
+	logger *slog.Logger
+}
+
+// NewJWKSHandler は新しいJWKSHandlerを作成する
+func NewJWKSHandler(keys *auth.SigningKeySet, logger *slog.Logger) *JWKSHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &JWKSHandler{
+		keys:   keys,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *JWKSHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// GETメソッドのみ許可
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	body, err := auth.MarshalJWKS(h.keys.PublicKeys())
+	if err != nil {
+		h.logger.ErrorContext(req.Context(), "failed to encode jwks", "error", err)
+		writeJSONError(w, errors.NewInternalServerError("failed to encode jwks"))
+		return
+	}
+
+	// ローテーションが数分で行き渡るよう、キャッシュは短めにする
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"api-gateway/internal/middleware/auth"
+)
+
+// newTestSigningKeySet はテスト用の署名鍵セットを作成する
+func newTestSigningKeySet(t *testing.T, activeKID string, kids ...string) *auth.SigningKeySet {
+	t.Helper()
+
+	keys := make(map[string]*rsa.PrivateKey)
+	for _, kid := range kids {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		keys[kid] = key
+	}
+	set, err := auth.NewSigningKeySet(keys, activeKID)
+	if err != nil {
+		t.Fatalf("NewSigningKeySet() error = %v", err)
+	}
+	return set
+}
+
+func TestJWKSHandler_ServeHTTP(t *testing.T) {
+	h := NewJWKSHandler(newTestSigningKeySet(t, "gw-2", "gw-1", "gw-2"), nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		wantStatus int
+		wantKids   []string
+	}{
+		{name: "アクティブとパッシブの鍵を公開する", method: http.MethodGet, wantStatus: http.StatusOK, wantKids: []string{"gw-1", "gw-2"}},
+		{name: "GET以外は405", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, JWKSPath, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantKids == nil {
+				return
+			}
+			if rec.Header().Get("Cache-Control") == "" {
+				t.Error("Cache-Control header is not set")
+			}
+
+			var body struct {
+				Keys []struct {
+					Kid string `json:"kid"`
+					Kty string `json:"kty"`
+				} `json:"keys"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if len(body.Keys) != len(tt.wantKids) {
+				t.Fatalf("got %d keys, want %d", len(body.Keys), len(tt.wantKids))
+			}
+			for i, kid := range tt.wantKids {
+				if body.Keys[i].Kid != kid || body.Keys[i].Kty != "RSA" {
+					t.Errorf("keys[%d] = %+v, want RSA key %s", i, body.Keys[i], kid)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+)
+
+// KeyRotationHandler は署名に使うアクティブな鍵を切り替える管理API
+type KeyRotationHandler struct {
+	keys   *auth.SigningKeySet
+	logger *slog.Logger
+}
+
+// KeyRotationRequest は鍵ローテーションAPIのリクエストボディ
+// KID は新たにアクティブにする鍵（設定済みのパッシブな鍵）
+type KeyRotationRequest struct {
+	KID string `json:"kid"`
+}
+
+// KeyRotationResponse は鍵ローテーションAPIのレスポンス
+type KeyRotationResponse struct {
+	ActiveKID   string   `json:"active_kid"`
+	PreviousKID string   `json:"previous_kid"`
+	KIDs        []string `json:"kids"`
+}
+
+// NewKeyRotationHandler は新しいKeyRotationHandlerを作成する
+func NewKeyRotationHandler(keys *auth.SigningKeySet, logger *slog.Logger) *KeyRotationHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &KeyRotationHandler{
+		keys:   keys,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *KeyRotationHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// POSTメソッドのみ許可
+	if req.Method != http.MethodPost {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only POST method is allowed"))
+		return
+	}
+
+	var body KeyRotationRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		h.logger.Warn("failed to parse request body", "error", err)
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
+		return
+	}
+	if body.KID == "" {
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "kid is required"))
+		return
+	}
+
+	previous := h.keys.ActiveKID()
+	if err := h.keys.Rotate(body.KID); err != nil {
+		writeJSONError(w, errors.NewError(http.StatusNotFound, "NotFound", err.Error()))
+		return
+	}
+
+	h.logger.Info("signing key rotated by admin", "active_kid", body.KID, "previous_kid", previous)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(KeyRotationResponse{
+		ActiveKID:   body.KID,
+		PreviousKID: previous,
+		KIDs:        h.keys.KIDs(),
+	})
+}
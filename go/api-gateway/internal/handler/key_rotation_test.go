@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKeyRotationHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantActive string
+	}{
+		{name: "パッシブな鍵をアクティブにする", method: http.MethodPost, body: `{"kid":"gw-2"}`, wantStatus: http.StatusOK, wantActive: "gw-2"},
+		{name: "未知のkidは404", method: http.MethodPost, body: `{"kid":"gw-3"}`, wantStatus: http.StatusNotFound, wantActive: "gw-1"},
+		{name: "kidが空", method: http.MethodPost, body: `{}`, wantStatus: http.StatusBadRequest, wantActive: "gw-1"},
+		{name: "不正なボディ", method: http.MethodPost, body: `{`, wantStatus: http.StatusBadRequest, wantActive: "gw-1"},
+		{name: "POST以外は405", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed, wantActive: "gw-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := newTestSigningKeySet(t, "gw-1", "gw-1", "gw-2")
+			h := NewKeyRotationHandler(keys, nil)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/keys/rotate", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if keys.ActiveKID() != tt.wantActive {
+				t.Errorf("ActiveKID() = %v, want %v", keys.ActiveKID(), tt.wantActive)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp KeyRotationResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if resp.ActiveKID != "gw-2" || resp.PreviousKID != "gw-1" || len(resp.KIDs) != 2 {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
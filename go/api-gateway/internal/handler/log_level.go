@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/pkg/logger"
+)
+
+// LogLevelHandler は実行中のログレベルを参照・変更する管理API
+// GETで現在のレベルを返し、PUTで変更する（再起動すると設定ファイルのレベルに戻る）
+type LogLevelHandler struct {
+	logger *slog.Logger
+}
+
+// LogLevelRequest はログレベル変更APIのリクエスト/レスポンスボディ
+type LogLevelRequest struct {
+	Level logger.LogLevel `json:"level"`
+}
+
+// NewLogLevelHandler は新しいLogLevelHandlerを作成する
+func NewLogLevelHandler(log *slog.Logger) *LogLevelHandler {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	return &LogLevelHandler{
+		logger: log,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *LogLevelHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var body LogLevelRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			h.logger.Warn("failed to parse request body", "error", err)
+			writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
+			return
+		}
+
+		previous := logger.GetLevel()
+		if err := logger.SetLevel(body.Level); err != nil {
+			writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", err.Error()))
+			return
+		}
+
+		// 変更後のレベルに関わらず記録されるようWarnで出力する
+		h.logger.Warn("log level changed by admin", "from", previous, "to", body.Level)
+	default:
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET and PUT methods are allowed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LogLevelRequest{Level: logger.GetLevel()})
+}
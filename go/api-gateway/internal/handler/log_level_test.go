@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"api-gateway/pkg/logger"
+)
+
+func TestLogLevelHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantLevel  logger.LogLevel
+	}{
+		{
+			name:       "現在のレベルを取得",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantLevel:  logger.LevelInfo,
+		},
+		{
+			name:       "レベルを変更",
+			method:     http.MethodPut,
+			body:       `{"level": "debug"}`,
+			wantStatus: http.StatusOK,
+			wantLevel:  logger.LevelDebug,
+		},
+		{
+			name:       "不正なレベル",
+			method:     http.MethodPut,
+			body:       `{"level": "verbose"}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  logger.LevelInfo,
+		},
+		{
+			name:       "不正なJSON",
+			method:     http.MethodPut,
+			body:       `{invalid json}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  logger.LevelInfo,
+		},
+		{
+			name:       "許可されていないメソッド",
+			method:     http.MethodPost,
+			body:       `{"level": "debug"}`,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantLevel:  logger.LevelInfo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.SetLevel(logger.LevelInfo)
+			defer logger.SetLevel(logger.LevelInfo)
+
+			handler := NewLogLevelHandler(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			req := httptest.NewRequest(tt.method, "/admin/log-level", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := logger.GetLevel(); got != tt.wantLevel {
+				t.Errorf("GetLevel() = %s, want %s", got, tt.wantLevel)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp LogLevelRequest
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Level != tt.wantLevel {
+				t.Errorf("level = %s, want %s", resp.Level, tt.wantLevel)
+			}
+		})
+	}
+}
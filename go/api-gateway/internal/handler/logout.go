@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/errors"
 	"api-gateway/internal/repository"
+	"api-gateway/pkg/logger"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -19,6 +22,8 @@ type LogoutConfig struct {
 	UserIDClaim    string        // ユーザーIDのクレーム名（デフォルト: "sub")
 	JWTExpiration  time.Duration // JWTの有効期限（Redis TTL用、デフォルト: 10時間)
 	Logger         *slog.Logger
+	Audit          *audit.Logger    // ログアウトによる失効を記録する監査ロガー（任意）
+	Now            func() time.Time // 失効時刻に使う現在時刻を返す（nilの場合は time.Now）
 }
 
 // LogoutHandler はログアウト処理を行うハンドラ
@@ -27,6 +32,8 @@ type LogoutHandler struct {
 	userIDClaim   string
 	jwtExpiration time.Duration
 	logger        *slog.Logger
+	audit         *audit.Logger
+	now           func() time.Time
 }
 
 // NewLogoutHandler は新しいLogoutHandlerを作成する
@@ -41,17 +48,26 @@ func NewLogoutHandler(config LogoutConfig) *LogoutHandler {
 	if config.Logger == nil {
 		config.Logger = slog.Default()
 	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
 
 	return &LogoutHandler{
 		repository:    config.Repository,
 		userIDClaim:   config.UserIDClaim,
 		jwtExpiration: config.JWTExpiration,
 		logger:        config.Logger,
+		audit:         config.Audit,
+		now:           config.Now,
 	}
 }
 
 // ServeHTTP はHTTPリクエストを処理する
 func (h *LogoutHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Gatewayから伝搬された相関IDとリクエスト情報をログに載せる
+	ctx := logger.WithCorrelation(req.Context(), logger.CorrelationFromRequest(req))
+	ctx = logger.WithHTTPRequest(ctx, req)
+
 	// DELETEメソッドのみ許可
 	if req.Method != http.MethodDelete {
 		h.writeError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only DELETE method is allowed"))
@@ -61,7 +77,8 @@ func (h *LogoutHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// Authorizationヘッダーからトークンを取得
 	token, err := h.extractToken(req)
 	if err != nil {
-		h.logger.Warn("failed to extract token", "error", err)
+		h.logger.WarnContext(ctx, "failed to extract token", "error", err)
+		h.auditLog(ctx, audit.OutcomeFailure, "", err.Error())
 		h.writeError(w, errors.NewError(http.StatusUnauthorized, "Unauthorized", "missing or invalid authorization header"))
 		return
 	}
@@ -70,7 +87,8 @@ func (h *LogoutHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// Gateway経由なのでGatewayで既に検証済みを前提
 	claims, err := h.parseTokenUnverified(token)
 	if err != nil {
-		h.logger.Warn("failed to parse token", "error", err)
+		h.logger.WarnContext(ctx, "failed to parse token", "error", err)
+		h.auditLog(ctx, audit.OutcomeFailure, "", "invalid token format")
 		h.writeError(w, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid token format"))
 		return
 	}
@@ -78,25 +96,28 @@ func (h *LogoutHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// ユーザーIDを取得
 	userID, err := h.getUserID(claims)
 	if err != nil {
-		h.logger.Warn("failed to get user id from claims", "error", err)
+		h.logger.WarnContext(ctx, "failed to get user id from claims", "error", err)
+		h.auditLog(ctx, audit.OutcomeFailure, "", err.Error())
 		h.writeError(w, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid token claims"))
 		return
 	}
 
 	// 現在時刻を失効時刻としてRedisに保存
-	revokedTime := time.Now()
+	revokedTime := h.now()
 	expiration := h.jwtExpiration
 
-	if err := h.repository.SetRevokedTime(req.Context(), userID, revokedTime, expiration); err != nil {
-		h.logger.Error("failed to set revoked time", "error", err, "user_id", userID)
+	if err := h.repository.SetRevokedTime(ctx, userID, revokedTime, expiration); err != nil {
+		h.logger.ErrorContext(ctx, "failed to set revoked time", "error", err, "user_id", userID)
+		h.auditLog(ctx, audit.OutcomeFailure, userID, "failed to set revoked time")
 		h.writeError(w, errors.NewError(http.StatusInternalServerError, "InternalServerError", "failed to process logout"))
 		return
 	}
 
-	h.logger.Info("user logged out successfully",
+	h.logger.InfoContext(ctx, "user logged out successfully",
 		"user_id", userID,
 		"revoked_at", revokedTime.Format(time.RFC3339),
 		"expires_at", revokedTime.Add(expiration).Format(time.RFC3339))
+	h.auditLog(ctx, audit.OutcomeSuccess, userID, "")
 
 	// 204 No Content
 	w.WriteHeader(http.StatusNoContent)
@@ -157,6 +178,16 @@ func (h *LogoutHandler) getUserID(claims jwt.MapClaims) (string, error) {
 	return userID, nil
 }
 
+// auditLog はログアウトによるトークン失効を監査ログに記録する
+func (h *LogoutHandler) auditLog(ctx context.Context, outcome audit.Outcome, userID, reason string) {
+	h.audit.Log(ctx, audit.Event{
+		Type:    audit.EventTokenRevoked,
+		Outcome: outcome,
+		UserID:  userID,
+		Reason:  reason,
+	})
+}
+
 // writeError はエラーレスポンスを書き込む
 func (h *LogoutHandler) writeError(w http.ResponseWriter, err errors.GatewayError) {
 	w.Header().Set("Content-Type", "application/json")
@@ -1,7 +1,9 @@
 package handler_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -10,6 +12,8 @@ import (
 	"time"
 
 	"api-gateway/internal/handler"
+	"api-gateway/internal/repository"
+	"api-gateway/pkg/logger"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -35,6 +39,14 @@ func (m *mockSessionRepository) GetRevokedTime(ctx context.Context, userID strin
 	return time.Time{}, nil
 }
 
+func (m *mockSessionRepository) SetRevokedTimes(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error {
+	return make([]error, len(userIDs))
+}
+
+func (m *mockSessionRepository) GetRevocation(ctx context.Context, userID string) (repository.Revocation, error) {
+	return repository.Revocation{}, nil
+}
+
 func (m *mockSessionRepository) DeleteRevokedTime(ctx context.Context, userID string) error {
 	if m.deleteRevokedTimeFunc != nil {
 		return m.deleteRevokedTimeFunc(ctx, userID)
@@ -112,6 +124,42 @@ func TestLogoutHandler_ServeHTTP_Success(t *testing.T) {
 	}
 }
 
+func TestLogoutHandler_ServeHTTP_Now(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var got time.Time
+	repo := &mockSessionRepository{
+		setRevokedTimeFunc: func(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error {
+			got = revokedTime
+			return nil
+		},
+	}
+
+	logoutHandler := handler.NewLogoutHandler(handler.LogoutConfig{
+		Repository: repo,
+		Now:        func() time.Time { return now },
+	})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"sub": "user123",
+		"iat": now.Add(-time.Minute).Unix(),
+	})
+	tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	w := httptest.NewRecorder()
+
+	logoutHandler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("ServeHTTP() status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if !got.Equal(now) {
+		t.Errorf("SetRevokedTime called with revokedTime = %v, want %v", got, now)
+	}
+}
+
 func TestLogoutHandler_ServeHTTP_MethodNotAllowed(t *testing.T) {
 	repo := &mockSessionRepository{}
 
@@ -370,3 +418,38 @@ func TestLogoutHandler_ServeHTTP_CustomExpiration(t *testing.T) {
 		t.Errorf("ServeHTTP() status = %d, want %d", w.Code, http.StatusNoContent)
 	}
 }
+
+func TestLogoutHandler_ServeHTTP_Correlation(t *testing.T) {
+	var buf bytes.Buffer
+	logoutHandler := handler.NewLogoutHandler(handler.LogoutConfig{
+		Repository: &mockSessionRepository{},
+		Logger:     slog.New(logger.NewCorrelationHandler(slog.NewJSONHandler(&buf, nil))),
+	})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "user123"})
+	tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	// Gatewayが転送するリクエストと同じヘッダーを付与する
+	req := httptest.NewRequest(http.MethodDelete, "/v1/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	req.Header.Set(logger.HeaderRequestID, "req-123")
+	req.Header.Set(logger.HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	logoutHandler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("ServeHTTP() status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to parse log output %s: %v", buf.String(), err)
+	}
+	if entry[logger.FieldRequestID] != "req-123" {
+		t.Errorf("request_id = %v, want req-123", entry[logger.FieldRequestID])
+	}
+	if entry[logger.FieldTraceID] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace_id = %v, want 4bf92f3577b34da6a3ce929d0e0e4736", entry[logger.FieldTraceID])
+	}
+}
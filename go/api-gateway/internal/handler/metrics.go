@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/metrics"
+)
+
+// MetricsHandler はメトリクスをPrometheusのテキスト形式で返す管理API
+type MetricsHandler struct {
+	registry *metrics.Registry
+	logger   *slog.Logger
+}
+
+// NewMetricsHandler は新しいMetricsHandlerを作成する
+func NewMetricsHandler(registry *metrics.Registry, logger *slog.Logger) *MetricsHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &MetricsHandler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// GETメソッドのみ許可
+	if req.Method != http.MethodGet {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := h.registry.WriteText(w); err != nil {
+		h.logger.WarnContext(req.Context(), "failed to write metrics", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-gateway/internal/errors"
+)
+
+// OpenAPIDocumentSource は統合済みのOpenAPIドキュメントを提供する
+type OpenAPIDocumentSource interface {
+	Document(ctx context.Context) map[string]any
+}
+
+// OpenAPIHandler はバックエンドのOpenAPIドキュメントを統合して返すハンドラ
+type OpenAPIHandler struct {
+	source OpenAPIDocumentSource
+	logger *slog.Logger
+}
+
+// NewOpenAPIHandler は新しいOpenAPIHandlerを作成する
+func NewOpenAPIHandler(source OpenAPIDocumentSource, logger *slog.Logger) *OpenAPIHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &OpenAPIHandler{
+		source: source,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	doc := h.source.Document(req.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		h.logger.Error("failed to write openapi document", "error", err)
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// staticDocument はテスト用のOpenAPIDocumentSource
+type staticDocument map[string]any
+
+func (d staticDocument) Document(ctx context.Context) map[string]any {
+	return d
+}
+
+func TestOpenAPIHandler_ServeHTTP(t *testing.T) {
+	handler := NewOpenAPIHandler(staticDocument{
+		"openapi": "3.0.3",
+		"paths":   map[string]any{"/api/v1/users": map[string]any{}},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/openapi", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %s, want application/json", ct)
+	}
+
+	var doc map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", doc["openapi"])
+	}
+}
+
+func TestOpenAPIHandler_ServeHTTP_MethodNotAllowed(t *testing.T) {
+	handler := NewOpenAPIHandler(staticDocument{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/openapi", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status code = %v, want %v", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+	"api-gateway/internal/routing"
+)
+
+// claimValue はJWTのクレームの値（テナントのリージョンやユーザーID）を返す（クレームが無い、または文字列でない場合は空）
+func claimValue(ctx context.Context, claim string) string {
+	if claim == "" {
+		return ""
+	}
+	claims, ok := requestctx.Claims(ctx)
+	if !ok {
+		return ""
+	}
+	value, _ := claims[claim].(string)
+	return value
+}
+
+// residencyError はリージョンの解決に失敗した理由をクライアントへ返すエラーに変換する
+func residencyError(err error) errors.GatewayError {
+	switch {
+	case stderrors.Is(err, routing.ErrRegionRequired):
+		return errors.NewError(http.StatusBadRequest, "REGION_REQUIRED", err.Error())
+	case stderrors.Is(err, routing.ErrRegionUnavailable):
+		return errors.NewError(http.StatusForbidden, "REGION_NOT_AVAILABLE", err.Error())
+	default:
+		return errors.NewError(http.StatusForbidden, "DATA_RESIDENCY_VIOLATION", err.Error())
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+// RouteMatchHandler はリクエストがどのルートにマッチするかを説明する管理API
+// 実際には転送せず、マッチしたルート・パスパラメータ・ミドルウェア・転送先を返す
+type RouteMatchHandler struct {
+	router *routing.Router
+	logger *slog.Logger
+}
+
+// RouteMatchRequest はルートマッチAPIのリクエストボディ
+// Path にはクエリを含めてよい
+type RouteMatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// RouteMatchResponse はルートマッチAPIのレスポンスボディ
+type RouteMatchResponse struct {
+	Matched bool `json:"matched"`
+	// Redirect は末尾スラッシュのポリシーによりリダイレクトされる場合のリダイレクト先のパス
+	Redirect string `json:"redirect,omitempty"`
+	// StatusCode と Reason はマッチしなかった場合にGatewayが返すステータスと理由
+	StatusCode int    `json:"status_code,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+
+	Route      *RouteMatchRoute   `json:"route,omitempty"`
+	Params     map[string]string  `json:"params,omitempty"`
+	Middleware []string           `json:"middleware,omitempty"`
+	Backend    *RouteMatchBackend `json:"backend,omitempty"`
+	// RequestHeaders はルートのヘッダー変換ルールを適用した後、バックエンドへ送られるヘッダー
+	RequestHeaders map[string]string `json:"request_headers,omitempty"`
+}
+
+// RouteMatchRoute はマッチしたルートの情報
+type RouteMatchRoute struct {
+	Path     string   `json:"path"`
+	Methods  []string `json:"methods,omitempty"`
+	Priority int      `json:"priority"`
+	Group    string   `json:"group,omitempty"`
+	// Match はルートのヘッダーとクエリパラメータの条件（"[X-Version: 2] ?beta=true"）
+	Match string `json:"match,omitempty"`
+}
+
+// RouteMatchBackend は転送先のバックエンドの情報
+type RouteMatchBackend struct {
+	// URL はパスとクエリを結合した実際の転送先
+	URL      string `json:"url"`
+	Timeout  string `json:"timeout,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	// Static は転送せずに固定のレスポンスを返すルートか（url は空）
+	Static bool `json:"static,omitempty"`
+	// Aggregate はレスポンスをまとめるバックエンドのURL（url は空）
+	Aggregate []string `json:"aggregate,omitempty"`
+}
+
+// NewRouteMatchHandler は新しいRouteMatchHandlerを作成する
+func NewRouteMatchHandler(router *routing.Router, logger *slog.Logger) *RouteMatchHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &RouteMatchHandler{
+		router: router,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *RouteMatchHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// POSTメソッドのみ許可
+	if req.Method != http.MethodPost {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only POST method is allowed"))
+		return
+	}
+
+	var body RouteMatchRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		h.logger.Warn("failed to parse request body", "error", err)
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid request body"))
+		return
+	}
+	if body.Method == "" || !strings.HasPrefix(body.Path, "/") {
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "method and path starting with / are required"))
+		return
+	}
+	target, err := url.ParseRequestURI(body.Path)
+	if err != nil {
+		writeJSONError(w, errors.NewError(http.StatusBadRequest, "BadRequest", "invalid path"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.explain(strings.ToUpper(body.Method), target, body.Headers))
+}
+
+// explain はGatewayと同じ手順でルートを解決し、結果を説明する
+func (h *RouteMatchHandler) explain(method string, target *url.URL, headers map[string]string) RouteMatchResponse {
+	req := explainRequest(method, target, headers)
+	result, err := h.router.MatchRequest(req)
+	if err != nil {
+		return RouteMatchResponse{StatusCode: routingError(err).StatusCode(), Reason: err.Error()}
+	}
+
+	route := result.Route
+	middleware := make([]string, 0, len(route.Middleware))
+	for _, m := range route.Middleware {
+		middleware = append(middleware, m.Type)
+	}
+
+	backendURL := *route.Backend.URL
+	backendURL.Path = route.Backend.URL.Path + target.Path
+	backendURL.RawQuery = target.RawQuery
+	backend := &RouteMatchBackend{
+		URL:      backendURL.String(),
+		Protocol: string(route.Backend.Protocol),
+	}
+	if route.Backend.Static != nil {
+		backend = &RouteMatchBackend{Static: true}
+	}
+	if aggregate := route.Backend.Aggregate; aggregate != nil {
+		backend = &RouteMatchBackend{}
+		for _, part := range aggregate.Parts {
+			backend.Aggregate = append(backend.Aggregate, part.URL)
+		}
+	}
+	if route.Backend.Timeout > 0 {
+		backend.Timeout = route.Backend.Timeout.String()
+	}
+
+	return RouteMatchResponse{
+		Matched:  true,
+		Redirect: result.RedirectPath,
+		Route: &RouteMatchRoute{
+			Path:     route.Path,
+			Methods:  route.Methods,
+			Priority: route.Priority,
+			Group:    route.Group,
+			Match:    route.Matcher.String(),
+		},
+		Params:         result.Params,
+		Middleware:     middleware,
+		Backend:        backend,
+		RequestHeaders: backendRequestHeaders(req, route.RequestHeaders),
+	}
+}
+
+// explainRequest はルートの条件（match）の判定とプレースホルダーの値の解決に使う、指定されたヘッダーを持つ擬似的なリクエストを組み立てる
+func explainRequest(method string, target *url.URL, headers map[string]string) *http.Request {
+	req := &http.Request{
+		Method: method,
+		URL:    target,
+		Header: make(http.Header, len(headers)),
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Host = req.Header.Get("Host")
+	return req
+}
+
+// backendRequestHeaders はヘッダー変換ルールを適用した後のリクエストヘッダーを返す（req のヘッダーを変更する）
+func backendRequestHeaders(req *http.Request, rules *routing.HeaderRules) map[string]string {
+	if !rules.Empty() {
+		rules.Apply(req.Header, req)
+	}
+
+	result := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		result[name] = strings.Join(values, ", ")
+	}
+	return result
+}
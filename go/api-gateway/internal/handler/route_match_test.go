@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+func TestRouteMatchHandler_ServeHTTP(t *testing.T) {
+	router := routing.NewRouter()
+	err := router.LoadFromConfig(&config.RoutingFileConfig{
+		Routes: []config.Route{
+			{
+				Path:    "/api/v1/users/:id",
+				Methods: []string{http.MethodGet},
+				Backend: config.BackendConfig{URL: "http://user-service:8080/base", Timeout: 5 * time.Second, Protocol: "h2c"},
+				Middleware: []config.MiddlewareConfig{
+					{Type: "jwt_auth"},
+					{Type: "cache", Config: map[string]any{"ttl": "1m"}},
+				},
+				Priority: 10,
+				Group:    "users",
+				RequestHeaders: config.HeaderRulesConfig{
+					Set:    map[string]string{"X-Forwarded-Host": "{host}"},
+					Remove: []string{"Cookie"},
+				},
+			},
+			{
+				Path:     "/api/v1/users/:id",
+				Methods:  []string{http.MethodGet},
+				Backend:  config.BackendConfig{URL: "http://user-service-v2:8080"},
+				Match:    &config.MatchConfig{Headers: map[string]string{"X-Version": "2"}},
+				Priority: 10,
+				Group:    "users",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	handler := NewRouteMatchHandler(router, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		want       RouteMatchResponse
+	}{
+		{
+			name:       "マッチしたルートを説明する",
+			method:     http.MethodPost,
+			body:       `{"method": "get", "path": "/api/v1/users/42?verbose=1", "headers": {"Host": "api.example.com", "Cookie": "a=b"}}`,
+			wantStatus: http.StatusOK,
+			want: RouteMatchResponse{
+				Matched: true,
+				Route: &RouteMatchRoute{
+					Path:     "/api/v1/users/:id",
+					Methods:  []string{http.MethodGet},
+					Priority: 10,
+					Group:    "users",
+				},
+				Params:     map[string]string{"id": "42"},
+				Middleware: []string{"jwt_auth", "cache"},
+				Backend: &RouteMatchBackend{
+					URL:      "http://user-service:8080/base/api/v1/users/42?verbose=1",
+					Timeout:  "5s",
+					Protocol: "h2c",
+				},
+				RequestHeaders: map[string]string{
+					"Host":             "api.example.com",
+					"X-Forwarded-Host": "api.example.com",
+				},
+			},
+		},
+		{
+			name:       "ヘッダーの条件を満たすルート",
+			method:     http.MethodPost,
+			body:       `{"method": "GET", "path": "/api/v1/users/42", "headers": {"X-Version": "2"}}`,
+			wantStatus: http.StatusOK,
+			want: RouteMatchResponse{
+				Matched: true,
+				Route: &RouteMatchRoute{
+					Path:     "/api/v1/users/:id",
+					Methods:  []string{http.MethodGet},
+					Priority: 10,
+					Group:    "users",
+					Match:    "[X-Version: 2]",
+				},
+				Params:         map[string]string{"id": "42"},
+				Backend:        &RouteMatchBackend{URL: "http://user-service-v2:8080/api/v1/users/42"},
+				RequestHeaders: map[string]string{"X-Version": "2"},
+			},
+		},
+		{
+			name:       "ルートが見つからない",
+			method:     http.MethodPost,
+			body:       `{"method": "GET", "path": "/api/v2/users"}`,
+			wantStatus: http.StatusOK,
+			want: RouteMatchResponse{
+				StatusCode: http.StatusNotFound,
+				Reason:     "no route found for path: /api/v2/users",
+			},
+		},
+		{
+			name:       "メソッドが許可されていない",
+			method:     http.MethodPost,
+			body:       `{"method": "DELETE", "path": "/api/v1/users/42"}`,
+			wantStatus: http.StatusOK,
+			want: RouteMatchResponse{
+				StatusCode: http.StatusMethodNotAllowed,
+				Reason:     "method DELETE not allowed",
+			},
+		},
+		{
+			name:       "pathが/で始まらない",
+			method:     http.MethodPost,
+			body:       `{"method": "GET", "path": "api/v1/users"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "不正なJSON",
+			method:     http.MethodPost,
+			body:       `{invalid json}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "POST以外のメソッド",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/routes/match", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got RouteMatchResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("response = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
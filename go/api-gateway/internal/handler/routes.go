@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+// RoutesHandler は読み込んでいるルートの一覧を返す管理API（参照のみ）
+// パスに対して実際にどのルートが使われているかを、マッチしたリクエスト数とあわせて確認するために使う
+type RoutesHandler struct {
+	router *routing.Router
+	logger *slog.Logger
+}
+
+// RouteTableEntry はルートの一覧の1つのルート
+type RouteTableEntry struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods,omitempty"`
+	// Match はルートのヘッダーとクエリパラメータの条件（"[X-Version: 2] ?beta=true"）
+	Match    string `json:"match,omitempty"`
+	Priority int    `json:"priority"`
+	Group    string `json:"group,omitempty"`
+
+	Backend RouteTableBackend `json:"backend"`
+	// Middleware はミドルウェアの種類（実行する順。cors は常に最も外側で適用する）
+	Middleware []string `json:"middleware"`
+
+	// Hits はルートにマッチしたリクエスト数（ルーティング設定の再読み込みで 0 に戻る）
+	Hits int64 `json:"hits"`
+	// LastHit は最後にマッチした時刻（まだマッチしていない場合は省略）
+	LastHit *time.Time `json:"last_hit,omitempty"`
+}
+
+// RouteTableBackend はルートの転送先のバックエンド
+type RouteTableBackend struct {
+	URL      string `json:"url"`
+	Timeout  string `json:"timeout,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	// Service はサービスディスカバリのサービス名（url のホストの代わりにインスタンスへ転送する。設定されていない場合は省略）
+	Service string `json:"service,omitempty"`
+	// Canary はカナリアのバックエンドのURL（設定されていない場合は省略）
+	Canary string `json:"canary,omitempty"`
+	// Static は転送せずに固定のレスポンスを返すルートか（url は空）
+	Static bool `json:"static,omitempty"`
+	// Aggregate はレスポンスをまとめるバックエンドのURL（url は空。設定されていない場合は省略）
+	Aggregate []string `json:"aggregate,omitempty"`
+}
+
+// NewRoutesHandler は新しいRoutesHandlerを作成する
+func NewRoutesHandler(router *routing.Router, logger *slog.Logger) *RoutesHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &RoutesHandler{
+		router: router,
+		logger: logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *RoutesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// GETメソッドのみ許可
+	if req.Method != http.MethodGet {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.routeTable())
+}
+
+// routeTable は全てのルートをパスの順（同じパスは優先する順）に返す
+func (h *RoutesHandler) routeTable() []RouteTableEntry {
+	routes := h.router.GetAllRoutes()
+	slices.SortStableFunc(routes, func(a, b *routing.Route) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(b.Priority, a.Priority),
+			cmp.Compare(a.Matcher.String(), b.Matcher.String()),
+		)
+	})
+
+	entries := make([]RouteTableEntry, 0, len(routes))
+	for _, route := range routes {
+		entries = append(entries, newRouteTableEntry(route))
+	}
+	return entries
+}
+
+// newRouteTableEntry はルートの一覧のエントリを作成する
+func newRouteTableEntry(route *routing.Route) RouteTableEntry {
+	middleware := make([]string, 0, len(route.Middleware))
+	for _, m := range route.Middleware {
+		middleware = append(middleware, m.Type)
+	}
+
+	backend := RouteTableBackend{
+		URL:      route.Backend.URL.String(),
+		Protocol: string(route.Backend.Protocol),
+		Service:  route.Backend.Service,
+		Static:   route.Backend.Static != nil,
+	}
+	if aggregate := route.Backend.Aggregate; aggregate != nil {
+		for _, part := range aggregate.Parts {
+			backend.Aggregate = append(backend.Aggregate, part.URL)
+		}
+	}
+	if route.Backend.Timeout > 0 {
+		backend.Timeout = route.Backend.Timeout.String()
+	}
+	if route.Canary != nil {
+		backend.Canary = route.Canary.URL.String()
+	}
+
+	entry := RouteTableEntry{
+		Path:       route.Path,
+		Methods:    route.Methods,
+		Match:      route.Matcher.String(),
+		Priority:   route.Priority,
+		Group:      route.Group,
+		Backend:    backend,
+		Middleware: middleware,
+		Hits:       route.Stats.Hits(),
+	}
+	if lastHit, ok := route.Stats.LastHit(); ok {
+		lastHit = lastHit.UTC()
+		entry.LastHit = &lastHit
+	}
+	return entry
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+func TestRoutesHandler_ServeHTTP(t *testing.T) {
+	router := routing.NewRouter()
+	err := router.LoadFromConfig(&config.RoutingFileConfig{
+		Routes: []config.Route{
+			{
+				Path:       "/api/v1/users/:id",
+				Methods:    []string{http.MethodGet},
+				Backend:    config.BackendConfig{URL: "http://user-service:8080", Timeout: 5 * time.Second},
+				Middleware: []config.MiddlewareConfig{{Type: "jwt"}, {Type: "cache"}},
+				Priority:   10,
+				Group:      "users",
+			},
+			{
+				Path:     "/api/v1/users/:id",
+				Methods:  []string{http.MethodGet},
+				Backend:  config.BackendConfig{URL: "http://user-service-v2:8080"},
+				Match:    &config.MatchConfig{Headers: map[string]string{"X-Version": "2"}},
+				Priority: 10,
+			},
+			{
+				Path:    "/api/v1/orders",
+				Backend: config.BackendConfig{URL: "http://order-service:8080"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+
+	// ゲートウェイを通したリクエストをマッチしたルートのリクエスト数として数える
+	gateway := NewGateway(router, &mockTransporter{}, nil, slog.New(slog.DiscardHandler))
+	for _, version := range []string{"", "", "2"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil)
+		if version != "" {
+			req.Header.Set("X-Version", version)
+		}
+		gateway.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	handler := NewRoutesHandler(router, slog.New(slog.DiscardHandler))
+
+	t.Run("ルートの一覧とマッチしたリクエスト数", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/routes", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		var got []RouteTableEntry
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("routes = %d, want 3", len(got))
+		}
+
+		tests := []struct {
+			path       string
+			match      string
+			backend    string
+			middleware int
+			hits       int64
+		}{
+			{path: "/api/v1/orders", backend: "http://order-service:8080"},
+			{path: "/api/v1/users/:id", backend: "http://user-service:8080", middleware: 2, hits: 2},
+			{path: "/api/v1/users/:id", match: "[X-Version: 2]", backend: "http://user-service-v2:8080", hits: 1},
+		}
+		for i, tt := range tests {
+			entry := got[i]
+			if entry.Path != tt.path || entry.Match != tt.match || entry.Backend.URL != tt.backend {
+				t.Errorf("routes[%d] = %s %q %s, want %s %q %s", i, entry.Path, entry.Match, entry.Backend.URL, tt.path, tt.match, tt.backend)
+			}
+			if len(entry.Middleware) != tt.middleware {
+				t.Errorf("routes[%d].middleware = %v, want %d entries", i, entry.Middleware, tt.middleware)
+			}
+			if entry.Hits != tt.hits || (entry.LastHit != nil) != (tt.hits > 0) {
+				t.Errorf("routes[%d] hits = %d, last_hit = %v, want %d hits", i, entry.Hits, entry.LastHit, tt.hits)
+			}
+		}
+		if got[1].Backend.Timeout != "5s" || got[1].Priority != 10 || got[1].Group != "users" {
+			t.Errorf("routes[1] = %+v, want timeout 5s, priority 10 and group users", got[1])
+		}
+	})
+
+	t.Run("GET以外のメソッド", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/routes", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+// routingError はルートを解決できなかった理由をクライアントへ返すエラーに変換する
+// 405の場合は許可されているメソッドをAllowヘッダーで返す
+func routingError(err error) errors.GatewayError {
+	var methodErr *routing.MethodNotAllowedError
+	switch {
+	case stderrors.Is(err, routing.ErrRouteNotFound):
+		return errors.NewNotFoundError(err.Error())
+	case stderrors.As(err, &methodErr):
+		return errors.WithHeader(
+			errors.NewError(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", err.Error()),
+			"Allow", methodErr.AllowHeader(),
+		)
+	default:
+		return errors.WrapError(err, http.StatusInternalServerError, "ROUTING_ERROR")
+	}
+}
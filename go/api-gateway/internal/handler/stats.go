@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/routing"
+)
+
+// ReloadResult はルーティング設定の読み込み（起動時または再読み込み）の結果
+type ReloadResult struct {
+	Time    time.Time `json:"time"`
+	Success bool      `json:"success"`
+	// Error は読み込みに失敗した理由（失敗した場合は以前の設定のまま動作する）
+	Error string `json:"error,omitempty"`
+	// Routes は読み込み後のルート数
+	Routes int `json:"routes"`
+	// Diff は以前の設定からの差分（失敗した場合は空）
+	Diff config.RoutingDiff `json:"diff"`
+}
+
+// ReloadStatus は最後に行ったルーティング設定の読み込みの結果を保持する
+type ReloadStatus struct {
+	mu   sync.Mutex
+	last *ReloadResult
+}
+
+// NewReloadStatus は新しいReloadStatusを作成する
+func NewReloadStatus() *ReloadStatus {
+	return &ReloadStatus{}
+}
+
+// Record は読み込みの結果を記録する
+func (s *ReloadStatus) Record(result ReloadResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = &result
+}
+
+// Last は最後の読み込みの結果を返す（まだ記録が無い場合は false）
+func (s *ReloadStatus) Last() (ReloadResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return ReloadResult{}, false
+	}
+	return *s.last, true
+}
+
+// StatsHandler はゲートウェイの稼働状況と最後の設定の読み込みの結果を返す管理API
+type StatsHandler struct {
+	router    *routing.Router
+	reloads   *ReloadStatus
+	startedAt time.Time
+	logger    *slog.Logger
+}
+
+// StatsResponse は稼働状況APIのレスポンス
+type StatsResponse struct {
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	Routes        int       `json:"routes"`
+	// LastReload は最後の設定の読み込みの結果（記録が無い場合は null）
+	LastReload *ReloadResult `json:"last_reload"`
+}
+
+// NewStatsHandler は新しいStatsHandlerを作成する
+func NewStatsHandler(router *routing.Router, reloads *ReloadStatus, logger *slog.Logger) *StatsHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &StatsHandler{
+		router:    router,
+		reloads:   reloads,
+		startedAt: time.Now(),
+		logger:    logger,
+	}
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// GETメソッドのみ許可
+	if req.Method != http.MethodGet {
+		writeJSONError(w, errors.NewError(http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET method is allowed"))
+		return
+	}
+
+	resp := StatsResponse{
+		StartedAt:     h.startedAt.UTC(),
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+		Routes:        len(h.router.GetAllRoutes()),
+	}
+	if last, ok := h.reloads.Last(); ok {
+		resp.LastReload = &last
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
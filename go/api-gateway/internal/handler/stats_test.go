@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+func TestStatsHandler_ServeHTTP(t *testing.T) {
+	router := routing.NewRouter()
+	backendURL, _ := url.Parse("http://users.internal:8080")
+	router.AddRoute(&routing.Route{Path: "/api/v1/users", Methods: []string{http.MethodGet}, Backend: &routing.Backend{URL: backendURL}})
+
+	reloadedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	diff := config.RoutingDiff{
+		Removed: []string{"GET /api/v1/orders"},
+		Changed: []config.RouteChange{{Route: "GET /api/v1/users", Fields: []string{"backend.timeout"}}},
+	}
+
+	tests := []struct {
+		name       string
+		record     *ReloadResult
+		method     string
+		wantStatus int
+		wantReload *ReloadResult
+	}{
+		{name: "読み込みの記録が無い場合は null", method: http.MethodGet, wantStatus: http.StatusOK},
+		{
+			name:       "最後の読み込みの結果と差分を返す",
+			record:     &ReloadResult{Time: reloadedAt, Success: true, Routes: 1, Diff: diff},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantReload: &ReloadResult{Time: reloadedAt, Success: true, Routes: 1, Diff: diff},
+		},
+		{
+			name:       "失敗した読み込みの理由を返す",
+			record:     &ReloadResult{Time: reloadedAt, Error: "invalid residency: backends is required", Routes: 1},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantReload: &ReloadResult{Time: reloadedAt, Error: "invalid residency: backends is required", Routes: 1},
+		},
+		{name: "GET以外は405", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reloads := NewReloadStatus()
+			if tt.record != nil {
+				reloads.Record(*tt.record)
+			}
+
+			rec := httptest.NewRecorder()
+			NewStatsHandler(router, reloads, nil).ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/stats", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp StatsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if resp.Routes != 1 {
+				t.Errorf("routes = %d, want 1", resp.Routes)
+			}
+			if resp.StartedAt.IsZero() {
+				t.Error("started_at should be set")
+			}
+			if !reflect.DeepEqual(resp.LastReload, tt.wantReload) {
+				t.Errorf("last_reload = %+v, want %+v", resp.LastReload, tt.wantReload)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+const (
+	defaultPath               = "/health"
+	defaultInterval           = 10 * time.Second
+	defaultTimeout            = 2 * time.Second
+	defaultUnhealthyThreshold = 3
+	defaultHealthyThreshold   = 2
+)
+
+// backendHealthy はバックエンドごとの状態（1: 正常, 0: 異常）
+var backendHealthy = metrics.NewGaugeVec(
+	"gateway_backend_healthy",
+	"Whether the backend passed active health checks (1) or not (0).",
+	"backend",
+)
+
+// Config はアクティブヘルスチェックの設定
+type Config struct {
+	// Path はバックエンドのホストに対して確認するパス（デフォルト: /health）
+	Path string
+	// Interval は確認の間隔（デフォルト: 10秒）
+	Interval time.Duration
+	// Timeout は1回の確認のタイムアウト（デフォルト: 2秒）
+	Timeout time.Duration
+	// UnhealthyThreshold は異常とみなす連続失敗回数（デフォルト: 3）
+	UnhealthyThreshold int
+	// HealthyThreshold は異常から正常に戻す連続成功回数（デフォルト: 2）
+	HealthyThreshold int
+	Client           *http.Client
+	Logger           *slog.Logger
+}
+
+// Status はバックエンドのヘルスチェックの状態
+type Status struct {
+	Backend              string    `json:"backend"`
+	Healthy              bool      `json:"healthy"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	LastCheck            time.Time `json:"last_check,omitzero"`
+	LastError            string    `json:"last_error,omitempty"`
+}
+
+// Checker はバックエンドを定期的に確認し、異常なバックエンドを記録する
+// nil の Checker は全てのバックエンドを正常とみなす（ヘルスチェックが無効の場合）
+type Checker struct {
+	config Config
+
+	mu      sync.RWMutex
+	targets map[string]*Status
+}
+
+// New はbackendsを確認するCheckerを作成する
+// 同じホストを共有するバックエンドはまとめて1つとして確認する
+func New(config Config, backends []*url.URL) *Checker {
+	// デフォルト値の設定
+	if config.Path == "" {
+		config.Path = defaultPath
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.UnhealthyThreshold <= 0 {
+		config.UnhealthyThreshold = defaultUnhealthyThreshold
+	}
+	if config.HealthyThreshold <= 0 {
+		config.HealthyThreshold = defaultHealthyThreshold
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{
+			// リダイレクト先ではなくバックエンド自身の応答で判定する
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	c := &Checker{config: config}
+	c.SetBackends(backends)
+	return c
+}
+
+// SetBackends は確認するバックエンドを backends に置き換える（ルートを読み込み直した場合に使う）
+// 引き続き確認するバックエンドは状態を引き継ぎ、確認しなくなったバックエンドはメトリクスからも削除する
+func (c *Checker) SetBackends(backends []*url.URL) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	targets := make(map[string]*Status)
+	for _, u := range backends {
+		if u == nil || u.Host == "" {
+			continue
+		}
+		key := backendKey(u)
+		if status, ok := c.targets[key]; ok {
+			targets[key] = status
+			continue
+		}
+		// 確認が行われるまでは正常とみなす
+		targets[key] = &Status{Backend: key, Healthy: true}
+		backendHealthy.With(key).Set(1)
+	}
+	for key := range c.targets {
+		if _, ok := targets[key]; !ok {
+			backendHealthy.Delete(key)
+		}
+	}
+	c.targets = targets
+}
+
+// Start はctxがキャンセルされるまでバックグラウンドで定期的に確認する
+func (c *Checker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.config.Interval)
+		defer ticker.Stop()
+
+		c.CheckAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.CheckAll(ctx)
+			}
+		}
+	}()
+}
+
+// CheckAll は全てのバックエンドを並行して1回ずつ確認する
+func (c *Checker) CheckAll(ctx context.Context) {
+	c.mu.RLock()
+	keys := make([]string, 0, len(c.targets))
+	for key := range c.targets {
+		keys = append(keys, key)
+	}
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.record(ctx, key, c.probe(ctx, key))
+		}()
+	}
+	wg.Wait()
+}
+
+// probe はバックエンドのヘルスチェック用のパスへリクエストし、2xxと3xx以外を失敗とする
+func (c *Checker) probe(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key+c.config.Path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := c.config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// コネクションを再利用できるよう読み捨てる
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected health check status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// record は確認結果を反映し、しきい値を超えた場合に状態を切り替える
+func (c *Checker) record(ctx context.Context, key string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, ok := c.targets[key]
+	if !ok {
+		return
+	}
+	status.LastCheck = time.Now()
+
+	if err != nil {
+		status.LastError = err.Error()
+		status.ConsecutiveSuccesses = 0
+		status.ConsecutiveFailures++
+		if status.Healthy && status.ConsecutiveFailures >= c.config.UnhealthyThreshold {
+			status.Healthy = false
+			backendHealthy.With(key).Set(0)
+			c.config.Logger.WarnContext(ctx, "backend marked unhealthy",
+				"backend", key,
+				"consecutive_failures", status.ConsecutiveFailures,
+				"error", err)
+		}
+		return
+	}
+
+	status.LastError = ""
+	status.ConsecutiveFailures = 0
+	status.ConsecutiveSuccesses++
+	if !status.Healthy && status.ConsecutiveSuccesses >= c.config.HealthyThreshold {
+		status.Healthy = true
+		backendHealthy.With(key).Set(1)
+		c.config.Logger.InfoContext(ctx, "backend marked healthy", "backend", key)
+	}
+}
+
+// IsHealthy はバックエンドが正常かを返す（確認対象外のバックエンドは正常とみなす）
+func (c *Checker) IsHealthy(u *url.URL) bool {
+	if c == nil || u == nil {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status, ok := c.targets[backendKey(u)]
+	return !ok || status.Healthy
+}
+
+// Statuses は全てのバックエンドの状態をバックエンド順に返す
+func (c *Checker) Statuses() []Status {
+	if c == nil {
+		return []Status{}
+	}
+
+	c.mu.RLock()
+	statuses := make([]Status, 0, len(c.targets))
+	for _, status := range c.targets {
+		statuses = append(statuses, *status)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Backend < statuses[j].Backend })
+	return statuses
+}
+
+// backendKey はバックエンドURLのスキームとホストを返す
+func backendKey(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,157 @@
+package healthcheck
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("failed to parse url: %v", err)
+	}
+	return u
+}
+
+func TestChecker_Thresholds(t *testing.T) {
+	var healthy atomic.Bool
+	var lastPath atomic.Value
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath.Store(r.URL.Path)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	backendURL := mustParseURL(t, backend.URL+"/api/v1/users")
+	checker := New(Config{
+		Path:               "/healthz",
+		UnhealthyThreshold: 2,
+		HealthyThreshold:   2,
+		Logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, []*url.URL{backendURL})
+	ctx := context.Background()
+
+	// 確認前は正常とみなす
+	if !checker.IsHealthy(backendURL) {
+		t.Fatal("backend should be healthy before the first check")
+	}
+
+	steps := []struct {
+		name    string
+		healthy bool
+		want    bool
+	}{
+		{name: "1回目の失敗ではまだ正常", healthy: false, want: true},
+		{name: "しきい値に達すると異常", healthy: false, want: false},
+		{name: "1回目の成功ではまだ異常", healthy: true, want: false},
+		{name: "しきい値に達すると正常", healthy: true, want: true},
+	}
+	for _, step := range steps {
+		healthy.Store(step.healthy)
+		checker.CheckAll(ctx)
+		if got := checker.IsHealthy(backendURL); got != step.want {
+			t.Fatalf("%s: IsHealthy() = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	if got := lastPath.Load(); got != "/healthz" {
+		t.Errorf("probe path = %v, want /healthz", got)
+	}
+	if got := backendHealthy.With(backendKey(backendURL)).Value(); got != 1 {
+		t.Errorf("gateway_backend_healthy = %v, want 1", got)
+	}
+}
+
+func TestChecker_Statuses(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer backend.Close()
+
+	// 同じホストのバックエンドは1つにまとめる
+	checker := New(Config{
+		UnhealthyThreshold: 1,
+		Logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, []*url.URL{
+		mustParseURL(t, backend.URL+"/users"),
+		mustParseURL(t, backend.URL+"/orders"),
+	})
+	checker.CheckAll(context.Background())
+
+	statuses := checker.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("got %d statuses, want 1", len(statuses))
+	}
+	status := statuses[0]
+	if status.Backend != backend.URL || status.Healthy || status.ConsecutiveFailures != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if status.LastError == "" || status.LastCheck.IsZero() {
+		t.Errorf("status should record last check and error: %+v", status)
+	}
+}
+
+func TestChecker_SetBackends(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer backend.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer other.Close()
+
+	kept, removed, added := mustParseURL(t, backend.URL), mustParseURL(t, other.URL), mustParseURL(t, "http://added:8080")
+	checker := New(Config{
+		UnhealthyThreshold: 1,
+		Logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, []*url.URL{kept, removed})
+	checker.CheckAll(context.Background())
+
+	// 引き続き確認するバックエンドは状態を引き継ぎ、新しいバックエンドは確認するまで正常とみなす
+	checker.SetBackends([]*url.URL{kept, added})
+
+	if statuses := checker.Statuses(); len(statuses) != 2 {
+		t.Fatalf("got %d statuses, want 2", len(statuses))
+	}
+	if checker.IsHealthy(kept) {
+		t.Error("kept backend should stay unhealthy")
+	}
+	if !checker.IsHealthy(added) {
+		t.Error("added backend should be healthy before the first check")
+	}
+	if !checker.IsHealthy(removed) {
+		t.Error("backend that is no longer checked should be healthy")
+	}
+}
+
+func TestChecker_Nil(t *testing.T) {
+	var checker *Checker
+
+	// ヘルスチェックが無効（nil）の場合は全て正常とみなす
+	if !checker.IsHealthy(mustParseURL(t, "http://backend:8080")) {
+		t.Error("nil checker should report healthy")
+	}
+	if statuses := checker.Statuses(); len(statuses) != 0 {
+		t.Errorf("Statuses() = %v, want empty", statuses)
+	}
+}
+
+func TestChecker_UnknownBackend(t *testing.T) {
+	checker := New(Config{}, nil)
+
+	if !checker.IsHealthy(mustParseURL(t, "http://unknown:8080")) {
+		t.Error("backend that is not checked should be healthy")
+	}
+}
@@ -0,0 +1,201 @@
+package healthcheck
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+const (
+	defaultOutlierInterval          = 10 * time.Second
+	defaultOutlierMinRequests       = 10
+	defaultOutlierMaxErrorPercent   = 50
+	defaultOutlierMaxTimeoutPercent = 50
+	defaultOutlierEjectionDuration  = 30 * time.Second
+)
+
+var (
+	// backendEjected はバックエンドごとの除外の状態（1: 除外中, 0: 転送する）
+	backendEjected = metrics.NewGaugeVec(
+		"gateway_backend_ejected",
+		"Whether the backend is ejected by outlier detection (1) or not (0).",
+		"backend",
+	)
+	backendEjectionsTotal = metrics.NewCounterVec(
+		"gateway_backend_ejections_total",
+		"Number of times the backend was ejected by outlier detection.",
+		"backend",
+	)
+)
+
+// Outcome はバックエンドへの1件のリクエストの結果
+type Outcome int
+
+const (
+	// OutcomeSuccess は5xx以外のレスポンス
+	OutcomeSuccess Outcome = iota
+	// OutcomeError は5xxのレスポンス、または接続できなかった場合
+	OutcomeError
+	// OutcomeTimeout はタイムアウトまでにレスポンスが無かった場合
+	OutcomeTimeout
+)
+
+// OutcomeFromStatus はレスポンスのステータスコードから結果を返す
+func OutcomeFromStatus(statusCode int) Outcome {
+	if statusCode >= http.StatusInternalServerError {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// OutlierConfig は外れ値検出の設定
+type OutlierConfig struct {
+	// Interval はエラーの割合を集計する期間（デフォルト: 10秒）
+	Interval time.Duration
+	// MinRequests は判定に必要な期間内のリクエスト数（デフォルト: 10）
+	MinRequests int
+	// MaxErrorPercent は除外する5xx（接続できない場合を含む）の割合（デフォルト: 50%）
+	MaxErrorPercent float64
+	// MaxTimeoutPercent は除外するタイムアウトの割合（デフォルト: 50%）
+	MaxTimeoutPercent float64
+	// EjectionDuration は除外する時間（デフォルト: 30秒）
+	EjectionDuration time.Duration
+	Logger           *slog.Logger
+}
+
+// OutlierDetector は実際のリクエストの結果からバックエンドごとの5xxとタイムアウトの割合を集計し、
+// しきい値を超えたバックエンドを一定時間除外する（パッシブヘルスチェック）
+// 除外する時間を過ぎたバックエンドは、次に状態を確認した時点で転送先に戻す
+// nil の OutlierDetector はどのバックエンドも除外しない（外れ値検出が無効の場合）
+type OutlierDetector struct {
+	config OutlierConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	backends map[string]*outlierStats
+}
+
+// outlierStats はバックエンドの集計中の期間の結果
+type outlierStats struct {
+	windowStart time.Time
+	requests    int
+	errors      int
+	timeouts    int
+	// ejectedUntil は除外を終える時刻（ゼロ値は除外していない）
+	ejectedUntil time.Time
+}
+
+// NewOutlierDetector は新しいOutlierDetectorを作成する
+func NewOutlierDetector(config OutlierConfig) *OutlierDetector {
+	if config.Interval <= 0 {
+		config.Interval = defaultOutlierInterval
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaultOutlierMinRequests
+	}
+	if config.MaxErrorPercent <= 0 {
+		config.MaxErrorPercent = defaultOutlierMaxErrorPercent
+	}
+	if config.MaxTimeoutPercent <= 0 {
+		config.MaxTimeoutPercent = defaultOutlierMaxTimeoutPercent
+	}
+	if config.EjectionDuration <= 0 {
+		config.EjectionDuration = defaultOutlierEjectionDuration
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &OutlierDetector{
+		config:   config,
+		now:      time.Now,
+		backends: make(map[string]*outlierStats),
+	}
+}
+
+// Record はバックエンドへのリクエストの結果を記録し、しきい値を超えた場合はバックエンドを除外する
+// 除外中のバックエンドの結果（除外する前に転送したリクエスト）は数えない
+func (d *OutlierDetector) Record(ctx context.Context, u *url.URL, outcome Outcome) {
+	if d == nil || u == nil {
+		return
+	}
+	key := backendKey(u)
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats, ok := d.backends[key]
+	if !ok {
+		stats = &outlierStats{windowStart: now}
+		d.backends[key] = stats
+	}
+	if d.reinstate(ctx, key, stats, now) {
+		return
+	}
+	if now.Sub(stats.windowStart) >= d.config.Interval {
+		*stats = outlierStats{windowStart: now}
+	}
+
+	stats.requests++
+	switch outcome {
+	case OutcomeError:
+		stats.errors++
+	case OutcomeTimeout:
+		stats.timeouts++
+	}
+	if stats.requests < d.config.MinRequests {
+		return
+	}
+
+	errorPercent := float64(stats.errors) * 100 / float64(stats.requests)
+	timeoutPercent := float64(stats.timeouts) * 100 / float64(stats.requests)
+	if errorPercent < d.config.MaxErrorPercent && timeoutPercent < d.config.MaxTimeoutPercent {
+		return
+	}
+
+	stats.ejectedUntil = now.Add(d.config.EjectionDuration)
+	backendEjected.With(key).Set(1)
+	backendEjectionsTotal.With(key).Inc()
+	d.config.Logger.WarnContext(ctx, "backend ejected by outlier detection",
+		"backend", key,
+		"requests", stats.requests,
+		"error_percent", errorPercent,
+		"timeout_percent", timeoutPercent,
+		"ejection_duration", d.config.EjectionDuration.String())
+}
+
+// IsEjected はバックエンドが除外されているかを返す
+func (d *OutlierDetector) IsEjected(ctx context.Context, u *url.URL) bool {
+	if d == nil || u == nil {
+		return false
+	}
+	key := backendKey(u)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats, ok := d.backends[key]
+	return ok && d.reinstate(ctx, key, stats, d.now())
+}
+
+// reinstate は除外する時間を過ぎたバックエンドを転送先に戻し、まだ除外中かを返す（mu を保持して呼ぶ）
+// 戻したバックエンドは新しい期間で集計し直す
+func (d *OutlierDetector) reinstate(ctx context.Context, key string, stats *outlierStats, now time.Time) bool {
+	if stats.ejectedUntil.IsZero() {
+		return false
+	}
+	if now.Before(stats.ejectedUntil) {
+		return true
+	}
+
+	*stats = outlierStats{windowStart: now}
+	backendEjected.With(key).Set(0)
+	d.config.Logger.InfoContext(ctx, "backend reinstated after outlier ejection", "backend", key)
+	return false
+}
@@ -0,0 +1,94 @@
+package healthcheck
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestOutlierDetector_Ejection(t *testing.T) {
+	tests := []struct {
+		name        string
+		outcomes    []Outcome
+		wantEjected bool
+	}{
+		{name: "5xxの割合がしきい値以上", outcomes: []Outcome{OutcomeSuccess, OutcomeError, OutcomeError, OutcomeSuccess}, wantEjected: true},
+		{name: "タイムアウトの割合がしきい値以上", outcomes: []Outcome{OutcomeTimeout, OutcomeSuccess, OutcomeTimeout, OutcomeSuccess}, wantEjected: true},
+		{name: "しきい値未満", outcomes: []Outcome{OutcomeError, OutcomeSuccess, OutcomeTimeout, OutcomeSuccess}},
+		{name: "リクエスト数が判定に必要な数より少ない", outcomes: []Outcome{OutcomeError, OutcomeError, OutcomeError}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewOutlierDetector(OutlierConfig{
+				MinRequests: 4,
+				Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+			})
+			u := mustParseURL(t, "http://orders.example.com/api")
+			for _, outcome := range tt.outcomes {
+				d.Record(context.Background(), u, outcome)
+			}
+
+			if got := d.IsEjected(context.Background(), u); got != tt.wantEjected {
+				t.Errorf("IsEjected() = %v, want %v", got, tt.wantEjected)
+			}
+			// 同じホストの別のパスも同じバックエンドとして扱う
+			if got := d.IsEjected(context.Background(), mustParseURL(t, "http://orders.example.com/other")); got != tt.wantEjected {
+				t.Errorf("IsEjected() for the same host = %v, want %v", got, tt.wantEjected)
+			}
+			if d.IsEjected(context.Background(), mustParseURL(t, "http://users.example.com")) {
+				t.Error("other backends must not be ejected")
+			}
+		})
+	}
+}
+
+func TestOutlierDetector_Reinstatement(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := NewOutlierDetector(OutlierConfig{
+		Interval:         10 * time.Second,
+		MinRequests:      2,
+		EjectionDuration: 30 * time.Second,
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	d.now = func() time.Time { return now }
+	u := mustParseURL(t, "http://orders.example.com")
+
+	// 期間を過ぎた結果は数えない
+	d.Record(context.Background(), u, OutcomeError)
+	now = now.Add(10 * time.Second)
+	d.Record(context.Background(), u, OutcomeError)
+	if d.IsEjected(context.Background(), u) {
+		t.Fatal("errors in different intervals must not eject the backend")
+	}
+
+	d.Record(context.Background(), u, OutcomeError)
+	if !d.IsEjected(context.Background(), u) {
+		t.Fatal("backend should be ejected")
+	}
+
+	// 除外中の結果は数えず、除外する時間を過ぎると転送先に戻す
+	now = now.Add(29 * time.Second)
+	d.Record(context.Background(), u, OutcomeError)
+	if !d.IsEjected(context.Background(), u) {
+		t.Error("backend should stay ejected during the ejection duration")
+	}
+	now = now.Add(time.Second)
+	if d.IsEjected(context.Background(), u) {
+		t.Error("backend should be reinstated after the ejection duration")
+	}
+
+	// 戻した後は新しい期間で集計し直す
+	d.Record(context.Background(), u, OutcomeError)
+	if d.IsEjected(context.Background(), u) {
+		t.Error("errors before the ejection must not count after reinstatement")
+	}
+
+	var none *OutlierDetector
+	none.Record(context.Background(), u, OutcomeError)
+	if none.IsEjected(context.Background(), u) {
+		t.Error("nil detector must not eject backends")
+	}
+}
@@ -0,0 +1,95 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// lastEntryReadSize は最後のエントリを探すためにファイルの末尾から読む最大のバイト数
+const lastEntryReadSize = 64 * 1024
+
+// FileSink はローカルファイルへJSON Linesで追記する出力先
+// 追記モードで開き、Sync で fsync して書き込みを確定させる
+// fsync はグループコミットで、実行中の fsync を待つ間に追記されたエントリは次の1回の fsync でまとめて確定させる
+type FileSink struct {
+	f *os.File
+	// written は追記した最後のエントリの連番
+	written atomic.Uint64
+
+	// syncMu は fsync を同時に1つだけ実行する
+	syncMu sync.Mutex
+	// synced は fsync で確定させた最後のエントリの連番
+	synced uint64
+}
+
+// OpenFile はジャーナルのファイルを開く（無い場合は作成する）
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal file: %w", err)
+	}
+	return &FileSink{f: f}, nil
+}
+
+// Last はファイルの最後のエントリを返す
+func (s *FileSink) Last(context.Context) (Entry, bool, error) {
+	info, err := s.f.Stat()
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to stat journal file: %w", err)
+	}
+	size := info.Size()
+	if size == 0 {
+		return Entry{}, false, nil
+	}
+
+	offset := max(size-lastEntryReadSize, 0)
+	buf := make([]byte, size-offset)
+	if _, err := s.f.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return Entry{}, false, fmt.Errorf("failed to read journal file: %w", err)
+	}
+
+	buf = bytes.TrimRight(buf, "\n")
+	line := buf[bytes.LastIndexByte(buf, '\n')+1:]
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		// 書き込み途中で停止した行がある場合などは、チェーンを継続できないため起動しない
+		return Entry{}, false, fmt.Errorf("invalid last journal entry: %w", err)
+	}
+	return e, true, nil
+}
+
+// Append はエントリを追記する（fsync は Sync で行う）
+func (s *FileSink) Append(e Entry, line []byte) error {
+	if _, err := s.f.Write(line); err != nil {
+		return err
+	}
+	s.written.Store(e.Seq)
+	return nil
+}
+
+// Sync は連番 seq までのエントリを fsync する
+// 他の呼び出しの fsync で seq まで確定済みの場合は fsync しない
+func (s *FileSink) Sync(seq uint64) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if s.synced >= seq {
+		return nil
+	}
+	written := s.written.Load()
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.synced = written
+	return nil
+}
+
+// Close はファイルを閉じる
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
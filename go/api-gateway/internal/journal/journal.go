@@ -0,0 +1,179 @@
+// Package journal はリーガルホールドのために、指定したルートのリクエストのメタデータを追記専用のジャーナルへ記録する
+// 各エントリは直前のエントリのハッシュを含むハッシュチェーンになっており、途中のエントリの改ざん・削除・挿入を検知できる
+// リクエスト・レスポンスのボディは記録しない
+//
+// 永続性は出力先ごとに異なる
+//   - file: Record はエントリを fsync してから返る（グループコミットのため、同時に記録したエントリは1回の fsync で確定させる）
+//   - s3: Record はバッファに追加して返り、アップロードは一定間隔で行う。プロセスが異常終了した場合、アップロード前のエントリは失われる
+package journal
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+// GenesisHash は最初のエントリの PrevHash
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// journalEntriesTotal は記録したジャーナルのエントリ数（記録に失敗した場合は result="error"）
+var journalEntriesTotal = metrics.NewCounterVec(
+	"gateway_journal_entries_total",
+	"Number of request journal entries by result.",
+	"result",
+)
+
+// Entry はジャーナルの1件のエントリ
+type Entry struct {
+	Seq           uint64    `json:"seq"`
+	Time          time.Time `json:"time"`
+	RequestID     string    `json:"request_id,omitempty"`
+	Route         string    `json:"route"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Status        int       `json:"status"`
+	ClientIP      string    `json:"client_ip,omitempty"`
+	UserID        string    `json:"user_id,omitempty"`
+	ResponseBytes int64     `json:"response_bytes"`
+	DurationMS    int64     `json:"duration_ms"`
+	Backend       string    `json:"backend,omitempty"`
+	// PrevHash は直前のエントリの Hash（最初のエントリは GenesisHash）
+	PrevHash string `json:"prev_hash"`
+	// Hash は Hash 以外のフィールド（PrevHash を含む）のJSONのSHA-256
+	Hash string `json:"hash,omitempty"`
+}
+
+// computeHash はエントリのハッシュを計算する
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Sink はジャーナルの出力先
+type Sink interface {
+	// Last は最後に記録したエントリを返す（記録が無い場合は false）。再起動後にハッシュチェーンを継続するために使う
+	Last(ctx context.Context) (Entry, bool, error)
+	// Append はエントリ（line は改行で終わるJSON）を追記する。連番の順に1つずつ呼ぶ
+	Append(e Entry, line []byte) error
+	// Sync は連番 seq までに追記したエントリを永続化する。Append と並行して呼ばれる
+	Sync(seq uint64) error
+	// Close はバッファしたエントリを書き出して出力先を閉じる
+	Close() error
+}
+
+// Journal はハッシュチェーンでエントリを記録する
+type Journal struct {
+	mu       sync.Mutex
+	sink     Sink
+	seq      uint64
+	prevHash string
+}
+
+// Open は出力先の最後のエントリからハッシュチェーンを継続するJournalを作成する
+func Open(ctx context.Context, sink Sink) (*Journal, error) {
+	j := &Journal{sink: sink, prevHash: GenesisHash}
+	last, ok, err := sink.Last(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last journal entry: %w", err)
+	}
+	if ok {
+		j.seq = last.Seq
+		j.prevHash = last.Hash
+	}
+	return j, nil
+}
+
+// Record はエントリに連番とハッシュを付けて記録し、出力先で永続化されるまで待つ
+// 出力先への書き込みに失敗した場合は連番を進めず、エラーを返す
+// 永続化（Sync）はハッシュチェーンのロックの外で行うため、同時に記録するエントリは互いの永続化を待たずに追記できる
+func (j *Journal) Record(e Entry) error {
+	seq, err := j.append(e)
+	if err != nil {
+		journalEntriesTotal.With("error").Inc()
+		return err
+	}
+	// 追記したエントリはチェーンに含まれるため、永続化に失敗しても連番は戻さない
+	if err := j.sink.Sync(seq); err != nil {
+		journalEntriesTotal.With("error").Inc()
+		return fmt.Errorf("failed to sync journal entry: %w", err)
+	}
+	journalEntriesTotal.With("ok").Inc()
+	return nil
+}
+
+// append はエントリに連番とハッシュを付けて出力先に追記し、付けた連番を返す
+func (j *Journal) append(e Entry) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.Seq = j.seq + 1
+	e.PrevHash = j.prevHash
+	e.Hash = e.computeHash()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	if err := j.sink.Append(e, append(line, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to append journal entry: %w", err)
+	}
+
+	j.seq = e.Seq
+	j.prevHash = e.Hash
+	return e.Seq, nil
+}
+
+// Close は出力先を閉じる
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.sink.Close()
+}
+
+// Verify はJSON Linesのジャーナルのハッシュチェーンを検証し、検証したエントリ数を返す
+// 連番が1のエントリから始まる場合は PrevHash が GenesisHash であることも確認する
+// 途中から始まる場合（オブジェクトストレージの一部のオブジェクトなど）は最初のエントリの PrevHash を信頼する
+func Verify(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	count := 0
+	var prev Entry
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return count, fmt.Errorf("entry %d: invalid JSON: %w", count+1, err)
+		}
+		if e.Hash != e.computeHash() {
+			return count, fmt.Errorf("entry seq %d: hash mismatch", e.Seq)
+		}
+		switch {
+		case count == 0 && e.Seq == 1 && e.PrevHash != GenesisHash:
+			return count, fmt.Errorf("entry seq 1: prev_hash is not the genesis hash")
+		case count > 0 && e.Seq != prev.Seq+1:
+			return count, fmt.Errorf("entry seq %d: expected seq %d", e.Seq, prev.Seq+1)
+		case count > 0 && e.PrevHash != prev.Hash:
+			return count, fmt.Errorf("entry seq %d: prev_hash does not match the previous entry", e.Seq)
+		}
+		prev = e
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return count, nil
+}
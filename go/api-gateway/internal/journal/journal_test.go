@@ -0,0 +1,164 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingSink は Append が常に失敗する出力先
+type failingSink struct{}
+
+func (failingSink) Last(context.Context) (Entry, bool, error) { return Entry{}, false, nil }
+func (failingSink) Append(Entry, []byte) error                { return errors.New("disk full") }
+func (failingSink) Sync(uint64) error                         { return nil }
+func (failingSink) Close() error                              { return nil }
+
+func TestJournal_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	record := func(paths ...string) {
+		t.Helper()
+		sink, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile() error = %v", err)
+		}
+		j, err := Open(context.Background(), sink)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		for _, p := range paths {
+			if err := j.Record(Entry{Time: at, Route: "/api/v1/orders", Method: "GET", Path: p, Status: 200}); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+		}
+		if err := j.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	// 再起動しても前回の最後のエントリからハッシュチェーンを継続する
+	record("/api/v1/orders/1", "/api/v1/orders/2")
+	record("/api/v1/orders/3")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	count, err := Verify(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Verify() count = %d, want 3", count)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	tests := []struct {
+		name    string
+		journal string
+		wantErr string
+	}{
+		{name: "エントリの改ざん", journal: strings.Replace(string(data), "/api/v1/orders/2", "/api/v1/orders/9", 1), wantErr: "hash mismatch"},
+		{name: "エントリの削除", journal: lines[0] + lines[2], wantErr: "expected seq 2"},
+		{name: "エントリの入れ替え", journal: lines[1] + lines[0], wantErr: "expected seq 3"},
+		{name: "途中からの検証は最初のエントリを信頼する", journal: lines[1] + lines[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(strings.NewReader(tt.journal))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Verify() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSink_Last_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := os.WriteFile(path, []byte(`{"seq":1,"hash":"ab`), 0o600); err != nil {
+		t.Fatalf("failed to write journal: %v", err)
+	}
+	sink, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer sink.Close()
+
+	// 書き込み途中の行があるとチェーンを継続できない
+	if _, err := Open(context.Background(), sink); err == nil {
+		t.Error("Open() should fail with a truncated last entry")
+	}
+}
+
+func TestJournal_Record_AppendError(t *testing.T) {
+	j, err := Open(context.Background(), failingSink{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := j.Record(Entry{Route: "/api/v1/orders"}); err == nil {
+		t.Fatal("Record() should fail")
+	}
+	// 失敗したエントリで連番とハッシュを進めない
+	if j.seq != 0 || j.prevHash != GenesisHash {
+		t.Errorf("seq = %d, prevHash = %s, want 0 and genesis", j.seq, j.prevHash)
+	}
+}
+
+func TestJournal_Record_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	sink, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	j, err := Open(context.Background(), sink)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// fsync をまとめても、並行して記録したエントリは連番の順にチェーンになる
+	const records = 50
+	var wg sync.WaitGroup
+	for range records {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := j.Record(Entry{Route: "/api/v1/orders", Method: "GET", Path: "/api/v1/orders/1", Status: 200}); err != nil {
+				t.Errorf("Record() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Record が返った時点で fsync 済みのため、以降の Sync は fsync しない（閉じたファイルでも失敗しない）
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := sink.Sync(records); err != nil {
+		t.Errorf("Sync() after all records were synced error = %v", err)
+	}
+	if err := sink.Sync(records + 1); err == nil {
+		t.Error("Sync() of an entry that is not synced should fsync the closed file and fail")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	if count, err := Verify(bytes.NewReader(data)); err != nil || count != records {
+		t.Errorf("Verify() = %d, %v, want %d", count, err, records)
+	}
+}
@@ -0,0 +1,261 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"api-gateway/internal/transport"
+)
+
+const (
+	// DefaultFlushInterval はバッファしたエントリをアップロードする間隔のデフォルト値
+	DefaultFlushInterval = time.Minute
+	// DefaultMaxEntries は1つのオブジェクトに含めるエントリ数のデフォルト値
+	DefaultMaxEntries = 1000
+
+	// headObject は最後にアップロードしたエントリの連番とハッシュを保存するオブジェクト名
+	headObject = "HEAD.json"
+)
+
+// ObjectSinkConfig はS3互換のオブジェクトストレージの出力先の設定
+type ObjectSinkConfig struct {
+	// URL はバケットのURL（https://bucket.s3.ap-northeast-1.amazonaws.com など）
+	URL *url.URL
+	// Prefix はオブジェクト名の接頭辞（"journal/" など）
+	Prefix string
+	// Signer はリクエストにAWS SigV4で署名する
+	Signer *transport.AWSSigner
+	// LegalHold はアップロードしたオブジェクトに S3 Object Lock のリーガルホールドを設定するか
+	LegalHold bool
+	// FlushInterval はバッファしたエントリをアップロードする間隔（0は DefaultFlushInterval）
+	FlushInterval time.Duration
+	// MaxEntries は1つのオブジェクトに含める最大のエントリ数（0は DefaultMaxEntries）
+	MaxEntries int
+	// Client はアップロードに使うHTTPクライアント（nilの場合は http.DefaultClient）
+	Client *http.Client
+	// Logger はバックグラウンドでのアップロードの失敗を記録するロガー（nilの場合は slog.Default）
+	Logger *slog.Logger
+}
+
+// ObjectSink はエントリをバッファし、一定間隔または一定数ごとに1つのオブジェクトとしてアップロードする出力先
+// オブジェクト名は "<prefix><最初の連番>-<最後の連番>.jsonl" で、連番の順に連結すると1つのジャーナルになる
+// アップロードに失敗したエントリはバッファに残して次回に再試行する。プロセスが異常終了した場合、アップロード前のエントリは失われる
+type ObjectSink struct {
+	cfg ObjectSinkConfig
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	first uint64
+	last  Entry
+	count int
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// objectHead は HEAD.json の内容
+type objectHead struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// NewObjectSink は新しいObjectSinkを作成し、バックグラウンドでのアップロードを開始する
+func NewObjectSink(cfg ObjectSinkConfig) *ObjectSink {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	s := &ObjectSink{
+		cfg:   cfg,
+		flush: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// run は一定間隔、またはエントリ数が上限に達した時点でアップロードする
+func (s *ObjectSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flush:
+		case <-s.stop:
+			return
+		}
+		if err := s.Flush(context.Background()); err != nil {
+			s.cfg.Logger.Error("failed to upload journal entries", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Last は HEAD.json から最後にアップロードしたエントリの連番とハッシュを返す
+func (s *ObjectSink) Last(ctx context.Context) (Entry, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(headObject), nil)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Entry{}, false, nil
+	default:
+		return Entry{}, false, fmt.Errorf("unexpected status reading %s: %d", headObject, resp.StatusCode)
+	}
+
+	var head objectHead
+	if err := json.NewDecoder(resp.Body).Decode(&head); err != nil {
+		return Entry{}, false, fmt.Errorf("invalid %s: %w", headObject, err)
+	}
+	return Entry{Seq: head.Seq, Hash: head.Hash}, true, nil
+}
+
+// Append はエントリをバッファする
+func (s *ObjectSink) Append(e Entry, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count == 0 {
+		s.first = e.Seq
+	}
+	s.buf.Write(line)
+	s.last = e
+	s.count++
+
+	// アップロードに失敗してエントリが残っている場合に、追記のたびに再試行しないよう上限の倍数でのみ通知する
+	if s.count%s.cfg.MaxEntries == 0 {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Sync は何もしない（エントリは一定間隔または一定数ごとにアップロードし、リクエストごとには待たない）
+func (s *ObjectSink) Sync(uint64) error {
+	return nil
+}
+
+// Flush はバッファしたエントリを1つのオブジェクトとしてアップロードし、HEAD.json を更新する
+// アップロード中も Append できるよう、ロックはバッファの取り出しと削除の間だけ取る
+func (s *ObjectSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	if s.count == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	data := bytes.Clone(s.buf.Bytes())
+	first, last, count := s.first, s.last, s.count
+	s.mu.Unlock()
+
+	name := fmt.Sprintf("%020d-%020d.jsonl", first, last.Seq)
+	header := http.Header{"Content-Type": {"application/x-ndjson"}}
+	if s.cfg.LegalHold {
+		// Object Lock を使うリクエストには Content-MD5 が必要
+		sum := md5.Sum(data)
+		header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		header.Set("X-Amz-Object-Lock-Legal-Hold", "ON")
+	}
+	if err := s.put(ctx, name, data, header); err != nil {
+		return err
+	}
+
+	head, _ := json.Marshal(objectHead{Seq: last.Seq, Hash: last.Hash})
+	if err := s.put(ctx, headObject, head, http.Header{"Content-Type": {"application/json"}}); err != nil {
+		return err
+	}
+
+	// アップロードしたエントリをバッファから削除する（アップロード中に追加されたエントリは残す）
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Next(len(data))
+	s.count -= count
+	s.first = last.Seq + 1
+	return nil
+}
+
+// Close はバックグラウンドでのアップロードを止め、残りのエントリをアップロードする
+func (s *ObjectSink) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Flush(context.Background())
+}
+
+// put はオブジェクトをアップロードする
+func (s *ObjectSink) put(ctx context.Context, name string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := s.do(req, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status uploading %s: %d", name, resp.StatusCode)
+	}
+	return nil
+}
+
+// do はリクエストに署名して送信する
+func (s *ObjectSink) do(req *http.Request, body []byte) (*http.Response, error) {
+	if s.cfg.Signer != nil {
+		sum := sha256.Sum256(body)
+		if err := s.cfg.Signer.Sign(req.Context(), req, sum[:], time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to object storage: %w", err)
+	}
+	return resp, nil
+}
+
+// objectURL はオブジェクトのURLを返す
+func (s *ObjectSink) objectURL(name string) string {
+	u := *s.cfg.URL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Prefix + name
+	return u.String()
+}
@@ -0,0 +1,153 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"api-gateway/internal/transport"
+)
+
+// fakeBucket はPUTとGETだけに応答するS3互換のバケット
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+	fail    bool
+}
+
+func newFakeBucket(t *testing.T) (*fakeBucket, *url.URL) {
+	t.Helper()
+	b := &fakeBucket{objects: map[string][]byte{}, headers: map[string]http.Header{}}
+	server := httptest.NewServer(b)
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL + "/bucket")
+	return b, u
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r.Header.Get("Authorization") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if b.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		b.objects[r.URL.Path] = body
+		b.headers[r.URL.Path] = r.Header.Clone()
+	case http.MethodGet:
+		body, ok := b.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}
+}
+
+// names はアップロードしたジャーナルのオブジェクト名を返す
+func (b *fakeBucket) names() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for name := range b.objects {
+		if strings.HasSuffix(name, ".jsonl") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestObjectSink(t *testing.T) {
+	bucket, bucketURL := newFakeBucket(t)
+	signer := &transport.AWSSigner{
+		Service:     "s3",
+		Region:      "ap-northeast-1",
+		Credentials: transport.StaticAWSCredentials(transport.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}),
+	}
+	open := func() (*Journal, *ObjectSink) {
+		t.Helper()
+		sink := NewObjectSink(ObjectSinkConfig{
+			URL:           bucketURL,
+			Prefix:        "journal/",
+			Signer:        signer,
+			LegalHold:     true,
+			FlushInterval: time.Hour,
+		})
+		j, err := Open(context.Background(), sink)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		return j, sink
+	}
+	record := func(j *Journal, n int) {
+		t.Helper()
+		for range n {
+			if err := j.Record(Entry{Route: "/api/v1/orders", Method: "GET", Path: "/api/v1/orders", Status: 200}); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+		}
+	}
+
+	j, sink := open()
+	record(j, 2)
+
+	// アップロードに失敗したエントリはバッファに残り、次回にまとめてアップロードする
+	bucket.fail = true
+	if err := sink.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() should fail")
+	}
+	bucket.fail = false
+	record(j, 1)
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// 再起動しても HEAD.json からハッシュチェーンを継続する
+	j, _ = open()
+	record(j, 2)
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []string{
+		"/bucket/journal/00000000000000000001-00000000000000000003.jsonl",
+		"/bucket/journal/00000000000000000004-00000000000000000005.jsonl",
+	}
+	names := bucket.names()
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("objects = %v, want %v", names, want)
+	}
+
+	var journal bytes.Buffer
+	for _, name := range names {
+		journal.Write(bucket.objects[name])
+		header := bucket.headers[name]
+		if header.Get("X-Amz-Object-Lock-Legal-Hold") != "ON" || header.Get("Content-MD5") == "" {
+			t.Errorf("%s: legal hold headers = %v", name, header)
+		}
+	}
+	count, err := Verify(&journal)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Verify() count = %d, want 5", count)
+	}
+}
@@ -0,0 +1,74 @@
+// Package kubernetes はKubernetesの HTTPRoute（Gateway API）と Ingress を監視し、ゲートウェイのルートに変換する
+//
+// APIサーバの監視には client-go の informer を使う。ゲートウェイをクラスタ内のイングレスコントローラとして動かす場合に、
+// routing.source: kubernetes で使う
+//
+// HTTPRoute は Gateway API のCRDで client-go の型付きクライアントに含まれないため、両方のリソースを dynamic の informer で監視し、
+// 変換に使うフィールドだけを resources.go の型にデコードする。
+// 一覧の取得し直し（watch が 410 Gone で終了した場合など）と再接続は informer（Reflector）が行う
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// Pod にマウントされるサービスアカウントの認証情報
+const (
+	DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	DefaultCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// ClientConfig はAPIサーバへの接続の設定
+type ClientConfig struct {
+	// APIServer はAPIサーバのURL（空はPod内の環境変数 KUBERNETES_SERVICE_HOST / KUBERNETES_SERVICE_PORT）
+	APIServer string
+	// TokenFile はBearerトークンのファイル（空は DefaultTokenFile。ファイルが無い場合はトークンを送らない）
+	TokenFile string
+	// CAFile はAPIサーバの証明書を検証するCA証明書（空は DefaultCAFile。ファイルが無い場合はシステムの証明書）
+	CAFile string
+}
+
+// NewClient はAPIサーバの dynamic クライアントを作成する
+// トークンのファイルは client-go が定期的に読み直すため、プロジェクションされたトークンの更新にも追従する
+func NewClient(config ClientConfig) (dynamic.Interface, error) {
+	server := config.APIServer
+	if server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes api_server is not set and not running in a cluster")
+		}
+		server = "https://" + net.JoinHostPort(host, port)
+	}
+	if config.TokenFile == "" {
+		config.TokenFile = DefaultTokenFile
+	}
+	if config.CAFile == "" {
+		config.CAFile = DefaultCAFile
+	}
+
+	restConfig := &rest.Config{Host: server}
+	switch _, err := os.Stat(config.TokenFile); {
+	case err == nil:
+		restConfig.BearerTokenFile = config.TokenFile
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read kubernetes token: %w", err)
+	}
+	switch _, err := os.Stat(config.CAFile); {
+	case err == nil:
+		restConfig.CAFile = config.CAFile
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read kubernetes ca_file: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return client, nil
+}
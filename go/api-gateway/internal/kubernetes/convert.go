@@ -0,0 +1,393 @@
+package kubernetes
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"api-gateway/internal/config"
+)
+
+// ルートの設定を指定するアノテーション（HTTPRoute・Ingress 共通）
+const (
+	// AnnotationMiddleware はルーティング設定ファイルの middlewares に定義したミドルウェアの名前（カンマ区切り。記述した順に実行する）
+	AnnotationMiddleware = "api-gateway/middleware"
+	// AnnotationGroup はルートのグループ（リスナーの groups で公開するルートを分ける）
+	AnnotationGroup = "api-gateway/group"
+	// AnnotationTimeout はバックエンドのタイムアウト（"10s" のような期間。HTTPRoute は timeouts.request を優先する）
+	AnnotationTimeout = "api-gateway/timeout"
+)
+
+// DefaultClusterDomain はサービスのDNS名のドメインのデフォルト値
+const DefaultClusterDomain = "cluster.local"
+
+// DefaultTimeout はバックエンドのタイムアウトのデフォルト値
+const DefaultTimeout = 30 * time.Second
+
+// converter はリソースをルートの設定に変換する
+type converter struct {
+	clusterDomain string
+	timeout       time.Duration
+}
+
+// convertedRoute はリソースから変換したルートと、同じパスと条件のルートの優先順位を決める情報
+type convertedRoute struct {
+	route config.Route
+	// source は変換元のリソース（"HTTPRoute default/users"）
+	source string
+	// exact はパスの完全一致の条件から変換したか（前方一致より優先する）
+	exact bool
+	// created は変換元のリソースの作成時刻（古いリソースを優先する）
+	created time.Time
+}
+
+// httpRoute は HTTPRoute をルートに変換する
+// 変換できないルール（正規表現の条件、未対応のフィルターなど）は飛ばし、その理由をエラーとして返す
+func (c converter) httpRoute(r HTTPRoute) ([]convertedRoute, []error) {
+	source := "HTTPRoute " + r.Metadata.key()
+	hostnames := r.Spec.Hostnames
+	if len(hostnames) == 0 {
+		hostnames = []string{""}
+	}
+
+	var routes []convertedRoute
+	var errs []error
+	for i, rule := range r.Spec.Rules {
+		base, err := c.httpRouteRule(r.Metadata, rule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s rule %d: %w", source, i, err))
+			continue
+		}
+
+		matches := rule.Matches
+		if len(matches) == 0 {
+			matches = []HTTPRouteMatch{{}}
+		}
+		for _, match := range matches {
+			paths, exact, err := httpRoutePaths(match.Path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s rule %d: %w", source, i, err))
+				continue
+			}
+			headers, err := exactValues(match.Headers)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s rule %d headers: %w", source, i, err))
+				continue
+			}
+			query, err := exactValues(match.QueryParams)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s rule %d queryParams: %w", source, i, err))
+				continue
+			}
+
+			for _, hostname := range hostnames {
+				for _, path := range paths {
+					route := base
+					route.Path = path
+					if match.Method != "" {
+						route.Methods = []string{strings.ToUpper(match.Method)}
+					}
+					route.Match = newMatch(hostname, headers, query)
+					routes = append(routes, convertedRoute{route: route, source: source, exact: exact, created: r.Metadata.CreationTimestamp})
+				}
+			}
+		}
+	}
+	return routes, errs
+}
+
+// httpRouteRule はルールの転送先・タイムアウト・フィルターを設定したルートを返す（パスと条件は呼び出し元で設定する）
+func (c converter) httpRouteRule(meta ObjectMeta, rule HTTPRouteRule) (config.Route, error) {
+	route, err := c.newRoute(meta)
+	if err != nil {
+		return config.Route{}, err
+	}
+
+	var refs []HTTPBackendRef
+	for _, ref := range rule.BackendRefs {
+		if (ref.Group != "" && ref.Group != "core") || (ref.Kind != "" && ref.Kind != "Service") {
+			return config.Route{}, fmt.Errorf("unsupported backendRef kind %s/%s", ref.Group, ref.Kind)
+		}
+		if ref.Port == 0 {
+			return config.Route{}, fmt.Errorf("backendRef %s requires port", ref.Name)
+		}
+		if ref.Weight == nil || *ref.Weight > 0 {
+			refs = append(refs, ref)
+		}
+	}
+	switch len(refs) {
+	case 0:
+		return config.Route{}, fmt.Errorf("no backendRefs with weight")
+	case 1:
+	case 2:
+		// 2つ目の Service をカナリアとして重みの割合で振り分ける
+		primary, canary := backendWeight(refs[0]), backendWeight(refs[1])
+		route.Canary = &config.CanaryConfig{
+			URL:    c.serviceURL(cmp.Or(refs[1].Namespace, meta.Namespace), refs[1].Name, refs[1].Port),
+			Weight: math.Round(float64(canary)*10000/float64(primary+canary)) / 100,
+		}
+	default:
+		return config.Route{}, fmt.Errorf("at most 2 backendRefs are supported")
+	}
+	route.Backend.URL = c.serviceURL(cmp.Or(refs[0].Namespace, meta.Namespace), refs[0].Name, refs[0].Port)
+
+	if rule.Timeouts != nil && rule.Timeouts.Request != "" {
+		timeout, err := time.ParseDuration(rule.Timeouts.Request)
+		if err != nil || timeout < 0 {
+			return config.Route{}, fmt.Errorf("invalid timeouts.request: %q", rule.Timeouts.Request)
+		}
+		route.Backend.Timeout = timeout
+	}
+
+	for _, filter := range rule.Filters {
+		switch {
+		case filter.Type == "RequestHeaderModifier" && filter.RequestHeaderModifier != nil:
+			route.RequestHeaders = headerRules(filter.RequestHeaderModifier)
+		case filter.Type == "ResponseHeaderModifier" && filter.ResponseHeaderModifier != nil:
+			route.ResponseHeaders = headerRules(filter.ResponseHeaderModifier)
+		default:
+			return config.Route{}, fmt.Errorf("unsupported filter %s", filter.Type)
+		}
+	}
+	return route, nil
+}
+
+// ingress は Ingress をルートに変換する（defaultBackend は全てのパスへの前方一致のルートになる）
+// 変換できないパス（名前で指定したポートなど）は飛ばし、その理由をエラーとして返す
+func (c converter) ingress(in Ingress) ([]convertedRoute, []error) {
+	source := "Ingress " + in.Metadata.key()
+
+	type ingressPath struct {
+		host string
+		path IngressPath
+	}
+	var paths []ingressPath
+	if in.Spec.DefaultBackend != nil {
+		paths = append(paths, ingressPath{path: IngressPath{Path: "/", PathType: "Prefix", Backend: *in.Spec.DefaultBackend}})
+	}
+	for _, rule := range in.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			paths = append(paths, ingressPath{host: rule.Host, path: path})
+		}
+	}
+
+	var routes []convertedRoute
+	var errs []error
+	for _, p := range paths {
+		route, err := c.newRoute(in.Metadata)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		service := p.path.Backend.Service
+		if service == nil {
+			errs = append(errs, fmt.Errorf("%s path %s: only service backends are supported", source, p.path.Path))
+			continue
+		}
+		if service.Port.Number == 0 {
+			errs = append(errs, fmt.Errorf("%s path %s: service port must be a number", source, p.path.Path))
+			continue
+		}
+		route.Backend.URL = c.serviceURL(in.Metadata.Namespace, service.Name, service.Port.Number)
+
+		exact := p.path.PathType == "Exact"
+		routePaths, err := routePaths(cmp.Or(p.path.Path, "/"), exact)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		for _, path := range routePaths {
+			route := route
+			route.Path = path
+			route.Match = newMatch(p.host, nil, nil)
+			routes = append(routes, convertedRoute{route: route, source: source, exact: exact, created: in.Metadata.CreationTimestamp})
+		}
+	}
+	return routes, errs
+}
+
+// newRoute はリソースのアノテーションからミドルウェア・グループ・タイムアウトを設定したルートを返す
+func (c converter) newRoute(meta ObjectMeta) (config.Route, error) {
+	route := config.Route{
+		Group:   meta.Annotations[AnnotationGroup],
+		Backend: config.BackendConfig{Timeout: c.timeout},
+	}
+	if value, ok := meta.Annotations[AnnotationTimeout]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return config.Route{}, fmt.Errorf("invalid %s annotation: %q", AnnotationTimeout, value)
+		}
+		route.Backend.Timeout = timeout
+	}
+	if value := meta.Annotations[AnnotationMiddleware]; value != "" {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				route.Middleware = append(route.Middleware, config.MiddlewareRef(name))
+			}
+		}
+	}
+	return route, nil
+}
+
+// serviceURL は Service のクラスタ内のURLを返す
+func (c converter) serviceURL(namespace, name string, port int) string {
+	return fmt.Sprintf("http://%s.%s.svc.%s:%d", name, namespace, c.clusterDomain, port)
+}
+
+// httpRoutePaths は HTTPRoute のパスの条件をルートのパスに変換する（省略時は "/" への前方一致）
+func httpRoutePaths(match *HTTPPathMatch) ([]string, bool, error) {
+	if match == nil {
+		match = &HTTPPathMatch{}
+	}
+	switch cmp.Or(match.Type, "PathPrefix") {
+	case "PathPrefix":
+		paths, err := routePaths(cmp.Or(match.Value, "/"), false)
+		return paths, false, err
+	case "Exact":
+		paths, err := routePaths(cmp.Or(match.Value, "/"), true)
+		return paths, true, err
+	default:
+		return nil, false, fmt.Errorf("unsupported path match type %s", match.Type)
+	}
+}
+
+// routePaths はパスを完全一致または前方一致（パスのセグメント単位）のルートのパスに変換する
+// 前方一致の "/api" は "/api" と "/api/**" の2つのルートになる
+func routePaths(path string, exact bool) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path must start with /: %q", path)
+	}
+	for segment := range strings.SplitSeq(path, "/") {
+		// ルートのパスではパラメータやワイルドカードとして扱われるため変換できない
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") || strings.HasPrefix(segment, "{") {
+			return nil, fmt.Errorf("unsupported path segment %q in %s", segment, path)
+		}
+	}
+	if exact {
+		return []string{path}, nil
+	}
+	prefix := strings.TrimSuffix(path, "/")
+	return []string{cmp.Or(prefix, "/"), prefix + "/**"}, nil
+}
+
+// exactValues は完全一致のヘッダー・クエリパラメータの条件を名前と値に変換する
+func exactValues(matches []HTTPValueMatch) (map[string]string, error) {
+	values := make(map[string]string, len(matches))
+	for _, m := range matches {
+		if m.Type != "" && m.Type != "Exact" {
+			return nil, fmt.Errorf("unsupported match type %s for %s", m.Type, m.Name)
+		}
+		values[m.Name] = m.Value
+	}
+	return values, nil
+}
+
+// newMatch はホスト・ヘッダー・クエリパラメータの条件を返す（条件が無い場合は nil）
+func newMatch(host string, headers, query map[string]string) *config.MatchConfig {
+	if host == "" && len(headers) == 0 && len(query) == 0 {
+		return nil
+	}
+	return &config.MatchConfig{Host: host, Headers: headers, Query: query}
+}
+
+// headerRules はヘッダーの変換をルートのヘッダーの変換ルールに変換する
+func headerRules(modifier *HTTPHeaderModifier) config.HeaderRulesConfig {
+	rules := config.HeaderRulesConfig{Remove: modifier.Remove}
+	for _, h := range modifier.Set {
+		if rules.Set == nil {
+			rules.Set = make(map[string]string)
+		}
+		rules.Set[h.Name] = h.Value
+	}
+	for _, h := range modifier.Add {
+		if rules.Add == nil {
+			rules.Add = make(map[string]string)
+		}
+		rules.Add[h.Name] = h.Value
+	}
+	return rules
+}
+
+// backendWeight は backendRef の重みを返す（省略時は1）
+func backendWeight(ref HTTPBackendRef) int {
+	if ref.Weight == nil {
+		return 1
+	}
+	return *ref.Weight
+}
+
+// mergeRoutes は base のルートに変換したルートを加えたルーティング設定を返す
+// 同じパスと条件のルートは base、完全一致、作成の古いリソースの順に優先し、転送先が同じ場合はメソッドをまとめる
+// 優先されなかったルートと、ミドルウェアの参照を展開できないルートは飛ばし、その理由をエラーとして返す
+func mergeRoutes(base *config.RoutingFileConfig, converted []convertedRoute) (*config.RoutingFileConfig, []error) {
+	slices.SortStableFunc(converted, func(a, b convertedRoute) int {
+		if a.exact != b.exact {
+			if a.exact {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(a.created.Compare(b.created), cmp.Compare(a.source, b.source))
+	})
+
+	merged := &config.RoutingFileConfig{
+		Middlewares: base.Middlewares,
+		Routes:      slices.Clone(base.Routes),
+		OpenAPI:     base.OpenAPI,
+	}
+	owners := make(map[string]string, len(base.Routes)+len(converted))
+	for _, route := range base.Routes {
+		owners[routeKey(route)] = "routing config file"
+	}
+
+	var errs []error
+	for _, c := range converted {
+		middleware, err := base.ResolveMiddleware(c.route.Middleware)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.source, err))
+			continue
+		}
+		c.route.Middleware = middleware
+
+		key := routeKey(c.route)
+		owner, ok := owners[key]
+		if !ok {
+			owners[key] = c.source
+			merged.Routes = append(merged.Routes, c.route)
+			continue
+		}
+		// 同じリソースの同じ転送先へのメソッド違いのルートはまとめる
+		i := slices.IndexFunc(merged.Routes, func(r config.Route) bool { return routeKey(r) == key })
+		existing := &merged.Routes[i]
+		if owner == c.source && sameBackend(*existing, c.route) {
+			if len(existing.Methods) > 0 && len(c.route.Methods) > 0 {
+				existing.Methods = slices.Concat(existing.Methods, c.route.Methods)
+			} else {
+				existing.Methods = nil
+			}
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: route %s conflicts with %s", c.source, key, owner))
+	}
+	return merged, errs
+}
+
+// routeKey は同じリクエストにマッチするルートを識別するパスと条件
+func routeKey(route config.Route) string {
+	if match := route.Match.String(); match != "" {
+		return route.Path + " " + match
+	}
+	return route.Path
+}
+
+// sameBackend はルートの転送先と変換した設定が同じか確認する
+func sameBackend(a, b config.Route) bool {
+	return a.Backend.URL == b.Backend.URL && a.Backend.Timeout == b.Backend.Timeout &&
+		(a.Canary == nil) == (b.Canary == nil) && (a.Canary == nil || *a.Canary == *b.Canary)
+}
@@ -0,0 +1,235 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+)
+
+func TestConverter_HTTPRoute(t *testing.T) {
+	c := converter{clusterDomain: DefaultClusterDomain, timeout: DefaultTimeout}
+
+	tests := []struct {
+		name string
+		// route は HTTPRoute のJSON
+		route     string
+		wantPaths []string
+		wantErrs  int
+		check     func(t *testing.T, routes []config.Route)
+	}{
+		{
+			name: "前方一致とメソッド",
+			route: `{"metadata": {"name": "users", "namespace": "apps"}, "spec": {"rules": [
+				{"matches": [{"path": {"type": "PathPrefix", "value": "/api/users/"}, "method": "get"}],
+				 "backendRefs": [{"name": "user-service", "port": 8080}]}]}}`,
+			wantPaths: []string{"/api/users", "/api/users/**"},
+			check: func(t *testing.T, routes []config.Route) {
+				if routes[0].Backend.URL != "http://user-service.apps.svc.cluster.local:8080" {
+					t.Errorf("Backend.URL = %q", routes[0].Backend.URL)
+				}
+				if routes[0].Backend.Timeout != DefaultTimeout {
+					t.Errorf("Backend.Timeout = %v, want default", routes[0].Backend.Timeout)
+				}
+				if !slices.Equal(routes[0].Methods, []string{"GET"}) {
+					t.Errorf("Methods = %v, want [GET]", routes[0].Methods)
+				}
+			},
+		},
+		{
+			name: "条件を省略すると全てのパス",
+			route: `{"metadata": {"name": "web", "namespace": "apps"}, "spec": {"rules": [
+				{"backendRefs": [{"name": "web", "port": 80}]}]}}`,
+			wantPaths: []string{"/", "/**"},
+		},
+		{
+			name: "ホスト名・ヘッダー・クエリパラメータの条件",
+			route: `{"metadata": {"name": "v2", "namespace": "apps"}, "spec": {
+				"hostnames": ["api.example.com", "*.example.org"],
+				"rules": [{"matches": [{"path": {"type": "Exact", "value": "/health"},
+					"headers": [{"name": "X-Version", "value": "2"}], "queryParams": [{"name": "debug", "value": "1"}]}],
+					"backendRefs": [{"name": "api", "port": 80}]}]}}`,
+			wantPaths: []string{"/health", "/health"},
+			check: func(t *testing.T, routes []config.Route) {
+				if got := routes[0].Match.String(); got != "api.example.com [X-Version: 2] ?debug=1" {
+					t.Errorf("Match = %q", got)
+				}
+				if routes[1].Match.Host != "*.example.org" {
+					t.Errorf("Match.Host = %q", routes[1].Match.Host)
+				}
+			},
+		},
+		{
+			name: "2つの転送先はカナリア",
+			route: `{"metadata": {"name": "orders", "namespace": "apps"}, "spec": {"rules": [
+				{"matches": [{"path": {"type": "Exact", "value": "/orders"}}],
+				 "backendRefs": [{"name": "orders", "port": 80, "weight": 90}, {"name": "orders-canary", "namespace": "canary", "port": 80, "weight": 10}],
+				 "timeouts": {"request": "5s"}}]}}`,
+			wantPaths: []string{"/orders"},
+			check: func(t *testing.T, routes []config.Route) {
+				canary := routes[0].Canary
+				if canary == nil || canary.URL != "http://orders-canary.canary.svc.cluster.local:80" || canary.Weight != 10 {
+					t.Errorf("Canary = %+v", canary)
+				}
+				if routes[0].Backend.Timeout != 5*time.Second {
+					t.Errorf("Backend.Timeout = %v, want 5s", routes[0].Backend.Timeout)
+				}
+			},
+		},
+		{
+			name: "ヘッダーの変換とアノテーション",
+			route: `{"metadata": {"name": "admin", "namespace": "apps", "annotations": {
+					"api-gateway/middleware": "auth, ratelimit", "api-gateway/group": "internal", "api-gateway/timeout": "2s"}},
+				"spec": {"rules": [{"matches": [{"path": {"type": "Exact", "value": "/admin"}}],
+					"filters": [{"type": "RequestHeaderModifier", "requestHeaderModifier": {"set": [{"name": "X-Env", "value": "prod"}], "remove": ["Cookie"]}}],
+					"backendRefs": [{"name": "admin", "port": 80}]}]}}`,
+			wantPaths: []string{"/admin"},
+			check: func(t *testing.T, routes []config.Route) {
+				route := routes[0]
+				if route.Group != "internal" || route.Backend.Timeout != 2*time.Second || len(route.Middleware) != 2 {
+					t.Errorf("route = %+v", route)
+				}
+				if route.RequestHeaders.Set["X-Env"] != "prod" || !slices.Equal(route.RequestHeaders.Remove, []string{"Cookie"}) {
+					t.Errorf("RequestHeaders = %+v", route.RequestHeaders)
+				}
+			},
+		},
+		{
+			name: "変換できないルールは飛ばす",
+			route: `{"metadata": {"name": "mixed", "namespace": "apps"}, "spec": {"rules": [
+				{"matches": [{"path": {"type": "RegularExpression", "value": "/v[0-9]+"}}], "backendRefs": [{"name": "api", "port": 80}]},
+				{"filters": [{"type": "RequestMirror"}], "backendRefs": [{"name": "api", "port": 80}]},
+				{"backendRefs": [{"name": "api"}]},
+				{"matches": [{"path": {"type": "Exact", "value": "/ok"}}], "backendRefs": [{"name": "api", "port": 80}]}]}}`,
+			wantPaths: []string{"/ok"},
+			wantErrs:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r HTTPRoute
+			if err := json.Unmarshal([]byte(tt.route), &r); err != nil {
+				t.Fatalf("invalid test route: %v", err)
+			}
+			converted, errs := c.httpRoute(r)
+			if len(errs) != tt.wantErrs {
+				t.Errorf("errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+			routes := make([]config.Route, len(converted))
+			paths := make([]string, len(converted))
+			for i, c := range converted {
+				routes[i], paths[i] = c.route, c.route.Path
+			}
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Fatalf("paths = %v, want %v", paths, tt.wantPaths)
+			}
+			if tt.check != nil {
+				tt.check(t, routes)
+			}
+		})
+	}
+}
+
+func TestConverter_Ingress(t *testing.T) {
+	c := converter{clusterDomain: "example.internal", timeout: 10 * time.Second}
+
+	var in Ingress
+	err := json.Unmarshal([]byte(`{"metadata": {"name": "shop", "namespace": "web"}, "spec": {
+		"defaultBackend": {"service": {"name": "frontend", "port": {"number": 80}}},
+		"rules": [{"host": "shop.example.com", "http": {"paths": [
+			{"path": "/cart", "pathType": "Exact", "backend": {"service": {"name": "cart", "port": {"number": 8080}}}},
+			{"path": "/api", "pathType": "Prefix", "backend": {"service": {"name": "api", "port": {"number": 8080}}}},
+			{"path": "/named", "pathType": "Prefix", "backend": {"service": {"name": "api", "port": {"name": "http"}}}}
+		]}}]}}`), &in)
+	if err != nil {
+		t.Fatalf("invalid test ingress: %v", err)
+	}
+
+	converted, errs := c.ingress(in)
+	// 名前で指定したポートは変換できない
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want 1 error", errs)
+	}
+
+	type result struct{ path, host, url string }
+	var got []result
+	for _, r := range converted {
+		got = append(got, result{r.route.Path, r.route.Match.String(), r.route.Backend.URL})
+	}
+	want := []result{
+		{"/", "", "http://frontend.web.svc.example.internal:80"},
+		{"/**", "", "http://frontend.web.svc.example.internal:80"},
+		{"/cart", "shop.example.com", "http://cart.web.svc.example.internal:8080"},
+		{"/api", "shop.example.com", "http://api.web.svc.example.internal:8080"},
+		{"/api/**", "shop.example.com", "http://api.web.svc.example.internal:8080"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+	if !converted[2].exact || converted[3].exact {
+		t.Error("Exact paths should be marked exact")
+	}
+}
+
+func TestMergeRoutes(t *testing.T) {
+	base := &config.RoutingFileConfig{
+		Middlewares: map[string]config.MiddlewareConfig{"auth": {Type: "jwt"}},
+		Routes:      []config.Route{{Path: "/health", Backend: config.BackendConfig{URL: "http://localhost:9000"}}},
+	}
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	route := func(path, url string, methods ...string) config.Route {
+		return config.Route{Path: path, Methods: methods, Backend: config.BackendConfig{URL: url}}
+	}
+
+	withAuth := route("/secure", "http://secure")
+	withAuth.Middleware = []config.MiddlewareConfig{config.MiddlewareRef("auth")}
+	withUnknown := route("/broken", "http://broken")
+	withUnknown.Middleware = []config.MiddlewareConfig{config.MiddlewareRef("missing")}
+
+	merged, errs := mergeRoutes(base, []convertedRoute{
+		// ルーティング設定ファイルのルートが優先される
+		{route: route("/health", "http://k8s"), source: "HTTPRoute apps/health", created: older},
+		// 新しいリソースより古いリソースが優先される
+		{route: route("/orders", "http://new"), source: "HTTPRoute apps/new", created: newer},
+		{route: route("/orders", "http://old"), source: "HTTPRoute apps/old", created: older},
+		// 前方一致より完全一致が優先される
+		{route: route("/items", "http://prefix"), source: "Ingress apps/prefix", created: older},
+		{route: route("/items", "http://exact"), source: "Ingress apps/exact", exact: true, created: newer},
+		// 同じリソースの同じ転送先へのルートはメソッドをまとめる
+		{route: route("/users", "http://users", "GET"), source: "HTTPRoute apps/users", created: older},
+		{route: route("/users", "http://users", "POST"), source: "HTTPRoute apps/users", created: older},
+		{route: withAuth, source: "HTTPRoute apps/secure", created: older},
+		{route: withUnknown, source: "HTTPRoute apps/broken", created: older},
+	})
+
+	// 競合した3つのルートと、参照できないミドルウェアのルート
+	if len(errs) != 4 {
+		t.Errorf("errors = %v, want 4 errors", errs)
+	}
+
+	got := make(map[string]config.Route, len(merged.Routes))
+	for _, r := range merged.Routes {
+		got[r.Path] = r
+	}
+	if len(got) != 5 {
+		t.Fatalf("routes = %+v, want 5 routes", merged.Routes)
+	}
+	for path, url := range map[string]string{"/health": "http://localhost:9000", "/orders": "http://old", "/items": "http://exact"} {
+		if got[path].Backend.URL != url {
+			t.Errorf("%s backend = %q, want %q", path, got[path].Backend.URL, url)
+		}
+	}
+	if !slices.Equal(got["/users"].Methods, []string{"GET", "POST"}) {
+		t.Errorf("/users methods = %v, want [GET POST]", got["/users"].Methods)
+	}
+	if middleware := got["/secure"].Middleware; len(middleware) != 1 || middleware[0].Type != "jwt" {
+		t.Errorf("/secure middleware = %+v, want resolved auth", middleware)
+	}
+	if merged.Middlewares["auth"].Type != "jwt" {
+		t.Error("middlewares should be kept from the base config")
+	}
+}
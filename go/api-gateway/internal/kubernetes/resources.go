@@ -0,0 +1,157 @@
+package kubernetes
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// 監視するリソースの GroupVersionResource
+var (
+	httpRouteResource = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+	ingressResource   = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+)
+
+// 変換するリソースの種類（設定の resources の値）
+const (
+	ResourceHTTPRoute = "httproute"
+	ResourceIngress   = "ingress"
+)
+
+// ObjectMeta はリソースのメタデータのうち使用するフィールド
+type ObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	ResourceVersion   string            `json:"resourceVersion"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+}
+
+// key はリソースを識別する "<名前空間>/<名前>"
+func (m ObjectMeta) key() string {
+	return m.Namespace + "/" + m.Name
+}
+
+// HTTPRoute は Gateway API の HTTPRoute（gateway.networking.k8s.io/v1）のうち変換に使うフィールド
+type HTTPRoute struct {
+	Metadata ObjectMeta    `json:"metadata"`
+	Spec     HTTPRouteSpec `json:"spec"`
+}
+
+// HTTPRouteSpec は HTTPRoute の spec
+type HTTPRouteSpec struct {
+	ParentRefs []ParentReference `json:"parentRefs,omitempty"`
+	Hostnames  []string          `json:"hostnames,omitempty"`
+	Rules      []HTTPRouteRule   `json:"rules,omitempty"`
+}
+
+// ParentReference は HTTPRoute を割り当てる Gateway
+type ParentReference struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// HTTPRouteRule は HTTPRoute のルール
+type HTTPRouteRule struct {
+	Matches     []HTTPRouteMatch  `json:"matches,omitempty"`
+	Filters     []HTTPRouteFilter `json:"filters,omitempty"`
+	BackendRefs []HTTPBackendRef  `json:"backendRefs,omitempty"`
+	Timeouts    *HTTPRouteTimeout `json:"timeouts,omitempty"`
+}
+
+// HTTPRouteMatch はルールにマッチするリクエストの条件
+type HTTPRouteMatch struct {
+	Path        *HTTPPathMatch   `json:"path,omitempty"`
+	Headers     []HTTPValueMatch `json:"headers,omitempty"`
+	QueryParams []HTTPValueMatch `json:"queryParams,omitempty"`
+	Method      string           `json:"method,omitempty"`
+}
+
+// HTTPPathMatch はパスの条件（type は Exact, PathPrefix, RegularExpression）
+type HTTPPathMatch struct {
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// HTTPValueMatch はヘッダーとクエリパラメータの条件（type は Exact, RegularExpression）
+type HTTPValueMatch struct {
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HTTPRouteFilter はルールのフィルター（ヘッダーの変換のみ変換する）
+type HTTPRouteFilter struct {
+	Type                   string              `json:"type"`
+	RequestHeaderModifier  *HTTPHeaderModifier `json:"requestHeaderModifier,omitempty"`
+	ResponseHeaderModifier *HTTPHeaderModifier `json:"responseHeaderModifier,omitempty"`
+}
+
+// HTTPHeaderModifier はヘッダーの変換
+type HTTPHeaderModifier struct {
+	Set    []HTTPHeader `json:"set,omitempty"`
+	Add    []HTTPHeader `json:"add,omitempty"`
+	Remove []string     `json:"remove,omitempty"`
+}
+
+// HTTPHeader はヘッダーの名前と値
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HTTPBackendRef はルールの転送先の Service
+type HTTPBackendRef struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Port      int    `json:"port,omitempty"`
+	// Weight は転送する割合の重み（省略時は1）
+	Weight *int `json:"weight,omitempty"`
+}
+
+// HTTPRouteTimeout はルールのタイムアウト（"10s" のような期間）
+type HTTPRouteTimeout struct {
+	Request string `json:"request,omitempty"`
+}
+
+// Ingress は Ingress（networking.k8s.io/v1）のうち変換に使うフィールド
+type Ingress struct {
+	Metadata ObjectMeta  `json:"metadata"`
+	Spec     IngressSpec `json:"spec"`
+}
+
+// IngressSpec は Ingress の spec
+type IngressSpec struct {
+	IngressClassName *string         `json:"ingressClassName,omitempty"`
+	DefaultBackend   *IngressBackend `json:"defaultBackend,omitempty"`
+	Rules            []IngressRule   `json:"rules,omitempty"`
+}
+
+// IngressRule はホストごとのルール
+type IngressRule struct {
+	Host string `json:"host,omitempty"`
+	HTTP *struct {
+		Paths []IngressPath `json:"paths"`
+	} `json:"http,omitempty"`
+}
+
+// IngressPath はパスごとの転送先（pathType は Exact, Prefix, ImplementationSpecific）
+type IngressPath struct {
+	Path     string         `json:"path,omitempty"`
+	PathType string         `json:"pathType"`
+	Backend  IngressBackend `json:"backend"`
+}
+
+// IngressBackend は転送先の Service
+type IngressBackend struct {
+	Service *struct {
+		Name string `json:"name"`
+		Port struct {
+			Name   string `json:"name,omitempty"`
+			Number int    `json:"number,omitempty"`
+		} `json:"port"`
+	} `json:"service,omitempty"`
+}
@@ -0,0 +1,308 @@
+package kubernetes
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"api-gateway/internal/config"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultIngressClass は読み込む Ingress のクラスのデフォルト値
+const DefaultIngressClass = "api-gateway"
+
+// ingressClassAnnotation は spec.ingressClassName の代わりにクラスを指定する旧来のアノテーション
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+// updateDelay は変更を受け取ってからルートを組み立て直すまでの待ち時間（連続した変更をまとめて反映する）
+var updateDelay = 500 * time.Millisecond
+
+// SourceConfig はKubernetesのリソースからルートを読み込む設定
+type SourceConfig struct {
+	Client dynamic.Interface
+	// Namespace は監視する名前空間（空は全ての名前空間）
+	Namespace string
+	// Resources は監視するリソース（ResourceHTTPRoute, ResourceIngress。空は両方）
+	Resources []string
+	// GatewayName は HTTPRoute の parentRefs で参照されている場合のみ読み込む Gateway の名前（空は全ての HTTPRoute）
+	GatewayName string
+	// IngressClass は読み込む Ingress のクラス（空は DefaultIngressClass）
+	IngressClass string
+	// ClusterDomain はサービスのDNS名のドメイン（空は DefaultClusterDomain）
+	ClusterDomain string
+	// Timeout はバックエンドのタイムアウトのデフォルト（0は DefaultTimeout）
+	Timeout time.Duration
+	// Base はリソースから変換したルートに加えるルーティング設定（middlewares はアノテーションから名前で参照できる）
+	Base   *config.RoutingFileConfig
+	Logger *slog.Logger
+}
+
+// Source は HTTPRoute・Ingress を informer で監視し、ルーティング設定に変換する
+type Source struct {
+	config    SourceConfig
+	converter converter
+
+	mu sync.Mutex
+	// routes はリソースの種類ごとの、リソース（"<名前空間>/<名前>"）から変換したルート
+	routes map[string]map[string][]convertedRoute
+	// stopInformers は Load で開始した informer を停止する（開始していない場合は nil）
+	stopInformers context.CancelFunc
+	// onUpdate は Watch で指定した、組み立て直したルーティング設定を受け取る関数
+	onUpdate func(*config.RoutingFileConfig)
+	// pending は組み立て直しを予約したタイマー（予約していない場合は nil）
+	pending *time.Timer
+	// stale は Watch の前に受け取った変更があり、組み立て直す必要があるか
+	stale bool
+
+	// updateMu は onUpdate を同時に呼ばないようにする
+	updateMu sync.Mutex
+}
+
+// NewSource は新しいSourceを作成する
+func NewSource(cfg SourceConfig) *Source {
+	if len(cfg.Resources) == 0 {
+		cfg.Resources = []string{ResourceHTTPRoute, ResourceIngress}
+	}
+	if cfg.IngressClass == "" {
+		cfg.IngressClass = DefaultIngressClass
+	}
+	if cfg.Base == nil {
+		cfg.Base = &config.RoutingFileConfig{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &Source{
+		config: cfg,
+		converter: converter{
+			clusterDomain: cmp.Or(cfg.ClusterDomain, DefaultClusterDomain),
+			timeout:       cmp.Or(cfg.Timeout, DefaultTimeout),
+		},
+		routes: make(map[string]map[string][]convertedRoute),
+	}
+}
+
+// Load はリソースの informer を開始し、最初の一覧を変換したルートを加えたルーティング設定を返す
+// 一覧を取得できなかった場合は informer を停止してエラーを返す（再試行は呼び出し側に任せる）
+func (s *Source) Load(ctx context.Context) (*config.RoutingFileConfig, error) {
+	s.stop()
+
+	// 一覧の取得や監視に失敗すると informer が呼ぶため、最初の一覧を待つ間に失敗した場合は待つのをやめる
+	loadCtx, cancelLoad := context.WithCancelCause(ctx)
+	defer cancelLoad(nil)
+
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(s.config.Client, 0, s.config.Namespace, nil)
+	routes := make(map[string]map[string][]convertedRoute, len(s.config.Resources))
+	var synced []cache.InformerSynced
+	for _, resource := range s.config.Resources {
+		informer := factory.ForResource(groupVersionResource(resource)).Informer()
+		informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+			cancelLoad(err)
+			s.config.Logger.Warn("Failed to watch kubernetes resources",
+				slog.String("resource", resource),
+				slog.String("error", err.Error()))
+		})
+		// 停止した以前の informer が変更を通知しても反映しないよう、informer ごとに保持するルートを分ける
+		routes[resource] = make(map[string][]convertedRoute)
+		registration, err := informer.AddEventHandler(s.handler(resource, routes[resource]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch kubernetes %s: %w", resource, err)
+		}
+		synced = append(synced, registration.HasSynced)
+	}
+
+	informerCtx, stopInformers := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.routes = routes
+	s.stopInformers = stopInformers
+	s.mu.Unlock()
+	factory.Start(informerCtx.Done())
+
+	if !cache.WaitForCacheSync(loadCtx.Done(), synced...) {
+		s.stop()
+		return nil, fmt.Errorf("failed to list kubernetes resources: %w", context.Cause(loadCtx))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = false
+	return s.build(), nil
+}
+
+// Watch は Load で取得した一覧以降の変更の反映を開始する
+// 変更を受け取ると、まとめてルートを組み立て直して onUpdate を呼ぶ（同時には呼ばない）。ctx がキャンセルされると informer を停止する
+func (s *Source) Watch(ctx context.Context, onUpdate func(*config.RoutingFileConfig)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = onUpdate
+	if s.stale {
+		s.scheduleUpdate()
+	}
+	go func() {
+		<-ctx.Done()
+		s.stop()
+	}()
+}
+
+// stop は Load で開始した informer を停止する
+func (s *Source) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopInformers != nil {
+		s.stopInformers()
+		s.stopInformers = nil
+	}
+}
+
+// handler は resource の informer が通知する変更を routes に反映する
+func (s *Source) handler(resource string, routes map[string][]convertedRoute) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { s.apply(resource, routes, obj, false) },
+		UpdateFunc: func(_, obj any) { s.apply(resource, routes, obj, false) },
+		DeleteFunc: func(obj any) { s.apply(resource, routes, obj, true) },
+	}
+}
+
+// apply は informer が通知したリソースを変換し、保持しているルートに反映する
+func (s *Source) apply(resource string, routes map[string][]convertedRoute, obj any, deleted bool) {
+	// 削除を見逃して一覧を取得し直した場合は、最後に知っていた状態で通知される
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, isObject := obj.(*unstructured.Unstructured)
+	if !isObject {
+		return
+	}
+	key := u.GetNamespace() + "/" + u.GetName()
+
+	converted, ok := []convertedRoute(nil), false
+	if !deleted {
+		object, err := u.MarshalJSON()
+		if err != nil {
+			s.config.Logger.Warn("Failed to decode kubernetes resource", slog.String("resource", resource), slog.String("error", err.Error()))
+			return
+		}
+		converted, ok = s.convert(resource, object)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 削除された場合と、クラスなどが変わって対象外になった場合はルートを削除する
+	if ok {
+		routes[key] = converted
+	} else {
+		delete(routes, key)
+	}
+	s.scheduleUpdate()
+}
+
+// convert は対象のリソースをルートに変換する（対象外のリソースや変換できないリソースは false）
+// 変換できないルールやパスは飛ばし、ログに記録する
+func (s *Source) convert(resource string, object []byte) ([]convertedRoute, bool) {
+	var (
+		routes []convertedRoute
+		errs   []error
+	)
+	switch resource {
+	case ResourceHTTPRoute:
+		var r HTTPRoute
+		if err := json.Unmarshal(object, &r); err != nil {
+			s.config.Logger.Warn("Failed to decode HTTPRoute", slog.String("error", err.Error()))
+			return nil, false
+		}
+		if !s.acceptHTTPRoute(r) {
+			return nil, false
+		}
+		routes, errs = s.converter.httpRoute(r)
+	case ResourceIngress:
+		var in Ingress
+		if err := json.Unmarshal(object, &in); err != nil {
+			s.config.Logger.Warn("Failed to decode Ingress", slog.String("error", err.Error()))
+			return nil, false
+		}
+		if !s.acceptIngress(in) {
+			return nil, false
+		}
+		routes, errs = s.converter.ingress(in)
+	}
+
+	for _, err := range errs {
+		s.config.Logger.Warn("Skipped kubernetes route", slog.String("error", err.Error()))
+	}
+	return routes, true
+}
+
+// acceptHTTPRoute は HTTPRoute が GatewayName の Gateway に割り当てられているか確認する
+func (s *Source) acceptHTTPRoute(r HTTPRoute) bool {
+	if s.config.GatewayName == "" {
+		return true
+	}
+	return slices.ContainsFunc(r.Spec.ParentRefs, func(ref ParentReference) bool {
+		return (ref.Kind == "" || ref.Kind == "Gateway") && ref.Name == s.config.GatewayName
+	})
+}
+
+// acceptIngress は Ingress のクラスが IngressClass か確認する
+func (s *Source) acceptIngress(in Ingress) bool {
+	if in.Spec.IngressClassName != nil {
+		return *in.Spec.IngressClassName == s.config.IngressClass
+	}
+	return in.Metadata.Annotations[ingressClassAnnotation] == s.config.IngressClass
+}
+
+// scheduleUpdate は updateDelay の後にルートを組み立て直して onUpdate を呼ぶよう予約する（s.mu を保持して呼ぶ）
+// Watch の前は予約せず、Watch で予約する
+func (s *Source) scheduleUpdate() {
+	if s.onUpdate == nil {
+		s.stale = true
+		return
+	}
+	if s.pending != nil {
+		return
+	}
+	s.pending = time.AfterFunc(updateDelay, func() {
+		s.updateMu.Lock()
+		defer s.updateMu.Unlock()
+
+		s.mu.Lock()
+		s.pending = nil
+		routing := s.build()
+		s.mu.Unlock()
+
+		s.onUpdate(routing)
+	})
+}
+
+// build は保持しているルートからルーティング設定を組み立てる（s.mu を保持して呼ぶ）
+func (s *Source) build() *config.RoutingFileConfig {
+	var converted []convertedRoute
+	for _, resources := range s.routes {
+		for _, routes := range resources {
+			converted = append(converted, routes...)
+		}
+	}
+	routing, errs := mergeRoutes(s.config.Base, converted)
+	for _, err := range errs {
+		s.config.Logger.Warn("Skipped kubernetes route", slog.String("error", err.Error()))
+	}
+	return routing
+}
+
+// groupVersionResource は resource の GroupVersionResource
+func groupVersionResource(resource string) schema.GroupVersionResource {
+	if resource == ResourceIngress {
+		return ingressResource
+	}
+	return httpRouteResource
+}
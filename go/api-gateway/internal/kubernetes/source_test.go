@@ -0,0 +1,160 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeClient は objects を登録した dynamic クライアントを作成する
+func newFakeClient(t *testing.T, objects ...string) *dynamicfake.FakeDynamicClient {
+	t.Helper()
+	var objs []runtime.Object
+	for _, object := range objects {
+		objs = append(objs, newObject(t, object))
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		httpRouteResource: "HTTPRouteList",
+		ingressResource:   "IngressList",
+	}, objs...)
+}
+
+// newObject はJSONのリソースを informer が通知する形式に変換する
+func newObject(t *testing.T, object string) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON([]byte(object)); err != nil {
+		t.Fatalf("failed to decode object: %v", err)
+	}
+	return u
+}
+
+// waitForWatch は informer が resources の監視を始めるまで待つ（監視の前に変更したリソースは通知されない）
+func waitForWatch(t *testing.T, client *dynamicfake.FakeDynamicClient, resources ...string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		watching := 0
+		for _, resource := range resources {
+			if slices.ContainsFunc(client.Actions(), func(action k8stesting.Action) bool {
+				return action.GetVerb() == "watch" && action.GetResource().Resource == resource
+			}) {
+				watching++
+			}
+		}
+		if watching == len(resources) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("informers did not start watching %v", resources)
+}
+
+func TestSource(t *testing.T) {
+	updateDelay = 10 * time.Millisecond
+
+	client := newFakeClient(t,
+		`{"apiVersion": "gateway.networking.k8s.io/v1", "kind": "HTTPRoute", "metadata": {"name": "users", "namespace": "apps"}, "spec": {"parentRefs": [{"name": "api-gateway"}],
+			"rules": [{"matches": [{"path": {"type": "Exact", "value": "/users"}}], "backendRefs": [{"name": "users", "port": 80}]}]}}`,
+		`{"apiVersion": "gateway.networking.k8s.io/v1", "kind": "HTTPRoute", "metadata": {"name": "other", "namespace": "apps"}, "spec": {"parentRefs": [{"name": "other-gateway"}],
+			"rules": [{"matches": [{"path": {"type": "Exact", "value": "/other"}}], "backendRefs": [{"name": "other", "port": 80}]}]}}`,
+		`{"apiVersion": "gateway.networking.k8s.io/v1", "kind": "HTTPRoute", "metadata": {"name": "admin", "namespace": "kube-system"}, "spec": {"parentRefs": [{"name": "api-gateway"}],
+			"rules": [{"matches": [{"path": {"type": "Exact", "value": "/admin"}}], "backendRefs": [{"name": "admin", "port": 80}]}]}}`,
+		`{"apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "metadata": {"name": "web", "namespace": "apps", "annotations": {"kubernetes.io/ingress.class": "api-gateway"}},
+			"spec": {"rules": [{"http": {"paths": [{"path": "/web", "pathType": "Exact", "backend": {"service": {"name": "web", "port": {"number": 80}}}}]}}]}}`,
+		`{"apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "metadata": {"name": "nginx", "namespace": "apps"},
+			"spec": {"ingressClassName": "nginx", "rules": [{"http": {"paths": [{"path": "/nginx", "pathType": "Exact", "backend": {"service": {"name": "nginx", "port": {"number": 80}}}}]}}]}}`,
+	)
+	source := NewSource(SourceConfig{
+		Client:      client,
+		Namespace:   "apps",
+		GatewayName: "api-gateway",
+		Base:        &config.RoutingFileConfig{Routes: []config.Route{{Path: "/health"}}},
+		Logger:      slog.New(slog.DiscardHandler),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 対象の名前空間・Gateway・クラスのリソースのみ読み込む
+	routing, err := source.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := sortedPaths(routing); !slices.Equal(got, []string{"/health", "/users", "/web"}) {
+		t.Errorf("Load() routes = %v", got)
+	}
+
+	// 一覧以降の変更をまとめて反映する
+	updates := make(chan *config.RoutingFileConfig, 1)
+	source.Watch(ctx, func(routing *config.RoutingFileConfig) {
+		select {
+		case updates <- routing:
+		default:
+		}
+	})
+	waitForWatch(t, client, "httproutes", "ingresses")
+
+	routes := client.Resource(httpRouteResource).Namespace("apps")
+	orders := newObject(t, `{"apiVersion": "gateway.networking.k8s.io/v1", "kind": "HTTPRoute", "metadata": {"name": "orders", "namespace": "apps"}, "spec": {"parentRefs": [{"name": "api-gateway"}],
+		"rules": [{"matches": [{"path": {"type": "Exact", "value": "/orders"}}], "backendRefs": [{"name": "orders", "port": 80}]}]}}`)
+	if _, err := routes.Create(ctx, orders, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create HTTPRoute: %v", err)
+	}
+	if err := routes.Delete(ctx, "users", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete HTTPRoute: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case routing := <-updates:
+			if got := sortedPaths(routing); slices.Equal(got, []string{"/health", "/orders", "/web"}) {
+				return
+			}
+		case <-deadline:
+			t.Fatal("routes were not updated")
+		}
+	}
+}
+
+func TestSource_Load_Error(t *testing.T) {
+	client := newFakeClient(t)
+	client.PrependReactor("list", "httproutes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	source := NewSource(SourceConfig{
+		Client:    client,
+		Resources: []string{ResourceHTTPRoute},
+		Logger:    slog.New(slog.DiscardHandler),
+	})
+
+	// 一覧を取得できない場合は待ち続けずにエラーを返し、再試行は呼び出し側に任せる
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := source.Load(ctx); err == nil || ctx.Err() != nil {
+		t.Fatalf("Load() error = %v, want list error before the deadline", err)
+	}
+}
+
+// sortedPaths はルーティング設定のルートのパスをソートして返す
+func sortedPaths(routing *config.RoutingFileConfig) []string {
+	paths := make([]string, 0, len(routing.Routes))
+	for _, r := range routing.Routes {
+		paths = append(paths, r.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
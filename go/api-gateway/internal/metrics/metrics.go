@@ -0,0 +1,430 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// collector はRegistryに登録されるメトリクス
+type collector interface {
+	metricName() string
+	writeText(b *strings.Builder)
+}
+
+// Counter は単調増加するカウンター
+type Counter struct {
+	name  string
+	help  string
+	value atomic.Uint64
+}
+
+// Inc はカウンターを1増やす
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add はカウンターをn増やす
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value は現在の値を返す
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) metricName() string { return c.name }
+
+func (c *Counter) writeText(b *strings.Builder) {
+	writeHeader(b, c.name, c.help, "counter")
+	fmt.Fprintf(b, "%s %d\n", c.name, c.Value())
+}
+
+// Gauge は増減する値
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set は値を設定する
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value は現在の値を返す
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// GaugeVec はラベルの値ごとに Gauge を持つ
+type GaugeVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	gauges map[string]*labeledGauge
+}
+
+type labeledGauge struct {
+	values []string
+	gauge  *Gauge
+}
+
+// With はラベルの値（labels と同じ順序）に対応する Gauge を返す（無ければ作成する）
+func (v *GaugeVec) With(values ...string) *Gauge {
+	key := labelKey(v.name, v.labels, values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if lg, ok := v.gauges[key]; ok {
+		return lg.gauge
+	}
+	lg := &labeledGauge{values: append([]string(nil), values...), gauge: &Gauge{}}
+	v.gauges[key] = lg
+	return lg.gauge
+}
+
+// Delete はラベルの値に対応する Gauge を削除する
+func (v *GaugeVec) Delete(values ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.gauges, strings.Join(values, "\xff"))
+}
+
+func (v *GaugeVec) metricName() string { return v.name }
+
+func (v *GaugeVec) writeText(b *strings.Builder) {
+	v.mu.Lock()
+	gauges := make([]*labeledGauge, 0, len(v.gauges))
+	for _, lg := range v.gauges {
+		gauges = append(gauges, lg)
+	}
+	v.mu.Unlock()
+
+	sort.Slice(gauges, func(i, j int) bool {
+		return strings.Join(gauges[i].values, "\xff") < strings.Join(gauges[j].values, "\xff")
+	})
+
+	writeHeader(b, v.name, v.help, "gauge")
+	for _, lg := range gauges {
+		fmt.Fprintf(b, "%s%s %s\n", v.name, formatLabels(v.labels, lg.values), strconv.FormatFloat(lg.gauge.Value(), 'g', -1, 64))
+	}
+}
+
+// CounterFunc は出力時に関数から値を取得するカウンター
+// 他のパッケージが保持している単調増加の値を公開するために使う
+type CounterFunc struct {
+	name  string
+	help  string
+	value func() uint64
+}
+
+func (c *CounterFunc) metricName() string { return c.name }
+
+func (c *CounterFunc) writeText(b *strings.Builder) {
+	writeHeader(b, c.name, c.help, "counter")
+	fmt.Fprintf(b, "%s %d\n", c.name, c.value())
+}
+
+// CounterVec はラベルの値ごとに Counter を持つ
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu       sync.Mutex
+	counters map[string]*labeledCounter
+}
+
+type labeledCounter struct {
+	values  []string
+	counter *Counter
+}
+
+// With はラベルの値（labels と同じ順序）に対応する Counter を返す（無ければ作成する）
+func (v *CounterVec) With(values ...string) *Counter {
+	key := labelKey(v.name, v.labels, values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if lc, ok := v.counters[key]; ok {
+		return lc.counter
+	}
+	lc := &labeledCounter{values: append([]string(nil), values...), counter: &Counter{}}
+	v.counters[key] = lc
+	return lc.counter
+}
+
+func (v *CounterVec) metricName() string { return v.name }
+
+func (v *CounterVec) writeText(b *strings.Builder) {
+	v.mu.Lock()
+	counters := make([]*labeledCounter, 0, len(v.counters))
+	for _, lc := range v.counters {
+		counters = append(counters, lc)
+	}
+	v.mu.Unlock()
+
+	sort.Slice(counters, func(i, j int) bool {
+		return strings.Join(counters[i].values, "\xff") < strings.Join(counters[j].values, "\xff")
+	})
+
+	writeHeader(b, v.name, v.help, "counter")
+	for _, lc := range counters {
+		fmt.Fprintf(b, "%s%s %d\n", v.name, formatLabels(v.labels, lc.values), lc.counter.Value())
+	}
+}
+
+// DefaultBuckets は処理時間（秒）のヒストグラムのデフォルトのバケット
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Histogram は観測値の分布をバケットごとの累積数で記録する
+type Histogram struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64 // バケットごと（累積ではない）の観測数。最後の要素は +Inf
+	sum    float64
+	count  uint64
+}
+
+// Observe は値を1つ記録する
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+// Count は観測数を返す
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// HistogramVec はラベルの値ごとに Histogram を持つ
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu         sync.Mutex
+	histograms map[string]*labeledHistogram
+}
+
+type labeledHistogram struct {
+	values    []string
+	histogram *Histogram
+}
+
+// With はラベルの値（labels と同じ順序）に対応する Histogram を返す（無ければ作成する）
+func (v *HistogramVec) With(values ...string) *Histogram {
+	key := labelKey(v.name, v.labels, values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if lh, ok := v.histograms[key]; ok {
+		return lh.histogram
+	}
+	lh := &labeledHistogram{
+		values:    append([]string(nil), values...),
+		histogram: &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets)+1)},
+	}
+	v.histograms[key] = lh
+	return lh.histogram
+}
+
+func (v *HistogramVec) metricName() string { return v.name }
+
+func (v *HistogramVec) writeText(b *strings.Builder) {
+	v.mu.Lock()
+	histograms := make([]*labeledHistogram, 0, len(v.histograms))
+	for _, lh := range v.histograms {
+		histograms = append(histograms, lh)
+	}
+	v.mu.Unlock()
+
+	sort.Slice(histograms, func(i, j int) bool {
+		return strings.Join(histograms[i].values, "\xff") < strings.Join(histograms[j].values, "\xff")
+	})
+
+	writeHeader(b, v.name, v.help, "histogram")
+	bucketLabels := append(append([]string(nil), v.labels...), "le")
+	for _, lh := range histograms {
+		h := lh.histogram
+		// 末尾の le ラベルの値をバケットごとに差し替える
+		bucketValues := append(append([]string(nil), lh.values...), "")
+		h.mu.Lock()
+		var cumulative uint64
+		for i, upper := range v.buckets {
+			cumulative += h.counts[i]
+			bucketValues[len(bucketValues)-1] = strconv.FormatFloat(upper, 'g', -1, 64)
+			fmt.Fprintf(b, "%s_bucket%s %d\n", v.name, formatLabels(bucketLabels, bucketValues), cumulative)
+		}
+		bucketValues[len(bucketValues)-1] = "+Inf"
+		fmt.Fprintf(b, "%s_bucket%s %d\n", v.name, formatLabels(bucketLabels, bucketValues), h.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", v.name, formatLabels(v.labels, lh.values), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count%s %d\n", v.name, formatLabels(v.labels, lh.values), h.count)
+		h.mu.Unlock()
+	}
+}
+
+// Registry はメトリクスを保持し、Prometheusのテキスト形式で公開する
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry は新しいRegistryを作成する
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// Default はパッケージ全体で共有するRegistry
+var Default = NewRegistry()
+
+// NewCounter はDefaultにカウンターを登録する
+func NewCounter(name, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+// NewGaugeVec はDefaultにラベル付きのゲージを登録する
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewCounterFunc はDefaultに関数から値を取得するカウンターを登録する
+func NewCounterFunc(name, help string, value func() uint64) *CounterFunc {
+	return Default.NewCounterFunc(name, help, value)
+}
+
+// NewCounterVec はDefaultにラベル付きのカウンターを登録する
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewHistogramVec はDefaultにラベル付きのヒストグラムを登録する
+// buckets が空の場合は DefaultBuckets を使う
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewCounter はカウンターを登録する
+// 同じ名前の二重登録はプログラムの誤りなので panic する（expvar と同じ）
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	r.register(c)
+	return c
+}
+
+// NewGaugeVec はラベル付きのゲージを登録する
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{name: name, help: help, labels: labels, gauges: make(map[string]*labeledGauge)}
+	r.register(v)
+	return v
+}
+
+// NewCounterFunc は関数から値を取得するカウンターを登録する
+func (r *Registry) NewCounterFunc(name, help string, value func() uint64) *CounterFunc {
+	c := &CounterFunc{name: name, help: help, value: value}
+	r.register(c)
+	return c
+}
+
+// NewCounterVec はラベル付きのカウンターを登録する
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{name: name, help: help, labels: labels, counters: make(map[string]*labeledCounter)}
+	r.register(v)
+	return v
+}
+
+// NewHistogramVec はラベル付きのヒストグラムを登録する
+// buckets が空の場合は DefaultBuckets を使う
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	v := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, histograms: make(map[string]*labeledHistogram)}
+	r.register(v)
+	return v
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[c.metricName()]; ok {
+		panic(fmt.Sprintf("metrics: duplicate metric name %q", c.metricName()))
+	}
+	r.collectors[c.metricName()] = c
+}
+
+// WriteText はPrometheusのテキスト形式（version 0.0.4）でメトリクスを書き出す
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].metricName() < collectors[j].metricName() })
+
+	var b strings.Builder
+	for _, c := range collectors {
+		c.writeText(&b)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// writeHeader は HELP（空の場合は省略）と TYPE の行を書き出す
+func writeHeader(b *strings.Builder, name, help, typ string) {
+	if help != "" {
+		fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, typ)
+}
+
+// labelKey はラベルの値からメトリクスを識別するキーを返す
+// ラベルの数が合わない場合はプログラムの誤りなので panic する
+func labelKey(name string, labels, values []string) string {
+	if len(values) != len(labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels は {label="value",...} の形式のラベルを返す
+func formatLabels(labels, values []string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%s", label, strconv.Quote(values[i]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	b := r.NewCounter("b_total", "second counter")
+	a := r.NewCounter("a_total", "")
+	a.Inc()
+	b.Add(3)
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 名前順に出力され、HELPが空の場合は省略される
+	want := "# TYPE a_total counter\n" +
+		"a_total 1\n" +
+		"# HELP b_total second counter\n" +
+		"# TYPE b_total counter\n" +
+		"b_total 3\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText() = %q, want %q", got, want)
+	}
+}
+
+func TestRegistry_NewCounter_Duplicate(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("dup_total", "")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate counter name")
+		}
+	}()
+	r.NewCounter("dup_total", "")
+}
+
+func TestGaugeVec_WriteText(t *testing.T) {
+	r := NewRegistry()
+	v := r.NewGaugeVec("backend_up", "Backend state.", "backend")
+	v.With("http://b:8080").Set(0)
+	v.With("http://a:8080").Set(1)
+	v.With("http://c:8080").Set(1)
+	v.Delete("http://c:8080")
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// ラベルの値の順に出力され、削除した値は出力されない
+	want := "# HELP backend_up Backend state.\n" +
+		"# TYPE backend_up gauge\n" +
+		"backend_up{backend=\"http://a:8080\"} 1\n" +
+		"backend_up{backend=\"http://b:8080\"} 0\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText() = %q, want %q", got, want)
+	}
+}
+
+func TestCounterVec_WriteText(t *testing.T) {
+	r := NewRegistry()
+	v := r.NewCounterVec("errors_total", "Errors.", "middleware", "route")
+	v.With("jwt", "/users").Add(2)
+	v.With("cors", "/users").Inc()
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "# HELP errors_total Errors.\n" +
+		"# TYPE errors_total counter\n" +
+		"errors_total{middleware=\"cors\",route=\"/users\"} 1\n" +
+		"errors_total{middleware=\"jwt\",route=\"/users\"} 2\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText() = %q, want %q", got, want)
+	}
+}
+
+func TestHistogramVec_WriteText(t *testing.T) {
+	r := NewRegistry()
+	v := r.NewHistogramVec("duration_seconds", "", []float64{0.5, 0.1}, "middleware")
+	h := v.With("jwt")
+	h.Observe(0.05)
+	h.Observe(0.1)
+	h.Observe(0.3)
+	h.Observe(2)
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// バケットは昇順に並べ替えられ、境界と等しい値はそのバケットに含まれる
+	want := "# TYPE duration_seconds histogram\n" +
+		"duration_seconds_bucket{middleware=\"jwt\",le=\"0.1\"} 2\n" +
+		"duration_seconds_bucket{middleware=\"jwt\",le=\"0.5\"} 3\n" +
+		"duration_seconds_bucket{middleware=\"jwt\",le=\"+Inf\"} 4\n" +
+		"duration_seconds_sum{middleware=\"jwt\"} 2.45\n" +
+		"duration_seconds_count{middleware=\"jwt\"} 4\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"api-gateway/internal/audit"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBasicAuthRealm は WWW-Authenticate で返す realm のデフォルト値
+const DefaultBasicAuthRealm = "Restricted"
+
+// dummyBcryptHash は存在しないユーザーでも bcrypt の比較を行い、応答時間からユーザーの有無を推測されないようにするためのハッシュ
+// 起動時に bcrypt.DefaultCost でハッシュを計算しないよう、"dummy-password" を DefaultCost でハッシュした値を埋め込む
+var dummyBcryptHash = []byte("$2a$10$LCKGZjIJyI7pA/Nme/LYk.g4PHcIxEfJ/vYZf5lsbYPd8D.RR19R2")
+
+// BasicAuthConfig はBasic認証ミドルウェアの設定
+type BasicAuthConfig struct {
+	// Realm は WWW-Authenticate で返す realm（空は DefaultBasicAuthRealm）
+	Realm string
+
+	// Users はユーザー名とパスワードの bcrypt ハッシュ（"$2a$..."、htpasswd -B で生成できる）
+	Users map[string]string
+
+	// Audit は認証失敗を記録する監査ロガー（nilの場合は記録しない）
+	Audit *audit.Logger
+}
+
+// BasicAuthMiddleware はBasic認証でリクエストを認証するミドルウェア
+// IdPを用意せずに /metrics や /admin などの内部向けのルートを保護するために使う
+// 認証に成功したユーザー名は "sub" クレームとしてコンテキストに保存し、Authorization ヘッダーはバックエンドへ転送しない
+type BasicAuthMiddleware struct {
+	realm string
+	users map[string][]byte
+	audit *audit.Logger
+}
+
+// NewBasicAuthMiddleware は新しいBasic認証ミドルウェアを作成する
+func NewBasicAuthMiddleware(config BasicAuthConfig) (*BasicAuthMiddleware, error) {
+	if len(config.Users) == 0 {
+		return nil, fmt.Errorf("basic_auth middleware requires at least one user")
+	}
+
+	users := make(map[string][]byte, len(config.Users))
+	for username, hash := range config.Users {
+		if username == "" || strings.Contains(username, ":") {
+			return nil, fmt.Errorf("invalid basic_auth username: %q", username)
+		}
+		// 平文のパスワードを設定する誤りを起動時に検出する
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("password of basic_auth user %s must be a bcrypt hash: %w", username, err)
+		}
+		users[username] = []byte(hash)
+	}
+
+	realm := config.Realm
+	if realm == "" {
+		realm = DefaultBasicAuthRealm
+	}
+
+	return &BasicAuthMiddleware{
+		realm: realm,
+		users: users,
+		audit: config.Audit,
+	}, nil
+}
+
+// Process はBasic認証を実行する
+func (m *BasicAuthMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	username, err := m.authenticate(req)
+	if err != nil {
+		m.audit.Log(ctx, audit.Event{
+			Type:    audit.EventAuthFailure,
+			Outcome: audit.OutcomeFailure,
+			UserID:  username,
+			Reason:  err.Error(),
+		})
+		return ctx, err
+	}
+
+	req.Header.Del("Authorization")
+	ctx = requestctx.WithClaims(ctx, jwt.MapClaims{"sub": username})
+	return ctx, nil
+}
+
+// authenticate はAuthorizationヘッダーのユーザー名とパスワードを検証し、ユーザー名を返す
+func (m *BasicAuthMiddleware) authenticate(req *http.Request) (string, error) {
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return "", m.challenge("missing basic authorization")
+	}
+
+	hash, known := m.users[username]
+	if !known {
+		hash = dummyBcryptHash
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || !known {
+		return username, m.challenge("invalid username or password")
+	}
+	return username, nil
+}
+
+// challenge はブラウザなどが認証情報を入力できるよう、WWW-Authenticate を付けた401エラーを返す
+func (m *BasicAuthMiddleware) challenge(message string) error {
+	return errors.WithHeader(errors.NewUnauthorizedError(message),
+		"WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, m.realm))
+}
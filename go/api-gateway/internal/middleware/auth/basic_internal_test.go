@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestDummyBcryptHash は埋め込んだダミーのハッシュが実在するユーザーと同じ DefaultCost の有効なハッシュであることを確認する
+func TestDummyBcryptHash(t *testing.T) {
+	cost, err := bcrypt.Cost(dummyBcryptHash)
+	if err != nil {
+		t.Fatalf("bcrypt.Cost() error = %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("cost = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+	if err := bcrypt.CompareHashAndPassword(dummyBcryptHash, []byte("dummy-password")); err != nil {
+		t.Errorf("CompareHashAndPassword() error = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/requestctx"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuthMiddleware_Process(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	m, err := auth.NewBasicAuthMiddleware(auth.BasicAuthConfig{
+		Realm: "internal",
+		Users: map[string]string{"prometheus": string(hash)},
+	})
+	if err != nil {
+		t.Fatalf("NewBasicAuthMiddleware() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		noAuth   bool
+		wantErr  bool
+	}{
+		{name: "正しい認証情報", username: "prometheus", password: "s3cret"},
+		{name: "パスワードが異なる", username: "prometheus", password: "wrong", wantErr: true},
+		{name: "存在しないユーザー", username: "unknown", password: "s3cret", wantErr: true},
+		{name: "認証情報が無い", noAuth: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/internal/metrics", nil)
+			if !tt.noAuth {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+
+			ctx, err := m.Process(context.Background(), req)
+			if tt.wantErr {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != http.StatusUnauthorized {
+					t.Fatalf("Process() error = %v, want 401", err)
+				}
+				if got := errors.ResponseHeaders(gatewayErr).Get("WWW-Authenticate"); got != `Basic realm="internal", charset="UTF-8"` {
+					t.Errorf("WWW-Authenticate = %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			claims, ok := requestctx.Claims(ctx)
+			if !ok || claims["sub"] != tt.username {
+				t.Errorf("claims = %v, want sub=%s", claims, tt.username)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Error("Authorization header should not be forwarded")
+			}
+		})
+	}
+}
+
+func TestNewBasicAuthMiddleware(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+
+	tests := []struct {
+		name    string
+		users   map[string]string
+		wantErr bool
+	}{
+		{name: "bcryptのハッシュ", users: map[string]string{"admin": string(hash)}},
+		{name: "ユーザーが無い", users: map[string]string{}, wantErr: true},
+		{name: "平文のパスワード", users: map[string]string{"admin": "s3cret"}, wantErr: true},
+		{name: "コロンを含むユーザー名", users: map[string]string{"ad:min": string(hash)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.NewBasicAuthMiddleware(auth.BasicAuthConfig{Users: tt.users})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewBasicAuthMiddleware() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
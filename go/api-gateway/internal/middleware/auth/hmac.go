@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-gateway/internal/audit"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultHMACHeader はHMAC署名を受け取るヘッダー名のデフォルト値
+	DefaultHMACHeader = "X-Signature"
+	// DefaultHMACMaxSkew はタイムスタンプとして許容する時刻のずれのデフォルト値
+	DefaultHMACMaxSkew = 5 * time.Minute
+	// DefaultHMACMaxBodySize は署名の検証のために読み込むボディの上限のデフォルト値
+	DefaultHMACMaxBodySize = 1 << 20
+)
+
+// DefaultHMACComponents は署名対象のデフォルトの要素
+var DefaultHMACComponents = []string{"method", "path", "timestamp", "body"}
+
+// hmacAlgorithms は署名に使えるハッシュ関数
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// HMACConfig はHMAC署名の検証ミドルウェアの設定
+type HMACConfig struct {
+	// Header は署名を受け取るヘッダー名（空は DefaultHMACHeader）
+	// 値は "key=<consumer>,ts=<unix秒>,sig=<hex>" の形式
+	Header string
+
+	// Algorithm はハッシュ関数（sha1, sha256, sha512。空は sha256）
+	Algorithm string
+
+	// Secrets はコンシューマーごとの共有鍵（コンシューマー → 鍵）
+	Secrets map[string]string
+
+	// Components は署名対象の要素（空は DefaultHMACComponents）
+	// method, path（パスとクエリ）, timestamp, body, header:<名前> を指定でき、指定した順に改行でつなげたものに署名する
+	// リプレイを防ぐため timestamp は必須
+	Components []string
+
+	// MaxSkew はタイムスタンプとして許容する時刻のずれ（0は DefaultHMACMaxSkew）
+	// 期間内に同じ署名を再度受け取った場合も拒否する
+	MaxSkew time.Duration
+
+	// MaxBodySize は署名の検証のために読み込むボディの上限（0は DefaultHMACMaxBodySize）
+	MaxBodySize int64
+
+	// Audit は認証失敗を記録する監査ロガー（nilの場合は記録しない）
+	Audit *audit.Logger
+
+	// Replay は使用済みの署名の記録（nilの場合はミドルウェアごとに作成する）
+	// ミドルウェアはリクエストごとに作成されるため、ゲートウェイでは Factory が共有する記録を渡す
+	Replay *HMACReplayCache
+
+	// Now は現在時刻を返す（nilの場合は time.Now）
+	Now func() time.Time
+}
+
+// HMACMiddleware は共有鍵によるHMAC署名でリクエストを認証するミドルウェア
+// JWTを発行できないWebhookの送信元などを認証するために使う
+// 認証に成功したコンシューマーは "sub" クレームとしてコンテキストに保存し、JWTと同じく後続のミドルウェアから参照できる
+type HMACMiddleware struct {
+	config     HMACConfig
+	newHash    func() hash.Hash
+	components []string
+}
+
+// NewHMACMiddleware は新しいHMAC署名の検証ミドルウェアを作成する
+func NewHMACMiddleware(config HMACConfig) (*HMACMiddleware, error) {
+	if len(config.Secrets) == 0 {
+		return nil, fmt.Errorf("hmac middleware requires at least one secret")
+	}
+	for consumer, secret := range config.Secrets {
+		if consumer == "" || secret == "" {
+			return nil, fmt.Errorf("hmac secret for consumer %q must not be empty", consumer)
+		}
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = "sha256"
+	}
+	newHash, ok := hmacAlgorithms[config.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hmac algorithm: %s", config.Algorithm)
+	}
+	if config.Header == "" {
+		config.Header = DefaultHMACHeader
+	}
+	if config.MaxSkew < 0 || config.MaxBodySize < 0 {
+		return nil, fmt.Errorf("hmac max_skew and max_body_size must be non-negative")
+	}
+	if config.MaxSkew == 0 {
+		config.MaxSkew = DefaultHMACMaxSkew
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = DefaultHMACMaxBodySize
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	if config.Replay == nil {
+		config.Replay = NewHMACReplayCache()
+	}
+
+	components := config.Components
+	if len(components) == 0 {
+		components = DefaultHMACComponents
+	}
+	hasTimestamp := false
+	for _, component := range components {
+		switch {
+		case component == "timestamp":
+			hasTimestamp = true
+		case component == "method", component == "path", component == "body":
+		case strings.HasPrefix(component, "header:") && len(component) > len("header:"):
+		default:
+			return nil, fmt.Errorf("unknown hmac component: %q", component)
+		}
+	}
+	if !hasTimestamp {
+		return nil, fmt.Errorf("hmac components must include timestamp")
+	}
+
+	return &HMACMiddleware{
+		config:     config,
+		newHash:    newHash,
+		components: components,
+	}, nil
+}
+
+// Process はHMAC署名を検証する
+func (m *HMACMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	consumer, err := m.authenticate(req)
+	if err != nil {
+		m.config.Audit.Log(ctx, audit.Event{
+			Type:    audit.EventAuthFailure,
+			Outcome: audit.OutcomeFailure,
+			UserID:  consumer,
+			Reason:  err.Error(),
+		})
+		return ctx, err
+	}
+
+	ctx = requestctx.WithClaims(ctx, jwt.MapClaims{"sub": consumer})
+	return ctx, nil
+}
+
+// authenticate は署名ヘッダーを検証し、コンシューマーを返す
+func (m *HMACMiddleware) authenticate(req *http.Request) (string, error) {
+	value := req.Header.Get(m.config.Header)
+	if value == "" {
+		return "", errors.NewUnauthorizedError(fmt.Sprintf("missing %s header", m.config.Header))
+	}
+	consumer, ts, sig, err := parseHMACHeader(value)
+	if err != nil {
+		return "", errors.NewUnauthorizedError(err.Error())
+	}
+	secret, ok := m.config.Secrets[consumer]
+	if !ok {
+		return consumer, errors.NewUnauthorizedError("unknown signing key")
+	}
+
+	now := m.config.Now()
+	if skew := now.Sub(time.Unix(ts, 0)); skew > m.config.MaxSkew || skew < -m.config.MaxSkew {
+		return consumer, errors.NewUnauthorizedError("signature timestamp is outside the allowed skew")
+	}
+
+	payload, err := m.stringToSign(req, ts)
+	if err != nil {
+		return consumer, err
+	}
+	mac := hmac.New(m.newHash, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return consumer, errors.NewUnauthorizedError("invalid signature")
+	}
+
+	// タイムスタンプが未来方向にずれている署名も受け付けるため、両方向のずれの分だけ保持する
+	// MaxSkew を過ぎた署名はタイムスタンプの検証で拒否される
+	if !m.config.Replay.MarkSeen(consumer+":"+hex.EncodeToString(sig), now, 2*m.config.MaxSkew) {
+		return consumer, errors.NewUnauthorizedError("signature has already been used")
+	}
+	return consumer, nil
+}
+
+// stringToSign は署名対象の要素を改行でつなげる
+// body を含む場合はボディを読み込み、後続の処理が読めるよう戻す
+func (m *HMACMiddleware) stringToSign(req *http.Request, ts int64) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, component := range m.components {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		switch component {
+		case "method":
+			buf.WriteString(req.Method)
+		case "path":
+			buf.WriteString(req.URL.RequestURI())
+		case "timestamp":
+			buf.WriteString(strconv.FormatInt(ts, 10))
+		case "body":
+			if req.Body == nil || req.Body == http.NoBody {
+				continue
+			}
+			body, err := m.readBody(req)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(body)
+		default:
+			buf.WriteString(req.Header.Get(strings.TrimPrefix(component, "header:")))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// readBody はリクエストボディを読み込み、後続の処理が読めるよう戻す
+func (m *HMACMiddleware) readBody(req *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, m.config.MaxBodySize+1))
+	req.Body.Close()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit))
+		}
+		return nil, errors.NewBadRequestError("failed to read request body")
+	}
+	if int64(len(body)) > m.config.MaxBodySize {
+		return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds signature limit of %d bytes", m.config.MaxBodySize))
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// parseHMACHeader は署名ヘッダーの値を解析する
+func parseHMACHeader(value string) (consumer string, ts int64, sig []byte, err error) {
+	var tsSet bool
+	for part := range strings.SplitSeq(value, ",") {
+		name, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", 0, nil, fmt.Errorf("malformed signature header")
+		}
+		switch name {
+		case "key":
+			consumer = v
+		case "ts":
+			ts, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", 0, nil, fmt.Errorf("invalid signature timestamp")
+			}
+			tsSet = true
+		case "sig":
+			sig, err = hex.DecodeString(v)
+			if err != nil {
+				return "", 0, nil, fmt.Errorf("invalid signature encoding")
+			}
+		}
+	}
+
+	if consumer == "" || !tsSet || len(sig) == 0 {
+		return "", 0, nil, fmt.Errorf("malformed signature header")
+	}
+	return consumer, ts, sig, nil
+}
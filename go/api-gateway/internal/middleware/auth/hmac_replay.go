@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// HMACReplayCache は使用済みのHMAC署名を期限まで保持し、同じ署名の再利用を検出する
+// ミドルウェアはルートのリクエストごとに作成されるため、Factory が1つを作成して全てのHMACミドルウェアで共有する
+// 期限切れの署名は記録した順のキューの先頭から削除するため、記録のたびに全体を走査しない
+type HMACReplayCache struct {
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	queue []hmacSeen // 記録した順（先頭が最も古い）
+	head  int        // queue の削除済みの位置
+}
+
+// hmacSeen は記録した署名とその期限
+type hmacSeen struct {
+	key     string
+	expires time.Time
+}
+
+// NewHMACReplayCache は新しいHMACReplayCacheを作成する
+func NewHMACReplayCache() *HMACReplayCache {
+	return &HMACReplayCache{seen: make(map[string]time.Time)}
+}
+
+// MarkSeen は署名を ttl の間使用済みとして記録する。期限内に使用済みの場合は false を返す
+func (c *HMACReplayCache) MarkSeen(key string, now time.Time, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire(now)
+	if expires, ok := c.seen[key]; ok && !now.After(expires) {
+		return false
+	}
+	expires := now.Add(ttl)
+	c.seen[key] = expires
+	c.queue = append(c.queue, hmacSeen{key: key, expires: expires})
+	return true
+}
+
+// Len は記録している署名の数を返す
+func (c *HMACReplayCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.seen)
+}
+
+// expire はキューの先頭から期限切れの署名を削除する
+// ルートごとに ttl が異なる場合、期限の長い署名より後ろの期限切れの署名は削除が遅れるが、MarkSeen は期限で判定する
+func (c *HMACReplayCache) expire(now time.Time) {
+	for c.head < len(c.queue) && now.After(c.queue[c.head].expires) {
+		entry := c.queue[c.head]
+		// 期限切れの後に同じ署名を記録し直した場合は新しい期限を残す
+		if c.seen[entry.key].Equal(entry.expires) {
+			delete(c.seen, entry.key)
+		}
+		c.queue[c.head] = hmacSeen{}
+		c.head++
+	}
+	// 削除済みの領域が半分を超えたら詰める
+	if c.head > len(c.queue)/2 {
+		c.queue = append(c.queue[:0], c.queue[c.head:]...)
+		c.head = 0
+	}
+}
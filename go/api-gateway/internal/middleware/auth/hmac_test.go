@@ -0,0 +1,214 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/requestctx"
+)
+
+// signHMAC はテスト用に署名ヘッダーの値を作成する
+func signHMAC(newHash func() hash.Hash, consumer, secret string, ts int64, payload string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return fmt.Sprintf("key=%s,ts=%d,sig=%s", consumer, ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestHMACMiddleware_Process(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := now.Unix()
+	const body = `{"event":"paid"}`
+	payload := fmt.Sprintf("POST\n/webhooks?id=1\n%d\n%s", ts, body)
+
+	tests := []struct {
+		name       string
+		config     auth.HMACConfig
+		header     string
+		signature  string
+		wantStatus int
+	}{
+		{
+			name:      "正しい署名",
+			config:    auth.HMACConfig{},
+			signature: signHMAC(sha256.New, "provider", "secret", ts, payload),
+		},
+		{
+			name:      "sha512とヘッダーを含む要素",
+			config:    auth.HMACConfig{Algorithm: "sha512", Components: []string{"timestamp", "header:X-Tenant", "body"}},
+			signature: signHMAC(sha512.New, "provider", "secret", ts, fmt.Sprintf("%d\ntenant-a\n%s", ts, body)),
+		},
+		{
+			name:      "ヘッダー名の変更",
+			config:    auth.HMACConfig{Header: "X-Hub-Signature"},
+			header:    "X-Hub-Signature",
+			signature: signHMAC(sha256.New, "provider", "secret", ts, payload),
+		},
+		{
+			name:       "署名が無い",
+			config:     auth.HMACConfig{},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "不明なコンシューマー",
+			config:     auth.HMACConfig{},
+			signature:  signHMAC(sha256.New, "unknown", "secret", ts, payload),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "鍵が異なる",
+			config:     auth.HMACConfig{},
+			signature:  signHMAC(sha256.New, "provider", "other", ts, payload),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "ボディの改ざん",
+			config:     auth.HMACConfig{},
+			signature:  signHMAC(sha256.New, "provider", "secret", ts, strings.Replace(payload, "paid", "refunded", 1)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "タイムスタンプが古い",
+			config:     auth.HMACConfig{},
+			signature:  signHMAC(sha256.New, "provider", "secret", ts-600, fmt.Sprintf("POST\n/webhooks?id=1\n%d\n%s", ts-600, body)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "不正な形式",
+			config:     auth.HMACConfig{},
+			signature:  "sig=zz",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "ボディが上限を超える",
+			config:     auth.HMACConfig{MaxBodySize: 4},
+			signature:  signHMAC(sha256.New, "provider", "secret", ts, payload),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Secrets = map[string]string{"provider": "secret"}
+			tt.config.Now = func() time.Time { return now }
+			m, err := auth.NewHMACMiddleware(tt.config)
+			if err != nil {
+				t.Fatalf("NewHMACMiddleware() error = %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks?id=1", strings.NewReader(body))
+			req.Header.Set("X-Tenant", "tenant-a")
+			if tt.signature != "" {
+				header := tt.header
+				if header == "" {
+					header = auth.DefaultHMACHeader
+				}
+				req.Header.Set(header, tt.signature)
+			}
+
+			ctx, err := m.Process(context.Background(), req)
+			if tt.wantStatus != 0 {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != tt.wantStatus {
+					t.Fatalf("Process() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			claims, ok := requestctx.Claims(ctx)
+			if !ok || claims["sub"] != "provider" {
+				t.Errorf("claims = %v, want sub=provider", claims)
+			}
+			// 後続の処理がボディを読めること
+			got, _ := io.ReadAll(req.Body)
+			if string(got) != body {
+				t.Errorf("body = %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestHMACMiddleware_Process_Replay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := auth.NewHMACMiddleware(auth.HMACConfig{
+		Secrets: map[string]string{"provider": "secret"},
+		Now:     func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("NewHMACMiddleware() error = %v", err)
+	}
+	signature := signHMAC(sha256.New, "provider", "secret", now.Unix(), fmt.Sprintf("GET\n/events\n%d\n", now.Unix()))
+
+	for i, wantErr := range []bool{false, true} {
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req.Header.Set(auth.DefaultHMACHeader, signature)
+		if _, err := m.Process(context.Background(), req); (err != nil) != wantErr {
+			t.Errorf("request %d: Process() error = %v, wantErr %v", i, err, wantErr)
+		}
+	}
+}
+
+func TestNewHMACMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  auth.HMACConfig
+		wantErr bool
+	}{
+		{name: "デフォルト設定", config: auth.HMACConfig{Secrets: map[string]string{"a": "s"}}},
+		{name: "鍵が無い", config: auth.HMACConfig{}, wantErr: true},
+		{name: "空の鍵", config: auth.HMACConfig{Secrets: map[string]string{"a": ""}}, wantErr: true},
+		{name: "未対応のアルゴリズム", config: auth.HMACConfig{Secrets: map[string]string{"a": "s"}, Algorithm: "md5"}, wantErr: true},
+		{name: "timestampを含まない", config: auth.HMACConfig{Secrets: map[string]string{"a": "s"}, Components: []string{"method", "body"}}, wantErr: true},
+		{name: "不明な要素", config: auth.HMACConfig{Secrets: map[string]string{"a": "s"}, Components: []string{"timestamp", "cookie"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.NewHMACMiddleware(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewHMACMiddleware() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHMACReplayCache_MarkSeen(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := auth.NewHMACReplayCache()
+
+	if !c.MarkSeen("provider:a", now, time.Minute) {
+		t.Fatal("MarkSeen() = false for a new signature")
+	}
+	if c.MarkSeen("provider:a", now.Add(30*time.Second), time.Minute) {
+		t.Error("MarkSeen() = true for a used signature")
+	}
+	if !c.MarkSeen("provider:b", now.Add(30*time.Second), time.Minute) {
+		t.Error("MarkSeen() = false for another signature")
+	}
+
+	// 期限を過ぎた署名は記録した順に削除する
+	if !c.MarkSeen("provider:c", now.Add(61*time.Second), time.Minute) {
+		t.Error("MarkSeen() = false for a new signature")
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+	if !c.MarkSeen("provider:a", now.Add(2*time.Minute), time.Minute) {
+		t.Error("MarkSeen() = false after the signature expired")
+	}
+}
@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultJWKSRefreshInterval = 5 * time.Minute
+	defaultJWKSStaleTolerance  = time.Hour
+	defaultJWKSTimeout         = 10 * time.Second
+
+	// jwksMinTTL は短すぎる max-age や no-cache でIdPへ問い合わせが集中しないための下限
+	jwksMinTTL = 30 * time.Second
+	// jwksRefreshRatio は有効期間のうち、どの時点で更新を始めるか（期限切れの前に更新する）
+	jwksRefreshRatio = 0.8
+
+	// jwksUnknownKidInterval は未知のkidで再取得する最小間隔（不正なkidのトークンでIdPへ問い合わせが集中しないようにする）
+	jwksUnknownKidInterval = jwksMinTTL
+
+	jwksRetryBase = time.Second
+	jwksRetryMax  = time.Minute
+
+	// maxJWKSSize は取得する鍵セットの上限サイズ
+	maxJWKSSize = 1 << 20
+)
+
+// JWKSConfig はJWKSキャッシュの設定
+type JWKSConfig struct {
+	// URL はJWKSエンドポイント
+	URL string
+	// RefreshInterval はCache-Controlのmax-ageが無い場合の有効期間（デフォルト: 5分）
+	RefreshInterval time.Duration
+	// StaleTolerance は有効期限の経過後も取得済みの鍵を使い続ける猶予（デフォルト: 1時間）
+	// IdPの一時的な障害で認証が失敗しないようにする
+	StaleTolerance time.Duration
+	// Timeout は1回の取得のタイムアウト（デフォルト: 10秒）
+	Timeout time.Duration
+	Client  *http.Client
+	Logger  *slog.Logger
+}
+
+// JWKSCache はJWKSエンドポイントから取得した公開鍵をキャッシュする
+// ETag による条件付き取得と Cache-Control の max-age に従い、有効期限の前にバックグラウンドで更新する
+type JWKSCache struct {
+	config JWKSConfig
+	now    func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	etag      string
+	expiresAt time.Time
+	// fetchedAt は最後に取得を試みた時刻
+	fetchedAt time.Time
+
+	// refreshMu は未知のkidによる再取得を同時に1回にまとめる
+	refreshMu sync.Mutex
+}
+
+// NewJWKSCache は新しいJWKSキャッシュを作成する
+func NewJWKSCache(config JWKSConfig) *JWKSCache {
+	// デフォルト値の設定
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = defaultJWKSRefreshInterval
+	}
+	if config.StaleTolerance <= 0 {
+		config.StaleTolerance = defaultJWKSStaleTolerance
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultJWKSTimeout
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &JWKSCache{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Start は鍵セットを取得し、ctxがキャンセルされるまでバックグラウンドで更新を続ける
+// 初回の取得に失敗した場合はエラーを返すが、バックグラウンドでの再試行は継続する
+func (c *JWKSCache) Start(ctx context.Context) error {
+	err := c.Refresh(ctx)
+	failures := 0
+	if err != nil {
+		failures = 1
+	}
+	go c.run(ctx, failures)
+	return err
+}
+
+// run は有効期限の前の更新と、失敗時のジッター付き再試行を繰り返す
+func (c *JWKSCache) run(ctx context.Context, failures int) {
+	for {
+		wait := c.nextRefresh()
+		if failures > 0 {
+			wait = jwksRetryDelay(failures)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := c.Refresh(ctx); err != nil {
+			failures++
+			c.config.Logger.WarnContext(ctx, "failed to refresh jwks",
+				"error", err,
+				"url", c.config.URL,
+				"attempt", failures)
+			continue
+		}
+		failures = 0
+	}
+}
+
+// Refresh はJWKSエンドポイントから鍵セットを取得する
+// 前回のETagを If-None-Match で送り、304の場合は有効期限のみ延長する
+func (c *JWKSCache) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create jwks request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	c.mu.Lock()
+	etag := c.etag
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	ttl := c.cacheTTL(resp.Header)
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		c.mu.Lock()
+		c.expiresAt = c.now().Add(ttl)
+		c.mu.Unlock()
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("unexpected jwks response status: %d", resp.StatusCode)
+	}
+
+	keys, err := parseJWKS(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.etag = resp.Header.Get("ETag")
+	c.expiresAt = c.now().Add(ttl)
+	c.mu.Unlock()
+
+	c.config.Logger.DebugContext(ctx, "jwks refreshed", "url", c.config.URL, "keys", len(keys))
+	return nil
+}
+
+// Key はkidに対応する公開鍵を返す
+// 有効期限が切れていても StaleTolerance の間は取得済みの鍵を返す
+// 鍵セットに無いkidはIdPが鍵をローテーションした直後の可能性があるため、jwksUnknownKidInterval に1回まで再取得する
+func (c *JWKSCache) Key(kid string) (*rsa.PublicKey, error) {
+	key, found, err := c.cachedKey(kid)
+	if found || err != nil {
+		return key, err
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// 待っている間に他のリクエストが再取得した場合は、その結果から探す
+	c.mu.RLock()
+	due := !c.now().Before(c.fetchedAt.Add(jwksUnknownKidInterval))
+	c.mu.RUnlock()
+	if due {
+		if err := c.Refresh(context.Background()); err != nil {
+			c.config.Logger.Warn("failed to refresh jwks for unknown kid",
+				"error", err,
+				"url", c.config.URL,
+				"kid", kid)
+		}
+	}
+
+	key, found, err = c.cachedKey(kid)
+	if err == nil && !found {
+		return nil, fmt.Errorf("public key not found for kid: %s", kid)
+	}
+	return key, err
+}
+
+// cachedKey は取得済みの鍵セットからkidに対応する公開鍵を探す（鍵セットに無い場合は found=false）
+func (c *JWKSCache) cachedKey(kid string) (key *rsa.PublicKey, found bool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.keys == nil {
+		return nil, false, fmt.Errorf("jwks has not been loaded")
+	}
+	if c.now().After(c.expiresAt.Add(c.config.StaleTolerance)) {
+		return nil, false, fmt.Errorf("jwks is stale: expired at %s", c.expiresAt.Format(time.RFC3339))
+	}
+
+	key, found = c.keys[kid]
+	return key, found, nil
+}
+
+// nextRefresh は有効期限の前に更新するまでの待ち時間を返す
+func (c *JWKSCache) nextRefresh() time.Duration {
+	c.mu.RLock()
+	remaining := c.expiresAt.Sub(c.now())
+	c.mu.RUnlock()
+
+	wait := time.Duration(float64(remaining) * jwksRefreshRatio)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+// cacheTTL はCache-Controlヘッダーから鍵セットの有効期間を求める
+func (c *JWKSCache) cacheTTL(header http.Header) time.Duration {
+	ttl := c.config.RefreshInterval
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache", directive == "no-store":
+			return jwksMinTTL
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if ttl < jwksMinTTL {
+		ttl = jwksMinTTL
+	}
+	return ttl
+}
+
+// jwksRetryDelay は失敗回数に応じた指数バックオフにジッターを加えた待ち時間を返す
+// 複数のゲートウェイが同時にIdPへ再試行しないよう [d/2, d) の範囲でばらつかせる
+func jwksRetryDelay(failures int) time.Duration {
+	d := jwksRetryBase << min(failures-1, 6)
+	if d > jwksRetryMax {
+		d = jwksRetryMax
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// jwk はJWKSの1つの鍵（RSA署名鍵のみ使用する）
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// MarshalJWKS は公開鍵マップをJWKSドキュメント（RS256の署名鍵）にエンコードする
+func MarshalJWKS(keys map[string]*rsa.PublicKey) ([]byte, error) {
+	kids := make([]string, 0, len(keys))
+	for kid := range keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: make([]jwk, 0, len(kids))}
+	for _, kid := range kids {
+		key := keys[kid]
+		set.Keys = append(set.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode jwks: %w", err)
+	}
+	return data, nil
+}
+
+// parseJWKS はJWKSドキュメントからRSA公開鍵を読み込む
+// RSA以外や署名用でない鍵は無視する
+func parseJWKS(r io.Reader) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk kid=%s: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks contains no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// rsaPublicKey はJWKのモジュラスと指数からRSA公開鍵を生成する
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode exponent: %w", err)
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("invalid modulus or exponent")
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
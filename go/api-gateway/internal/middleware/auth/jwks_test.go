@@ -0,0 +1,389 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"api-gateway/internal/requestctx"
+)
+
+// jwksDocument はテスト用のJWKSドキュメントを生成する
+func jwksDocument(t *testing.T, keys map[string]*rsa.PublicKey) []byte {
+	t.Helper()
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("failed to marshal jwks: %v", err)
+	}
+	return data
+}
+
+func TestJWKSCache_Refresh(t *testing.T) {
+	_, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	body := jwksDocument(t, map[string]*rsa.PublicKey{"test-kid": publicKey})
+
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "public, max-age=600")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := NewJWKSCache(JWKSConfig{URL: server.URL})
+	cache.now = func() time.Time { return now }
+
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	key, err := cache.Key("test-kid")
+	if err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	if key.N.Cmp(publicKey.N) != 0 || key.E != publicKey.E {
+		t.Error("key does not match published key")
+	}
+	if _, err := cache.Key("unknown-kid"); err == nil {
+		t.Error("Key(unknown-kid) error = nil, want error")
+	}
+
+	// 2回目はETagで条件付き取得し、304でも有効期限を延長する
+	now = now.Add(5 * time.Minute)
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("conditional requests = %d, want 1", notModified.Load())
+	}
+	if want := now.Add(10 * time.Minute); !cache.expiresAt.Equal(want) {
+		t.Errorf("expiresAt = %v, want %v", cache.expiresAt, want)
+	}
+	if _, err := cache.Key("test-kid"); err != nil {
+		t.Errorf("Key() after 304 error = %v", err)
+	}
+}
+
+func TestJWKSCache_Key_UnknownKid(t *testing.T) {
+	_, oldKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	_, newKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	before := jwksDocument(t, map[string]*rsa.PublicKey{"old-kid": oldKey})
+	after := jwksDocument(t, map[string]*rsa.PublicKey{"old-kid": oldKey, "new-kid": newKey})
+
+	var requests atomic.Int32
+	var rotated atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if rotated.Load() {
+			w.Write(after)
+			return
+		}
+		w.Write(before)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	now := time.Now()
+	cache := NewJWKSCache(JWKSConfig{URL: server.URL, RefreshInterval: time.Hour})
+	cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	rotated.Store(true)
+
+	// 直前に取得した場合は再取得しない
+	if _, err := cache.Key("new-kid"); err == nil {
+		t.Error("Key(new-kid) right after refresh error = nil, want error")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+
+	// ローテーション後の鍵は1回の再取得で見つかる
+	advance(jwksUnknownKidInterval)
+	key, err := cache.Key("new-kid")
+	if err != nil {
+		t.Fatalf("Key(new-kid) error = %v", err)
+	}
+	if key.N.Cmp(newKey.N) != 0 {
+		t.Error("key does not match rotated key")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+
+	// 同時に届いた未知のkidは再取得を1回にまとめ、間隔内の再取得はしない
+	advance(jwksUnknownKidInterval)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Key("unknown-kid"); err == nil {
+				t.Error("Key(unknown-kid) error = nil, want error")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+func TestJWKSCache_StaleTolerance(t *testing.T) {
+	_, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	body := jwksDocument(t, map[string]*rsa.PublicKey{"test-kid": publicKey})
+
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := NewJWKSCache(JWKSConfig{
+		URL:             server.URL,
+		RefreshInterval: time.Minute,
+		StaleTolerance:  10 * time.Minute,
+	})
+	cache.now = func() time.Time { return now }
+
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	// IdPの障害中は取得に失敗するが、取得済みの鍵は猶予の間使い続ける
+	down.Store(true)
+	now = now.Add(5 * time.Minute)
+	if err := cache.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh() error = nil, want error while IdP is down")
+	}
+	if _, err := cache.Key("test-kid"); err != nil {
+		t.Errorf("Key() within stale tolerance error = %v", err)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, err := cache.Key("test-kid"); err == nil {
+		t.Error("Key() after stale tolerance error = nil, want error")
+	}
+}
+
+func TestJWKSCache_Start_RetriesInBackground(t *testing.T) {
+	_, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	body := jwksDocument(t, map[string]*rsa.PublicKey{"test-kid": publicKey})
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 初回のみ失敗させる
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache := NewJWKSCache(JWKSConfig{URL: server.URL})
+	if err := cache.Start(ctx); err == nil {
+		t.Fatal("Start() error = nil, want initial fetch error")
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, err := cache.Key("test-kid"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("key was not loaded by background retry (requests = %d)", requests.Load())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestJWKSCache_CacheTTL(t *testing.T) {
+	cache := NewJWKSCache(JWKSConfig{RefreshInterval: 5 * time.Minute})
+
+	tests := []struct {
+		name         string
+		cacheControl string
+		want         time.Duration
+	}{
+		{name: "no header uses refresh interval", cacheControl: "", want: 5 * time.Minute},
+		{name: "max-age", cacheControl: "public, max-age=3600", want: time.Hour},
+		{name: "max-age below minimum", cacheControl: "max-age=1", want: jwksMinTTL},
+		{name: "no-cache", cacheControl: "no-cache", want: jwksMinTTL},
+		{name: "invalid max-age", cacheControl: "max-age=abc", want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.cacheControl != "" {
+				header.Set("Cache-Control", tt.cacheControl)
+			}
+			if got := cache.cacheTTL(header); got != tt.want {
+				t.Errorf("cacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKSRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		max      time.Duration
+	}{
+		{failures: 1, max: jwksRetryBase},
+		{failures: 3, max: 4 * jwksRetryBase},
+		{failures: 20, max: jwksRetryMax},
+	}
+
+	for _, tt := range tests {
+		for range 10 {
+			d := jwksRetryDelay(tt.failures)
+			if d < tt.max/2 || d >= tt.max {
+				t.Errorf("jwksRetryDelay(%d) = %v, want in [%v, %v)", tt.failures, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	_, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	n := base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+
+	tests := []struct {
+		name     string
+		body     string
+		wantKids []string
+		wantErr  bool
+	}{
+		{
+			name:     "skips non-RSA and encryption keys",
+			body:     `{"keys":[{"kty":"RSA","kid":"sig","use":"sig","n":"` + n + `","e":"AQAB"},{"kty":"RSA","kid":"enc","use":"enc","n":"` + n + `","e":"AQAB"},{"kty":"EC","kid":"ec","crv":"P-256"}]}`,
+			wantKids: []string{"sig"},
+		},
+		{name: "no usable keys", body: `{"keys":[{"kty":"EC","kid":"ec"}]}`, wantErr: true},
+		{name: "invalid modulus", body: `{"keys":[{"kty":"RSA","kid":"bad","n":"!!","e":"AQAB"}]}`, wantErr: true},
+		{name: "invalid json", body: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseJWKS(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJWKS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(keys) != len(tt.wantKids) {
+				t.Errorf("got %d keys, want %d", len(keys), len(tt.wantKids))
+			}
+			for _, kid := range tt.wantKids {
+				if _, ok := keys[kid]; !ok {
+					t.Errorf("key %s not found", kid)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_Process_JWKS(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	body := jwksDocument(t, map[string]*rsa.PublicKey{"idp-kid": publicKey})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	cache := NewJWKSCache(JWKSConfig{URL: server.URL})
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	middleware := NewJWTMiddleware(JWTConfig{JWKS: cache})
+
+	tokenString, err := generateTestToken(privateKey, "idp-kid", jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	ctx, err := middleware.Process(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if claims, _ := requestctx.Claims(ctx); claims["sub"] != "user123" {
+		t.Errorf("sub = %v, want user123", claims["sub"])
+	}
+}
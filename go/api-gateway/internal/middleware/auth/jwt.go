@@ -7,31 +7,39 @@ import (
 	"encoding/pem"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// contextKey はコンテキストのキー型
-type contextKey string
-
-const (
-	// ClaimsContextKey はJWTクレームを格納するコンテキストキー
-	ClaimsContextKey contextKey = "jwt_claims"
-)
-
 // JWTConfig はJWT認証ミドルウェアの設定
 type JWTConfig struct {
 	// PublicKeys はJWT検証用の公開鍵マップ (kid → 公開鍵)
 	PublicKeys map[string]*rsa.PublicKey
 
+	// KeySet はPublicKeysに無いkidを解決する、設定の再読み込みで入れ替わる公開鍵（nilの場合は使用しない）
+	KeySet *PublicKeySet
+
+	// JWKS はPublicKeys・KeySetに無いkidを解決するJWKSキャッシュ（nilの場合は使用しない）
+	JWKS *JWKSCache
+
 	// SkipValidation はtrueの場合、JWT検証をスキップする（開発環境用）
 	SkipValidation bool
 
 	// RequiredClaims は必須のクレーム
 	RequiredClaims []string
+
+	// Audit は認証失敗を記録する監査ロガー（nilの場合は記録しない）
+	Audit *audit.Logger
+
+	// Now は有効期限の検証に使う現在時刻を返す（nilの場合は time.Now）
+	Now func() time.Time
 }
 
 // JWTMiddleware はJWT認証を行うミドルウェア
@@ -41,6 +49,9 @@ type JWTMiddleware struct {
 
 // NewJWTMiddleware は新しいJWT認証ミドルウェアを作成する
 func NewJWTMiddleware(config JWTConfig) *JWTMiddleware {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
 	return &JWTMiddleware{
 		config: config,
 	}
@@ -49,12 +60,10 @@ func NewJWTMiddleware(config JWTConfig) *JWTMiddleware {
 // NewJWTMiddlewareFromPEMs はPEM形式の公開鍵マップからJWT認証ミドルウェアを作成する
 func NewJWTMiddlewareFromPEMs(publicKeyPEMs map[string]string, skipValidation bool, requiredClaims []string) (*JWTMiddleware, error) {
 	if skipValidation {
-		return &JWTMiddleware{
-			config: JWTConfig{
-				SkipValidation: true,
-				RequiredClaims: requiredClaims,
-			},
-		}, nil
+		return NewJWTMiddleware(JWTConfig{
+			SkipValidation: true,
+			RequiredClaims: requiredClaims,
+		}), nil
 	}
 
 	publicKeys := make(map[string]*rsa.PublicKey)
@@ -66,37 +75,61 @@ func NewJWTMiddlewareFromPEMs(publicKeyPEMs map[string]string, skipValidation bo
 		publicKeys[kid] = publicKey
 	}
 
-	return &JWTMiddleware{
-		config: JWTConfig{
-			PublicKeys:     publicKeys,
-			SkipValidation: false,
-			RequiredClaims: requiredClaims,
-		},
-	}, nil
+	return NewJWTMiddleware(JWTConfig{
+		PublicKeys:     publicKeys,
+		SkipValidation: false,
+		RequiredClaims: requiredClaims,
+	}), nil
 }
 
 // Process はJWT認証を実行する
 func (m *JWTMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	claims, err := m.authenticate(req)
+	if err != nil {
+		// 必須クレームの不足など、クレームを取得できた場合はユーザーIDも記録する
+		userID, _ := claims["sub"].(string)
+		m.config.Audit.Log(ctx, audit.Event{
+			Type:    audit.EventAuthFailure,
+			Outcome: audit.OutcomeFailure,
+			UserID:  userID,
+			Reason:  err.Error(),
+		})
+		return ctx, err
+	}
+
+	// クレームをコンテキストに保存
+	ctx = requestctx.WithClaims(ctx, claims)
+
+	return ctx, nil
+}
+
+// authenticate はAuthorizationヘッダーのJWTを検証し、クレームを返す
+func (m *JWTMiddleware) authenticate(req *http.Request) (jwt.MapClaims, error) {
 	// Authorizationヘッダーからトークンを取得
 	authHeader := req.Header.Get("Authorization")
 	if authHeader == "" {
-		return ctx, errors.NewUnauthorizedError("missing authorization header")
+		return nil, errors.NewUnauthorizedError("missing authorization header")
 	}
 
 	// "Bearer "プレフィックスを削除
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	if tokenString == authHeader {
-		return ctx, errors.NewUnauthorizedError("invalid authorization header format")
+		return nil, errors.NewUnauthorizedError("invalid authorization header format")
 	}
 
 	// 検証をスキップする場合は、トークンをパースせずにコンテキストに保存
 	if m.config.SkipValidation {
-		ctx = context.WithValue(ctx, ClaimsContextKey, jwt.MapClaims{
+		return jwt.MapClaims{
 			"skip_validation": true,
-		})
-		return ctx, nil
+		}, nil
 	}
 
+	return m.verify(tokenString)
+}
+
+// verify はJWTの署名と有効期限、必須クレームを検証し、クレームを返す
+// 必須クレームが不足している場合は、記録用のクレームとエラーを返す
+func (m *JWTMiddleware) verify(tokenString string) (jwt.MapClaims, error) {
 	// JWTトークンをパースして検証
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 		// アルゴリズムの確認
@@ -110,37 +143,39 @@ func (m *JWTMiddleware) Process(ctx context.Context, req *http.Request) (context
 			return nil, fmt.Errorf("kid header not found")
 		}
 
-		publicKey, ok := m.config.PublicKeys[kid]
-		if !ok {
-			return nil, fmt.Errorf("public key not found for kid: %s", kid)
+		if publicKey, ok := m.config.PublicKeys[kid]; ok {
+			return publicKey, nil
+		}
+		if publicKey, ok := m.config.KeySet.Key(kid); ok {
+			return publicKey, nil
+		}
+		if m.config.JWKS != nil {
+			return m.config.JWKS.Key(kid)
 		}
 
-		return publicKey, nil
-	})
+		return nil, fmt.Errorf("public key not found for kid: %s", kid)
+	}, jwt.WithTimeFunc(m.config.Now))
 
 	if err != nil {
-		return ctx, errors.NewUnauthorizedError(fmt.Sprintf("invalid token: %v", err))
+		return nil, errors.NewUnauthorizedError(fmt.Sprintf("invalid token: %v", err))
 	}
 
 	if !token.Valid {
-		return ctx, errors.NewUnauthorizedError("token is not valid")
+		return nil, errors.NewUnauthorizedError("token is not valid")
 	}
 
 	// クレームを取得
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return ctx, errors.NewUnauthorizedError("invalid token claims")
+		return nil, errors.NewUnauthorizedError("invalid token claims")
 	}
 
 	// 必須クレームの検証
 	if err := m.validateRequiredClaims(claims); err != nil {
-		return ctx, err
+		return claims, err
 	}
 
-	// クレームをコンテキストに保存
-	ctx = context.WithValue(ctx, ClaimsContextKey, claims)
-
-	return ctx, nil
+	return claims, nil
 }
 
 // validateRequiredClaims は必須クレームが存在するか検証する
@@ -173,8 +208,20 @@ func parsePublicKeyFromPEM(publicKeyPEM string) (*rsa.PublicKey, error) {
 	return rsaPub, nil
 }
 
-// GetClaimsFromContext はコンテキストからJWTクレームを取得する
-func GetClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
-	claims, ok := ctx.Value(ClaimsContextKey).(jwt.MapClaims)
-	return claims, ok
+// HasRole はクレームがロールを持つか返す
+// roles, role クレーム（文字列または配列）と、OAuth 2.0 の scope（スペース区切り）, scp クレームを確認する
+func HasRole(claims jwt.MapClaims, role string) bool {
+	for _, name := range []string{"roles", "role", "scope", "scp"} {
+		switch v := claims[name].(type) {
+		case string:
+			if slices.Contains(strings.Fields(v), role) {
+				return true
+			}
+		case []any:
+			if slices.Contains(v, any(role)) {
+				return true
+			}
+		}
+	}
+	return false
 }
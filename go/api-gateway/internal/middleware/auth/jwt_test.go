@@ -1,16 +1,22 @@
 package auth
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"api-gateway/internal/audit"
+	"api-gateway/internal/requestctx"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -170,7 +176,7 @@ func TestJWTMiddleware_Process_Success(t *testing.T) {
 	}
 
 	// コンテキストからクレームを取得
-	resultClaims, ok := GetClaimsFromContext(resultCtx)
+	resultClaims, ok := requestctx.Claims(resultCtx)
 	if !ok {
 		t.Fatal("claims not found in context")
 	}
@@ -226,7 +232,7 @@ func TestJWTMiddleware_Process_SkipValidation(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	claims, ok := GetClaimsFromContext(resultCtx)
+	claims, ok := requestctx.Claims(resultCtx)
 	if !ok {
 		t.Fatal("claims not found in context")
 	}
@@ -292,6 +298,63 @@ func TestJWTMiddleware_Process_ExpiredToken(t *testing.T) {
 	}
 }
 
+func TestJWTMiddleware_Process_Now(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	middleware := NewJWTMiddleware(JWTConfig{
+		PublicKeys: map[string]*rsa.PublicKey{
+			"test-kid": publicKey,
+		},
+		Now: func() time.Time { return now },
+	})
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr bool
+	}{
+		{
+			name:   "有効期限の1秒前は受け付ける",
+			claims: jwt.MapClaims{"sub": "user123", "exp": now.Add(time.Second).Unix()},
+		},
+		{
+			name:    "有効期限ちょうどは拒否",
+			claims:  jwt.MapClaims{"sub": "user123", "exp": now.Unix()},
+			wantErr: true,
+		},
+		{
+			name:    "nbfより前は拒否",
+			claims:  jwt.MapClaims{"sub": "user123", "exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Second).Unix()},
+			wantErr: true,
+		},
+		{
+			name:   "nbfちょうどは受け付ける",
+			claims: jwt.MapClaims{"sub": "user123", "exp": now.Add(time.Hour).Unix(), "nbf": now.Unix()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, err := generateTestToken(privateKey, "test-kid", tt.claims)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenString)
+
+			_, err = middleware.Process(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestJWTMiddleware_Process_MissingKid(t *testing.T) {
 	privateKey, publicKey, err := generateTestKeyPair()
 	if err != nil {
@@ -444,7 +507,7 @@ func TestJWTMiddleware_Process_MultiplePublicKeys(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error for token 1: %v", err)
 	}
-	claims1Result, ok := GetClaimsFromContext(resultCtx1)
+	claims1Result, ok := requestctx.Claims(resultCtx1)
 	if !ok || claims1Result["sub"] != "user1" {
 		t.Error("claims1 not found or invalid")
 	}
@@ -456,45 +519,12 @@ func TestJWTMiddleware_Process_MultiplePublicKeys(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error for token 2: %v", err)
 	}
-	claims2Result, ok := GetClaimsFromContext(resultCtx2)
+	claims2Result, ok := requestctx.Claims(resultCtx2)
 	if !ok || claims2Result["sub"] != "user2" {
 		t.Error("claims2 not found or invalid")
 	}
 }
 
-func TestGetClaimsFromContext(t *testing.T) {
-	claims := jwt.MapClaims{
-		"sub": "user123",
-		"iss": "test-issuer",
-	}
-
-	ctx := context.WithValue(context.Background(), ClaimsContextKey, claims)
-
-	resultClaims, ok := GetClaimsFromContext(ctx)
-
-	if !ok {
-		t.Fatal("claims not found in context")
-	}
-
-	if resultClaims["sub"] != "user123" {
-		t.Errorf("expected sub=user123, got %v", resultClaims["sub"])
-	}
-
-	if resultClaims["iss"] != "test-issuer" {
-		t.Errorf("expected iss=test-issuer, got %v", resultClaims["iss"])
-	}
-}
-
-func TestGetClaimsFromContext_NotFound(t *testing.T) {
-	ctx := context.Background()
-
-	_, ok := GetClaimsFromContext(ctx)
-
-	if ok {
-		t.Error("expected claims not found, but got ok=true")
-	}
-}
-
 func TestParsePublicKeyFromPEM(t *testing.T) {
 	_, publicKey, err := generateTestKeyPair()
 	if err != nil {
@@ -535,3 +565,110 @@ func TestParsePublicKeyFromPEM(t *testing.T) {
 		}
 	})
 }
+
+func TestJWTMiddleware_Process_Audit(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+
+	validToken, err := generateTestToken(privateKey, "test-kid", jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		requiredClaims []string
+		wantAudit      bool
+		wantUserID     string
+		wantReason     string
+	}{
+		{
+			name:          "success is not audited",
+			authorization: "Bearer " + validToken,
+		},
+		{
+			name:       "missing header",
+			wantAudit:  true,
+			wantReason: "missing authorization header",
+		},
+		{
+			name:           "missing required claim records user id",
+			authorization:  "Bearer " + validToken,
+			requiredClaims: []string{"sub", "iss"},
+			wantAudit:      true,
+			wantUserID:     "user123",
+			wantReason:     "missing required claim: iss",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			config := JWTConfig{
+				PublicKeys:     map[string]*rsa.PublicKey{"test-kid": publicKey},
+				RequiredClaims: tt.requiredClaims,
+				Audit:          audit.New(&buf),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			_, _ = NewJWTMiddleware(config).Process(audit.WithRoute(context.Background(), "/api/v1/users"), req)
+
+			if !tt.wantAudit {
+				if buf.Len() != 0 {
+					t.Errorf("unexpected audit event: %s", buf.String())
+				}
+				return
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse audit output: %v", err)
+			}
+			if entry["event"] != string(audit.EventAuthFailure) {
+				t.Errorf("event = %v, want %v", entry["event"], audit.EventAuthFailure)
+			}
+			if entry["route"] != "/api/v1/users" {
+				t.Errorf("route = %v, want /api/v1/users", entry["route"])
+			}
+			if tt.wantUserID != "" && entry["user_id"] != tt.wantUserID {
+				t.Errorf("user_id = %v, want %v", entry["user_id"], tt.wantUserID)
+			}
+			if reason, _ := entry["reason"].(string); !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("reason = %q, want to contain %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{name: "roles配列", claims: jwt.MapClaims{"roles": []any{"viewer", "admin"}}, want: true},
+		{name: "role文字列", claims: jwt.MapClaims{"role": "admin"}, want: true},
+		{name: "scope（スペース区切り）", claims: jwt.MapClaims{"scope": "openid admin"}, want: true},
+		{name: "scp配列", claims: jwt.MapClaims{"scp": []any{"admin"}}, want: true},
+		{name: "ロールが無い", claims: jwt.MapClaims{"roles": []any{"viewer"}, "scope": "openid"}, want: false},
+		{name: "部分一致は許可しない", claims: jwt.MapClaims{"role": "administrator"}, want: false},
+		{name: "クレームが無い", claims: jwt.MapClaims{"sub": "user123"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasRole(tt.claims, "admin"); got != tt.want {
+				t.Errorf("HasRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
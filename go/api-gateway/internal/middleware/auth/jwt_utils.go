@@ -4,6 +4,8 @@ import (
 	"crypto/rsa"
 	"fmt"
 	"os"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // LoadPublicKeysFromFiles はファイルから公開鍵を読み込む
@@ -27,6 +29,27 @@ func LoadPublicKeysFromFiles(keyFiles map[string]string) (map[string]*rsa.Public
 	return publicKeys, nil
 }
 
+// LoadPrivateKeysFromFiles はファイルからRSA秘密鍵（PKCS#1 または PKCS#8）を読み込む
+func LoadPrivateKeysFromFiles(keyFiles map[string]string) (map[string]*rsa.PrivateKey, error) {
+	privateKeys := make(map[string]*rsa.PrivateKey)
+
+	for kid, filePath := range keyFiles {
+		pemData, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key file for kid=%s: %w", kid, err)
+		}
+
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key for kid=%s: %w", kid, err)
+		}
+
+		privateKeys[kid] = privateKey
+	}
+
+	return privateKeys, nil
+}
+
 // LoadPublicKeysFromPEMs はPEM文字列から公開鍵を読み込む
 func LoadPublicKeysFromPEMs(publicKeyPEMs map[string]string) (map[string]*rsa.PublicKey, error) {
 	publicKeys := make(map[string]*rsa.PublicKey)
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// PublicKeySet は実行中に入れ替えられるJWT検証用の公開鍵 (kid → 公開鍵) を保持する
+// 設定の再読み込みで鍵を入れ替えても、作成済みのミドルウェアは次のリクエストから新しい鍵で検証する
+type PublicKeySet struct {
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+}
+
+// PublicKeysDiff は公開鍵の入れ替え前後の差分（kidのみを記録する）
+type PublicKeysDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	// Changed は同じkidで鍵が変わったもの
+	Changed []string `json:"changed"`
+}
+
+// NewPublicKeySet は公開鍵のセットを作成する（keys はコピーして保持する）
+func NewPublicKeySet(keys map[string]*rsa.PublicKey) *PublicKeySet {
+	return &PublicKeySet{keys: maps.Clone(keys)}
+}
+
+// Key はkidの公開鍵を返す（nilのセットは鍵を持たない）
+func (s *PublicKeySet) Key(kid string) (*rsa.PublicKey, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// Len は公開鍵の数を返す
+func (s *PublicKeySet) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.keys)
+}
+
+// KIDs は全ての公開鍵のkidをソートして返す
+func (s *PublicKeySet) KIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.keys))
+}
+
+// Replace は全ての公開鍵を keys に入れ替え、差分を返す
+func (s *PublicKeySet) Replace(keys map[string]*rsa.PublicKey) PublicKeysDiff {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var diff PublicKeysDiff
+	for _, kid := range slices.Sorted(maps.Keys(keys)) {
+		old, ok := s.keys[kid]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, kid)
+		case !old.Equal(keys[kid]):
+			diff.Changed = append(diff.Changed, kid)
+		}
+	}
+	for _, kid := range slices.Sorted(maps.Keys(s.keys)) {
+		if _, ok := keys[kid]; !ok {
+			diff.Removed = append(diff.Removed, kid)
+		}
+	}
+
+	s.keys = maps.Clone(keys)
+	return diff
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestPublicKeySet_Replace(t *testing.T) {
+	_, key1, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, key2, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := NewPublicKeySet(map[string]*rsa.PublicKey{"kept": key1, "rotated": key1, "removed": key1})
+	diff := set.Replace(map[string]*rsa.PublicKey{"kept": key1, "rotated": key2, "added": key2})
+
+	want := PublicKeysDiff{Added: []string{"added"}, Removed: []string{"removed"}, Changed: []string{"rotated"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Replace() = %+v, want %+v", diff, want)
+	}
+	if got := set.KIDs(); !reflect.DeepEqual(got, []string{"added", "kept", "rotated"}) {
+		t.Errorf("KIDs() = %v", got)
+	}
+	if key, ok := set.Key("rotated"); !ok || !key.Equal(key2) {
+		t.Error("Key(rotated) did not return the replaced key")
+	}
+	if _, ok := set.Key("removed"); ok {
+		t.Error("Key(removed) found after Replace()")
+	}
+
+	var empty *PublicKeySet
+	if _, ok := empty.Key("kept"); ok || empty.Len() != 0 {
+		t.Error("nil PublicKeySet returned a key")
+	}
+}
+
+func TestJWTMiddleware_Process_KeySet(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := generateTestToken(privateKey, "rotated", jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 作成済みのミドルウェアも入れ替えた鍵で検証する
+	set := NewPublicKeySet(nil)
+	middleware := NewJWTMiddleware(JWTConfig{KeySet: set})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if _, err := middleware.Process(context.Background(), req); err == nil {
+		t.Fatal("Process() error = nil before the key was added")
+	}
+	set.Replace(map[string]*rsa.PublicKey{"rotated": publicKey})
+	if _, err := middleware.Process(context.Background(), req); err != nil {
+		t.Errorf("Process() error = %v after the key was added", err)
+	}
+}
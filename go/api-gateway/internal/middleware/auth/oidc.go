@@ -0,0 +1,404 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"api-gateway/internal/audit"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/repository"
+	"api-gateway/internal/requestctx"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultOIDCCookieName はセッションCookieの名前のデフォルト値
+	DefaultOIDCCookieName = "gateway_session"
+	// DefaultOIDCSessionTTL はセッションの有効期限のデフォルト値
+	DefaultOIDCSessionTTL = 8 * time.Hour
+
+	// oidcLoginTimeout はIdPへリダイレクトしてからコールバックまでの制限時間
+	oidcLoginTimeout = 10 * time.Minute
+	// oidcTokenResponseLimit はトークンエンドポイントのレスポンスの上限バイト数
+	oidcTokenResponseLimit = 1 << 20
+)
+
+// defaultOIDCScopes は scopes を省略した場合に要求するスコープ
+var defaultOIDCScopes = []string{"openid", "profile", "email"}
+
+// OIDCConfig はOIDCセッション認証ミドルウェアの設定
+type OIDCConfig struct {
+	// Issuer はIDトークンの iss と照合するIdPの識別子（空の場合は照合しない）
+	Issuer string
+	// AuthorizationURL はIdPの認可エンドポイント
+	AuthorizationURL string
+	// TokenURL はIdPのトークンエンドポイント
+	TokenURL string
+	// ClientID, ClientSecret はIdPに登録したクライアントの資格情報
+	ClientID     string
+	ClientSecret string
+	// RedirectURL はIdPに登録したコールバックURL。このパスへのリクエストはミドルウェアが処理する
+	RedirectURL string
+	// Scopes は要求するスコープ（空は openid, profile, email）
+	Scopes []string
+	// LogoutPath はセッションを削除してCookieを消すパス（空の場合は提供しない）
+	// 同じサイトのページからのPOSTだけを受け付ける（GETは405、他サイトからは403）
+	LogoutPath string
+
+	// CookieName はセッションCookieの名前（空は DefaultOIDCCookieName）
+	CookieName string
+	// InsecureCookie はCookieの Secure 属性を外すか（HTTPで動かす開発環境用）
+	InsecureCookie bool
+	// SessionTTL はセッションの有効期限（0は DefaultOIDCSessionTTL）
+	SessionTTL time.Duration
+
+	// PublicKeys, KeySet, JWKS はIDトークンの署名の検証に使う鍵（jwt ミドルウェアと共通）
+	PublicKeys map[string]*rsa.PublicKey
+	KeySet     *PublicKeySet
+	JWKS       *JWKSCache
+
+	// Sessions はセッションとログインの state の保存先
+	Sessions repository.OIDCSessionRepository
+
+	// HTTPClient はトークンエンドポイントへのリクエストに使うクライアント（nilの場合はタイムアウト10秒）
+	HTTPClient *http.Client
+
+	// Audit はログインの失敗を記録する監査ロガー（nilの場合は記録しない）
+	Audit *audit.Logger
+
+	// Now はセッションとIDトークンの有効期限の判定に使う現在時刻を返す（nilの場合は time.Now）
+	Now func() time.Time
+}
+
+// OIDCMiddleware はゲートウェイがOIDCの認可コードフローを行い、セッションCookieで認証するミドルウェア
+// ブラウザのアプリがJavaScriptでJWTを扱わずにゲートウェイを使えるようにする
+//
+// セッションが無いブラウザのリクエスト（GET かつ text/html を受け付ける）はIdPへリダイレクトし、
+// コールバックでコードをIDトークンと交換してセッションを保存し、HttpOnly のCookieを設定して元のパスへ戻す。
+// それ以外のリクエストは401を返す。セッションのクレームは jwt ミドルウェアと同じくコンテキストに保存し、
+// セッションCookieはバックエンドへ転送しない
+//
+// ミドルウェアはレスポンスを書き込めないため、リダイレクトは Location と Set-Cookie を付けた3xxのエラーとして返す
+type OIDCMiddleware struct {
+	config       OIDCConfig
+	callbackPath string
+	stateCookie  string
+	verifier     *JWTMiddleware
+}
+
+// NewOIDCMiddleware は新しいOIDCセッション認証ミドルウェアを作成する
+func NewOIDCMiddleware(config OIDCConfig) (*OIDCMiddleware, error) {
+	if config.AuthorizationURL == "" || config.TokenURL == "" || config.ClientID == "" {
+		return nil, fmt.Errorf("oidc middleware requires authorization_url, token_url and client_id")
+	}
+	if config.Sessions == nil {
+		return nil, fmt.Errorf("oidc middleware requires a session store (redis)")
+	}
+	if len(config.PublicKeys) == 0 && config.KeySet.Len() == 0 && config.JWKS == nil {
+		return nil, fmt.Errorf("oidc middleware requires jwt public keys or jwks to verify id tokens")
+	}
+	redirectURL, err := url.Parse(config.RedirectURL)
+	if err != nil || !redirectURL.IsAbs() || redirectURL.Path == "" {
+		return nil, fmt.Errorf("oidc redirect_url must be an absolute URL: %q", config.RedirectURL)
+	}
+	if config.LogoutPath != "" && !strings.HasPrefix(config.LogoutPath, "/") {
+		return nil, fmt.Errorf("oidc logout_path must start with /: %s", config.LogoutPath)
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaultOIDCScopes
+	}
+	if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultOIDCCookieName
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = DefaultOIDCSessionTTL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &OIDCMiddleware{
+		config:       config,
+		callbackPath: redirectURL.Path,
+		stateCookie:  config.CookieName + "_state",
+		verifier: NewJWTMiddleware(JWTConfig{
+			PublicKeys:     config.PublicKeys,
+			KeySet:         config.KeySet,
+			JWKS:           config.JWKS,
+			RequiredClaims: []string{"sub"},
+			Now:            config.Now,
+		}),
+	}, nil
+}
+
+// Process はセッションCookieを検証し、クレームをコンテキストに保存する
+func (m *OIDCMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	switch req.URL.Path {
+	case m.callbackPath:
+		return ctx, m.callback(ctx, req)
+	case m.config.LogoutPath:
+		if m.config.LogoutPath != "" {
+			return ctx, m.logout(ctx, req)
+		}
+	}
+
+	session, err := m.session(ctx, req)
+	if stderrors.Is(err, repository.ErrNotFound) {
+		return ctx, m.login(ctx, req)
+	}
+	if err != nil {
+		return ctx, errors.NewError(http.StatusServiceUnavailable, "SESSION_STORE_UNAVAILABLE", "failed to load session")
+	}
+
+	removeCookie(req, m.config.CookieName)
+	return requestctx.WithClaims(ctx, jwt.MapClaims(session.Claims)), nil
+}
+
+// session はCookieのセッションIDから有効なセッションを返す（無い、または期限切れの場合は repository.ErrNotFound）
+func (m *OIDCMiddleware) session(ctx context.Context, req *http.Request) (repository.OIDCSession, error) {
+	cookie, err := req.Cookie(m.config.CookieName)
+	if err != nil || cookie.Value == "" {
+		return repository.OIDCSession{}, repository.ErrNotFound
+	}
+	session, err := m.config.Sessions.GetSession(ctx, cookie.Value)
+	if err != nil {
+		return repository.OIDCSession{}, err
+	}
+	if m.config.Now().After(session.ExpiresAt) {
+		return repository.OIDCSession{}, repository.ErrNotFound
+	}
+	return session, nil
+}
+
+// login はブラウザのリクエストをIdPの認可エンドポイントへリダイレクトする
+func (m *OIDCMiddleware) login(ctx context.Context, req *http.Request) error {
+	if req.Method != http.MethodGet || !strings.Contains(req.Header.Get("Accept"), "text/html") {
+		return errors.NewUnauthorizedError("login required")
+	}
+
+	state, nonce, verifier := randomToken(), randomToken(), randomToken()
+	login := repository.OIDCLoginState{Nonce: nonce, CodeVerifier: verifier, ReturnTo: req.URL.RequestURI()}
+	if err := m.config.Sessions.SaveLoginState(ctx, state, login, oidcLoginTimeout); err != nil {
+		return errors.NewError(http.StatusServiceUnavailable, "SESSION_STORE_UNAVAILABLE", "failed to start login")
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {m.config.ClientID},
+		"redirect_uri":          {m.config.RedirectURL},
+		"scope":                 {strings.Join(m.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	authURL := m.config.AuthorizationURL + "?" + query.Encode()
+	if strings.Contains(m.config.AuthorizationURL, "?") {
+		authURL = m.config.AuthorizationURL + "&" + query.Encode()
+	}
+
+	// state はブラウザにも保存し、ログインを開始したブラウザからのコールバックであることを確認する
+	return m.redirect(authURL, m.cookie(m.stateCookie, state, m.callbackPath, oidcLoginTimeout))
+}
+
+// callback は認可コードをIDトークンと交換してセッションを作成し、ログイン前のパスへリダイレクトする
+func (m *OIDCMiddleware) callback(ctx context.Context, req *http.Request) error {
+	query := req.URL.Query()
+	if idpErr := query.Get("error"); idpErr != "" {
+		return m.loginFailure(ctx, "", fmt.Sprintf("authorization failed: %s", idpErr))
+	}
+
+	state := query.Get("state")
+	stateCookie, err := req.Cookie(m.stateCookie)
+	if state == "" || err != nil || stateCookie.Value != state {
+		return m.loginFailure(ctx, "", "state does not match")
+	}
+	login, err := m.config.Sessions.TakeLoginState(ctx, state)
+	if err != nil {
+		return m.loginFailure(ctx, "", "invalid or expired login state")
+	}
+
+	claims, err := m.exchange(ctx, query.Get("code"), login)
+	if err != nil {
+		userID, _ := claims["sub"].(string)
+		return m.loginFailure(ctx, userID, err.Error())
+	}
+
+	sessionID := randomToken()
+	session := repository.OIDCSession{Claims: claims, ExpiresAt: m.config.Now().Add(m.config.SessionTTL)}
+	if err := m.config.Sessions.SaveSession(ctx, sessionID, session, m.config.SessionTTL); err != nil {
+		return errors.NewError(http.StatusServiceUnavailable, "SESSION_STORE_UNAVAILABLE", "failed to save session")
+	}
+
+	return m.redirect(login.ReturnTo,
+		m.cookie(m.config.CookieName, sessionID, "/", m.config.SessionTTL),
+		m.cookie(m.stateCookie, "", m.callbackPath, -1))
+}
+
+// exchange は認可コードをトークンエンドポイントでIDトークンと交換し、検証したクレームを返す
+// 検証に失敗した場合でも、署名を検証できたクレームは記録用に返す
+func (m *OIDCMiddleware) exchange(ctx context.Context, code string, login repository.OIDCLoginState) (jwt.MapClaims, error) {
+	if code == "" {
+		return nil, fmt.Errorf("missing authorization code")
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {m.config.RedirectURL},
+		"client_id":     {m.config.ClientID},
+		"code_verifier": {login.CodeVerifier},
+	}
+	if m.config.ClientSecret != "" {
+		form.Set("client_secret", m.config.ClientSecret)
+	}
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	tokenReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenReq.Header.Set("Accept", "application/json")
+
+	resp, err := m.config.HTTPClient.Do(tokenReq)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcTokenResponseLimit)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	claims, err := m.verifier.verify(token.IDToken)
+	if err != nil {
+		return claims, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != login.Nonce {
+		return claims, fmt.Errorf("id_token nonce does not match")
+	}
+	if audience, _ := claims.GetAudience(); !slices.Contains(audience, m.config.ClientID) {
+		return claims, fmt.Errorf("id_token audience does not include client_id")
+	}
+	if m.config.Issuer != "" {
+		if issuer, _ := claims.GetIssuer(); issuer != m.config.Issuer {
+			return claims, fmt.Errorf("id_token issuer does not match: %s", issuer)
+		}
+	}
+	return claims, nil
+}
+
+// logout はセッションを削除し、Cookieを消してルートへリダイレクトする
+// 画像やリンクなど他サイトからのログアウトを防ぐため、同じサイトからのPOSTだけを受け付ける
+func (m *OIDCMiddleware) logout(ctx context.Context, req *http.Request) error {
+	if req.Method != http.MethodPost {
+		return errors.WithHeader(errors.NewError(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "logout requires POST"), "Allow", http.MethodPost)
+	}
+	if !sameSite(req) {
+		return errors.NewForbiddenError("cross-site logout request rejected")
+	}
+	if cookie, err := req.Cookie(m.config.CookieName); err == nil && cookie.Value != "" {
+		if err := m.config.Sessions.DeleteSession(ctx, cookie.Value); err != nil {
+			return errors.NewError(http.StatusServiceUnavailable, "SESSION_STORE_UNAVAILABLE", "failed to delete session")
+		}
+	}
+	return m.redirect("/", m.cookie(m.config.CookieName, "", "/", -1))
+}
+
+// sameSite はリクエストが同じサイトのページから送られたかを返す
+// Sec-Fetch-Site を優先し、無い場合は Origin のホストを比較する（どちらも無いブラウザ以外のクライアントは許可する）
+func sameSite(req *http.Request) bool {
+	if site := req.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "same-site"
+	}
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && u.Host == req.Host
+}
+
+// loginFailure はログインの失敗を監査ログに記録し、401エラーを返す
+func (m *OIDCMiddleware) loginFailure(ctx context.Context, userID, reason string) error {
+	m.config.Audit.Log(ctx, audit.Event{
+		Type:    audit.EventAuthFailure,
+		Outcome: audit.OutcomeFailure,
+		UserID:  userID,
+		Reason:  "oidc: " + reason,
+	})
+	return errors.NewUnauthorizedError(fmt.Sprintf("login failed: %s", reason))
+}
+
+// redirect は Location と Set-Cookie を付けた302のエラーを返す
+func (m *OIDCMiddleware) redirect(location string, cookies ...*http.Cookie) errors.GatewayError {
+	err := errors.WithHeader(errors.NewError(http.StatusFound, "OIDC_REDIRECT", "redirecting"), "Location", location)
+	for _, cookie := range cookies {
+		err = errors.WithHeader(err, "Set-Cookie", cookie.String())
+	}
+	return errors.WithHeader(err, "Cache-Control", "no-store")
+}
+
+// cookie はHttpOnlyのCookieを作成する（ttlが負の場合は削除するCookie）
+// クロスサイトのPOSTでセッションを送らないよう SameSite=Lax にする
+func (m *OIDCMiddleware) cookie(name, value, path string, ttl time.Duration) *http.Cookie {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   !m.config.InsecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// removeCookie はリクエストからCookieを1つ取り除く
+func removeCookie(req *http.Request, name string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			req.AddCookie(cookie)
+		}
+	}
+}
+
+// randomToken は推測できない32バイトのランダムな文字列を返す
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
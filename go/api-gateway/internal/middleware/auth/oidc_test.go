@@ -0,0 +1,336 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/repository"
+	"api-gateway/internal/requestctx"
+	redisclient "api-gateway/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcTestIdP はテスト用のトークンエンドポイント
+type oidcTestIdP struct {
+	server     *httptest.Server
+	privateKey *rsa.PrivateKey
+	// challenge は認可リクエストの code_challenge（トークンリクエストの code_verifier と照合する）
+	challenge string
+	// nonce はIDトークンに含める nonce
+	nonce string
+	// audience はIDトークンの aud（空は client_id）
+	audience string
+}
+
+func newOIDCTestIdP(t *testing.T) *oidcTestIdP {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	idp := &oidcTestIdP{privateKey: privateKey}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(verifier[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		audience := idp.audience
+		if audience == "" {
+			audience = r.PostForm.Get("client_id")
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   "https://idp.example.com",
+			"sub":   "user123",
+			"aud":   audience,
+			"nonce": idp.nonce,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "idp-kid"
+		signed, _ := token.SignedString(privateKey)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func newTestOIDCMiddleware(t *testing.T, idp *oidcTestIdP) (*auth.OIDCMiddleware, *repository.RedisOIDCSessionRepository) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client, err := redisclient.NewClient(redisclient.Config{Host: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	sessions := repository.NewRedisOIDCSessionRepository(client, "")
+
+	m, err := auth.NewOIDCMiddleware(auth.OIDCConfig{
+		Issuer:           "https://idp.example.com",
+		AuthorizationURL: "https://idp.example.com/authorize",
+		TokenURL:         idp.server.URL + "/token",
+		ClientID:         "gateway-web",
+		ClientSecret:     "secret",
+		RedirectURL:      "https://app.example.com/app/oidc/callback",
+		LogoutPath:       "/app/logout",
+		PublicKeys:       map[string]*rsa.PublicKey{"idp-kid": &idp.privateKey.PublicKey},
+		Sessions:         sessions,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCMiddleware() error = %v", err)
+	}
+	return m, sessions
+}
+
+// oidcResponse はミドルウェアが返したエラーのステータスとヘッダー
+func oidcResponse(t *testing.T, err error) (int, http.Header) {
+	t.Helper()
+	gatewayErr, ok := err.(errors.GatewayError)
+	if !ok {
+		t.Fatalf("Process() error = %v, want GatewayError", err)
+	}
+	return gatewayErr.StatusCode(), errors.ResponseHeaders(gatewayErr)
+}
+
+// oidcCookie はSet-Cookieヘッダーから名前が一致するCookieを返す
+func oidcCookie(header http.Header, name string) *http.Cookie {
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestOIDCMiddleware_Process_LoginFlow(t *testing.T) {
+	idp := newOIDCTestIdP(t)
+	m, _ := newTestOIDCMiddleware(t, idp)
+	ctx := context.Background()
+
+	// 1. セッションの無いブラウザのリクエストはIdPへリダイレクトする
+	req := httptest.NewRequest(http.MethodGet, "/app/orders?page=2", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	_, err := m.Process(ctx, req)
+	status, header := oidcResponse(t, err)
+	if status != http.StatusFound {
+		t.Fatalf("login status = %d, want 302", status)
+	}
+	location, _ := url.Parse(header.Get("Location"))
+	query := location.Query()
+	if location.Host != "idp.example.com" || query.Get("client_id") != "gateway-web" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("Location = %s", location)
+	}
+	if query.Get("scope") != "openid profile email" {
+		t.Errorf("scope = %q", query.Get("scope"))
+	}
+	stateCookie := oidcCookie(header, "gateway_session_state")
+	if stateCookie == nil || stateCookie.Value != query.Get("state") {
+		t.Fatalf("state cookie = %v, want %s", stateCookie, query.Get("state"))
+	}
+	idp.challenge = query.Get("code_challenge")
+	idp.nonce = query.Get("nonce")
+
+	// 2. コールバックでセッションを作成し、元のパスへ戻す
+	req = httptest.NewRequest(http.MethodGet, "/app/oidc/callback?code=good-code&state="+url.QueryEscape(query.Get("state")), nil)
+	req.AddCookie(stateCookie)
+	_, err = m.Process(ctx, req)
+	status, header = oidcResponse(t, err)
+	if status != http.StatusFound || header.Get("Location") != "/app/orders?page=2" {
+		t.Fatalf("callback = %d %s, want 302 /app/orders?page=2", status, header.Get("Location"))
+	}
+	sessionCookie := oidcCookie(header, auth.DefaultOIDCCookieName)
+	if sessionCookie == nil || !sessionCookie.HttpOnly || !sessionCookie.Secure || sessionCookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("session cookie = %v, want HttpOnly, Secure, SameSite=Lax", sessionCookie)
+	}
+
+	// 3. セッションCookieのリクエストはクレームをコンテキストに保存し、Cookieを転送しない
+	req = httptest.NewRequest(http.MethodGet, "/app/orders", nil)
+	req.AddCookie(&http.Cookie{Name: auth.DefaultOIDCCookieName, Value: sessionCookie.Value})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	resultCtx, err := m.Process(ctx, req)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	claims, ok := requestctx.Claims(resultCtx)
+	if !ok || claims["sub"] != "user123" {
+		t.Errorf("claims = %v, want sub=user123", claims)
+	}
+	if cookie := req.Header.Get("Cookie"); cookie != "theme=dark" {
+		t.Errorf("Cookie = %q, want theme=dark", cookie)
+	}
+
+	// 4. ログアウトでセッションを削除する
+	req = httptest.NewRequest(http.MethodPost, "/app/logout", nil)
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.AddCookie(&http.Cookie{Name: auth.DefaultOIDCCookieName, Value: sessionCookie.Value})
+	_, err = m.Process(ctx, req)
+	status, header = oidcResponse(t, err)
+	if cleared := oidcCookie(header, auth.DefaultOIDCCookieName); status != http.StatusFound || cleared == nil || cleared.MaxAge >= 0 {
+		t.Errorf("logout = %d %v, want 302 with cleared cookie", status, header)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/app/orders", nil)
+	req.AddCookie(&http.Cookie{Name: auth.DefaultOIDCCookieName, Value: sessionCookie.Value})
+	_, err = m.Process(ctx, req)
+	if status, _ := oidcResponse(t, err); status != http.StatusUnauthorized {
+		t.Errorf("after logout status = %d, want 401", status)
+	}
+}
+
+func TestOIDCMiddleware_Process_Logout(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		header      map[string]string
+		wantStatus  int
+		wantDeleted bool
+	}{
+		{name: "同じオリジンからのPOST", method: http.MethodPost, header: map[string]string{"Sec-Fetch-Site": "same-origin"}, wantStatus: http.StatusFound, wantDeleted: true},
+		{name: "Originが同じホストのPOST", method: http.MethodPost, header: map[string]string{"Origin": "https://app.example.com"}, wantStatus: http.StatusFound, wantDeleted: true},
+		{name: "ヘッダーの無いPOST", method: http.MethodPost, wantStatus: http.StatusFound, wantDeleted: true},
+		{name: "GETは拒否", method: http.MethodGet, header: map[string]string{"Sec-Fetch-Site": "same-origin"}, wantStatus: http.StatusMethodNotAllowed},
+		{name: "他サイトからのPOST", method: http.MethodPost, header: map[string]string{"Sec-Fetch-Site": "cross-site"}, wantStatus: http.StatusForbidden},
+		{name: "Originが別のホストのPOST", method: http.MethodPost, header: map[string]string{"Origin": "https://evil.example.net"}, wantStatus: http.StatusForbidden},
+		{name: "OriginがnullのPOST", method: http.MethodPost, header: map[string]string{"Origin": "null"}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, sessions := newTestOIDCMiddleware(t, newOIDCTestIdP(t))
+			session := repository.OIDCSession{Claims: map[string]any{"sub": "user123"}, ExpiresAt: time.Now().Add(time.Hour)}
+			if err := sessions.SaveSession(ctx, "session-id", session, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(tt.method, "https://app.example.com/app/logout", nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			req.AddCookie(&http.Cookie{Name: auth.DefaultOIDCCookieName, Value: "session-id"})
+			_, err := m.Process(ctx, req)
+			status, header := oidcResponse(t, err)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed && header.Get("Allow") != http.MethodPost {
+				t.Errorf("Allow = %q, want POST", header.Get("Allow"))
+			}
+			_, err = sessions.GetSession(ctx, "session-id")
+			if deleted := stderrors.Is(err, repository.ErrNotFound); deleted != tt.wantDeleted {
+				t.Errorf("session deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestOIDCMiddleware_Process_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		audience    string
+		callback    string
+		stateCookie string
+		wantStatus  int
+	}{
+		{name: "ブラウザ以外のリクエスト", wantStatus: http.StatusUnauthorized},
+		{name: "stateのCookieが異なる", callback: "code=good-code", stateCookie: "other", wantStatus: http.StatusUnauthorized},
+		{name: "IdPのエラー", callback: "error=access_denied", wantStatus: http.StatusUnauthorized},
+		{name: "認可コードが不正", callback: "code=bad-code", wantStatus: http.StatusUnauthorized},
+		{name: "audienceが異なる", callback: "code=good-code", audience: "other-client", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newOIDCTestIdP(t)
+			idp.audience = tt.audience
+			m, _ := newTestOIDCMiddleware(t, idp)
+			ctx := context.Background()
+
+			if tt.callback == "" {
+				req := httptest.NewRequest(http.MethodGet, "/app/api/orders", nil)
+				req.Header.Set("Accept", "application/json")
+				_, err := m.Process(ctx, req)
+				if status, _ := oidcResponse(t, err); status != tt.wantStatus {
+					t.Errorf("status = %d, want %d", status, tt.wantStatus)
+				}
+				return
+			}
+
+			// ログインを開始して state を発行する
+			req := httptest.NewRequest(http.MethodGet, "/app/", nil)
+			req.Header.Set("Accept", "text/html")
+			_, err := m.Process(ctx, req)
+			_, header := oidcResponse(t, err)
+			location, _ := url.Parse(header.Get("Location"))
+			state := location.Query().Get("state")
+			idp.challenge = location.Query().Get("code_challenge")
+			idp.nonce = location.Query().Get("nonce")
+
+			stateCookie := state
+			if tt.stateCookie != "" {
+				stateCookie = tt.stateCookie
+			}
+			req = httptest.NewRequest(http.MethodGet, "/app/oidc/callback?state="+url.QueryEscape(state)+"&"+tt.callback, nil)
+			req.AddCookie(&http.Cookie{Name: "gateway_session_state", Value: stateCookie})
+			_, err = m.Process(ctx, req)
+			status, header := oidcResponse(t, err)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if strings.Contains(header.Get("Set-Cookie"), auth.DefaultOIDCCookieName+"=") {
+				t.Errorf("session cookie should not be set: %s", header.Get("Set-Cookie"))
+			}
+		})
+	}
+}
+
+func TestNewOIDCMiddleware(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	valid := auth.OIDCConfig{
+		AuthorizationURL: "https://idp.example.com/authorize",
+		TokenURL:         "https://idp.example.com/token",
+		ClientID:         "gateway-web",
+		RedirectURL:      "https://app.example.com/callback",
+		PublicKeys:       map[string]*rsa.PublicKey{"kid": &privateKey.PublicKey},
+		Sessions:         repository.NewRedisOIDCSessionRepository(nil, ""),
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *auth.OIDCConfig)
+		wantErr bool
+	}{
+		{name: "正しい設定", modify: func(c *auth.OIDCConfig) {}},
+		{name: "client_idが無い", modify: func(c *auth.OIDCConfig) { c.ClientID = "" }, wantErr: true},
+		{name: "セッションの保存先が無い", modify: func(c *auth.OIDCConfig) { c.Sessions = nil }, wantErr: true},
+		{name: "検証に使う鍵が無い", modify: func(c *auth.OIDCConfig) { c.PublicKeys = nil }, wantErr: true},
+		{name: "相対パスのredirect_url", modify: func(c *auth.OIDCConfig) { c.RedirectURL = "/callback" }, wantErr: true},
+		{name: "スラッシュで始まらないlogout_path", modify: func(c *auth.OIDCConfig) { c.LogoutPath = "logout" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			_, err := auth.NewOIDCMiddleware(config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewOIDCMiddleware() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
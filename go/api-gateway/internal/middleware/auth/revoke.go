@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/errors"
 	"api-gateway/internal/repository"
+	"api-gateway/internal/requestctx"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -16,10 +18,14 @@ import (
 // RevokeConfig はRevokeミドルウェアの設定
 type RevokeConfig struct {
 	Repository     repository.SessionRepository
-	UserIDClaim    string // ユーザーIDのクレーム名（デフォルト: "sub")
-	IssuedAtClaim  string // 発行時刻のクレーム名（デフォルト: "iat")
-	FailOpen       bool   // Redis接続エラー時に通過させるか（デフォルト: false)
+	UserIDClaim    string        // ユーザーIDのクレーム名（デフォルト: "sub")
+	IssuedAtClaim  string        // 発行時刻のクレーム名（デフォルト: "iat")
+	FailOpen       bool          // Redis接続エラー時に通過させるか（デフォルト: false)
+	ClockSkew      time.Duration // 発行時刻と失効時刻の比較で許容するずれ（デフォルト: 0）
 	Logger         *slog.Logger
+	Audit          *audit.Logger     // 拒否を記録する監査ロガー（nilの場合は記録しない）
+	Cache          *RevokedTimeCache // 失効時刻のローカルキャッシュ（nilの場合は毎回Redisに問い合わせる）
+	Now            func() time.Time  // 現在時刻を返す（nilの場合は time.Now）
 }
 
 // RevokeMiddleware はJWT Revokeをチェックするミドルウェア
@@ -28,7 +34,11 @@ type RevokeMiddleware struct {
 	userIDClaim   string
 	issuedAtClaim string
 	failOpen      bool
+	clockSkew     time.Duration
 	logger        *slog.Logger
+	audit         *audit.Logger
+	cache         *RevokedTimeCache
+	now           func() time.Time
 }
 
 // NewRevokeMiddleware は新しいRevokeMiddlewareを作成する
@@ -43,20 +53,27 @@ func NewRevokeMiddleware(config RevokeConfig) *RevokeMiddleware {
 	if config.Logger == nil {
 		config.Logger = slog.Default()
 	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
 
 	return &RevokeMiddleware{
 		repository:    config.Repository,
 		userIDClaim:   config.UserIDClaim,
 		issuedAtClaim: config.IssuedAtClaim,
 		failOpen:      config.FailOpen,
+		clockSkew:     config.ClockSkew,
 		logger:        config.Logger,
+		audit:         config.Audit,
+		cache:         config.Cache,
+		now:           config.Now,
 	}
 }
 
 // Process はRevokeチェックを実行する
 func (m *RevokeMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
 	// コンテキストからClaimsを取得
-	claims, ok := GetClaimsFromContext(ctx)
+	claims, ok := requestctx.Claims(ctx)
 	if !ok {
 		// JWTミドルウェアの後に実行されることを想定
 		// Claimsが存在しない場合はスキップ
@@ -67,6 +84,7 @@ func (m *RevokeMiddleware) Process(ctx context.Context, req *http.Request) (cont
 	userID, err := m.getUserID(claims)
 	if err != nil {
 		m.logger.Warn("failed to get user id from claims", "error", err)
+		m.deny(ctx, "", fmt.Sprintf("invalid token claims: %v", err))
 		return ctx, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid token claims")
 	}
 
@@ -74,11 +92,12 @@ func (m *RevokeMiddleware) Process(ctx context.Context, req *http.Request) (cont
 	issuedAt, err := m.getIssuedAt(claims)
 	if err != nil {
 		m.logger.Warn("failed to get issued at from claims", "error", err, "user_id", userID)
+		m.deny(ctx, userID, fmt.Sprintf("invalid token claims: %v", err))
 		return ctx, errors.NewError(http.StatusUnauthorized, "Unauthorized", "invalid token claims")
 	}
 
-	// Redisから失効時刻を取得
-	revokedTime, err := m.repository.GetRevokedTime(ctx, userID)
+	// ローカルキャッシュまたはRedisから失効時刻を取得
+	revokedTime, err := m.getRevokedTime(ctx, userID)
 	if err != nil {
 		m.logger.Error("failed to get revoked time from redis", "error", err, "user_id", userID)
 
@@ -89,6 +108,7 @@ func (m *RevokeMiddleware) Process(ctx context.Context, req *http.Request) (cont
 			return ctx, nil
 		}
 		// Fail Close: エラー時は拒否（セキュリティ優先）
+		m.deny(ctx, userID, "session service unavailable")
 		return ctx, errors.NewError(http.StatusServiceUnavailable, "ServiceUnavailable", "session service unavailable")
 	}
 
@@ -97,18 +117,59 @@ func (m *RevokeMiddleware) Process(ctx context.Context, req *http.Request) (cont
 		return ctx, nil
 	}
 
+	// 未来の失効時刻は失効を記録したサーバーとの時刻のずれを示すため、判定は変えずに警告を残す
+	if now := m.now(); revokedTime.After(now) {
+		m.logger.Warn("revoked time is in the future",
+			"user_id", userID,
+			"revoked_at", revokedTime.Format(time.RFC3339),
+			"now", now.Format(time.RFC3339))
+	}
+
 	// 発行時刻が失効時刻より前の場合は拒否
-	if issuedAt.Before(revokedTime) {
+	// 発行時刻は秒単位のため、ログアウトと同じ秒に再ログインして発行されたトークンも失効時刻より前になる
+	// clockSkew の範囲のずれは許容する（失効の直前に発行されたトークンも同じ幅で通過する）
+	if issuedAt.Add(m.clockSkew).Before(revokedTime) {
 		m.logger.Info("token revoked",
 			"user_id", userID,
 			"issued_at", issuedAt.Format(time.RFC3339),
-			"revoked_at", revokedTime.Format(time.RFC3339))
+			"revoked_at", revokedTime.Format(time.RFC3339),
+			"clock_skew", m.clockSkew.String())
+		m.deny(ctx, userID, "token has been revoked")
 		return ctx, errors.NewError(http.StatusUnauthorized, "Unauthorized", "token has been revoked")
 	}
 
 	return ctx, nil
 }
 
+// getRevokedTime はユーザーの失効時刻を返す
+// キャッシュがある場合はキャッシュを優先し、Redisから取得した値（失効していない場合のゼロ値を含む）を保存する
+func (m *RevokeMiddleware) getRevokedTime(ctx context.Context, userID string) (time.Time, error) {
+	if m.cache != nil {
+		if revokedTime, ok := m.cache.Get(userID); ok {
+			return revokedTime, nil
+		}
+	}
+
+	revokedTime, err := m.repository.GetRevokedTime(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if m.cache != nil {
+		m.cache.Set(userID, revokedTime)
+	}
+	return revokedTime, nil
+}
+
+// deny はリクエストの拒否を監査ログに記録する
+func (m *RevokeMiddleware) deny(ctx context.Context, userID, reason string) {
+	m.audit.Log(ctx, audit.Event{
+		Type:    audit.EventAuthzDenied,
+		Outcome: audit.OutcomeFailure,
+		UserID:  userID,
+		Reason:  reason,
+	})
+}
+
 // getUserID はClaimsからユーザーIDを取得する
 func (m *RevokeMiddleware) getUserID(claims jwt.MapClaims) (string, error) {
 	userIDRaw, ok := claims[m.userIDClaim]
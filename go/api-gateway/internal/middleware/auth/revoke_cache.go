@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+	"api-gateway/internal/repository"
+)
+
+const (
+	// DefaultRevokeCacheSize はRevokedTimeCacheの既定の最大ユーザー数
+	DefaultRevokeCacheSize = 10000
+	// DefaultRevokeCacheTTL はRevokedTimeCacheの既定の保存期間
+	DefaultRevokeCacheTTL = 5 * time.Second
+)
+
+// revokeCacheLookups はローカルキャッシュの参照結果（hit, miss）ごとの回数
+var revokeCacheLookups = metrics.NewCounterVec(
+	"gateway_revoke_cache_lookups_total",
+	"Number of revoked time lookups served from the local cache (hit) or Redis (miss).",
+	"result",
+)
+
+// revocationEvents は失効の通知の種類（revoked, resync, error）ごとの受信回数
+var revocationEvents = metrics.NewCounterVec(
+	"gateway_revocation_events_total",
+	"Number of revocation broadcast events handled by the local revoke cache.",
+	"type",
+)
+
+// RevokedTimeCache はユーザーごとの失効時刻をプロセス内に短時間保持するLRUキャッシュ
+// リクエストごとにRedisへ問い合わせないようにし、失効していないこと（ゼロ値）も保存する
+// 失効の通知（Redisのpub/sub）を受け取った場合は破棄し、TTLを待たずに反映する（Listener を参照）
+type RevokedTimeCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List // 先頭が最近参照されたエントリ
+	now   func() time.Time
+}
+
+type revokedTimeItem struct {
+	userID      string
+	revokedTime time.Time
+	expiresAt   time.Time
+}
+
+// NewRevokedTimeCache は新しいRevokedTimeCacheを作成する（0以下は既定値）
+func NewRevokedTimeCache(size int, ttl time.Duration) *RevokedTimeCache {
+	if size <= 0 {
+		size = DefaultRevokeCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultRevokeCacheTTL
+	}
+
+	return &RevokedTimeCache{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+// Get はユーザーの失効時刻を返す（保存されていない、または期限切れの場合は false）
+func (c *RevokedTimeCache) Get(userID string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[userID]
+	if !ok {
+		revokeCacheLookups.With("miss").Inc()
+		return time.Time{}, false
+	}
+
+	item := elem.Value.(*revokedTimeItem)
+	if !c.now().Before(item.expiresAt) {
+		c.remove(elem)
+		revokeCacheLookups.With("miss").Inc()
+		return time.Time{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	revokeCacheLookups.With("hit").Inc()
+	return item.revokedTime, true
+}
+
+// Set はユーザーの失効時刻（失効していない場合はゼロ値）を保存する
+func (c *RevokedTimeCache) Set(userID string, revokedTime time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := &revokedTimeItem{userID: userID, revokedTime: revokedTime, expiresAt: c.now().Add(c.ttl)}
+	if elem, ok := c.items[userID]; ok {
+		elem.Value = item
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[userID] = c.order.PushFront(item)
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate はユーザーの失効時刻を破棄し、次のリクエストでRedisから取得し直す
+func (c *RevokedTimeCache) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[userID]; ok {
+		c.remove(elem)
+	}
+}
+
+// Clear は全てのユーザーの失効時刻を破棄する
+func (c *RevokedTimeCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.items)
+	c.order.Init()
+}
+
+// Listener は失効の通知でキャッシュを更新する repository.RevocationListener を返す
+// 失効したユーザーのエントリを破棄し、購読し直した時は切断中の通知を受け取れていないため全て破棄する
+// 失効時刻はイベントの値で上書きせず、次のリクエストでRedisから取得し直す（通知の順序が保存の順序と異なる場合があるため）
+func (c *RevokedTimeCache) Listener(logger *slog.Logger) repository.RevocationListener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return repository.RevocationListener{
+		OnRevocation: func(event repository.RevocationEvent) {
+			revocationEvents.With("revoked").Inc()
+			c.Invalidate(event.UserID)
+			logger.Debug("Revocation received",
+				slog.String("user_id", event.UserID),
+				slog.Time("revoked_at", event.RevokedAt))
+		},
+		OnResync: func() {
+			revocationEvents.With("resync").Inc()
+			c.Clear()
+			logger.Info("Revocation subscription established, revoke cache cleared")
+		},
+		OnError: func(err error) {
+			revocationEvents.With("error").Inc()
+			logger.Warn("Failed to receive revocation", slog.String("error", err.Error()))
+		},
+	}
+}
+
+// Len は保存しているユーザー数を返す
+func (c *RevokedTimeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove はエントリを削除する（呼び出し側でロックを取得していること）
+func (c *RevokedTimeCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*revokedTimeItem).userID)
+}
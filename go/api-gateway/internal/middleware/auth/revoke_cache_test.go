@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"api-gateway/internal/repository"
+)
+
+func TestRevokedTimeCache(t *testing.T) {
+	revokedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		ops     func(c *RevokedTimeCache, advance func(time.Duration))
+		userID  string
+		want    time.Time
+		wantHit bool
+	}{
+		{
+			name:    "保存した失効時刻を返す",
+			ops:     func(c *RevokedTimeCache, _ func(time.Duration)) { c.Set("user1", revokedAt) },
+			userID:  "user1",
+			want:    revokedAt,
+			wantHit: true,
+		},
+		{
+			name:    "失効していないこと（ゼロ値）も保存する",
+			ops:     func(c *RevokedTimeCache, _ func(time.Duration)) { c.Set("user1", time.Time{}) },
+			userID:  "user1",
+			wantHit: true,
+		},
+		{
+			name:   "保存していないユーザー",
+			ops:    func(c *RevokedTimeCache, _ func(time.Duration)) {},
+			userID: "user1",
+		},
+		{
+			name: "TTLを過ぎたエントリは返さない",
+			ops: func(c *RevokedTimeCache, advance func(time.Duration)) {
+				c.Set("user1", revokedAt)
+				advance(time.Second)
+			},
+			userID: "user1",
+		},
+		{
+			name: "Invalidateしたエントリは返さない",
+			ops: func(c *RevokedTimeCache, _ func(time.Duration)) {
+				c.Set("user1", revokedAt)
+				c.Invalidate("user1")
+			},
+			userID: "user1",
+		},
+		{
+			name: "上限を超えると最も古く参照されたエントリを削除する",
+			ops: func(c *RevokedTimeCache, _ func(time.Duration)) {
+				c.Set("user1", revokedAt)
+				c.Set("user2", revokedAt)
+				c.Get("user1")
+				c.Set("user3", revokedAt)
+			},
+			userID: "user2",
+		},
+		{
+			name: "上限を超えても最近参照したエントリは残る",
+			ops: func(c *RevokedTimeCache, _ func(time.Duration)) {
+				c.Set("user1", revokedAt)
+				c.Set("user2", revokedAt)
+				c.Get("user1")
+				c.Set("user3", revokedAt)
+			},
+			userID:  "user1",
+			want:    revokedAt,
+			wantHit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			c := NewRevokedTimeCache(2, time.Second)
+			c.now = func() time.Time { return now }
+
+			tt.ops(c, func(d time.Duration) { now = now.Add(d) })
+
+			got, hit := c.Get(tt.userID)
+			if hit != tt.wantHit {
+				t.Fatalf("Get() hit = %v, want %v", hit, tt.wantHit)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Get() = %v, want %v", got, tt.want)
+			}
+			if c.Len() > 2 {
+				t.Errorf("Len() = %d, want <= 2", c.Len())
+			}
+		})
+	}
+}
+
+func TestRevokedTimeCache_Listener(t *testing.T) {
+	c := NewRevokedTimeCache(10, time.Minute)
+	listener := c.Listener(nil)
+
+	c.Set("user1", time.Time{})
+	c.Set("user2", time.Time{})
+
+	// 失効したユーザーのエントリのみ破棄する
+	listener.OnRevocation(repository.RevocationEvent{UserID: "user1", RevokedAt: time.Now()})
+	if _, ok := c.Get("user1"); ok {
+		t.Error("user1 is cached after revocation")
+	}
+	if _, ok := c.Get("user2"); !ok {
+		t.Error("user2 is not cached")
+	}
+
+	// 購読し直した時は全て破棄する
+	listener.OnResync()
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after resync, want 0", c.Len())
+	}
+}
@@ -1,7 +1,9 @@
 package auth_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,7 +11,10 @@ import (
 	"testing"
 	"time"
 
+	"api-gateway/internal/audit"
 	"api-gateway/internal/middleware/auth"
+	"api-gateway/internal/repository"
+	"api-gateway/internal/requestctx"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -35,6 +40,14 @@ func (m *mockSessionRepository) SetRevokedTime(ctx context.Context, userID strin
 	return nil
 }
 
+func (m *mockSessionRepository) SetRevokedTimes(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error {
+	return make([]error, len(userIDs))
+}
+
+func (m *mockSessionRepository) GetRevocation(ctx context.Context, userID string) (repository.Revocation, error) {
+	return repository.Revocation{}, nil
+}
+
 func (m *mockSessionRepository) DeleteRevokedTime(ctx context.Context, userID string) error {
 	if m.deleteRevokedTimeFunc != nil {
 		return m.deleteRevokedTimeFunc(ctx, userID)
@@ -96,7 +109,7 @@ func TestRevokeMiddleware_Process_Success(t *testing.T) {
 		"sub": "user123",
 		"iat": float64(now.Unix()),
 	}
-	ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+	ctx := requestctx.WithClaims(context.Background(), claims)
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 	newCtx, err := middleware.Process(ctx, req)
@@ -127,7 +140,7 @@ func TestRevokeMiddleware_Process_TokenRevoked(t *testing.T) {
 		"sub": "user123",
 		"iat": float64(now.Unix()),
 	}
-	ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+	ctx := requestctx.WithClaims(context.Background(), claims)
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 	_, err := middleware.Process(ctx, req)
@@ -155,7 +168,7 @@ func TestRevokeMiddleware_Process_TokenNotRevoked(t *testing.T) {
 		"sub": "user123",
 		"iat": float64(now.Unix()),
 	}
-	ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+	ctx := requestctx.WithClaims(context.Background(), claims)
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 	_, err := middleware.Process(ctx, req)
@@ -201,7 +214,7 @@ func TestRevokeMiddleware_Process_FailOpen(t *testing.T) {
 		"sub": "user123",
 		"iat": float64(time.Now().Unix()),
 	}
-	ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+	ctx := requestctx.WithClaims(context.Background(), claims)
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 	_, err := middleware.Process(ctx, req)
@@ -227,7 +240,7 @@ func TestRevokeMiddleware_Process_FailClose(t *testing.T) {
 		"sub": "user123",
 		"iat": float64(time.Now().Unix()),
 	}
-	ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+	ctx := requestctx.WithClaims(context.Background(), claims)
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 	_, err := middleware.Process(ctx, req)
@@ -271,7 +284,7 @@ func TestRevokeMiddleware_Process_InvalidUserID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, tt.claims)
+			ctx := requestctx.WithClaims(context.Background(), tt.claims)
 			req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 			_, err := middleware.Process(ctx, req)
@@ -310,7 +323,7 @@ func TestRevokeMiddleware_Process_InvalidIssuedAt(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, tt.claims)
+			ctx := requestctx.WithClaims(context.Background(), tt.claims)
 			req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 			_, err := middleware.Process(ctx, req)
@@ -339,7 +352,7 @@ func TestRevokeMiddleware_Process_CustomClaims(t *testing.T) {
 		"custom_user_id":   "user123",
 		"custom_issued_at": float64(now.Unix()),
 	}
-	ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+	ctx := requestctx.WithClaims(context.Background(), claims)
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 	_, err := middleware.Process(ctx, req)
@@ -389,7 +402,216 @@ func TestRevokeMiddleware_Process_IssuedAtTypes(t *testing.T) {
 				"sub": "user123",
 				"iat": tt.iatType,
 			}
-			ctx := context.WithValue(context.Background(), auth.ClaimsContextKey, claims)
+			ctx := requestctx.WithClaims(context.Background(), claims)
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+			_, err := middleware.Process(ctx, req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRevokeMiddleware_Process_Audit(t *testing.T) {
+	now := time.Now()
+	repo := &mockSessionRepository{
+		getRevokedTimeFunc: func(ctx context.Context, userID string) (time.Time, error) {
+			return now.Add(1 * time.Hour), nil
+		},
+	}
+
+	var buf bytes.Buffer
+	middleware := auth.NewRevokeMiddleware(auth.RevokeConfig{
+		Repository: repo,
+		Audit:      audit.New(&buf),
+	})
+
+	claims := jwt.MapClaims{
+		"sub": "user123",
+		"iat": float64(now.Unix()),
+	}
+	ctx := requestctx.WithClaims(context.Background(), claims)
+	ctx = audit.WithRoute(ctx, "/api/v1/users")
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	if _, err := middleware.Process(ctx, req); err == nil {
+		t.Fatal("Process() error = nil, want error (token revoked)")
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to parse audit output: %v", err)
+	}
+	want := map[string]any{
+		"event":   string(audit.EventAuthzDenied),
+		"outcome": string(audit.OutcomeFailure),
+		"user_id": "user123",
+		"route":   "/api/v1/users",
+		"reason":  "token has been revoked",
+	}
+	for key, w := range want {
+		if entry[key] != w {
+			t.Errorf("%s = %v, want %v", key, entry[key], w)
+		}
+	}
+}
+
+func TestRevokeMiddleware_Process_Cache(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	repo := &mockSessionRepository{
+		getRevokedTimeFunc: func(ctx context.Context, userID string) (time.Time, error) {
+			calls++
+			return time.Time{}, nil
+		},
+	}
+
+	cache := auth.NewRevokedTimeCache(10, time.Minute)
+	middleware := auth.NewRevokeMiddleware(auth.RevokeConfig{
+		Repository: repo,
+		Cache:      cache,
+	})
+
+	claims := jwt.MapClaims{
+		"sub": "user123",
+		"iat": float64(now.Unix()),
+	}
+	ctx := requestctx.WithClaims(context.Background(), claims)
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	// 2回目はキャッシュから失効していないことを返す
+	for range 2 {
+		if _, err := middleware.Process(ctx, req); err != nil {
+			t.Fatalf("Process() error = %v, want nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("GetRevokedTime() calls = %d, want 1", calls)
+	}
+
+	// 失効の通知でキャッシュを破棄すると、Redisから取得し直して拒否する
+	repo.getRevokedTimeFunc = func(ctx context.Context, userID string) (time.Time, error) {
+		calls++
+		return now.Add(1 * time.Hour), nil
+	}
+	cache.Invalidate("user123")
+	if _, err := middleware.Process(ctx, req); err == nil {
+		t.Error("Process() error = nil, want error (token revoked)")
+	}
+	if calls != 2 {
+		t.Errorf("GetRevokedTime() calls = %d, want 2", calls)
+	}
+}
+
+func TestRevokeMiddleware_Process_FutureRevokedTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	revokedTime := now.Add(time.Minute) // 失効を記録したサーバーの時刻が進んでいる
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		wantErr  bool
+	}{
+		{name: "失効時刻より前に発行されたトークンは拒否", issuedAt: now, wantErr: true},
+		{name: "失効時刻ちょうどに発行されたトークンは受け付ける", issuedAt: revokedTime, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			middleware := auth.NewRevokeMiddleware(auth.RevokeConfig{
+				Repository: &mockSessionRepository{
+					getRevokedTimeFunc: func(ctx context.Context, userID string) (time.Time, error) {
+						return revokedTime, nil
+					},
+				},
+				Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+				Now:    func() time.Time { return now },
+			})
+
+			claims := jwt.MapClaims{
+				"sub": "user123",
+				"iat": float64(tt.issuedAt.Unix()),
+			}
+			ctx := requestctx.WithClaims(context.Background(), claims)
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+			_, err := middleware.Process(ctx, req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Contains(logs.Bytes(), []byte("revoked time is in the future")) {
+				t.Errorf("expected future revoked time warning, got logs: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestRevokeMiddleware_Process_ClockSkew(t *testing.T) {
+	logoutTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		clockSkew   time.Duration
+		revokedTime time.Time
+		issuedAt    time.Time
+		wantErr     bool
+	}{
+		{
+			name:        "ずれを許容しない場合はログアウトと同じ秒に発行されたトークンを拒否",
+			revokedTime: logoutTime.Add(500 * time.Millisecond),
+			issuedAt:    logoutTime,
+			wantErr:     true,
+		},
+		{
+			name:        "ログアウトと同じ秒に発行されたトークンはずれの範囲内なら受け付ける",
+			clockSkew:   time.Second,
+			revokedTime: logoutTime.Add(500 * time.Millisecond),
+			issuedAt:    logoutTime,
+			wantErr:     false,
+		},
+		{
+			name:        "発行時刻にずれを加えて失効時刻ちょうどなら受け付ける",
+			clockSkew:   time.Second,
+			revokedTime: logoutTime,
+			issuedAt:    logoutTime.Add(-time.Second),
+			wantErr:     false,
+		},
+		{
+			name:        "ずれの範囲より前に発行されたトークンは拒否",
+			clockSkew:   time.Second,
+			revokedTime: logoutTime,
+			issuedAt:    logoutTime.Add(-2 * time.Second),
+			wantErr:     true,
+		},
+		{
+			name:        "ずれの範囲の1ナノ秒外は拒否",
+			clockSkew:   time.Second,
+			revokedTime: logoutTime.Add(time.Nanosecond),
+			issuedAt:    logoutTime.Add(-time.Second),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := auth.NewRevokeMiddleware(auth.RevokeConfig{
+				Repository: &mockSessionRepository{
+					getRevokedTimeFunc: func(ctx context.Context, userID string) (time.Time, error) {
+						return tt.revokedTime, nil
+					},
+				},
+				ClockSkew: tt.clockSkew,
+				Logger:    slog.New(slog.DiscardHandler),
+				Now:       func() time.Time { return logoutTime.Add(time.Second) },
+			})
+
+			claims := jwt.MapClaims{
+				"sub": "user123",
+				"iat": float64(tt.issuedAt.Unix()),
+			}
+			ctx := requestctx.WithClaims(context.Background(), claims)
 			req := httptest.NewRequest(http.MethodGet, "/test", nil)
 
 			_, err := middleware.Process(ctx, req)
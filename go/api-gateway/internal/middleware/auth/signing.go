@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKeySet はゲートウェイが発行するトークンの署名鍵を管理する
+// 署名にはアクティブな鍵のみを使い、パッシブな鍵はローテーション前後のトークンを検証するために公開し続ける
+type SigningKeySet struct {
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PrivateKey
+	activeKID string
+}
+
+// NewSigningKeySet は署名鍵のセットを作成する
+// activeKID は keys に含まれている必要がある
+func NewSigningKeySet(keys map[string]*rsa.PrivateKey, activeKID string) (*SigningKeySet, error) {
+	if _, ok := keys[activeKID]; !ok {
+		return nil, fmt.Errorf("active signing key not found for kid: %s", activeKID)
+	}
+
+	return &SigningKeySet{
+		keys:      keys,
+		activeKID: activeKID,
+	}, nil
+}
+
+// Sign はアクティブな鍵でクレームに署名し、kidヘッダー付きのトークンを返す
+func (s *SigningKeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	kid := s.activeKID
+	key := s.keys[kid]
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token with kid=%s: %w", kid, err)
+	}
+	return signed, nil
+}
+
+// Rotate はアクティブな鍵を kid に切り替える
+// それまでのアクティブな鍵はパッシブとして検証用に残る
+func (s *SigningKeySet) Rotate(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[kid]; !ok {
+		return fmt.Errorf("signing key not found for kid: %s", kid)
+	}
+	s.activeKID = kid
+	return nil
+}
+
+// ActiveKID はアクティブな鍵のkidを返す
+func (s *SigningKeySet) ActiveKID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.activeKID
+}
+
+// Active はアクティブな鍵とそのkidを返す
+func (s *SigningKeySet) Active() (string, *rsa.PrivateKey) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.activeKID, s.keys[s.activeKID]
+}
+
+// KIDs は全ての鍵（アクティブとパッシブ）のkidをソートして返す
+func (s *SigningKeySet) KIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids
+}
+
+// PublicKeys は検証用の公開鍵マップ (kid → 公開鍵) を返す
+func (s *SigningKeySet) PublicKeys() map[string]*rsa.PublicKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	publicKeys := make(map[string]*rsa.PublicKey, len(s.keys))
+	for kid, key := range s.keys {
+		publicKeys[kid] = &key.PublicKey
+	}
+	return publicKeys
+}
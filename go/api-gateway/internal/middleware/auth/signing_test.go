@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestSigningKeySet(t *testing.T, activeKID string, kids ...string) *SigningKeySet {
+	t.Helper()
+
+	keys := make(map[string]*rsa.PrivateKey)
+	for _, kid := range kids {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		keys[kid] = key
+	}
+	set, err := NewSigningKeySet(keys, activeKID)
+	if err != nil {
+		t.Fatalf("NewSigningKeySet() error = %v", err)
+	}
+	return set
+}
+
+func TestNewSigningKeySet_UnknownActiveKID(t *testing.T) {
+	if _, err := NewSigningKeySet(map[string]*rsa.PrivateKey{}, "missing"); err == nil {
+		t.Error("NewSigningKeySet() error = nil, want error")
+	}
+}
+
+func TestSigningKeySet_SignAndRotate(t *testing.T) {
+	set := newTestSigningKeySet(t, "old", "old", "new")
+	verifier := NewJWTMiddleware(JWTConfig{PublicKeys: set.PublicKeys()})
+
+	claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+	oldToken, err := set.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	if err := set.Rotate("new"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if set.ActiveKID() != "new" {
+		t.Errorf("ActiveKID() = %v, want new", set.ActiveKID())
+	}
+	newToken, err := set.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	// ローテーション前に発行したトークンもパッシブな鍵で検証できる
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		if err != nil {
+			t.Fatalf("failed to parse %s token: %v", name, err)
+		}
+		if parsed.Header["kid"] != name {
+			t.Errorf("%s token kid = %v, want %v", name, parsed.Header["kid"], name)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if _, err := verifier.Process(context.Background(), req); err != nil {
+			t.Errorf("%s token verification error = %v", name, err)
+		}
+	}
+
+	if err := set.Rotate("unknown"); err == nil {
+		t.Error("Rotate(unknown) error = nil, want error")
+	}
+	if set.ActiveKID() != "new" {
+		t.Errorf("ActiveKID() after failed rotation = %v, want new", set.ActiveKID())
+	}
+}
+
+func TestMarshalJWKS(t *testing.T) {
+	set := newTestSigningKeySet(t, "b", "a", "b")
+
+	data, err := MarshalJWKS(set.PublicKeys())
+	if err != nil {
+		t.Fatalf("MarshalJWKS() error = %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"alg":"RS256"`)) {
+		t.Errorf("jwks = %s, want alg RS256", data)
+	}
+
+	// 公開したJWKSをJWKSキャッシュで読み込める
+	keys, err := parseJWKS(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parseJWKS() error = %v", err)
+	}
+	for kid, want := range set.PublicKeys() {
+		if got := keys[kid]; got == nil || !got.Equal(want) {
+			t.Errorf("key %s does not round-trip", kid)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"api-gateway/internal/cache"
+)
+
+// CacheConfig はキャッシュミドルウェアの設定
+type CacheConfig struct {
+	// Store はキャッシュの保存先
+	Store cache.Store
+
+	// TTL はレスポンスにCache-Controlの有効期限が無い場合の保存期間
+	TTL time.Duration
+
+	// VaryHeaders はキャッシュキーに含めるリクエストヘッダー
+	VaryHeaders []string
+
+	// MaxBodySize はキャッシュするレスポンスボディの上限バイト数
+	MaxBodySize int64
+}
+
+// CachePolicy はリクエストに適用するキャッシュの方針
+// キャッシュの参照と保存はレスポンスを扱えるハンドラ側で行う
+type CachePolicy struct {
+	Store       cache.Store
+	Key         string
+	TTL         time.Duration
+	MaxBodySize int64
+
+	// VaryHeaders はキャッシュキーに含めたリクエストヘッダー（レスポンスの Vary がこれ以外を含む場合は保存しない）
+	VaryHeaders []string
+
+	// Revalidate はクライアントが no-cache を指定したため、キャッシュを参照せずにバックエンドへ転送するか
+	Revalidate bool
+}
+
+// CacheMiddleware はGETレスポンスをキャッシュするミドルウェア
+type CacheMiddleware struct {
+	config CacheConfig
+}
+
+// NewCacheMiddleware は新しいキャッシュミドルウェアを作成する
+func NewCacheMiddleware(config CacheConfig) *CacheMiddleware {
+	// デフォルト値の設定
+	if config.TTL == 0 {
+		config.TTL = 60 * time.Second
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = 1 << 20 // 1MiB
+	}
+
+	return &CacheMiddleware{
+		config: config,
+	}
+}
+
+// cacheContextKey はコンテキストのキー型
+type cacheContextKey string
+
+const (
+	// cachePolicyKey はキャッシュ方針を格納するコンテキストキー
+	cachePolicyKey cacheContextKey = "cache_policy"
+)
+
+// Process はキャッシュ対象のリクエストにキャッシュ方針を設定する
+func (m *CacheMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return ctx, nil
+	}
+
+	cc := cache.ParseCacheControl(req.Header)
+	if cc.NoStore {
+		return ctx, nil
+	}
+
+	ctx = context.WithValue(ctx, cachePolicyKey, &CachePolicy{
+		Store:       m.config.Store,
+		Key:         cache.Key(req, m.config.VaryHeaders),
+		TTL:         m.config.TTL,
+		MaxBodySize: m.config.MaxBodySize,
+		VaryHeaders: m.config.VaryHeaders,
+		Revalidate:  cc.NoCache || cc.MaxAge == 0,
+	})
+
+	return ctx, nil
+}
+
+// GetCachePolicy はコンテキストからキャッシュ方針を取得する
+func GetCachePolicy(ctx context.Context) (*CachePolicy, bool) {
+	policy, ok := ctx.Value(cachePolicyKey).(*CachePolicy)
+	return policy, ok
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"api-gateway/internal/cache"
+)
+
+func TestNewCacheMiddleware(t *testing.T) {
+	m := NewCacheMiddleware(CacheConfig{Store: cache.NewMemoryStore(0)})
+
+	if m.config.TTL != 60*time.Second {
+		t.Errorf("TTL = %v, want %v", m.config.TTL, 60*time.Second)
+	}
+	if m.config.MaxBodySize != 1<<20 {
+		t.Errorf("MaxBodySize = %d, want %d", m.config.MaxBodySize, 1<<20)
+	}
+}
+
+func TestCacheMiddleware_Process(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		cacheControl   string
+		wantPolicy     bool
+		wantRevalidate bool
+	}{
+		{
+			name:       "GETリクエストにはキャッシュ方針を設定する",
+			method:     http.MethodGet,
+			wantPolicy: true,
+		},
+		{
+			name:       "HEADリクエストにはキャッシュ方針を設定する",
+			method:     http.MethodHead,
+			wantPolicy: true,
+		},
+		{
+			name:       "POSTリクエストはキャッシュしない",
+			method:     http.MethodPost,
+			wantPolicy: false,
+		},
+		{
+			name:         "no-storeの場合はキャッシュしない",
+			method:       http.MethodGet,
+			cacheControl: "no-store",
+			wantPolicy:   false,
+		},
+		{
+			name:           "no-cacheの場合は再検証する",
+			method:         http.MethodGet,
+			cacheControl:   "no-cache",
+			wantPolicy:     true,
+			wantRevalidate: true,
+		},
+		{
+			name:           "max-age=0の場合は再検証する",
+			method:         http.MethodGet,
+			cacheControl:   "max-age=0",
+			wantPolicy:     true,
+			wantRevalidate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewCacheMiddleware(CacheConfig{
+				Store:       cache.NewMemoryStore(0),
+				TTL:         30 * time.Second,
+				VaryHeaders: []string{"Accept"},
+			})
+
+			req := httptest.NewRequest(tt.method, "/api/v1/users?page=1", nil)
+			if tt.cacheControl != "" {
+				req.Header.Set("Cache-Control", tt.cacheControl)
+			}
+
+			ctx, err := m.Process(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			policy, ok := GetCachePolicy(ctx)
+			if ok != tt.wantPolicy {
+				t.Fatalf("GetCachePolicy() ok = %v, want %v", ok, tt.wantPolicy)
+			}
+			if !ok {
+				return
+			}
+
+			if policy.Key != cache.Key(req, []string{"Accept"}) {
+				t.Errorf("Key = %s, want %s", policy.Key, cache.Key(req, []string{"Accept"}))
+			}
+			if policy.TTL != 30*time.Second {
+				t.Errorf("TTL = %v, want %v", policy.TTL, 30*time.Second)
+			}
+			if policy.Revalidate != tt.wantRevalidate {
+				t.Errorf("Revalidate = %v, want %v", policy.Revalidate, tt.wantRevalidate)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+
+	"api-gateway/internal/requestctx"
 )
 
 // CORSConfig はCORSミドルウェアの設定
@@ -52,8 +54,8 @@ func NewCORSMiddleware(config CORSConfig) *CORSMiddleware {
 	}
 }
 
-// Process はCORSヘッダーを設定する
-// このミドルウェアはリクエストを検証するだけで、実際のヘッダー設定はハンドラ側で行う必要がある
+// Process はCORSヘッダーの情報をコンテキストに保存する
+// レスポンスへのヘッダーの設定は Wrap で行う（Execute でミドルウェアを実行する場合はハンドラ側で行う必要がある）
 func (m *CORSMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
 	// Originヘッダーを取得
 	origin := req.Header.Get("Origin")
@@ -75,6 +77,55 @@ func (m *CORSMiddleware) Process(ctx context.Context, req *http.Request) (contex
 	return ctx, nil
 }
 
+// Wrap は実際のリクエストへのレスポンスにCORSヘッダーを設定する
+// バックエンドが返したCORSヘッダーはポリシーで上書きし、Vary は追加する
+// 後続のミドルウェアのエラーレスポンスにも設定するため、ブラウザはエラーの内容を読める
+func (m *CORSMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if headers, ok := m.ResponseHeaders(r); ok {
+			w = &corsWriter{ResponseWriter: w, headers: headers}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsWriter はレスポンスヘッダーの送信前にCORSヘッダーを設定する
+type corsWriter struct {
+	http.ResponseWriter
+	headers     http.Header
+	wroteHeader bool
+}
+
+// WriteHeader はCORSヘッダーを設定してからステータスコードを書き込む
+func (cw *corsWriter) WriteHeader(statusCode int) {
+	// 1xxの中間レスポンスは最終レスポンスではないため設定しない
+	if !cw.wroteHeader && statusCode >= http.StatusOK {
+		cw.wroteHeader = true
+		h := cw.ResponseWriter.Header()
+		for name, values := range cw.headers {
+			if name == "Vary" {
+				h[name] = append(h[name], values...)
+				continue
+			}
+			h[name] = values
+		}
+	}
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write はヘッダー未送信の場合にCORSヘッダーを設定してからボディを書き込む
+func (cw *corsWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap はhttp.ResponseControllerがFlushなどを元のResponseWriterへ委譲するために使う
+func (cw *corsWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
 // isOriginAllowed はオリジンが許可されているか確認する
 func (m *CORSMiddleware) isOriginAllowed(origin string) bool {
 	// ワイルドカードの場合は全て許可
@@ -129,24 +180,106 @@ func (m *CORSMiddleware) setCORSContext(ctx context.Context, origin string) cont
 	}
 
 	// コンテキストに保存
-	ctx = context.WithValue(ctx, corsHeadersKey, corsHeaders)
+	ctx = requestctx.WithCORSHeaders(ctx, corsHeaders)
 
 	return ctx
 }
 
-// contextKey はコンテキストのキー型
-type corsContextKey string
-
+// CORSリクエストのヘッダー
 const (
-	// corsHeadersKey はCORSヘッダーを格納するコンテキストキー
-	corsHeadersKey corsContextKey = "cors_headers"
+	headerOrigin                      = "Origin"
+	headerAccessControlRequestMethod  = "Access-Control-Request-Method"
+	headerAccessControlRequestHeaders = "Access-Control-Request-Headers"
+	headerAccessControlAllowOrigin    = "Access-Control-Allow-Origin"
 )
 
-// GetCORSHeaders はコンテキストからCORSヘッダーを取得する
-func GetCORSHeaders(ctx context.Context) map[string]string {
-	headers, ok := ctx.Value(corsHeadersKey).(map[string]string)
-	if !ok {
-		return nil
+// IsPreflight はCORSのプリフライトリクエストか確認する
+func IsPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions &&
+		req.Header.Get(headerOrigin) != "" &&
+		req.Header.Get(headerAccessControlRequestMethod) != ""
+}
+
+// Preflight はプリフライトリクエストをポリシーで評価し、許可する場合は応答するヘッダーを返す
+// オリジン、Access-Control-Request-Method、Access-Control-Request-Headers のいずれかが許可されていない場合は false を返す
+func (m *CORSMiddleware) Preflight(req *http.Request) (http.Header, bool) {
+	origin := req.Header.Get(headerOrigin)
+	if !m.isOriginAllowed(origin) {
+		return nil, false
+	}
+
+	method := req.Header.Get(headerAccessControlRequestMethod)
+	if !containsFold(m.config.AllowedMethods, method) {
+		return nil, false
+	}
+
+	var requested []string
+	for name := range strings.SplitSeq(req.Header.Get(headerAccessControlRequestHeaders), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !containsFold(m.config.AllowedHeaders, name) && !containsFold(m.config.AllowedHeaders, "*") {
+			return nil, false
+		}
+		requested = append(requested, name)
 	}
-	return headers
+
+	h := m.allowOriginHeaders(origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(m.config.AllowedMethods, ", "))
+	if len(requested) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if m.config.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(m.config.MaxAge))
+	}
+	// 結果はリクエストするメソッドとヘッダーによって変わるため、キャッシュのキーに含める
+	h.Add("Vary", headerAccessControlRequestMethod)
+	h.Add("Vary", headerAccessControlRequestHeaders)
+	return h, true
+}
+
+// ResponseHeaders は実際のリクエストへのレスポンスに設定するヘッダーを返す
+// Originヘッダーが無い、または許可されていないオリジンの場合は false を返す
+func (m *CORSMiddleware) ResponseHeaders(req *http.Request) (http.Header, bool) {
+	origin := req.Header.Get(headerOrigin)
+	if origin == "" || !m.isOriginAllowed(origin) {
+		return nil, false
+	}
+
+	h := m.allowOriginHeaders(origin)
+	if len(m.config.ExposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(m.config.ExposedHeaders, ", "))
+	}
+	return h, true
+}
+
+// allowOriginHeaders はプリフライトと実際のリクエストで共通のヘッダーを返す
+func (m *CORSMiddleware) allowOriginHeaders(origin string) http.Header {
+	h := make(http.Header)
+	// クレデンシャルを許可する場合、ブラウザは "*" を受け付けないためオリジンをそのまま返す
+	if m.isWildcard() && !m.config.AllowCredentials {
+		h.Set(headerAccessControlAllowOrigin, "*")
+	} else {
+		h.Set(headerAccessControlAllowOrigin, origin)
+		h.Add("Vary", headerOrigin)
+	}
+	if m.config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return h
+}
+
+// isWildcard は全てのオリジンを許可するか確認する
+func (m *CORSMiddleware) isWildcard() bool {
+	return len(m.config.AllowedOrigins) == 1 && m.config.AllowedOrigins[0] == "*"
+}
+
+// containsFold は大文字小文字を区別せずに values が s を含むか確認する
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
 }
@@ -3,7 +3,11 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"api-gateway/internal/requestctx"
 )
 
 func TestNewCORSMiddleware(t *testing.T) {
@@ -147,7 +151,7 @@ func TestCORSMiddleware_Process(t *testing.T) {
 				return
 			}
 
-			headers := GetCORSHeaders(newCtx)
+			headers := requestctx.CORSHeaders(newCtx)
 
 			if tt.wantHeaders {
 				if headers == nil {
@@ -229,54 +233,6 @@ func TestCORSMiddleware_isOriginAllowed(t *testing.T) {
 	}
 }
 
-func TestGetCORSHeaders(t *testing.T) {
-	tests := []struct {
-		name    string
-		ctx     context.Context
-		want    map[string]string
-		wantNil bool
-	}{
-		{
-			name: "CORSヘッダーが設定されている場合",
-			ctx: context.WithValue(context.Background(), corsHeadersKey, map[string]string{
-				"Access-Control-Allow-Origin": "https://example.com",
-			}),
-			want: map[string]string{
-				"Access-Control-Allow-Origin": "https://example.com",
-			},
-			wantNil: false,
-		},
-		{
-			name:    "CORSヘッダーが設定されていない場合",
-			ctx:     context.Background(),
-			wantNil: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := GetCORSHeaders(tt.ctx)
-
-			if tt.wantNil {
-				if got != nil {
-					t.Errorf("GetCORSHeaders() = %v, want nil", got)
-				}
-				return
-			}
-
-			if got == nil {
-				t.Fatal("GetCORSHeaders() returned nil, want non-nil")
-			}
-
-			for key, wantValue := range tt.want {
-				if gotValue, ok := got[key]; !ok || gotValue != wantValue {
-					t.Errorf("GetCORSHeaders()[%s] = %v, want %v", key, gotValue, wantValue)
-				}
-			}
-		})
-	}
-}
-
 func TestCORSMiddleware_ExposedHeaders(t *testing.T) {
 	m := NewCORSMiddleware(CORSConfig{
 		AllowedOrigins: []string{"https://example.com"},
@@ -292,7 +248,7 @@ func TestCORSMiddleware_ExposedHeaders(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	headers := GetCORSHeaders(newCtx)
+	headers := requestctx.CORSHeaders(newCtx)
 	if headers == nil {
 		t.Fatal("Expected CORS headers to be set")
 	}
@@ -318,7 +274,7 @@ func TestCORSMiddleware_MaxAge(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	headers := GetCORSHeaders(newCtx)
+	headers := requestctx.CORSHeaders(newCtx)
 	if headers == nil {
 		t.Fatal("Expected CORS headers to be set")
 	}
@@ -328,3 +284,222 @@ func TestCORSMiddleware_MaxAge(t *testing.T) {
 		t.Errorf("Access-Control-Max-Age = %v, want %v", got, want)
 	}
 }
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	m := NewCORSMiddleware(CORSConfig{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:         600,
+	})
+
+	tests := []struct {
+		name        string
+		origin      string
+		method      string
+		headers     string
+		wantOK      bool
+		wantHeaders string
+	}{
+		{
+			name:        "allowed request",
+			origin:      "https://example.com",
+			method:      "PUT",
+			headers:     "content-type, Authorization",
+			wantOK:      true,
+			wantHeaders: "content-type, Authorization",
+		},
+		{
+			name:   "allowed request without headers",
+			origin: "https://example.com",
+			method: "GET",
+			wantOK: true,
+		},
+		{
+			name:   "disallowed origin",
+			origin: "https://evil.com",
+			method: "GET",
+			wantOK: false,
+		},
+		{
+			name:   "disallowed method",
+			origin: "https://example.com",
+			method: "DELETE",
+			wantOK: false,
+		},
+		{
+			name:    "disallowed header",
+			origin:  "https://example.com",
+			method:  "GET",
+			headers: "X-Debug",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("OPTIONS", "http://localhost/test", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+
+			if !IsPreflight(req) {
+				t.Fatal("IsPreflight() = false, want true")
+			}
+
+			headers, ok := m.Preflight(req)
+			if ok != tt.wantOK {
+				t.Fatalf("Preflight() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if got := headers.Get("Access-Control-Allow-Origin"); got != tt.origin {
+				t.Errorf("Access-Control-Allow-Origin = %v, want %v", got, tt.origin)
+			}
+			if got := headers.Get("Access-Control-Allow-Methods"); got != "GET, PUT" {
+				t.Errorf("Access-Control-Allow-Methods = %v, want GET, PUT", got)
+			}
+			if got := headers.Get("Access-Control-Allow-Headers"); got != tt.wantHeaders {
+				t.Errorf("Access-Control-Allow-Headers = %v, want %v", got, tt.wantHeaders)
+			}
+			if got := headers.Get("Access-Control-Max-Age"); got != "600" {
+				t.Errorf("Access-Control-Max-Age = %v, want 600", got)
+			}
+		})
+	}
+}
+
+func TestIsPreflight(t *testing.T) {
+	req, _ := http.NewRequest("OPTIONS", "http://localhost/test", nil)
+	if IsPreflight(req) {
+		t.Error("OPTIONS without Origin should not be a preflight request")
+	}
+
+	req.Header.Set("Origin", "https://example.com")
+	if IsPreflight(req) {
+		t.Error("OPTIONS without Access-Control-Request-Method should not be a preflight request")
+	}
+}
+
+func TestCORSMiddleware_ResponseHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          CORSConfig
+		origin          string
+		wantOK          bool
+		wantAllowOrigin string
+		wantVary        bool
+	}{
+		{
+			name:            "wildcard origin",
+			config:          CORSConfig{AllowedOrigins: []string{"*"}},
+			origin:          "https://example.com",
+			wantOK:          true,
+			wantAllowOrigin: "*",
+		},
+		{
+			name:            "wildcard origin with credentials",
+			config:          CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			origin:          "https://example.com",
+			wantOK:          true,
+			wantAllowOrigin: "https://example.com",
+			wantVary:        true,
+		},
+		{
+			name:            "specific origin",
+			config:          CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			origin:          "https://example.com",
+			wantOK:          true,
+			wantAllowOrigin: "https://example.com",
+			wantVary:        true,
+		},
+		{
+			name:   "disallowed origin",
+			config: CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			origin: "https://evil.com",
+			wantOK: false,
+		},
+		{
+			name:   "no origin",
+			config: CORSConfig{AllowedOrigins: []string{"*"}},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewCORSMiddleware(tt.config)
+			req, _ := http.NewRequest("GET", "http://localhost/test", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			headers, ok := m.ResponseHeaders(req)
+			if ok != tt.wantOK {
+				t.Fatalf("ResponseHeaders() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := headers.Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %v, want %v", got, tt.wantAllowOrigin)
+			}
+			if got := headers.Get("Vary") == "Origin"; got != tt.wantVary {
+				t.Errorf("Vary: Origin = %v, want %v", got, tt.wantVary)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_Wrap(t *testing.T) {
+	m := NewCORSMiddleware(CORSConfig{
+		AllowedOrigins: []string{"https://example.com"},
+		ExposedHeaders: []string{"X-Request-ID"},
+	})
+
+	tests := []struct {
+		name            string
+		origin          string
+		wantAllowOrigin string
+		wantVary        []string
+	}{
+		{
+			name:            "許可されたオリジンはバックエンドのCORSヘッダーを上書きし、Varyは追加する",
+			origin:          "https://example.com",
+			wantAllowOrigin: "https://example.com",
+			wantVary:        []string{"Accept-Encoding", "Origin"},
+		},
+		{
+			name:            "許可されていないオリジンはバックエンドのヘッダーのまま",
+			origin:          "https://evil.com",
+			wantAllowOrigin: "*",
+			wantVary:        []string{"Accept-Encoding"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				w.Header().Set("Vary", "Accept-Encoding")
+				w.Write([]byte("ok"))
+			})
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+
+			m.Wrap(backend).ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %v, want %v", got, tt.wantAllowOrigin)
+			}
+			if got := rec.Header().Values("Vary"); strings.Join(got, ",") != strings.Join(tt.wantVary, ",") {
+				t.Errorf("Vary = %v, want %v", got, tt.wantVary)
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"crypto/rsa"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"api-gateway/internal/audit"
+	"api-gateway/internal/cache"
 	"api-gateway/internal/config"
 	"api-gateway/internal/middleware/auth"
 	"api-gateway/internal/repository"
@@ -13,15 +16,29 @@ import (
 // Factory はミドルウェアを生成するファクトリー
 type Factory struct {
 	jwtPublicKeys map[string]*rsa.PublicKey
+	jwtKeys       *auth.PublicKeySet
+	jwks          *auth.JWKSCache
 	sessionRepo   repository.SessionRepository
+	revokeCache   *auth.RevokedTimeCache
+	hmacReplay    *auth.HMACReplayCache
+	oidcSessions  repository.OIDCSessionRepository
+	cacheStore    cache.Store
 	logger        *slog.Logger
+	audit         *audit.Logger
 }
 
 // FactoryConfig はファクトリーの設定
 type FactoryConfig struct {
 	JWTPublicKeys map[string]*rsa.PublicKey
+	JWTKeys       *auth.PublicKeySet // 設定の再読み込みで入れ替わるJWT検証用の公開鍵（任意）
+	JWKS          *auth.JWKSCache    // JWT検証に使うJWKSキャッシュ（任意）
 	SessionRepo   repository.SessionRepository
+	RevokeCache   *auth.RevokedTimeCache           // revoke ミドルウェアで共有する失効時刻のキャッシュ（任意）
+	HMACReplay    *auth.HMACReplayCache            // hmac ミドルウェアで共有する使用済みの署名の記録（nilの場合はファクトリーごとに作成する）
+	OIDCSessions  repository.OIDCSessionRepository // oidc ミドルウェアのセッションの保存先（任意）
+	CacheStore    cache.Store
 	Logger        *slog.Logger
+	Audit         *audit.Logger // 認証系ミドルウェアの監査ロガー（任意）
 }
 
 // NewFactory は新しいファクトリーを作成する
@@ -29,11 +46,22 @@ func NewFactory(cfg FactoryConfig) *Factory {
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
+	// ミドルウェアはリクエストごとに作成されるため、リプレイの検出に使う記録はファクトリーで共有する
+	if cfg.HMACReplay == nil {
+		cfg.HMACReplay = auth.NewHMACReplayCache()
+	}
 
 	return &Factory{
 		jwtPublicKeys: cfg.JWTPublicKeys,
+		jwtKeys:       cfg.JWTKeys,
+		jwks:          cfg.JWKS,
 		sessionRepo:   cfg.SessionRepo,
+		revokeCache:   cfg.RevokeCache,
+		hmacReplay:    cfg.HMACReplay,
+		oidcSessions:  cfg.OIDCSessions,
+		cacheStore:    cfg.CacheStore,
 		logger:        cfg.Logger,
+		audit:         cfg.Audit,
 	}
 }
 
@@ -50,6 +78,18 @@ func (f *Factory) Create(cfg config.MiddlewareConfig) (Middleware, error) {
 		return f.createLoggingMiddleware(cfg.Config)
 	case "recovery":
 		return f.createRecoveryMiddleware(cfg.Config)
+	case "cache":
+		return f.createCacheMiddleware(cfg.Config)
+	case "timeout":
+		return f.createTimeoutMiddleware(cfg.Config)
+	case "transform":
+		return f.createTransformMiddleware(cfg.Config)
+	case "hmac":
+		return f.createHMACMiddleware(cfg.Config)
+	case "basic_auth":
+		return f.createBasicAuthMiddleware(cfg.Config)
+	case "oidc":
+		return f.createOIDCMiddleware(cfg.Config)
 	default:
 		return nil, fmt.Errorf("unknown middleware type: %s", cfg.Type)
 	}
@@ -59,8 +99,11 @@ func (f *Factory) Create(cfg config.MiddlewareConfig) (Middleware, error) {
 func (f *Factory) createJWTMiddleware(cfg map[string]any) (Middleware, error) {
 	jwtConfig := auth.JWTConfig{
 		PublicKeys:     f.jwtPublicKeys,
+		KeySet:         f.jwtKeys,
+		JWKS:           f.jwks,
 		SkipValidation: false,
 		RequiredClaims: []string{},
+		Audit:          f.audit,
 	}
 
 	// skip_validation の設定
@@ -96,6 +139,8 @@ func (f *Factory) createRevokeMiddleware(cfg map[string]any) (Middleware, error)
 		IssuedAtClaim: "iat",
 		FailOpen:      false,
 		Logger:        f.logger,
+		Audit:         f.audit,
+		Cache:         f.revokeCache,
 	}
 
 	// fail_open の設定
@@ -119,6 +164,21 @@ func (f *Factory) createRevokeMiddleware(cfg map[string]any) (Middleware, error)
 		}
 	}
 
+	// clock_skew の設定（"1s" 形式または秒数）
+	switch v := cfg["clock_skew"].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid revoke clock_skew %q: %w", v, err)
+		}
+		revokeConfig.ClockSkew = d
+	case int:
+		revokeConfig.ClockSkew = time.Duration(v) * time.Second
+	}
+	if revokeConfig.ClockSkew < 0 {
+		return nil, fmt.Errorf("revoke clock_skew must not be negative: %s", revokeConfig.ClockSkew)
+	}
+
 	return auth.NewRevokeMiddleware(revokeConfig), nil
 }
 
@@ -219,6 +279,13 @@ func (f *Factory) createLoggingMiddleware(cfg map[string]any) (Middleware, error
 		}
 	}
 
+	// log_headers の設定
+	if logHeadersVal, ok := cfg["log_headers"]; ok {
+		if logHeaders, ok := logHeadersVal.(bool); ok {
+			loggingConfig.LogHeaders = logHeaders
+		}
+	}
+
 	// skip_paths の設定
 	if skipPathsVal, ok := cfg["skip_paths"]; ok {
 		if skipPaths, ok := skipPathsVal.([]any); ok {
@@ -230,6 +297,35 @@ func (f *Factory) createLoggingMiddleware(cfg map[string]any) (Middleware, error
 		}
 	}
 
+	// max_body_size の設定
+	if maxVal, ok := cfg["max_body_size"]; ok {
+		if maxSize, ok := maxVal.(int); ok {
+			loggingConfig.MaxBodySize = maxSize
+		}
+	}
+
+	// content_types の設定
+	if typesVal, ok := cfg["content_types"]; ok {
+		if types, ok := typesVal.([]any); ok {
+			for _, contentType := range types {
+				if typeStr, ok := contentType.(string); ok {
+					loggingConfig.BodyContentTypes = append(loggingConfig.BodyContentTypes, typeStr)
+				}
+			}
+		}
+	}
+
+	// redact_fields の設定
+	if fieldsVal, ok := cfg["redact_fields"]; ok {
+		if fields, ok := fieldsVal.([]any); ok {
+			for _, field := range fields {
+				if fieldStr, ok := field.(string); ok {
+					loggingConfig.RedactFields = append(loggingConfig.RedactFields, fieldStr)
+				}
+			}
+		}
+	}
+
 	return NewLoggingMiddleware(f.logger, loggingConfig), nil
 }
 
@@ -248,3 +344,293 @@ func (f *Factory) createRecoveryMiddleware(cfg map[string]any) (Middleware, erro
 
 	return NewRecoveryMiddleware(f.logger, recoveryConfig), nil
 }
+
+// createCacheMiddleware はキャッシュミドルウェアを生成する
+func (f *Factory) createCacheMiddleware(cfg map[string]any) (Middleware, error) {
+	if f.cacheStore == nil {
+		return nil, fmt.Errorf("cache store is required for cache middleware")
+	}
+
+	cacheConfig := CacheConfig{
+		Store:       f.cacheStore,
+		VaryHeaders: []string{},
+	}
+
+	// ttl の設定（"30s" 形式または秒数）
+	if ttlVal, ok := cfg["ttl"]; ok {
+		switch ttl := ttlVal.(type) {
+		case string:
+			d, err := time.ParseDuration(ttl)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cache ttl %q: %w", ttl, err)
+			}
+			cacheConfig.TTL = d
+		case int:
+			cacheConfig.TTL = time.Duration(ttl) * time.Second
+		}
+	}
+
+	// vary の設定
+	if varyVal, ok := cfg["vary"]; ok {
+		if vary, ok := varyVal.([]any); ok {
+			for _, header := range vary {
+				if headerStr, ok := header.(string); ok {
+					cacheConfig.VaryHeaders = append(cacheConfig.VaryHeaders, headerStr)
+				}
+			}
+		}
+	}
+
+	// max_body_size の設定
+	if maxVal, ok := cfg["max_body_size"]; ok {
+		if maxSize, ok := maxVal.(int); ok {
+			cacheConfig.MaxBodySize = int64(maxSize)
+		}
+	}
+
+	return NewCacheMiddleware(cacheConfig), nil
+}
+
+// createTimeoutMiddleware はタイムアウトミドルウェアを生成する
+func (f *Factory) createTimeoutMiddleware(cfg map[string]any) (Middleware, error) {
+	var timeout time.Duration
+
+	// timeout の設定（"5s" 形式または秒数）
+	switch v := cfg["timeout"].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", v, err)
+		}
+		timeout = d
+	case int:
+		timeout = time.Duration(v) * time.Second
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout middleware requires a positive timeout")
+	}
+
+	return NewTimeoutMiddleware(timeout), nil
+}
+
+// createTransformMiddleware はリクエストボディ変換ミドルウェアを生成する
+func (f *Factory) createTransformMiddleware(cfg map[string]any) (Middleware, error) {
+	transformConfig := TransformConfig{
+		Rename: map[string]string{},
+		Set:    map[string]any{},
+	}
+
+	// rename の設定（変更前のパス: 変更後のパス）
+	if renameVal, ok := cfg["rename"]; ok {
+		rename, ok := renameVal.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("transform rename must be a map")
+		}
+		for from, toVal := range rename {
+			to, ok := toVal.(string)
+			if !ok {
+				return nil, fmt.Errorf("transform rename of %s must be a string", from)
+			}
+			transformConfig.Rename[from] = to
+		}
+	}
+
+	// remove の設定
+	if removeVal, ok := cfg["remove"]; ok {
+		if remove, ok := removeVal.([]any); ok {
+			for _, field := range remove {
+				if fieldStr, ok := field.(string); ok {
+					transformConfig.Remove = append(transformConfig.Remove, fieldStr)
+				}
+			}
+		}
+	}
+
+	// set の設定（パス: 値またはテンプレート）
+	if setVal, ok := cfg["set"]; ok {
+		set, ok := setVal.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("transform set must be a map")
+		}
+		transformConfig.Set = set
+	}
+
+	// max_body_size の設定
+	if maxVal, ok := cfg["max_body_size"]; ok {
+		if maxSize, ok := maxVal.(int); ok {
+			transformConfig.MaxBodySize = int64(maxSize)
+		}
+	}
+
+	m, err := NewTransformMiddleware(transformConfig)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// createHMACMiddleware はHMAC署名の検証ミドルウェアを生成する
+func (f *Factory) createHMACMiddleware(cfg map[string]any) (Middleware, error) {
+	hmacConfig := auth.HMACConfig{
+		Secrets: map[string]string{},
+		Audit:   f.audit,
+		Replay:  f.hmacReplay,
+	}
+
+	// header, algorithm の設定
+	if header, ok := cfg["header"].(string); ok {
+		hmacConfig.Header = header
+	}
+	if algorithm, ok := cfg["algorithm"].(string); ok {
+		hmacConfig.Algorithm = algorithm
+	}
+
+	// secrets の設定（コンシューマー: 鍵）
+	if secretsVal, ok := cfg["secrets"]; ok {
+		secrets, ok := secretsVal.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("hmac secrets must be a map")
+		}
+		for consumer, secretVal := range secrets {
+			secret, ok := secretVal.(string)
+			if !ok {
+				return nil, fmt.Errorf("hmac secret of %s must be a string", consumer)
+			}
+			hmacConfig.Secrets[consumer] = secret
+		}
+	}
+
+	// components の設定
+	if componentsVal, ok := cfg["components"]; ok {
+		if components, ok := componentsVal.([]any); ok {
+			for _, component := range components {
+				if componentStr, ok := component.(string); ok {
+					hmacConfig.Components = append(hmacConfig.Components, componentStr)
+				}
+			}
+		}
+	}
+
+	// max_skew の設定（"5m" 形式または秒数）
+	switch v := cfg["max_skew"].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hmac max_skew %q: %w", v, err)
+		}
+		hmacConfig.MaxSkew = d
+	case int:
+		hmacConfig.MaxSkew = time.Duration(v) * time.Second
+	}
+
+	// max_body_size の設定
+	if maxSize, ok := cfg["max_body_size"].(int); ok {
+		hmacConfig.MaxBodySize = int64(maxSize)
+	}
+
+	m, err := auth.NewHMACMiddleware(hmacConfig)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// createBasicAuthMiddleware はBasic認証ミドルウェアを生成する
+func (f *Factory) createBasicAuthMiddleware(cfg map[string]any) (Middleware, error) {
+	basicConfig := auth.BasicAuthConfig{
+		Users: map[string]string{},
+		Audit: f.audit,
+	}
+
+	// realm の設定
+	if realm, ok := cfg["realm"].(string); ok {
+		basicConfig.Realm = realm
+	}
+
+	// users の設定（ユーザー名: bcrypt ハッシュ）
+	if usersVal, ok := cfg["users"]; ok {
+		users, ok := usersVal.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("basic_auth users must be a map")
+		}
+		for username, hashVal := range users {
+			hash, ok := hashVal.(string)
+			if !ok {
+				return nil, fmt.Errorf("basic_auth password hash of %s must be a string", username)
+			}
+			basicConfig.Users[username] = hash
+		}
+	}
+
+	m, err := auth.NewBasicAuthMiddleware(basicConfig)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// createOIDCMiddleware はOIDCセッション認証ミドルウェアを生成する
+// IDトークンは jwt ミドルウェアと同じ公開鍵・JWKSで検証し、セッションはRedisに保存する
+func (f *Factory) createOIDCMiddleware(cfg map[string]any) (Middleware, error) {
+	if f.oidcSessions == nil {
+		return nil, fmt.Errorf("oidc middleware requires redis")
+	}
+
+	oidcConfig := auth.OIDCConfig{
+		PublicKeys: f.jwtPublicKeys,
+		KeySet:     f.jwtKeys,
+		JWKS:       f.jwks,
+		Sessions:   f.oidcSessions,
+		Audit:      f.audit,
+	}
+
+	// IdPとクライアントの設定（client_secret は ${OIDC_CLIENT_SECRET} のように環境変数から設定する）
+	for key, field := range map[string]*string{
+		"issuer":            &oidcConfig.Issuer,
+		"authorization_url": &oidcConfig.AuthorizationURL,
+		"token_url":         &oidcConfig.TokenURL,
+		"client_id":         &oidcConfig.ClientID,
+		"client_secret":     &oidcConfig.ClientSecret,
+		"redirect_url":      &oidcConfig.RedirectURL,
+		"logout_path":       &oidcConfig.LogoutPath,
+		"cookie_name":       &oidcConfig.CookieName,
+	} {
+		if v, ok := cfg[key].(string); ok {
+			*field = v
+		}
+	}
+
+	// scopes の設定
+	if scopesVal, ok := cfg["scopes"]; ok {
+		if scopes, ok := scopesVal.([]any); ok {
+			for _, scope := range scopes {
+				if scopeStr, ok := scope.(string); ok {
+					oidcConfig.Scopes = append(oidcConfig.Scopes, scopeStr)
+				}
+			}
+		}
+	}
+
+	// insecure_cookie の設定
+	if insecure, ok := cfg["insecure_cookie"].(bool); ok {
+		oidcConfig.InsecureCookie = insecure
+	}
+
+	// session_ttl の設定（"8h" 形式または秒数）
+	switch v := cfg["session_ttl"].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oidc session_ttl %q: %w", v, err)
+		}
+		oidcConfig.SessionTTL = d
+	case int:
+		oidcConfig.SessionTTL = time.Duration(v) * time.Second
+	}
+
+	m, err := auth.NewOIDCMiddleware(oidcConfig)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
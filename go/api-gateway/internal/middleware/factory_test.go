@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"testing"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/repository"
+)
+
+func TestFactory_CreateRevokeMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{name: "未指定", config: map[string]any{}},
+		{name: "duration形式", config: map[string]any{"clock_skew": "1500ms"}},
+		{name: "秒数", config: map[string]any{"clock_skew": 2}},
+		{name: "不正な値", config: map[string]any{"clock_skew": "soon"}, wantErr: true},
+		{name: "負の値", config: map[string]any{"clock_skew": "-1s"}, wantErr: true},
+	}
+
+	// 生成時にはRedisに接続しないため、クライアントは不要
+	factory := NewFactory(FactoryConfig{SessionRepo: repository.NewRedisSessionRepository(nil, "")})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := factory.Create(config.MiddlewareConfig{Type: "revoke", Config: tt.config})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
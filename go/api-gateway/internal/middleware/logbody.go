@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultLogBodySize はログに記録するボディの上限バイト数のデフォルト値
+const DefaultLogBodySize = 4096
+
+// DefaultLogBodyContentTypes はボディをログに記録するContent-Typeのデフォルト値
+var DefaultLogBodyContentTypes = []string{"application/json", "application/x-www-form-urlencoded", "text/plain"}
+
+// DefaultLogRedactFields は設定に関わらず値を伏せるフィールド（大文字小文字を区別しない）
+var DefaultLogRedactFields = []string{
+	"password", "passwd", "secret", "client_secret", "api_key", "apikey",
+	"token", "access_token", "refresh_token", "id_token", "authorization",
+}
+
+// redactedValue は伏せた値の置き換え後の文字列
+const redactedValue = "[REDACTED]"
+
+// bodyLogger はボディを上限まで読み取り、フィールドの値を伏せてログの属性にする
+type bodyLogger struct {
+	maxSize      int
+	contentTypes []string
+	fields       map[string]bool
+	// jsonPattern・formPattern は解析できないボディ（上限で切り詰めたものなど）の値を伏せるパターン
+	jsonPattern *regexp.Regexp
+	formPattern *regexp.Regexp
+}
+
+// newBodyLogger は新しいbodyLoggerを作成する（redactFields は DefaultLogRedactFields に加える）
+func newBodyLogger(maxSize int, contentTypes, redactFields []string) *bodyLogger {
+	if maxSize <= 0 {
+		maxSize = DefaultLogBodySize
+	}
+	if len(contentTypes) == 0 {
+		contentTypes = DefaultLogBodyContentTypes
+	}
+
+	fields := make(map[string]bool)
+	var quoted []string
+	for _, field := range append(append([]string{}, DefaultLogRedactFields...), redactFields...) {
+		field = strings.ToLower(field)
+		if field != "" && !fields[field] {
+			fields[field] = true
+			quoted = append(quoted, regexp.QuoteMeta(field))
+		}
+	}
+	names := strings.Join(quoted, "|")
+
+	return &bodyLogger{
+		maxSize:      maxSize,
+		contentTypes: contentTypes,
+		fields:       fields,
+		// "password": "..." の値（文字列は閉じていなくてもよい）
+		jsonPattern: regexp.MustCompile(`(?i)("(?:` + names + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`),
+		// password=... または password: ... の値
+		formPattern: regexp.MustCompile(`(?i)(^|[&\s;,])((?:` + names + `)\s*[=:]\s*)[^&\s;,]*`),
+	}
+}
+
+// allowed はボディを記録するContent-Typeか確認する（"text/*" のように末尾のワイルドカードを使える）
+func (b *bodyLogger) allowed(header http.Header) bool {
+	// 圧縮されたボディは読めないため記録しない
+	if encoding := header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, allowed := range b.contentTypes {
+		allowed = strings.ToLower(allowed)
+		if mediaType == allowed || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+		// application/problem+json なども application/json として扱う
+		if allowed == "application/json" && strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json") {
+			return true
+		}
+	}
+	return false
+}
+
+// readRequest はリクエストボディの先頭を上限まで読み取り、読み取った分を戻したボディに差し替える
+// 記録しないリクエストの場合は false を返す
+func (b *bodyLogger) readRequest(req *http.Request) ([]byte, bool, bool) {
+	if req.Body == nil || req.Body == http.NoBody || !b.allowed(req.Header) {
+		return nil, false, false
+	}
+	head, _ := io.ReadAll(io.LimitReader(req.Body, int64(b.maxSize)+1))
+	req.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(head), req.Body), Closer: req.Body}
+	if len(head) > b.maxSize {
+		return head[:b.maxSize], true, true
+	}
+	return head, false, true
+}
+
+// attrs はボディの値を伏せたログの属性を返す（key はボディ、key_truncated は切り詰めたかどうか）
+func (b *bodyLogger) attrs(key, contentType string, body []byte, truncated bool) []any {
+	attrs := []any{slog.String(key, b.redact(contentType, body))}
+	if truncated {
+		attrs = append(attrs, slog.Bool(key+"_truncated", true))
+	}
+	return attrs
+}
+
+// redact はボディのうち伏せるフィールドの値を置き換えた文字列を返す
+func (b *bodyLogger) redact(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		if redacted, ok := b.redactJSON(body); ok {
+			return redacted
+		}
+		return strings.ToValidUTF8(b.jsonPattern.ReplaceAllString(string(body), `$1"`+redactedValue+`"`), "")
+	}
+	return strings.ToValidUTF8(b.formPattern.ReplaceAllString(string(body), "${1}${2}"+redactedValue), "")
+}
+
+// redactJSON はJSONとして解析し、伏せるフィールドの値を全ての階層で置き換える（解析できない場合は false）
+func (b *bodyLogger) redactJSON(body []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(b.redactValue(v)); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// redactValue は伏せるフィールドの値を置き換える
+func (b *bodyLogger) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if b.fields[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = b.redactValue(value)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = b.redactValue(item)
+		}
+	}
+	return v
+}
+
+// replayBody は読み取った先頭と残りのボディを続けて読めるようにする
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCapture はレスポンスボディの先頭を上限まで保持する
+type bodyCapture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// write は上限までボディを保持する
+func (c *bodyCapture) write(p []byte) {
+	if room := c.limit - c.buf.Len(); len(p) > room {
+		c.truncated = true
+		p = p[:max(room, 0)]
+	}
+	c.buf.Write(p)
+}
@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"time"
 
+	"api-gateway/internal/requestctx"
+	"api-gateway/pkg/logger"
+
 	"github.com/google/uuid"
 )
 
@@ -17,6 +20,18 @@ type LoggingConfig struct {
 	// LogResponseBody はレスポンスボディをログに記録するか
 	LogResponseBody bool
 
+	// MaxBodySize はログに記録するボディの上限バイト数（0は DefaultLogBodySize。超えた分は切り詰める）
+	MaxBodySize int
+
+	// BodyContentTypes はボディを記録するContent-Type（空は DefaultLogBodyContentTypes）
+	BodyContentTypes []string
+
+	// RedactFields はボディのうち値を伏せるフィールド（DefaultLogRedactFields に加える。大文字小文字を区別しない）
+	RedactFields []string
+
+	// LogHeaders はリクエストヘッダーをログに記録するか（Authorization などの値は伏せる）
+	LogHeaders bool
+
 	// SkipPaths はログ記録をスキップするパスのリスト
 	SkipPaths []string
 }
@@ -25,6 +40,7 @@ type LoggingConfig struct {
 type LoggingMiddleware struct {
 	logger *slog.Logger
 	config LoggingConfig
+	body   *bodyLogger
 }
 
 // NewLoggingMiddleware は新しいログミドルウェアを作成する
@@ -32,20 +48,10 @@ func NewLoggingMiddleware(logger *slog.Logger, config LoggingConfig) *LoggingMid
 	return &LoggingMiddleware{
 		logger: logger,
 		config: config,
+		body:   newBodyLogger(config.MaxBodySize, config.BodyContentTypes, config.RedactFields),
 	}
 }
 
-// contextKey はコンテキストのキー型
-type loggingContextKey string
-
-const (
-	// requestIDKey はリクエストIDを格納するコンテキストキー
-	requestIDKey loggingContextKey = "request_id"
-
-	// requestStartTimeKey はリクエスト開始時刻を格納するコンテキストキー
-	requestStartTimeKey loggingContextKey = "request_start_time"
-)
-
 // Process はアクセスログを記録する
 func (m *LoggingMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
 	// スキップパスのチェック
@@ -53,36 +59,65 @@ func (m *LoggingMiddleware) Process(ctx context.Context, req *http.Request) (con
 		return ctx, nil
 	}
 
-	// リクエストIDの生成
-	requestID := uuid.New().String()
-	ctx = context.WithValue(ctx, requestIDKey, requestID)
-
-	// リクエスト開始時刻を記録
-	startTime := time.Now()
-	ctx = context.WithValue(ctx, requestStartTimeKey, startTime)
+	// リクエストIDと開始時刻はGatewayが確定済みの場合はそれを使い、無い場合（Gatewayを経由しない場合）は生成する
+	values := requestctx.From(ctx)
+	if values.RequestID == "" {
+		values.RequestID = uuid.New().String()
+		if c, ok := logger.CorrelationFromContext(ctx); ok && c.RequestID != "" {
+			values.RequestID = c.RequestID
+		}
+	}
+	if values.StartTime.IsZero() {
+		values.StartTime = time.Now()
+	}
+	ctx = requestctx.With(ctx, values)
+	requestID := values.RequestID
 
 	// リクエストログの記録
-	m.logRequest(req, requestID)
+	m.logRequest(ctx, req, requestID)
 
 	return ctx, nil
 }
 
+// Wrap はレスポンスのステータスコードとボディのサイズを記録し、レスポンスの送信後にログに記録する
+// 後続のミドルウェアのエラーレスポンスも対象にする
+func (m *LoggingMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.shouldSkipPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sw := &statusWriter{ResponseWriter: w}
+		if m.config.LogResponseBody {
+			sw.body = &bodyCapture{limit: m.body.maxSize}
+		}
+		next.ServeHTTP(sw, r)
+
+		var attrs []any
+		if sw.body != nil && sw.body.buf.Len() > 0 && m.body.allowed(sw.Header()) {
+			attrs = m.body.attrs("response_body", sw.Header().Get("Content-Type"), sw.body.buf.Bytes(), sw.body.truncated)
+		}
+		LogResponse(m.logger, r.Context(), sw.status(), sw.bytes, attrs...)
+	})
+}
+
 // logRequest はリクエスト情報をログに記録する
-func (m *LoggingMiddleware) logRequest(req *http.Request, requestID string) {
+func (m *LoggingMiddleware) logRequest(ctx context.Context, req *http.Request, requestID string) {
+	// method, path, query などのリクエスト情報は http グループにまとめる（空のクエリは出力しない）
 	attrs := []any{
 		slog.String("request_id", requestID),
-		slog.String("method", req.Method),
-		slog.String("path", req.URL.Path),
-		slog.String("remote_addr", req.RemoteAddr),
-		slog.String("user_agent", req.UserAgent()),
+		logger.NewHTTPRequest(req).Attr(),
 	}
-
-	// クエリパラメータが存在する場合は記録
-	if req.URL.RawQuery != "" {
-		attrs = append(attrs, slog.String("query", req.URL.RawQuery))
+	if m.config.LogHeaders {
+		attrs = append(attrs, slog.Any("headers", logger.ScrubHeaders(req.Header)))
 	}
-
-	m.logger.Info("incoming request", attrs...)
+	// ボディは先頭だけを読み取り、読み取った分は後続の処理が読めるように戻す
+	if m.config.LogRequestBody {
+		if body, truncated, ok := m.body.readRequest(req); ok {
+			attrs = append(attrs, m.body.attrs("request_body", req.Header.Get("Content-Type"), body, truncated)...)
+		}
+	}
+	m.logger.InfoContext(ctx, "incoming request", attrs...)
 }
 
 // shouldSkipPath はパスがスキップ対象か確認する
@@ -95,23 +130,12 @@ func (m *LoggingMiddleware) shouldSkipPath(path string) bool {
 	return false
 }
 
-// GetRequestID はコンテキストからリクエストIDを取得する
-func GetRequestID(ctx context.Context) (string, bool) {
-	requestID, ok := ctx.Value(requestIDKey).(string)
-	return requestID, ok
-}
-
-// GetRequestStartTime はコンテキストからリクエスト開始時刻を取得する
-func GetRequestStartTime(ctx context.Context) (time.Time, bool) {
-	startTime, ok := ctx.Value(requestStartTimeKey).(time.Time)
-	return startTime, ok
-}
-
 // LogResponse はレスポンス情報をログに記録するヘルパー関数
-// この関数はハンドラ側から呼び出される
-func LogResponse(logger *slog.Logger, ctx context.Context, statusCode int, bytesWritten int) {
-	requestID, _ := GetRequestID(ctx)
-	startTime, ok := GetRequestStartTime(ctx)
+// Chain.Then では Wrap から呼び出される。Execute でミドルウェアを実行する場合はハンドラ側から呼び出す
+// extra はレスポンスボディなどの追加の属性
+func LogResponse(logger *slog.Logger, ctx context.Context, statusCode int, bytesWritten int, extra ...any) {
+	requestID, _ := requestctx.RequestID(ctx)
+	startTime, ok := requestctx.StartTime(ctx)
 
 	attrs := []any{
 		slog.String("request_id", requestID),
@@ -123,6 +147,51 @@ func LogResponse(logger *slog.Logger, ctx context.Context, statusCode int, bytes
 		duration := time.Since(startTime)
 		attrs = append(attrs, slog.Duration("duration", duration))
 	}
+	attrs = append(attrs, extra...)
+
+	logger.InfoContext(ctx, "response sent", attrs...)
+}
+
+// statusWriter はレスポンスのステータスコードと書き込んだバイト数を記録する
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int
+	// body はログに記録するレスポンスボディ（記録しない場合は nil）
+	body *bodyCapture
+}
+
+// WriteHeader はステータスコードを記録してから書き込む
+func (sw *statusWriter) WriteHeader(statusCode int) {
+	// 1xxの中間レスポンスは最終レスポンスではないため記録しない
+	if sw.statusCode == 0 && statusCode >= http.StatusOK {
+		sw.statusCode = statusCode
+	}
+	sw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write は書き込んだバイト数を記録する
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.statusCode == 0 {
+		sw.statusCode = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += n
+	if sw.body != nil {
+		sw.body.write(b[:n])
+	}
+	return n, err
+}
 
-	logger.Info("response sent", attrs...)
+// Unwrap はhttp.ResponseControllerがFlushなどを元のResponseWriterへ委譲するために使う
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// status は記録したステータスコードを返す（何も書き込まれなかった場合は200）
+func (sw *statusWriter) status() int {
+	if sw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return sw.statusCode
 }
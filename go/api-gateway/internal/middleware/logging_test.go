@@ -3,11 +3,15 @@ package middleware
 import (
 	"bytes"
 	"context"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"api-gateway/internal/requestctx"
 )
 
 func TestNewLoggingMiddleware(t *testing.T) {
@@ -104,7 +108,7 @@ func TestLoggingMiddleware_Process(t *testing.T) {
 			}
 
 			// リクエストIDの確認
-			requestID, hasRequestID := GetRequestID(newCtx)
+			requestID, hasRequestID := requestctx.RequestID(newCtx)
 			if hasRequestID != tt.wantRequestID {
 				t.Errorf("hasRequestID = %v, want %v", hasRequestID, tt.wantRequestID)
 			}
@@ -113,7 +117,7 @@ func TestLoggingMiddleware_Process(t *testing.T) {
 			}
 
 			// 開始時刻の確認
-			startTime, hasStartTime := GetRequestStartTime(newCtx)
+			startTime, hasStartTime := requestctx.StartTime(newCtx)
 			if hasStartTime != tt.wantStartTime {
 				t.Errorf("hasStartTime = %v, want %v", hasStartTime, tt.wantStartTime)
 			}
@@ -199,80 +203,6 @@ func TestLoggingMiddleware_shouldSkipPath(t *testing.T) {
 	}
 }
 
-func TestGetRequestID(t *testing.T) {
-	tests := []struct {
-		name      string
-		ctx       context.Context
-		wantID    string
-		wantFound bool
-	}{
-		{
-			name:      "リクエストIDが設定されている場合",
-			ctx:       context.WithValue(context.Background(), requestIDKey, "test-request-id"),
-			wantID:    "test-request-id",
-			wantFound: true,
-		},
-		{
-			name:      "リクエストIDが設定されていない場合",
-			ctx:       context.Background(),
-			wantID:    "",
-			wantFound: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotID, gotFound := GetRequestID(tt.ctx)
-
-			if gotFound != tt.wantFound {
-				t.Errorf("GetRequestID() found = %v, want %v", gotFound, tt.wantFound)
-			}
-
-			if gotID != tt.wantID {
-				t.Errorf("GetRequestID() id = %v, want %v", gotID, tt.wantID)
-			}
-		})
-	}
-}
-
-func TestGetRequestStartTime(t *testing.T) {
-	now := time.Now()
-
-	tests := []struct {
-		name      string
-		ctx       context.Context
-		wantTime  time.Time
-		wantFound bool
-	}{
-		{
-			name:      "開始時刻が設定されている場合",
-			ctx:       context.WithValue(context.Background(), requestStartTimeKey, now),
-			wantTime:  now,
-			wantFound: true,
-		},
-		{
-			name:      "開始時刻が設定されていない場合",
-			ctx:       context.Background(),
-			wantTime:  time.Time{},
-			wantFound: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotTime, gotFound := GetRequestStartTime(tt.ctx)
-
-			if gotFound != tt.wantFound {
-				t.Errorf("GetRequestStartTime() found = %v, want %v", gotFound, tt.wantFound)
-			}
-
-			if !gotTime.Equal(tt.wantTime) {
-				t.Errorf("GetRequestStartTime() time = %v, want %v", gotTime, tt.wantTime)
-			}
-		})
-	}
-}
-
 func TestLogResponse(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -286,8 +216,8 @@ func TestLogResponse(t *testing.T) {
 			name: "完全なコンテキスト",
 			ctx: func() context.Context {
 				ctx := context.Background()
-				ctx = context.WithValue(ctx, requestIDKey, "test-id")
-				ctx = context.WithValue(ctx, requestStartTimeKey, time.Now().Add(-100*time.Millisecond))
+				ctx = requestctx.WithRequestID(ctx, "test-id")
+				ctx = requestctx.WithStartTime(ctx, time.Now().Add(-100*time.Millisecond))
 				return ctx
 			}(),
 			statusCode:    200,
@@ -337,6 +267,67 @@ func TestLogResponse(t *testing.T) {
 	}
 }
 
+func TestLoggingMiddleware_Wrap(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		handler    http.HandlerFunc
+		wantLogged bool
+		wantStatus string
+		wantBytes  string
+	}{
+		{
+			name: "ステータスコードとボディのサイズを記録する",
+			path: "/api/users",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("not found"))
+			},
+			wantLogged: true,
+			wantStatus: "status_code=404",
+			wantBytes:  "bytes_written=9",
+		},
+		{
+			name: "WriteHeaderを呼ばない場合は200",
+			path: "/api/users",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			},
+			wantLogged: true,
+			wantStatus: "status_code=200",
+			wantBytes:  "bytes_written=2",
+		},
+		{
+			name:    "スキップパスは記録しない",
+			path:    "/health",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			m := NewLoggingMiddleware(slog.New(slog.NewTextHandler(&buf, nil)), LoggingConfig{SkipPaths: []string{"/health"}})
+
+			rec := httptest.NewRecorder()
+			NewChain(m).Then(tt.handler, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			logOutput := buf.String()
+			if got := strings.Contains(logOutput, "response sent"); got != tt.wantLogged {
+				t.Fatalf("response logged = %v, want %v: %s", got, tt.wantLogged, logOutput)
+			}
+			if !tt.wantLogged {
+				return
+			}
+			for _, want := range []string{tt.wantStatus, tt.wantBytes, "request_id=", "duration="} {
+				if !strings.Contains(logOutput, want) {
+					t.Errorf("log does not contain %s: %s", want, logOutput)
+				}
+			}
+		})
+	}
+}
+
 func TestLoggingMiddleware_Process_Race(t *testing.T) {
 	var buf bytes.Buffer
 	logger := slog.New(slog.NewTextHandler(&buf, nil))
@@ -357,3 +348,98 @@ func TestLoggingMiddleware_Process_Race(t *testing.T) {
 		<-done
 	}
 }
+
+func TestLoggingMiddleware_Body(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       LoggingConfig
+		contentType  string
+		body         string
+		respType     string
+		respBody     string
+		wantLog      []string
+		wantNotInLog []string
+	}{
+		{
+			name:         "JSONのフィールドは全ての階層で伏せる",
+			config:       LoggingConfig{LogRequestBody: true, LogResponseBody: true},
+			contentType:  "application/json",
+			body:         `{"user":"alice","Password":"hunter2","nested":{"api_key":"k-1"}}`,
+			respType:     "application/json; charset=utf-8",
+			respBody:     `{"access_token":"eyJ.abc","expires_in":3600}`,
+			wantLog:      []string{`"user":"alice"`, `"Password":"[REDACTED]"`, `"api_key":"[REDACTED]"`, `"access_token":"[REDACTED]"`, `"expires_in":3600`},
+			wantNotInLog: []string{"hunter2", "k-1", "eyJ.abc"},
+		},
+		{
+			name:         "設定したフィールドとフォームの値を伏せる",
+			config:       LoggingConfig{LogRequestBody: true, RedactFields: []string{"card_number"}},
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "name=alice&card_number=4111111111111111&password=hunter2",
+			wantLog:      []string{"name=alice", "card_number=[REDACTED]", "password=[REDACTED]"},
+			wantNotInLog: []string{"4111111111111111", "hunter2"},
+		},
+		{
+			name:         "上限で切り詰めたJSONも伏せる",
+			config:       LoggingConfig{LogRequestBody: true, MaxBodySize: 40},
+			contentType:  "application/json",
+			body:         `{"user":"alice","token":"secret-token-value-that-is-long"}`,
+			wantLog:      []string{`"token":"[REDACTED]"`, "request_body_truncated=true"},
+			wantNotInLog: []string{"secret-token"},
+		},
+		{
+			name:         "許可していないContent-Typeは記録しない",
+			config:       LoggingConfig{LogRequestBody: true, LogResponseBody: true},
+			contentType:  "application/octet-stream",
+			body:         "binary",
+			respType:     "image/png",
+			respBody:     "png",
+			wantNotInLog: []string{"request_body", "response_body"},
+		},
+		{
+			name:         "無効な場合は記録しない",
+			contentType:  "application/json",
+			body:         `{"user":"alice"}`,
+			respType:     "application/json",
+			respBody:     `{}`,
+			wantNotInLog: []string{"request_body", "response_body"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			m := NewLoggingMiddleware(slog.New(slog.NewTextHandler(&buf, nil)), tt.config)
+
+			var forwarded string
+			handler := NewChain(m).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				forwarded = string(b)
+				if tt.respType != "" {
+					w.Header().Set("Content-Type", tt.respType)
+				}
+				w.Write([]byte(tt.respBody))
+			}), nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			// ログに記録しても後続の処理はボディ全体を読める
+			if forwarded != tt.body {
+				t.Errorf("forwarded body = %q, want %q", forwarded, tt.body)
+			}
+			logOutput := buf.String()
+			// テキスト形式のログでは値の " がエスケープされる
+			for _, want := range tt.wantLog {
+				if !strings.Contains(logOutput, strings.ReplaceAll(want, `"`, `\"`)) {
+					t.Errorf("log does not contain %s: %s", want, logOutput)
+				}
+			}
+			for _, unwanted := range tt.wantNotInLog {
+				if strings.Contains(logOutput, unwanted) {
+					t.Errorf("log contains %s: %s", unwanted, logOutput)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,25 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+var (
+	// middlewareDuration はミドルウェアの種類とルートごとの処理時間
+	middlewareDuration = metrics.NewHistogramVec(
+		"gateway_middleware_duration_seconds",
+		"Time spent in each middleware, by middleware type and route.",
+		metrics.DefaultBuckets,
+		"middleware", "route",
+	)
+	// middlewareErrors はミドルウェアの種類とルートごとのエラー数
+	middlewareErrors = metrics.NewCounterVec(
+		"gateway_middleware_errors_total",
+		"Number of requests rejected by each middleware, by middleware type and route.",
+		"middleware", "route",
+	)
 )
 
 // Middleware はHTTPリクエストを処理するミドルウェアのインターフェース
@@ -13,9 +32,54 @@ type Middleware interface {
 	Process(ctx context.Context, req *http.Request) (context.Context, error)
 }
 
+// Wrapper はレスポンスも扱うミドルウェアが実装するインターフェース
+// Process はリクエストの検証とcontextの更新だけを行うため、レスポンスヘッダーの書き込みやステータスコードの観測は Wrap で後続の処理を包んで行う
+// Chain.Then では Process が成功した後に、Wrap で包んだ後続の処理（以降のミドルウェアとバックエンドへの転送）を呼び出す
+type Wrapper interface {
+	Wrap(next http.Handler) http.Handler
+}
+
+// Handler は http.Handler を包む形式のミドルウェア
+type Handler func(next http.Handler) http.Handler
+
+// handlerMiddleware は Handler をチェーンに追加するためのアダプター
+type handlerMiddleware struct {
+	handler Handler
+}
+
+// FromHandler は Handler をチェーンに追加できるミドルウェアにする
+// Process は何もせず、Chain.Then で後続の処理を handler で包む（Execute では何もしない）
+func FromHandler(handler Handler) Middleware {
+	return &handlerMiddleware{handler: handler}
+}
+
+// Process は何もしない
+func (m *handlerMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	return ctx, nil
+}
+
+// Wrap は後続の処理を handler で包む
+func (m *handlerMiddleware) Wrap(next http.Handler) http.Handler {
+	return m.handler(next)
+}
+
+// ErrorHandler はミドルウェアの Process が返したエラーのレスポンスを書き込む
+// r は Process が返したcontextを持つ（期限切れの判定などに使う）
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
 // Chain は複数のミドルウェアを順次実行するチェーン
 type Chain struct {
 	middlewares []Middleware
+
+	// route と types が設定されている場合、ミドルウェアごとの処理時間とエラー数を記録する
+	route string
+	types []string
+}
+
+// Named はミドルウェアとその種類（設定の type）の組
+type Named struct {
+	Type       string
+	Middleware Middleware
 }
 
 // NewChain は新しいミドルウェアチェーンを作成する
@@ -25,12 +89,32 @@ func NewChain(middlewares ...Middleware) *Chain {
 	}
 }
 
+// NewRouteChain はルートのミドルウェアチェーンを作成する
+// 実行時にミドルウェアの種類とルートごとの処理時間（gateway_middleware_duration_seconds）と
+// エラー数（gateway_middleware_errors_total）を記録する
+func NewRouteChain(route string, middlewares ...Named) *Chain {
+	c := &Chain{
+		middlewares: make([]Middleware, 0, len(middlewares)),
+		route:       route,
+		types:       make([]string, 0, len(middlewares)),
+	}
+	for _, m := range middlewares {
+		c.middlewares = append(c.middlewares, m.Middleware)
+		c.types = append(c.types, m.Type)
+	}
+	return c
+}
+
 // Execute はチェーン内のすべてのミドルウェアを順次実行する
 // いずれかのミドルウェアがエラーを返した場合、処理を中断してエラーを返す
 func (c *Chain) Execute(ctx context.Context, req *http.Request) (context.Context, error) {
-	for _, mw := range c.middlewares {
+	for i, mw := range c.middlewares {
+		start := time.Now()
 		var err error
 		ctx, err = mw.Process(ctx, req)
+		if c.types != nil {
+			c.observe(c.types[i], time.Since(start), err)
+		}
 		if err != nil {
 			return ctx, err
 		}
@@ -38,14 +122,70 @@ func (c *Chain) Execute(ctx context.Context, req *http.Request) (context.Context
 	return ctx, nil
 }
 
+// Then はチェーン内のミドルウェアで final を包んだ http.Handler を返す
+// ミドルウェアは追加した順に Process を実行し、更新されたcontextを後続の処理へ渡す
+// いずれかのミドルウェアがエラーを返した場合は onError でレスポンスを書き込み、以降のミドルウェアと final は実行しない
+// Wrapper を実装するミドルウェアは後続の処理を包むため、以降のミドルウェアのエラーレスポンスと final のレスポンスの両方を扱える
+// 処理時間（gateway_middleware_duration_seconds）は Process の時間だけを記録する
+func (c *Chain) Then(final http.Handler, onError ErrorHandler) http.Handler {
+	h := final
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.handler(i, h, onError)
+	}
+	return h
+}
+
+// handler は i 番目のミドルウェアで next を包む
+func (c *Chain) handler(i int, next http.Handler, onError ErrorHandler) http.Handler {
+	mw := c.middlewares[i]
+	if wrapper, ok := mw.(Wrapper); ok {
+		next = wrapper.Wrap(next)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, err := mw.Process(r.Context(), r)
+		if c.types != nil {
+			c.observe(c.types[i], time.Since(start), err)
+		}
+		r = r.WithContext(ctx)
+		if err != nil {
+			onError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// observe はミドルウェア1つ分の処理時間とエラーを記録する
+func (c *Chain) observe(typ string, elapsed time.Duration, err error) {
+	middlewareDuration.With(typ, c.route).Observe(elapsed.Seconds())
+	if err != nil {
+		middlewareErrors.With(typ, c.route).Inc()
+	}
+}
+
 // Append は既存のチェーンに新しいミドルウェアを追加する
+// NewRouteChain で作成したチェーンでは、種類が不明なため "unknown" として記録する
 func (c *Chain) Append(middlewares ...Middleware) {
 	c.middlewares = append(c.middlewares, middlewares...)
+	if c.types != nil {
+		for range middlewares {
+			c.types = append(c.types, "unknown")
+		}
+	}
 }
 
 // Prepend は既存のチェーンの先頭に新しいミドルウェアを追加する
+// NewRouteChain で作成したチェーンでは、種類が不明なため "unknown" として記録する
 func (c *Chain) Prepend(middlewares ...Middleware) {
 	c.middlewares = append(middlewares, c.middlewares...)
+	if c.types != nil {
+		types := make([]string, len(middlewares), len(middlewares)+len(c.types))
+		for i := range types {
+			types[i] = "unknown"
+		}
+		c.types = append(types, c.types...)
+	}
 }
 
 // Len はチェーン内のミドルウェア数を返す
@@ -217,3 +217,164 @@ func TestChain_EmptyChain(t *testing.T) {
 		t.Error("context should be unchanged")
 	}
 }
+
+func TestRouteChain_Execute_Metrics(t *testing.T) {
+	ok := &mockMiddleware{
+		processFunc: func(ctx context.Context, req *http.Request) (context.Context, error) {
+			return ctx, nil
+		},
+	}
+	failing := &mockMiddleware{
+		processFunc: func(ctx context.Context, req *http.Request) (context.Context, error) {
+			return ctx, errors.New("unauthorized")
+		},
+	}
+	skipped := &mockMiddleware{
+		processFunc: func(ctx context.Context, req *http.Request) (context.Context, error) {
+			t.Error("middleware after an error must not run")
+			return ctx, nil
+		},
+	}
+
+	const route = "/test/route-chain-metrics"
+	chain := NewRouteChain(route,
+		Named{Type: "cors", Middleware: ok},
+		Named{Type: "jwt", Middleware: failing},
+		Named{Type: "revoke", Middleware: skipped},
+	)
+
+	for range 2 {
+		if _, err := chain.Execute(context.Background(), httptest.NewRequest(http.MethodGet, "/test", nil)); err == nil {
+			t.Fatal("expected error from chain")
+		}
+	}
+
+	tests := []struct {
+		typ        string
+		wantCount  uint64
+		wantErrors uint64
+	}{
+		{typ: "cors", wantCount: 2, wantErrors: 0},
+		{typ: "jwt", wantCount: 2, wantErrors: 2},
+		{typ: "revoke", wantCount: 0, wantErrors: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			if got := middlewareDuration.With(tt.typ, route).Count(); got != tt.wantCount {
+				t.Errorf("duration count = %d, want %d", got, tt.wantCount)
+			}
+			if got := middlewareErrors.With(tt.typ, route).Value(); got != tt.wantErrors {
+				t.Errorf("errors = %d, want %d", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+// wrapMiddleware はレスポンスも扱うテスト用のミドルウェア
+type wrapMiddleware struct {
+	mockMiddleware
+	wrapFunc func(next http.Handler) http.Handler
+}
+
+func (m *wrapMiddleware) Wrap(next http.Handler) http.Handler {
+	return m.wrapFunc(next)
+}
+
+func TestChain_Then(t *testing.T) {
+	passthrough := func(ctx context.Context, req *http.Request) (context.Context, error) {
+		return ctx, nil
+	}
+	failing := &mockMiddleware{
+		processFunc: func(ctx context.Context, req *http.Request) (context.Context, error) {
+			return ctx, errors.New("unauthorized")
+		},
+	}
+	// ctxKey はテスト用のコンテキストキー
+	type ctxKey struct{}
+	setValue := &mockMiddleware{
+		processFunc: func(ctx context.Context, req *http.Request) (context.Context, error) {
+			return context.WithValue(ctx, ctxKey{}, "set"), nil
+		},
+	}
+	// observer は後続の処理のステータスコードをヘッダーに書き込む
+	var observed int
+	observer := &wrapMiddleware{
+		mockMiddleware: mockMiddleware{processFunc: passthrough},
+		wrapFunc: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Wrapped", "true")
+				sw := &statusWriter{ResponseWriter: w}
+				next.ServeHTTP(sw, r)
+				observed = sw.status()
+			})
+		},
+	}
+
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v, _ := r.Context().Value(ctxKey{}).(string); v != "set" {
+			t.Errorf("context value = %q, want set", v)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	}
+
+	tests := []struct {
+		name         string
+		middlewares  []Middleware
+		wantStatus   int
+		wantObserved int
+		wantWrapped  bool
+	}{
+		{
+			name:         "Processで更新したcontextが後続に渡り、Wrapがレスポンスを観測する",
+			middlewares:  []Middleware{observer, setValue},
+			wantStatus:   http.StatusCreated,
+			wantObserved: http.StatusCreated,
+			wantWrapped:  true,
+		},
+		{
+			name:         "後のミドルウェアのエラーレスポンスもWrapで観測する",
+			middlewares:  []Middleware{observer, failing, setValue},
+			wantStatus:   http.StatusUnauthorized,
+			wantObserved: http.StatusUnauthorized,
+			wantWrapped:  true,
+		},
+		{
+			name:        "前のミドルウェアがエラーの場合はWrapも実行しない",
+			middlewares: []Middleware{failing, observer, setValue},
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name: "Handler形式のミドルウェア",
+			middlewares: []Middleware{setValue, FromHandler(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Wrapped", "true")
+					next.ServeHTTP(w, r)
+				})
+			})},
+			wantStatus:  http.StatusCreated,
+			wantWrapped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observed = 0
+			rec := httptest.NewRecorder()
+			NewChain(tt.middlewares...).Then(final, onError).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if observed != tt.wantObserved {
+				t.Errorf("observed status = %d, want %d", observed, tt.wantObserved)
+			}
+			if got := rec.Header().Get("X-Wrapped") == "true"; got != tt.wantWrapped {
+				t.Errorf("wrapped = %v, want %v", got, tt.wantWrapped)
+			}
+		})
+	}
+}
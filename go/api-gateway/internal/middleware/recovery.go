@@ -2,12 +2,12 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"net/http"
-	"runtime/debug"
 
 	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+	"api-gateway/pkg/logger"
 )
 
 // RecoveryConfig はリカバリーミドルウェアの設定
@@ -30,38 +30,48 @@ func NewRecoveryMiddleware(logger *slog.Logger, config RecoveryConfig) *Recovery
 	}
 }
 
-// Process はパニックから回復する
+// Process は何もしない
+// パニックの回復はGatewayが全てのリクエストでミドルウェアチェーンとバックエンドへの転送を Recover で囲んで行うため、
+// ルーティング設定の recovery は互換性のために受け付ける
 func (m *RecoveryMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
-	// パニックハンドリングはミドルウェアチェーン内では難しいため、
-	// このミドルウェアは主にパニック発生時の情報をコンテキストに保存する役割を持つ
-	// 実際のパニック回復はハンドラ層で行う必要がある
 	return ctx, nil
 }
 
-// Recover はパニックから回復し、エラーを返す
-// この関数はミドルウェアチェーンの実行をラップする形で使用される
+// Recover は next で起きたパニックから回復し、500のエラーを返す
+// パニックの値はログにのみ出力し、クライアントに返すエラーには含めない
+// http.ErrAbortHandler は接続を切るための意図的なパニックのため、回復せずにそのまま net/http へ渡す
 func (m *RecoveryMiddleware) Recover(ctx context.Context, req *http.Request, next func() error) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+
 			// パニックをログに記録
-			requestID, _ := GetRequestID(ctx)
+			// リクエストIDはGatewayが requestctx に設定する（Gatewayを経由しない場合は相関IDを使う）
+			requestID, ok := requestctx.RequestID(ctx)
+			if !ok {
+				if corr, ok := logger.CorrelationFromContext(ctx); ok {
+					requestID = corr.RequestID
+				}
+			}
 
 			attrs := []any{
 				slog.String("request_id", requestID),
-				slog.String("method", req.Method),
-				slog.String("path", req.URL.Path),
+				logger.NewHTTPRequest(req).Attr(),
+				slog.Any("headers", logger.ScrubHeaders(req.Header)),
 				slog.Any("panic", r),
 			}
 
-			// スタックトレースの追加
+			// スタックトレースの追加（パニック発生箇所から先頭の数フレームを出力する）
 			if m.config.EnableStackTrace {
-				attrs = append(attrs, slog.String("stack", string(debug.Stack())))
+				attrs = append(attrs, slog.Any("stack", logger.CallerStack(1)))
 			}
 
 			m.logger.Error("panic recovered", attrs...)
 
 			// パニックをエラーに変換
-			err = errors.NewInternalServerError(fmt.Sprintf("panic recovered: %v", r))
+			err = errors.NewInternalServerError("internal server error")
 		}
 	}()
 
@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+	pkglogger "api-gateway/pkg/logger"
 )
 
 func TestNewRecoveryMiddleware(t *testing.T) {
@@ -156,7 +158,7 @@ func TestRecoveryMiddleware_Recover_WithRequestID(t *testing.T) {
 	m := NewRecoveryMiddleware(logger, RecoveryConfig{})
 
 	req, _ := http.NewRequest("GET", "http://localhost/test", nil)
-	ctx := context.WithValue(context.Background(), requestIDKey, "test-request-id")
+	ctx := requestctx.WithRequestID(context.Background(), "test-request-id")
 
 	err := m.Recover(ctx, req, func() error {
 		panic("test panic")
@@ -172,6 +174,68 @@ func TestRecoveryMiddleware_Recover_WithRequestID(t *testing.T) {
 	}
 }
 
+func TestRecoveryMiddleware_Recover_WithCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	m := NewRecoveryMiddleware(logger, RecoveryConfig{})
+
+	req, _ := http.NewRequest("GET", "http://localhost/test", nil)
+	ctx := pkglogger.WithCorrelation(context.Background(), pkglogger.Correlation{RequestID: "gateway-request-id"})
+
+	err := m.Recover(ctx, req, func() error {
+		panic("test panic")
+	})
+
+	if err == nil {
+		t.Fatal("Recover() error = nil, want non-nil")
+	}
+	if strings.Contains(err.Error(), "test panic") {
+		t.Errorf("Recover() error = %v, should not contain the panic value", err)
+	}
+	if !strings.Contains(buf.String(), "request_id=gateway-request-id") {
+		t.Errorf("log does not contain request_id: %s", buf.String())
+	}
+}
+
+func TestRecoveryMiddleware_Recover_ScrubsHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	m := NewRecoveryMiddleware(logger, RecoveryConfig{})
+
+	req, _ := http.NewRequest("GET", "http://localhost/test", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Tenant", "acme")
+
+	m.Recover(context.Background(), req, func() error {
+		panic("test panic")
+	})
+
+	// ヘッダーは記録するが、Authorization の値は伏せる
+	logOutput := buf.String()
+	if strings.Contains(logOutput, "secret-token") {
+		t.Errorf("log contains the Authorization value: %s", logOutput)
+	}
+	if !strings.Contains(logOutput, "acme") {
+		t.Errorf("log does not contain headers: %s", logOutput)
+	}
+}
+
+func TestRecoveryMiddleware_Recover_AbortHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
+	m := NewRecoveryMiddleware(logger, RecoveryConfig{})
+	req, _ := http.NewRequest("GET", "http://localhost/test", nil)
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	m.Recover(context.Background(), req, func() error {
+		panic(http.ErrAbortHandler)
+	})
+	t.Error("Recover() should re-panic http.ErrAbortHandler")
+}
+
 func TestRecoveryMiddleware_Recover_NextReturnsError(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(bytes.NewBuffer(nil), nil))
 	m := NewRecoveryMiddleware(logger, RecoveryConfig{})
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TimeoutMiddleware はルートのリクエスト全体（ミドルウェアチェーンとバックエンドへの転送）の処理時間を制限する
+// バックエンドの timeout は転送のみを対象とするため、認証などのミドルウェアの待ち時間も含めて制限する場合に使う
+// 期限はチェーンの実行前に Gateway が context.WithTimeout で設定するため、Process では何もしない
+type TimeoutMiddleware struct {
+	timeout time.Duration
+}
+
+// NewTimeoutMiddleware は新しいタイムアウトミドルウェアを作成する
+func NewTimeoutMiddleware(timeout time.Duration) *TimeoutMiddleware {
+	return &TimeoutMiddleware{timeout: timeout}
+}
+
+// Timeout はリクエスト全体の処理時間の上限を返す
+func (m *TimeoutMiddleware) Timeout() time.Duration {
+	return m.timeout
+}
+
+// Process はミドルウェアの処理を実行する
+func (m *TimeoutMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	return ctx, nil
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+)
+
+func TestFactory_CreateTimeoutMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "duration形式", config: map[string]any{"timeout": "1500ms"}, want: 1500 * time.Millisecond},
+		{name: "秒数", config: map[string]any{"timeout": 5}, want: 5 * time.Second},
+		{name: "未指定", config: map[string]any{}, wantErr: true},
+		{name: "不正な値", config: map[string]any{"timeout": "soon"}, wantErr: true},
+		{name: "0", config: map[string]any{"timeout": "0s"}, wantErr: true},
+	}
+
+	factory := NewFactory(FactoryConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := factory.Create(config.MiddlewareConfig{Type: "timeout", Config: tt.config})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := m.(*TimeoutMiddleware).Timeout(); got != tt.want {
+				t.Errorf("Timeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
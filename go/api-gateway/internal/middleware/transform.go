@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+)
+
+// DefaultTransformMaxBodySize は変換のために読み込むリクエストボディの上限のデフォルト値
+const DefaultTransformMaxBodySize = 1 << 20
+
+// templatePattern は set の値に埋め込むテンプレート（{{claims.sub}}, {{header.X-Tenant}}, {{query.page}}）
+var templatePattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// TransformConfig はリクエストボディの変換の設定
+// フィールドはドット区切りのパス（"customer.address.zip" など）で指定し、rename → remove → set の順に適用する
+type TransformConfig struct {
+	// Rename はフィールド名の変更（変更前のパス → 変更後のパス）
+	Rename map[string]string
+	// Remove は削除するフィールド
+	Remove []string
+	// Set は設定するフィールドと値。文字列の値にはテンプレートを埋め込める
+	// 値がテンプレートのみの場合はクレームの型（数値・配列など）のまま設定し、値が無ければクライアントが送った値も削除する
+	Set map[string]any
+	// MaxBodySize は変換のために読み込むボディの上限（0は DefaultTransformMaxBodySize）
+	MaxBodySize int64
+}
+
+// TransformMiddleware はJSONのリクエストボディをルートの設定に従って書き換える
+// コードを変更できない既存のバックエンドに合わせてフィールド名を変えたり、JWTのクレームを埋め込んだりするために使う
+// JSON以外のリクエスト（Content-Typeが application/json または +json でないもの）はそのまま転送する
+type TransformMiddleware struct {
+	rename      []renameRule
+	remove      [][]string
+	set         []setRule
+	maxBodySize int64
+}
+
+// renameRule はフィールド名の変更
+type renameRule struct {
+	from []string
+	to   []string
+}
+
+// setRule は設定するフィールドと値
+type setRule struct {
+	path []string
+	// value はテンプレートを含まない値
+	value any
+	// template は値の文字列（テンプレートを含む場合のみ）
+	template string
+}
+
+// NewTransformMiddleware は新しいリクエストボディ変換ミドルウェアを作成する
+func NewTransformMiddleware(cfg TransformConfig) (*TransformMiddleware, error) {
+	if len(cfg.Rename) == 0 && len(cfg.Remove) == 0 && len(cfg.Set) == 0 {
+		return nil, fmt.Errorf("transform middleware requires rename, remove or set")
+	}
+	if cfg.MaxBodySize < 0 {
+		return nil, fmt.Errorf("transform max_body_size must be non-negative")
+	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = DefaultTransformMaxBodySize
+	}
+
+	m := &TransformMiddleware{maxBodySize: cfg.MaxBodySize}
+
+	// 設定の順序によって結果が変わらないよう、パスの順に適用する
+	for _, from := range slices.Sorted(maps.Keys(cfg.Rename)) {
+		fromPath, err := parseFieldPath(from)
+		if err != nil {
+			return nil, err
+		}
+		toPath, err := parseFieldPath(cfg.Rename[from])
+		if err != nil {
+			return nil, err
+		}
+		m.rename = append(m.rename, renameRule{from: fromPath, to: toPath})
+	}
+	for _, field := range cfg.Remove {
+		path, err := parseFieldPath(field)
+		if err != nil {
+			return nil, err
+		}
+		m.remove = append(m.remove, path)
+	}
+	for _, field := range slices.Sorted(maps.Keys(cfg.Set)) {
+		path, err := parseFieldPath(field)
+		if err != nil {
+			return nil, err
+		}
+		rule := setRule{path: path, value: cfg.Set[field]}
+		if s, ok := rule.value.(string); ok && templatePattern.MatchString(s) {
+			for _, match := range templatePattern.FindAllStringSubmatch(s, -1) {
+				if _, _, err := parseTemplateRef(match[1]); err != nil {
+					return nil, fmt.Errorf("invalid template for %s: %w", field, err)
+				}
+			}
+			rule.value, rule.template = nil, s
+		}
+		m.set = append(m.set, rule)
+	}
+	return m, nil
+}
+
+// Process はミドルウェアの処理を実行する
+func (m *TransformMiddleware) Process(ctx context.Context, req *http.Request) (context.Context, error) {
+	if req.Body == nil || req.Body == http.NoBody || !isJSONContentType(req.Header.Get("Content-Type")) {
+		return ctx, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, m.maxBodySize+1))
+	req.Body.Close()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			return ctx, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit))
+		}
+		return ctx, errors.NewBadRequestError("failed to read request body")
+	}
+	if int64(len(data)) > m.maxBodySize {
+		return ctx, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds transform limit of %d bytes", m.maxBodySize))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var body map[string]any
+	if err := decoder.Decode(&body); err != nil {
+		return ctx, errors.NewBadRequestError("request body must be a JSON object")
+	}
+
+	m.apply(ctx, req, body)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(body); err != nil {
+		return ctx, errors.NewInternalServerError(fmt.Sprintf("failed to encode transformed body: %v", err))
+	}
+	transformed := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+
+	req.Body = io.NopCloser(bytes.NewReader(transformed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(transformed)), nil
+	}
+	req.ContentLength = int64(len(transformed))
+	req.Header.Set("Content-Length", strconv.Itoa(len(transformed)))
+	return ctx, nil
+}
+
+// apply はボディに rename → remove → set の順に変換を適用する
+func (m *TransformMiddleware) apply(ctx context.Context, req *http.Request, body map[string]any) {
+	for _, rule := range m.rename {
+		if value, ok := deleteField(body, rule.from); ok {
+			setField(body, rule.to, value)
+		}
+	}
+	for _, path := range m.remove {
+		deleteField(body, path)
+	}
+
+	claims, _ := requestctx.Claims(ctx)
+	for _, rule := range m.set {
+		if rule.template == "" {
+			setField(body, rule.path, rule.value)
+			continue
+		}
+		value, ok := renderTemplate(rule.template, claims, req)
+		if !ok {
+			// ゲートウェイが埋め込むフィールドをクライアントが偽装できないよう、参照先が無い場合は送られた値も削除する
+			deleteField(body, rule.path)
+			continue
+		}
+		setField(body, rule.path, value)
+	}
+}
+
+// renderTemplate はテンプレートを展開する
+// 値がテンプレートのみの場合は参照先の値をそのまま返し、参照先が無い場合は false を返す
+func renderTemplate(tmpl string, claims map[string]any, req *http.Request) (any, bool) {
+	if match := templatePattern.FindStringSubmatch(tmpl); match != nil && match[0] == tmpl {
+		return lookupTemplateRef(match[1], claims, req)
+	}
+	return templatePattern.ReplaceAllStringFunc(tmpl, func(s string) string {
+		value, ok := lookupTemplateRef(templatePattern.FindStringSubmatch(s)[1], claims, req)
+		if !ok {
+			return ""
+		}
+		if str, ok := value.(string); ok {
+			return str
+		}
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}), true
+}
+
+// lookupTemplateRef はテンプレートが参照する値を返す
+func lookupTemplateRef(ref string, claims map[string]any, req *http.Request) (any, bool) {
+	source, name, _ := parseTemplateRef(ref)
+	switch source {
+	case "claims":
+		value, ok := claims[name]
+		return value, ok && value != nil
+	case "header":
+		value := req.Header.Get(name)
+		return value, value != ""
+	case "query":
+		values, ok := req.URL.Query()[name]
+		if !ok || len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	}
+	return nil, false
+}
+
+// parseTemplateRef は "claims.sub" のようなテンプレートの参照先を分解する
+func parseTemplateRef(ref string) (source, name string, err error) {
+	source, name, ok := strings.Cut(ref, ".")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("template %q must be claims.<name>, header.<name> or query.<name>", ref)
+	}
+	switch source {
+	case "claims", "header", "query":
+		return source, name, nil
+	}
+	return "", "", fmt.Errorf("unknown template source %q", source)
+}
+
+// parseFieldPath はドット区切りのフィールドのパスを分解する
+func parseFieldPath(field string) ([]string, error) {
+	path := strings.Split(field, ".")
+	if slices.Contains(path, "") {
+		return nil, fmt.Errorf("invalid field path: %q", field)
+	}
+	return path, nil
+}
+
+// deleteField はパスのフィールドを削除し、削除した値を返す
+func deleteField(obj map[string]any, path []string) (any, bool) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		obj = next
+	}
+	value, ok := obj[path[len(path)-1]]
+	if ok {
+		delete(obj, path[len(path)-1])
+	}
+	return value, ok
+}
+
+// setField はパスのフィールドに値を設定する。途中のオブジェクトが無い場合は作成する
+func setField(obj map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			obj[key] = next
+		}
+		obj = next
+	}
+	obj[path[len(path)-1]] = value
+}
+
+// isJSONContentType はContent-TypeがJSONかを判定する
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/errors"
+	"api-gateway/internal/requestctx"
+)
+
+func TestTransformMiddleware_Process(t *testing.T) {
+	claims := jwt.MapClaims{"sub": "user-1", "tenant": "acme", "roles": []any{"admin"}, "level": float64(3)}
+
+	tests := []struct {
+		name        string
+		config      map[string]any
+		contentType string
+		body        string
+		want        map[string]any
+		wantBody    string
+		wantStatus  int
+	}{
+		{
+			name: "フィールド名の変更と削除",
+			config: map[string]any{
+				"rename": map[string]any{"user_name": "name", "address.zip": "address.postal_code"},
+				"remove": []any{"internal"},
+			},
+			body: `{"user_name":"alice","address":{"zip":"100-0001"},"internal":true}`,
+			want: map[string]any{"name": "alice", "address": map[string]any{"postal_code": "100-0001"}},
+		},
+		{
+			name: "クレームとヘッダーの埋め込み",
+			config: map[string]any{"set": map[string]any{
+				"tenant_id":   "{{claims.tenant}}",
+				"meta.roles":  "{{ claims.roles }}",
+				"meta.level":  "{{claims.level}}",
+				"meta.label":  "{{claims.tenant}}/{{header.X-Client}}",
+				"meta.source": "gateway",
+				"meta.limit":  10,
+				"missing":     "{{claims.email}}",
+			}},
+			body: `{"amount":1200}`,
+			want: map[string]any{
+				"amount":    float64(1200),
+				"tenant_id": "acme",
+				"meta": map[string]any{
+					"roles": []any{"admin"}, "level": float64(3), "label": "acme/web", "source": "gateway", "limit": float64(10),
+				},
+			},
+		},
+		{
+			name:   "参照先が無い場合はクライアントが送った値を転送しない",
+			config: map[string]any{"set": map[string]any{"user_email": "{{claims.email}}", "meta.tenant": "{{header.X-Tenant}}"}},
+			body:   `{"amount":1200,"user_email":"admin@example.com","meta":{"tenant":"other"}}`,
+			want:   map[string]any{"amount": float64(1200), "meta": map[string]any{}},
+		},
+		{
+			name:     "数値の精度を保つ",
+			config:   map[string]any{"remove": []any{"x"}},
+			body:     `{"id":12345678901234567890,"html":"<b>"}`,
+			wantBody: `{"html":"<b>","id":12345678901234567890}`,
+		},
+		{
+			name:        "JSON以外はそのまま",
+			config:      map[string]any{"remove": []any{"internal"}},
+			contentType: "text/plain",
+			body:        `{"internal":true}`,
+			wantBody:    `{"internal":true}`,
+		},
+		{
+			name:       "JSONオブジェクト以外は400",
+			config:     map[string]any{"remove": []any{"internal"}},
+			body:       `[1,2]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "上限を超えるボディは413",
+			config:     map[string]any{"remove": []any{"internal"}, "max_body_size": 8},
+			body:       `{"internal":true}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	factory := NewFactory(FactoryConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := factory.Create(config.MiddlewareConfig{Type: "transform", Config: tt.config})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req.Header.Set("X-Client", "web")
+			ctx := requestctx.WithClaims(req.Context(), claims)
+
+			_, err = m.Process(ctx, req)
+			if tt.wantStatus != 0 {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != tt.wantStatus {
+					t.Fatalf("Process() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			body, _ := io.ReadAll(req.Body)
+			if tt.wantBody != "" {
+				if string(body) != tt.wantBody {
+					t.Errorf("body = %s, want %s", body, tt.wantBody)
+				}
+				return
+			}
+			var got map[string]any
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("invalid body %s: %v", body, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+			if req.ContentLength != int64(len(body)) || req.Header.Get("Content-Length") != strconv.Itoa(len(body)) {
+				t.Errorf("content length = %d (%s), want %d", req.ContentLength, req.Header.Get("Content-Length"), len(body))
+			}
+		})
+	}
+}
+
+func TestFactory_CreateTransformMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]any
+	}{
+		{name: "変換が無い", config: map[string]any{}},
+		{name: "不正なパス", config: map[string]any{"remove": []any{"a..b"}}},
+		{name: "不明なテンプレート", config: map[string]any{"set": map[string]any{"a": "{{env.HOME}}"}}},
+		{name: "renameの値が文字列でない", config: map[string]any{"rename": map[string]any{"a": 1}}},
+		{name: "負の上限", config: map[string]any{"remove": []any{"a"}, "max_body_size": -1}},
+	}
+
+	factory := NewFactory(FactoryConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := factory.Create(config.MiddlewareConfig{Type: "transform", Config: tt.config}); err == nil {
+				t.Error("Create() should fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,508 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/yaml.v3"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+const (
+	// defaultCacheTTL は集約済みドキュメントを再利用する期間
+	defaultCacheTTL = time.Minute
+	// defaultRetryInterval は取得に失敗したグループがある場合に再取得するまでの期間（cacheTTL より長い場合は cacheTTL）
+	defaultRetryInterval = 5 * time.Second
+	// fetchTimeout は全グループのドキュメントを取得するタイムアウト
+	fetchTimeout = 10 * time.Second
+)
+
+// httpMethods はOpenAPIのPath Item上でオペレーションを表すキー
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// RouteSource は集約対象のルート一覧を提供する
+type RouteSource interface {
+	GetAllRoutes() []*routing.Route
+}
+
+// Aggregator はバックエンドのOpenAPIドキュメントを取得し、
+// Gatewayの外部向けパスに書き換えた1つのドキュメントに統合する
+type Aggregator struct {
+	cfg      config.OpenAPIConfig
+	routes   RouteSource
+	client   *http.Client
+	logger   *slog.Logger
+	cacheTTL time.Duration
+	// retryInterval は取得に失敗したグループがある場合に再取得するまでの期間
+	retryInterval time.Duration
+
+	// refresh は同時に期限切れを検出したリクエストの取得を1回にまとめる
+	refresh singleflight.Group
+
+	// mu は cached 以下を保護する（取得中は保持しない）
+	mu        sync.Mutex
+	cached    map[string]any
+	complete  bool // cached が全グループを含むか
+	expiresAt time.Time
+}
+
+// NewAggregator は新しいAggregatorを作成する
+func NewAggregator(cfg config.OpenAPIConfig, routes RouteSource, logger *slog.Logger) *Aggregator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	cacheTTL := cfg.CacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = defaultCacheTTL
+	}
+
+	return &Aggregator{
+		cfg:           cfg,
+		routes:        routes,
+		client:        &http.Client{Timeout: fetchTimeout},
+		logger:        logger,
+		cacheTTL:      cacheTTL,
+		retryInterval: min(defaultRetryInterval, cacheTTL),
+	}
+}
+
+// Document は統合済みのOpenAPIドキュメントを返す
+// 取得に失敗したグループは除外し、x-gateway-unavailable に名前を列挙する
+// 取得は呼び出し元から切り離して1回にまとめるため、遅いリクエストや切断されたリクエストが他のリクエストを待たせない
+// ctx が先に終了した場合は取得を待たずに前回のドキュメントを返す
+func (a *Aggregator) Document(ctx context.Context) map[string]any {
+	a.mu.Lock()
+	cached, fresh := a.cached, time.Now().Before(a.expiresAt)
+	a.mu.Unlock()
+	if cached != nil && fresh {
+		return cached
+	}
+
+	result := a.refresh.DoChan("document", func() (any, error) {
+		return a.update(), nil
+	})
+	select {
+	case r := <-result:
+		return r.Val.(map[string]any)
+	case <-ctx.Done():
+		if cached != nil {
+			return cached
+		}
+		// 取得済みのドキュメントが無い場合は、全グループを取得できなかったものとして返す
+		return a.merge(nil)
+	}
+}
+
+// update は全グループのドキュメントを取得し直して統合し、キャッシュを更新する
+// 取得に失敗したグループがある場合は、全グループを含む前回のドキュメントを残して retryInterval 後に再取得する
+func (a *Aggregator) update() map[string]any {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	specs := a.fetchAll(ctx)
+	doc := a.merge(specs)
+	complete := len(specs) == len(a.cfg.Groups)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if complete {
+		a.cached, a.complete = doc, true
+		a.expiresAt = now.Add(a.cacheTTL)
+		return doc
+	}
+
+	a.expiresAt = now.Add(a.retryInterval)
+	if a.cached != nil && a.complete {
+		return a.cached
+	}
+	a.cached = doc
+	return doc
+}
+
+// fetchAll は全グループのドキュメントを並行して取得する
+func (a *Aggregator) fetchAll(ctx context.Context) map[string]map[string]any {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		specs = make(map[string]map[string]any, len(a.cfg.Groups))
+	)
+
+	for _, group := range a.cfg.Groups {
+		wg.Add(1)
+		go func(group config.OpenAPIGroupConfig) {
+			defer wg.Done()
+
+			spec, err := a.fetch(ctx, group.SpecURL)
+			if err != nil {
+				a.logger.Warn("failed to fetch backend openapi document",
+					slog.String("group", group.Name),
+					slog.String("spec_url", group.SpecURL),
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+
+			mu.Lock()
+			specs[group.Name] = spec
+			mu.Unlock()
+		}(group)
+	}
+	wg.Wait()
+
+	return specs
+}
+
+// fetch はOpenAPIドキュメントを取得する（JSONはYAMLのサブセットとして読み込む）
+func (a *Aggregator) fetch(ctx context.Context, specURL string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch spec: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	spec, ok := normalize(raw).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("spec is not an object")
+	}
+	return spec, nil
+}
+
+// merge は取得済みドキュメントをルート定義に従って統合する
+func (a *Aggregator) merge(specs map[string]map[string]any) map[string]any {
+	paths := make(map[string]any)
+	components := make(map[string]any)
+	var unavailable []string
+
+	routesByGroup := make(map[string][]*routing.Route)
+	for _, route := range a.routes.GetAllRoutes() {
+		if route.Group != "" {
+			routesByGroup[route.Group] = append(routesByGroup[route.Group], route)
+		}
+	}
+
+	openapiVersion := "3.0.3"
+	for _, group := range a.cfg.Groups {
+		spec, ok := specs[group.Name]
+		if !ok {
+			unavailable = append(unavailable, group.Name)
+			continue
+		}
+		if v, ok := spec["openapi"].(string); ok {
+			openapiVersion = v
+		}
+
+		// コンポーネント名はグループ間で衝突しうるため、グループ名を接頭辞にする
+		spec = rewriteRefs(spec, group.Name).(map[string]any)
+		mergeComponents(components, spec, group.Name)
+
+		index := indexPaths(spec)
+		for _, route := range routesByGroup[group.Name] {
+			entry, ok := index[templateKey(backendPath(route))]
+			if !ok {
+				continue
+			}
+			paths[externalPath(route.Path)] = filterOperations(entry, route)
+		}
+	}
+
+	doc := map[string]any{
+		"openapi": openapiVersion,
+		"info": map[string]any{
+			"title":   a.cfg.Title,
+			"version": a.cfg.Version,
+		},
+		"paths": paths,
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+	if len(unavailable) > 0 {
+		doc["x-gateway-unavailable"] = unavailable
+	}
+	return doc
+}
+
+// pathEntry はバックエンドのパステンプレートとPath Itemの組
+type pathEntry struct {
+	template string
+	item     map[string]any
+}
+
+// indexPaths はバックエンドのフルパス（servers のベースパス込み）をキーにPath Itemを引けるようにする
+func indexPaths(spec map[string]any) map[string]pathEntry {
+	basePath := ""
+	if servers, ok := spec["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			if rawURL, ok := server["url"].(string); ok {
+				if u, err := url.Parse(rawURL); err == nil {
+					basePath = strings.TrimSuffix(u.Path, "/")
+				}
+			}
+		}
+	}
+
+	index := make(map[string]pathEntry)
+	paths, _ := spec["paths"].(map[string]any)
+	for p, item := range paths {
+		if m, ok := item.(map[string]any); ok {
+			index[templateKey(basePath+p)] = pathEntry{template: basePath + p, item: m}
+		}
+	}
+	return index
+}
+
+// backendPath はルートに対応するバックエンド側のパスを返す
+func backendPath(route *routing.Route) string {
+	prefix := ""
+	if route.Backend != nil && route.Backend.URL != nil {
+		prefix = strings.TrimSuffix(route.Backend.URL.Path, "/")
+	}
+	return prefix + route.Path
+}
+
+// templateKey はパラメータ名の違いを無視して比較できるよう、パスのパラメータ部分を共通化する
+func templateKey(path string) string {
+	segments := routing.SplitPath(path)
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || (strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")) {
+			segments[i] = "{}"
+		}
+	}
+	return routing.JoinPath(segments)
+}
+
+// externalPath はGatewayのルートパス（:id, {id:[0-9]+} 形式）をOpenAPIのパス（{id} 形式）に変換する
+func externalPath(path string) string {
+	segments := routing.SplitPath(path)
+	for i, s := range segments {
+		if name, ok := routing.ParamName(s); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return routing.JoinPath(segments)
+}
+
+// filterOperations はルートで許可されたメソッドのオペレーションだけを残し、
+// パスパラメータ名を外部ルートの名前に揃える
+func filterOperations(entry pathEntry, route *routing.Route) map[string]any {
+	renames := paramRenames(entry.template, route.Path)
+
+	filtered := make(map[string]any, len(entry.item))
+	for key, value := range entry.item {
+		if isOperation(key) && !route.HasMethod(strings.ToUpper(key)) {
+			continue
+		}
+		filtered[key] = renamePathParams(value, key, renames)
+	}
+	return filtered
+}
+
+// paramRenames はバックエンドのパスパラメータ名からルートのパラメータ名への対応を作る
+// 名前はサービスごとに異なりうるため、パス上の位置で対応付ける
+func paramRenames(backendTemplate, routePath string) map[string]string {
+	renames := make(map[string]string)
+	backendSegments := routing.SplitPath(backendTemplate)
+	routeSegments := routing.SplitPath(routePath)
+
+	// バックエンドのパスはベースパスの分だけ長いため、末尾から揃える
+	offset := len(backendSegments) - len(routeSegments)
+	if offset < 0 {
+		return renames
+	}
+
+	for i, s := range routeSegments {
+		name, ok := routing.ParamName(s)
+		if !ok {
+			continue
+		}
+		b := backendSegments[offset+i]
+		if backendName, ok := strings.CutPrefix(b, "{"); ok {
+			backendName = strings.TrimSuffix(backendName, "}")
+			if backendName != name {
+				renames[backendName] = name
+			}
+		}
+	}
+	return renames
+}
+
+// renamePathParams はパスパラメータ定義の name を書き換える
+func renamePathParams(value any, key string, renames map[string]string) any {
+	if len(renames) == 0 {
+		return value
+	}
+
+	switch {
+	case key == "parameters":
+		return renameParamList(value, renames)
+	case isOperation(key):
+		op, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		copied := make(map[string]any, len(op))
+		for k, v := range op {
+			copied[k] = v
+		}
+		copied["parameters"] = renameParamList(op["parameters"], renames)
+		if copied["parameters"] == nil {
+			delete(copied, "parameters")
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func renameParamList(value any, renames map[string]string) any {
+	list, ok := value.([]any)
+	if !ok {
+		return value
+	}
+
+	renamed := make([]any, 0, len(list))
+	for _, p := range list {
+		param, ok := p.(map[string]any)
+		if !ok || param["in"] != "path" {
+			renamed = append(renamed, p)
+			continue
+		}
+		name, _ := param["name"].(string)
+		newName, ok := renames[name]
+		if !ok {
+			renamed = append(renamed, p)
+			continue
+		}
+		copied := make(map[string]any, len(param))
+		for k, v := range param {
+			copied[k] = v
+		}
+		copied["name"] = newName
+		renamed = append(renamed, copied)
+	}
+	return renamed
+}
+
+func isOperation(key string) bool {
+	for _, m := range httpMethods {
+		if key == m {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeComponents はグループのコンポーネントを名前に接頭辞を付けて統合する
+func mergeComponents(dst map[string]any, spec map[string]any, group string) {
+	src, ok := spec["components"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	for kind, defs := range src {
+		defsMap, ok := defs.(map[string]any)
+		if !ok {
+			continue
+		}
+		target, ok := dst[kind].(map[string]any)
+		if !ok {
+			target = make(map[string]any)
+			dst[kind] = target
+		}
+		for name, def := range defsMap {
+			target[group+"."+name] = def
+		}
+	}
+}
+
+// rewriteRefs はローカル参照 (#/components/...) をグループ接頭辞付きの名前に書き換える
+func rewriteRefs(v any, group string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if ref, ok := val.(string); ok && k == "$ref" {
+				out[k] = prefixRef(ref, group)
+				continue
+			}
+			out[k] = rewriteRefs(val, group)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = rewriteRefs(val, group)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func prefixRef(ref, group string) string {
+	rest, ok := strings.CutPrefix(ref, "#/components/")
+	if !ok {
+		return ref
+	}
+	kind, name, ok := strings.Cut(rest, "/")
+	if !ok {
+		return ref
+	}
+	return "#/components/" + kind + "/" + group + "." + name
+}
+
+// normalize はYAMLデコード結果をJSONエンコード可能な形に揃える
+// （レスポンスコードなどの数値キーは map[any]any としてデコードされるため）
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			v[k] = normalize(val)
+		}
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[fmt.Sprint(k)] = normalize(val)
+		}
+		return out
+	case []any:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,307 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+const userServiceSpec = `
+openapi: 3.0.3
+info:
+  title: User Service
+  version: 1.0.0
+servers:
+  - url: http://user-service/internal
+paths:
+  /api/v1/users:
+    get:
+      operationId: listUsers
+      responses:
+        200:
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/User'
+    post:
+      operationId: createUser
+      responses:
+        '201':
+          description: Created
+    delete:
+      operationId: deleteAllUsers
+      responses:
+        '204':
+          description: Deleted
+  /api/v1/users/{userId}:
+    parameters:
+      - name: userId
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getUser
+      responses:
+        '200':
+          description: OK
+  /internal/metrics:
+    get:
+      responses:
+        '200':
+          description: OK
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        id:
+          type: string
+`
+
+// staticRoutes はテスト用のRouteSource
+type staticRoutes []*routing.Route
+
+func (r staticRoutes) GetAllRoutes() []*routing.Route {
+	return r
+}
+
+func newTestRoute(path string, methods []string, backendURL, group string) *routing.Route {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		panic(err)
+	}
+	return &routing.Route{
+		Path:    path,
+		Methods: methods,
+		Backend: &routing.Backend{URL: u},
+		Group:   group,
+	}
+}
+
+func TestAggregator_Document(t *testing.T) {
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(userServiceSpec))
+	}))
+	defer specServer.Close()
+
+	routes := staticRoutes{
+		newTestRoute("/api/v1/users", []string{"GET", "POST"}, "http://user-service/internal", "users"),
+		newTestRoute("/api/v1/users/:id", []string{"GET"}, "http://user-service/internal", "users"),
+		newTestRoute("/health", []string{"GET"}, "http://localhost:8080", ""),
+	}
+
+	aggregator := NewAggregator(config.OpenAPIConfig{
+		Title:   "Gateway",
+		Version: "2.0.0",
+		Groups: []config.OpenAPIGroupConfig{
+			{Name: "users", SpecURL: specServer.URL},
+		},
+	}, routes, nil)
+
+	doc := aggregator.Document(context.Background())
+
+	info := doc["info"].(map[string]any)
+	if info["title"] != "Gateway" || info["version"] != "2.0.0" {
+		t.Errorf("info = %v, want title=Gateway version=2.0.0", info)
+	}
+
+	paths := doc["paths"].(map[string]any)
+	if len(paths) != 2 {
+		t.Fatalf("paths = %v, want 2 entries", paths)
+	}
+
+	// ルートで許可されていないメソッドは除外される
+	users := paths["/api/v1/users"].(map[string]any)
+	if _, ok := users["delete"]; ok {
+		t.Error("DELETE operation should be filtered out")
+	}
+	if _, ok := users["get"]; !ok {
+		t.Error("GET operation should be kept")
+	}
+
+	// パスパラメータ名は外部ルートの名前に揃えられる
+	user, ok := paths["/api/v1/users/{id}"].(map[string]any)
+	if !ok {
+		t.Fatalf("path /api/v1/users/{id} not found in %v", paths)
+	}
+	params := user["parameters"].([]any)
+	if name := params[0].(map[string]any)["name"]; name != "id" {
+		t.Errorf("path parameter name = %v, want id", name)
+	}
+
+	// コンポーネント参照はグループ名付きに書き換えられる
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	if _, ok := schemas["users.User"]; !ok {
+		t.Errorf("component users.User not found in %v", schemas)
+	}
+	ref := users["get"].(map[string]any)["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)["items"].(map[string]any)["$ref"]
+	if ref != "#/components/schemas/users.User" {
+		t.Errorf("$ref = %v, want #/components/schemas/users.User", ref)
+	}
+}
+
+func TestAggregator_Document_UnavailableGroup(t *testing.T) {
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer specServer.Close()
+
+	aggregator := NewAggregator(config.OpenAPIConfig{
+		Groups: []config.OpenAPIGroupConfig{
+			{Name: "orders", SpecURL: specServer.URL},
+		},
+	}, staticRoutes{}, nil)
+
+	doc := aggregator.Document(context.Background())
+
+	unavailable, ok := doc["x-gateway-unavailable"].([]string)
+	if !ok || len(unavailable) != 1 || unavailable[0] != "orders" {
+		t.Errorf("x-gateway-unavailable = %v, want [orders]", doc["x-gateway-unavailable"])
+	}
+}
+
+func TestAggregator_Document_Cache(t *testing.T) {
+	var fetches atomic.Int32
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(userServiceSpec))
+	}))
+	defer specServer.Close()
+
+	aggregator := NewAggregator(config.OpenAPIConfig{
+		CacheTTL: time.Hour,
+		Groups: []config.OpenAPIGroupConfig{
+			{Name: "users", SpecURL: specServer.URL},
+		},
+	}, staticRoutes{}, nil)
+
+	aggregator.Document(context.Background())
+	aggregator.Document(context.Background())
+
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("spec fetched %d times, want 1", got)
+	}
+}
+
+func TestAggregator_Document_KeepsLastGoodDocument(t *testing.T) {
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(userServiceSpec))
+	}))
+	defer specServer.Close()
+
+	aggregator := NewAggregator(config.OpenAPIConfig{
+		CacheTTL: time.Hour,
+		Groups: []config.OpenAPIGroupConfig{
+			{Name: "users", SpecURL: specServer.URL},
+		},
+	}, staticRoutes{}, nil)
+
+	good := aggregator.Document(context.Background())
+	failing.Store(true)
+	aggregator.mu.Lock()
+	aggregator.expiresAt = time.Now()
+	aggregator.mu.Unlock()
+
+	// 取得に失敗したグループがある場合は前回のドキュメントを返し、cacheTTL より早く再取得する
+	doc := aggregator.Document(context.Background())
+	if _, ok := doc["x-gateway-unavailable"]; ok {
+		t.Errorf("x-gateway-unavailable = %v, want the last good document", doc["x-gateway-unavailable"])
+	}
+	if doc["components"] == nil || good["components"] == nil {
+		t.Error("last good document was not returned")
+	}
+	aggregator.mu.Lock()
+	retryIn := time.Until(aggregator.expiresAt)
+	aggregator.mu.Unlock()
+	if retryIn > defaultRetryInterval {
+		t.Errorf("retry in %v, want at most %v", retryIn, defaultRetryInterval)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("spec fetched %d times, want 2", got)
+	}
+}
+
+func TestAggregator_Document_CanceledCaller(t *testing.T) {
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.Write([]byte(userServiceSpec))
+	}))
+	defer specServer.Close()
+
+	aggregator := NewAggregator(config.OpenAPIConfig{
+		CacheTTL: time.Hour,
+		Groups: []config.OpenAPIGroupConfig{
+			{Name: "users", SpecURL: specServer.URL},
+		},
+	}, staticRoutes{}, nil)
+
+	// 切断されたリクエストは取得を待たずに返り、取得は続ける
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if doc := aggregator.Document(ctx); doc["x-gateway-unavailable"] == nil {
+		t.Errorf("canceled caller got %v, want an unavailable document", doc)
+	}
+
+	// 取得中の他のリクエストは同じ取得の結果を待つ
+	done := make(chan map[string]any)
+	go func() { done <- aggregator.Document(context.Background()) }()
+	close(release)
+	doc := <-done
+	if _, ok := doc["x-gateway-unavailable"]; ok {
+		t.Errorf("x-gateway-unavailable = %v, want none", doc["x-gateway-unavailable"])
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("spec fetched %d times, want 1", got)
+	}
+}
+
+func TestExternalPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/v1/users", want: "/api/v1/users"},
+		{path: "/api/v1/users/:id", want: "/api/v1/users/{id}"},
+		{path: "/orders/:orderId/items/:itemId", want: "/orders/{orderId}/items/{itemId}"},
+		{path: "/users/{id:[0-9]+}", want: "/users/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := externalPath(tt.path); got != tt.want {
+				t.Errorf("externalPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateKey(t *testing.T) {
+	if templateKey("/users/:id") != templateKey("/users/{userId}") {
+		t.Error("templateKey() should ignore parameter names")
+	}
+	if templateKey("/users/:id") == templateKey("/users/me") {
+		t.Error("templateKey() should distinguish parameters from static segments")
+	}
+}
@@ -0,0 +1,99 @@
+package portal
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// Prefix は開発者ポータルを公開するパス
+const Prefix = "/docs/"
+
+// specPath はポータルが読み込むOpenAPIドキュメントのパス（Prefixからの相対）
+const specPath = "openapi.json"
+
+//go:embed index.html
+var indexHTML string
+
+var indexTemplate = template.Must(template.New("index").Parse(indexHTML))
+
+// Handler は統合済みOpenAPIドキュメントをSwagger UIで表示する開発者ポータル
+// Swagger UIのアセットはバイナリに埋め込まれているため外部CDNに依存しない
+type Handler struct {
+	index  []byte
+	spec   http.Handler
+	assets http.Handler
+}
+
+// NewHandler は新しいHandlerを作成する
+// spec には /docs/openapi.json で返すOpenAPIドキュメントのハンドラを渡す
+func NewHandler(title string, spec http.Handler) (*Handler, error) {
+	if title == "" {
+		title = "API Documentation"
+	}
+
+	// タイトルは起動時に確定するのでindex.htmlは1度だけ描画しておく
+	var buf bytes.Buffer
+	if err := indexTemplate.Execute(&buf, struct {
+		Title   string
+		SpecURL string
+	}{
+		Title:   title,
+		SpecURL: specPath,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		index:  buf.Bytes(),
+		spec:   spec,
+		assets: http.StripPrefix(Prefix, http.FileServerFS(assetsFS())),
+	}, nil
+}
+
+// ServeHTTP はHTTPリクエストを処理する
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch name := strings.TrimPrefix(req.URL.Path, Prefix); name {
+	case "", "index.html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(h.index)
+	case specPath:
+		h.spec.ServeHTTP(w, req)
+	default:
+		h.assets.ServeHTTP(w, req)
+	}
+}
+
+// assetsFS は配信するSwagger UIのアセットを返す
+// 同梱のindex.htmlとサンプル用の初期化スクリプトは自前のindex.htmlと衝突するため除外する
+func assetsFS() fs.FS {
+	return filteredFS{fsys: swaggerFiles.FS, hidden: map[string]bool{
+		"index.html":             true,
+		"swagger-initializer.js": true,
+	}}
+}
+
+// filteredFS は指定したファイルを隠すfs.FS
+type filteredFS struct {
+	fsys   fs.FS
+	hidden map[string]bool
+}
+
+// Open はファイルを開く
+func (f filteredFS) Open(name string) (fs.File, error) {
+	if f.hidden[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.fsys.Open(name)
+}
@@ -0,0 +1,104 @@
+package portal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	spec := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"openapi":"3.0.3"}`))
+	})
+
+	h, err := NewHandler("Test Portal", spec)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "index",
+			method:          http.MethodGet,
+			path:            "/docs/",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<title>Test Portal</title>",
+		},
+		{
+			name:            "index references spec",
+			method:          http.MethodGet,
+			path:            "/docs/index.html",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        `url: "openapi.json"`,
+		},
+		{
+			name:            "openapi document",
+			method:          http.MethodGet,
+			path:            "/docs/openapi.json",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `"openapi":"3.0.3"`,
+		},
+		{
+			name:            "embedded asset",
+			method:          http.MethodGet,
+			path:            "/docs/swagger-ui-bundle.js",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/javascript; charset=utf-8",
+		},
+		{
+			name:       "sample initializer is hidden",
+			method:     http.MethodGet,
+			path:       "/docs/swagger-initializer.js",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			path:       "/docs/",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status code = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantContentType != "" {
+				if ct := rec.Header().Get("Content-Type"); ct != tt.wantContentType {
+					t.Errorf("Content-Type = %s, want %s", ct, tt.wantContentType)
+				}
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body does not contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewHandler_DefaultTitle(t *testing.T) {
+	h, err := NewHandler("", http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+
+	if !strings.Contains(string(h.index), "<title>API Documentation</title>") {
+		t.Error("default title should be used when title is empty")
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	redisclient "api-gateway/pkg/redis"
+)
+
+// ErrNotFound はセッションまたはログインの state が存在しない（期限切れを含む）ことを表す
+var ErrNotFound = stderrors.New("not found")
+
+// OIDCSession はゲートウェイがOIDCのコードフローで確立したブラウザのセッション
+type OIDCSession struct {
+	// Claims はIDトークンのクレーム
+	Claims map[string]any `json:"claims"`
+	// ExpiresAt はセッションの有効期限
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OIDCLoginState はIdPへリダイレクトしてからコールバックまでの間に保持するログインの状態
+type OIDCLoginState struct {
+	// Nonce はIDトークンの nonce クレームと照合する値
+	Nonce string `json:"nonce"`
+	// CodeVerifier はPKCEのcode_verifier
+	CodeVerifier string `json:"code_verifier"`
+	// ReturnTo はログイン後に戻すパス（クエリを含む）
+	ReturnTo string `json:"return_to"`
+}
+
+// OIDCSessionRepository はOIDCのセッションとログインの state を管理するリポジトリインターフェース
+type OIDCSessionRepository interface {
+	// SaveSession はセッションを保存する
+	SaveSession(ctx context.Context, sessionID string, session OIDCSession, expiration time.Duration) error
+
+	// GetSession はセッションを取得する（存在しない場合は ErrNotFound）
+	GetSession(ctx context.Context, sessionID string) (OIDCSession, error)
+
+	// DeleteSession はセッションを削除する
+	DeleteSession(ctx context.Context, sessionID string) error
+
+	// SaveLoginState はログインの state を保存する
+	SaveLoginState(ctx context.Context, state string, login OIDCLoginState, expiration time.Duration) error
+
+	// TakeLoginState はログインの state を取得して削除する（存在しない場合は ErrNotFound）
+	// コールバックを再送されても同じ state を2回使えないようにする
+	TakeLoginState(ctx context.Context, state string) (OIDCLoginState, error)
+}
+
+// RedisOIDCSessionRepository はRedisを使用したOIDCセッションリポジトリの実装
+type RedisOIDCSessionRepository struct {
+	client    *redisclient.Client
+	keyPrefix string
+}
+
+// NewRedisOIDCSessionRepository は新しいRedisOIDCSessionRepositoryを作成する
+func NewRedisOIDCSessionRepository(client *redisclient.Client, keyPrefix string) *RedisOIDCSessionRepository {
+	if keyPrefix == "" {
+		keyPrefix = "oidc:" // デフォルトプレフィックス
+	}
+	return &RedisOIDCSessionRepository{
+		client: client,
+		// 全てのキーをクライアントの名前空間の中に作る
+		keyPrefix: client.Key(keyPrefix),
+	}
+}
+
+// SaveSession はセッションを保存する
+func (r *RedisOIDCSessionRepository) SaveSession(ctx context.Context, sessionID string, session OIDCSession, expiration time.Duration) error {
+	if err := r.set(ctx, r.sessionKey(sessionID), session, expiration); err != nil {
+		return fmt.Errorf("failed to save oidc session: %w", err)
+	}
+	return nil
+}
+
+// GetSession はセッションを取得する
+func (r *RedisOIDCSessionRepository) GetSession(ctx context.Context, sessionID string) (OIDCSession, error) {
+	var session OIDCSession
+	if err := r.get(ctx, r.sessionKey(sessionID), &session); err != nil {
+		return OIDCSession{}, fmt.Errorf("failed to get oidc session: %w", err)
+	}
+	return session, nil
+}
+
+// DeleteSession はセッションを削除する
+func (r *RedisOIDCSessionRepository) DeleteSession(ctx context.Context, sessionID string) error {
+	if err := r.client.Delete(ctx, r.sessionKey(sessionID)); err != nil {
+		return fmt.Errorf("failed to delete oidc session: %w", err)
+	}
+	return nil
+}
+
+// SaveLoginState はログインの state を保存する
+func (r *RedisOIDCSessionRepository) SaveLoginState(ctx context.Context, state string, login OIDCLoginState, expiration time.Duration) error {
+	if err := r.set(ctx, r.stateKey(state), login, expiration); err != nil {
+		return fmt.Errorf("failed to save oidc login state: %w", err)
+	}
+	return nil
+}
+
+// TakeLoginState はログインの state を取得して削除する
+func (r *RedisOIDCSessionRepository) TakeLoginState(ctx context.Context, state string) (OIDCLoginState, error) {
+	key := r.stateKey(state)
+	var login OIDCLoginState
+	if err := r.get(ctx, key, &login); err != nil {
+		return OIDCLoginState{}, fmt.Errorf("failed to get oidc login state: %w", err)
+	}
+	if err := r.client.Delete(ctx, key); err != nil {
+		return OIDCLoginState{}, fmt.Errorf("failed to delete oidc login state: %w", err)
+	}
+	return login, nil
+}
+
+// set は値をJSONで保存する
+func (r *RedisOIDCSessionRepository) set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, string(data), expiration)
+}
+
+// get はJSONで保存した値を読み込む（キーが存在しない場合は ErrNotFound）
+func (r *RedisOIDCSessionRepository) get(ctx context.Context, key string, value any) error {
+	data, err := r.client.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if data == "" {
+		return ErrNotFound
+	}
+	return json.Unmarshal([]byte(data), value)
+}
+
+// sessionKey はセッションIDからRedisキーを生成する
+// Redisを読める人がそのままCookieとして使えないよう、セッションIDはハッシュにして保存する
+func (r *RedisOIDCSessionRepository) sessionKey(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return fmt.Sprintf("%ssession:%s", r.keyPrefix, hex.EncodeToString(sum[:]))
+}
+
+// stateKey はログインの state からRedisキーを生成する
+func (r *RedisOIDCSessionRepository) stateKey(state string) string {
+	return fmt.Sprintf("%sstate:%s", r.keyPrefix, state)
+}
@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"api-gateway/internal/repository"
+	redisclient "api-gateway/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestOIDCSessionRepository(t *testing.T) (*repository.RedisOIDCSessionRepository, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client, err := redisclient.NewClient(redisclient.Config{Host: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return repository.NewRedisOIDCSessionRepository(client, ""), mr
+}
+
+func TestRedisOIDCSessionRepository_Session(t *testing.T) {
+	repo, mr := newTestOIDCSessionRepository(t)
+	ctx := context.Background()
+
+	session := repository.OIDCSession{
+		Claims:    map[string]any{"sub": "user123", "email": "user@example.com"},
+		ExpiresAt: time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC),
+	}
+	if err := repo.SaveSession(ctx, "session-id", session, time.Hour); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	// セッションIDはハッシュにして保存する
+	if mr.Exists("oidc:session:session-id") {
+		t.Error("session id should not be stored as is")
+	}
+
+	got, err := repo.GetSession(ctx, "session-id")
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	if got.Claims["sub"] != "user123" || !got.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Errorf("GetSession() = %+v, want %+v", got, session)
+	}
+
+	if err := repo.DeleteSession(ctx, "session-id"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if _, err := repo.GetSession(ctx, "session-id"); !stderrors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetSession() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRedisOIDCSessionRepository_TakeLoginState(t *testing.T) {
+	repo, mr := newTestOIDCSessionRepository(t)
+	ctx := context.Background()
+
+	login := repository.OIDCLoginState{Nonce: "nonce", CodeVerifier: "verifier", ReturnTo: "/app?tab=1"}
+	if err := repo.SaveLoginState(ctx, "state", login, 10*time.Minute); err != nil {
+		t.Fatalf("SaveLoginState() error = %v", err)
+	}
+	if ttl := mr.TTL("oidc:state:state"); ttl != 10*time.Minute {
+		t.Errorf("TTL = %v, want 10m", ttl)
+	}
+
+	got, err := repo.TakeLoginState(ctx, "state")
+	if err != nil {
+		t.Fatalf("TakeLoginState() error = %v", err)
+	}
+	if got != login {
+		t.Errorf("TakeLoginState() = %+v, want %+v", got, login)
+	}
+
+	// 同じ state は2回使えない
+	if _, err := repo.TakeLoginState(ctx, "state"); !stderrors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second TakeLoginState() error = %v, want ErrNotFound", err)
+	}
+}
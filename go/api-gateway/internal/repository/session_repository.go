@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	redisclient "api-gateway/pkg/redis"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // SessionRepository はセッション管理のリポジトリインターフェース
@@ -17,11 +20,54 @@ type SessionRepository interface {
 	// 失効時刻が設定されていない場合はゼロ値を返す
 	GetRevokedTime(ctx context.Context, userID string) (time.Time, error)
 
+	// SetRevokedTimes は複数のユーザーのJWT失効時刻をまとめて設定する
+	// userIDs と同じ順序でユーザーごとの結果（成功した場合はnil）を返す
+	SetRevokedTimes(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error
+
+	// GetRevocation はユーザーのJWT失効時刻と保持する残り時間を取得する
+	GetRevocation(ctx context.Context, userID string) (Revocation, error)
+
 	// DeleteRevokedTime はユーザーのJWT失効時刻を削除する
 	DeleteRevokedTime(ctx context.Context, userID string) error
 }
 
+// Revocation はユーザーの失効時刻の状態
+type Revocation struct {
+	// RevokedAt は失効時刻（失効していない場合はゼロ値）
+	RevokedAt time.Time
+	// TTL は失効時刻を保持する残り時間（失効していない場合、または有効期限がない場合は0）
+	TTL time.Duration
+}
+
+// RevocationEvent は失効時刻の変更を全てのゲートウェイへ通知するイベント
+type RevocationEvent struct {
+	// UserID は失効時刻を変更したユーザー
+	UserID string `json:"user_id"`
+	// RevokedAt は設定した失効時刻（失効時刻を削除した場合はゼロ値）
+	RevokedAt time.Time `json:"revoked_at,omitzero"`
+}
+
+// RevocationListener は失効時刻の変更の通知を受け取る関数
+type RevocationListener struct {
+	// OnRevocation は受信したイベントごとに呼び出す
+	OnRevocation func(event RevocationEvent)
+	// OnResync は購読を開始した時（再接続を含む）に呼び出す（任意）
+	// 切断中のイベントは受信できないため、ローカルキャッシュを全て破棄するなどに使う
+	OnResync func()
+	// OnError は受信に失敗した時、または不正なイベントを受信した時に呼び出す（任意）
+	OnError func(err error)
+}
+
+// RevocationSubscriber は失効時刻の変更の通知を購読するインターフェース
+// ゲートウェイは通知を受け取ったユーザーの失効時刻をローカルキャッシュから破棄する
+type RevocationSubscriber interface {
+	// SubscribeRevocations は失効時刻の変更を listener へ通知する
+	// ctx がキャンセルされるまでブロックし、接続が切れた場合は再接続する
+	SubscribeRevocations(ctx context.Context, listener RevocationListener)
+}
+
 // RedisSessionRepository はRedisを使用したセッションリポジトリの実装
+// 失効時刻を変更するとRedisのpub/sub（キープレフィックス + "revocations" チャンネル）で RevocationEvent を通知する
 type RedisSessionRepository struct {
 	client    *redisclient.Client
 	keyPrefix string
@@ -33,8 +79,9 @@ func NewRedisSessionRepository(client *redisclient.Client, keyPrefix string) *Re
 		keyPrefix = "revoke:" // デフォルトプレフィックス
 	}
 	return &RedisSessionRepository{
-		client:    client,
-		keyPrefix: keyPrefix,
+		client: client,
+		// 全てのキー（とチャンネル名）をクライアントの名前空間の中に作る
+		keyPrefix: client.Key(keyPrefix),
 	}
 }
 
@@ -52,7 +99,53 @@ func (r *RedisSessionRepository) SetRevokedTime(ctx context.Context, userID stri
 		return fmt.Errorf("failed to set revoked time for user %s: %w", userID, err)
 	}
 
-	return nil
+	return r.publish(ctx, RevocationEvent{UserID: userID, RevokedAt: revokedTime})
+}
+
+// SetRevokedTimes は複数のユーザーのJWT失効時刻を1回のパイプラインで設定し、失効を通知する
+func (r *RedisSessionRepository) SetRevokedTimes(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error {
+	results := make([]error, len(userIDs))
+	// 既に有効期限が切れている場合は保存しない
+	if expiration <= 0 {
+		return results
+	}
+
+	value := revokedTime.Format(time.RFC3339)
+	messages := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		data, err := json.Marshal(RevocationEvent{UserID: userID, RevokedAt: revokedTime})
+		if err != nil {
+			results[i] = fmt.Errorf("failed to marshal revocation event: %w", err)
+			continue
+		}
+		messages[i] = string(data)
+	}
+
+	// ユーザーごとに SET と PUBLISH を積む（cmds はユーザーごとに2つずつ並ぶ）
+	cmds := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) {
+		for i, userID := range userIDs {
+			if results[i] != nil {
+				continue
+			}
+			pipe.Set(ctx, r.makeKey(userID), value, expiration)
+			pipe.Publish(ctx, r.channel(), messages[i])
+		}
+	})
+
+	for i, userID := range userIDs {
+		if results[i] != nil {
+			continue
+		}
+		set, publish := cmds[0], cmds[1]
+		cmds = cmds[2:]
+		switch {
+		case set.Err() != nil:
+			results[i] = fmt.Errorf("failed to set revoked time for user %s: %w", userID, set.Err())
+		case publish.Err() != nil:
+			results[i] = fmt.Errorf("failed to publish revocation for user %s: %w", userID, publish.Err())
+		}
+	}
+	return results
 }
 
 // GetRevokedTime はユーザーのJWT失効時刻を取得する
@@ -77,6 +170,37 @@ func (r *RedisSessionRepository) GetRevokedTime(ctx context.Context, userID stri
 	return revokedTime, nil
 }
 
+// GetRevocation はユーザーのJWT失効時刻と保持する残り時間を1回のパイプラインで取得する
+func (r *RedisSessionRepository) GetRevocation(ctx context.Context, userID string) (Revocation, error) {
+	key := r.makeKey(userID)
+
+	var get *redis.StringCmd
+	var ttl *redis.DurationCmd
+	r.client.Pipelined(ctx, func(pipe redis.Pipeliner) {
+		get = pipe.Get(ctx, key)
+		ttl = pipe.TTL(ctx, key)
+	})
+
+	value, err := get.Result()
+	if err == redis.Nil {
+		return Revocation{}, nil
+	}
+	if err != nil {
+		return Revocation{}, fmt.Errorf("failed to get revoked time for user %s: %w", userID, err)
+	}
+	if ttl.Err() != nil {
+		return Revocation{}, fmt.Errorf("failed to get ttl of revoked time for user %s: %w", userID, ttl.Err())
+	}
+
+	revokedTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return Revocation{}, fmt.Errorf("failed to parse revoked time for user %s: %w", userID, err)
+	}
+
+	// 有効期限がない場合（-1）とGETの後に期限切れになった場合（-2）は負の値になる
+	return Revocation{RevokedAt: revokedTime, TTL: max(ttl.Val(), 0)}, nil
+}
+
 // DeleteRevokedTime はユーザーのJWT失効時刻を削除する
 func (r *RedisSessionRepository) DeleteRevokedTime(ctx context.Context, userID string) error {
 	key := r.makeKey(userID)
@@ -85,9 +209,46 @@ func (r *RedisSessionRepository) DeleteRevokedTime(ctx context.Context, userID s
 		return fmt.Errorf("failed to delete revoked time for user %s: %w", userID, err)
 	}
 
+	return r.publish(ctx, RevocationEvent{UserID: userID})
+}
+
+// SubscribeRevocations は失効時刻の変更を listener へ通知する
+func (r *RedisSessionRepository) SubscribeRevocations(ctx context.Context, listener RevocationListener) {
+	r.client.Subscribe(ctx, r.channel(), redisclient.SubscribeHandlers{
+		OnMessage: func(message string) {
+			var event RevocationEvent
+			if err := json.Unmarshal([]byte(message), &event); err != nil || event.UserID == "" {
+				if listener.OnError != nil {
+					listener.OnError(fmt.Errorf("invalid revocation event: %q", message))
+				}
+				return
+			}
+			listener.OnRevocation(event)
+		},
+		OnSubscribe: listener.OnResync,
+		OnError:     listener.OnError,
+	})
+}
+
+// publish は失効時刻の変更を通知する
+// 失効時刻は保存済みのため、通知に失敗してもゲートウェイのキャッシュはTTLで期限切れになる
+// 呼び出し側が再試行できるようエラーは返す（失効時刻の保存は冪等）
+func (r *RedisSessionRepository) publish(ctx context.Context, event RevocationEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation event: %w", err)
+	}
+	if err := r.client.Publish(ctx, r.channel(), string(data)); err != nil {
+		return fmt.Errorf("failed to publish revocation for user %s: %w", event.UserID, err)
+	}
 	return nil
 }
 
+// channel は失効時刻の変更を通知するチャンネル名を返す
+func (r *RedisSessionRepository) channel() string {
+	return r.keyPrefix + "revocations"
+}
+
 // makeKey はユーザーIDからRedisキーを生成する
 func (r *RedisSessionRepository) makeKey(userID string) string {
 	return fmt.Sprintf("%s%s", r.keyPrefix, userID)
@@ -156,6 +156,72 @@ func TestRedisSessionRepository_SetRevokedTime_Success(t *testing.T) {
 	}
 }
 
+func TestRedisSessionRepository_SetRevokedTimes(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{
+		Host: mr.Addr(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	repo := repository.NewRedisSessionRepository(client, "test:")
+	ctx := context.Background()
+	revokedTime := time.Now()
+
+	t.Run("成功: 全てのユーザーを保存", func(t *testing.T) {
+		userIDs := []string{"user1", "user2", "user3"}
+		errs := repo.SetRevokedTimes(ctx, userIDs, revokedTime, 10*time.Minute)
+		if len(errs) != len(userIDs) {
+			t.Fatalf("len(errs) = %d, want %d", len(errs), len(userIDs))
+		}
+		for i, userID := range userIDs {
+			if errs[i] != nil {
+				t.Errorf("errs[%d] = %v, want nil", i, errs[i])
+			}
+			value, err := mr.Get("test:" + userID)
+			if err != nil {
+				t.Fatalf("Get(%s) error = %v", userID, err)
+			}
+			if value != revokedTime.Format(time.RFC3339) {
+				t.Errorf("Value = %v, want %v", value, revokedTime.Format(time.RFC3339))
+			}
+			if ttl := mr.TTL("test:" + userID); ttl <= 0 {
+				t.Errorf("TTL = %v, want > 0", ttl)
+			}
+		}
+	})
+
+	t.Run("スキップ: 有効期限0", func(t *testing.T) {
+		errs := repo.SetRevokedTimes(ctx, []string{"user4"}, revokedTime, 0)
+		if len(errs) != 1 || errs[0] != nil {
+			t.Errorf("errs = %v, want [nil]", errs)
+		}
+		if mr.Exists("test:user4") {
+			t.Error("Key should not exist")
+		}
+	})
+
+	t.Run("失敗: Redisに接続できない場合は全てのユーザーがエラー", func(t *testing.T) {
+		mr.Close()
+		errs := repo.SetRevokedTimes(ctx, []string{"user5", "user6"}, revokedTime, 10*time.Minute)
+		if len(errs) != 2 {
+			t.Fatalf("len(errs) = %d, want 2", len(errs))
+		}
+		for i, err := range errs {
+			if err == nil {
+				t.Errorf("errs[%d] = nil, want error", i)
+			}
+		}
+	})
+}
+
 func TestRedisSessionRepository_GetRevokedTime_Success(t *testing.T) {
 	mr, err := miniredis.Run()
 	if err != nil {
@@ -227,6 +293,71 @@ func TestRedisSessionRepository_GetRevokedTime_Success(t *testing.T) {
 	}
 }
 
+func TestRedisSessionRepository_GetRevocation(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{
+		Host: mr.Addr(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	repo := repository.NewRedisSessionRepository(client, "test:")
+	ctx := context.Background()
+	revokedTime := time.Now().Truncate(time.Second)
+
+	if err := repo.SetRevokedTime(ctx, "user123", revokedTime, 10*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	mr.Set("test:noexpire", revokedTime.Format(time.RFC3339))
+
+	tests := []struct {
+		name   string
+		userID string
+		want   repository.Revocation
+	}{
+		{
+			name:   "成功: 失効時刻と残り時間",
+			userID: "user123",
+			want:   repository.Revocation{RevokedAt: revokedTime, TTL: 10 * time.Minute},
+		},
+		{
+			name:   "成功: 有効期限がない場合は残り時間0",
+			userID: "noexpire",
+			want:   repository.Revocation{RevokedAt: revokedTime},
+		},
+		{
+			name:   "成功: 存在しないユーザーはゼロ値",
+			userID: "nonexistent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetRevocation(ctx, tt.userID)
+			if err != nil {
+				t.Fatalf("GetRevocation() error = %v", err)
+			}
+			if !got.RevokedAt.Equal(tt.want.RevokedAt) || got.TTL != tt.want.TTL {
+				t.Errorf("GetRevocation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("失敗: 不正な形式", func(t *testing.T) {
+		mr.Set("test:invalid", "invalid-time-format")
+		if _, err := repo.GetRevocation(ctx, "invalid"); err == nil {
+			t.Error("GetRevocation() error = nil, want error")
+		}
+	})
+}
+
 func TestRedisSessionRepository_GetRevokedTime_ParseError(t *testing.T) {
 	mr, err := miniredis.Run()
 	if err != nil {
@@ -364,3 +495,123 @@ func TestRedisSessionRepository_Integration(t *testing.T) {
 		t.Errorf("GetRevokedTime() after delete = %v, want zero time", gotTime)
 	}
 }
+
+func TestRedisSessionRepository_SubscribeRevocations(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{
+		Host: mr.Addr(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	repo := repository.NewRedisSessionRepository(client, "test:")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events := make(chan repository.RevocationEvent, 2)
+	resyncs := make(chan struct{}, 2)
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.SubscribeRevocations(ctx, repository.RevocationListener{
+			OnRevocation: func(event repository.RevocationEvent) { events <- event },
+			OnResync:     func() { resyncs <- struct{}{} },
+			OnError:      func(err error) { errs <- err },
+		})
+	}()
+
+	waitResync := func() {
+		t.Helper()
+		select {
+		case <-resyncs:
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscription was not established")
+		}
+	}
+	waitResync()
+
+	// 失効時刻の設定と削除はどちらも通知される
+	revokedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := repo.SetRevokedTime(ctx, "user1", revokedAt, time.Minute); err != nil {
+		t.Fatalf("SetRevokedTime() error = %v", err)
+	}
+	if err := repo.DeleteRevokedTime(ctx, "user2"); err != nil {
+		t.Fatalf("DeleteRevokedTime() error = %v", err)
+	}
+	for _, want := range []repository.RevocationEvent{
+		{UserID: "user1", RevokedAt: revokedAt},
+		{UserID: "user2"},
+	} {
+		select {
+		case got := <-events:
+			if got.UserID != want.UserID || !got.RevokedAt.Equal(want.RevokedAt) {
+				t.Errorf("received %+v, want %+v", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("did not receive %+v", want)
+		}
+	}
+
+	// 不正なイベントは OnError へ通知する
+	mr.Publish("test:revocations", "user3")
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("invalid event was not reported")
+	}
+
+	// 再接続して購読し直した時も OnResync を呼び出す
+	addr := mr.Addr()
+	mr.Close()
+	mr = miniredis.NewMiniRedis()
+	if err := mr.StartAddr(addr); err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	waitResync()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("SubscribeRevocations() did not return after cancel")
+	}
+}
+
+func TestRedisSessionRepository_Keyspace(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{
+		Host:     mr.Addr(),
+		Keyspace: redisclient.Keyspace{Namespace: "gw", Version: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	repo := repository.NewRedisSessionRepository(client, "revoke:")
+	revokedTime := time.Now().Truncate(time.Second)
+	if err := repo.SetRevokedTime(context.Background(), "user123", revokedTime, time.Hour); err != nil {
+		t.Fatalf("SetRevokedTime() error = %v", err)
+	}
+
+	// 名前空間の無いキーとは別に保存する
+	if !mr.Exists("gw:v2:revoke:user123") {
+		t.Errorf("key gw:v2:revoke:user123 does not exist, keys = %v", mr.Keys())
+	}
+	if mr.Exists("revoke:user123") {
+		t.Error("key without keyspace should not be written")
+	}
+}
@@ -0,0 +1,90 @@
+// Package requestctx はリクエストのスコープで共有する値（リクエストID・開始時刻・認証済みのクレーム・CORSヘッダー）を保持する
+// 値は1つのcontextのキーにまとめて格納し、ゲートウェイ・ミドルウェア・ハンドラ・トランスポートで同じアクセサを使う
+// 格納した Values は変更せず、値を設定するたびにコピーした Values を新しいcontextに格納する
+package requestctx
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Values はリクエストのスコープで共有する値
+type Values struct {
+	// RequestID はリクエストID（X-Request-Id と同じ値）
+	RequestID string
+	// StartTime はゲートウェイがリクエストを受け付けた時刻
+	StartTime time.Time
+	// Claims は認証したクライアントのクレーム（JWT、OIDCのセッション、Basic・HMAC認証の sub）
+	Claims jwt.MapClaims
+	// CORSHeaders はCORSミドルウェアがレスポンスに付けるヘッダー
+	CORSHeaders map[string]string
+}
+
+// key はcontextのキー（パッケージ外のキーと衝突しないよう非公開の型にする）
+type key struct{}
+
+// From はcontextの値を返す（設定されていない値はゼロ値）
+func From(ctx context.Context) Values {
+	v, _ := ctx.Value(key{}).(*Values)
+	if v == nil {
+		return Values{}
+	}
+	return *v
+}
+
+// With は値を格納したcontextを返す
+func With(ctx context.Context, v Values) context.Context {
+	return context.WithValue(ctx, key{}, &v)
+}
+
+// update は現在の値をコピーして f で変更し、格納したcontextを返す
+func update(ctx context.Context, f func(v *Values)) context.Context {
+	v := From(ctx)
+	f(&v)
+	return With(ctx, v)
+}
+
+// WithRequestID はリクエストIDを設定したcontextを返す
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return update(ctx, func(v *Values) { v.RequestID = requestID })
+}
+
+// WithStartTime はリクエストの開始時刻を設定したcontextを返す
+func WithStartTime(ctx context.Context, start time.Time) context.Context {
+	return update(ctx, func(v *Values) { v.StartTime = start })
+}
+
+// WithClaims は認証したクライアントのクレームを設定したcontextを返す
+func WithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return update(ctx, func(v *Values) { v.Claims = claims })
+}
+
+// WithCORSHeaders はCORSヘッダーを設定したcontextを返す
+func WithCORSHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return update(ctx, func(v *Values) { v.CORSHeaders = headers })
+}
+
+// RequestID はリクエストIDを返す
+func RequestID(ctx context.Context) (string, bool) {
+	id := From(ctx).RequestID
+	return id, id != ""
+}
+
+// StartTime はリクエストの開始時刻を返す
+func StartTime(ctx context.Context) (time.Time, bool) {
+	start := From(ctx).StartTime
+	return start, !start.IsZero()
+}
+
+// Claims は認証したクライアントのクレームを返す
+func Claims(ctx context.Context) (jwt.MapClaims, bool) {
+	claims := From(ctx).Claims
+	return claims, claims != nil
+}
+
+// CORSHeaders はCORSヘッダーを返す（設定されていない場合は nil）
+func CORSHeaders(ctx context.Context) map[string]string {
+	return From(ctx).CORSHeaders
+}
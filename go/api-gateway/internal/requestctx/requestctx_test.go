@@ -0,0 +1,123 @@
+package requestctx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantID    string
+		wantFound bool
+	}{
+		{
+			name:      "リクエストIDが設定されている場合",
+			ctx:       WithRequestID(context.Background(), "test-request-id"),
+			wantID:    "test-request-id",
+			wantFound: true,
+		},
+		{
+			name:      "リクエストIDが設定されていない場合",
+			ctx:       context.Background(),
+			wantID:    "",
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, gotFound := RequestID(tt.ctx)
+
+			if gotFound != tt.wantFound {
+				t.Errorf("RequestID() found = %v, want %v", gotFound, tt.wantFound)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("RequestID() id = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestStartTime(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantTime  time.Time
+		wantFound bool
+	}{
+		{
+			name:      "開始時刻が設定されている場合",
+			ctx:       WithStartTime(context.Background(), now),
+			wantTime:  now,
+			wantFound: true,
+		},
+		{
+			name:      "開始時刻が設定されていない場合",
+			ctx:       context.Background(),
+			wantTime:  time.Time{},
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTime, gotFound := StartTime(tt.ctx)
+
+			if gotFound != tt.wantFound {
+				t.Errorf("StartTime() found = %v, want %v", gotFound, tt.wantFound)
+			}
+			if !gotTime.Equal(tt.wantTime) {
+				t.Errorf("StartTime() time = %v, want %v", gotTime, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestClaims(t *testing.T) {
+	ctx := WithClaims(context.Background(), jwt.MapClaims{"sub": "user123", "iss": "test-issuer"})
+
+	claims, ok := Claims(ctx)
+	if !ok {
+		t.Fatal("claims not found in context")
+	}
+	if claims["sub"] != "user123" || claims["iss"] != "test-issuer" {
+		t.Errorf("claims = %v", claims)
+	}
+
+	if _, ok := Claims(context.Background()); ok {
+		t.Error("expected claims not found, but got ok=true")
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	ctx := WithCORSHeaders(context.Background(), map[string]string{"Access-Control-Allow-Origin": "https://example.com"})
+
+	if got := CORSHeaders(ctx)["Access-Control-Allow-Origin"]; got != "https://example.com" {
+		t.Errorf("CORSHeaders()[Access-Control-Allow-Origin] = %q", got)
+	}
+	if got := CORSHeaders(context.Background()); got != nil {
+		t.Errorf("CORSHeaders() = %v, want nil", got)
+	}
+}
+
+func TestWith_KeepsParentValues(t *testing.T) {
+	start := time.Now()
+	parent := With(context.Background(), Values{RequestID: "req-1", StartTime: start})
+	child := WithClaims(parent, jwt.MapClaims{"sub": "user123"})
+
+	// 値を追加しても既存の値は引き継ぎ、親のcontextは変更しない
+	got := From(child)
+	if got.RequestID != "req-1" || !got.StartTime.Equal(start) || got.Claims["sub"] != "user123" {
+		t.Errorf("From(child) = %+v", got)
+	}
+	if _, ok := Claims(parent); ok {
+		t.Error("parent context was modified")
+	}
+}
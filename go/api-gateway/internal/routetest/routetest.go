@@ -0,0 +1,231 @@
+// Package routetest はルーティング設定に対する宣言的なテストケースを実行する
+// 実際のバックエンドには転送せず、ルーティングとミドルウェアの結果のみを確認するため、CIで回帰テストとして使える
+package routetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-gateway/internal/cache"
+	"api-gateway/internal/handler"
+	"api-gateway/internal/middleware"
+	"api-gateway/internal/repository"
+	"api-gateway/internal/routing"
+	"api-gateway/internal/transport"
+	"api-gateway/pkg/signature"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File はテストケースファイル（cases.yaml）の構造
+type File struct {
+	Cases []Case `yaml:"cases"`
+}
+
+// Case は1つのテストケース
+type Case struct {
+	Name    string  `yaml:"name"`
+	Request Request `yaml:"request"`
+	Expect  Expect  `yaml:"expect"`
+}
+
+// Request はテストで送るリクエスト
+type Request struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// Expect は期待する結果（空の項目は確認しない）
+type Expect struct {
+	// Route はマッチするルートのパス（"-" はどのルートにもマッチしないこと）
+	Route string `yaml:"route,omitempty"`
+	// Backend は転送先のバックエンドURL
+	Backend string `yaml:"backend,omitempty"`
+	// Status はステータスコード（"200"）またはステータスクラス（"2xx"）
+	Status string `yaml:"status,omitempty"`
+}
+
+// Result はテストケースの実行結果
+type Result struct {
+	Case     Case
+	Route    string
+	Backend  string
+	Status   int
+	Failures []string
+}
+
+// Passed はテストケースが成功したかを返す
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// LoadFile はテストケースファイルを読み込む
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cases file: %w", err)
+	}
+
+	var file File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to unmarshal cases file: %w", err)
+	}
+
+	for i, c := range file.Cases {
+		if c.Request.Path == "" {
+			return nil, fmt.Errorf("cases[%d]: request.path is required", i)
+		}
+		if c.Expect.Status != "" {
+			if _, _, err := parseStatus(c.Expect.Status); err != nil {
+				return nil, fmt.Errorf("cases[%d]: %w", i, err)
+			}
+		}
+	}
+	return &file, nil
+}
+
+// Runner はテストケースをルーターとミドルウェアに対して実行する
+type Runner struct {
+	router  *routing.Router
+	gateway http.Handler
+}
+
+// NewRunner は新しいRunnerを作成する
+// バックエンドへの転送は行わず、転送先を記録して200を返す
+// jwtPublicKeys はjwtミドルウェアの検証に使う公開鍵（nilの場合、認証が必要なルートは401になる）
+// revokeミドルウェアは失効済みのユーザーが無いものとして扱う
+func NewRunner(router *routing.Router, jwtPublicKeys map[string]*rsa.PublicKey) *Runner {
+	// テスト結果の出力と混ざらないよう、ゲートウェイのログは捨てる
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	factory := middleware.NewFactory(middleware.FactoryConfig{
+		JWTPublicKeys: jwtPublicKeys,
+		SessionRepo:   noRevocations{},
+		CacheStore:    cache.NewMemoryStore(0),
+		Logger:        logger,
+	})
+	gateway := handler.NewGateway(router, &recordingTransporter{}, factory, logger)
+	// sign_requests のルートが署名鍵の未設定で500にならないようにする（転送しないため署名は行われない）
+	gateway.SetRequestSigner(signature.NewSigner(func() (string, *rsa.PrivateKey) { return "routetest", nil }))
+	return &Runner{
+		router:  router,
+		gateway: gateway,
+	}
+}
+
+// Run は全てのテストケースを順に実行する
+func (r *Runner) Run(ctx context.Context, cases []Case) []Result {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		results = append(results, r.runCase(ctx, c))
+	}
+	return results
+}
+
+// runCase は1つのテストケースを実行し、期待値と比較する
+func (r *Runner) runCase(ctx context.Context, c Case) Result {
+	method := c.Request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req := httptest.NewRequest(method, c.Request.Path, nil).WithContext(ctx)
+	for name, value := range c.Request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	result := Result{Case: c}
+	if match, err := r.router.MatchRequest(req); err == nil {
+		result.Route = match.Route.Path
+	}
+
+	var backend string
+	req = req.WithContext(withBackendRecorder(req.Context(), &backend))
+	w := httptest.NewRecorder()
+	r.gateway.ServeHTTP(w, req)
+	result.Backend = backend
+	result.Status = w.Code
+
+	expect := c.Expect
+	switch {
+	case expect.Route == "-" && result.Route != "":
+		result.Failures = append(result.Failures, fmt.Sprintf("route: expected no match, got %q", result.Route))
+	case expect.Route != "" && expect.Route != "-" && expect.Route != result.Route:
+		result.Failures = append(result.Failures, fmt.Sprintf("route: expected %q, got %q", expect.Route, result.Route))
+	}
+	if expect.Backend != "" && expect.Backend != result.Backend {
+		result.Failures = append(result.Failures, fmt.Sprintf("backend: expected %q, got %q", expect.Backend, result.Backend))
+	}
+	if expect.Status != "" {
+		if lo, hi, _ := parseStatus(expect.Status); result.Status < lo || result.Status > hi {
+			result.Failures = append(result.Failures, fmt.Sprintf("status: expected %s, got %d", expect.Status, result.Status))
+		}
+	}
+	return result
+}
+
+// parseStatus はステータスコードまたはステータスクラスを範囲に変換する
+func parseStatus(s string) (lo, hi int, err error) {
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") && s[0] >= '1' && s[0] <= '5' {
+		class := int(s[0]-'0') * 100
+		return class, class + 99, nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("invalid expected status: %q", s)
+	}
+	return code, code, nil
+}
+
+// backendRecorderKey はバックエンドURLの記録先を保存するコンテキストキー
+type backendRecorderKey struct{}
+
+func withBackendRecorder(ctx context.Context, backend *string) context.Context {
+	return context.WithValue(ctx, backendRecorderKey{}, backend)
+}
+
+// recordingTransporter は転送せずにバックエンドURLを記録し、200を返す
+type recordingTransporter struct{}
+
+func (t *recordingTransporter) Transport(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *transport.Backend) error {
+	if dst, ok := ctx.Value(backendRecorderKey{}).(*string); ok && backend != nil && backend.URL != nil {
+		*dst = backend.URL.String()
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// noRevocations は失効済みのユーザーが存在しないセッションリポジトリ
+type noRevocations struct{}
+
+func (noRevocations) SetRevokedTime(ctx context.Context, userID string, revokedTime time.Time, expiration time.Duration) error {
+	return nil
+}
+
+func (noRevocations) SetRevokedTimes(ctx context.Context, userIDs []string, revokedTime time.Time, expiration time.Duration) []error {
+	return make([]error, len(userIDs))
+}
+
+func (noRevocations) GetRevokedTime(ctx context.Context, userID string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (noRevocations) GetRevocation(ctx context.Context, userID string) (repository.Revocation, error) {
+	return repository.Revocation{}, nil
+}
+
+func (noRevocations) DeleteRevokedTime(ctx context.Context, userID string) error {
+	return nil
+}
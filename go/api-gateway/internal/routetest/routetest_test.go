@@ -0,0 +1,138 @@
+package routetest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/routing"
+)
+
+func newTestRouter(t *testing.T) *routing.Router {
+	t.Helper()
+
+	router := routing.NewRouter()
+	err := router.LoadFromConfig(&config.RoutingFileConfig{Routes: []config.Route{
+		{
+			Path:    "/api/v1/users",
+			Methods: []string{"GET"},
+			Backend: config.BackendConfig{URL: "http://user-service:8080"},
+			Middleware: []config.MiddlewareConfig{
+				{Type: "jwt"},
+				{Type: "revoke"},
+			},
+		},
+		{
+			Path:    "/public/:id",
+			Backend: config.BackendConfig{URL: "http://public-service:8080", SignRequests: true},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+	return router
+}
+
+func TestRunner_Run(t *testing.T) {
+	runner := NewRunner(newTestRouter(t), nil)
+
+	tests := []struct {
+		name         string
+		c            Case
+		wantFailures int
+	}{
+		{
+			name: "全ての期待値が一致",
+			c: Case{
+				Request: Request{Method: "GET", Path: "/public/1"},
+				Expect:  Expect{Route: "/public/:id", Backend: "http://public-service:8080", Status: "2xx"},
+			},
+		},
+		{
+			name: "認証が必要なルートはバックエンドへ転送されない",
+			c: Case{
+				Request: Request{Method: "GET", Path: "/api/v1/users", Headers: map[string]string{"Authorization": "Bearer invalid"}},
+				Expect:  Expect{Route: "/api/v1/users", Status: "401"},
+			},
+		},
+		{
+			name: "マッチしないこと",
+			c: Case{
+				Request: Request{Path: "/unknown"},
+				Expect:  Expect{Route: "-", Status: "4xx"},
+			},
+		},
+		{
+			name: "ルート・バックエンド・ステータスの不一致",
+			c: Case{
+				Request: Request{Method: "GET", Path: "/public/1"},
+				Expect:  Expect{Route: "/api/v1/users", Backend: "http://user-service:8080", Status: "5xx"},
+			},
+			wantFailures: 3,
+		},
+		{
+			name: "許可されていないメソッド",
+			c: Case{
+				Request: Request{Method: "POST", Path: "/api/v1/users"},
+				Expect:  Expect{Route: "/api/v1/users"},
+			},
+			wantFailures: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := runner.Run(context.Background(), []Case{tt.c})
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			if got := len(results[0].Failures); got != tt.wantFailures {
+				t.Errorf("failures = %v, want %d failures", results[0].Failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "正常なファイル",
+			content: `cases:
+  - name: users
+    request: {method: GET, path: /api/v1/users}
+    expect: {route: /api/v1/users, status: 2xx}
+  - request: {path: /health}
+    expect: {status: "200"}
+`,
+			want: 2,
+		},
+		{name: "空のファイル", content: "", want: 0},
+		{name: "未知のフィールド", content: "cases:\n  - request: {path: /a}\n    expected: {status: 2xx}\n", wantErr: true},
+		{name: "パスが無い", content: "cases:\n  - request: {method: GET}\n", wantErr: true},
+		{name: "不正なステータス", content: "cases:\n  - request: {path: /a}\n    expect: {status: 6xx}\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cases.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(file.Cases) != tt.want {
+				t.Errorf("len(Cases) = %d, want %d", len(file.Cases), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package routing
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"sync/atomic"
+
+	"api-gateway/internal/config"
+)
+
+// canaryScale は振り分けの割合の分解能（0.01%単位）
+const canaryScale = 10000
+
+// Canary はルートのリクエストの一部をカナリアのバックエンドへ振り分ける
+// 割合は管理APIで実行中に変更できる（ルーティング設定の再読み込みや再起動で設定ファイルの値に戻る）
+type Canary struct {
+	// URL はカナリアのバックエンドのURL
+	URL *url.URL
+	// StickyClaim は振り分けをユーザーごとに固定するJWTのクレーム名（空の場合はリクエストごとに振り分ける）
+	StickyClaim string
+
+	// weight はカナリアへ送る割合（0〜canaryScale）
+	weight atomic.Int64
+}
+
+// NewCanary は設定からCanaryを作成する
+func NewCanary(cfg config.CanaryConfig) (*Canary, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL: %q", cfg.URL)
+	}
+
+	c := &Canary{
+		URL:         u,
+		StickyClaim: cfg.StickyClaim,
+	}
+	if err := c.SetWeight(cfg.Weight); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Weight はカナリアへ送るリクエストの割合（パーセント）を返す
+func (c *Canary) Weight() float64 {
+	return float64(c.weight.Load()) * 100 / canaryScale
+}
+
+// SetWeight はカナリアへ送るリクエストの割合（0〜100のパーセント）を変更する
+func (c *Canary) SetWeight(percent float64) error {
+	if math.IsNaN(percent) || percent < 0 || percent > 100 {
+		return fmt.Errorf("weight must be between 0 and 100: %v", percent)
+	}
+	c.weight.Store(int64(math.Round(percent * canaryScale / 100)))
+	return nil
+}
+
+// Pick はリクエストをカナリアへ送るか決める
+// key（StickyClaim の値）が空でない場合はハッシュで決めるため、同じユーザーは常に同じバックエンドへ送られ、
+// 割合を上げても既にカナリアへ送られているユーザーはカナリアのまま
+func (c *Canary) Pick(key string) bool {
+	weight := c.weight.Load()
+	switch weight {
+	case 0:
+		return false
+	case canaryScale:
+		return true
+	}
+
+	if key == "" {
+		return rand.Int64N(canaryScale) < weight
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int64(h.Sum32()%canaryScale) < weight
+}
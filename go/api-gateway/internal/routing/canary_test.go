@@ -0,0 +1,98 @@
+package routing
+
+import (
+	"fmt"
+	"testing"
+
+	"api-gateway/internal/config"
+)
+
+func TestNewCanary(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.CanaryConfig
+		wantErr bool
+	}{
+		{name: "割合を指定", cfg: config.CanaryConfig{URL: "https://canary.example.com", Weight: 5}},
+		{name: "0.01刻みの割合", cfg: config.CanaryConfig{URL: "https://canary.example.com", Weight: 0.25}},
+		{name: "相対URL", cfg: config.CanaryConfig{URL: "/canary", Weight: 5}, wantErr: true},
+		{name: "負の割合", cfg: config.CanaryConfig{URL: "https://canary.example.com", Weight: -1}, wantErr: true},
+		{name: "100を超える割合", cfg: config.CanaryConfig{URL: "https://canary.example.com", Weight: 101}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCanary(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCanary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && c.Weight() != tt.cfg.Weight {
+				t.Errorf("Weight() = %v, want %v", c.Weight(), tt.cfg.Weight)
+			}
+		})
+	}
+}
+
+func TestCanary_Pick(t *testing.T) {
+	const requests = 10000
+
+	tests := []struct {
+		name   string
+		weight float64
+		sticky bool
+		// カナリアへ送られる件数の範囲
+		wantMin, wantMax int
+	}{
+		{name: "0%は送らない", weight: 0, wantMin: 0, wantMax: 0},
+		{name: "100%は全て送る", weight: 100, wantMin: requests, wantMax: requests},
+		{name: "ランダムに約5%", weight: 5, wantMin: 350, wantMax: 650},
+		{name: "ユーザーのハッシュで約5%", weight: 5, sticky: true, wantMin: 350, wantMax: 650},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCanary(config.CanaryConfig{URL: "https://canary.example.com", Weight: tt.weight})
+			if err != nil {
+				t.Fatalf("NewCanary() error = %v", err)
+			}
+			picked := 0
+			for i := range requests {
+				key := ""
+				if tt.sticky {
+					key = fmt.Sprintf("user-%d", i)
+				}
+				if c.Pick(key) {
+					picked++
+				}
+			}
+			if picked < tt.wantMin || picked > tt.wantMax {
+				t.Errorf("picked = %d, want between %d and %d", picked, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestCanary_Pick_Sticky(t *testing.T) {
+	c, err := NewCanary(config.CanaryConfig{URL: "https://canary.example.com", Weight: 10})
+	if err != nil {
+		t.Fatalf("NewCanary() error = %v", err)
+	}
+
+	// 同じユーザーは常に同じバックエンドへ送られ、割合を上げてもカナリアのユーザーはカナリアのまま
+	inCanary := make(map[string]bool)
+	for i := range 1000 {
+		key := fmt.Sprintf("user-%d", i)
+		inCanary[key] = c.Pick(key)
+		if c.Pick(key) != inCanary[key] {
+			t.Fatalf("Pick(%q) is not stable", key)
+		}
+	}
+	if err := c.SetWeight(50); err != nil {
+		t.Fatalf("SetWeight() error = %v", err)
+	}
+	for key, picked := range inCanary {
+		if picked && !c.Pick(key) {
+			t.Errorf("Pick(%q) moved back to stable after raising the weight", key)
+		}
+	}
+}
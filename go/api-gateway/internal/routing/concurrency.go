@@ -0,0 +1,196 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+var concurrencyQueueDepth = metrics.NewGaugeVec(
+	"gateway_concurrency_queue_depth",
+	"Number of requests waiting for the route's max_concurrent_requests limit.",
+	"route",
+)
+
+var (
+	// ErrConcurrencyLimitExceeded は同時リクエスト数の上限に達し、待ち行列にも入れなかった場合のエラー
+	ErrConcurrencyLimitExceeded = errors.New("concurrency limit exceeded")
+	// ErrConcurrencyQueueTimeout は待ち行列で待つ時間の上限を過ぎた場合のエラー
+	ErrConcurrencyQueueTimeout = errors.New("timed out waiting for concurrency limit")
+)
+
+// ConcurrencyQueue は同時リクエスト数の上限に達した場合にリクエストを待たせる設定
+type ConcurrencyQueue struct {
+	// Route はメトリクスのラベルに使うルートのパス
+	Route string
+	// Depth は待たせるリクエスト数の上限（0は待たせない）
+	Depth int
+	// Timeout は待つ時間の上限
+	Timeout time.Duration
+}
+
+// ConcurrencyLimiter は同時に処理するリクエスト数を上限までに制限するセマフォ
+// 待ち行列を設定した場合、上限に達している間のリクエストは到着した順に待ち、処理中のリクエストが終わると枠を引き継ぐ
+// ルートごとの上限はリスナーごとのルーターで同じ ConcurrencyLimiter を共有するため、全てのリスナーのリクエストを数える
+// nil の場合は制限しない
+type ConcurrencyLimiter struct {
+	limit int
+	queue ConcurrencyQueue
+	// depth は待ち行列の長さのメトリクス（待ち行列が無い場合はnil）
+	depth *metrics.Gauge
+
+	mu       sync.Mutex
+	inFlight int
+	waiters  []*concurrencyWaiter
+}
+
+// concurrencyWaiter は待ち行列で枠が空くのを待つリクエスト
+type concurrencyWaiter struct {
+	// ready は枠を引き継いだ時に閉じる
+	ready chan struct{}
+	// granted は枠を引き継いだか（mu で保護する）
+	granted bool
+}
+
+// NewConcurrencyLimiter は同時に limit 件までのリクエストを処理する ConcurrencyLimiter を作成する
+func NewConcurrencyLimiter(limit int, queue ConcurrencyQueue) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{limit: limit, queue: queue}
+	if queue.Depth > 0 {
+		l.depth = concurrencyQueueDepth.With(queue.Route)
+		l.depth.Set(0)
+	}
+	return l
+}
+
+// TryAcquire は処理中のリクエストが上限未満の場合に1件分を確保する（上限に達している場合は待たずに false）
+// 確保した場合は処理の終了時に Release を呼ぶ
+func (l *ConcurrencyLimiter) TryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 待っているリクエストを追い越さない
+	if l.inFlight >= l.limit || len(l.waiters) > 0 {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// Acquire は1件分を確保する。上限に達している場合は待ち行列で枠が空くのを待つ
+// 待ち行列が一杯の場合は ErrConcurrencyLimitExceeded、待つ時間の上限を過ぎた場合は ErrConcurrencyQueueTimeout、
+// 待っている間に ctx が終了した場合は ctx.Err() を返す
+// 確保した場合は処理の終了時に Release を呼ぶ
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.inFlight < l.limit && len(l.waiters) == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return nil
+	}
+	if len(l.waiters) >= l.queue.Depth {
+		l.mu.Unlock()
+		return ErrConcurrencyLimitExceeded
+	}
+	waiter := &concurrencyWaiter{ready: make(chan struct{})}
+	l.waiters = append(l.waiters, waiter)
+	l.updateDepth()
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.queue.Timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-timer.C:
+		err = ErrConcurrencyQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 待つのをやめる直前に枠を引き継いでいた場合はそのまま使う
+	if waiter.granted {
+		return nil
+	}
+	l.waiters = slices.DeleteFunc(l.waiters, func(w *concurrencyWaiter) bool { return w == waiter })
+	l.updateDepth()
+	return err
+}
+
+// Release は TryAcquire または Acquire で確保した1件分を解放する
+// 待っているリクエストがある場合は、最も長く待っているリクエストへ枠を引き継ぐ
+func (l *ConcurrencyLimiter) Release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.waiters) == 0 {
+		l.inFlight--
+		return
+	}
+	waiter := l.waiters[0]
+	l.waiters = slices.Delete(l.waiters, 0, 1)
+	waiter.granted = true
+	close(waiter.ready)
+	l.updateDepth()
+}
+
+// updateDepth は待ち行列の長さのメトリクスを更新する（mu を保持して呼ぶ）
+func (l *ConcurrencyLimiter) updateDepth() {
+	if l.depth != nil {
+		l.depth.Set(float64(len(l.waiters)))
+	}
+}
+
+// Limit は同時に処理するリクエスト数の上限を返す（nilの場合は0）
+func (l *ConcurrencyLimiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	return l.limit
+}
+
+// Queue は待ち行列の設定を返す
+func (l *ConcurrencyLimiter) Queue() ConcurrencyQueue {
+	if l == nil {
+		return ConcurrencyQueue{}
+	}
+	return l.queue
+}
+
+// InFlight は処理中のリクエスト数を返す
+func (l *ConcurrencyLimiter) InFlight() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// QueueDepth は待ち行列で待っているリクエスト数を返す
+func (l *ConcurrencyLimiter) QueueDepth() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.waiters)
+}
@@ -0,0 +1,110 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+func TestConcurrencyLimiter_Acquire(t *testing.T) {
+	t.Run("待ち行列が無い場合は上限で断る", func(t *testing.T) {
+		l := NewConcurrencyLimiter(1, ConcurrencyQueue{})
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		if err := l.Acquire(context.Background()); !errors.Is(err, ErrConcurrencyLimitExceeded) {
+			t.Errorf("Acquire() error = %v, want ErrConcurrencyLimitExceeded", err)
+		}
+		if l.TryAcquire() {
+			t.Error("TryAcquire() = true, want false at the limit")
+		}
+		l.Release()
+		if !l.TryAcquire() {
+			t.Error("TryAcquire() = false, want true after Release")
+		}
+	})
+
+	t.Run("到着した順に枠を引き継ぐ", func(t *testing.T) {
+		l := NewConcurrencyLimiter(1, ConcurrencyQueue{Route: "/fifo", Depth: 2, Timeout: 5 * time.Second})
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+
+		order := make(chan int, 2)
+		for i := range 2 {
+			go func() {
+				if err := l.Acquire(context.Background()); err != nil {
+					t.Errorf("Acquire() error = %v", err)
+				}
+				order <- i
+			}()
+			for l.QueueDepth() != i+1 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		// 待ち行列が一杯
+		if err := l.Acquire(context.Background()); !errors.Is(err, ErrConcurrencyLimitExceeded) {
+			t.Errorf("Acquire() error = %v, want ErrConcurrencyLimitExceeded", err)
+		}
+		// 待っているリクエストを追い越さない
+		if l.TryAcquire() {
+			t.Error("TryAcquire() = true, want false while requests are waiting")
+		}
+
+		var b strings.Builder
+		metrics.Default.WriteText(&b)
+		if !strings.Contains(b.String(), `gateway_concurrency_queue_depth{route="/fifo"} 2`) {
+			t.Errorf("metrics should report queue depth 2:\n%s", b.String())
+		}
+
+		for want := range 2 {
+			l.Release()
+			if got := <-order; got != want {
+				t.Errorf("acquired waiter %d, want %d", got, want)
+			}
+		}
+		if l.InFlight() != 1 || l.QueueDepth() != 0 {
+			t.Errorf("InFlight() = %d, QueueDepth() = %d, want 1 and 0", l.InFlight(), l.QueueDepth())
+		}
+	})
+
+	t.Run("待つ時間の上限", func(t *testing.T) {
+		l := NewConcurrencyLimiter(1, ConcurrencyQueue{Depth: 1, Timeout: 10 * time.Millisecond})
+		l.TryAcquire()
+		if err := l.Acquire(context.Background()); !errors.Is(err, ErrConcurrencyQueueTimeout) {
+			t.Errorf("Acquire() error = %v, want ErrConcurrencyQueueTimeout", err)
+		}
+		if l.QueueDepth() != 0 {
+			t.Errorf("QueueDepth() = %d, want 0 after timeout", l.QueueDepth())
+		}
+	})
+
+	t.Run("待っている間にcontextが終了", func(t *testing.T) {
+		l := NewConcurrencyLimiter(1, ConcurrencyQueue{Depth: 1, Timeout: 5 * time.Second})
+		l.TryAcquire()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := l.Acquire(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Acquire() error = %v, want context.Canceled", err)
+		}
+
+		// 待つのをやめたリクエストには枠を引き継がない
+		l.Release()
+		if l.InFlight() != 0 {
+			t.Errorf("InFlight() = %d, want 0", l.InFlight())
+		}
+	})
+
+	t.Run("nilは制限しない", func(t *testing.T) {
+		var l *ConcurrencyLimiter
+		if err := l.Acquire(context.Background()); err != nil || !l.TryAcquire() {
+			t.Errorf("nil limiter should not limit: %v", err)
+		}
+		l.Release()
+	})
+}
@@ -0,0 +1,81 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRouteConflict は同じリクエストにマッチするルートの優先順位を決められない場合のエラー
+var ErrRouteConflict = errors.New("conflicting routes")
+
+// RouteConflictError は優先度が同じで、パラメータ名以外のパターンと match の条件が同じルートを追加しようとした場合のエラー
+// これらのルートは同じリクエストにマッチし、どちらを使うかを決められないため追加できない
+// errors.Is(err, ErrRouteConflict) で判定でき、errors.As で衝突するルートを取得できる
+type RouteConflictError struct {
+	// Conflicts は衝突するルートの組（ルートはパスと match の条件で表す）
+	Conflicts []RouteConflict
+}
+
+// RouteConflict は互いに衝突するルートの組
+type RouteConflict struct {
+	// Priority はルートの優先度
+	Priority int
+	// Routes は衝突するルート（追加した順）
+	Routes []string
+}
+
+func (e *RouteConflictError) Error() string {
+	groups := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		groups = append(groups, fmt.Sprintf("%s (priority %d)", strings.Join(c.Routes, ", "), c.Priority))
+	}
+	return fmt.Sprintf("%s: %s; set different priorities or match conditions", ErrRouteConflict, strings.Join(groups, "; "))
+}
+
+func (e *RouteConflictError) Unwrap() error {
+	return ErrRouteConflict
+}
+
+// checkConflicts は routes に衝突するルートの組が無いか確認する（衝突がある場合は *RouteConflictError）
+func checkConflicts(routes []*Route) error {
+	type conflictKey struct {
+		pattern  string
+		priority int
+	}
+
+	var keys []conflictKey
+	groups := make(map[conflictKey][]string)
+	for _, route := range routes {
+		key := conflictKey{pattern: conflictPattern(route), priority: route.Priority}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], routeName(route))
+	}
+
+	var conflicts []RouteConflict
+	for _, key := range keys {
+		if names := groups[key]; len(names) > 1 {
+			conflicts = append(conflicts, RouteConflict{Priority: key.priority, Routes: names})
+		}
+	}
+	if len(conflicts) > 0 {
+		return &RouteConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// conflictPattern はパラメータ名と末尾スラッシュの違いを無視したパスのパターンと match の条件を返す
+// （/users/:id と /users/{name}、/users/{id:[0-9]+} と /users/{n:[0-9]+} は同じパターン）
+func conflictPattern(route *Route) string {
+	segments := SplitPath(route.Path)
+	for i, segment := range segments {
+		if _, expr, ok := parseRegexSegment(segment); ok {
+			segments[i] = "{:" + expr + "}"
+		} else if _, ok := ParamName(segment); ok {
+			segments[i] = ":"
+		}
+	}
+	return JoinPath(segments) + " " + route.Matcher.String()
+}
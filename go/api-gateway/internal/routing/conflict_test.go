@@ -0,0 +1,117 @@
+package routing
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/config"
+)
+
+func TestRouter_AddRoute_Conflict(t *testing.T) {
+	backend := &Backend{URL: mustParseURL("https://example.com")}
+	beta, err := NewRequestMatcher(config.MatchConfig{Query: map[string]string{"beta": "true"}})
+	if err != nil {
+		t.Fatalf("NewRequestMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		existing  *Route
+		route     *Route
+		wantRoute []string
+	}{
+		{
+			name:      "同じパス",
+			existing:  &Route{Path: "/users", Backend: backend},
+			route:     &Route{Path: "/users/", Methods: []string{"POST"}, Backend: backend},
+			wantRoute: []string{"/users", "/users/"},
+		},
+		{
+			name:      "パラメータ名のみ異なる",
+			existing:  &Route{Path: "/users/:id", Backend: backend},
+			route:     &Route{Path: "/users/{name}", Backend: backend},
+			wantRoute: []string{"/users/:id", "/users/{name}"},
+		},
+		{
+			name:      "正規表現が同じでパラメータ名のみ異なる",
+			existing:  &Route{Path: "/users/{id:[0-9]+}", Priority: 5, Backend: backend},
+			route:     &Route{Path: "/users/{uid:[0-9]+}", Priority: 5, Backend: backend},
+			wantRoute: []string{"/users/{id:[0-9]+}", "/users/{uid:[0-9]+}"},
+		},
+		{
+			name:      "match の条件が同じ",
+			existing:  &Route{Path: "/users", Matcher: beta, Backend: backend},
+			route:     &Route{Path: "/users", Matcher: beta, Backend: backend},
+			wantRoute: []string{"/users ?beta=true", "/users ?beta=true"},
+		},
+		{name: "優先度が異なる", existing: &Route{Path: "/users/:id", Backend: backend}, route: &Route{Path: "/users/:name", Priority: 1, Backend: backend}},
+		{name: "match の条件が異なる", existing: &Route{Path: "/users", Backend: backend}, route: &Route{Path: "/users", Matcher: beta, Backend: backend}},
+		{name: "正規表現が異なる", existing: &Route{Path: "/users/{id:[0-9]+}", Backend: backend}, route: &Route{Path: "/users/{code:[a-z]+}", Backend: backend}},
+		{name: "静的なセグメントとパラメータ", existing: &Route{Path: "/users/me", Backend: backend}, route: &Route{Path: "/users/:id", Backend: backend}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			if err := router.AddRoute(tt.existing); err != nil {
+				t.Fatalf("AddRoute() error = %v", err)
+			}
+
+			err := router.AddRoute(tt.route)
+			if tt.wantRoute == nil {
+				if err != nil {
+					t.Errorf("AddRoute() error = %v", err)
+				}
+				return
+			}
+
+			var conflictErr *RouteConflictError
+			if !errors.As(err, &conflictErr) || !errors.Is(err, ErrRouteConflict) {
+				t.Fatalf("AddRoute() error = %v, want RouteConflictError", err)
+			}
+			if len(conflictErr.Conflicts) != 1 || !reflect.DeepEqual(conflictErr.Conflicts[0].Routes, tt.wantRoute) {
+				t.Errorf("Conflicts = %+v, want routes %v", conflictErr.Conflicts, tt.wantRoute)
+			}
+			if got := len(router.GetAllRoutes()); got != 1 {
+				t.Errorf("GetAllRoutes() = %d routes, want 1 (conflicting route must not be added)", got)
+			}
+		})
+	}
+}
+
+func TestRouter_LoadFromConfig_Conflict(t *testing.T) {
+	backend := config.BackendConfig{URL: "https://example.com"}
+	router := NewRouter()
+	err := router.LoadFromConfig(&config.RoutingFileConfig{Routes: []config.Route{
+		{Path: "/users/:id", Backend: backend, Priority: 10},
+		{Path: "/orders", Backend: backend},
+		{Path: "/users/:name", Backend: backend, Priority: 10},
+		{Path: "/orders/", Backend: backend},
+		{Path: "/users/{uid}", Backend: backend, Priority: 10},
+		{Path: "/health", Backend: backend},
+	}})
+
+	var conflictErr *RouteConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("LoadFromConfig() error = %v, want RouteConflictError", err)
+	}
+	want := []RouteConflict{
+		{Priority: 10, Routes: []string{"/users/:id", "/users/:name", "/users/{uid}"}},
+		{Priority: 0, Routes: []string{"/orders", "/orders/"}},
+	}
+	if !reflect.DeepEqual(conflictErr.Conflicts, want) {
+		t.Errorf("Conflicts = %+v, want %+v", conflictErr.Conflicts, want)
+	}
+	for _, s := range []string{"/users/:id, /users/:name, /users/{uid} (priority 10)", "/orders, /orders/ (priority 0)"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error %q should contain %q", err.Error(), s)
+		}
+	}
+
+	// 衝突がある場合はどのルートも追加しない
+	if got := len(router.GetAllRoutes()); got != 0 {
+		t.Errorf("GetAllRoutes() = %d routes, want 0", got)
+	}
+}
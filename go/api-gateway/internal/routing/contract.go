@@ -0,0 +1,90 @@
+package routing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"api-gateway/internal/contract"
+	"api-gateway/internal/transport"
+)
+
+// RequestValidator はクライアントのリクエストをルートのOpenAPIドキュメントで検証する
+// ドキュメントはバックエンドへ転送するメソッドとパスで照合し、記載の無いオペレーションへのリクエストは検証しない
+type RequestValidator struct {
+	// Route はログとメトリクスのラベルに使うルートのパス
+	Route string
+	// Spec はOpenAPIのドキュメント
+	Spec *contract.Spec
+	// Enforce は違反したリクエストを転送せずに400を返すか（false は違反を記録して転送する）
+	Enforce bool
+	// MaxBody は検証するボディの上限バイト数（0は transport.DefaultContractMaxBody）
+	MaxBody int64
+}
+
+// RequestValidation はリクエストの検証結果
+type RequestValidation struct {
+	// Operation は照合したドキュメントのパス（記載の無いオペレーションは空）
+	Operation string
+	// Violations は契約に違反した項目
+	Violations []contract.Violation
+	// Skipped は検証できなかった理由（ボディが上限を超えた場合など）
+	Skipped string
+}
+
+// Validate はリクエストを検証する。path はバックエンドへ転送するパス
+// ボディは読み込んだ後に転送できるよう r.Body に戻す。読み込みに失敗した場合のみエラーを返す
+func (v *RequestValidator) Validate(r *http.Request, path string) (RequestValidation, error) {
+	op, ok := v.Spec.FindOperation(r.Method, path)
+	if !ok {
+		return RequestValidation{}, nil
+	}
+	result := RequestValidation{Operation: op.Path}
+
+	var body []byte
+	if op.HasRequestBody() {
+		var err error
+		body, result.Skipped, err = v.readBody(r)
+		if err != nil {
+			return RequestValidation{}, err
+		}
+		if result.Skipped != "" {
+			return result, nil
+		}
+	}
+	result.Violations = op.ValidateRequest(r, body)
+	return result, nil
+}
+
+// readBody はリクエストボディを読み込み、読み込んだ内容で r.Body を置き換える
+// 上限を超えたボディは読み込んだ部分と残りをつないで戻し、理由（skipped）を返す
+func (v *RequestValidator) readBody(r *http.Request) (body []byte, skipped string, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, "", nil
+	}
+	maxBody := v.MaxBody
+	if maxBody <= 0 {
+		maxBody = transport.DefaultContractMaxBody
+	}
+	body, err = io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+	if err != nil {
+		r.Body.Close()
+		return nil, "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	if int64(len(body)) > maxBody {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, fmt.Sprintf("request body is larger than %d bytes", maxBody), nil
+	}
+	r.Body.Close()
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	return body, "", nil
+}
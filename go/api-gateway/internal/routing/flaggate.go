@@ -0,0 +1,80 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/errors"
+)
+
+// flagNamePattern はフィーチャーフラグの名前として使える文字列
+var flagNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// FlagGate はフィーチャーフラグによってルートを公開するか切り替える
+// 設定だけでルートをダークローンチし、フラグを有効にした時点で公開するために使う
+type FlagGate struct {
+	// Flag はフラグの名前
+	Flag string
+	// Negate はフラグが無効な場合にルートを公開するか（"!flags.<name>"）
+	Negate bool
+	// DisabledStatus はルートが無効な場合に返すステータス（404, 503）
+	DisabledStatus int
+	// Fallback はルートが無効な場合に代わりに転送するバックエンド（nilの場合は DisabledStatus を返す）
+	Fallback *Backend
+}
+
+// NewFlagGate は設定からFlagGateを作成する
+func NewFlagGate(cfg config.Route) (*FlagGate, error) {
+	expr := strings.TrimSpace(cfg.EnabledWhen)
+	negate := strings.HasPrefix(expr, "!")
+	name, ok := strings.CutPrefix(strings.TrimSpace(strings.TrimPrefix(expr, "!")), "flags.")
+	if !ok || !flagNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("enabled_when must be flags.<name> or !flags.<name>: %q", cfg.EnabledWhen)
+	}
+
+	gate := &FlagGate{Flag: name, Negate: negate, DisabledStatus: http.StatusNotFound}
+	disabled := cfg.WhenDisabled
+	if disabled == nil {
+		return gate, nil
+	}
+	switch {
+	case disabled.Status != 0 && disabled.Backend != nil:
+		return nil, fmt.Errorf("when_disabled status and backend cannot be used together")
+	case disabled.Status != 0 && disabled.Status != http.StatusNotFound && disabled.Status != http.StatusServiceUnavailable:
+		return nil, fmt.Errorf("when_disabled status must be 404 or 503: %d", disabled.Status)
+	case disabled.Status != 0:
+		gate.DisabledStatus = disabled.Status
+	case disabled.Backend != nil:
+		// 代わりのバックエンドはルートの他の設定（ヘッダー・ボディの上限など）をそのまま使う
+		fallbackCfg := cfg
+		fallbackCfg.Backend = *disabled.Backend
+		fallbackCfg.Response = nil
+		fallbackCfg.Aggregate = nil
+		fallbackCfg.EnabledWhen = ""
+		fallbackCfg.WhenDisabled = nil
+		fallback, err := NewRoute(fallbackCfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid when_disabled backend: %w", err)
+		}
+		gate.Fallback = fallback.Backend
+	}
+	return gate, nil
+}
+
+// Enabled はルートを公開するか返す
+// enabled はフラグが有効か返す関数（定義されていないフラグは無効とみなす）
+func (g *FlagGate) Enabled(enabled func(name string) bool) bool {
+	return enabled(g.Flag) != g.Negate
+}
+
+// DisabledError はルートが無効な場合のエラーを返す
+// 404 の場合はルートが存在しない場合と同じエラーを返し、ダークローンチ中のルートの存在を明かさない
+func (g *FlagGate) DisabledError(path string) errors.GatewayError {
+	if g.DisabledStatus == http.StatusServiceUnavailable {
+		return errors.NewError(http.StatusServiceUnavailable, "FEATURE_DISABLED", "this feature is currently unavailable")
+	}
+	return errors.NewNotFoundError(fmt.Sprintf("no route found for path: %s", path))
+}
@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"api-gateway/internal/config"
+	"api-gateway/internal/forwarded"
+)
+
+// HeaderRules はルートごとのヘッダー変換ルール
+// remove → set → add の順に適用する
+type HeaderRules struct {
+	Remove []string
+	Set    http.Header
+	Add    http.Header
+}
+
+// headerPlaceholders はヘッダー値で使えるプレースホルダー
+var headerPlaceholders = []string{"{client_ip}", "{host}", "{scheme}", "{method}", "{path}"}
+
+// NewHeaderRules は設定からヘッダー変換ルールを作成する
+func NewHeaderRules(cfg config.HeaderRulesConfig) (*HeaderRules, error) {
+	rules := &HeaderRules{
+		Set: make(http.Header, len(cfg.Set)),
+		Add: make(http.Header, len(cfg.Add)),
+	}
+	for _, name := range cfg.Remove {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("invalid header name to remove: %q", name)
+		}
+		rules.Remove = append(rules.Remove, http.CanonicalHeaderKey(name))
+	}
+	for name, value := range cfg.Set {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("invalid header name to set: %q", name)
+		}
+		rules.Set.Set(name, value)
+	}
+	for name, value := range cfg.Add {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("invalid header name to add: %q", name)
+		}
+		rules.Add.Add(name, value)
+	}
+
+	return rules, nil
+}
+
+// Empty は適用するルールが無いか確認する
+func (h *HeaderRules) Empty() bool {
+	return h == nil || (len(h.Remove) == 0 && len(h.Set) == 0 && len(h.Add) == 0)
+}
+
+// Apply はヘッダーにルールを適用する
+// プレースホルダーはクライアントからのリクエスト req の値で置き換える
+func (h *HeaderRules) Apply(header http.Header, req *http.Request) {
+	var replacer *strings.Replacer
+	expand := func(value string) string {
+		if !strings.Contains(value, "{") {
+			return value
+		}
+		if replacer == nil {
+			replacer = newPlaceholderReplacer(req)
+		}
+		return replacer.Replace(value)
+	}
+
+	for _, name := range h.Remove {
+		header.Del(name)
+	}
+	for name, values := range h.Set {
+		header.Set(name, expand(values[0]))
+	}
+	for name, values := range h.Add {
+		header.Add(name, expand(values[0]))
+	}
+}
+
+// newPlaceholderReplacer はheaderPlaceholdersをリクエストの値に置き換えるReplacerを作成する
+func newPlaceholderReplacer(req *http.Request) *strings.Replacer {
+	clientIP := forwarded.ClientIP(req)
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	values := []string{clientIP, req.Host, scheme, req.Method, req.URL.Path}
+	pairs := make([]string, 0, len(headerPlaceholders)*2)
+	for i, placeholder := range headerPlaceholders {
+		pairs = append(pairs, placeholder, values[i])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// validHeaderName はヘッダー名がRFC 9110のtokenとして有効か確認する
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
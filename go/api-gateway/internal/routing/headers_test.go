@@ -0,0 +1,89 @@
+package routing
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"api-gateway/internal/config"
+)
+
+func TestNewHeaderRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.HeaderRulesConfig
+		wantErr   bool
+		wantEmpty bool
+	}{
+		{name: "empty", cfg: config.HeaderRulesConfig{}, wantEmpty: true},
+		{
+			name: "valid rules",
+			cfg: config.HeaderRulesConfig{
+				Add:    map[string]string{"X-Forwarded-Host": "{host}"},
+				Set:    map[string]string{"Strict-Transport-Security": "max-age=31536000"},
+				Remove: []string{"X-Internal-Token"},
+			},
+		},
+		{name: "invalid add name", cfg: config.HeaderRulesConfig{Add: map[string]string{"X Bad": "v"}}, wantErr: true},
+		{name: "invalid set name", cfg: config.HeaderRulesConfig{Set: map[string]string{"X-Bad:": "v"}}, wantErr: true},
+		{name: "empty remove name", cfg: config.HeaderRulesConfig{Remove: []string{""}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewHeaderRules(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHeaderRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && rules.Empty() != tt.wantEmpty {
+				t.Errorf("Empty() = %v, want %v", rules.Empty(), tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestHeaderRules_Apply(t *testing.T) {
+	rules, err := NewHeaderRules(config.HeaderRulesConfig{
+		Add: map[string]string{
+			"x-forwarded-host":  "{host}",
+			"X-Forwarded-Proto": "{scheme}",
+			"Via":               "gateway",
+		},
+		Set: map[string]string{
+			"X-Real-IP":    "{client_ip}",
+			"X-Original":   "{method} {path}",
+			"X-Replaced":   "new",
+			"X-Unexpanded": "{unknown}",
+		},
+		Remove: []string{"x-internal-token", "X-Replaced"},
+	})
+	if err != nil {
+		t.Fatalf("NewHeaderRules() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/api/v1/users?page=1", nil)
+	req.RemoteAddr = "192.0.2.1:54321"
+	req.TLS = &tls.ConnectionState{}
+
+	header := http.Header{}
+	header.Set("X-Internal-Token", "secret")
+	header.Set("X-Replaced", "old")
+	header.Set("Via", "proxy")
+
+	rules.Apply(header, req)
+
+	want := http.Header{
+		"X-Forwarded-Host":  {"api.example.com"},
+		"X-Forwarded-Proto": {"https"},
+		"Via":               {"proxy", "gateway"},
+		"X-Real-Ip":         {"192.0.2.1"},
+		"X-Original":        {"GET /api/v1/users"},
+		"X-Replaced":        {"new"},
+		"X-Unexpanded":      {"{unknown}"},
+	}
+	if !reflect.DeepEqual(header, want) {
+		t.Errorf("Apply() header = %v, want %v", header, want)
+	}
+}
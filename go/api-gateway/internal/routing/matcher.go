@@ -0,0 +1,119 @@
+package routing
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"api-gateway/internal/config"
+)
+
+// RequestMatcher はパス以外にルートがマッチする条件（ホスト、ヘッダーとクエリパラメータ）
+// 同じ名前のヘッダーやクエリパラメータが複数ある場合は、いずれかの値が一致すればよい
+type RequestMatcher struct {
+	// Host はリクエストのホスト名（小文字。"*." から始まる場合はサブドメインにマッチする）
+	Host string
+	// Headers はリクエストヘッダーの名前（正規化済み）と値
+	Headers map[string]string
+	// Query はクエリパラメータの名前と値
+	Query map[string]string
+
+	// description はログや管理APIで表示する条件（config.MatchConfig.String の形式）
+	description string
+}
+
+// NewRequestMatcher は設定から条件を作成する
+func NewRequestMatcher(cfg config.MatchConfig) (*RequestMatcher, error) {
+	if cfg.Host == "" && len(cfg.Headers) == 0 && len(cfg.Query) == 0 {
+		return nil, fmt.Errorf("match requires host, headers or query")
+	}
+
+	host := strings.ToLower(cfg.Host)
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") || strings.ContainsAny(host, ":/ ") {
+		return nil, fmt.Errorf("invalid host to match: %q", cfg.Host)
+	}
+
+	m := &RequestMatcher{
+		Host:        host,
+		Headers:     make(map[string]string, len(cfg.Headers)),
+		Query:       make(map[string]string, len(cfg.Query)),
+		description: cfg.String(),
+	}
+	for name, value := range cfg.Headers {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("invalid header name to match: %q", name)
+		}
+		m.Headers[http.CanonicalHeaderKey(name)] = value
+	}
+	for name, value := range cfg.Query {
+		if name == "" {
+			return nil, fmt.Errorf("query parameter name to match must not be empty")
+		}
+		m.Query[name] = value
+	}
+	return m, nil
+}
+
+// Matches はリクエストが全ての条件を満たすか確認する
+// req が nil の場合（パスとメソッドのみで検索する場合）は条件を満たさないものとして扱う
+func (m *RequestMatcher) Matches(req *http.Request) bool {
+	if m == nil {
+		return true
+	}
+	if req == nil {
+		return false
+	}
+
+	if m.Host != "" && !matchHost(m.Host, req.Host) {
+		return false
+	}
+	for name, value := range m.Headers {
+		if !slices.Contains(req.Header.Values(name), value) {
+			return false
+		}
+	}
+	if len(m.Query) > 0 {
+		query := req.URL.Query()
+		for name, value := range m.Query {
+			if !slices.Contains(query[name], value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// conditions は条件の数を返す（条件の多いルートを優先するために使う）
+func (m *RequestMatcher) conditions() int {
+	if m == nil {
+		return 0
+	}
+	conditions := len(m.Headers) + len(m.Query)
+	if m.Host != "" {
+		conditions++
+	}
+	return conditions
+}
+
+// matchHost はリクエストの Host（ポートを含む場合がある）が pattern にマッチするか確認する
+// "*.example.com" は "api.example.com" や "a.b.example.com" にマッチし、"example.com" にはマッチしない
+func matchHost(pattern, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+	return host == pattern
+}
+
+// String は条件を "api.example.com [X-Version: 2] ?beta=true" の形式で返す（条件が無い場合は空）
+func (m *RequestMatcher) String() string {
+	if m == nil {
+		return ""
+	}
+	return m.description
+}
@@ -0,0 +1,231 @@
+package routing
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"api-gateway/internal/config"
+)
+
+func TestNewRequestMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.MatchConfig
+		wantErr  bool
+		wantDesc string
+	}{
+		{
+			name:     "ヘッダーとクエリ",
+			cfg:      config.MatchConfig{Headers: map[string]string{"x-version": "2"}, Query: map[string]string{"beta": "true"}},
+			wantDesc: "[X-Version: 2] ?beta=true",
+		},
+		{
+			name:     "ホスト",
+			cfg:      config.MatchConfig{Host: "API.example.com", Headers: map[string]string{"x-version": "2"}},
+			wantDesc: "api.example.com [X-Version: 2]",
+		},
+		{name: "条件が無い", cfg: config.MatchConfig{}, wantErr: true},
+		{name: "先頭以外のワイルドカードのホスト", cfg: config.MatchConfig{Host: "api.*.example.com"}, wantErr: true},
+		{name: "ポートを含むホスト", cfg: config.MatchConfig{Host: "api.example.com:8080"}, wantErr: true},
+		{name: "不正なヘッダー名", cfg: config.MatchConfig{Headers: map[string]string{"X Bad": "v"}}, wantErr: true},
+		{name: "空のクエリパラメータ名", cfg: config.MatchConfig{Query: map[string]string{"": "v"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewRequestMatcher(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRequestMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && m.String() != tt.wantDesc {
+				t.Errorf("String() = %q, want %q", m.String(), tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestRequestMatcher_Matches(t *testing.T) {
+	m, err := NewRequestMatcher(config.MatchConfig{
+		Headers: map[string]string{"X-Version": "2"},
+		Query:   map[string]string{"beta": "true"},
+	})
+	if err != nil {
+		t.Fatalf("NewRequestMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string][]string
+		want    bool
+	}{
+		{name: "全ての条件を満たす", target: "/?beta=true", headers: map[string][]string{"x-version": {"2"}}, want: true},
+		{name: "複数の値のいずれかが一致", target: "/?beta=false&beta=true", headers: map[string][]string{"X-Version": {"1", "2"}}, want: true},
+		{name: "ヘッダーの値が異なる", target: "/?beta=true", headers: map[string][]string{"X-Version": {"1"}}},
+		{name: "ヘッダーが無い", target: "/?beta=true"},
+		{name: "クエリパラメータが無い", target: "/", headers: map[string][]string{"X-Version": {"2"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			for name, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+			if got := m.Matches(req); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if m.Matches(nil) {
+		t.Error("Matches(nil) should be false for a matcher with conditions")
+	}
+	var none *RequestMatcher
+	if !none.Matches(nil) {
+		t.Error("nil matcher should match any request")
+	}
+}
+
+func TestRequestMatcher_MatchesHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		host    string
+		want    bool
+	}{
+		{name: "完全一致", pattern: "api.example.com", host: "api.example.com", want: true},
+		{name: "大文字とポートは無視する", pattern: "api.example.com", host: "API.example.com:8443", want: true},
+		{name: "異なるホスト", pattern: "api.example.com", host: "www.example.com"},
+		{name: "ワイルドカードはサブドメインにマッチする", pattern: "*.example.com", host: "a.b.example.com", want: true},
+		{name: "ワイルドカードは親ドメインにマッチしない", pattern: "*.example.com", host: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewRequestMatcher(config.MatchConfig{Host: tt.pattern})
+			if err != nil {
+				t.Fatalf("NewRequestMatcher() error = %v", err)
+			}
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = tt.host
+			if got := m.Matches(req); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_MatchRequest(t *testing.T) {
+	mustMatcher := func(cfg config.MatchConfig) *RequestMatcher {
+		m, err := NewRequestMatcher(cfg)
+		if err != nil {
+			t.Fatalf("NewRequestMatcher() error = %v", err)
+		}
+		return m
+	}
+	v1 := &Route{Path: "/api/users", Methods: []string{"GET", "POST"}, Backend: &Backend{URL: mustParseURL("https://v1.example.com")}}
+	v2 := &Route{
+		Path:    "/api/users",
+		Methods: []string{"GET"},
+		Backend: &Backend{URL: mustParseURL("https://v2.example.com")},
+		Matcher: mustMatcher(config.MatchConfig{Headers: map[string]string{"X-Version": "2"}}),
+	}
+	v2Beta := &Route{
+		Path:    "/api/users",
+		Methods: []string{"GET"},
+		Backend: &Backend{URL: mustParseURL("https://beta.example.com")},
+		Matcher: mustMatcher(config.MatchConfig{Headers: map[string]string{"X-Version": "2"}, Query: map[string]string{"beta": "true"}}),
+	}
+	beta := &Route{
+		Path:    "/api/orders",
+		Methods: []string{"GET"},
+		Backend: &Backend{URL: mustParseURL("https://beta.example.com")},
+		Matcher: mustMatcher(config.MatchConfig{Query: map[string]string{"beta": "true"}}),
+	}
+
+	router := NewRouter()
+	for _, route := range []*Route{v2, v1, v2Beta, beta} {
+		if err := router.AddRoute(route); err != nil {
+			t.Fatalf("AddRoute() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		version string
+		want    *Route
+		wantErr error
+	}{
+		{name: "条件の無いルート", method: "GET", target: "/api/users", want: v1},
+		{name: "ヘッダーの条件を満たすルート", method: "GET", target: "/api/users", version: "2", want: v2},
+		{name: "条件の多いルートを優先", method: "GET", target: "/api/users?beta=true", version: "2", want: v2Beta},
+		{name: "条件を満たすルートがメソッドを許可しない場合は他のルート", method: "POST", target: "/api/users", version: "2", want: v1},
+		{name: "クエリの条件を満たすルート", method: "GET", target: "/api/orders?beta=true", want: beta},
+		{name: "条件を満たすルートが無い", method: "GET", target: "/api/orders", wantErr: ErrRouteNotFound},
+		{name: "条件を満たすルートがメソッドを許可しない", method: "DELETE", target: "/api/users", version: "2", wantErr: ErrMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.version != "" {
+				req.Header.Set("X-Version", tt.version)
+			}
+
+			result, err := router.MatchRequest(req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("MatchRequest() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MatchRequest() error = %v", err)
+			}
+			if result.Route != tt.want {
+				t.Errorf("MatchRequest() route = %s, want %s", result.Route.Backend.URL, tt.want.Backend.URL)
+			}
+		})
+	}
+
+	// Match はパスとメソッドのみで検索し、条件を持つルートにはマッチしない
+	if result, err := router.Match("GET", "/api/users"); err != nil || result.Route != v1 {
+		t.Errorf("Match() = %v, %v, want route without match conditions", result, err)
+	}
+	if _, err := router.Match("GET", "/api/orders"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Match() error = %v, want ErrRouteNotFound", err)
+	}
+
+	// 許可されているメソッドは条件を満たす全てのルートから集める
+	var methodErr *MethodNotAllowedError
+	req := httptest.NewRequest("DELETE", "/api/users", nil)
+	req.Header.Set("X-Version", "2")
+	if _, err := router.MatchRequest(req); !errors.As(err, &methodErr) || methodErr.AllowHeader() != "GET, POST" {
+		t.Errorf("MatchRequest() error = %v, want Allow: GET, POST", err)
+	}
+
+	// 同じパスと条件のルートは追加できない
+	if err := router.AddRoute(&Route{Path: "/api/users", Matcher: v2.Matcher, Backend: v2.Backend}); err == nil {
+		t.Error("AddRoute() with the same path and match should return error")
+	}
+
+	// UpdateRoute は条件が一致するルートのみを置き換える
+	updated := &Route{Path: "/api/users", Methods: []string{"GET"}, Backend: &Backend{URL: mustParseURL("https://v2-updated.example.com")}, Matcher: v2.Matcher}
+	if err := router.UpdateRoute(updated); err != nil {
+		t.Fatalf("UpdateRoute() error = %v", err)
+	}
+	if got := len(router.GetAllRoutes()); got != 4 {
+		t.Errorf("GetAllRoutes() = %d routes, want 4", got)
+	}
+	req = httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("X-Version", "2")
+	if result, err := router.MatchRequest(req); err != nil || result.Route != updated {
+		t.Errorf("MatchRequest() after UpdateRoute = %v, %v, want updated route", result, err)
+	}
+}
@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"api-gateway/internal/config"
+)
+
+// データレジデンシーの検証で転送を拒否する理由
+var (
+	// ErrRegionRequired はリクエストのリージョンを決められない場合のエラー
+	ErrRegionRequired = errors.New("region is required")
+	// ErrRegionMismatch はリクエストが別のリージョンのデータにアクセスしようとした場合のエラー
+	ErrRegionMismatch = errors.New("request crosses regions")
+	// ErrRegionUnavailable はリージョンに対応するバックエンドが無い場合のエラー
+	ErrRegionUnavailable = errors.New("region is not available")
+)
+
+// Residency はリクエストをリージョンごとのバックエンドへ振り分け、リージョンをまたぐ転送を拒否する
+// リージョンはルートの固定値・JWTのクレーム（テナントの所在）・ヘッダーから決め、値が食い違う場合は拒否する
+type Residency struct {
+	// Region はルートを固定するリージョン（空の場合はクレームやヘッダーで決める）
+	Region string
+	// Claim はテナントのリージョンを表すJWTのクレーム名
+	Claim string
+	// Header はクライアントが指定するリージョンのヘッダー名
+	Header string
+	// Backends はリージョンごとのバックエンドのURL
+	Backends map[string]*url.URL
+}
+
+// NewResidency は設定からResidencyを作成する
+func NewResidency(cfg config.ResidencyConfig) (*Residency, error) {
+	if cfg.Region == "" && cfg.Claim == "" && cfg.Header == "" {
+		return nil, fmt.Errorf("one of region, claim or header is required")
+	}
+	if len(cfg.Backends) == 0 {
+		return nil, fmt.Errorf("backends is required")
+	}
+	if cfg.Header != "" && !validHeaderName(cfg.Header) {
+		return nil, fmt.Errorf("invalid header name: %q", cfg.Header)
+	}
+
+	backends := make(map[string]*url.URL, len(cfg.Backends))
+	for region, rawURL := range cfg.Backends {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("backend for region %q must be an absolute http(s) URL: %q", region, rawURL)
+		}
+		backends[region] = u
+	}
+	if _, ok := backends[cfg.Region]; cfg.Region != "" && !ok {
+		return nil, fmt.Errorf("no backend for pinned region %q", cfg.Region)
+	}
+
+	return &Residency{
+		Region:   cfg.Region,
+		Claim:    cfg.Claim,
+		Header:   http.CanonicalHeaderKey(cfg.Header),
+		Backends: backends,
+	}, nil
+}
+
+// Resolve はリクエストのリージョンとその転送先を返す
+// claimRegion はJWTのクレームの値（クレームが無い場合は空）
+// ルートの固定値・クレーム・ヘッダーのうち空でない値が全て一致する必要がある
+// クレームを設定したルートでトークンにクレームが無い場合は、クライアントが指定するヘッダーでリージョンを選ばせず拒否する
+func (r *Residency) Resolve(req *http.Request, claimRegion string) (string, *url.URL, error) {
+	if r.Claim != "" && claimRegion == "" {
+		return "", nil, fmt.Errorf("%w: token has no %s claim", ErrRegionRequired, r.Claim)
+	}
+
+	var headerRegion string
+	if r.Header != "" {
+		headerRegion = req.Header.Get(r.Header)
+	}
+
+	region := r.Region
+	sources := []struct{ name, value string }{
+		{"claim " + r.Claim, claimRegion},
+		{"header " + r.Header, headerRegion},
+	}
+
+	for _, source := range sources {
+		if source.value == "" {
+			continue
+		}
+		if region == "" {
+			region = source.value
+			continue
+		}
+		if source.value != region {
+			return "", nil, fmt.Errorf("%w: %s is %q but the request is pinned to %q", ErrRegionMismatch, source.name, source.value, region)
+		}
+	}
+
+	if region == "" {
+		return "", nil, ErrRegionRequired
+	}
+	backend, ok := r.Backends[region]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %q", ErrRegionUnavailable, region)
+	}
+	return region, backend, nil
+}
@@ -0,0 +1,145 @@
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"api-gateway/internal/config"
+)
+
+func TestNewResidency(t *testing.T) {
+	backends := map[string]string{"jp": "https://jp.example.com", "eu": "https://eu.example.com"}
+
+	tests := []struct {
+		name    string
+		cfg     config.ResidencyConfig
+		wantErr bool
+	}{
+		{name: "claim", cfg: config.ResidencyConfig{Claim: "region", Backends: backends}},
+		{name: "固定のリージョン", cfg: config.ResidencyConfig{Region: "eu", Backends: backends}},
+		{name: "リージョンの決め方が無い", cfg: config.ResidencyConfig{Backends: backends}, wantErr: true},
+		{name: "backends が無い", cfg: config.ResidencyConfig{Claim: "region"}, wantErr: true},
+		{name: "固定のリージョンのバックエンドが無い", cfg: config.ResidencyConfig{Region: "us", Backends: backends}, wantErr: true},
+		{name: "相対URL", cfg: config.ResidencyConfig{Claim: "region", Backends: map[string]string{"jp": "/jp"}}, wantErr: true},
+		{name: "不正なヘッダー名", cfg: config.ResidencyConfig{Header: "X Region", Backends: backends}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewResidency(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewResidency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResidency_Resolve(t *testing.T) {
+	backends := map[string]string{"jp": "https://jp.example.com", "eu": "https://eu.example.com"}
+
+	tests := []struct {
+		name        string
+		cfg         config.ResidencyConfig
+		claim       string
+		header      string
+		wantRegion  string
+		wantBackend string
+		wantErr     error
+	}{
+		{
+			name:        "クレームのリージョン",
+			cfg:         config.ResidencyConfig{Claim: "region", Header: "X-Data-Region", Backends: backends},
+			claim:       "jp",
+			wantRegion:  "jp",
+			wantBackend: "https://jp.example.com",
+		},
+		{
+			name:        "ヘッダーのリージョン",
+			cfg:         config.ResidencyConfig{Header: "X-Data-Region", Backends: backends},
+			header:      "eu",
+			wantRegion:  "eu",
+			wantBackend: "https://eu.example.com",
+		},
+		{
+			name:        "クレームとヘッダーが一致する",
+			cfg:         config.ResidencyConfig{Claim: "region", Header: "X-Data-Region", Backends: backends},
+			claim:       "eu",
+			header:      "eu",
+			wantRegion:  "eu",
+			wantBackend: "https://eu.example.com",
+		},
+		{
+			name:    "クレームとヘッダーが食い違う",
+			cfg:     config.ResidencyConfig{Claim: "region", Header: "X-Data-Region", Backends: backends},
+			claim:   "jp",
+			header:  "eu",
+			wantErr: ErrRegionMismatch,
+		},
+		{
+			name:    "固定のリージョンと食い違う",
+			cfg:     config.ResidencyConfig{Region: "eu", Claim: "region", Backends: backends},
+			claim:   "jp",
+			wantErr: ErrRegionMismatch,
+		},
+		{
+			name:        "固定のリージョン",
+			cfg:         config.ResidencyConfig{Region: "eu", Backends: backends},
+			wantRegion:  "eu",
+			wantBackend: "https://eu.example.com",
+		},
+		{
+			name:    "クレームが無い場合はヘッダーでリージョンを選ばせない",
+			cfg:     config.ResidencyConfig{Claim: "region", Header: "X-Data-Region", Backends: backends},
+			header:  "eu",
+			wantErr: ErrRegionRequired,
+		},
+		{
+			name:    "固定のリージョンでもクレームが無い場合は拒否する",
+			cfg:     config.ResidencyConfig{Region: "eu", Claim: "region", Backends: backends},
+			wantErr: ErrRegionRequired,
+		},
+		{
+			name:    "リージョンを決められない",
+			cfg:     config.ResidencyConfig{Claim: "region", Header: "X-Data-Region", Backends: backends},
+			wantErr: ErrRegionRequired,
+		},
+		{
+			name:    "バックエンドの無いリージョン",
+			cfg:     config.ResidencyConfig{Header: "X-Data-Region", Backends: backends},
+			header:  "us",
+			wantErr: ErrRegionUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			residency, err := NewResidency(tt.cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Data-Region", tt.header)
+			}
+
+			region, backend, err := residency.Resolve(req, tt.claim)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if region != tt.wantRegion {
+				t.Errorf("region = %q, want %q", region, tt.wantRegion)
+			}
+			if backend.String() != tt.wantBackend {
+				t.Errorf("backend = %q, want %q", backend, tt.wantBackend)
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package routing
 
 import (
+	"fmt"
+	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"api-gateway/internal/config"
+	"api-gateway/internal/contract"
+	"api-gateway/internal/transport"
 )
 
 // Route はルーティング情報を保持する
@@ -13,19 +20,96 @@ type Route struct {
 	Methods    []string
 	Backend    *Backend
 	Middleware []config.MiddlewareConfig
-	Priority   int
+	// Priority はパスが重なるルートの優先度（値が大きいほど優先する）
+	Priority int
+	Group    string
+	// Matcher はパス以外にリクエストが満たす条件（nilの場合はパスとメソッドのみでマッチする）
+	Matcher *RequestMatcher
+
+	// MaxRequestBody はリクエストボディの上限バイト数（0は無制限）
+	MaxRequestBody int64
+	// MaxResponseBody はレスポンスボディの上限バイト数（0は無制限）
+	MaxResponseBody int64
+	// Concurrency は同時にバックエンドへ転送するリクエスト数の制限（nilの場合は制限しない）
+	Concurrency *ConcurrencyLimiter
+
+	// RequestHeaders はバックエンドへ転送するリクエストヘッダーの変換ルール
+	RequestHeaders *HeaderRules
+	// ResponseHeaders はクライアントへ返すレスポンスヘッダーの変換ルール
+	ResponseHeaders *HeaderRules
+
+	// TrailingSlash は末尾スラッシュの扱い（空はルーターのデフォルト）
+	TrailingSlash TrailingSlashPolicy
+
+	// Residency はリージョンごとのバックエンドへの振り分け（nilの場合は Backend.URL へ転送する）
+	Residency *Residency
+
+	// Canary はカナリアのバックエンドへの振り分け（nilの場合は振り分けない）
+	// リスナーごとのルーターで同じ Canary を共有するため、管理APIで変更した割合は全てのリスナーに反映される
+	Canary *Canary
+
+	// ResponseMasker はレスポンスの個人情報のマスク（nilの場合はマスクしない）
+	ResponseMasker *transport.ResponseMasker
+
+	// RequestValidator はOpenAPIドキュメントによるリクエストの検証（nilの場合は検証しない）
+	RequestValidator *RequestValidator
+
+	// ResponseValidator はOpenAPIドキュメントによるレスポンスの検証（nilの場合は検証しない）
+	ResponseValidator *transport.ResponseValidator
+
+	// Journal はリクエストのメタデータをジャーナルへ記録するか
+	Journal bool
+
+	// FlagGate はフィーチャーフラグによる公開の切り替え（nilの場合は常に公開する）
+	FlagGate *FlagGate
+
+	// Stats はルートにマッチしたリクエストの統計（nilの場合は記録しない）
+	Stats *RouteStats
 }
 
 // Backend はバックエンドサービスの情報
 type Backend struct {
-	URL     *url.URL
-	Timeout time.Duration
+	URL      *url.URL
+	Timeout  time.Duration
+	Protocol transport.Protocol
+	// Service はサービスディスカバリのサービス名（空の場合は URL へ転送する）。URL のホストはインスタンスのアドレスで置き換える
+	Service string
+	// Pool はバックエンドへの接続プールの設定
+	Pool transport.ConnectionPool
+	// SignRequests は転送するリクエストに署名するか
+	SignRequests bool
+	// Retry は502/504の再試行の方針（nilの場合は再試行しない）
+	Retry *transport.RetryPolicy
+	// Credentials はバックエンド用のアクセストークンを取得する（nilの場合はクライアントの認証情報をそのまま転送する）
+	Credentials *transport.ClientCredentials
+	// AWSSigner は転送するリクエストにAWS SigV4で署名する（nilの場合は署名しない）
+	AWSSigner *transport.AWSSigner
+	// Shadow は移行先のバックエンドとのレスポンスの比較（nilの場合は比較しない）
+	Shadow *transport.ShadowCompare
+	// Mirror はリクエストの複製を送るミラーのバックエンド（nilの場合は送らない）
+	Mirror *transport.Mirror
+	// GRPC はgRPCのバックエンドへの転送の設定（nilの場合はHTTPで転送する）
+	GRPC *transport.GRPCOptions
+	// DecompressResponses はクライアントが受け付けない gzip のレスポンスを展開するか
+	DecompressResponses bool
+	// SOAP はJSONとSOAPの変換（nilの場合は変換しない）
+	SOAP *transport.SOAPAdapter
+	// Storage はオブジェクトストレージの配信（nilの場合はリクエストをそのまま転送する）
+	Storage *transport.ObjectStorage
+	// LongPoll はロングポーリングの中継（nilの場合は中継しない）
+	LongPoll *transport.LongPollOptions
+	// Static はバックエンドへ転送せずに返す固定のレスポンス（nilの場合は URL へ転送する）
+	Static *StaticResponse
+	// Aggregate は複数のバックエンドのレスポンスのまとめ（nilの場合は URL へ転送する）
+	Aggregate *transport.Aggregator
 }
 
 // MatchResult はルーティングマッチの結果
 type MatchResult struct {
 	Route  *Route
 	Params map[string]string // パスパラメータ（:id など）
+	// RedirectPath は末尾スラッシュのポリシーが redirect の場合のリダイレクト先のパス（不要な場合は空）
+	RedirectPath string
 }
 
 // NewRoute は新しいRouteを作成する
@@ -34,16 +118,670 @@ func NewRoute(cfg config.Route) (*Route, error) {
 	if err != nil {
 		return nil, err
 	}
+	var static *StaticResponse
+	if cfg.Response != nil {
+		// 固定のレスポンスを返すルートはバックエンドへ転送しないため、転送先の設定と併用できない
+		if cfg.Backend.URL != "" || cfg.Backend.Service != "" || cfg.Residency != nil || cfg.Canary != nil {
+			return nil, fmt.Errorf("response cannot be combined with backend, residency or canary")
+		}
+		static, err = NewStaticResponse(*cfg.Response)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	var aggregator *transport.Aggregator
+	if cfg.Aggregate != nil {
+		aggregator, err = newAggregator(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregate: %w", err)
+		}
+	}
+	if cfg.Backend.Service != "" && cfg.Residency != nil {
+		// リージョンごとのバックエンドは静的なURLで指定するため併用できない
+		return nil, fmt.Errorf("backend service cannot be combined with residency")
+	}
+
+	protocol, err := transport.ParseProtocol(cfg.Backend.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := newConnectionPool(cfg.Backend.ConnectionPool)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection_pool: %w", err)
+	}
+
+	if cfg.MaxRequestBody < 0 || cfg.MaxResponseBody < 0 {
+		return nil, fmt.Errorf("body size limits must not be negative")
+	}
+
+	var concurrency *ConcurrencyLimiter
+	switch {
+	case cfg.MaxConcurrentRequests < 0:
+		return nil, fmt.Errorf("max_concurrent_requests must not be negative")
+	case cfg.MaxConcurrentRequests > 0:
+		queue, err := newConcurrencyQueue(cfg.Path, cfg.ConcurrencyQueue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid concurrency_queue: %w", err)
+		}
+		concurrency = NewConcurrencyLimiter(cfg.MaxConcurrentRequests, queue)
+	case cfg.ConcurrencyQueue != nil:
+		return nil, fmt.Errorf("concurrency_queue requires max_concurrent_requests")
+	}
+
+	requestHeaders, err := NewHeaderRules(cfg.RequestHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request_headers: %w", err)
+	}
+	responseHeaders, err := NewHeaderRules(cfg.ResponseHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response_headers: %w", err)
+	}
+
+	trailingSlash, err := ParseTrailingSlashPolicy(cfg.TrailingSlash)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials *transport.ClientCredentials
+	if cfg.Backend.OAuth2 != nil {
+		credentials, err = newClientCredentials(*cfg.Backend.OAuth2)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oauth2: %w", err)
+		}
+	}
+
+	var awsSigner *transport.AWSSigner
+	if cfg.Backend.AWSSigV4 != nil {
+		// どちらも Authorization ヘッダーを設定するため併用できない
+		if cfg.Backend.OAuth2 != nil {
+			return nil, fmt.Errorf("aws_sigv4 and oauth2 cannot be used together")
+		}
+		awsSigner, err = NewAWSSigner(*cfg.Backend.AWSSigV4)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aws_sigv4: %w", err)
+		}
+	}
+
+	var shadow *transport.ShadowCompare
+	if cfg.Backend.Shadow != nil {
+		shadow, err = newShadowCompare(cfg.Path, *cfg.Backend.Shadow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shadow: %w", err)
+		}
+	}
+
+	var mirror *transport.Mirror
+	if cfg.Backend.Mirror != nil {
+		mirror, err = newMirror(cfg.Path, *cfg.Backend.Mirror)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mirror: %w", err)
+		}
+	}
+
+	var matcher *RequestMatcher
+	if cfg.Match != nil {
+		matcher, err = NewRequestMatcher(*cfg.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match: %w", err)
+		}
+	}
+
+	var residency *Residency
+	if cfg.Residency != nil {
+		residency, err = NewResidency(*cfg.Residency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid residency: %w", err)
+		}
+	}
+
+	var canary *Canary
+	if cfg.Canary != nil {
+		if cfg.Residency != nil {
+			return nil, fmt.Errorf("canary cannot be combined with residency")
+		}
+		canary, err = NewCanary(*cfg.Canary)
+		if err != nil {
+			return nil, fmt.Errorf("invalid canary: %w", err)
+		}
+	}
+
+	var masker *transport.ResponseMasker
+	if cfg.ResponseMasking != nil {
+		masker, err = newResponseMasker(cfg.Path, *cfg.ResponseMasking)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response_masking: %w", err)
+		}
+	}
+
+	var requestValidator *RequestValidator
+	var validator *transport.ResponseValidator
+	if cfg.Contract != nil {
+		spec, err := newContractSpec(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contract: %w", err)
+		}
+		if mode, _ := contract.ParseMode(cfg.Contract.Requests); mode != contract.ModeOff {
+			requestValidator = &RequestValidator{
+				Route:   cfg.Path,
+				Spec:    spec,
+				Enforce: mode == contract.ModeEnforce,
+				MaxBody: cfg.Contract.MaxBody,
+			}
+		}
+		if mode, _ := contract.ParseMode(cfg.Contract.Responses); mode != contract.ModeOff {
+			validator = &transport.ResponseValidator{
+				Route:   cfg.Path,
+				Spec:    spec,
+				Enforce: mode == contract.ModeEnforce,
+				MaxBody: cfg.Contract.MaxBody,
+			}
+		}
+	}
+
+	var grpc *transport.GRPCOptions
+	if cfg.Backend.GRPC != nil {
+		grpc, err = newGRPCOptions(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grpc: %w", err)
+		}
+	}
+
+	var soap *transport.SOAPAdapter
+	if cfg.Backend.SOAP != nil {
+		soap, err = newSOAPAdapter(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid soap: %w", err)
+		}
+	}
+
+	var storage *transport.ObjectStorage
+	if cfg.Backend.ObjectStorage != nil {
+		storage, err = newObjectStorage(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object_storage: %w", err)
+		}
+	}
+
+	var longPoll *transport.LongPollOptions
+	if cfg.Backend.LongPoll != nil {
+		longPoll, err = newLongPollOptions(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid long_poll: %w", err)
+		}
+	}
+
+	var flagGate *FlagGate
+	if cfg.EnabledWhen != "" {
+		flagGate, err = NewFlagGate(cfg)
+		if err != nil {
+			return nil, err
+		}
+	} else if cfg.WhenDisabled != nil {
+		return nil, fmt.Errorf("when_disabled requires enabled_when")
+	}
+
+	var retry *transport.RetryPolicy
+	if cfg.Backend.Retry.Attempts != 0 {
+		retry, err = newRetryPolicy(cfg.Path, cfg.Backend.Retry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry: %w", err)
+		}
+	}
 
 	return &Route{
 		Path:    cfg.Path,
 		Methods: cfg.Methods,
 		Backend: &Backend{
-			URL:     backendURL,
-			Timeout: cfg.Backend.Timeout,
+			URL:          backendURL,
+			Timeout:      cfg.Backend.Timeout,
+			Protocol:     protocol,
+			Service:      cfg.Backend.Service,
+			Pool:         pool,
+			SignRequests: cfg.Backend.SignRequests,
+			Retry:        retry,
+			Credentials:  credentials,
+			AWSSigner:    awsSigner,
+			Shadow:       shadow,
+			Mirror:       mirror,
+			GRPC:         grpc,
+			SOAP:         soap,
+			Storage:      storage,
+			LongPoll:     longPoll,
+			Static:       static,
+			Aggregate:    aggregator,
+
+			DecompressResponses: cfg.Backend.DecompressResponses,
 		},
 		Middleware: cfg.Middleware,
 		Priority:   cfg.Priority,
+		Group:      cfg.Group,
+		Matcher:    matcher,
+
+		MaxRequestBody:  cfg.MaxRequestBody,
+		MaxResponseBody: cfg.MaxResponseBody,
+		Concurrency:     concurrency,
+
+		RequestHeaders:  requestHeaders,
+		ResponseHeaders: responseHeaders,
+
+		TrailingSlash: trailingSlash,
+
+		Residency: residency,
+		Canary:    canary,
+
+		ResponseMasker:    masker,
+		RequestValidator:  requestValidator,
+		ResponseValidator: validator,
+
+		Journal: cfg.Journal,
+
+		FlagGate: flagGate,
+
+		Stats: &RouteStats{},
+	}, nil
+}
+
+// newConnectionPool は設定から接続プールの設定を作成する
+func newConnectionPool(cfg config.ConnectionPoolConfig) (transport.ConnectionPool, error) {
+	if cfg.MaxIdleConns < 0 || cfg.MaxConnsPerHost < 0 || cfg.IdleConnTimeout < 0 {
+		return transport.ConnectionPool{}, fmt.Errorf("max_idle_conns, max_conns_per_host and idle_conn_timeout must not be negative")
+	}
+	return transport.ConnectionPool{
+		MaxIdleConns:      cfg.MaxIdleConns,
+		MaxConnsPerHost:   cfg.MaxConnsPerHost,
+		IdleConnTimeout:   cfg.IdleConnTimeout,
+		DisableKeepAlives: cfg.DisableKeepAlives,
+	}, nil
+}
+
+// newConcurrencyQueue は concurrency_queue の設定から待ち行列の設定を作成する（設定が無い場合は待たせない）
+func newConcurrencyQueue(route string, cfg *config.ConcurrencyQueueConfig) (ConcurrencyQueue, error) {
+	if cfg == nil {
+		return ConcurrencyQueue{}, nil
+	}
+	if cfg.MaxDepth <= 0 {
+		return ConcurrencyQueue{}, fmt.Errorf("max_depth must be positive")
+	}
+	if cfg.MaxWait <= 0 {
+		return ConcurrencyQueue{}, fmt.Errorf("max_wait must be positive")
+	}
+	return ConcurrencyQueue{Route: route, Depth: cfg.MaxDepth, Timeout: cfg.MaxWait}, nil
+}
+
+// newRetryPolicy は設定から再試行の方針を作成する
+// 予算はルートの全リクエストで共有する
+func newRetryPolicy(path string, cfg config.RetryConfig) (*transport.RetryPolicy, error) {
+	budget := cfg.Budget
+	switch {
+	case cfg.Attempts <= 0:
+		return nil, fmt.Errorf("attempts must be positive")
+	case budget.Ratio < 0 || budget.Ratio > 1:
+		return nil, fmt.Errorf("budget ratio must be between 0 and 1")
+	case budget.MinRetries < 0:
+		return nil, fmt.Errorf("budget min_retries must not be negative")
+	case budget.Window < 0:
+		return nil, fmt.Errorf("budget window must not be negative")
+	}
+
+	ratio := budget.Ratio
+	if ratio == 0 {
+		ratio = transport.DefaultRetryBudgetRatio
+	}
+	minRetries := budget.MinRetries
+	if minRetries == 0 {
+		minRetries = transport.DefaultRetryBudgetMinRetries
+	}
+	return &transport.RetryPolicy{
+		Route:    path,
+		Attempts: cfg.Attempts,
+		Budget:   transport.NewRetryBudget(ratio, minRetries, budget.Window),
+	}, nil
+}
+
+// newClientCredentials は設定からバックエンド用のトークンの取得元を作成する
+// トークンはルートごとに取得してキャッシュする
+func newClientCredentials(cfg config.OAuth2ClientConfig) (*transport.ClientCredentials, error) {
+	tokenURL, err := url.Parse(cfg.TokenURL)
+	if err != nil || (tokenURL.Scheme != "http" && tokenURL.Scheme != "https") || tokenURL.Host == "" {
+		return nil, fmt.Errorf("token_url must be an absolute http(s) URL: %q", cfg.TokenURL)
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+	if cfg.RefreshBefore < 0 {
+		return nil, fmt.Errorf("refresh_before must not be negative")
+	}
+
+	return transport.NewClientCredentials(transport.ClientCredentialsConfig{
+		TokenURL:      cfg.TokenURL,
+		ClientID:      cfg.ClientID,
+		ClientSecret:  cfg.ClientSecret,
+		Scopes:        cfg.Scopes,
+		Audience:      cfg.Audience,
+		RefreshBefore: cfg.RefreshBefore,
+	}), nil
+}
+
+// NewAWSSigner は設定からAWS SigV4の署名を作成する
+func NewAWSSigner(cfg config.AWSSigV4Config) (*transport.AWSSigner, error) {
+	if cfg.Service == "" {
+		return nil, fmt.Errorf("service is required")
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("region is required")
+	}
+
+	var credentials transport.AWSCredentialsFunc
+	switch cfg.Credentials.Provider {
+	case "", "env":
+		credentials = transport.EnvAWSCredentials()
+	case "static":
+		if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+			return nil, fmt.Errorf("static credentials require access_key_id and secret_access_key")
+		}
+		credentials = transport.StaticAWSCredentials(transport.AWSCredentials{
+			AccessKeyID:     cfg.Credentials.AccessKeyID,
+			SecretAccessKey: cfg.Credentials.SecretAccessKey,
+			SessionToken:    cfg.Credentials.SessionToken,
+		})
+	default:
+		return nil, fmt.Errorf("unknown credentials provider: %q", cfg.Credentials.Provider)
+	}
+
+	return &transport.AWSSigner{
+		Service:     cfg.Service,
+		Region:      cfg.Region,
+		Credentials: credentials,
+	}, nil
+}
+
+// newShadowCompare は設定から移行先のバックエンドとの比較を作成する
+func newShadowCompare(path string, cfg config.ShadowConfig) (*transport.ShadowCompare, error) {
+	shadowURL, err := url.Parse(cfg.URL)
+	if err != nil || (shadowURL.Scheme != "http" && shadowURL.Scheme != "https") || shadowURL.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL: %q", cfg.URL)
+	}
+	if cfg.Timeout < 0 || cfg.MaxBody < 0 {
+		return nil, fmt.Errorf("timeout and max_body must not be negative")
+	}
+	for _, field := range cfg.IgnoreFields {
+		if field == "" || slices.Contains(strings.Split(field, "."), "") {
+			return nil, fmt.Errorf("invalid ignore_fields entry: %q", field)
+		}
+	}
+
+	methods := make([]string, len(cfg.Methods))
+	for i, method := range cfg.Methods {
+		methods[i] = strings.ToUpper(method)
+	}
+
+	return &transport.ShadowCompare{
+		Route:         path,
+		URL:           shadowURL,
+		Timeout:       cfg.Timeout,
+		Methods:       methods,
+		IgnoreHeaders: cfg.IgnoreHeaders,
+		IgnoreFields:  cfg.IgnoreFields,
+		MaxBody:       cfg.MaxBody,
+	}, nil
+}
+
+// newMirror は設定からミラーへのリクエストの複製を作成する
+func newMirror(path string, cfg config.MirrorConfig) (*transport.Mirror, error) {
+	mirrorURL, err := url.Parse(cfg.URL)
+	if err != nil || (mirrorURL.Scheme != "http" && mirrorURL.Scheme != "https") || mirrorURL.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL: %q", cfg.URL)
+	}
+	if cfg.Timeout < 0 || cfg.MaxBody < 0 || cfg.MaxInFlight < 0 {
+		return nil, fmt.Errorf("timeout, max_body and max_in_flight must not be negative")
+	}
+
+	methods := make([]string, len(cfg.Methods))
+	for i, method := range cfg.Methods {
+		methods[i] = strings.ToUpper(method)
+	}
+
+	return &transport.Mirror{
+		Route:       path,
+		URL:         mirrorURL,
+		Timeout:     cfg.Timeout,
+		Methods:     methods,
+		MaxBody:     cfg.MaxBody,
+		MaxInFlight: int64(cfg.MaxInFlight),
+	}, nil
+}
+
+// newResponseMasker は設定からレスポンスのマスクを作成する
+func newResponseMasker(path string, cfg config.ResponseMaskingConfig) (*transport.ResponseMasker, error) {
+	if len(cfg.Fields) == 0 && len(cfg.Patterns) == 0 {
+		return nil, fmt.Errorf("fields or patterns is required")
+	}
+	if cfg.MaxBody < 0 {
+		return nil, fmt.Errorf("max_body must not be negative")
+	}
+	for _, field := range cfg.Fields {
+		if field == "" || slices.Contains(strings.Split(field, "."), "") {
+			return nil, fmt.Errorf("invalid fields entry: %q", field)
+		}
+	}
+
+	patterns := make([]transport.MaskPattern, 0, len(cfg.Patterns))
+	for _, p := range cfg.Patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("pattern name is required")
+		}
+		pattern, err := transport.NewMaskPattern(p.Name, p.Regex)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return &transport.ResponseMasker{
+		Route:       path,
+		Fields:      cfg.Fields,
+		Patterns:    patterns,
+		Replacement: cfg.Replacement,
+		MaxBody:     cfg.MaxBody,
+	}, nil
+}
+
+// newContractSpec は設定を検証し、ルートのOpenAPIドキュメントを読み込む
+// ドキュメントは検証が off の場合も読み込み、設定の誤りをルートの読み込み時に検出する
+// バックエンドへ転送するリクエストとレスポンスを検証するため、固定のレスポンス・まとめたレスポンス・ストリーミングの中継は対象にできない
+func newContractSpec(cfg config.Route) (*contract.Spec, error) {
+	contractCfg := cfg.Contract
+	switch {
+	case contractCfg.Spec == "":
+		return nil, fmt.Errorf("spec is required")
+	case contractCfg.MaxBody < 0:
+		return nil, fmt.Errorf("max_body must be non-negative")
+	case cfg.Response != nil, cfg.Aggregate != nil, cfg.Backend.GRPC != nil, cfg.Backend.LongPoll != nil:
+		return nil, fmt.Errorf("contract cannot be used with response, aggregate, grpc or long_poll")
+	}
+	if _, err := contract.ParseMode(contractCfg.Requests); err != nil {
+		return nil, fmt.Errorf("invalid requests: %w", err)
+	}
+	if _, err := contract.ParseMode(contractCfg.Responses); err != nil {
+		return nil, fmt.Errorf("invalid responses: %w", err)
+	}
+	return contract.Load(contractCfg.Spec)
+}
+
+// newGRPCOptions は設定からgRPCのバックエンドへの転送の設定を作成する
+// ストリーミングのボディを読み込む機能（署名・再試行・シャドー比較・レスポンスの加工）は併用できない
+func newGRPCOptions(cfg config.Route) (*transport.GRPCOptions, error) {
+	backend := cfg.Backend
+	switch {
+	case backend.Protocol == string(transport.ProtocolHTTP1):
+		return nil, fmt.Errorf("grpc requires HTTP/2 (protocol h2 or h2c)")
+	case backend.SignRequests, backend.AWSSigV4 != nil:
+		return nil, fmt.Errorf("grpc cannot be used with sign_requests or aws_sigv4")
+	case backend.Retry.Attempts != 0, backend.Shadow != nil, backend.Mirror != nil:
+		return nil, fmt.Errorf("grpc cannot be used with retry, shadow or mirror")
+	case cfg.MaxResponseBody != 0, cfg.ResponseMasking != nil, backend.DecompressResponses:
+		return nil, fmt.Errorf("grpc cannot be used with max_response_body, response_masking or decompress_responses")
+	}
+
+	for method, timeout := range backend.GRPC.MethodTimeouts {
+		if _, _, ok := transport.ParseGRPCMethod(method); !ok {
+			return nil, fmt.Errorf("method_timeouts key must be /package.Service/Method or /package.Service/*: %q", method)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("method_timeouts for %s must be positive", method)
+		}
+	}
+
+	return &transport.GRPCOptions{MethodTimeouts: backend.GRPC.MethodTimeouts}, nil
+}
+
+// xmlNamePattern はSOAPのオペレーション名として使える要素名（接頭辞は付けない）
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// newSOAPAdapter は設定からSOAPのバックエンドとの変換を作成する
+// 移行先へ変換前のリクエストを送るシャドー比較と、gRPCは併用できない
+func newSOAPAdapter(cfg config.Route) (*transport.SOAPAdapter, error) {
+	soap := cfg.Backend.SOAP
+	switch {
+	case soap.Version != "" && soap.Version != transport.SOAP11 && soap.Version != transport.SOAP12:
+		return nil, fmt.Errorf("version must be 1.1 or 1.2: %q", soap.Version)
+	case soap.Operation == "" || soap.Namespace == "":
+		return nil, fmt.Errorf("operation and namespace are required")
+	case !xmlNamePattern.MatchString(soap.Operation):
+		return nil, fmt.Errorf("operation is not a valid XML element name: %q", soap.Operation)
+	case soap.MaxBody < 0:
+		return nil, fmt.Errorf("max_body must be non-negative")
+	case cfg.Backend.Shadow != nil, cfg.Backend.Mirror != nil, cfg.Backend.GRPC != nil:
+		return nil, fmt.Errorf("soap cannot be used with shadow, mirror or grpc")
+	}
+
+	adapter := &transport.SOAPAdapter{
+		Version:   soap.Version,
+		Operation: soap.Operation,
+		Namespace: soap.Namespace,
+		Action:    soap.Action,
+		MaxBody:   soap.MaxBody,
+	}
+	if soap.Template != "" {
+		tmpl, err := transport.NewSOAPTemplate(soap.Template)
+		if err != nil {
+			return nil, err
+		}
+		adapter.Template = tmpl
+	}
+	return adapter, nil
+}
+
+// newObjectStorage は設定からオブジェクトストレージの配信を作成する
+// キーはルートのパスのワイルドカード・パラメータ以降のため、パスにはワイルドカードかパラメータが必要
+func newObjectStorage(cfg config.Route) (*transport.ObjectStorage, error) {
+	storage := cfg.Backend.ObjectStorage
+	switch {
+	case cfg.Backend.SOAP != nil, cfg.Backend.GRPC != nil, cfg.Backend.Shadow != nil, cfg.Backend.Mirror != nil:
+		return nil, fmt.Errorf("object_storage cannot be used with soap, grpc, shadow or mirror")
+	case cfg.Backend.AWSSigV4 != nil && cfg.Backend.AWSSigV4.Service != "s3":
+		return nil, fmt.Errorf("object_storage requires aws_sigv4 service s3")
+	case strings.HasPrefix(storage.Prefix, "/"):
+		return nil, fmt.Errorf("prefix must not start with /: %q", storage.Prefix)
+	}
+
+	segments := SplitPath(cfg.Path)
+	static := slices.IndexFunc(segments, func(s string) bool {
+		_, param := ParamName(s)
+		return param || strings.HasPrefix(s, "*")
+	})
+	if static < 0 {
+		return nil, fmt.Errorf("path must contain a wildcard or parameter for the object key")
+	}
+
+	return &transport.ObjectStorage{
+		RoutePrefix:     JoinPath(segments[:static]),
+		KeyPrefix:       storage.Prefix,
+		SignedURLSecret: []byte(storage.SignedURLSecret),
+	}, nil
+}
+
+// newLongPollOptions は設定からロングポーリングの中継の設定を作成する
+// バックエンドへはストリーミングで接続するため、リクエスト・レスポンスのボディを扱う機能は併用できない
+func newLongPollOptions(cfg config.Route) (*transport.LongPollOptions, error) {
+	backend := cfg.Backend
+	longPoll := backend.LongPoll
+	switch {
+	case longPoll.Protocol != transport.LongPollSSE && longPoll.Protocol != transport.LongPollWebSocket:
+		return nil, fmt.Errorf("protocol must be sse or websocket: %q", longPoll.Protocol)
+	case longPoll.Timeout < 0:
+		return nil, fmt.Errorf("timeout must be non-negative")
+	case longPoll.MaxMessage < 0:
+		return nil, fmt.Errorf("max_message must be non-negative")
+	case backend.GRPC != nil, backend.SOAP != nil, backend.ObjectStorage != nil, backend.Shadow != nil, backend.Mirror != nil:
+		return nil, fmt.Errorf("long_poll cannot be used with grpc, soap, object_storage, shadow or mirror")
+	case backend.SignRequests, backend.AWSSigV4 != nil, backend.Retry.Attempts != 0:
+		return nil, fmt.Errorf("long_poll cannot be used with sign_requests, aws_sigv4 or retry")
+	case cfg.MaxResponseBody != 0, cfg.ResponseMasking != nil, backend.DecompressResponses:
+		return nil, fmt.Errorf("long_poll cannot be used with max_response_body, response_masking or decompress_responses")
+	}
+
+	return &transport.LongPollOptions{
+		Protocol:    longPoll.Protocol,
+		Timeout:     longPoll.Timeout,
+		ContentType: longPoll.ContentType,
+		CursorParam: longPoll.CursorParam,
+		MaxMessage:  longPoll.MaxMessage,
+	}, nil
+}
+
+// newAggregator は設定から複数のバックエンドのレスポンスのまとめを作成する
+// まとめたレスポンスを返すため、転送先とリクエストボディに関する設定は併用できない
+// URL の {name} はルートのパスのパラメータのみ参照できる
+func newAggregator(cfg config.Route) (*transport.Aggregator, error) {
+	aggregate := cfg.Aggregate
+	switch {
+	case cfg.Backend.URL != "", cfg.Backend.Service != "", cfg.Response != nil, cfg.Residency != nil, cfg.Canary != nil:
+		return nil, fmt.Errorf("aggregate cannot be combined with backend, response, residency or canary")
+	case slices.ContainsFunc(cfg.Methods, func(m string) bool { return m != http.MethodGet && m != http.MethodHead }):
+		return nil, fmt.Errorf("aggregate routes only support GET and HEAD")
+	case aggregate.Timeout < 0:
+		return nil, fmt.Errorf("timeout must be non-negative")
+	case len(aggregate.Backends) == 0:
+		return nil, fmt.Errorf("at least one backend is required")
+	}
+
+	params := make(map[string]bool)
+	for _, segment := range SplitPath(cfg.Path) {
+		if name, ok := ParamName(segment); ok {
+			params[name] = true
+		}
+	}
+
+	parts := make([]transport.AggregatePart, 0, len(aggregate.Backends))
+	keys := make(map[string]bool, len(aggregate.Backends))
+	for _, backend := range aggregate.Backends {
+		if backend.Key == "" {
+			return nil, fmt.Errorf("backend key is required")
+		}
+		if keys[backend.Key] {
+			return nil, fmt.Errorf("duplicate backend key: %s", backend.Key)
+		}
+		keys[backend.Key] = true
+
+		for _, m := range transport.AggregateParamPattern.FindAllStringSubmatch(backend.URL, -1) {
+			if !params[m[1]] {
+				return nil, fmt.Errorf("backend %s url references unknown path parameter: %s", backend.Key, m[1])
+			}
+		}
+		u, err := url.Parse(transport.AggregateParamPattern.ReplaceAllString(backend.URL, "x"))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("backend %s url must be an absolute http or https URL: %q", backend.Key, backend.URL)
+		}
+		parts = append(parts, transport.AggregatePart{Key: backend.Key, URL: backend.URL, Required: backend.Required})
+	}
+
+	return &transport.Aggregator{
+		Route:   cfg.Path,
+		Timeout: aggregate.Timeout,
+		Parts:   parts,
+		MaxBody: cfg.MaxResponseBody,
 	}, nil
 }
 
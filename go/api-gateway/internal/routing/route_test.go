@@ -0,0 +1,151 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"api-gateway/internal/config"
+)
+
+func TestNewRoute_Aggregate(t *testing.T) {
+	backends := []config.AggregateBackendConfig{
+		{Key: "user", URL: "http://user-service:8080/users/{id}", Required: true},
+		{Key: "orders", URL: "http://order-service:8080/orders?user={id}"},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.Route
+		wantErr bool
+	}{
+		{
+			name: "パスパラメータを参照する",
+			cfg:  config.Route{Path: "/bff/users/{id:[0-9]+}", Methods: []string{"GET"}, Aggregate: &config.AggregateConfig{Timeout: time.Second, Backends: backends}},
+		},
+		{
+			name:    "backend との併用",
+			cfg:     config.Route{Path: "/bff/users/:id", Backend: config.BackendConfig{URL: "http://user-service:8080"}, Aggregate: &config.AggregateConfig{Backends: backends}},
+			wantErr: true,
+		},
+		{
+			name:    "GET以外のメソッド",
+			cfg:     config.Route{Path: "/bff/users/:id", Methods: []string{"POST"}, Aggregate: &config.AggregateConfig{Backends: backends}},
+			wantErr: true,
+		},
+		{
+			name:    "バックエンドが無い",
+			cfg:     config.Route{Path: "/bff/users/:id", Aggregate: &config.AggregateConfig{}},
+			wantErr: true,
+		},
+		{
+			name: "キーの重複",
+			cfg: config.Route{Path: "/bff/users/:id", Aggregate: &config.AggregateConfig{Backends: []config.AggregateBackendConfig{
+				{Key: "user", URL: "http://a/users/{id}"}, {Key: "user", URL: "http://b/users/{id}"},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:    "存在しないパスパラメータ",
+			cfg:     config.Route{Path: "/bff/users/:user_id", Aggregate: &config.AggregateConfig{Backends: backends}},
+			wantErr: true,
+		},
+		{
+			name: "相対URL",
+			cfg: config.Route{Path: "/bff", Aggregate: &config.AggregateConfig{Backends: []config.AggregateBackendConfig{
+				{Key: "user", URL: "/users"},
+			}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := NewRoute(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(route.Backend.Aggregate.Parts) != len(tt.cfg.Aggregate.Backends) {
+				t.Errorf("Parts = %v", route.Backend.Aggregate.Parts)
+			}
+		})
+	}
+}
+
+func TestNewRoute_Contract(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(spec, []byte("openapi: 3.0.3\npaths: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backend := config.BackendConfig{URL: "http://user-service:8080"}
+
+	tests := []struct {
+		name          string
+		cfg           config.Route
+		wantErr       bool
+		wantValidator bool
+		wantEnforce   bool
+	}{
+		{
+			name:          "audit",
+			cfg:           config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec, Responses: "audit"}},
+			wantValidator: true,
+		},
+		{
+			name:          "enforce",
+			cfg:           config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec, Responses: "enforce"}},
+			wantValidator: true,
+			wantEnforce:   true,
+		},
+		{
+			name: "省略時は検証しない",
+			cfg:  config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec}},
+		},
+		{
+			name:    "offでもドキュメントを読み込む",
+			cfg:     config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec + ".missing"}},
+			wantErr: true,
+		},
+		{
+			name:    "不明なモード",
+			cfg:     config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec, Responses: "strict"}},
+			wantErr: true,
+		},
+		{
+			name: "リクエストのみ検証する",
+			cfg:  config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec, Requests: "enforce"}},
+		},
+		{
+			name:    "不明なリクエストのモード",
+			cfg:     config.Route{Path: "/users", Backend: backend, Contract: &config.ContractConfig{Spec: spec, Requests: "strict"}},
+			wantErr: true,
+		},
+		{
+			name:    "固定のレスポンスとの併用",
+			cfg:     config.Route{Path: "/users", Response: &config.StaticResponseConfig{Body: "{}"}, Contract: &config.ContractConfig{Spec: spec, Responses: "audit"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := NewRoute(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := route.ResponseValidator != nil; got != tt.wantValidator {
+				t.Fatalf("ResponseValidator = %v, want %v", route.ResponseValidator, tt.wantValidator)
+			}
+			if got := route.RequestValidator != nil; got != (tt.cfg.Contract.Requests != "") {
+				t.Errorf("RequestValidator = %v", route.RequestValidator)
+			}
+			if tt.wantValidator && route.ResponseValidator.Enforce != tt.wantEnforce {
+				t.Errorf("Enforce = %v, want %v", route.ResponseValidator.Enforce, tt.wantEnforce)
+			}
+		})
+	}
+}
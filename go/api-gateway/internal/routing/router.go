@@ -1,16 +1,58 @@
 package routing
 
 import (
+	"errors"
 	"fmt"
-	"sort"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
 
 	"api-gateway/internal/config"
-	"api-gateway/internal/errors"
 )
 
+// Match がルートを解決できなかった理由
+var (
+	// ErrRouteNotFound はパスに一致するルートが無い場合のエラー
+	ErrRouteNotFound = errors.New("no route found")
+	// ErrMethodNotAllowed はパスに一致するルートがメソッドを許可していない場合のエラー
+	// 許可されているメソッドは MethodNotAllowedError から取得する
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+// MethodNotAllowedError はルートがメソッドを許可していない場合のエラー
+// errors.Is(err, ErrMethodNotAllowed) で判定でき、errors.As で許可されているメソッドを取得できる
+type MethodNotAllowedError struct {
+	// Method はリクエストのメソッド
+	Method string
+	// Allowed はルートが許可しているメソッド
+	Allowed []string
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	return fmt.Sprintf("method %s not allowed", e.Method)
+}
+
+func (e *MethodNotAllowedError) Unwrap() error {
+	return ErrMethodNotAllowed
+}
+
+// AllowHeader はAllowヘッダーの値を返す
+func (e *MethodNotAllowedError) AllowHeader() string {
+	return strings.Join(e.Allowed, ", ")
+}
+
 // Router はルーティングを管理する
+// ルートの追加・削除はTrieのコピーに対して行い、完成したTrieに差し替える（コピーオンライト）
+// そのため起動後も管理APIや設定のリロードから、リクエストを処理しながらルートを変更できる
 type Router struct {
+	// mu は root と trailingSlash を保護する。変更は書き込みロックで直列化し、
+	// 検索は読み込みロックで root を取得した後、ロックを持たずに変更されないTrieをたどる
+	mu   sync.RWMutex
 	root *node
+	// trailingSlash はルートで指定が無い場合の末尾スラッシュの扱い
+	trailingSlash TrailingSlashPolicy
 }
 
 // NewRouter は新しいRouterを作成する
@@ -20,77 +62,274 @@ func NewRouter() *Router {
 	}
 }
 
+// SetTrailingSlashPolicy はルートで指定が無い場合の末尾スラッシュの扱いを設定する
+// 空文字の場合は TrailingSlashMerge として扱う
+func (r *Router) SetTrailingSlashPolicy(policy TrailingSlashPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trailingSlash = policy
+}
+
+// snapshot は現在のTrieのルートノードと末尾スラッシュの扱いを返す
+// 返したTrieは変更されないため、ロックを持たずにたどってよい
+func (r *Router) snapshot() (*node, TrailingSlashPolicy) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.root, r.trailingSlash
+}
+
 // AddRoute はルートを追加する
+// 既存のルートと衝突する場合（優先度と、パラメータ名以外のパターンと match の条件が同じ場合）は *RouteConflictError を返す
 func (r *Router) AddRoute(route *Route) error {
-	if route == nil {
-		return fmt.Errorf("route is nil")
+	if err := validateRoute(route); err != nil {
+		return err
 	}
 
-	if route.Path == "" {
-		return fmt.Errorf("route path is empty")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := checkConflicts(append(allRoutes(r.root), route)); err != nil {
+		return err
 	}
+	root, err := insertRoute(r.root, route)
+	if err != nil {
+		return err
+	}
+	r.root = root
+	return nil
+}
+
+// RemoveRoute はパスが一致するルートを削除する（match の条件が異なるルートも全て削除する）
+func (r *Router) RemoveRoute(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	root, err := removeRoute(r.root, path)
+	if err != nil {
+		return err
+	}
+	r.root = root
+	return nil
+}
 
-	segments := SplitPath(route.Path)
-	current := r.root
+// UpdateRoute はパスと match の条件が一致するルートを route に置き換える（ルートが無い場合はエラー）
+// 変更はルーター全体を作り直さず、パス上のノードのみを複製して行う
+func (r *Router) UpdateRoute(route *Route) error {
+	if err := validateRoute(route); err != nil {
+		return err
+	}
 
-	// パスの各セグメントに対してノードを作成または取得
-	for _, segment := range segments {
-		current = current.addChild(segment)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	root, err := removeRoutes(r.root, route.Path, func(existing *Route) bool {
+		return existing.Matcher.String() == route.Matcher.String()
+	})
+	if err != nil {
+		return err
 	}
+	if err := checkConflicts(append(allRoutes(root), route)); err != nil {
+		return err
+	}
+	if root, err = insertRoute(root, route); err != nil {
+		return err
+	}
+	r.root = root
+	return nil
+}
 
-	// ルートを設定
-	if current.route != nil {
-		return fmt.Errorf("route already exists for path: %s", route.Path)
+// ReplaceAll は全てのルートを routes に置き換える
+// いずれかのルートが不正な場合はエラーを返し、現在のルートを変更しない
+func (r *Router) ReplaceAll(routes []*Route) error {
+	root, err := buildTrie(routes)
+	if err != nil {
+		return err
 	}
 
-	current.route = route
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.root = root
+	return nil
+}
+
+// validateRoute は追加できるルートか検証する
+func validateRoute(route *Route) error {
+	if route == nil {
+		return fmt.Errorf("route is nil")
+	}
+	if route.Path == "" {
+		return fmt.Errorf("route path is empty")
+	}
 	return nil
 }
 
+// buildTrie は routes を持つ新しいTrieを作成し、ルートノードを返す
+func buildTrie(routes []*Route) (*node, error) {
+	for _, route := range routes {
+		if err := validateRoute(route); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkConflicts(routes); err != nil {
+		return nil, err
+	}
+
+	root := newNode("")
+	for _, route := range routes {
+		var err error
+		if root, err = insertRoute(root, route); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
 // Match はパスとメソッドにマッチするルートを検索する
+// パスにマッチするルートのうちメソッドを許可しているものから、優先度の高いルート、同じ優先度ではパスの具体的なルートを選ぶ
+// ルートが無い場合は ErrRouteNotFound、メソッドが許可されていない場合は *MethodNotAllowedError を返す
+// ヘッダーやクエリパラメータの条件（match）を持つルートにはマッチしない。リクエストを処理する場合は MatchRequest を使う
 func (r *Router) Match(method, path string) (*MatchResult, error) {
+	return r.match(method, path, nil)
+}
+
+// MatchRequest はリクエストのメソッド・パス・ヘッダー・クエリパラメータにマッチするルートを検索する
+// 同じパスのルートでは条件（match）を満たすルートのうち優先度の高いもの、同じ優先度では条件の多いものを優先する
+func (r *Router) MatchRequest(req *http.Request) (*MatchResult, error) {
+	return r.match(req.Method, req.URL.Path, req)
+}
+
+// match はルートを検索する（req が nil の場合は条件を持つルートを除く）
+func (r *Router) match(method, path string, req *http.Request) (*MatchResult, error) {
+	root, defaultPolicy := r.snapshot()
 	segments := SplitPath(path)
 	params := make(map[string]string)
 
-	route := r.findRoute(r.root, segments, params)
-	if route == nil {
-		return nil, errors.NewNotFoundError(fmt.Sprintf("no route found for path: %s", path))
+	var matches []pathMatch
+	r.findRoutes(root, segments, params, req, &matches)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w for path: %s", ErrRouteNotFound, path)
+	}
+
+	// メソッドを許可しているルートのうち最も優先するものを使う（許可しているルートが無い場合は最も優先するルート）
+	route, params, allowed := selectRoute(matches, func(route *Route) bool { return route.HasMethod(method) })
+	if !allowed {
+		route, params, _ = selectRoute(matches, func(*Route) bool { return true })
+	}
+
+	// 末尾スラッシュの有無がルートと異なる場合の扱い
+	policy := trailingSlashPolicy(route, defaultPolicy)
+	mismatch := hasTrailingSlash(path) != hasTrailingSlash(route.Path)
+	if mismatch && policy == TrailingSlashStrict {
+		return nil, fmt.Errorf("%w for path: %s", ErrRouteNotFound, path)
 	}
 
 	// HTTPメソッドのチェック
-	if !route.HasMethod(method) {
-		return nil, errors.NewError(405, "METHOD_NOT_ALLOWED", fmt.Sprintf("method %s not allowed", method))
+	if !allowed {
+		var candidates []*Route
+		for _, m := range matches {
+			candidates = append(candidates, m.routes...)
+		}
+		return nil, &MethodNotAllowedError{Method: method, Allowed: allowedMethods(candidates)}
 	}
 
-	return &MatchResult{
+	result := &MatchResult{
 		Route:  route,
 		Params: params,
-	}, nil
+	}
+	if mismatch && policy == TrailingSlashRedirect {
+		result.RedirectPath = canonicalPath(path, route.Path)
+	}
+	return result, nil
+}
+
+// trailingSlashPolicy はルートに適用する末尾スラッシュの扱いを返す
+// defaultPolicy はルーターに設定した扱い（空の場合は TrailingSlashMerge）
+func trailingSlashPolicy(route *Route, defaultPolicy TrailingSlashPolicy) TrailingSlashPolicy {
+	if route.TrailingSlash != "" {
+		return route.TrailingSlash
+	}
+	if defaultPolicy != "" {
+		return defaultPolicy
+	}
+	return TrailingSlashMerge
+}
+
+// allowedMethods は routes が許可しているメソッドを重複なく返す
+func allowedMethods(routes []*Route) []string {
+	var allowed []string
+	for _, route := range routes {
+		for _, method := range route.Methods {
+			if !slices.Contains(allowed, method) {
+				allowed = append(allowed, method)
+			}
+		}
+	}
+	return allowed
+}
+
+// pathMatch はパスにマッチしたノードのルートとパスパラメータ
+type pathMatch struct {
+	// routes はリクエストが条件（match）を満たすルート（優先度の高い順、同じ優先度は条件の多い順）
+	routes []*Route
+	params map[string]string
+}
+
+// selectRoute は matches から accept が true を返すルートのうち最も優先するものを選ぶ（無い場合は false）
+// 優先度の高いルートを優先し、同じ優先度ではパスの具体的なルート（matches の先頭に近いもの）を優先する
+func selectRoute(matches []pathMatch, accept func(*Route) bool) (*Route, map[string]string, bool) {
+	var selected *Route
+	var params map[string]string
+	for _, m := range matches {
+		for _, route := range m.routes {
+			if !accept(route) {
+				continue
+			}
+			if selected == nil || route.Priority > selected.Priority {
+				selected, params = route, m.params
+			}
+			// m.routes は優先度の高い順のため、同じノードの残りのルートは選ばれない
+			break
+		}
+	}
+	return selected, params, selected != nil
 }
 
-// findRoute は再帰的にルートを検索する
-func (r *Router) findRoute(current *node, segments []string, params map[string]string) *Route {
+// findRoutes は再帰的にパスにマッチする全てのノードを検索し、リクエストが条件（match）を満たすルートを matches に追加する
+// ノードは 静的なセグメント > 正規表現 > パラメータ > ワイルドカード の順にたどるため、matches はパスの具体的な順になる
+func (r *Router) findRoutes(current *node, segments []string, params map[string]string, req *http.Request, matches *[]pathMatch) {
 	// すべてのセグメントを処理した場合
 	if len(segments) == 0 {
-		return current.route
+		var routes []*Route
+		for _, route := range current.routes {
+			if route.Matcher.Matches(req) {
+				routes = append(routes, route)
+			}
+		}
+		if len(routes) > 0 {
+			*matches = append(*matches, pathMatch{routes: routes, params: maps.Clone(params)})
+		}
+		return
 	}
 
 	segment := segments[0]
 	remaining := segments[1:]
 
-	// マッチする子ノードを検索
-	child, found := current.findMatchingChild(segment)
-	if !found {
-		return nil
-	}
+	for child := range current.matchingChildren(segment) {
+		// パラメータノードの場合、パラメータを記録
+		isParam := child.nodeType == paramNode || child.nodeType == regexNode
+		if isParam {
+			params[child.paramName] = segment
+		}
 
-	// パラメータノードの場合、パラメータを記録
-	if child.nodeType == paramNode {
-		params[child.paramName] = segment
-	}
+		// ** は残りの全てのセグメントにマッチする
+		if child.segment == catchAllSegment {
+			r.findRoutes(child, nil, params, req, matches)
+			continue
+		}
 
-	// 再帰的に次のセグメントを処理
-	return r.findRoute(child, remaining, params)
+		// 再帰的に次のセグメントを処理
+		r.findRoutes(child, remaining, params, req, matches)
+		if isParam {
+			delete(params, child.paramName)
+		}
+	}
 }
 
 // LoadFromConfig は設定ファイルからルートを読み込む
@@ -99,42 +338,75 @@ func (r *Router) LoadFromConfig(cfg *config.RoutingFileConfig) error {
 		return fmt.Errorf("routing config is nil")
 	}
 
-	// 優先度でソート（昇順）
-	routes := make([]config.Route, len(cfg.Routes))
-	copy(routes, cfg.Routes)
-	sort.Slice(routes, func(i, j int) bool {
-		return routes[i].Priority < routes[j].Priority
-	})
-
-	// ルートを登録
-	for _, routeCfg := range routes {
+	routes := make([]*Route, 0, len(cfg.Routes))
+	for _, routeCfg := range cfg.Routes {
 		route, err := NewRoute(routeCfg)
 		if err != nil {
 			return fmt.Errorf("failed to create route for %s: %w", routeCfg.Path, err)
 		}
+		routes = append(routes, route)
+	}
 
-		if err := r.AddRoute(route); err != nil {
-			return fmt.Errorf("failed to add route %s: %w", routeCfg.Path, err)
+	// ルートを登録（全てのルートを追加できた場合のみ差し替える）
+	// マッチするルートは優先度で決まるため、登録する順序は結果に影響しない
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	root := r.root
+	if err := checkConflicts(append(allRoutes(root), routes...)); err != nil {
+		return err
+	}
+	for _, route := range routes {
+		var err error
+		if root, err = insertRoute(root, route); err != nil {
+			return fmt.Errorf("failed to add route %s: %w", route.Path, err)
 		}
 	}
+	r.root = root
 
 	return nil
 }
 
+// Subset は groups のいずれかに属するルートのみを持つRouterを返す（groups が空の場合は全てのルート）
+// middleware を指定していないルートには defaults を適用する。末尾スラッシュの扱いは引き継ぐ
+func (r *Router) Subset(groups []string, defaults []config.MiddlewareConfig) (*Router, error) {
+	_, policy := r.snapshot()
+	subset := NewRouter()
+	subset.SetTrailingSlashPolicy(policy)
+
+	for _, route := range r.GetAllRoutes() {
+		if len(groups) > 0 && !slices.Contains(groups, route.Group) {
+			continue
+		}
+		if route.Middleware == nil && len(defaults) > 0 {
+			copied := *route
+			copied.Middleware = defaults
+			route = &copied
+		}
+		if err := subset.AddRoute(route); err != nil {
+			return nil, err
+		}
+	}
+	return subset, nil
+}
+
 // GetAllRoutes はすべてのルートを取得する（デバッグ用）
 func (r *Router) GetAllRoutes() []*Route {
+	root, _ := r.snapshot()
+	return allRoutes(root)
+}
+
+// allRoutes は root 以下の全てのルートを返す
+func allRoutes(root *node) []*Route {
 	var routes []*Route
-	r.collectRoutes(r.root, &routes)
+	collectRoutes(root, &routes)
 	return routes
 }
 
 // collectRoutes は再帰的にすべてのルートを収集する
-func (r *Router) collectRoutes(current *node, routes *[]*Route) {
-	if current.route != nil {
-		*routes = append(*routes, current.route)
-	}
+func collectRoutes(current *node, routes *[]*Route) {
+	*routes = append(*routes, current.routes...)
 
 	for _, child := range current.children {
-		r.collectRoutes(child, routes)
+		collectRoutes(child, routes)
 	}
 }
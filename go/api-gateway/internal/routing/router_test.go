@@ -1,7 +1,11 @@
 package routing
 
 import (
+	"errors"
+	"fmt"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -116,6 +120,16 @@ func TestMatch(t *testing.T) {
 			Methods: []string{"GET"},
 			Backend: &Backend{URL: mustParseURL("https://localhost:8080")},
 		},
+		{
+			Path:    "/static/*",
+			Methods: []string{"GET"},
+			Backend: &Backend{URL: mustParseURL("https://assets.com")},
+		},
+		{
+			Path:    "/files/**",
+			Methods: []string{"GET"},
+			Backend: &Backend{URL: mustParseURL("https://files.com")},
+		},
 	}
 
 	for _, route := range routes {
@@ -175,6 +189,34 @@ func TestMatch(t *testing.T) {
 			path:    "/api/v1/nonexistent",
 			wantErr: true,
 		},
+		{
+			name:       "wildcard matches one segment",
+			method:     "GET",
+			path:       "/static/app.css",
+			wantErr:    false,
+			wantPath:   "/static/*",
+			wantParams: map[string]string{},
+		},
+		{
+			name:    "wildcard does not match nested segments",
+			method:  "GET",
+			path:    "/static/css/app.css",
+			wantErr: true,
+		},
+		{
+			name:       "catch-all matches nested segments",
+			method:     "GET",
+			path:       "/files/a/b/c",
+			wantErr:    false,
+			wantPath:   "/files/**",
+			wantParams: map[string]string{},
+		},
+		{
+			name:    "catch-all requires a segment",
+			method:  "GET",
+			path:    "/files",
+			wantErr: true,
+		},
 		{
 			name:    "method not allowed",
 			method:  "DELETE",
@@ -224,6 +266,171 @@ func TestMatch(t *testing.T) {
 	}
 }
 
+func TestMatch_Errors(t *testing.T) {
+	router := NewRouter()
+	router.SetTrailingSlashPolicy(TrailingSlashStrict)
+	if err := router.AddRoute(&Route{
+		Path:    "/api/v1/users",
+		Methods: []string{"GET", "POST"},
+		Backend: &Backend{URL: mustParseURL("https://user-service.com")},
+	}); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantErr     error
+		wantAllowed []string
+	}{
+		{
+			name:    "ルートが無い",
+			method:  "GET",
+			path:    "/api/v1/orders",
+			wantErr: ErrRouteNotFound,
+		},
+		{
+			name:    "末尾スラッシュが異なる（strict）",
+			method:  "GET",
+			path:    "/api/v1/users/",
+			wantErr: ErrRouteNotFound,
+		},
+		{
+			name:        "メソッドが許可されていない",
+			method:      "DELETE",
+			path:        "/api/v1/users",
+			wantErr:     ErrMethodNotAllowed,
+			wantAllowed: []string{"GET", "POST"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := router.Match(tt.method, tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Match() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var methodErr *MethodNotAllowedError
+			if !errors.As(err, &methodErr) {
+				if tt.wantAllowed != nil {
+					t.Fatalf("Match() error = %v, want *MethodNotAllowedError", err)
+				}
+				return
+			}
+			if fmt.Sprint(methodErr.Allowed) != fmt.Sprint(tt.wantAllowed) {
+				t.Errorf("Allowed = %v, want %v", methodErr.Allowed, tt.wantAllowed)
+			}
+			if got := methodErr.AllowHeader(); got != "GET, POST" {
+				t.Errorf("AllowHeader() = %q, want %q", got, "GET, POST")
+			}
+		})
+	}
+}
+
+func TestMatch_RegexSegments(t *testing.T) {
+	router := NewRouter()
+	for _, path := range []string{"/users/me", "/users/{id:[0-9]+}", "/users/{id:[0-9]+}/posts", "/users/:name/posts", "/orders/{orderId:[0-9]+}"} {
+		if err := router.AddRoute(&Route{Path: path, Backend: &Backend{URL: mustParseURL("https://example.com")}}); err != nil {
+			t.Fatalf("failed to add route %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantRoute  string
+		wantParams map[string]string
+	}{
+		{name: "静的ノードを優先", path: "/users/me", wantRoute: "/users/me", wantParams: map[string]string{}},
+		{name: "正規表現にマッチ", path: "/users/42", wantRoute: "/users/{id:[0-9]+}", wantParams: map[string]string{"id": "42"}},
+		{name: "正規表現をパラメータより優先", path: "/users/42/posts", wantRoute: "/users/{id:[0-9]+}/posts", wantParams: map[string]string{"id": "42"}},
+		{name: "正規表現にマッチしない場合はパラメータ", path: "/users/alice/posts", wantRoute: "/users/:name/posts", wantParams: map[string]string{"name": "alice"}},
+		{name: "静的ノードの先にルートが無い場合はパラメータ", path: "/users/me/posts", wantRoute: "/users/:name/posts", wantParams: map[string]string{"name": "me"}},
+		{name: "不正なIDは404", path: "/orders/abc"},
+		{name: "セグメントの一部のみのマッチは404", path: "/orders/12abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := router.Match("GET", tt.path)
+			if tt.wantRoute == "" {
+				if !errors.Is(err, ErrRouteNotFound) {
+					t.Errorf("Match(%s) error = %v, want ErrRouteNotFound", tt.path, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Match(%s) error = %v", tt.path, err)
+			}
+			if result.Route.Path != tt.wantRoute {
+				t.Errorf("Match(%s) route = %s, want %s", tt.path, result.Route.Path, tt.wantRoute)
+			}
+			if fmt.Sprint(result.Params) != fmt.Sprint(tt.wantParams) {
+				t.Errorf("Match(%s) params = %v, want %v", tt.path, result.Params, tt.wantParams)
+			}
+		})
+	}
+
+	if err := router.AddRoute(&Route{Path: "/items/{id:[0-9}", Backend: &Backend{URL: mustParseURL("https://example.com")}}); err == nil {
+		t.Error("AddRoute() with invalid regex should return error")
+	}
+}
+
+func TestMatch_Priority(t *testing.T) {
+	routes := []*Route{
+		{Path: "/files/readme", Methods: []string{"GET"}},
+		{Path: "/files/:name", Methods: []string{"GET", "DELETE"}, Priority: 10},
+		{Path: "/users/me", Methods: []string{"GET"}},
+		{Path: "/users/{id:[0-9]+}", Methods: []string{"GET"}},
+		{Path: "/users/:name", Methods: []string{"GET", "DELETE"}, Priority: -1},
+		{Path: "/items/:id", Methods: []string{"GET"}, Priority: 5},
+		{Path: "/items/:id", Methods: []string{"GET", "POST"}, Priority: 1},
+	}
+	router := NewRouter()
+	for _, route := range routes {
+		route.Backend = &Backend{URL: mustParseURL("https://example.com")}
+		if err := router.AddRoute(route); err != nil {
+			t.Fatalf("failed to add route %s: %v", route.Path, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   *Route
+	}{
+		{name: "優先度の高いパラメータを静的なセグメントより優先", method: "GET", path: "/files/readme", want: routes[1]},
+		{name: "同じ優先度では静的なセグメントを優先", method: "GET", path: "/users/me", want: routes[2]},
+		{name: "同じ優先度では正規表現を優先", method: "GET", path: "/users/42", want: routes[3]},
+		{name: "優先度の低いルートのみがマッチ", method: "GET", path: "/users/alice", want: routes[4]},
+		{name: "メソッドを許可しているルートから選ぶ", method: "DELETE", path: "/users/me", want: routes[4]},
+		{name: "同じパスは優先度の高いルート", method: "GET", path: "/items/1", want: routes[5]},
+		{name: "同じパスで優先度の高いルートがメソッドを許可しない", method: "POST", path: "/items/1", want: routes[6]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := router.Match(tt.method, tt.path)
+			if err != nil {
+				t.Fatalf("Match(%s %s) error = %v", tt.method, tt.path, err)
+			}
+			if result.Route != tt.want {
+				t.Errorf("Match(%s %s) = %s (priority %d), want %s (priority %d)",
+					tt.method, tt.path, result.Route.Path, result.Route.Priority, tt.want.Path, tt.want.Priority)
+			}
+		})
+	}
+
+	// 許可されているメソッドはパスにマッチする全てのルートから集める
+	var methodErr *MethodNotAllowedError
+	if _, err := router.Match("PUT", "/users/me"); !errors.As(err, &methodErr) || methodErr.AllowHeader() != "GET, DELETE" {
+		t.Errorf("Match(PUT /users/me) error = %v, want Allow: GET, DELETE", err)
+	}
+}
+
 func TestLoadFromConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -262,111 +469,930 @@ func TestLoadFromConfig(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "invalid backend URL",
+			name: "unsupported backend protocol",
 			config: &config.RoutingFileConfig{
 				Routes: []config.Route{
 					{
 						Path:    "/api/v1/test",
 						Methods: []string{"GET"},
 						Backend: config.BackendConfig{
-							URL:     "://invalid-url",
-							Timeout: 30 * time.Second,
+							URL:      "https://example.com",
+							Protocol: "spdy",
 						},
 					},
 				},
 			},
 			wantErr: true,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			router := NewRouter()
-			err := router.LoadFromConfig(tt.config)
-
-			if (err != nil) != tt.wantErr {
-				t.Errorf("LoadFromConfig() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
-func TestGetAllRoutes(t *testing.T) {
-	router := NewRouter()
-
-	routes := []*Route{
 		{
-			Path:    "/api/v1/users",
-			Methods: []string{"GET"},
-			Backend: &Backend{URL: mustParseURL("https://example.com")},
+			name: "negative body size limit",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:           "/api/v1/test",
+						Methods:        []string{"GET"},
+						Backend:        config.BackendConfig{URL: "https://example.com"},
+						MaxRequestBody: -1,
+					},
+				},
+			},
+			wantErr: true,
 		},
 		{
-			Path:    "/api/v1/orders",
-			Methods: []string{"GET"},
-			Backend: &Backend{URL: mustParseURL("https://example.com")},
+			name: "invalid header rule",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:            "/api/v1/test",
+						Methods:         []string{"GET"},
+						Backend:         config.BackendConfig{URL: "https://example.com"},
+						ResponseHeaders: config.HeaderRulesConfig{Remove: []string{"Bad Header"}},
+					},
+				},
+			},
+			wantErr: true,
 		},
-	}
-
-	for _, route := range routes {
-		if err := router.AddRoute(route); err != nil {
-			t.Fatalf("failed to add route: %v", err)
-		}
-	}
-
-	allRoutes := router.GetAllRoutes()
-	if len(allRoutes) != len(routes) {
-		t.Errorf("GetAllRoutes() returned %d routes, want %d", len(allRoutes), len(routes))
-	}
-}
-
-func TestRouteHasMethod(t *testing.T) {
-	tests := []struct {
-		name    string
-		route   *Route
-		method  string
-		want    bool
-	}{
 		{
-			name: "method exists",
-			route: &Route{
-				Methods: []string{"GET", "POST"},
+			name: "retry with budget",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL: "https://example.com",
+							Retry: config.RetryConfig{
+								Attempts: 2,
+								Budget:   config.RetryBudgetConfig{Ratio: 0.2, MinRetries: 5, Window: time.Minute},
+							},
+						},
+					},
+				},
 			},
-			method: "GET",
-			want:   true,
+			wantErr: false,
 		},
 		{
-			name: "method does not exist",
-			route: &Route{
-				Methods: []string{"GET", "POST"},
+			name: "negative retry attempts",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:   "https://example.com",
+							Retry: config.RetryConfig{Attempts: -1},
+						},
+					},
+				},
 			},
-			method: "DELETE",
-			want:   false,
+			wantErr: true,
 		},
 		{
-			name: "empty methods allows all",
-			route: &Route{
-				Methods: []string{},
+			name: "retry budget ratio out of range",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL: "https://example.com",
+							Retry: config.RetryConfig{
+								Attempts: 1,
+								Budget:   config.RetryBudgetConfig{Ratio: 1.5},
+							},
+						},
+					},
+				},
 			},
-			method: "GET",
-			want:   true,
+			wantErr: true,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := tt.route.HasMethod(tt.method)
-			if got != tt.want {
-				t.Errorf("HasMethod() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-// Helper function
-func mustParseURL(rawURL string) *url.URL {
-	u, err := url.Parse(rawURL)
-	if err != nil {
-		panic(err)
-	}
-	return u
+		{
+			name: "oauth2 without client_id",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:    "https://example.com",
+							OAuth2: &config.OAuth2ClientConfig{TokenURL: "https://idp.example.com/token"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "oauth2 with relative token_url",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:    "https://example.com",
+							OAuth2: &config.OAuth2ClientConfig{TokenURL: "/token", ClientID: "gateway"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "aws_sigv4 without region",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:      "https://example.com",
+							AWSSigV4: &config.AWSSigV4Config{Service: "execute-api"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "aws_sigv4 static credentials without secret",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL: "https://example.com",
+							AWSSigV4: &config.AWSSigV4Config{
+								Service:     "es",
+								Region:      "ap-northeast-1",
+								Credentials: config.AWSCredentialsConfig{Provider: "static", AccessKeyID: "AKIDEXAMPLE"},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "aws_sigv4 with oauth2",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:      "https://example.com",
+							OAuth2:   &config.OAuth2ClientConfig{TokenURL: "https://idp.example.com/token", ClientID: "gateway"},
+							AWSSigV4: &config.AWSSigV4Config{Service: "execute-api", Region: "us-east-1"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shadow with relative url",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:    "https://example.com",
+							Shadow: &config.ShadowConfig{URL: "/v2"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shadow with empty ignore_fields segment",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:    "https://example.com",
+							Shadow: &config.ShadowConfig{URL: "https://v2.example.com", IgnoreFields: []string{"meta..id"}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "response_masking with unknown builtin pattern",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:            "/api/v1/test",
+						Methods:         []string{"GET"},
+						Backend:         config.BackendConfig{URL: "https://example.com"},
+						ResponseMasking: &config.ResponseMaskingConfig{Patterns: []config.MaskPatternConfig{{Name: "passport"}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "response_masking with invalid regex",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:            "/api/v1/test",
+						Methods:         []string{"GET"},
+						Backend:         config.BackendConfig{URL: "https://example.com"},
+						ResponseMasking: &config.ResponseMaskingConfig{Patterns: []config.MaskPatternConfig{{Name: "id", Regex: "[0-9"}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "response_masking without fields and patterns",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:            "/api/v1/test",
+						Methods:         []string{"GET"},
+						Backend:         config.BackendConfig{URL: "https://example.com"},
+						ResponseMasking: &config.ResponseMaskingConfig{Replacement: "***"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "grpc over http1",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/inventory.v1.InventoryService/*",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL:      "http://inventory.example.com",
+							Protocol: "http1",
+							GRPC:     &config.GRPCConfig{},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "grpc with retry",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/inventory.v1.InventoryService/*",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL:   "http://inventory.example.com",
+							Retry: config.RetryConfig{Attempts: 2},
+							GRPC:  &config.GRPCConfig{},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "grpc with invalid method_timeouts key",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/inventory.v1.InventoryService/*",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL: "http://inventory.example.com",
+							GRPC: &config.GRPCConfig{MethodTimeouts: map[string]time.Duration{
+								"InventoryService.GetStock": time.Second,
+							}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "soap backend",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/quotes",
+						Methods: []string{"GET", "POST"},
+						Backend: config.BackendConfig{
+							URL:  "https://legacy.example.com/QuoteService.asmx",
+							SOAP: &config.SOAPConfig{Operation: "GetQuote", Namespace: "urn:quotes", Template: `<GetQuote>{{xml .Params.symbol}}</GetQuote>`},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "soap without namespace",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/quotes",
+						Methods: []string{"GET", "POST"},
+						Backend: config.BackendConfig{
+							URL:  "https://legacy.example.com/QuoteService.asmx",
+							SOAP: &config.SOAPConfig{Operation: "GetQuote"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "soap with invalid version",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/quotes",
+						Methods: []string{"GET", "POST"},
+						Backend: config.BackendConfig{
+							URL:  "https://legacy.example.com/QuoteService.asmx",
+							SOAP: &config.SOAPConfig{Version: "2.0", Operation: "GetQuote", Namespace: "urn:quotes"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "soap with invalid template",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/quotes",
+						Methods: []string{"GET", "POST"},
+						Backend: config.BackendConfig{
+							URL:  "https://legacy.example.com/QuoteService.asmx",
+							SOAP: &config.SOAPConfig{Operation: "GetQuote", Namespace: "urn:quotes", Template: "{{.Params"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "object storage",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/downloads/:tenant/*",
+						Methods: []string{"GET", "HEAD"},
+						Backend: config.BackendConfig{
+							URL:           "https://assets.s3.ap-northeast-1.amazonaws.com",
+							ObjectStorage: &config.ObjectStorageConfig{Prefix: "downloads/", SignedURLSecret: "secret"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "object storage without wildcard",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/downloads/latest.zip",
+						Methods: []string{"GET", "HEAD"},
+						Backend: config.BackendConfig{
+							URL:           "https://assets.s3.ap-northeast-1.amazonaws.com",
+							ObjectStorage: &config.ObjectStorageConfig{},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "object storage with non-s3 signer",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/downloads/*",
+						Methods: []string{"GET", "HEAD"},
+						Backend: config.BackendConfig{
+							URL:           "https://assets.s3.ap-northeast-1.amazonaws.com",
+							ObjectStorage: &config.ObjectStorageConfig{},
+							AWSSigV4:      &config.AWSSigV4Config{Service: "execute-api", Region: "ap-northeast-1"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "long poll",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/notifications/poll",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:      "http://notification-service:8080/stream",
+							LongPoll: &config.LongPollConfig{Protocol: "sse", Timeout: 25 * time.Second},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "long poll with unknown protocol",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/notifications/poll",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:      "http://notification-service:8080/stream",
+							LongPoll: &config.LongPollConfig{Protocol: "mqtt"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "long poll with retry",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/notifications/poll",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:      "http://notification-service:8080/stream",
+							LongPoll: &config.LongPollConfig{Protocol: "websocket"},
+							Retry:    config.RetryConfig{Attempts: 2},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "feature flag with fallback backend",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v2/checkout",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL: "http://checkout-service:8080",
+						},
+						EnabledWhen: "flags.new_checkout",
+						WhenDisabled: &config.WhenDisabledConfig{
+							Backend: &config.BackendConfig{URL: "http://legacy-checkout:8080"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "feature flag with invalid expression",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v2/checkout",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL: "http://checkout-service:8080",
+						},
+						EnabledWhen: "new_checkout",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "feature flag with status and backend",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v2/checkout",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL: "http://checkout-service:8080",
+						},
+						EnabledWhen: "!flags.legacy",
+						WhenDisabled: &config.WhenDisabledConfig{
+							Status:  503,
+							Backend: &config.BackendConfig{URL: "http://legacy-checkout:8080"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "when_disabled without enabled_when",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v2/checkout",
+						Methods: []string{"POST"},
+						Backend: config.BackendConfig{
+							URL: "http://checkout-service:8080",
+						},
+						WhenDisabled: &config.WhenDisabledConfig{Status: 503},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid backend URL",
+			config: &config.RoutingFileConfig{
+				Routes: []config.Route{
+					{
+						Path:    "/api/v1/test",
+						Methods: []string{"GET"},
+						Backend: config.BackendConfig{
+							URL:     "://invalid-url",
+							Timeout: 30 * time.Second,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			err := router.LoadFromConfig(tt.config)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadFromConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetAllRoutes(t *testing.T) {
+	router := NewRouter()
+
+	routes := []*Route{
+		{
+			Path:    "/api/v1/users",
+			Methods: []string{"GET"},
+			Backend: &Backend{URL: mustParseURL("https://example.com")},
+		},
+		{
+			Path:    "/api/v1/orders",
+			Methods: []string{"GET"},
+			Backend: &Backend{URL: mustParseURL("https://example.com")},
+		},
+	}
+
+	for _, route := range routes {
+		if err := router.AddRoute(route); err != nil {
+			t.Fatalf("failed to add route: %v", err)
+		}
+	}
+
+	allRoutes := router.GetAllRoutes()
+	if len(allRoutes) != len(routes) {
+		t.Errorf("GetAllRoutes() returned %d routes, want %d", len(allRoutes), len(routes))
+	}
+}
+
+func TestRouterSubset(t *testing.T) {
+	router := NewRouter()
+	router.SetTrailingSlashPolicy("strict")
+
+	routes := []*Route{
+		{
+			Path:    "/api/v1/users",
+			Methods: []string{"GET"},
+			Group:   "public",
+			Backend: &Backend{URL: mustParseURL("https://example.com")},
+		},
+		{
+			Path:       "/api/v1/orders",
+			Methods:    []string{"GET"},
+			Group:      "public",
+			Middleware: []config.MiddlewareConfig{{Type: "jwt"}},
+			Backend:    &Backend{URL: mustParseURL("https://example.com")},
+		},
+		{
+			Path:    "/internal/jobs",
+			Methods: []string{"POST"},
+			Group:   "internal",
+			Backend: &Backend{URL: mustParseURL("https://example.com")},
+		},
+	}
+	for _, route := range routes {
+		if err := router.AddRoute(route); err != nil {
+			t.Fatalf("failed to add route: %v", err)
+		}
+	}
+
+	defaults := []config.MiddlewareConfig{{Type: "cors"}}
+	subset, err := router.Subset([]string{"public"}, defaults)
+	if err != nil {
+		t.Fatalf("Subset() error = %v", err)
+	}
+
+	if got := len(subset.GetAllRoutes()); got != 2 {
+		t.Errorf("Subset() returned %d routes, want 2", got)
+	}
+	if _, err := subset.Match("POST", "/internal/jobs"); err == nil {
+		t.Error("route outside the groups should not match")
+	}
+
+	users, err := subset.Match("GET", "/api/v1/users")
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if len(users.Route.Middleware) != 1 || users.Route.Middleware[0].Type != "cors" {
+		t.Errorf("route without middleware should use defaults, got %v", users.Route.Middleware)
+	}
+	if routes[0].Middleware != nil {
+		t.Error("Subset() should not modify the original route")
+	}
+
+	orders, err := subset.Match("GET", "/api/v1/orders")
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if len(orders.Route.Middleware) != 1 || orders.Route.Middleware[0].Type != "jwt" {
+		t.Errorf("route with middleware should keep it, got %v", orders.Route.Middleware)
+	}
+
+	if _, err := subset.Match("GET", "/api/v1/users/"); err == nil {
+		t.Error("Subset() should keep the trailing slash policy")
+	}
+
+	all, err := router.Subset(nil, nil)
+	if err != nil {
+		t.Fatalf("Subset() error = %v", err)
+	}
+	if got := len(all.GetAllRoutes()); got != len(routes) {
+		t.Errorf("Subset(nil) returned %d routes, want %d", got, len(routes))
+	}
+}
+
+func TestRouteHasMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   *Route
+		method  string
+		want    bool
+	}{
+		{
+			name: "method exists",
+			route: &Route{
+				Methods: []string{"GET", "POST"},
+			},
+			method: "GET",
+			want:   true,
+		},
+		{
+			name: "method does not exist",
+			route: &Route{
+				Methods: []string{"GET", "POST"},
+			},
+			method: "DELETE",
+			want:   false,
+		},
+		{
+			name: "empty methods allows all",
+			route: &Route{
+				Methods: []string{},
+			},
+			method: "GET",
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.route.HasMethod(tt.method)
+			if got != tt.want {
+				t.Errorf("HasMethod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveRoute(t *testing.T) {
+	router := NewRouter()
+	for _, path := range []string{"/api/v1/users", "/api/v1/users/:id", "/api/v1/orders"} {
+		if err := router.AddRoute(&Route{Path: path, Backend: &Backend{URL: mustParseURL("https://example.com")}}); err != nil {
+			t.Fatalf("failed to add route: %v", err)
+		}
+	}
+
+	if err := router.RemoveRoute("/api/v1/users/:id"); err != nil {
+		t.Fatalf("RemoveRoute() error = %v", err)
+	}
+	if _, err := router.Match("GET", "/api/v1/users/123"); err == nil {
+		t.Error("removed route should not match")
+	}
+	for _, path := range []string{"/api/v1/users", "/api/v1/orders"} {
+		if _, err := router.Match("GET", path); err != nil {
+			t.Errorf("Match(%s) error = %v", path, err)
+		}
+	}
+
+	if err := router.RemoveRoute("/api/v1/unknown"); err == nil {
+		t.Error("RemoveRoute() of unknown path should return error")
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	router := NewRouter()
+	if err := router.AddRoute(&Route{Path: "/api/v1/users", Backend: &Backend{URL: mustParseURL("https://example.com")}}); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+
+	// 不正なルートを含む場合は現在のルートを変更しない
+	err := router.ReplaceAll([]*Route{
+		{Path: "/api/v2/users", Backend: &Backend{URL: mustParseURL("https://example.com")}},
+		{Path: "/api/v2/users", Backend: &Backend{URL: mustParseURL("https://example.com")}},
+	})
+	if err == nil {
+		t.Fatal("ReplaceAll() with duplicate paths should return error")
+	}
+	if _, err := router.Match("GET", "/api/v1/users"); err != nil {
+		t.Errorf("routes should be unchanged after failed ReplaceAll: %v", err)
+	}
+
+	if err := router.ReplaceAll([]*Route{{Path: "/api/v2/users", Backend: &Backend{URL: mustParseURL("https://example.com")}}}); err != nil {
+		t.Fatalf("ReplaceAll() error = %v", err)
+	}
+	if _, err := router.Match("GET", "/api/v1/users"); err == nil {
+		t.Error("old route should not match after ReplaceAll")
+	}
+	if _, err := router.Match("GET", "/api/v2/users"); err != nil {
+		t.Errorf("Match() error = %v", err)
+	}
+}
+
+func TestUpdateRoute(t *testing.T) {
+	router := NewRouter()
+	if err := router.AddRoute(&Route{Path: "/api/v1/users/:id", Methods: []string{"GET"}, Backend: &Backend{URL: mustParseURL("https://v1.example.com")}}); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+
+	updated := &Route{Path: "/api/v1/users/:id", Methods: []string{"GET", "PUT"}, Backend: &Backend{URL: mustParseURL("https://v2.example.com")}}
+	if err := router.UpdateRoute(updated); err != nil {
+		t.Fatalf("UpdateRoute() error = %v", err)
+	}
+	result, err := router.Match("PUT", "/api/v1/users/123")
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if result.Route != updated || result.Params["id"] != "123" {
+		t.Errorf("Match() = %+v, want updated route", result)
+	}
+
+	if err := router.UpdateRoute(&Route{Path: "/api/v1/orders", Backend: &Backend{URL: mustParseURL("https://example.com")}}); err == nil {
+		t.Error("UpdateRoute() of unknown path should return error")
+	}
+	if err := router.UpdateRoute(nil); err == nil {
+		t.Error("UpdateRoute(nil) should return error")
+	}
+}
+
+func TestAddRoute_CopyOnWrite(t *testing.T) {
+	router := NewRouter()
+	if err := router.AddRoute(&Route{Path: "/api/v1/users", Backend: &Backend{URL: mustParseURL("https://example.com")}}); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+	before, _ := router.snapshot()
+
+	if err := router.AddRoute(&Route{Path: "/api/v1/users/:id", Backend: &Backend{URL: mustParseURL("https://example.com")}}); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+
+	// 追加前に取得したTrieは変更されない
+	var routes []*Route
+	collectRoutes(before, &routes)
+	if len(routes) != 1 {
+		t.Errorf("snapshot has %d routes, want 1", len(routes))
+	}
+	if got := len(router.GetAllRoutes()); got != 2 {
+		t.Errorf("GetAllRoutes() returned %d routes, want 2", got)
+	}
+}
+
+// go test -race で、リクエストの処理中にルートを変更してもデータ競合が無いことを確認する
+func TestRouter_ConcurrentMutation(t *testing.T) {
+	router := NewRouter()
+	stable := &Route{Path: "/api/v1/users/:id", Backend: &Backend{URL: mustParseURL("https://example.com")}}
+	if err := router.AddRoute(stable); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := router.Match("GET", "/api/v1/users/123"); err != nil {
+					t.Errorf("stable route should always match: %v", err)
+					return
+				}
+				router.GetAllRoutes()
+			}
+		}()
+	}
+
+	for i := range 100 {
+		path := fmt.Sprintf("/api/v1/items/%d", i)
+		if err := router.AddRoute(&Route{Path: path, Backend: &Backend{URL: mustParseURL("https://example.com")}}); err != nil {
+			t.Errorf("AddRoute() error = %v", err)
+		}
+		if i%2 == 0 {
+			if err := router.RemoveRoute(path); err != nil {
+				t.Errorf("RemoveRoute() error = %v", err)
+			}
+		}
+		if i%10 == 0 {
+			updated := *stable
+			if err := router.UpdateRoute(&updated); err != nil {
+				t.Errorf("UpdateRoute() error = %v", err)
+			}
+		}
+		if i%25 == 0 {
+			if err := router.ReplaceAll([]*Route{stable}); err != nil {
+				t.Errorf("ReplaceAll() error = %v", err)
+			}
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// Helper function
+func mustParseURL(rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// FuzzRouterMatch は任意のルートとリクエストのパスで Match がパニックせず、
+// 一致したルートまたは型付きのエラーを返すことを確認する
+// go test -fuzz FuzzRouterMatch ./internal/routing/
+func FuzzRouterMatch(f *testing.F) {
+	f.Add("/api/v1/users/:id", "GET", "/api/v1/users/123")
+	f.Add("/static/*", "GET", "/static/css/app.css")
+	f.Add("/files/**", "POST", "/files/a/b/c/")
+	f.Add("/:a/:a/:a", "DELETE", "//x//")
+	f.Add("/", "", "")
+	f.Add("/a", "GET", strings.Repeat("/a", 10000))
+
+	f.Fuzz(func(t *testing.T, routePath, method, path string) {
+		router := NewRouter()
+		routes := []string{"/api/v1/users", "/api/v1/users/:id", "/static/*", "/files/**", routePath}
+		for _, p := range routes {
+			// 重複や不正なパスの追加エラーは無視し、追加できたルートで検索する
+			_ = router.AddRoute(&Route{
+				Path:    p,
+				Methods: []string{"GET", "POST"},
+				Backend: &Backend{URL: mustParseURL("https://example.com"), Timeout: time.Second},
+			})
+		}
+
+		start := time.Now()
+		result, err := router.Match(method, path)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Match(%q, %q) took %v", method, path, elapsed)
+		}
+
+		if err != nil {
+			if !errors.Is(err, ErrRouteNotFound) && !errors.Is(err, ErrMethodNotAllowed) {
+				t.Errorf("Match(%q, %q) error = %v, want ErrRouteNotFound or ErrMethodNotAllowed", method, path, err)
+			}
+			return
+		}
+		if result == nil || result.Route == nil {
+			t.Fatalf("Match(%q, %q) returned no route without error", method, path)
+		}
+		if !result.Route.HasMethod(method) {
+			t.Errorf("Match(%q, %q) matched route %s without the method", method, path, result.Route.Path)
+		}
+	})
 }
@@ -0,0 +1,86 @@
+package routing
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"api-gateway/internal/config"
+)
+
+// StaticResponse はバックエンドへ転送せずにゲートウェイが返す固定のレスポンス
+// ボディはルートの読み込み時に確定し、リクエストごとにファイルを読まない
+type StaticResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// NewStaticResponse は設定からStaticResponseを作成する
+// file はルートの読み込み時に読み込むため、内容を変更した場合はルートをリロードする
+func NewStaticResponse(cfg config.StaticResponseConfig) (*StaticResponse, error) {
+	status := cfg.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status < http.StatusOK || status > 599 {
+		return nil, fmt.Errorf("status must be between 200 and 599: %d", status)
+	}
+
+	var body []byte
+	switch {
+	case cfg.Body != "" && cfg.File != "":
+		return nil, fmt.Errorf("body and file cannot be used together")
+	case cfg.File != "":
+		data, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		body = data
+	default:
+		body = []byte(cfg.Body)
+	}
+	if len(body) > 0 && !bodyAllowed(status) {
+		return nil, fmt.Errorf("status %d cannot have a body", status)
+	}
+
+	header := make(http.Header, len(cfg.Headers)+1)
+	for name, value := range cfg.Headers {
+		header.Set(name, value)
+	}
+	// Content-Length はボディから決めるため、設定された値は使わない
+	header.Del("Content-Length")
+	if header.Get("Content-Type") == "" && len(body) > 0 {
+		contentType := mime.TypeByExtension(filepath.Ext(cfg.File))
+		if contentType == "" {
+			contentType = http.DetectContentType(body)
+		}
+		header.Set("Content-Type", contentType)
+	}
+
+	return &StaticResponse{Status: status, Header: header, Body: body}, nil
+}
+
+// ServeHTTP はレスポンスを書き込む（HEADではボディを省略する）
+func (s *StaticResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 後段のヘッダーの変換が値を追加しても共有するヘッダーを変更しないよう、値はコピーする
+	for name, values := range s.Header {
+		w.Header()[name] = slices.Clone(values)
+	}
+	if bodyAllowed(s.Status) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.Body)))
+	}
+	w.WriteHeader(s.Status)
+	if r.Method != http.MethodHead && len(s.Body) > 0 {
+		w.Write(s.Body)
+	}
+}
+
+// bodyAllowed はステータスのレスポンスがボディを持てるか返す
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
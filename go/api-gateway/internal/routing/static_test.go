@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"api-gateway/internal/config"
+)
+
+func TestNewStaticResponse(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "maintenance.html")
+	if err := os.WriteFile(page, []byte("<h1>maintenance</h1>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		cfg             config.StaticResponseConfig
+		wantErr         bool
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "ボディとContent-Typeの推定",
+			cfg:             config.StaticResponseConfig{Body: "User-agent: *\nDisallow: /\n"},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "User-agent: *\nDisallow: /\n",
+		},
+		{
+			name:            "ファイルの拡張子からContent-Typeを決める",
+			cfg:             config.StaticResponseConfig{Status: http.StatusServiceUnavailable, File: page},
+			wantStatus:      http.StatusServiceUnavailable,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<h1>maintenance</h1>",
+		},
+		{
+			name:            "設定したContent-Typeを優先する",
+			cfg:             config.StaticResponseConfig{Headers: map[string]string{"content-type": "application/json"}, Body: `{"id":1}`},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"id":1}`,
+		},
+		{name: "ボディの無い204", cfg: config.StaticResponseConfig{Status: http.StatusNoContent}, wantStatus: http.StatusNoContent},
+		{name: "bodyとfileの併用", cfg: config.StaticResponseConfig{Body: "a", File: page}, wantErr: true},
+		{name: "存在しないファイル", cfg: config.StaticResponseConfig{File: filepath.Join(dir, "missing.html")}, wantErr: true},
+		{name: "範囲外のステータス", cfg: config.StaticResponseConfig{Status: 101}, wantErr: true},
+		{name: "204にボディ", cfg: config.StaticResponseConfig{Status: http.StatusNoContent, Body: "a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static, err := NewStaticResponse(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStaticResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if static.Status != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", static.Status, tt.wantStatus)
+			}
+			if got := static.Header.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if string(static.Body) != tt.wantBody {
+				t.Errorf("Body = %q, want %q", static.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestStaticResponse_ServeHTTP(t *testing.T) {
+	static, err := NewStaticResponse(config.StaticResponseConfig{
+		Headers: map[string]string{"Cache-Control": "max-age=86400"},
+		Body:    "User-agent: *\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		t.Run(method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			static.ServeHTTP(rec, httptest.NewRequest(method, "/robots.txt", nil))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "max-age=86400" {
+				t.Errorf("Cache-Control = %q", got)
+			}
+			if got := rec.Header().Get("Content-Length"); got != "14" {
+				t.Errorf("Content-Length = %q, want 14", got)
+			}
+			wantBody := "User-agent: *\n"
+			if method == http.MethodHead {
+				wantBody = ""
+			}
+			if rec.Body.String() != wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), wantBody)
+			}
+		})
+	}
+
+	// 書き込んだヘッダーを変更しても次のレスポンスに影響しない
+	rec := httptest.NewRecorder()
+	static.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	rec.Header().Add("Cache-Control", "public")
+	if got := static.Header.Values("Cache-Control"); len(got) != 1 {
+		t.Errorf("shared header modified: %v", got)
+	}
+}
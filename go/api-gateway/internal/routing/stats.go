@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RouteStats はルートにマッチしたリクエストの統計
+// リスナーごとのルーターで同じ RouteStats を共有するため、全てのリスナーのリクエストを数える
+// ルーティング設定の再読み込みでルートを作り直すと 0 に戻る
+type RouteStats struct {
+	hits atomic.Int64
+	// lastHit は最後にマッチした時刻（UnixNano。0はまだマッチしていない）
+	lastHit atomic.Int64
+}
+
+// RecordHit はルートにマッチしたリクエストを記録する（nilの場合は何もしない）
+func (s *RouteStats) RecordHit() {
+	if s == nil {
+		return
+	}
+	s.hits.Add(1)
+	s.lastHit.Store(time.Now().UnixNano())
+}
+
+// Hits はルートにマッチしたリクエスト数を返す
+func (s *RouteStats) Hits() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.hits.Load()
+}
+
+// LastHit は最後にマッチした時刻を返す（まだマッチしていない場合は false）
+func (s *RouteStats) LastHit() (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	nanos := s.lastHit.Load()
+	if nanos == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
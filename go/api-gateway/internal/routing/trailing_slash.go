@@ -0,0 +1,46 @@
+package routing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrailingSlashPolicy はリクエストパスとルートのパスで末尾スラッシュの有無が異なる場合の扱い
+type TrailingSlashPolicy string
+
+const (
+	// TrailingSlashMerge は末尾スラッシュの有無を区別せずにマッチさせる（デフォルト）
+	TrailingSlashMerge TrailingSlashPolicy = "merge"
+	// TrailingSlashStrict は末尾スラッシュの有無が異なる場合はマッチさせない（404）
+	TrailingSlashStrict TrailingSlashPolicy = "strict"
+	// TrailingSlashRedirect はルートのパスの形式（正規形）へリダイレクトする
+	TrailingSlashRedirect TrailingSlashPolicy = "redirect"
+)
+
+// ParseTrailingSlashPolicy は設定値をTrailingSlashPolicyに変換する
+// 空文字はルーターのデフォルトを使うことを表す
+func ParseTrailingSlashPolicy(s string) (TrailingSlashPolicy, error) {
+	switch p := TrailingSlashPolicy(s); p {
+	case "", TrailingSlashMerge, TrailingSlashStrict, TrailingSlashRedirect:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported trailing_slash policy: %s", s)
+	}
+}
+
+// hasTrailingSlash はルートパス以外で末尾がスラッシュか確認する
+func hasTrailingSlash(path string) bool {
+	return len(path) > 1 && strings.HasSuffix(path, "/")
+}
+
+// canonicalPath はリクエストパスの末尾スラッシュをルートのパスの形式に揃える
+func canonicalPath(path, routePath string) string {
+	trimmed := strings.TrimRight(path, "/")
+	if hasTrailingSlash(routePath) {
+		return trimmed + "/"
+	}
+	if trimmed == "" {
+		return "/"
+	}
+	return trimmed
+}
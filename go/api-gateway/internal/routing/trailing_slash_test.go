@@ -0,0 +1,78 @@
+package routing
+
+import (
+	"testing"
+)
+
+func TestMatch_TrailingSlash(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultPolicy TrailingSlashPolicy
+		routePolicy   TrailingSlashPolicy
+		routePath     string
+		path          string
+		wantErr       bool
+		wantRedirect  string
+	}{
+		{name: "merge by default", routePath: "/api/v1/users", path: "/api/v1/users/"},
+		{name: "strict rejects extra slash", defaultPolicy: TrailingSlashStrict, routePath: "/api/v1/users", path: "/api/v1/users/", wantErr: true},
+		{name: "strict rejects missing slash", defaultPolicy: TrailingSlashStrict, routePath: "/docs/", path: "/docs", wantErr: true},
+		{name: "strict allows exact path", defaultPolicy: TrailingSlashStrict, routePath: "/api/v1/users", path: "/api/v1/users"},
+		{name: "redirect removes slash", defaultPolicy: TrailingSlashRedirect, routePath: "/api/v1/users/:id", path: "/api/v1/users/1//", wantRedirect: "/api/v1/users/1"},
+		{name: "redirect adds slash", defaultPolicy: TrailingSlashRedirect, routePath: "/docs/", path: "/docs", wantRedirect: "/docs/"},
+		{name: "redirect not needed for canonical path", defaultPolicy: TrailingSlashRedirect, routePath: "/docs/", path: "/docs/"},
+		{name: "route policy overrides default", defaultPolicy: TrailingSlashStrict, routePolicy: TrailingSlashMerge, routePath: "/api/v1/users", path: "/api/v1/users/"},
+		{name: "root path is never redirected", defaultPolicy: TrailingSlashRedirect, routePath: "/", path: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			router.SetTrailingSlashPolicy(tt.defaultPolicy)
+			if err := router.AddRoute(&Route{
+				Path:          tt.routePath,
+				Backend:       &Backend{URL: mustParseURL("https://example.com")},
+				TrailingSlash: tt.routePolicy,
+			}); err != nil {
+				t.Fatalf("failed to add route: %v", err)
+			}
+
+			result, err := router.Match("GET", tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Match() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.RedirectPath != tt.wantRedirect {
+				t.Errorf("RedirectPath = %q, want %q", result.RedirectPath, tt.wantRedirect)
+			}
+		})
+	}
+}
+
+func TestParseTrailingSlashPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TrailingSlashPolicy
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "merge", want: TrailingSlashMerge},
+		{input: "strict", want: TrailingSlashStrict},
+		{input: "redirect", want: TrailingSlashRedirect},
+		{input: "ignore", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTrailingSlashPolicy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrailingSlashPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTrailingSlashPolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
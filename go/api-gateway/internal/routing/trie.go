@@ -1,6 +1,12 @@
 package routing
 
 import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -9,8 +15,9 @@ type nodeType int
 
 const (
 	staticNode nodeType = iota // 静的パス
+	regexNode                   // 正規表現で制約したパラメータ（{id:[0-9]+}）
 	paramNode                   // パラメータ（:id）
-	wildcardNode                // ワイルドカード（*）
+	wildcardNode                // ワイルドカード（* は1つのセグメント、** は残りの全てのセグメント）
 )
 
 // node はTrie構造のノード
@@ -18,10 +25,15 @@ type node struct {
 	nodeType nodeType
 	segment  string            // パスセグメント（例: "users", ":id"）
 	children map[string]*node  // 子ノード
-	route    *Route            // このノードに対応するルート
+	routes   []*Route          // このノードに対応するルート（優先度の高い順、同じ優先度は match の条件が多い順）
 	paramName string           // パラメータ名（:id の場合 "id"）
+	pattern  *regexp.Regexp    // セグメント全体にマッチする正規表現（regexNode のみ）
+	dynamic  []*node           // 静的ノード以外の子ノード（マッチを試す順）
 }
 
+// catchAllSegment は残りの全てのセグメント（1つ以上）にマッチするワイルドカード。パスの最後にのみ置ける
+const catchAllSegment = "**"
+
 // newNode は新しいノードを作成する
 func newNode(segment string) *node {
 	n := &node{
@@ -29,11 +41,15 @@ func newNode(segment string) *node {
 		children: make(map[string]*node),
 	}
 
-	// ノードタイプを判定
-	if strings.HasPrefix(segment, ":") {
+	// ノードタイプを判定（正規表現は insertRoute で検証済み）
+	if name, expr, ok := parseRegexSegment(segment); ok {
+		n.nodeType = regexNode
+		n.paramName = name
+		n.pattern = regexp.MustCompile("^(?:" + expr + ")$")
+	} else if strings.HasPrefix(segment, ":") {
 		n.nodeType = paramNode
 		n.paramName = strings.TrimPrefix(segment, ":")
-	} else if segment == "*" || segment == "**" {
+	} else if segment == "*" || segment == catchAllSegment {
 		n.nodeType = wildcardNode
 	} else {
 		n.nodeType = staticNode
@@ -49,38 +65,236 @@ func (n *node) addChild(segment string) *node {
 	}
 
 	child := newNode(segment)
-	n.children[segment] = child
+	n.setChild(segment, child)
 	return child
 }
 
+// setChild は子ノードを追加または置き換える
+func (n *node) setChild(segment string, child *node) {
+	n.children[segment] = child
+	if child.nodeType != staticNode {
+		n.sortDynamic()
+	}
+}
+
+// deleteChild は子ノードを取り除く
+func (n *node) deleteChild(segment string) {
+	child := n.children[segment]
+	delete(n.children, segment)
+	if child != nil && child.nodeType != staticNode {
+		n.sortDynamic()
+	}
+}
+
+// sortDynamic は静的ノード以外の子ノードをマッチを試す順に並べ直す
+// 正規表現 > パラメータ > ワイルドカードの順で、同じ種類はセグメントの順（マップの反復順に依らず結果を決めるため）
+func (n *node) sortDynamic() {
+	n.dynamic = n.dynamic[:0:0]
+	for _, child := range n.children {
+		if child.nodeType != staticNode {
+			n.dynamic = append(n.dynamic, child)
+		}
+	}
+	slices.SortFunc(n.dynamic, func(a, b *node) int {
+		if a.nodeType != b.nodeType {
+			return cmp.Compare(a.nodeType, b.nodeType)
+		}
+		return strings.Compare(a.segment, b.segment)
+	})
+}
+
+// clone はノードのコピーを返す
+// 子ノードのマップは複製し、子ノード自体は共有する（コピーオンライトでパス上のノードのみ複製するため）
+// dynamic は setChild・deleteChild で作り直すため共有してよい
+func (n *node) clone() *node {
+	c := *n
+	c.children = maps.Clone(n.children)
+	return &c
+}
+
 // getChild は子ノードを取得する（静的マッチング）
 func (n *node) getChild(segment string) *node {
 	return n.children[segment]
 }
 
-// findMatchingChild はセグメントにマッチする子ノードを検索する
-// 優先順位: 静的マッチ > パラメータマッチ > ワイルドカード
+// matchingChildren はセグメントにマッチする子ノードを優先する順に返す
+// 優先順位: 静的マッチ > 正規表現のパラメータ > パラメータ > ワイルドカード
+func (n *node) matchingChildren(segment string) iter.Seq[*node] {
+	return func(yield func(*node) bool) {
+		// 1. 静的マッチを試行
+		if child := n.getChild(segment); child != nil {
+			if !yield(child) {
+				return
+			}
+		}
+
+		// 2. 正規表現・パラメータ・ワイルドカードの順に試行
+		for _, child := range n.dynamic {
+			if child.nodeType == regexNode && !child.pattern.MatchString(segment) {
+				continue
+			}
+			if !yield(child) {
+				return
+			}
+		}
+	}
+}
+
+// findMatchingChild はセグメントにマッチする最も優先する子ノードを検索する
 func (n *node) findMatchingChild(segment string) (*node, bool) {
-	// 1. 静的マッチを試行
-	if child := n.getChild(segment); child != nil {
+	for child := range n.matchingChildren(segment) {
 		return child, true
 	}
+	return nil, false
+}
+
+// insertRoute は root を変更せずに、ルートを追加したTrieのルートノードを返す
+// 追加するパス上のノードのみを複製し、それ以外のノードは元のTrieと共有する
+// 他のルートと衝突しないかは呼び出し元で checkConflicts により確認する
+func insertRoute(root *node, route *Route) (*node, error) {
+	segments, err := patternSegments(route.Path)
+	if err != nil {
+		return nil, err
+	}
 
-	// 2. パラメータノードを検索
-	for _, child := range n.children {
-		if child.nodeType == paramNode {
-			return child, true
+	newRoot := root.clone()
+	current := newRoot
+
+	// パスの各セグメントに対してノードを複製または作成
+	for _, segment := range segments {
+		child, exists := current.children[segment]
+		if exists {
+			child = child.clone()
+		} else {
+			child = newNode(segment)
 		}
+		current.setChild(segment, child)
+		current = child
 	}
 
-	// 3. ワイルドカードノードを検索
-	for _, child := range n.children {
-		if child.nodeType == wildcardNode {
-			return child, true
+	// 優先するルートから順に試すよう、優先度の降順、同じ優先度は match の条件の数の降順（同じ数は追加した順）に並べる
+	// current は複製したノードで元のTrieとスライスを共有しているため、新しいスライスを作る
+	i := 0
+	for i < len(current.routes) && !routeBefore(route, current.routes[i]) {
+		i++
+	}
+	current.routes = slices.Insert(slices.Clone(current.routes), i, route)
+	return newRoot, nil
+}
+
+// routeBefore は同じパスのルートで a を b より優先するか判定する
+func routeBefore(a, b *Route) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.Matcher.conditions() > b.Matcher.conditions()
+}
+
+// routeName はエラーやログでルートを表す名前（パスと match の条件）を返す
+func routeName(route *Route) string {
+	if route.Matcher == nil {
+		return route.Path
+	}
+	return route.Path + " " + route.Matcher.String()
+}
+
+// removeRoute は root を変更せずに、path の全てのルートを削除したTrieのルートノードを返す
+// ルートも子ノードも無くなったノードは取り除く。空の静的ノードが残ると、同じ位置のパラメータやワイルドカードへのマッチを妨げるため
+func removeRoute(root *node, path string) (*node, error) {
+	return removeRoutes(root, path, func(*Route) bool { return true })
+}
+
+// removeRoutes は root を変更せずに、path のルートのうち remove が true を返すものを削除したTrieのルートノードを返す
+func removeRoutes(root *node, path string, remove func(*Route) bool) (*node, error) {
+	segments, err := patternSegments(path)
+	if err != nil {
+		return nil, err
+	}
+	newRoot, found := removeFromNode(root, segments, remove)
+	if !found {
+		return nil, fmt.Errorf("route not found for path: %s", path)
+	}
+	return newRoot, nil
+}
+
+// removeFromNode は n 以下から segments のルートを削除したノードを返す（削除するルートが無い場合は n と false）
+func removeFromNode(n *node, segments []string, remove func(*Route) bool) (*node, bool) {
+	if len(segments) == 0 {
+		routes := slices.DeleteFunc(slices.Clone(n.routes), remove)
+		if len(routes) == len(n.routes) {
+			return n, false
 		}
+		removed := n.clone()
+		removed.routes = routes
+		return removed, true
 	}
 
-	return nil, false
+	segment := segments[0]
+	child, exists := n.children[segment]
+	if !exists {
+		return n, false
+	}
+	newChild, found := removeFromNode(child, segments[1:], remove)
+	if !found {
+		return n, false
+	}
+
+	removed := n.clone()
+	if len(newChild.routes) == 0 && len(newChild.children) == 0 {
+		removed.deleteChild(segment)
+	} else {
+		removed.setChild(segment, newChild)
+	}
+	return removed, true
+}
+
+// patternSegments はルートのパスをTrieのセグメントに分割する
+// {id} は :id と同じノードにまとめ、{id:[0-9]+} の正規表現を検証する
+func patternSegments(path string) ([]string, error) {
+	segments := SplitPath(path)
+	for i, segment := range segments {
+		if segment == catchAllSegment && i != len(segments)-1 {
+			return nil, fmt.Errorf("%s must be the last path segment", catchAllSegment)
+		}
+		name, expr, ok := parseRegexSegment(segment)
+		if !ok {
+			if name, ok := strings.CutPrefix(segment, "{"); ok && strings.HasSuffix(name, "}") {
+				segments[i] = ":" + strings.TrimSuffix(name, "}")
+			}
+			continue
+		}
+		if name == "" || expr == "" {
+			return nil, fmt.Errorf("parameter name and regular expression are required in path segment %q", segment)
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid regular expression in path segment %q: %w", segment, err)
+		}
+	}
+	return segments, nil
+}
+
+// parseRegexSegment は {name:regex} 形式のセグメントのパラメータ名と正規表現を返す
+// 正規表現はセグメント全体にマッチする必要がある（スラッシュは含められない）
+func parseRegexSegment(segment string) (name, expr string, ok bool) {
+	inner, ok := strings.CutPrefix(segment, "{")
+	if !ok || !strings.HasSuffix(inner, "}") {
+		return "", "", false
+	}
+	name, expr, ok = strings.Cut(strings.TrimSuffix(inner, "}"), ":")
+	return name, expr, ok
+}
+
+// ParamName はパスのセグメントがパラメータ（:id, {id}, {id:[0-9]+}）の場合にパラメータ名を返す
+func ParamName(segment string) (string, bool) {
+	if name, ok := strings.CutPrefix(segment, ":"); ok {
+		return name, true
+	}
+	inner, ok := strings.CutPrefix(segment, "{")
+	if !ok || !strings.HasSuffix(inner, "}") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(strings.TrimSuffix(inner, "}"), ":")
+	return name, true
 }
 
 // SplitPath はパスをセグメントに分割する
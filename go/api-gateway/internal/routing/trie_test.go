@@ -2,6 +2,7 @@ package routing
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -110,6 +111,12 @@ func TestNewNode(t *testing.T) {
 			wantNodeType: paramNode,
 			wantParam:    "id",
 		},
+		{
+			name:         "regex node",
+			segment:      "{id:[0-9]+}",
+			wantNodeType: regexNode,
+			wantParam:    "id",
+		},
 		{
 			name:         "wildcard node",
 			segment:      "*",
@@ -319,3 +326,204 @@ func TestNodeFindMatchingChild_Priority(t *testing.T) {
 		t.Error("findMatchingChild() should match wildcard when no static or param")
 	}
 }
+
+// buildTestTrie はパスのみを持つルートでTrieを作成する
+func buildTestTrie(t *testing.T, paths ...string) *node {
+	t.Helper()
+	root := newNode("")
+	for _, path := range paths {
+		var err error
+		if root, err = insertRoute(root, &Route{Path: path}); err != nil {
+			t.Fatalf("insertRoute(%s) error = %v", path, err)
+		}
+	}
+	return root
+}
+
+// trieNodeCount はTrieのノード数を返す（ルートノードを含む）
+func trieNodeCount(n *node) int {
+	count := 1
+	for _, child := range n.children {
+		count += trieNodeCount(child)
+	}
+	return count
+}
+
+func TestNodeFindMatchingChild_Regex(t *testing.T) {
+	// 優先順位のテスト: 静的 > 正規表現 > パラメータ
+	root := buildTestTrie(t, "/users/me", "/users/{id:[0-9]+}", "/users/{code:[a-z]{3}}", "/users/:name", "/users/*")
+	parent := root.children["users"]
+
+	tests := []struct {
+		segment   string
+		wantMatch string
+	}{
+		{segment: "me", wantMatch: "me"},
+		{segment: "123", wantMatch: "{id:[0-9]+}"},
+		{segment: "abc", wantMatch: "{code:[a-z]{3}}"},
+		{segment: "12a", wantMatch: ":name"},
+		{segment: "abcd", wantMatch: ":name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			child, found := parent.findMatchingChild(tt.segment)
+			if !found || child.segment != tt.wantMatch {
+				t.Errorf("findMatchingChild(%s) = %v, want %s", tt.segment, child, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestPatternSegments(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "/users/{id:[0-9]+}/posts", want: []string{"users", "{id:[0-9]+}", "posts"}},
+		{path: "/users/{id}", want: []string{"users", ":id"}},
+		{path: "/users/:id", want: []string{"users", ":id"}},
+		{path: "/users/{id:[0-9}", wantErr: true},
+		{path: "/users/{:[0-9]+}", wantErr: true},
+		{path: "/users/{id:}", wantErr: true},
+		{path: "/files/**", want: []string{"files", "**"}},
+		{path: "/files/**/meta", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := patternSegments(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("patternSegments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("patternSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveRoute_Prune(t *testing.T) {
+	tests := []struct {
+		name      string
+		paths     []string
+		remove    string
+		wantNodes int
+		parent    []string
+		segment   string
+		// wantMatch は削除後に parent の下で segment にマッチする子ノード（空はマッチしない）
+		wantMatch string
+	}{
+		{
+			name:      "静的ノードを削除するとパラメータにマッチする",
+			paths:     []string{"/users/admin", "/users/:id"},
+			remove:    "/users/admin",
+			wantNodes: 3,
+			parent:    []string{"users"},
+			segment:   "admin",
+			wantMatch: ":id",
+		},
+		{
+			name:      "静的ノードを削除するとワイルドカードにマッチする",
+			paths:     []string{"/files/readme", "/files/*"},
+			remove:    "/files/readme",
+			wantNodes: 3,
+			parent:    []string{"files"},
+			segment:   "readme",
+			wantMatch: "*",
+		},
+		{
+			name:      "パラメータの削除で空になった親ノードも取り除く",
+			paths:     []string{"/api/v1/users/:id/orders/:orderId", "/api/v1/health"},
+			remove:    "/api/v1/users/:id/orders/:orderId",
+			wantNodes: 4,
+			parent:    []string{"api", "v1"},
+			segment:   "users",
+			wantMatch: "",
+		},
+		{
+			name:      "ワイルドカードの削除",
+			paths:     []string{"/static/*", "/static/index.html"},
+			remove:    "/static/*",
+			wantNodes: 3,
+			parent:    []string{"static"},
+			segment:   "app.js",
+			wantMatch: "",
+		},
+		{
+			name:      "子ノードを持つノードはルートのみ削除する",
+			paths:     []string{"/users", "/users/:id"},
+			remove:    "/users",
+			wantNodes: 3,
+			parent:    []string{"users"},
+			segment:   "123",
+			wantMatch: ":id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := buildTestTrie(t, tt.paths...)
+			before := trieNodeCount(root)
+
+			removed, err := removeRoute(root, tt.remove)
+			if err != nil {
+				t.Fatalf("removeRoute() error = %v", err)
+			}
+			if got := trieNodeCount(removed); got != tt.wantNodes {
+				t.Errorf("node count = %d, want %d", got, tt.wantNodes)
+			}
+			// 元のTrieは変更しない
+			if got := trieNodeCount(root); got != before {
+				t.Errorf("original node count = %d, want %d", got, before)
+			}
+
+			parent := removed
+			for _, segment := range tt.parent {
+				parent = parent.children[segment]
+				if parent == nil {
+					t.Fatalf("parent node %s was pruned", segment)
+				}
+			}
+			child, found := parent.findMatchingChild(tt.segment)
+			switch {
+			case tt.wantMatch == "" && found:
+				t.Errorf("findMatchingChild(%s) = %s, want no match", tt.segment, child.segment)
+			case tt.wantMatch != "" && (!found || child.segment != tt.wantMatch):
+				t.Errorf("findMatchingChild(%s) = %v, want %s", tt.segment, child, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestRemoveRoute_NotFound(t *testing.T) {
+	root := buildTestTrie(t, "/users/:id/orders")
+
+	// 途中のノード（ルートを持たない）と存在しないパスは削除できない
+	for _, path := range []string{"/users/:id", "/users/:id/orders/:orderId", "/orders"} {
+		if _, err := removeRoute(root, path); err == nil {
+			t.Errorf("removeRoute(%s) should return error", path)
+		}
+	}
+}
+
+// FuzzSplitPath は任意のパスで SplitPath が区切り文字を含まないセグメントに分割することを確認する
+// go test -fuzz FuzzSplitPath ./internal/routing/
+func FuzzSplitPath(f *testing.F) {
+	for _, seed := range []string{"", "/", "//", "/api/v1/users", "api//v1/", "/%2F/../\x00", strings.Repeat("/a", 1000)} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		segments := SplitPath(path)
+		for _, segment := range segments {
+			if strings.Contains(segment, "/") {
+				t.Fatalf("SplitPath(%q) segment %q contains '/'", path, segment)
+			}
+		}
+		if got, want := JoinPath(segments), "/"+strings.Trim(path, "/"); got != want {
+			t.Errorf("JoinPath(SplitPath(%q)) = %q, want %q", path, got, want)
+		}
+	})
+}
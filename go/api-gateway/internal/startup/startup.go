@@ -0,0 +1,110 @@
+// Package startup は起動時に依存先（ルーティング設定ファイル、Redis、JWKS）の準備ができるまで再試行する
+//
+// 依存先ごとに同じ再試行とバックオフを使い、依存先によって起動を続けるか終了するかが異なる挙動を揃える。
+// Wait（--wait-for-deps）の場合は再試行の回数に関わらず、ctx がキャンセルされるまで待ち続ける。
+// コンテナのオーケストレーターで依存先と同時に起動する場合に使う
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// DefaultBackoff は最初の再試行までの待ち時間のデフォルト値
+	DefaultBackoff = time.Second
+	// DefaultMaxBackoff は再試行の待ち時間の上限のデフォルト値
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// Config は依存先を待つ設定
+type Config struct {
+	// Retries は失敗した場合の再試行回数（0の場合は再試行しない。Wait の場合は使わない）
+	Retries int
+	// Backoff は最初の再試行までの待ち時間（0の場合は DefaultBackoff）。再試行ごとに倍にする
+	Backoff time.Duration
+	// MaxBackoff は再試行の待ち時間の上限（0の場合は DefaultMaxBackoff）
+	MaxBackoff time.Duration
+	// Wait は回数に関わらず、成功するか ctx がキャンセルされるまで再試行するか
+	Wait   bool
+	Logger *slog.Logger
+}
+
+// Dependencies は起動時の依存先の準備を待つ
+type Dependencies struct {
+	config Config
+}
+
+// permanentError は再試行しても解決しないエラー
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent は err を再試行しないエラーにする（設定ファイルの構文エラーなど）
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// New は新しいDependenciesを作成する
+func New(config Config) *Dependencies {
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &Dependencies{config: config}
+}
+
+// Wait は依存先 name の準備ができる（fn が成功する）まで再試行する
+// 再試行を使い切った場合、ctx がキャンセルされた場合と Permanent のエラーの場合は最後のエラーを返す
+func (d *Dependencies) Wait(ctx context.Context, name string, fn func(context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				d.config.Logger.Info("Dependency is ready",
+					slog.String("dependency", name),
+					slog.Int("attempts", attempt))
+			}
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return fmt.Errorf("%s is not available: %w", name, permanent.err)
+		}
+		if !d.config.Wait && attempt > d.config.Retries {
+			return fmt.Errorf("%s is not available after %d attempts: %w", name, attempt, err)
+		}
+
+		delay := d.backoff(attempt)
+		d.config.Logger.Warn("Dependency is not available, retrying",
+			slog.String("dependency", name),
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.String("error", err.Error()))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s is not available: %w", name, errors.Join(ctx.Err(), err))
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff は attempt 回目の失敗の後の待ち時間を返す
+func (d *Dependencies) backoff(attempt int) time.Duration {
+	return min(d.config.Backoff<<min(attempt-1, 16), d.config.MaxBackoff)
+}
@@ -0,0 +1,89 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDependencies_Wait(t *testing.T) {
+	errUnavailable := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		config       Config
+		failures     int   // fn が失敗する回数
+		err          error // fn が返すエラー（nilの場合は errUnavailable）
+		wantErr      bool
+		wantAttempts int
+	}{
+		{name: "初回で成功", config: Config{}, failures: 0, wantAttempts: 1},
+		{name: "再試行しない場合は1回で失敗", config: Config{}, failures: 1, wantErr: true, wantAttempts: 1},
+		{name: "再試行の範囲内で成功", config: Config{Retries: 3}, failures: 3, wantAttempts: 4},
+		{name: "再試行を使い切ると失敗", config: Config{Retries: 2}, failures: 5, wantErr: true, wantAttempts: 3},
+		{name: "Waitは回数に関わらず再試行する", config: Config{Wait: true}, failures: 5, wantAttempts: 6},
+		{
+			name:         "Permanentのエラーは再試行しない",
+			config:       Config{Retries: 3},
+			failures:     5,
+			err:          Permanent(errors.New("invalid yaml")),
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Backoff = time.Millisecond
+			tt.config.Logger = slog.New(slog.DiscardHandler)
+			deps := New(tt.config)
+
+			attempts := 0
+			err := deps.Wait(context.Background(), "redis", func(context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					if tt.err != nil {
+						return tt.err
+					}
+					return errUnavailable
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Wait() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDependencies_Wait_ContextCanceled(t *testing.T) {
+	deps := New(Config{Wait: true, Backoff: time.Millisecond, Logger: slog.New(slog.DiscardHandler)})
+	errUnavailable := errors.New("connection refused")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := deps.Wait(ctx, "jwks", func(context.Context) error { return errUnavailable })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("Wait() error = %v, want the last error of the dependency", err)
+	}
+}
+
+func TestDependencies_Backoff(t *testing.T) {
+	deps := New(Config{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := deps.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
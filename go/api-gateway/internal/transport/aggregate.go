@@ -0,0 +1,250 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/metrics"
+)
+
+const (
+	// DefaultAggregateTimeout は全てのバックエンドの呼び出しをまとめた期限のデフォルト値
+	DefaultAggregateTimeout = 10 * time.Second
+	// DefaultAggregateMaxBody はバックエンドごとのレスポンスボディの上限バイト数のデフォルト値
+	DefaultAggregateMaxBody = 10 << 20 // 10MiB
+
+	// HeaderAggregateFailed は失敗して null を格納したバックエンドのキー（カンマ区切り）を返すレスポンスヘッダー
+	HeaderAggregateFailed = "X-Gateway-Aggregate-Failed"
+)
+
+// aggregateCallsTotal はまとめるバックエンドの呼び出しの結果（success, error, timeout）ごとの数
+var aggregateCallsTotal = metrics.NewCounterVec(
+	"gateway_aggregate_calls_total",
+	"Number of backend calls made by aggregate routes, by route, key and result.",
+	"route", "key", "result",
+)
+
+// AggregateParamPattern はまとめるバックエンドのURLのうちパスパラメータに置き換える部分（{name}）
+var AggregateParamPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// Aggregator は複数のバックエンドを並行に呼び出し、JSONのレスポンスを Key をキーとする1つのオブジェクトにまとめる
+// 全てのバックエンドには Timeout の期限をまとめて適用し、必須でないバックエンドの失敗は null にしてまとめる
+type Aggregator struct {
+	// Route はメトリクスのラベルに使うルートのパス
+	Route string
+	// Timeout は全ての呼び出しをまとめた期限（0は DefaultAggregateTimeout）
+	Timeout time.Duration
+	// Parts は呼び出すバックエンド
+	Parts []AggregatePart
+	// MaxBody はバックエンドごとのレスポンスボディの上限バイト数（0は DefaultAggregateMaxBody）
+	MaxBody int64
+}
+
+// AggregatePart はまとめるバックエンドの1つ
+type AggregatePart struct {
+	// Key はまとめたJSONでレスポンスを格納するキー
+	Key string
+	// URL は呼び出すURL（{name} はパスパラメータの値に置き換える）
+	URL string
+	// Required は失敗した場合にリクエスト全体を失敗にするか
+	Required bool
+}
+
+// target はパスパラメータとクライアントのクエリから呼び出すURLを作る
+// パラメータの値はパスではパスのセグメント、クエリではクエリの値としてエスケープする
+func (p AggregatePart) target(params map[string]string, rawQuery string) (string, error) {
+	expand := func(s string, escape func(string) string) string {
+		return AggregateParamPattern.ReplaceAllStringFunc(s, func(m string) string {
+			return escape(params[m[1:len(m)-1]])
+		})
+	}
+	path, query, hasQuery := strings.Cut(p.URL, "?")
+	target := expand(path, url.PathEscape)
+	if hasQuery {
+		target += "?" + expand(query, url.QueryEscape)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	if rawQuery != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += rawQuery
+	}
+	return u.String(), nil
+}
+
+// aggregateResult はバックエンドの1つの呼び出しの結果
+type aggregateResult struct {
+	body json.RawMessage
+	err  error
+}
+
+// aggregate は Aggregator のバックエンドを並行に呼び出し、レスポンスをまとめて返す
+func (t *HTTPTransporter) aggregate(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *Backend) error {
+	agg := backend.Aggregate
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return errors.NewError(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET and HEAD are allowed for aggregate routes")
+	}
+
+	timeout := agg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultAggregateTimeout
+	}
+	clientCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	roundTripper := t.roundTripper(backend.Protocol, backend.Pool)
+	results := make([]aggregateResult, len(agg.Parts))
+	var wg sync.WaitGroup
+	for i, part := range agg.Parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := t.callAggregatePart(ctx, req, backend, part, roundTripper)
+			results[i] = aggregateResult{body: body, err: err}
+		}()
+	}
+	wg.Wait()
+
+	if err := clientCtx.Err(); err != nil {
+		if stderrors.Is(err, context.DeadlineExceeded) {
+			return ErrRequestTimeout
+		}
+		clientAbortedTotal.Inc()
+		return ErrClientAborted
+	}
+
+	// 期限を過ぎた後に失敗した呼び出しは期限切れとして扱う
+	timedOut := ctx.Err() != nil
+	merged := make(map[string]json.RawMessage, len(agg.Parts))
+	var failed []string
+	var requiredErr error
+	for i, part := range agg.Parts {
+		result := results[i]
+		switch {
+		case result.err == nil:
+			aggregateCallsTotal.With(agg.Route, part.Key, "success").Inc()
+			merged[part.Key] = result.body
+			continue
+		case timedOut:
+			aggregateCallsTotal.With(agg.Route, part.Key, "timeout").Inc()
+		default:
+			aggregateCallsTotal.With(agg.Route, part.Key, "error").Inc()
+		}
+
+		if part.Required {
+			if requiredErr == nil {
+				requiredErr = aggregateError(part.Key, result.err, timedOut, timeout)
+			}
+			continue
+		}
+		t.logger().WarnContext(clientCtx, "aggregate backend failed",
+			slog.String("route", agg.Route),
+			slog.String("key", part.Key),
+			slog.String("error", result.err.Error()),
+		)
+		merged[part.Key] = json.RawMessage("null")
+		failed = append(failed, part.Key)
+	}
+	if requiredErr != nil {
+		return requiredErr
+	}
+
+	body, err := json.Marshal(merged)
+	if err != nil {
+		return errors.NewInternalServerError(fmt.Sprintf("failed to merge aggregate responses: %v", err))
+	}
+	if len(failed) > 0 {
+		w.Header().Set(HeaderAggregateFailed, strings.Join(failed, ","))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		w.Write(body)
+	}
+	return nil
+}
+
+// aggregateError は必須のバックエンドが失敗した場合のエラー（期限切れは504、それ以外は502）を返す
+func aggregateError(key string, err error, timedOut bool, timeout time.Duration) errors.GatewayError {
+	if timedOut {
+		return errors.NewGatewayTimeoutError(fmt.Sprintf("aggregate backend %s did not respond within %s", key, timeout))
+	}
+	return errors.NewBadGatewayError(fmt.Sprintf("aggregate backend %s failed: %v", key, err))
+}
+
+// callAggregatePart はバックエンドの1つをGETで呼び出し、2xxのJSONのレスポンスボディを返す（空のボディは null）
+// クライアントのヘッダーは転送するが、接続に関するヘッダーとボディのヘッダーは送らない
+func (t *HTTPTransporter) callAggregatePart(ctx context.Context, req *http.Request, backend *Backend, part AggregatePart, roundTripper http.RoundTripper) (json.RawMessage, error) {
+	target, err := part.target(backend.PathParams, req.URL.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	out, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	out.Header = req.Header.Clone()
+	for _, name := range connectionHeaders {
+		out.Header.Del(name)
+	}
+	out.Header.Del("Content-Length")
+	out.Header.Del("Content-Type")
+	out.Header.Set("Accept", "application/json")
+	for key, value := range backend.Headers {
+		out.Header.Set(key, value)
+	}
+	setRequestTimeout(ctx, out.Header)
+	if backend.Credentials != nil {
+		token, err := backend.Credentials.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain backend access token: %w", err)
+		}
+		out.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := roundTripper.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("backend returned status %d", resp.StatusCode)
+	}
+
+	maxBody := backend.Aggregate.MaxBody
+	if maxBody <= 0 {
+		maxBody = DefaultAggregateMaxBody
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case int64(len(body)) > maxBody:
+		return nil, fmt.Errorf("response body exceeds limit of %d bytes", maxBody)
+	case len(body) == 0:
+		return json.RawMessage("null"), nil
+	case !json.Valid(body):
+		return nil, fmt.Errorf("backend returned invalid JSON")
+	}
+	return body, nil
+}
@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"api-gateway/internal/errors"
+)
+
+func TestHTTPTransporter_Transport_Aggregate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/users/a b":
+			w.Write([]byte(`{"id":"a b","name":"Alice"}`))
+		case "/orders":
+			w.Write([]byte(`[{"user":"` + r.URL.Query().Get("user") + `","limit":"` + r.URL.Query().Get("limit") + `"}]`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		case "/text":
+			w.Write([]byte("not json"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name       string
+		method     string
+		parts      []AggregatePart
+		wantBody   string
+		wantFailed string
+		wantErr    int
+	}{
+		{
+			name:   "レスポンスをキーごとにまとめる",
+			method: http.MethodGet,
+			parts: []AggregatePart{
+				{Key: "user", URL: server.URL + "/users/{id}", Required: true},
+				{Key: "orders", URL: server.URL + "/orders?user={id}"},
+				{Key: "empty", URL: server.URL + "/empty"},
+			},
+			wantBody: `{"empty":null,"orders":[{"user":"a b","limit":"5"}],"user":{"id":"a b","name":"Alice"}}`,
+		},
+		{
+			name:   "必須でないバックエンドの失敗は null にする",
+			method: http.MethodGet,
+			parts: []AggregatePart{
+				{Key: "user", URL: server.URL + "/users/{id}", Required: true},
+				{Key: "broken", URL: server.URL + "/broken"},
+				{Key: "text", URL: server.URL + "/text"},
+			},
+			wantBody:   `{"broken":null,"text":null,"user":{"id":"a b","name":"Alice"}}`,
+			wantFailed: "broken,text",
+		},
+		{
+			name:   "必須のバックエンドの失敗は502",
+			method: http.MethodGet,
+			parts: []AggregatePart{
+				{Key: "user", URL: server.URL + "/users/{id}"},
+				{Key: "broken", URL: server.URL + "/broken", Required: true},
+			},
+			wantErr: http.StatusBadGateway,
+		},
+		{
+			name:   "必須のバックエンドの期限切れは504",
+			method: http.MethodGet,
+			parts: []AggregatePart{
+				{Key: "slow", URL: server.URL + "/slow", Required: true},
+			},
+			wantErr: http.StatusGatewayTimeout,
+		},
+		{
+			name:    "GET以外は405",
+			method:  http.MethodPost,
+			parts:   []AggregatePart{{Key: "user", URL: server.URL + "/users/{id}"}},
+			wantErr: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &Backend{
+				URL:        &url.URL{},
+				Aggregate:  &Aggregator{Route: "/bff/users/:id", Timeout: 100 * time.Millisecond, Parts: tt.parts},
+				PathParams: map[string]string{"id": "a b"},
+			}
+
+			req := httptest.NewRequest(tt.method, "/bff/users/a%20b?limit=5", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			transporter := NewHTTPTransporter()
+			transporter.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+			err := transporter.Transport(context.Background(), w, req, backend)
+			if tt.wantErr != 0 {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != tt.wantErr {
+					t.Fatalf("Transport() error = %v, want status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transport() error = %v", err)
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+			if got := w.Header().Get(HeaderAggregateFailed); got != tt.wantFailed {
+				t.Errorf("%s = %q, want %q", HeaderAggregateFailed, got, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"cmp"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 接続プールのデフォルト値
+// http.DefaultTransport はホストごとのアイドル接続が2つまでのため、同じバックエンドへの同時リクエストが多いと接続を作り直し続ける
+const (
+	// DefaultMaxIdleConns は全てのバックエンドで保持するアイドル接続の上限
+	DefaultMaxIdleConns = 1000
+	// DefaultMaxIdleConnsPerHost はバックエンドのホストごとに保持するアイドル接続の上限
+	DefaultMaxIdleConnsPerHost = 100
+	// DefaultIdleConnTimeout はアイドル接続を閉じるまでの時間
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// ConnectionPool はバックエンドへの接続プールの設定（ゼロ値はデフォルト）
+type ConnectionPool struct {
+	// MaxIdleConns はホストごとに保持するアイドル接続の上限（0は DefaultMaxIdleConnsPerHost）
+	MaxIdleConns int
+	// MaxConnsPerHost はホストごとの接続数の上限（0は無制限）。上限に達したリクエストは接続が空くまで待つ
+	MaxConnsPerHost int
+	// IdleConnTimeout はアイドル接続を閉じるまでの時間（0は DefaultIdleConnTimeout）
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives はリクエストごとに接続を閉じるか
+	DisableKeepAlives bool
+}
+
+// roundTripperKey は http.Transport を共有する単位
+type roundTripperKey struct {
+	protocol Protocol
+	pool     ConnectionPool
+}
+
+// roundTrippers はプロトコルと接続プールの設定ごとに http.Transport を共有する
+// 接続プールを共有するため、リクエストごとではなく同じ設定のバックエンドで一度だけ作る
+type roundTrippers struct {
+	mu         sync.Mutex
+	transports map[roundTripperKey]*http.Transport
+}
+
+// defaultRoundTrippers は NewHTTPTransporter を使わずに作成したトランスポーターが使う roundTrippers
+var defaultRoundTrippers = newRoundTrippers()
+
+// newRoundTrippers は新しいroundTrippersを作成する
+func newRoundTrippers() *roundTrippers {
+	return &roundTrippers{transports: make(map[roundTripperKey]*http.Transport)}
+}
+
+// get はプロトコルと接続プールの設定に対応する http.Transport を返す（無ければ作成する）
+// nil の場合は defaultRoundTrippers から返す
+func (r *roundTrippers) get(protocol Protocol, pool ConnectionPool) *http.Transport {
+	if r == nil {
+		r = defaultRoundTrippers
+	}
+	key := roundTripperKey{protocol: protocol, pool: pool}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.transports[key]; ok {
+		return t
+	}
+	t := newTransport(protocol, pool)
+	r.transports[key] = t
+	return t
+}
+
+// newTransport はプロトコルと接続プールの設定から http.Transport を作成する
+// プロキシの環境変数やダイヤルのタイムアウトなどは http.DefaultTransport と同じ設定を使う
+func newTransport(protocol Protocol, pool ConnectionPool) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = DefaultMaxIdleConns
+	t.MaxIdleConnsPerHost = cmp.Or(pool.MaxIdleConns, DefaultMaxIdleConnsPerHost)
+	t.MaxConnsPerHost = pool.MaxConnsPerHost
+	t.IdleConnTimeout = cmp.Or(pool.IdleConnTimeout, DefaultIdleConnTimeout)
+	t.DisableKeepAlives = pool.DisableKeepAlives
+
+	switch protocol {
+	case ProtocolHTTP1:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP1(true)
+	case ProtocolH2:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP2(true)
+	case ProtocolH2C:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return t
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRoundTrippers_Get(t *testing.T) {
+	rts := newRoundTrippers()
+
+	t.Run("デフォルトの設定", func(t *testing.T) {
+		tr := rts.get(ProtocolAuto, ConnectionPool{})
+		if tr.MaxIdleConns != DefaultMaxIdleConns || tr.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || tr.IdleConnTimeout != DefaultIdleConnTimeout {
+			t.Errorf("transport = MaxIdleConns %d, MaxIdleConnsPerHost %d, IdleConnTimeout %s, want defaults", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+		}
+		if tr.MaxConnsPerHost != 0 || tr.DisableKeepAlives {
+			t.Errorf("transport = MaxConnsPerHost %d, DisableKeepAlives %v, want unlimited with keep-alives", tr.MaxConnsPerHost, tr.DisableKeepAlives)
+		}
+	})
+
+	t.Run("バックエンドの設定", func(t *testing.T) {
+		pool := ConnectionPool{MaxIdleConns: 10, MaxConnsPerHost: 20, IdleConnTimeout: 30 * time.Second, DisableKeepAlives: true}
+		tr := rts.get(ProtocolHTTP1, pool)
+		if tr.MaxIdleConnsPerHost != 10 || tr.MaxConnsPerHost != 20 || tr.IdleConnTimeout != 30*time.Second || !tr.DisableKeepAlives {
+			t.Errorf("transport = %+v, want settings from %+v", tr, pool)
+		}
+		if tr.Protocols == nil || !tr.Protocols.HTTP1() || tr.Protocols.HTTP2() {
+			t.Errorf("Protocols = %v, want HTTP/1.1 only", tr.Protocols)
+		}
+	})
+
+	t.Run("同じプロトコルと設定のバックエンドで共有する", func(t *testing.T) {
+		pool := ConnectionPool{MaxIdleConns: 10}
+		if rts.get(ProtocolAuto, pool) != rts.get(ProtocolAuto, pool) {
+			t.Error("same protocol and pool settings should share the transport")
+		}
+		if rts.get(ProtocolAuto, pool) == rts.get(ProtocolAuto, ConnectionPool{}) {
+			t.Error("different pool settings should not share the transport")
+		}
+		if rts.get(ProtocolAuto, pool) == rts.get(ProtocolHTTP1, pool) {
+			t.Error("different protocols should not share the transport")
+		}
+	})
+}
+
+func TestHTTPTransporter_Transport_ConnectionReuse(t *testing.T) {
+	var conns atomic.Int32
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	backendServer.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	backendServer.Start()
+	defer backendServer.Close()
+
+	tests := []struct {
+		name      string
+		pool      ConnectionPool
+		wantConns int32
+	}{
+		{name: "接続を再利用する", wantConns: 1},
+		{name: "disable_keepalives はリクエストごとに接続する", pool: ConnectionPool{DisableKeepAlives: true}, wantConns: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns.Store(0)
+			backend, err := NewBackend(backendServer.URL, 5*time.Second)
+			if err != nil {
+				t.Fatalf("failed to create backend: %v", err)
+			}
+			backend.Pool = tt.pool
+			transporter := NewHTTPTransporter()
+
+			for range 3 {
+				w := httptest.NewRecorder()
+				if err := transporter.Transport(context.Background(), w, httptest.NewRequest(http.MethodGet, "/", nil), backend); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if got := conns.Load(); got != tt.wantConns {
+				t.Errorf("connections = %d, want %d", got, tt.wantConns)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/contract"
+)
+
+// DefaultContractMaxBody は検証するレスポンスボディの上限バイト数のデフォルト値
+const DefaultContractMaxBody = 10 << 20 // 10MiB
+
+// errContractViolation は enforce でレスポンスが契約に違反した場合のエラー（502を返す）
+var errContractViolation = fmt.Errorf("backend response does not match the API contract")
+
+// ResponseValidator はバックエンドのレスポンスをルートのOpenAPIドキュメントで検証する
+// ドキュメントはバックエンドへ転送したメソッドとパスで照合し、記載の無いオペレーションのレスポンスは検証しない
+type ResponseValidator struct {
+	// Route はログとメトリクスのラベルに使うルートのパス
+	Route string
+	// Spec はOpenAPIのドキュメント
+	Spec *contract.Spec
+	// Enforce は違反したレスポンスの代わりに502を返すか（false は違反を記録してそのまま返す）
+	Enforce bool
+	// MaxBody は検証するボディの上限バイト数（0は DefaultContractMaxBody）
+	MaxBody int64
+}
+
+// prepareRequest はバックエンドが圧縮せずに応答するよう Accept-Encoding を削除する
+// （Accept-Encoding が無い場合、http.Transport は gzip を要求して透過的に展開する）
+func (v *ResponseValidator) prepareRequest(req *http.Request) {
+	req.Header.Del("Accept-Encoding")
+}
+
+// validate はレスポンスを検証する
+// ボディを全て読み込むため、検証の対象のレスポンスはストリーミングされなくなる
+func (v *ResponseValidator) validate(resp *http.Response, logger *slog.Logger) error {
+	op, ok := v.Spec.FindOperation(resp.Request.Method, resp.Request.URL.Path)
+	if !ok {
+		return nil
+	}
+
+	body, skipped, err := v.readBody(resp)
+	if err != nil {
+		return err
+	}
+	if skipped != "" {
+		if v.Enforce {
+			return fmt.Errorf("%w: %s", errContractViolation, skipped)
+		}
+		// audit では検証できないレスポンスもそのまま返す
+		logger.WarnContext(resp.Request.Context(), "response contract not validated",
+			slog.String("route", v.Route),
+			slog.String("reason", skipped),
+		)
+		return nil
+	}
+
+	violations := op.ValidateResponse(resp.StatusCode, resp.Header, body)
+	if len(violations) == 0 {
+		return nil
+	}
+	mode := contract.ModeAudit
+	if v.Enforce {
+		mode = contract.ModeEnforce
+	}
+	contract.RecordViolation(v.Route, "response", mode)
+
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.String()
+	}
+	logger.WarnContext(resp.Request.Context(), "response contract violation",
+		slog.String("route", v.Route),
+		slog.String("method", resp.Request.Method),
+		slog.String("operation", op.Path),
+		slog.Int("status_code", resp.StatusCode),
+		slog.String("mode", mode),
+		slog.Any("violations", messages),
+	)
+	if v.Enforce {
+		return errContractViolation
+	}
+	return nil
+}
+
+// readBody はレスポンスボディを読み込み、読み込んだ内容で Body を置き換える
+// HEADのレスポンスとボディの無いレスポンスは空のボディとして検証する。検証できないレスポンスはボディを読み込まずに理由（skipped）を返す
+func (v *ResponseValidator) readBody(resp *http.Response) (body []byte, skipped string, err error) {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.Request.Method == http.MethodHead {
+		return nil, "", nil
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return nil, fmt.Sprintf("response has content encoding %q", encoding), nil
+	}
+
+	maxBody := v.MaxBody
+	if maxBody <= 0 {
+		maxBody = DefaultContractMaxBody
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("failed to read backend response body: %w", err)
+	}
+	if int64(len(body)) > maxBody {
+		// 読み込んだ部分と残りをつなぎ、検証せずにそのまま返せるようにする
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil, fmt.Sprintf("response body is larger than %d bytes", maxBody), nil
+	}
+	resp.Body.Close()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return body, "", nil
+}
@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/contract"
+	"api-gateway/internal/metrics"
+)
+
+const contractTestSpec = `
+openapi: 3.0.3
+info:
+  title: users
+  version: "1"
+paths:
+  /users/{id}:
+    get:
+      responses:
+        200:
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: integer
+`
+
+func TestHTTPTransporter_Transport_ResponseValidation(t *testing.T) {
+	spec, err := contract.Parse([]byte(contractTestSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			// Accept-Encoding を削除した場合は http.Transport が gzip を要求する
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/users/1":
+			w.Write([]byte(`{"id":1}`))
+		case "/users/2":
+			w.Write([]byte(`{"id":"2"}`))
+		case "/users/large":
+			w.Write([]byte(`{"id":1,"name":"` + strings.Repeat("a", 64) + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	backendURL, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name           string
+		path           string
+		enforce        bool
+		wantStatus     int
+		wantViolation  bool
+		wantSkipLogged bool
+	}{
+		{name: "契約どおりのレスポンス", path: "/users/1", enforce: true, wantStatus: http.StatusOK},
+		{name: "auditは違反を記録して返す", path: "/users/2", wantStatus: http.StatusOK, wantViolation: true},
+		{name: "enforceは違反したレスポンスの代わりに502", path: "/users/2", enforce: true, wantStatus: http.StatusBadGateway, wantViolation: true},
+		{name: "記載の無いステータス", path: "/users/3", enforce: true, wantStatus: http.StatusBadGateway, wantViolation: true},
+		{name: "記載の無いオペレーションは検証しない", path: "/orders", enforce: true, wantStatus: http.StatusNotFound},
+		{name: "auditは上限を超えたボディを検証せずに返す", path: "/users/large", wantStatus: http.StatusOK, wantSkipLogged: true},
+		{name: "enforceは上限を超えたボディを返さない", path: "/users/large", enforce: true, wantStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			transporter := NewHTTPTransporter()
+			transporter.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+			backend := &Backend{
+				URL:       backendURL,
+				Validator: &ResponseValidator{Route: "/users", Spec: spec, Enforce: tt.enforce, MaxBody: 32},
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept-Encoding", "br")
+			w := httptest.NewRecorder()
+			if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+				t.Fatalf("Transport() error = %v", err)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := strings.Contains(logs.String(), "response contract violation"); got != tt.wantViolation {
+				t.Errorf("violation logged = %v, want %v: %s", got, tt.wantViolation, logs.String())
+			}
+			if got := strings.Contains(logs.String(), "response contract not validated"); got != tt.wantSkipLogged {
+				t.Errorf("skip logged = %v, want %v: %s", got, tt.wantSkipLogged, logs.String())
+			}
+			if tt.wantStatus == http.StatusOK && tt.path == "/users/large" && !strings.HasSuffix(w.Body.String(), `"}`) {
+				t.Errorf("body truncated: %s", w.Body.String())
+			}
+		})
+	}
+
+	var b strings.Builder
+	metrics.Default.WriteText(&b)
+	for _, want := range []string{
+		`gateway_contract_violations_total{route="/users",direction="response",mode="audit"} 1`,
+		`gateway_contract_violations_total{route="/users",direction="response",mode="enforce"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
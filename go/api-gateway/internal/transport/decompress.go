@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/buffer"
+	"api-gateway/internal/metrics"
+)
+
+// responseDecompressedTotal はクライアントが圧縮を受け付けないため展開したレスポンス数
+var responseDecompressedTotal = metrics.NewCounter(
+	"gateway_response_decompressed_total",
+	"Number of gzip backend responses decompressed because the client did not accept gzip.",
+)
+
+// decompressResponse はクライアントが gzip を受け付けないのにバックエンドが gzip で応答した場合にボディを展開する
+// 展開後の長さは分からないため Content-Length を削除する（normalizeResponse で確定させる）
+// 表現が変わるため、強いETagは弱いETagにする
+func decompressResponse(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "x-gzip" {
+		return nil
+	}
+	if acceptsGzip(resp.Request.Header.Values("Accept-Encoding")) || !hasResponseBody(resp) {
+		return nil
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("failed to decompress backend response: %w", err)
+	}
+	resp.Body = &gzipBody{Reader: gz, body: resp.Body}
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.Header.Del("Content-MD5")
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		resp.Header.Set("ETag", "W/"+etag)
+	}
+	responseDecompressedTotal.Inc()
+	return nil
+}
+
+// normalizeResponse は長さの分からない（chunked の）レスポンスボディを読み込んで Content-Length を設定する
+// レスポンスボディを読み込む処理（マスク・変換など）と、クライアントへのレスポンスの形式を揃えるために使う
+// Server-Sent Events はストリーミングのため対象外。max_response_body を設定すると読み込む量も制限される
+func normalizeResponse(resp *http.Response, bufConfig buffer.Config) error {
+	if resp.ContentLength >= 0 || !hasResponseBody(resp) {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		return nil
+	}
+
+	buf := buffer.New(bufConfig)
+	_, err := copyBuffer(buf, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		buf.Close()
+		return fmt.Errorf("failed to read backend response body: %w", err)
+	}
+	body, err := buf.Reader()
+	if err != nil {
+		buf.Close()
+		return fmt.Errorf("failed to read buffered response body: %w", err)
+	}
+	resp.Body = body
+	resp.ContentLength = buf.Len()
+	resp.TransferEncoding = nil
+	resp.Header.Del("Transfer-Encoding")
+	resp.Header.Set("Content-Length", strconv.FormatInt(buf.Len(), 10))
+	return nil
+}
+
+// acceptsGzip はクライアントの Accept-Encoding が gzip を受け付けるか確認する（q=0 は受け付けない）
+func acceptsGzip(values []string) bool {
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "gzip" && coding != "x-gzip" && coding != "*" {
+				continue
+			}
+			if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// hasResponseBody はレスポンスにボディがあるか確認する
+func hasResponseBody(resp *http.Response) bool {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.Request.Method == http.MethodHead {
+		return false
+	}
+	return resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified
+}
+
+// gzipBody は展開したボディと元のボディを閉じる
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
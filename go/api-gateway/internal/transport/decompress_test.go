@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestHTTPTransporter_Transport_DecompressResponses(t *testing.T) {
+	const body = `{"id":1,"name":"alice"}`
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(body))
+	gz.Close()
+
+	// Accept-Encoding に関係なく gzip で応答するバックエンド（Content-Length 無しの chunked）
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(compressed.Bytes())
+		w.(http.Flusher).Flush()
+	}))
+	defer server.Close()
+	backendURL, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		decompress     bool
+		wantEncoding   string
+		wantBody       []byte
+		wantETag       string
+	}{
+		{name: "受け付けない場合は展開する", acceptEncoding: "br", decompress: true, wantBody: []byte(body), wantETag: `W/"v1"`},
+		{name: "q=0 は受け付けない", acceptEncoding: "gzip;q=0, br", decompress: true, wantBody: []byte(body), wantETag: `W/"v1"`},
+		{name: "受け付ける場合はそのまま", acceptEncoding: "gzip, br", decompress: true, wantEncoding: "gzip", wantBody: compressed.Bytes(), wantETag: `"v1"`},
+		{name: "無効な場合はそのまま", acceptEncoding: "br", wantEncoding: "gzip", wantBody: compressed.Bytes(), wantETag: `"v1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+
+			backend := &Backend{URL: backendURL, DecompressResponses: tt.decompress}
+			if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if !bytes.Equal(w.Body.Bytes(), tt.wantBody) {
+				t.Errorf("body = %q, want %q", w.Body.Bytes(), tt.wantBody)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if tt.decompress && w.Header().Get("Content-Length") != strconv.Itoa(len(tt.wantBody)) {
+				t.Errorf("Content-Length = %q, want %d", w.Header().Get("Content-Length"), len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestHTTPTransporter_Transport_DecompressResponses_Limit(t *testing.T) {
+	// 展開すると上限を超えるレスポンス
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(bytes.Repeat([]byte("a"), 1<<16))
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+	backendURL, _ := url.Parse(server.URL)
+
+	req := httptest.NewRequest(http.MethodGet, "/files/1", nil)
+	req.Header.Set("Accept-Encoding", "identity")
+	w := httptest.NewRecorder()
+	backend := &Backend{URL: backendURL, DecompressResponses: true, MaxResponseBody: 1024}
+	if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,341 @@
+package transport
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-gateway/internal/errors"
+)
+
+// gRPCのステータスコード（https://grpc.github.io/grpc/core/md_doc_statuscodes.html）
+const (
+	grpcCodeUnknown           = 2
+	grpcCodeInvalidArgument   = 3
+	grpcCodeDeadlineExceeded  = 4
+	grpcCodePermissionDenied  = 7
+	grpcCodeResourceExhausted = 8
+	grpcCodeUnimplemented     = 12
+	grpcCodeInternal          = 13
+	grpcCodeUnavailable       = 14
+	grpcCodeUnauthenticated   = 16
+)
+
+// gRPCのヘッダー
+const (
+	headerGRPCStatus  = "Grpc-Status"
+	headerGRPCMessage = "Grpc-Message"
+	headerGRPCTimeout = "Grpc-Timeout"
+)
+
+// GRPCOptions はgRPCのバックエンドへの転送の設定
+type GRPCOptions struct {
+	// MethodTimeouts はメソッドごとのタイムアウト
+	// キーは "/package.Service/Method"、サービスの全てのメソッドに適用する場合は "/package.Service/*"
+	MethodTimeouts map[string]time.Duration
+}
+
+// timeout はメソッドのタイムアウトを返す（設定が無い場合は0）
+// メソッドの設定をサービスの設定より優先する
+func (o *GRPCOptions) timeout(fullMethod string) time.Duration {
+	if timeout, ok := o.MethodTimeouts[fullMethod]; ok {
+		return timeout
+	}
+	if service, _, ok := ParseGRPCMethod(fullMethod); ok {
+		return o.MethodTimeouts["/"+service+"/*"]
+	}
+	return 0
+}
+
+// GRPCTransporter はHTTP/2でgRPCの呼び出しをバックエンドへ転送する
+// ストリーミングのメッセージは到着ごとに転送し、バックエンドのトレーラー（grpc-status など）をそのままクライアントへ返す
+// 転送できない場合は HTTP のエラーレスポンスではなく gRPC のステータス（Trailers-Only）で応答する
+type GRPCTransporter struct {
+	// transports はプロトコルと接続プールの設定ごとのRoundTripper
+	transports *roundTrippers
+}
+
+// NewGRPCTransporter は新しいGRPCTransporterを作成する
+func NewGRPCTransporter() *GRPCTransporter {
+	return &GRPCTransporter{
+		transports: newRoundTrippers(),
+	}
+}
+
+// IsGRPCRequest はHTTP/2のgRPCの呼び出しか確認する（gRPC-Webは含まない）
+func IsGRPCRequest(r *http.Request) bool {
+	if r.ProtoMajor != 2 || r.Method != http.MethodPost {
+		return false
+	}
+	contentType := r.Header.Get("Content-Type")
+	return contentType == "application/grpc" || strings.HasPrefix(contentType, "application/grpc+") || strings.HasPrefix(contentType, "application/grpc;")
+}
+
+// ParseGRPCMethod は "/package.Service/Method" 形式のパスをサービス名とメソッド名に分ける
+func ParseGRPCMethod(path string) (service, method string, ok bool) {
+	rest, found := strings.CutPrefix(path, "/")
+	if !found {
+		return "", "", false
+	}
+	service, method, found = strings.Cut(rest, "/")
+	if !found || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", false
+	}
+	return service, method, true
+}
+
+// Transport はgRPCの呼び出しをバックエンドに転送する
+// 期限は クライアントの grpc-timeout・メソッドのタイムアウト（無い場合はバックエンドの timeout）・ctx のうち最も短いものを使い、
+// 残り時間を grpc-timeout でバックエンドへ伝える
+func (t *GRPCTransporter) Transport(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *Backend) (err error) {
+	if backend == nil || backend.URL == nil {
+		return errors.NewBadGatewayError("invalid backend configuration")
+	}
+	if !IsGRPCRequest(req) {
+		return errors.NewError(http.StatusUnsupportedMediaType, "GRPC_REQUIRED", "backend accepts only gRPC requests over HTTP/2")
+	}
+	if _, _, ok := ParseGRPCMethod(req.URL.Path); !ok {
+		return errors.NewError(http.StatusNotFound, "GRPC_METHOD_INVALID", fmt.Sprintf("invalid gRPC method: %s", req.URL.Path))
+	}
+
+	clientCtx := ctx
+	timeout := backend.Timeout
+	if backend.GRPC != nil {
+		if methodTimeout := backend.GRPC.timeout(req.URL.Path); methodTimeout > 0 {
+			timeout = methodTimeout
+		}
+	}
+	if clientTimeout, ok := parseGRPCTimeout(req.Header.Get(headerGRPCTimeout)); ok && (timeout <= 0 || clientTimeout < timeout) {
+		timeout = clientTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+
+	// ヘッダーを書き込んだか記録し、ストリームの途中で期限を過ぎた場合はトレーラーでステータスを返す
+	gw := &grpcResponseWriter{ResponseWriter: w}
+	defer func() {
+		if rec := recover(); rec != nil {
+			if rec != http.ErrAbortHandler || ctx.Err() == nil {
+				panic(rec)
+			}
+			if clientCtx.Err() != nil && !stderrors.Is(clientCtx.Err(), context.DeadlineExceeded) {
+				clientAbortedTotal.Inc()
+				err = ErrClientAborted
+				return
+			}
+			if !gw.wroteHeader {
+				panic(rec)
+			}
+			w.Header().Set(http.TrailerPrefix+headerGRPCStatus, strconv.Itoa(grpcCodeDeadlineExceeded))
+			w.Header().Set(http.TrailerPrefix+headerGRPCMessage, encodeGRPCMessage("deadline exceeded"))
+		}
+	}()
+
+	originalURL := req.URL
+	req.URL = &url.URL{
+		Scheme: backend.URL.Scheme,
+		Host:   backend.URL.Host,
+		Path:   strings.TrimSuffix(backend.URL.Path, "/") + originalURL.Path,
+	}
+	req.Host = backend.URL.Host
+
+	for key, value := range backend.Headers {
+		req.Header.Set(key, value)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set(headerGRPCTimeout, encodeGRPCTimeout(time.Until(deadline)))
+	}
+
+	if backend.Credentials != nil {
+		token, err := backend.Credentials.Token(ctx)
+		if err != nil {
+			writeGRPCStatus(gw, grpcCodeUnavailable, fmt.Sprintf("failed to obtain backend access token: %v", err))
+			return nil
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	aborted := false
+	proxy := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, proxyErr error) {
+			switch {
+			case clientCtx.Err() != nil && !stderrors.Is(clientCtx.Err(), context.DeadlineExceeded):
+				aborted = true
+			case ctx.Err() != nil:
+				writeGRPCStatus(w, grpcCodeDeadlineExceeded, "deadline exceeded")
+			default:
+				writeGRPCStatus(w, grpcCodeUnavailable, fmt.Sprintf("backend unavailable: %v", proxyErr))
+			}
+		},
+		Transport: t.roundTripper(backend),
+		// ストリーミングのメッセージを溜め込まずに転送する
+		FlushInterval: -1,
+		BufferPool:    proxyBuffers,
+	}
+	proxy.ServeHTTP(gw, req)
+
+	if aborted {
+		clientAbortedTotal.Inc()
+		return ErrClientAborted
+	}
+	return nil
+}
+
+// roundTripper はバックエンドのプロトコルと接続プールの設定に対応するRoundTripperを返す
+// gRPCはHTTP/2が必須のため、プロトコルの指定が無い場合は https なら h2、http なら h2c を使う
+func (t *GRPCTransporter) roundTripper(backend *Backend) http.RoundTripper {
+	protocol := backend.Protocol
+	if protocol == ProtocolAuto {
+		protocol = ProtocolH2C
+		if backend.URL.Scheme == "https" {
+			protocol = ProtocolH2
+		}
+	}
+	return t.transports.get(protocol, backend.Pool)
+}
+
+// grpcResponseWriter はヘッダーを書き込んだか記録する
+type grpcResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (gw *grpcResponseWriter) WriteHeader(statusCode int) {
+	if statusCode >= http.StatusOK {
+		gw.wroteHeader = true
+	}
+	gw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (gw *grpcResponseWriter) Write(b []byte) (int, error) {
+	gw.wroteHeader = true
+	return gw.ResponseWriter.Write(b)
+}
+
+// Unwrap はhttp.ResponseControllerがFlushなどを元のResponseWriterへ委譲するために使う
+func (gw *grpcResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// WriteGRPCError はゲートウェイのエラー（HTTPステータス）を gRPC のステータスに変換して返す
+// gRPCのクライアントは HTTP のエラーレスポンスのボディを解釈できないため、Trailers-Only のレスポンスで返す
+func WriteGRPCError(w http.ResponseWriter, statusCode int, message string) {
+	writeGRPCStatus(w, grpcCodeFromHTTPStatus(statusCode), message)
+}
+
+// writeGRPCStatus は Trailers-Only（ヘッダーのみでボディの無い）のレスポンスで gRPC のステータスを返す
+func writeGRPCStatus(w http.ResponseWriter, code int, message string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/grpc")
+	h.Set(headerGRPCStatus, strconv.Itoa(code))
+	if message != "" {
+		h.Set(headerGRPCMessage, encodeGRPCMessage(message))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// grpcCodeFromHTTPStatus はゲートウェイが返すHTTPステータスに対応する gRPC のステータスを返す
+func grpcCodeFromHTTPStatus(statusCode int) int {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return grpcCodeInvalidArgument
+	case http.StatusUnauthorized:
+		return grpcCodeUnauthenticated
+	case http.StatusForbidden:
+		return grpcCodePermissionDenied
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return grpcCodeUnimplemented
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return grpcCodeDeadlineExceeded
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return grpcCodeResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return grpcCodeUnavailable
+	case http.StatusInternalServerError:
+		return grpcCodeInternal
+	default:
+		return grpcCodeUnknown
+	}
+}
+
+// parseGRPCTimeout は grpc-timeout ヘッダー（"100m" などの最大8桁の数値と単位）を解析する
+func parseGRPCTimeout(s string) (time.Duration, bool) {
+	if len(s) < 2 || len(s) > 9 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	// 時間の単位で最大値を指定された場合に time.Duration があふれないようにする
+	if n > int64((1<<63-1)/unit) {
+		return 1<<63 - 1, true
+	}
+	return time.Duration(n) * unit, true
+}
+
+// encodeGRPCTimeout は期限までの残り時間を grpc-timeout ヘッダーの値にする
+// 8桁に収まる最も細かい単位を使い、端数は切り上げる
+func encodeGRPCTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0n"
+	}
+	const maxValue = 99999999
+	units := []struct {
+		unit   time.Duration
+		suffix string
+	}{
+		{time.Nanosecond, "n"},
+		{time.Microsecond, "u"},
+		{time.Millisecond, "m"},
+		{time.Second, "S"},
+		{time.Minute, "M"},
+		{time.Hour, "H"},
+	}
+	for _, u := range units {
+		value := (d + u.unit - 1) / u.unit
+		if value <= maxValue {
+			return strconv.FormatInt(int64(value), 10) + u.suffix
+		}
+	}
+	return strconv.Itoa(maxValue) + "H"
+}
+
+// encodeGRPCMessage は grpc-message ヘッダーの値をパーセントエンコードする
+// 表示可能なASCII以外と % をエンコードする
+func encodeGRPCMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
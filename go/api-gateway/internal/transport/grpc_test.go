@@ -0,0 +1,235 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"api-gateway/internal/errors"
+)
+
+func TestParseGRPCMethod(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantService string
+		wantMethod  string
+		wantOK      bool
+	}{
+		{path: "/inventory.v1.InventoryService/GetStock", wantService: "inventory.v1.InventoryService", wantMethod: "GetStock", wantOK: true},
+		{path: "/inventory.v1.InventoryService/*", wantService: "inventory.v1.InventoryService", wantMethod: "*", wantOK: true},
+		{path: "/inventory.v1.InventoryService"},
+		{path: "/inventory.v1.InventoryService/GetStock/extra"},
+		{path: "inventory.v1.InventoryService/GetStock"},
+		{path: "//GetStock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			service, method, ok := ParseGRPCMethod(tt.path)
+			if service != tt.wantService || method != tt.wantMethod || ok != tt.wantOK {
+				t.Errorf("ParseGRPCMethod() = (%q, %q, %v), want (%q, %q, %v)", service, method, ok, tt.wantService, tt.wantMethod, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGRPCTimeout(t *testing.T) {
+	parseTests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "100m", want: 100 * time.Millisecond, wantOK: true},
+		{value: "3S", want: 3 * time.Second, wantOK: true},
+		{value: "2H", want: 2 * time.Hour, wantOK: true},
+		{value: "99999999H", want: 1<<63 - 1, wantOK: true},
+		{value: "100"},
+		{value: "m"},
+		{value: "100x"},
+		{value: "123456789m"},
+	}
+	for _, tt := range parseTests {
+		got, ok := parseGRPCTimeout(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseGRPCTimeout(%q) = (%v, %v), want (%v, %v)", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	encodeTests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 50 * time.Millisecond, want: "50000000n"},
+		{d: 2 * time.Second, want: "2000000u"},
+		{d: 10 * time.Minute, want: "600000m"},
+		{d: 0, want: "0n"},
+	}
+	for _, tt := range encodeTests {
+		if got := encodeGRPCTimeout(tt.d); got != tt.want {
+			t.Errorf("encodeGRPCTimeout(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestGRPCOptions_timeout(t *testing.T) {
+	options := &GRPCOptions{MethodTimeouts: map[string]time.Duration{
+		"/inventory.v1.InventoryService/*":          time.Second,
+		"/inventory.v1.InventoryService/WatchStock": time.Minute,
+	}}
+
+	tests := map[string]time.Duration{
+		"/inventory.v1.InventoryService/WatchStock": time.Minute,
+		"/inventory.v1.InventoryService/GetStock":   time.Second,
+		"/orders.v1.OrderService/GetOrder":          0,
+	}
+	for method, want := range tests {
+		if got := options.timeout(method); got != want {
+			t.Errorf("timeout(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestEncodeGRPCMessage(t *testing.T) {
+	if got := encodeGRPCMessage("100% 在庫なし"); got != "100%25 %E5%9C%A8%E5%BA%AB%E3%81%AA%E3%81%97" {
+		t.Errorf("encodeGRPCMessage() = %q", got)
+	}
+}
+
+// newH2CServer は平文のHTTP/2（prior knowledge）で待ち受けるテスト用のサーバを起動する
+func newH2CServer(t *testing.T, handler http.Handler) *url.URL {
+	t.Helper()
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	return u
+}
+
+// newGRPCRequest はクライアントからのgRPCの呼び出しを作成する
+func newGRPCRequest(method string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, method, bytes.NewReader(body))
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	return req
+}
+
+func TestGRPCTransporter_Transport(t *testing.T) {
+	// 長さ付きのメッセージ（圧縮なし・長さ3）
+	message := []byte{0, 0, 0, 0, 3, 'a', 'b', 'c'}
+
+	type received struct {
+		proto   string
+		path    string
+		timeout string
+		body    []byte
+	}
+	requests := make(chan received, 1)
+	backendURL := newH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{proto: r.Proto, path: r.URL.Path, timeout: r.Header.Get("Grpc-Timeout"), body: body}
+
+		if r.URL.Path == "/inventory.v1.InventoryService/WatchStock" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		w.Header().Set(http.TrailerPrefix+"X-Stock-Version", "42")
+	}))
+
+	backend := &Backend{
+		URL:     backendURL,
+		Timeout: 30 * time.Second,
+		GRPC: &GRPCOptions{MethodTimeouts: map[string]time.Duration{
+			"/inventory.v1.InventoryService/WatchStock": 50 * time.Millisecond,
+		}},
+	}
+	transporter := NewGRPCTransporter()
+
+	t.Run("HTTP/2で転送し、トレーラーを返す", func(t *testing.T) {
+		req := newGRPCRequest("/inventory.v1.InventoryService/GetStock", message)
+		req.Header.Set("Grpc-Timeout", "5S")
+		w := httptest.NewRecorder()
+
+		if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got := <-requests
+		if got.proto != "HTTP/2.0" || got.path != "/inventory.v1.InventoryService/GetStock" || !bytes.Equal(got.body, message) {
+			t.Errorf("unexpected backend request: %+v", got)
+		}
+		// クライアントの期限（5秒）がバックエンドの timeout より短いため、残り時間を伝える
+		if timeout, ok := parseGRPCTimeout(got.timeout); !ok || timeout > 5*time.Second || timeout < 4*time.Second {
+			t.Errorf("expected grpc-timeout of about 5s, got %q", got.timeout)
+		}
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		if !bytes.Equal(body, message) {
+			t.Errorf("body = %v, want %v", body, message)
+		}
+		if status := resp.Trailer.Get("Grpc-Status"); status != "0" {
+			t.Errorf("expected trailer grpc-status 0, got %q", status)
+		}
+		if version := resp.Trailer.Get("X-Stock-Version"); version != "42" {
+			t.Errorf("expected trailer X-Stock-Version 42, got %q", version)
+		}
+	})
+
+	t.Run("メソッドのタイムアウトを過ぎると DEADLINE_EXCEEDED を返す", func(t *testing.T) {
+		req := newGRPCRequest("/inventory.v1.InventoryService/WatchStock", message)
+		w := httptest.NewRecorder()
+
+		if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		<-requests
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+		if status := w.Header().Get("Grpc-Status"); status != "4" {
+			t.Errorf("expected grpc-status 4, got %q", status)
+		}
+	})
+
+	t.Run("バックエンドに接続できない場合は UNAVAILABLE を返す", func(t *testing.T) {
+		unreachable, _ := url.Parse("http://127.0.0.1:1")
+		req := newGRPCRequest("/inventory.v1.InventoryService/GetStock", message)
+		w := httptest.NewRecorder()
+
+		if err := transporter.Transport(context.Background(), w, req, &Backend{URL: unreachable, GRPC: &GRPCOptions{}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status := w.Header().Get("Grpc-Status"); status != "14" {
+			t.Errorf("expected grpc-status 14, got %q", status)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/grpc" {
+			t.Errorf("expected Content-Type application/grpc, got %q", contentType)
+		}
+	})
+
+	t.Run("gRPCでないリクエストは転送しない", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/inventory.v1.InventoryService/GetStock", nil)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		err := transporter.Transport(context.Background(), w, req, backend)
+		var gatewayErr errors.GatewayError
+		if !stderrors.As(err, &gatewayErr) || gatewayErr.StatusCode() != http.StatusUnsupportedMediaType {
+			t.Errorf("expected 415 error, got %v", err)
+		}
+	})
+}
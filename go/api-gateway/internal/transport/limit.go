@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"api-gateway/internal/buffer"
+)
+
+// ResponseTooLargeError はバックエンドのレスポンスボディが上限を超えた場合のエラー
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("backend response body exceeds limit of %d bytes", e.Limit)
+}
+
+// limitResponseBody はレスポンスボディが上限以内か確認する
+// Content-Lengthが無い場合は上限+1バイトまで読み込んで判定するため、ストリーミングはされなくなる
+// 読み込んだボディは buffer.SpillBuffer に保持し、メモリ上限を超えた分は一時ファイルへ書き出す
+func limitResponseBody(resp *http.Response, limit int64, bufConfig buffer.Config) error {
+	if resp.ContentLength > limit {
+		resp.Body.Close()
+		return &ResponseTooLargeError{Limit: limit}
+	}
+	if resp.ContentLength >= 0 {
+		return nil
+	}
+
+	buf := buffer.New(bufConfig)
+	_, err := copyBuffer(buf, io.LimitReader(resp.Body, limit+1))
+	resp.Body.Close()
+	if err != nil {
+		buf.Close()
+		return fmt.Errorf("failed to read backend response body: %w", err)
+	}
+	if buf.Len() > limit {
+		buf.Close()
+		return &ResponseTooLargeError{Limit: limit}
+	}
+
+	// 転送後にReverseProxyがボディを Close した時点で一時ファイルも削除される
+	body, err := buf.Reader()
+	if err != nil {
+		buf.Close()
+		return fmt.Errorf("failed to read buffered response body: %w", err)
+	}
+	resp.Body = body
+	resp.ContentLength = buf.Len()
+	resp.Header.Set("Content-Length", strconv.FormatInt(buf.Len(), 10))
+	return nil
+}
@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/buffer"
+)
+
+func TestLimitResponseBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		limit         int64
+		wantErr       bool
+	}{
+		{name: "known length within limit", body: "hello", contentLength: 5, limit: 5},
+		{name: "known length over limit", body: "hello!", contentLength: 6, limit: 5, wantErr: true},
+		{name: "unknown length within limit", body: "hello", contentLength: -1, limit: 5},
+		{name: "unknown length over limit", body: "hello!", contentLength: -1, limit: 5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header:        make(http.Header),
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: tt.contentLength,
+			}
+
+			// メモリ上限を小さくして一時ファイルへの書き出しも確認する
+			dir := t.TempDir()
+			err := limitResponseBody(resp, tt.limit, buffer.Config{MemoryLimit: 2, TempDir: dir})
+			if tt.wantErr {
+				var tooLarge *ResponseTooLargeError
+				if !errors.As(err, &tooLarge) {
+					t.Fatalf("limitResponseBody() error = %v, want ResponseTooLargeError", err)
+				}
+				if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+					t.Errorf("temp files remain after error: %v", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// 読み込み済みのボディがそのまま転送されること
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if resp.ContentLength != int64(len(tt.body)) {
+				t.Errorf("ContentLength = %d, want %d", resp.ContentLength, len(tt.body))
+			}
+
+			// ボディを Close すると一時ファイルが削除されること
+			resp.Body.Close()
+			if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+				t.Errorf("temp files remain after Close: %v", files)
+			}
+		})
+	}
+}
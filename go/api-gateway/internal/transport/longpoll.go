@@ -0,0 +1,374 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-gateway/internal/errors"
+	"api-gateway/internal/metrics"
+)
+
+const (
+	// LongPollSSE と LongPollWebSocket はロングポーリングを中継するバックエンドのプロトコル
+	LongPollSSE       = "sse"
+	LongPollWebSocket = "websocket"
+
+	// DefaultLongPollTimeout はイベントを待つ時間のデフォルト値
+	DefaultLongPollTimeout = 30 * time.Second
+	// DefaultLongPollMaxMessage はイベント1件の上限バイト数のデフォルト値
+	DefaultLongPollMaxMessage = 1 << 20 // 1MiB
+	// DefaultLongPollCursorParam はSSEの Last-Event-ID として送るクエリパラメータのデフォルト名
+	DefaultLongPollCursorParam = "last_event_id"
+
+	// websocketGUID は Sec-WebSocket-Accept の計算に使う固定値（RFC 6455）
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// longPollsTotal はロングポーリングの結果（event, timeout, error）ごとのリクエスト数
+var longPollsTotal = metrics.NewCounterVec(
+	"gateway_long_polls_total",
+	"Number of long-poll requests bridged to streaming backends, by protocol and result.",
+	"protocol", "result",
+)
+
+// LongPollOptions は従来のロングポーリングのクライアントを、SSE または WebSocket のバックエンドへ中継する設定
+// ゲートウェイがバックエンドとストリーミングで接続し、最初のイベントを受け取るか Timeout になるまでリクエストを保留する
+// イベントを受け取った場合は200でイベントのデータをボディに返し（SSEの id は X-Event-Id、event は X-Event-Type）、
+// Timeout になった場合は204を返す。クライアントは応答を受け取るたびに再度リクエストする
+type LongPollOptions struct {
+	// Protocol はバックエンドのプロトコル（sse, websocket）
+	Protocol string
+	// Timeout はイベントを待つ時間（0は DefaultLongPollTimeout）
+	Timeout time.Duration
+	// ContentType はイベントを返すレスポンスのContent-Type（空は application/json）
+	ContentType string
+	// CursorParam はSSEの Last-Event-ID として送るクエリパラメータ（空は DefaultLongPollCursorParam）
+	CursorParam string
+	// MaxMessage はイベント1件の上限バイト数（0は DefaultLongPollMaxMessage）
+	MaxMessage int64
+}
+
+// connectionHeaders はゲートウェイが新しく作るバックエンドへのリクエストに転送しない、接続と圧縮に関するヘッダー
+// 圧縮は net/http に任せ、展開したレスポンスを受け取る
+var connectionHeaders = []string{"Connection", "Upgrade", "Keep-Alive", "Te", "Trailer", "Transfer-Encoding", "Accept-Encoding"}
+
+// errLongPollClosed はバックエンドがイベントを送らずに接続を閉じたことを表す
+var errLongPollClosed = stderrors.New("long-poll backend closed the stream")
+
+// longPollEvent はバックエンドから受け取ったイベント
+type longPollEvent struct {
+	id    string
+	event string
+	data  []byte
+}
+
+// bridgeLongPoll はロングポーリングのリクエストをストリーミングのバックエンドへ中継する
+func (t *HTTPTransporter) bridgeLongPoll(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *Backend) error {
+	opts := backend.LongPoll
+	if req.Method != http.MethodGet {
+		return errors.NewError(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET is allowed for long-poll routes")
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLongPollTimeout
+	}
+	clientCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out, err := t.newLongPollRequest(ctx, req, backend)
+	if err != nil {
+		return err
+	}
+
+	var event *longPollEvent
+	if opts.Protocol == LongPollWebSocket {
+		// Upgrade はHTTP/1.1でのみ行える
+		event, err = t.receiveWebSocket(out, opts, t.roundTripper(ProtocolHTTP1, backend.Pool))
+	} else {
+		event, err = t.receiveSSE(out, opts, t.roundTripper(backend.Protocol, backend.Pool))
+	}
+
+	switch {
+	case clientCtx.Err() != nil:
+		if stderrors.Is(clientCtx.Err(), context.DeadlineExceeded) {
+			return ErrRequestTimeout
+		}
+		clientAbortedTotal.Inc()
+		return ErrClientAborted
+	case event != nil:
+		longPollsTotal.With(opts.Protocol, "event").Inc()
+		contentType := opts.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		if event.id != "" {
+			w.Header().Set("X-Event-Id", event.id)
+		}
+		if event.event != "" {
+			w.Header().Set("X-Event-Type", event.event)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(event.data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(event.data)
+		return nil
+	case stderrors.Is(err, errLongPollClosed) || stderrors.Is(err, io.EOF) || ctx.Err() != nil:
+		// 期限までにイベントが無い場合、またはバックエンドがイベントを送らずに接続を閉じた場合は、再度リクエストさせる
+		longPollsTotal.With(opts.Protocol, "timeout").Inc()
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		longPollsTotal.With(opts.Protocol, "error").Inc()
+		var gatewayErr errors.GatewayError
+		if stderrors.As(err, &gatewayErr) {
+			return gatewayErr
+		}
+		return errors.NewBadGatewayError(err.Error())
+	}
+}
+
+// newLongPollRequest はバックエンドへのストリーミングのリクエストを作成する
+// クライアントのヘッダーは転送するが、接続に関するヘッダーと圧縮の指定は送らない
+func (t *HTTPTransporter) newLongPollRequest(ctx context.Context, req *http.Request, backend *Backend) (*http.Request, error) {
+	target := *backend.URL
+	target.Path = backend.URL.Path + req.URL.Path
+	target.RawPath = ""
+	target.RawQuery = req.URL.RawQuery
+
+	out, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, errors.NewBadGatewayError(fmt.Sprintf("invalid long-poll backend request: %v", err))
+	}
+	out.Header = req.Header.Clone()
+	for _, name := range connectionHeaders {
+		out.Header.Del(name)
+	}
+	for key, value := range backend.Headers {
+		out.Header.Set(key, value)
+	}
+	if backend.Credentials != nil {
+		token, err := backend.Credentials.Token(ctx)
+		if err != nil {
+			return nil, errors.NewBadGatewayError(fmt.Sprintf("failed to obtain backend access token: %v", err))
+		}
+		out.Header.Set("Authorization", "Bearer "+token)
+	}
+	return out, nil
+}
+
+// receiveSSE はSSEのバックエンドから最初のイベント（data のあるもの）を受け取る
+func (t *HTTPTransporter) receiveSSE(req *http.Request, opts *LongPollOptions, roundTripper http.RoundTripper) (*longPollEvent, error) {
+	cursorParam := opts.CursorParam
+	if cursorParam == "" {
+		cursorParam = DefaultLongPollCursorParam
+	}
+	if cursor := req.URL.Query().Get(cursorParam); cursor != "" {
+		req.Header.Set("Last-Event-ID", cursor)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sse backend returned status %d", resp.StatusCode)
+	}
+
+	maxMessage := longPollMaxMessage(opts)
+	reader := bufio.NewReader(resp.Body)
+	event := &longPollEvent{}
+	var data bytes.Buffer
+	hasData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// 空行でイベントを確定する（data の無いイベントとコメントは読み飛ばす）
+		if line == "" {
+			if hasData {
+				event.data = data.Bytes()
+				return event, nil
+			}
+			event.event = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+			if int64(data.Len()) > maxMessage {
+				return nil, fmt.Errorf("sse event exceeds limit of %d bytes", maxMessage)
+			}
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		}
+	}
+}
+
+// receiveWebSocket はWebSocketのバックエンドから最初のメッセージ（テキストまたはバイナリ）を受け取る
+func (t *HTTPTransporter) receiveWebSocket(req *http.Request, opts *LongPollOptions, roundTripper http.RoundTripper) (*longPollEvent, error) {
+	switch req.URL.Scheme {
+	case "ws":
+		req.URL.Scheme = "http"
+	case "wss":
+		req.URL.Scheme = "https"
+	}
+	keyBytes := make([]byte, 16)
+	rand.Read(keyBytes)
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return nil, fmt.Errorf("websocket backend returned status %d", resp.StatusCode)
+	}
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("websocket backend connection is not writable")
+	}
+	defer conn.Close()
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return nil, fmt.Errorf("invalid websocket handshake from backend")
+	}
+
+	// 期限を過ぎたら接続を閉じて読み込みを中断する
+	stop := context.AfterFunc(req.Context(), func() { conn.Close() })
+	defer stop()
+
+	data, err := readWebSocketMessage(bufio.NewReader(conn), longPollMaxMessage(opts))
+	if err != nil {
+		return nil, err
+	}
+	// 正常に終了したことをバックエンドへ伝える（失敗しても結果には影響しない）
+	writeWebSocketClose(conn)
+	return &longPollEvent{data: data}, nil
+}
+
+// readWebSocketMessage は最初のデータメッセージを読み込む（分割されたフレームは連結する）
+// バックエンドが接続を閉じた（close フレーム）場合は errLongPollClosed を返す
+func readWebSocketMessage(r *bufio.Reader, maxMessage int64) ([]byte, error) {
+	var message []byte
+	inMessage := false
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode := header[0]&0x80 != 0, header[0]&0x0f
+		masked, length := header[1]&0x80 != 0, int64(header[1]&0x7f)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return nil, err
+			}
+			length = int64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return nil, err
+			}
+			length = int64(binary.BigEndian.Uint64(ext[:]) & (1<<63 - 1))
+		}
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(r, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		if int64(len(message))+length > maxMessage {
+			return nil, fmt.Errorf("websocket message exceeds limit of %d bytes", maxMessage)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch {
+		case opcode == 0x8: // close
+			return nil, errLongPollClosed
+		case opcode >= 0x8: // ping / pong は読み飛ばす
+			continue
+		case opcode == 0x1 || opcode == 0x2:
+			message, inMessage = payload, true
+		case opcode == 0x0 && inMessage: // continuation
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected websocket opcode %d", opcode)
+		}
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// writeWebSocketClose はクライアントとして（マスクした）close フレームを送る
+func writeWebSocketClose(w io.Writer) {
+	var mask [4]byte
+	rand.Read(mask[:])
+	status := []byte{0x03, 0xe8} // 1000 Normal Closure
+	frame := []byte{0x88, 0x80 | byte(len(status))}
+	frame = append(frame, mask[:]...)
+	for i, b := range status {
+		frame = append(frame, b^mask[i%4])
+	}
+	w.Write(frame)
+}
+
+// websocketAccept は Sec-WebSocket-Key に対する Sec-WebSocket-Accept を計算する
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func longPollMaxMessage(opts *LongPollOptions) int64 {
+	if opts.MaxMessage > 0 {
+		return opts.MaxMessage
+	}
+	return DefaultLongPollMaxMessage
+}
@@ -0,0 +1,245 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"api-gateway/internal/errors"
+)
+
+// newSSEServer は events を書き込んだ後、クライアントが切断するまで接続を保持するSSEのサーバーを作成する
+func newSSEServer(t *testing.T, status int, events string, gotLastEventID *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gotLastEventID != nil {
+			*gotLastEventID = r.Header.Get("Last-Event-ID")
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(events))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newWebSocketServer は frames をそのまま書き込んだ後、クライアントが切断するまで接続を保持するWebSocketのサーバーを作成する
+func newWebSocketServer(t *testing.T, frames []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Write(frames)
+		rw.Flush()
+		// クライアントの close フレームか切断まで待つ
+		bufio.NewReader(conn).ReadByte()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPTransporter_Transport_LongPollSSE(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		status     int
+		events     string
+		wantStatus int
+		wantBody   string
+		wantID     string
+		wantType   string
+		wantCursor string
+		wantErr    int
+	}{
+		{
+			name:       "最初のイベントを返す",
+			method:     http.MethodGet,
+			target:     "/poll?last_event_id=41",
+			status:     http.StatusOK,
+			events:     ": keepalive\n\nid: 42\nevent: message\ndata: {\"text\":\"hello\"}\n\nid: 43\ndata: {}\n\n",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"text":"hello"}`,
+			wantID:     "42",
+			wantType:   "message",
+			wantCursor: "41",
+		},
+		{
+			name:       "複数行のdataは改行でつなげる",
+			method:     http.MethodGet,
+			target:     "/poll",
+			status:     http.StatusOK,
+			events:     "data: line1\r\ndata: line2\r\n\r\n",
+			wantStatus: http.StatusOK,
+			wantBody:   "line1\nline2",
+		},
+		{
+			name:       "イベントが無ければ204",
+			method:     http.MethodGet,
+			target:     "/poll",
+			status:     http.StatusOK,
+			events:     "event: ping\n\n",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:    "バックエンドのエラーは502",
+			method:  http.MethodGet,
+			target:  "/poll",
+			status:  http.StatusInternalServerError,
+			wantErr: http.StatusBadGateway,
+		},
+		{
+			name:    "GET以外は405",
+			method:  http.MethodPost,
+			target:  "/poll",
+			status:  http.StatusOK,
+			wantErr: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCursor string
+			server := newSSEServer(t, tt.status, tt.events, &gotCursor)
+			backendURL, _ := url.Parse(server.URL)
+			backend := &Backend{URL: backendURL, LongPoll: &LongPollOptions{Protocol: LongPollSSE, Timeout: 200 * time.Millisecond}}
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			w := httptest.NewRecorder()
+			err := NewHTTPTransporter().Transport(context.Background(), w, req, backend)
+			if tt.wantErr != 0 {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != tt.wantErr {
+					t.Fatalf("Transport() error = %v, want status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transport() error = %v", err)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Get("X-Event-Id"); got != tt.wantID {
+				t.Errorf("X-Event-Id = %q, want %q", got, tt.wantID)
+			}
+			if got := w.Header().Get("X-Event-Type"); got != tt.wantType {
+				t.Errorf("X-Event-Type = %q, want %q", got, tt.wantType)
+			}
+			if gotCursor != tt.wantCursor {
+				t.Errorf("Last-Event-ID = %q, want %q", gotCursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestHTTPTransporter_Transport_LongPollWebSocket(t *testing.T) {
+	tests := []struct {
+		name       string
+		frames     []byte
+		maxMessage int64
+		wantStatus int
+		wantBody   string
+		wantErr    int
+	}{
+		{
+			name:       "テキストメッセージを返す",
+			frames:     append([]byte{0x81, 5}, "hello"...),
+			wantStatus: http.StatusOK,
+			wantBody:   "hello",
+		},
+		{
+			name:       "pingを読み飛ばし分割されたメッセージをつなげる",
+			frames:     append(append(append([]byte{0x89, 0}, 0x01, 3), "hel"...), append([]byte{0x80, 2}, "lo"...)...),
+			wantStatus: http.StatusOK,
+			wantBody:   "hello",
+		},
+		{
+			name:       "closeフレームは204",
+			frames:     []byte{0x88, 2, 0x03, 0xe8},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "メッセージが無ければ204",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "上限を超えるメッセージは502",
+			frames:     append([]byte{0x81, 5}, "hello"...),
+			maxMessage: 4,
+			wantErr:    http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebSocketServer(t, tt.frames)
+			backendURL, _ := url.Parse("ws" + server.URL[len("http"):])
+			backend := &Backend{URL: backendURL, LongPoll: &LongPollOptions{
+				Protocol:    LongPollWebSocket,
+				Timeout:     200 * time.Millisecond,
+				ContentType: "text/plain",
+				MaxMessage:  tt.maxMessage,
+			}}
+
+			req := httptest.NewRequest(http.MethodGet, "/poll", nil)
+			w := httptest.NewRecorder()
+			err := NewHTTPTransporter().Transport(context.Background(), w, req, backend)
+			if tt.wantErr != 0 {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != tt.wantErr {
+					t.Fatalf("Transport() error = %v, want status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transport() error = %v", err)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusOK && w.Header().Get("Content-Type") != "text/plain" {
+				t.Errorf("Content-Type = %q, want text/plain", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHTTPTransporter_Transport_LongPollClientAborted(t *testing.T) {
+	server := newSSEServer(t, http.StatusOK, "", nil)
+	backendURL, _ := url.Parse(server.URL)
+	backend := &Backend{URL: backendURL, LongPoll: &LongPollOptions{Protocol: LongPollSSE, Timeout: 5 * time.Second}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	req := httptest.NewRequest(http.MethodGet, "/poll", nil)
+	err := NewHTTPTransporter().Transport(ctx, httptest.NewRecorder(), req, backend)
+	if err != ErrClientAborted {
+		t.Fatalf("Transport() error = %v, want ErrClientAborted", err)
+	}
+}
@@ -0,0 +1,262 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/metrics"
+)
+
+const (
+	// DefaultMaskReplacement はマスクした値の置き換え後の文字列のデフォルト値
+	DefaultMaskReplacement = "****"
+
+	// DefaultMaskMaxBody はマスクするレスポンスボディの上限バイト数のデフォルト値
+	DefaultMaskMaxBody = 10 << 20 // 10MiB
+)
+
+// responseMaskedTotal はマスクのルールが適用されたレスポンス数
+var responseMaskedTotal = metrics.NewCounterVec(
+	"gateway_response_masked_total",
+	"Number of responses in which a masking rule masked at least one value, by route and rule.",
+	"route", "rule",
+)
+
+// BuiltinMaskPatterns は名前だけで使える組み込みのパターン
+var BuiltinMaskPatterns = map[string]string{
+	// 13〜19桁のカード番号（数字の間の空白・ハイフンを許す）。Luhnのチェックディジットが正しいもののみマスクする
+	"credit_card": `\b\d(?:[ -]?\d){12,18}\b`,
+	"email":       `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+}
+
+// MaskPattern は文字列の値のうちパターンに一致した部分をマスクするルール
+type MaskPattern struct {
+	// Name はメトリクスのラベルに使うルールの名前
+	Name   string
+	Regexp *regexp.Regexp
+}
+
+// ResponseMasker はレスポンスに含まれる個人情報（PII）をマスクする
+// JSONのレスポンスでは Fields のフィールドの値と、全ての文字列の値のうち Patterns に一致した部分をマスクする
+// テキストのレスポンスでは本文のうち Patterns に一致した部分をマスクする。それ以外（画像など）はマスクしない
+type ResponseMasker struct {
+	// Route はメトリクスのラベルに使うルートのパス
+	Route string
+	// Fields はマスクするJSONのフィールド（"user.email" のようにドットで区切る。配列は全ての要素が対象）
+	Fields []string
+	// Patterns は文字列の値からマスクする部分のパターン
+	Patterns []MaskPattern
+	// Replacement はマスクした値の置き換え後の文字列（空は DefaultMaskReplacement）
+	Replacement string
+	// MaxBody はマスクするボディの上限バイト数（0は DefaultMaskMaxBody）
+	// マスクできないレスポンスをそのまま返さないよう、上限を超えた場合は502を返す
+	MaxBody int64
+}
+
+// NewMaskPattern は名前と正規表現からMaskPatternを作成する
+// 正規表現が空の場合は組み込みのパターン（BuiltinMaskPatterns）を使う
+func NewMaskPattern(name, expr string) (MaskPattern, error) {
+	if expr == "" {
+		builtin, ok := BuiltinMaskPatterns[name]
+		if !ok {
+			return MaskPattern{}, fmt.Errorf("unknown builtin pattern: %q", name)
+		}
+		expr = builtin
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return MaskPattern{}, fmt.Errorf("invalid pattern %q: %w", name, err)
+	}
+	return MaskPattern{Name: name, Regexp: re}, nil
+}
+
+// prepareRequest はバックエンドが圧縮せずに応答するよう Accept-Encoding を削除する
+// （Accept-Encoding が無い場合、http.Transport は gzip を要求して透過的に展開する）
+func (m *ResponseMasker) prepareRequest(req *http.Request) {
+	req.Header.Del("Accept-Encoding")
+}
+
+// mask はレスポンスボディをマスクする
+// ボディを全て読み込むため、マスクの対象のレスポンスはストリーミングされなくなる
+func (m *ResponseMasker) mask(resp *http.Response) error {
+	kind := maskableContent(resp.Header.Get("Content-Type"))
+	if kind == "" || resp.Body == nil || resp.Body == http.NoBody || resp.Request.Method == http.MethodHead {
+		return nil
+	}
+	// 展開できない形式で圧縮されている場合はマスクできないため、そのまま返さない
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		resp.Body.Close()
+		return fmt.Errorf("cannot mask response with content encoding %q", encoding)
+	}
+
+	maxBody := m.MaxBody
+	if maxBody <= 0 {
+		maxBody = DefaultMaskMaxBody
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read backend response body: %w", err)
+	}
+	if int64(len(body)) > maxBody {
+		return fmt.Errorf("cannot mask response body larger than %d bytes", maxBody)
+	}
+
+	masked := make(map[string]bool)
+	if kind == "json" {
+		body = m.maskJSON(body, masked)
+	} else {
+		body = []byte(m.maskString(string(body), masked))
+	}
+	for rule := range masked {
+		responseMaskedTotal.With(m.Route, rule).Inc()
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	if len(masked) > 0 {
+		// 内容が変わったため、バックエンドの検証子は使えない
+		resp.Header.Del("ETag")
+		resp.Header.Del("Content-MD5")
+	}
+	return nil
+}
+
+// maskableContent はマスクの対象のContent-Typeか確認し、"json" か "text" を返す（対象外は空）
+func maskableContent(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return "text"
+	default:
+		return ""
+	}
+}
+
+// maskJSON はJSONのボディをマスクする。マスクしなかった場合は元のボディをそのまま返す
+// JSONとして解析できない場合はテキストとしてマスクする
+func (m *ResponseMasker) maskJSON(body []byte, masked map[string]bool) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []byte(m.maskString(string(body), masked))
+	}
+
+	for _, field := range m.Fields {
+		v = m.maskField(v, strings.Split(field, "."), field, masked)
+	}
+	v = m.maskValues(v, masked)
+	if len(masked) == 0 {
+		return body
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return body
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// maskField は path のフィールドの値を置き換える（配列の場合は全ての要素が対象）
+func (m *ResponseMasker) maskField(v any, path []string, rule string, masked map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		value, ok := v[path[0]]
+		if !ok {
+			return v
+		}
+		if len(path) == 1 {
+			if value != nil {
+				v[path[0]] = m.replacement()
+				masked[rule] = true
+			}
+			return v
+		}
+		v[path[0]] = m.maskField(value, path[1:], rule, masked)
+	case []any:
+		for i, item := range v {
+			v[i] = m.maskField(item, path, rule, masked)
+		}
+	}
+	return v
+}
+
+// maskValues は全ての文字列と数値の値のうちパターンに一致した部分を置き換える
+func (m *ResponseMasker) maskValues(v any, masked map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, value := range v {
+			v[k] = m.maskValues(value, masked)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = m.maskValues(item, masked)
+		}
+	case string:
+		return m.maskString(v, masked)
+	case json.Number:
+		// カード番号などが数値として返される場合
+		if s := m.maskString(v.String(), masked); s != v.String() {
+			return s
+		}
+	}
+	return v
+}
+
+// maskString は文字列のうちパターンに一致した部分を置き換える
+func (m *ResponseMasker) maskString(s string, masked map[string]bool) string {
+	for _, p := range m.Patterns {
+		s = p.Regexp.ReplaceAllStringFunc(s, func(match string) string {
+			if p.Name == "credit_card" && !luhnValid(match) {
+				return match
+			}
+			masked[p.Name] = true
+			return m.replacement()
+		})
+	}
+	return s
+}
+
+func (m *ResponseMasker) replacement() string {
+	if m.Replacement != "" {
+		return m.Replacement
+	}
+	return DefaultMaskReplacement
+}
+
+// luhnValid は数字（空白・ハイフンを除く）のLuhnのチェックディジットが正しいか確認する
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
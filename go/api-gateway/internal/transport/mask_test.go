@@ -0,0 +1,195 @@
+package transport
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func mustMaskPattern(t *testing.T, name, expr string) MaskPattern {
+	t.Helper()
+	p, err := NewMaskPattern(name, expr)
+	if err != nil {
+		t.Fatalf("NewMaskPattern(%q) error = %v", name, err)
+	}
+	return p
+}
+
+func TestResponseMasker_mask(t *testing.T) {
+	masker := &ResponseMasker{
+		Route:  "/test/mask",
+		Fields: []string{"customer.email", "items.serial"},
+		Patterns: []MaskPattern{
+			mustMaskPattern(t, "credit_card", ""),
+			mustMaskPattern(t, "phone", `0\d{1,4}-\d{1,4}-\d{4}`),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		wantRules   []string
+	}{
+		{
+			name:        "フィールドとパターンをマスクする",
+			contentType: "application/json",
+			body:        `{"customer":{"email":"a@example.com","name":"<Taro>"},"note":"card 4111 1111 1111 1111","items":[{"serial":"x1"},{"serial":"x2"}]}`,
+			want:        `{"customer":{"email":"****","name":"<Taro>"},"items":[{"serial":"****"},{"serial":"****"}],"note":"card ****"}`,
+			wantRules:   []string{"customer.email", "items.serial", "credit_card"},
+		},
+		{
+			name:        "数値のカード番号もマスクする",
+			contentType: "application/problem+json",
+			body:        `{"card":4111111111111111,"amount":1200.50}`,
+			want:        `{"amount":1200.50,"card":"****"}`,
+			wantRules:   []string{"credit_card"},
+		},
+		{
+			name:        "Luhnのチェックディジットが誤った番号はマスクしない",
+			contentType: "application/json",
+			body:        `{"order_id": 4111111111111112}`,
+			want:        `{"order_id": 4111111111111112}`,
+		},
+		{
+			name:        "テキストはパターンでマスクする",
+			contentType: "text/plain; charset=utf-8",
+			body:        "tel: 03-1234-5678",
+			want:        "tel: ****",
+			wantRules:   []string{"phone"},
+		},
+		{
+			name:        "対象外のContent-Typeはマスクしない",
+			contentType: "image/png",
+			body:        "03-1234-5678",
+			want:        "03-1234-5678",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := make(map[string]uint64)
+			for _, rule := range tt.wantRules {
+				before[rule] = responseMaskedTotal.With(masker.Route, rule).Value()
+			}
+
+			resp := &http.Response{
+				Header:  http.Header{"Content-Type": {tt.contentType}, "Etag": {`"v1"`}},
+				Body:    io.NopCloser(strings.NewReader(tt.body)),
+				Request: httptest.NewRequest(http.MethodGet, "/", nil),
+			}
+			if err := masker.mask(resp); err != nil {
+				t.Fatalf("mask() error = %v", err)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("body = %s, want %s", body, tt.want)
+			}
+			if resp.ContentLength != int64(len(body)) && tt.contentType != "image/png" {
+				t.Errorf("ContentLength = %d, want %d", resp.ContentLength, len(body))
+			}
+			if masked := len(tt.wantRules) > 0; (resp.Header.Get("ETag") == "") != masked {
+				t.Errorf("ETag = %q, masked %v", resp.Header.Get("ETag"), masked)
+			}
+			for _, rule := range tt.wantRules {
+				if got := responseMaskedTotal.With(masker.Route, rule).Value() - before[rule]; got != 1 {
+					t.Errorf("masked count for %s = %d, want 1", rule, got)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseMasker_mask_FailClosed(t *testing.T) {
+	tests := []struct {
+		name   string
+		masker *ResponseMasker
+		header http.Header
+		body   string
+	}{
+		{
+			name:   "展開できない圧縮形式",
+			masker: &ResponseMasker{Fields: []string{"email"}},
+			header: http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"br"}},
+			body:   "compressed",
+		},
+		{
+			name:   "上限を超えるボディ",
+			masker: &ResponseMasker{Fields: []string{"email"}, MaxBody: 8},
+			header: http.Header{"Content-Type": {"application/json"}},
+			body:   `{"email":"a@example.com"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header:  tt.header,
+				Body:    io.NopCloser(strings.NewReader(tt.body)),
+				Request: httptest.NewRequest(http.MethodGet, "/", nil),
+			}
+			if err := tt.masker.mask(resp); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestNewMaskPattern(t *testing.T) {
+	if _, err := NewMaskPattern("email", ""); err != nil {
+		t.Errorf("builtin pattern: unexpected error: %v", err)
+	}
+	if _, err := NewMaskPattern("passport", ""); err == nil {
+		t.Error("unknown builtin pattern: expected error")
+	}
+	if _, err := NewMaskPattern("id", "[0-9"); err == nil {
+		t.Error("invalid regex: expected error")
+	}
+}
+
+func TestHTTPTransporter_Transport_Masking(t *testing.T) {
+	// Accept-Encoding が無い場合は http.Transport が gzip を要求して展開するため、圧縮されたレスポンスもマスクできる
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := `{"email":"a@example.com","id":1}`
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(body))
+			gz.Close()
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	backendURL, _ := url.Parse(server.URL)
+	backend := &Backend{
+		URL:    backendURL,
+		Masker: &ResponseMasker{Route: "/users", Patterns: []MaskPattern{mustMaskPattern(t, "email", "")}},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	w := httptest.NewRecorder()
+
+	if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("expected uncompressed response, got Content-Encoding %q", encoding)
+	}
+	if body := w.Body.String(); body != `{"email":"****","id":1}` {
+		t.Errorf("body = %s", body)
+	}
+}
@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+const (
+	// DefaultMirrorMaxBody はミラーへ送るリクエストボディの上限バイト数のデフォルト値
+	DefaultMirrorMaxBody = 1 << 20 // 1MiB
+
+	// DefaultMirrorMaxInFlight はルートごとに同時にミラーへ送るリクエスト数の上限のデフォルト値
+	DefaultMirrorMaxInFlight = 100
+)
+
+// mirrorRequestsTotal はミラーへのリクエストの結果（sent, error, dropped, skipped）ごとの件数
+var mirrorRequestsTotal = metrics.NewCounterVec(
+	"gateway_mirror_requests_total",
+	"Number of requests copied to the mirror backend by result.",
+	"route", "result",
+)
+
+// Mirror はリクエストの複製をミラーのバックエンドへ非同期に送る
+// ミラーのレスポンスは読み捨て、クライアントへの応答を待たせることも、ミラーの障害がクライアントに影響することもない
+// 新しいバージョンのサービスに本番のトラフィックで負荷をかける用途に使う
+type Mirror struct {
+	// Route はメトリクスのラベルとログに使うルートのパス
+	Route string
+	// URL はミラーのバックエンドのURL
+	URL *url.URL
+	// Timeout はミラーへのリクエストのタイムアウト（0の場合はバックエンドのタイムアウト）
+	Timeout time.Duration
+	// Methods はミラーへ送るメソッド（空の場合は全てのメソッド）
+	Methods []string
+	// MaxBody はミラーへ送るリクエストボディの上限バイト数（0は DefaultMirrorMaxBody）。超える場合は送らない
+	MaxBody int64
+	// MaxInFlight は同時にミラーへ送るリクエスト数の上限（0は DefaultMirrorMaxInFlight）
+	// ミラーが遅い場合にゴルーチンと接続が溜まり続けないよう、上限に達している間のリクエストは送らない
+	MaxInFlight int64
+
+	inFlight atomic.Int64
+}
+
+// applies はミラーへ送るリクエストか確認する
+func (m *Mirror) applies(req *http.Request) bool {
+	return len(m.Methods) == 0 || slices.Contains(m.Methods, req.Method)
+}
+
+func (m *Mirror) maxBody() int64 {
+	if m.MaxBody > 0 {
+		return m.MaxBody
+	}
+	return DefaultMirrorMaxBody
+}
+
+func (m *Mirror) maxInFlight() int64 {
+	if m.MaxInFlight > 0 {
+		return m.MaxInFlight
+	}
+	return DefaultMirrorMaxInFlight
+}
+
+// startMirror はミラーへのリクエストをバックグラウンドで送る
+// req はバックエンド向けに書き換える前のリクエスト。ミラーへのリクエストはクライアントの切断やバックエンドのタイムアウトでは中断しない
+func (t *HTTPTransporter) startMirror(ctx context.Context, req *http.Request, backend *Backend) {
+	m := backend.Mirror
+	if m.inFlight.Add(1) > m.maxInFlight() {
+		m.inFlight.Add(-1)
+		mirrorRequestsTotal.With(m.Route, "dropped").Inc()
+		return
+	}
+
+	body, ok := bufferShadowBody(req, m.maxBody())
+	if !ok {
+		m.inFlight.Add(-1)
+		mirrorRequestsTotal.With(m.Route, "skipped").Inc()
+		return
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = backend.Timeout
+	}
+	mirrorReq := copyRequest(ctx, req, m.URL, body, backend.Headers)
+	roundTripper := t.roundTripper(backend.Protocol, backend.Pool)
+	logger := t.logger()
+
+	go func() {
+		defer m.inFlight.Add(-1)
+		if timeout > 0 {
+			reqCtx, cancel := context.WithTimeout(mirrorReq.Context(), timeout)
+			defer cancel()
+			mirrorReq = mirrorReq.WithContext(reqCtx)
+		}
+
+		resp, err := roundTripper.RoundTrip(mirrorReq)
+		if err != nil {
+			mirrorRequestsTotal.With(m.Route, "error").Inc()
+			logger.DebugContext(mirrorReq.Context(), "mirror request failed",
+				slog.String("route", m.Route),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		// 接続を再利用できるよう、レスポンスボディは読み捨ててから閉じる（MaxBody を超える分は読まずに接続ごと閉じる）
+		io.Copy(io.Discard, io.LimitReader(resp.Body, m.maxBody()))
+		resp.Body.Close()
+		mirrorRequestsTotal.With(m.Route, "sent").Inc()
+	}()
+}
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransporter_Transport_Mirror(t *testing.T) {
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("primary"))
+	}))
+	defer primaryServer.Close()
+
+	// mirrored はミラーが受け取ったリクエスト（メソッド、パス、ボディ）
+	type mirrored struct{ method, path, body string }
+	mirrorRequests := make(chan mirrored, 1)
+	release := make(chan struct{})
+	mirrorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrorRequests <- mirrored{r.Method, r.URL.RequestURI(), string(body)}
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mirrorServer.Close()
+	defer close(release)
+
+	primaryURL, _ := url.Parse(primaryServer.URL)
+	mirrorURL, _ := url.Parse(mirrorServer.URL)
+	transporter := NewHTTPTransporter()
+
+	newBackend := func(mirror *Mirror) *Backend {
+		mirror.URL = mirrorURL
+		return &Backend{URL: primaryURL, Mirror: mirror}
+	}
+	// transport はリクエストを転送し、クライアントへのレスポンスがミラーを待たずに移行元のものであることを確認する
+	transport := func(t *testing.T, backend *Backend, method, body string) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/orders?id=1", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if w.Code != http.StatusOK || w.Body.String() != "primary" {
+			t.Fatalf("expected primary response, got %d %s", w.Code, w.Body.String())
+		}
+	}
+	// expectMirrored はミラーがリクエストを受け取ったか確認する
+	expectMirrored := func(t *testing.T, want *mirrored) {
+		t.Helper()
+		timeout := 5 * time.Second
+		if want == nil {
+			timeout = 100 * time.Millisecond
+		}
+		select {
+		case got := <-mirrorRequests:
+			if want == nil {
+				t.Fatalf("unexpected mirror request: %+v", got)
+			}
+			if got != *want {
+				t.Errorf("mirror request = %+v, want %+v", got, *want)
+			}
+		case <-time.After(timeout):
+			if want != nil {
+				t.Fatal("timed out waiting for mirror request")
+			}
+		}
+	}
+
+	t.Run("ボディを含めて複製し、ミラーの応答を待たない", func(t *testing.T) {
+		backend := newBackend(&Mirror{Route: "/orders"})
+		transport(t, backend, http.MethodPost, `{"id":1}`)
+		expectMirrored(t, &mirrored{http.MethodPost, "/orders?id=1", `{"id":1}`})
+		release <- struct{}{}
+	})
+
+	t.Run("Methods に無いメソッドは送らない", func(t *testing.T) {
+		backend := newBackend(&Mirror{Route: "/orders", Methods: []string{http.MethodGet}})
+		transport(t, backend, http.MethodPost, `{"id":1}`)
+		expectMirrored(t, nil)
+	})
+
+	t.Run("上限を超えるボディは送らない", func(t *testing.T) {
+		backend := newBackend(&Mirror{Route: "/orders", MaxBody: 4})
+		transport(t, backend, http.MethodPost, `{"id":1}`)
+		expectMirrored(t, nil)
+	})
+
+	t.Run("同時に送るリクエスト数の上限を超えた分は送らない", func(t *testing.T) {
+		backend := newBackend(&Mirror{Route: "/orders", MaxInFlight: 1})
+		transport(t, backend, http.MethodGet, "")
+		expectMirrored(t, &mirrored{http.MethodGet, "/orders?id=1", ""})
+		// 1件目のミラーへのリクエストが終わっていないため、2件目は送らない
+		transport(t, backend, http.MethodGet, "")
+		expectMirrored(t, nil)
+		release <- struct{}{}
+	})
+}
@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenRefreshBefore はトークンの有効期限の何秒前に更新するかのデフォルト値
+	DefaultTokenRefreshBefore = 30 * time.Second
+
+	// defaultTokenLifetime はトークンエンドポイントが expires_in を返さない場合にトークンを使い回す期間
+	defaultTokenLifetime = 5 * time.Minute
+
+	// tokenRequestTimeout はトークンエンドポイントへのリクエストのタイムアウト
+	tokenRequestTimeout = 10 * time.Second
+)
+
+// ClientCredentialsConfig はクライアントクレデンシャルフローの設定
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Audience はトークンの対象（IdPが audience パラメータに対応する場合のみ指定する）
+	Audience string
+	// RefreshBefore は有効期限のどれだけ前にトークンを更新するか（0は DefaultTokenRefreshBefore）
+	RefreshBefore time.Duration
+	// Client はトークンエンドポイントへのリクエストに使うHTTPクライアント（nilの場合はデフォルト）
+	Client *http.Client
+}
+
+// ClientCredentials はOAuth2のクライアントクレデンシャルフローでバックエンド用のアクセストークンを取得してキャッシュする
+// ユーザーのトークンを転送せず、ゲートウェイ自身のクライアントとして認証を求めるバックエンドに使う
+type ClientCredentials struct {
+	cfg ClientCredentialsConfig
+	now func() time.Time
+
+	// mu は取得中も保持し、有効期限切れの時点で同時に届いたリクエストがそれぞれトークンを取得しないようにする
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewClientCredentials は新しいClientCredentialsを作成する
+func NewClientCredentials(cfg ClientCredentialsConfig) *ClientCredentials {
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = DefaultTokenRefreshBefore
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: tokenRequestTimeout}
+	}
+	return &ClientCredentials{cfg: cfg, now: time.Now}
+}
+
+// Token はアクセストークンを返す
+// キャッシュしたトークンの有効期限が RefreshBefore 以内に迫っている場合は取得し直す
+// 取得に失敗しても、キャッシュしたトークンが期限切れでなければそれを返す
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.token != "" && now.Before(c.expiry.Add(-c.cfg.RefreshBefore)) {
+		return c.token, nil
+	}
+
+	token, lifetime, err := c.fetch(ctx)
+	if err != nil {
+		if c.token != "" && now.Before(c.expiry) {
+			return c.token, nil
+		}
+		return "", err
+	}
+	c.token = token
+	c.expiry = now.Add(lifetime)
+	return c.token, nil
+}
+
+// tokenResponse はトークンエンドポイントのレスポンス（RFC 6749 5.1, 5.2）
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetch はトークンエンドポイントからアクセストークンと有効期間を取得する
+func (c *ClientCredentials) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	if c.cfg.Audience != "" {
+		form.Set("audience", c.cfg.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic（RFC 6749 2.3.1 に従いURLエンコードする）
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", 0, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Error != "" {
+			return "", 0, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+		}
+		return "", 0, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	if body.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+	if body.TokenType != "" && !strings.EqualFold(body.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("unsupported token type: %s", body.TokenType)
+	}
+
+	lifetime := time.Duration(body.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	return body.AccessToken, lifetime, nil
+}
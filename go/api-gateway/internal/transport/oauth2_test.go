@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer は発行回数をトークンに含めるトークンエンドポイントのモックを作成する
+func newTokenServer(t *testing.T, expiresIn int64, status *atomic.Int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != nil && status.Load() != 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(int(status.Load()))
+			w.Write([]byte(`{"error":"invalid_client","error_description":"client authentication failed"}`))
+			return
+		}
+		// client_secret_basic の値はURLエンコードされている
+		id, secret, ok := r.BasicAuth()
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		if !ok || id != "gateway" || secret != "s3cr%t" {
+			t.Errorf("unexpected client credentials: %q %q", id, secret)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if got := r.PostForm.Get("grant_type"); got != "client_credentials" {
+			t.Errorf("grant_type = %q, want client_credentials", got)
+		}
+		if got := r.PostForm.Get("scope"); got != "orders.read orders.write" {
+			t.Errorf("scope = %q, want %q", got, "orders.read orders.write")
+		}
+
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func TestClientCredentials_Token(t *testing.T) {
+	newCredentials := func(tokenURL string) *ClientCredentials {
+		return NewClientCredentials(ClientCredentialsConfig{
+			TokenURL:      tokenURL,
+			ClientID:      "gateway",
+			ClientSecret:  "s3cr%t",
+			Scopes:        []string{"orders.read", "orders.write"},
+			RefreshBefore: 30 * time.Second,
+		})
+	}
+
+	t.Run("有効期限まではキャッシュしたトークンを使う", func(t *testing.T) {
+		server, issued := newTokenServer(t, 300, nil)
+		creds := newCredentials(server.URL)
+
+		for range 3 {
+			token, err := creds.Token(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token != "token-1" {
+				t.Errorf("expected token-1, got %s", token)
+			}
+		}
+		if got := issued.Load(); got != 1 {
+			t.Errorf("expected 1 token request, got %d", got)
+		}
+	})
+
+	t.Run("有効期限が近づくと更新する", func(t *testing.T) {
+		server, issued := newTokenServer(t, 300, nil)
+		creds := newCredentials(server.URL)
+		now := time.Now()
+		creds.now = func() time.Time { return now }
+
+		if _, err := creds.Token(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		now = now.Add(271 * time.Second)
+		token, err := creds.Token(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token != "token-2" || issued.Load() != 2 {
+			t.Errorf("expected refreshed token-2, got %s (requests %d)", token, issued.Load())
+		}
+	})
+
+	t.Run("更新に失敗しても期限内のトークンを使う", func(t *testing.T) {
+		var status atomic.Int32
+		server, _ := newTokenServer(t, 300, &status)
+		creds := newCredentials(server.URL)
+		now := time.Now()
+		creds.now = func() time.Time { return now }
+
+		if _, err := creds.Token(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		status.Store(http.StatusUnauthorized)
+		now = now.Add(280 * time.Second)
+		token, err := creds.Token(context.Background())
+		if err != nil || token != "token-1" {
+			t.Errorf("expected cached token-1, got %q (err %v)", token, err)
+		}
+
+		now = now.Add(30 * time.Second)
+		if _, err := creds.Token(context.Background()); err == nil {
+			t.Error("expected error after the cached token expired")
+		}
+	})
+
+	t.Run("トークンエンドポイントのエラーを返す", func(t *testing.T) {
+		var status atomic.Int32
+		status.Store(http.StatusUnauthorized)
+		server, _ := newTokenServer(t, 300, &status)
+
+		_, err := newCredentials(server.URL).Token(context.Background())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if want := "token endpoint returned 401: invalid_client client authentication failed"; err.Error() != want {
+			t.Errorf("expected error %q, got %q", want, err.Error())
+		}
+	})
+}
+
+func TestHTTPTransporter_Transport_ClientCredentials(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, 300, nil)
+
+	var authorization string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	backendURL, _ := url.Parse(backendServer.URL)
+	backend := &Backend{
+		URL: backendURL,
+		Credentials: NewClientCredentials(ClientCredentialsConfig{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "gateway",
+			ClientSecret: "s3cr%t",
+			Scopes:       []string{"orders.read", "orders.write"},
+		}),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Authorization", "Bearer user-token")
+	w := httptest.NewRecorder()
+
+	if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authorization != "Bearer token-1" {
+		t.Errorf("expected backend Authorization %q, got %q", "Bearer token-1", authorization)
+	}
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"io"
+	"sync"
+)
+
+// proxyBufferSize はボディのコピーに使うバッファのサイズ（io.Copy のデフォルトと同じ）
+const proxyBufferSize = 32 * 1024
+
+// proxyBuffers はボディのコピーに使うバッファのプール
+// ReverseProxy はBufferPoolを指定しない場合、リクエストごとに32KBのバッファを割り当てる
+var proxyBuffers = &bufferPool{
+	pool: sync.Pool{
+		New: func() any {
+			buf := make([]byte, proxyBufferSize)
+			return &buf
+		},
+	},
+}
+
+// bufferPool は httputil.BufferPool の実装
+// BufferPool は []byte を受け渡すため Put でスライスヘッダーの割り当ては残るが、32KBのバッファ自体は再利用される
+type bufferPool struct {
+	pool sync.Pool
+}
+
+// Get はプールからバッファを取得する
+func (p *bufferPool) Get() []byte {
+	return *p.pool.Get().(*[]byte)
+}
+
+// Put はバッファをプールへ戻す
+func (p *bufferPool) Put(buf []byte) {
+	if cap(buf) < proxyBufferSize {
+		return
+	}
+	buf = buf[:proxyBufferSize]
+	p.pool.Put(&buf)
+}
+
+// copyBuffer はプールのバッファを使って src を dst へコピーする
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	buf := proxyBuffers.Get()
+	defer proxyBuffers.Put(buf)
+	return io.CopyBuffer(dst, src, buf)
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	t.Run("取得したバッファはコピー用のサイズを持つ", func(t *testing.T) {
+		buf := proxyBuffers.Get()
+		defer proxyBuffers.Put(buf)
+
+		if len(buf) != proxyBufferSize {
+			t.Errorf("expected buffer size %d, got %d", proxyBufferSize, len(buf))
+		}
+	})
+
+	t.Run("切り詰めたバッファも元のサイズで再利用する", func(t *testing.T) {
+		proxyBuffers.Put(make([]byte, 10, proxyBufferSize))
+
+		buf := proxyBuffers.Get()
+		defer proxyBuffers.Put(buf)
+
+		if len(buf) != proxyBufferSize {
+			t.Errorf("expected buffer size %d, got %d", proxyBufferSize, len(buf))
+		}
+	})
+
+	t.Run("小さいバッファはプールへ戻さない", func(t *testing.T) {
+		proxyBuffers.Put(make([]byte, 10))
+
+		buf := proxyBuffers.Get()
+		defer proxyBuffers.Put(buf)
+
+		if len(buf) != proxyBufferSize {
+			t.Errorf("expected buffer size %d, got %d", proxyBufferSize, len(buf))
+		}
+	})
+}
+
+func TestCopyBuffer(t *testing.T) {
+	src := strings.Repeat("a", proxyBufferSize*2+1)
+
+	var dst bytes.Buffer
+	n, err := copyBuffer(&dst, strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(len(src)) || dst.String() != src {
+		t.Errorf("expected %d bytes copied, got %d", len(src), n)
+	}
+}
+
+// BenchmarkHTTPTransporter_Transport はバックエンドへの転送1回あたりの割り当てを計測する
+// go test -bench Transport -benchmem -memprofile mem.out ./internal/transport/
+func BenchmarkHTTPTransporter_Transport(b *testing.B) {
+	body := bytes.Repeat([]byte("a"), 64*1024)
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(body)
+	}))
+	defer backendServer.Close()
+
+	backendURL, _ := url.Parse(backendServer.URL)
+	transporter := NewHTTPTransporter()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		backend := &Backend{URL: backendURL}
+		req := httptest.NewRequest(http.MethodGet, "/bench", nil)
+		w := httptest.NewRecorder()
+
+		if err := transporter.Transport(context.Background(), discardWriter{w}, req, backend); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+// discardWriter はボディを捨てるResponseWriter
+type discardWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w discardWriter) Write(p []byte) (int, error) {
+	return io.Discard.Write(p)
+}
@@ -0,0 +1,31 @@
+package transport
+
+import "fmt"
+
+// Protocol はバックエンドとの通信に使うHTTPプロトコル
+type Protocol string
+
+const (
+	// ProtocolAuto はTLSならALPNでHTTP/2をネゴシエートし、平文ならHTTP/1.1を使う（デフォルト）
+	ProtocolAuto Protocol = ""
+
+	// ProtocolHTTP1 はHTTP/1.1のみを使う
+	ProtocolHTTP1 Protocol = "http1"
+
+	// ProtocolH2 はTLS上のHTTP/2を強制する
+	ProtocolH2 Protocol = "h2"
+
+	// ProtocolH2C は平文のHTTP/2（prior knowledge）を使う
+	// TLSを終端しない内部バックエンドとgRPCや多重化接続でやり取りするためのもの
+	ProtocolH2C Protocol = "h2c"
+)
+
+// ParseProtocol は設定値をProtocolに変換する
+func ParseProtocol(s string) (Protocol, error) {
+	switch p := Protocol(s); p {
+	case ProtocolAuto, ProtocolHTTP1, ProtocolH2, ProtocolH2C:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported backend protocol: %s", s)
+	}
+}
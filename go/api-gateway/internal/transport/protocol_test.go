@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Protocol
+		wantErr bool
+	}{
+		{name: "empty is auto", input: "", want: ProtocolAuto},
+		{name: "http1", input: "http1", want: ProtocolHTTP1},
+		{name: "h2", input: "h2", want: ProtocolH2},
+		{name: "h2c", input: "h2c", want: ProtocolH2C},
+		{name: "unknown", input: "http3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProtocol(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProtocol() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProtocol() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPTransporter_Transport_H2C(t *testing.T) {
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		w.WriteHeader(http.StatusOK)
+	}))
+	backendServer.Config.Protocols = new(http.Protocols)
+	backendServer.Config.Protocols.SetHTTP1(true)
+	backendServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	backendServer.Start()
+	defer backendServer.Close()
+
+	tests := []struct {
+		name      string
+		protocol  Protocol
+		wantProto string
+	}{
+		{name: "auto uses HTTP/1.1 for cleartext", protocol: ProtocolAuto, wantProto: "HTTP/1.1"},
+		{name: "http1", protocol: ProtocolHTTP1, wantProto: "HTTP/1.1"},
+		{name: "h2c", protocol: ProtocolH2C, wantProto: "HTTP/2.0"},
+	}
+
+	transporter := NewHTTPTransporter()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := NewBackend(backendServer.URL, 5*time.Second)
+			if err != nil {
+				t.Fatalf("failed to create backend: %v", err)
+			}
+			backend.Protocol = tt.protocol
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+
+			if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("X-Proto"); got != tt.wantProto {
+				t.Errorf("backend saw protocol %s, want %s", got, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestHTTPTransporter_Transport_H2(t *testing.T) {
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		w.WriteHeader(http.StatusOK)
+	}))
+	backendServer.EnableHTTP2 = true
+	backendServer.StartTLS()
+	defer backendServer.Close()
+
+	transporter := NewHTTPTransporter()
+
+	// テストサーバの自己署名証明書を信頼させる
+	h2 := transporter.transports.get(ProtocolH2, ConnectionPool{})
+	h2.TLSClientConfig = backendServer.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+
+	backend, err := NewBackend(backendServer.URL, 5*time.Second)
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	backend.Protocol = ProtocolH2
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-Proto"); got != "HTTP/2.0" {
+		t.Errorf("backend saw protocol %s, want HTTP/2.0", got)
+	}
+}
@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+// 再試行の状況をクライアントへ伝えるレスポンスヘッダー
+const (
+	// HeaderRetries はバックエンドへの再試行の回数
+	HeaderRetries = "X-Gateway-Retries"
+	// HeaderRetryBudgetRemaining はルートの再試行の予算の残り
+	HeaderRetryBudgetRemaining = "X-Gateway-Retry-Budget-Remaining"
+)
+
+// 再試行の予算のデフォルト値
+const (
+	DefaultRetryBudgetRatio      = 0.1
+	DefaultRetryBudgetMinRetries = 3
+	DefaultRetryBudgetWindow     = 10 * time.Second
+)
+
+var (
+	retriesTotal = metrics.NewCounterVec(
+		"gateway_retries_total",
+		"Number of retries of requests to backends that returned 502 or 504.",
+		"route",
+	)
+	retryBudgetExhaustedTotal = metrics.NewCounterVec(
+		"gateway_retry_budget_exhausted_total",
+		"Number of retries rejected because the route's retry budget was exhausted.",
+		"route",
+	)
+	retryBudgetRemaining = metrics.NewGaugeVec(
+		"gateway_retry_budget_remaining",
+		"Number of retries the route may still perform in the current budget window.",
+		"route",
+	)
+)
+
+// RetryPolicy はバックエンドが502/504を返した場合（接続できない場合を含む）の再試行の方針
+type RetryPolicy struct {
+	// Route はメトリクスのラベルに使うルートのパス
+	Route string
+	// Attempts は最初のリクエストに加えて再試行する最大回数
+	Attempts int
+	// Budget はルートの全リクエストで共有する再試行の予算
+	Budget *RetryBudget
+}
+
+// RetryBudget は期間内の再試行の回数をリクエスト数の一定割合に制限する
+// バックエンドの障害時に再試行でリクエストが増幅する（リトライストーム）のを防ぐ
+type RetryBudget struct {
+	ratio      float64
+	minRetries int
+	window     time.Duration
+	now        func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	requests    int
+	retries     int
+}
+
+// NewRetryBudget は新しいRetryBudgetを作成する
+// 期間 window ごとに minRetries 回とリクエスト数の ratio 倍までの再試行を許可する
+func NewRetryBudget(ratio float64, minRetries int, window time.Duration) *RetryBudget {
+	if window <= 0 {
+		window = DefaultRetryBudgetWindow
+	}
+	return &RetryBudget{
+		ratio:      ratio,
+		minRetries: minRetries,
+		window:     window,
+		now:        time.Now,
+	}
+}
+
+// recordRequest はリクエスト（再試行を除く）を1件数える
+func (b *RetryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	b.requests++
+}
+
+// withdraw は予算が残っていれば再試行を1回分消費して true を返す
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	if b.remaining() <= 0 {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// Remaining は現在の期間で許可される残りの再試行の回数を返す
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	return b.remaining()
+}
+
+func (b *RetryBudget) remaining() int {
+	return max(b.minRetries+int(b.ratio*float64(b.requests))-b.retries, 0)
+}
+
+// roll は期間が過ぎていれば数え直す
+func (b *RetryBudget) roll() {
+	now := b.now()
+	if now.Sub(b.windowStart) >= b.window {
+		b.windowStart = now
+		b.requests = 0
+		b.retries = 0
+	}
+}
+
+// retryTransport はバックエンドが502/504を返した場合に予算の範囲で再試行するRoundTripper
+// リクエストごとに作成し、行った再試行の回数をレスポンスヘッダーで返せるよう保持する
+type retryTransport struct {
+	next   http.RoundTripper
+	policy *RetryPolicy
+
+	retries int
+}
+
+// RoundTrip はhttp.RoundTripperインターフェースの実装
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.policy.Budget.recordRequest()
+	replayable := retryableRequest(req)
+
+	for {
+		resp, err := t.next.RoundTrip(req)
+		if !replayable || t.retries >= t.policy.Attempts || !retryableResponse(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if !t.policy.Budget.withdraw() {
+			retryBudgetExhaustedTotal.With(t.policy.Route).Inc()
+			return resp, err
+		}
+
+		if resp != nil {
+			// 接続を再利用できるよう、捨てるレスポンスのボディを読み切ってから閉じる
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		t.retries++
+		retriesTotal.With(t.policy.Route).Inc()
+	}
+}
+
+// setHeaders は再試行の回数と予算の残りをヘッダーに設定する
+func (t *retryTransport) setHeaders(h http.Header) {
+	remaining := t.policy.Budget.Remaining()
+	retryBudgetRemaining.With(t.policy.Route).Set(float64(remaining))
+	h.Set(HeaderRetries, strconv.Itoa(t.retries))
+	h.Set(HeaderRetryBudgetRemaining, strconv.Itoa(remaining))
+}
+
+// retryableRequest は同じリクエストを再送しても安全か確認する
+// 副作用が重複しないよう冪等なメソッドのみ対象とし、ボディは読み直せる場合のみ再送する
+func retryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+// retryableResponse は再試行の対象となる結果か確認する
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout
+}
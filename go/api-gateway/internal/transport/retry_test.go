@@ -0,0 +1,216 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	t.Run("リクエストが少ない場合もmin_retries回まで許可する", func(t *testing.T) {
+		budget := NewRetryBudget(0.1, 2, time.Minute)
+		budget.recordRequest()
+
+		if !budget.withdraw() || !budget.withdraw() {
+			t.Fatal("expected min_retries retries to be allowed")
+		}
+		if budget.withdraw() {
+			t.Error("expected retry to be rejected after min_retries")
+		}
+		if got := budget.Remaining(); got != 0 {
+			t.Errorf("Remaining() = %d, want 0", got)
+		}
+	})
+
+	t.Run("リクエスト数のratio倍まで許可する", func(t *testing.T) {
+		budget := NewRetryBudget(0.1, 0, time.Minute)
+		for range 30 {
+			budget.recordRequest()
+		}
+
+		if got := budget.Remaining(); got != 3 {
+			t.Fatalf("Remaining() = %d, want 3", got)
+		}
+		for range 3 {
+			if !budget.withdraw() {
+				t.Fatal("expected retry to be allowed")
+			}
+		}
+		if budget.withdraw() {
+			t.Error("expected retry to be rejected after ratio is exhausted")
+		}
+	})
+
+	t.Run("期間が過ぎると数え直す", func(t *testing.T) {
+		now := time.Now()
+		budget := NewRetryBudget(0.1, 1, time.Minute)
+		budget.now = func() time.Time { return now }
+
+		budget.recordRequest()
+		if !budget.withdraw() {
+			t.Fatal("expected retry to be allowed")
+		}
+		if budget.withdraw() {
+			t.Fatal("expected retry to be rejected")
+		}
+
+		now = now.Add(time.Minute)
+		if !budget.withdraw() {
+			t.Error("expected retry to be allowed in the next window")
+		}
+	})
+}
+
+func TestHTTPTransporter_Transport_Retry(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		failures      int32
+		failureStatus int
+		attempts      int
+		minRetries    int
+		wantStatus    int
+		wantCalls     int32
+		wantRetries   string
+	}{
+		{
+			name:          "502の後に成功する",
+			method:        http.MethodGet,
+			failures:      1,
+			failureStatus: http.StatusBadGateway,
+			attempts:      2,
+			minRetries:    10,
+			wantStatus:    http.StatusOK,
+			wantCalls:     2,
+			wantRetries:   "1",
+		},
+		{
+			name:          "504が続く場合はattempts回まで再試行する",
+			method:        http.MethodGet,
+			failures:      10,
+			failureStatus: http.StatusGatewayTimeout,
+			attempts:      2,
+			minRetries:    10,
+			wantStatus:    http.StatusGatewayTimeout,
+			wantCalls:     3,
+			wantRetries:   "2",
+		},
+		{
+			name:          "予算を使い切った場合は再試行しない",
+			method:        http.MethodGet,
+			failures:      10,
+			failureStatus: http.StatusBadGateway,
+			attempts:      3,
+			minRetries:    1,
+			wantStatus:    http.StatusBadGateway,
+			wantCalls:     2,
+			wantRetries:   "1",
+		},
+		{
+			name:          "500は再試行しない",
+			method:        http.MethodGet,
+			failures:      1,
+			failureStatus: http.StatusInternalServerError,
+			attempts:      2,
+			minRetries:    10,
+			wantStatus:    http.StatusInternalServerError,
+			wantCalls:     1,
+			wantRetries:   "0",
+		},
+		{
+			name:          "POSTは再試行しない",
+			method:        http.MethodPost,
+			body:          `{"name":"test"}`,
+			failures:      1,
+			failureStatus: http.StatusBadGateway,
+			attempts:      2,
+			minRetries:    10,
+			wantStatus:    http.StatusBadGateway,
+			wantCalls:     1,
+			wantRetries:   "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.failureStatus)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer backendServer.Close()
+
+			backendURL, _ := url.Parse(backendServer.URL)
+			backend := &Backend{
+				URL: backendURL,
+				Retry: &RetryPolicy{
+					Route:    "/test",
+					Attempts: tt.attempts,
+					Budget:   NewRetryBudget(0, tt.minRetries, time.Minute),
+				},
+			}
+
+			var req *http.Request
+			if tt.body != "" {
+				req = httptest.NewRequest(tt.method, "/test", strings.NewReader(tt.body))
+			} else {
+				req = httptest.NewRequest(tt.method, "/test", nil)
+			}
+			w := httptest.NewRecorder()
+
+			if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d backend calls, got %d", tt.wantCalls, got)
+			}
+			if got := w.Header().Get(HeaderRetries); got != tt.wantRetries {
+				t.Errorf("expected %s %s, got %q", HeaderRetries, tt.wantRetries, got)
+			}
+			if w.Header().Get(HeaderRetryBudgetRemaining) == "" {
+				t.Errorf("expected %s header", HeaderRetryBudgetRemaining)
+			}
+		})
+	}
+
+	t.Run("接続できない場合も再試行し、エラー応答に回数を返す", func(t *testing.T) {
+		backendServer := httptest.NewServer(http.NotFoundHandler())
+		backendURL, _ := url.Parse(backendServer.URL)
+		backendServer.Close()
+
+		budget := NewRetryBudget(0, 10, time.Minute)
+		backend := &Backend{
+			URL:   backendURL,
+			Retry: &RetryPolicy{Route: "/test", Attempts: 2, Budget: budget},
+		}
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		w := httptest.NewRecorder()
+
+		if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("expected status %d, got %d", http.StatusBadGateway, w.Code)
+		}
+		if got := w.Header().Get(HeaderRetries); got != "2" {
+			t.Errorf("expected %s 2, got %q", HeaderRetries, got)
+		}
+		if got := w.Header().Get(HeaderRetryBudgetRemaining); got != "8" {
+			t.Errorf("expected %s 8, got %q", HeaderRetryBudgetRemaining, got)
+		}
+	})
+}
@@ -0,0 +1,436 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"api-gateway/internal/metrics"
+)
+
+const (
+	// DefaultShadowMaxBody は比較するレスポンスボディの上限バイト数のデフォルト値
+	DefaultShadowMaxBody = 1 << 20 // 1MiB
+
+	// maxShadowDiffs は1件のログに含める差分の上限
+	maxShadowDiffs = 20
+)
+
+// defaultShadowIgnoreHeaders はバックエンドごとに異なるのが当然のため比較しないヘッダー
+var defaultShadowIgnoreHeaders = []string{"Date", "Content-Length", "Connection", "Keep-Alive", "Transfer-Encoding"}
+
+// shadowComparisonsTotal は比較の結果（match, mismatch, error）ごとの件数
+var shadowComparisonsTotal = metrics.NewCounterVec(
+	"gateway_shadow_comparisons_total",
+	"Number of shadow comparisons between the primary and the shadow backend by result.",
+	"route", "result",
+)
+
+// ShadowCompare は同じリクエストを移行先のバックエンドにも送り、レスポンスの差分をログに記録する
+// クライアントには常に移行元（Backend.URL）のレスポンスを返すため、移行先の不具合はクライアントに影響しない
+type ShadowCompare struct {
+	// Route はメトリクスのラベルとログに使うルートのパス
+	Route string
+	// URL は移行先のバックエンドのURL
+	URL *url.URL
+	// Timeout は移行先へのリクエストのタイムアウト（0の場合はバックエンドのタイムアウト）
+	Timeout time.Duration
+	// Methods は移行先にも送るメソッド（副作用が重複しないよう、デフォルトは GET と HEAD）
+	Methods []string
+	// IgnoreHeaders は比較しないレスポンスヘッダー（Date などは常に比較しない）
+	IgnoreHeaders []string
+	// IgnoreFields は比較しないJSONボディのフィールド（"meta.request_id" のようにドットで区切る）
+	IgnoreFields []string
+	// MaxBody は比較するボディの上限バイト数（0は DefaultShadowMaxBody）。超える場合はボディを比較しない
+	MaxBody int64
+}
+
+// ShadowDiff はレスポンスの差分1件
+type ShadowDiff struct {
+	// Field は status, header.<name>, body.<path> のいずれか
+	Field   string `json:"field"`
+	Primary any    `json:"primary"`
+	Shadow  any    `json:"shadow"`
+}
+
+// shadowResponse は比較のために保持したレスポンス
+type shadowResponse struct {
+	status    int
+	header    http.Header
+	body      []byte
+	truncated bool
+	err       error
+}
+
+// shadowExchange はリクエスト1件分の移行元と移行先のレスポンスを突き合わせる
+type shadowExchange struct {
+	compare *ShadowCompare
+	logger  *slog.Logger
+	ctx     context.Context
+	method  string
+	path    string
+
+	wg     sync.WaitGroup
+	shadow shadowResponse
+}
+
+// applies は移行先にも送るリクエストか確認する
+func (s *ShadowCompare) applies(req *http.Request) bool {
+	if len(s.Methods) == 0 {
+		return req.Method == http.MethodGet || req.Method == http.MethodHead
+	}
+	return slices.Contains(s.Methods, req.Method)
+}
+
+func (s *ShadowCompare) maxBody() int64 {
+	if s.MaxBody > 0 {
+		return s.MaxBody
+	}
+	return DefaultShadowMaxBody
+}
+
+// startShadow は移行先へのリクエストをバックグラウンドで開始する
+// req はバックエンド向けに書き換える前のリクエスト。ボディが MaxBody を超える場合は移行先へ送らず nil を返す
+// 移行先へのリクエストはクライアントの切断やバックエンドのタイムアウトでは中断しない
+func (t *HTTPTransporter) startShadow(ctx context.Context, req *http.Request, backend *Backend) *shadowExchange {
+	s := backend.Shadow
+	body, ok := bufferShadowBody(req, s.maxBody())
+	if !ok {
+		return nil
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = backend.Timeout
+	}
+
+	shadowReq := copyRequest(ctx, req, s.URL, body, backend.Headers)
+
+	ex := &shadowExchange{
+		compare: s,
+		logger:  t.logger(),
+		ctx:     context.WithoutCancel(ctx),
+		method:  req.Method,
+		path:    req.URL.Path,
+	}
+	roundTripper := t.roundTripper(backend.Protocol, backend.Pool)
+	ex.wg.Add(1)
+	go func() {
+		defer ex.wg.Done()
+		if timeout > 0 {
+			reqCtx, cancel := context.WithTimeout(shadowReq.Context(), timeout)
+			defer cancel()
+			shadowReq = shadowReq.WithContext(reqCtx)
+		}
+		ex.shadow = fetchShadow(roundTripper, shadowReq, s.maxBody())
+	}()
+	return ex
+}
+
+// copyRequest は req を target のバックエンドへ送る複製を作る（移行先やミラーへのリクエストに使う）
+// body は bufferShadowBody で読み込んだボディ。複製はクライアントの切断やバックエンドのタイムアウトでは中断しない
+func copyRequest(ctx context.Context, req *http.Request, target *url.URL, body []byte, headers map[string]string) *http.Request {
+	targetURL := *req.URL
+	targetURL.Scheme = target.Scheme
+	targetURL.Host = target.Host
+	targetURL.Path = target.Path + req.URL.Path
+	targetURL.RawPath = ""
+
+	copied := req.Clone(context.WithoutCancel(ctx))
+	copied.URL = &targetURL
+	copied.Host = target.Host
+	copied.RequestURI = ""
+	copied.Body = http.NoBody
+	copied.ContentLength = 0
+	if body != nil {
+		copied.Body = io.NopCloser(bytes.NewReader(body))
+		copied.ContentLength = int64(len(body))
+	}
+	for key, value := range headers {
+		copied.Header.Set(key, value)
+	}
+	return copied
+}
+
+// bufferShadowBody は移行元と移行先の両方へ送れるようリクエストボディを読み込む
+// 上限を超える場合は読み込んだ分と残りを繋いで移行元へ送れる状態に戻し、false を返す
+func bufferShadowBody(req *http.Request, limit int64) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil || int64(len(body)) > limit {
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return nil, false
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+// fetchShadow は移行先へリクエストを送り、比較のためにレスポンスを読み込む
+func fetchShadow(rt http.RoundTripper, req *http.Request, limit int64) shadowResponse {
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return shadowResponse{err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return shadowResponse{err: fmt.Errorf("failed to read shadow response body: %w", err)}
+	}
+	return shadowResponse{
+		status:    resp.StatusCode,
+		header:    resp.Header,
+		body:      body[:min(int64(len(body)), limit)],
+		truncated: int64(len(body)) > limit,
+	}
+}
+
+// capture は移行元のレスポンスのボディを読み取りながら保持し、読み切った時点で移行先と比較する
+// 比較は移行先の応答を待つため、クライアントへの応答を遅らせないようバックグラウンドで行う
+func (ex *shadowExchange) capture(resp *http.Response) {
+	resp.Body = &shadowCaptureBody{
+		ReadCloser: resp.Body,
+		exchange:   ex,
+		primary:    shadowResponse{status: resp.StatusCode, header: resp.Header.Clone()},
+		limit:      ex.compare.maxBody(),
+	}
+}
+
+// fail は移行元への転送が失敗した場合に結果を記録する
+func (ex *shadowExchange) fail(err error) {
+	go func() {
+		ex.wg.Wait()
+		ex.report(shadowResponse{err: err})
+	}()
+}
+
+// shadowCaptureBody は移行元のレスポンスボディを上限まで保持する
+type shadowCaptureBody struct {
+	io.ReadCloser
+	exchange *shadowExchange
+	primary  shadowResponse
+	limit    int64
+	buf      bytes.Buffer
+	size     int64
+	eof      bool
+	once     sync.Once
+}
+
+// Read はボディを読み取り、上限までの内容を保持する
+func (b *shadowCaptureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if room := b.limit - int64(b.buf.Len()); room > 0 && n > 0 {
+		b.buf.Write(p[:min(int64(n), room)])
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// Close はボディを閉じ、最後まで読み切っていれば比較を始める
+// 途中で終わった場合（クライアントの切断など）は比較できないため何もしない
+func (b *shadowCaptureBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		if !b.eof {
+			return
+		}
+		primary := b.primary
+		primary.body = b.buf.Bytes()
+		primary.truncated = b.size > b.limit
+		go func() {
+			b.exchange.wg.Wait()
+			b.exchange.report(primary)
+		}()
+	})
+	return err
+}
+
+// report は比較の結果をメトリクスとログに記録する
+func (ex *shadowExchange) report(primary shadowResponse) {
+	s := ex.compare
+	attrs := []any{
+		slog.String("route", s.Route),
+		slog.String("method", ex.method),
+		slog.String("path", ex.path),
+	}
+
+	if primary.err != nil || ex.shadow.err != nil {
+		shadowComparisonsTotal.With(s.Route, "error").Inc()
+		if primary.err != nil {
+			attrs = append(attrs, slog.String("primary_error", primary.err.Error()))
+		}
+		if ex.shadow.err != nil {
+			attrs = append(attrs, slog.String("shadow_error", ex.shadow.err.Error()))
+		}
+		ex.logger.WarnContext(ex.ctx, "shadow comparison failed", attrs...)
+		return
+	}
+
+	diffs := s.diff(primary, ex.shadow)
+	if len(diffs) == 0 {
+		shadowComparisonsTotal.With(s.Route, "match").Inc()
+		return
+	}
+
+	shadowComparisonsTotal.With(s.Route, "mismatch").Inc()
+	attrs = append(attrs,
+		slog.Int("primary_status", primary.status),
+		slog.Int("shadow_status", ex.shadow.status),
+		slog.Any("diffs", diffs),
+	)
+	ex.logger.WarnContext(ex.ctx, "shadow response mismatch", attrs...)
+}
+
+// diff はステータス・ヘッダー・ボディの差分を返す（最大 maxShadowDiffs 件）
+func (s *ShadowCompare) diff(primary, shadow shadowResponse) []ShadowDiff {
+	var diffs []ShadowDiff
+	if primary.status != shadow.status {
+		diffs = append(diffs, ShadowDiff{Field: "status", Primary: primary.status, Shadow: shadow.status})
+	}
+	diffs = append(diffs, s.diffHeaders(primary.header, shadow.header)...)
+	if !primary.truncated && !shadow.truncated {
+		diffs = append(diffs, s.diffBodies(primary.body, shadow.body)...)
+	}
+
+	if len(diffs) > maxShadowDiffs {
+		diffs = diffs[:maxShadowDiffs]
+	}
+	return diffs
+}
+
+// diffHeaders は比較対象のヘッダーの差分を名前順に返す
+func (s *ShadowCompare) diffHeaders(primary, shadow http.Header) []ShadowDiff {
+	ignored := func(name string) bool {
+		return slices.ContainsFunc(defaultShadowIgnoreHeaders, func(h string) bool { return strings.EqualFold(h, name) }) ||
+			slices.ContainsFunc(s.IgnoreHeaders, func(h string) bool { return strings.EqualFold(h, name) })
+	}
+
+	names := make(map[string]struct{})
+	for name := range primary {
+		names[name] = struct{}{}
+	}
+	for name := range shadow {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if !ignored(name) {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	var diffs []ShadowDiff
+	for _, name := range sorted {
+		p, sh := strings.Join(primary.Values(name), ", "), strings.Join(shadow.Values(name), ", ")
+		if p != sh {
+			diffs = append(diffs, ShadowDiff{Field: "header." + name, Primary: p, Shadow: sh})
+		}
+	}
+	return diffs
+}
+
+// diffBodies はボディの差分を返す
+// 両方がJSONの場合は IgnoreFields を除いてフィールドごとに比較し、それ以外はバイト列として比較する
+func (s *ShadowCompare) diffBodies(primary, shadow []byte) []ShadowDiff {
+	var p, sh any
+	if json.Unmarshal(primary, &p) != nil || json.Unmarshal(shadow, &sh) != nil {
+		if bytes.Equal(primary, shadow) {
+			return nil
+		}
+		return []ShadowDiff{{Field: "body", Primary: fmt.Sprintf("%d bytes", len(primary)), Shadow: fmt.Sprintf("%d bytes", len(shadow))}}
+	}
+
+	for _, field := range s.IgnoreFields {
+		path := strings.Split(field, ".")
+		removeJSONField(p, path)
+		removeJSONField(sh, path)
+	}
+
+	var diffs []ShadowDiff
+	diffJSON("body", p, sh, &diffs)
+	return diffs
+}
+
+// removeJSONField はJSONの値から path のフィールドを削除する
+// 配列の場合は全ての要素から削除する
+func removeJSONField(v any, path []string) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			delete(v, path[0])
+			return
+		}
+		removeJSONField(v[path[0]], path[1:])
+	case []any:
+		for _, item := range v {
+			removeJSONField(item, path)
+		}
+	}
+}
+
+// diffJSON はJSONの値を再帰的に比較し、異なるフィールドを diffs に追加する
+func diffJSON(path string, primary, shadow any, diffs *[]ShadowDiff) {
+	if len(*diffs) >= maxShadowDiffs {
+		return
+	}
+
+	switch p := primary.(type) {
+	case map[string]any:
+		sh, ok := shadow.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]struct{})
+		for k := range p {
+			keys[k] = struct{}{}
+		}
+		for k := range sh {
+			keys[k] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffJSON(path+"."+k, p[k], sh[k], diffs)
+		}
+		return
+	case []any:
+		sh, ok := shadow.([]any)
+		if !ok || len(p) != len(sh) {
+			break
+		}
+		for i := range p {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), p[i], sh[i], diffs)
+		}
+		return
+	default:
+		pb, _ := json.Marshal(primary)
+		sb, _ := json.Marshal(shadow)
+		if bytes.Equal(pb, sb) {
+			return
+		}
+	}
+	*diffs = append(*diffs, ShadowDiff{Field: path, Primary: primary, Shadow: shadow})
+}
@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestShadowCompare_diff(t *testing.T) {
+	tests := []struct {
+		name      string
+		compare   ShadowCompare
+		primary   shadowResponse
+		shadow    shadowResponse
+		wantField []string
+	}{
+		{
+			name:    "同じレスポンス",
+			primary: shadowResponse{status: 200, header: http.Header{"Content-Type": {"application/json"}}, body: []byte(`{"id":1}`)},
+			shadow:  shadowResponse{status: 200, header: http.Header{"Content-Type": {"application/json"}}, body: []byte(`{"id":1}`)},
+		},
+		{
+			name:      "ステータスとヘッダーの差分",
+			primary:   shadowResponse{status: 200, header: http.Header{"X-Version": {"1"}}},
+			shadow:    shadowResponse{status: 500, header: http.Header{"X-Version": {"2"}}},
+			wantField: []string{"status", "header.X-Version"},
+		},
+		{
+			name:    "Date などは常に比較しない",
+			primary: shadowResponse{status: 200, header: http.Header{"Date": {"Mon, 01 Jan 2026 00:00:00 GMT"}, "Content-Length": {"8"}}},
+			shadow:  shadowResponse{status: 200, header: http.Header{"Date": {"Mon, 01 Jan 2026 00:00:01 GMT"}, "Content-Length": {"9"}}},
+		},
+		{
+			name:    "IgnoreHeaders のヘッダーは比較しない",
+			compare: ShadowCompare{IgnoreHeaders: []string{"x-served-by"}},
+			primary: shadowResponse{status: 200, header: http.Header{"X-Served-By": {"v1"}}},
+			shadow:  shadowResponse{status: 200, header: http.Header{"X-Served-By": {"v2"}}},
+		},
+		{
+			name:      "JSONはフィールドごとに比較する",
+			primary:   shadowResponse{status: 200, body: []byte(`{"id":1,"items":[{"name":"a"},{"name":"b"}]}`)},
+			shadow:    shadowResponse{status: 200, body: []byte(`{"items":[{"name":"a"},{"name":"c"}],"id":1,"extra":true}`)},
+			wantField: []string{"body.extra", "body.items[1].name"},
+		},
+		{
+			name:    "IgnoreFields のフィールドは配列の要素も含めて比較しない",
+			compare: ShadowCompare{IgnoreFields: []string{"meta.request_id", "items.updated_at"}},
+			primary: shadowResponse{status: 200, body: []byte(`{"meta":{"request_id":"a"},"items":[{"id":1,"updated_at":"t1"}]}`)},
+			shadow:  shadowResponse{status: 200, body: []byte(`{"meta":{"request_id":"b"},"items":[{"id":1,"updated_at":"t2"}]}`)},
+		},
+		{
+			name:      "JSONでないボディはバイト列として比較する",
+			primary:   shadowResponse{status: 200, body: []byte("hello")},
+			shadow:    shadowResponse{status: 200, body: []byte("world")},
+			wantField: []string{"body"},
+		},
+		{
+			name:    "上限を超えたボディは比較しない",
+			primary: shadowResponse{status: 200, body: []byte("hello"), truncated: true},
+			shadow:  shadowResponse{status: 200, body: []byte("world")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range tt.compare.diff(tt.primary, tt.shadow) {
+				got = append(got, d.Field)
+			}
+			if !reflect.DeepEqual(got, tt.wantField) {
+				t.Errorf("diff fields = %v, want %v", got, tt.wantField)
+			}
+		})
+	}
+}
+
+// recordHandler は出力したログを送る slog.Handler
+type recordHandler struct {
+	records chan slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records <- r
+	return nil
+}
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestHTTPTransporter_Transport_Shadow(t *testing.T) {
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"old","request_id":"a"}`))
+	}))
+	defer primaryServer.Close()
+
+	shadowPaths := make(chan string, 1)
+	shadowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shadowPaths <- r.URL.RequestURI()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"new","request_id":"b"}`))
+	}))
+	defer shadowServer.Close()
+
+	primaryURL, _ := url.Parse(primaryServer.URL)
+	shadowURL, _ := url.Parse(shadowServer.URL)
+	backend := &Backend{
+		URL: primaryURL,
+		Shadow: &ShadowCompare{
+			Route:        "/orders",
+			URL:          shadowURL,
+			IgnoreFields: []string{"request_id"},
+		},
+	}
+
+	handler := &recordHandler{records: make(chan slog.Record, 1)}
+	transporter := NewHTTPTransporter()
+	transporter.Logger = slog.New(handler)
+
+	t.Run("移行元のレスポンスを返し、差分をログに記録する", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orders?id=1", nil)
+		w := httptest.NewRecorder()
+
+		if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if body := w.Body.String(); body != `{"id":1,"name":"old","request_id":"a"}` {
+			t.Errorf("expected primary response, got %s", body)
+		}
+		if path := <-shadowPaths; path != "/orders?id=1" {
+			t.Errorf("expected shadow request to /orders?id=1, got %s", path)
+		}
+
+		select {
+		case record := <-handler.records:
+			if record.Message != "shadow response mismatch" {
+				t.Fatalf("unexpected log message: %s", record.Message)
+			}
+			var diffs []ShadowDiff
+			record.Attrs(func(a slog.Attr) bool {
+				if a.Key == "diffs" {
+					diffs, _ = a.Value.Any().([]ShadowDiff)
+				}
+				return true
+			})
+			want := []ShadowDiff{{Field: "body.name", Primary: "old", Shadow: "new"}}
+			if !reflect.DeepEqual(diffs, want) {
+				t.Errorf("diffs = %+v, want %+v", diffs, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for shadow comparison")
+		}
+	})
+
+	t.Run("GET と HEAD 以外は移行先へ送らない", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		w := httptest.NewRecorder()
+
+		if err := transporter.Transport(context.Background(), w, req, backend); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		select {
+		case path := <-shadowPaths:
+			t.Errorf("unexpected shadow request to %s", path)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
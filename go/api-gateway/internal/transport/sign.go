@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"crypto/sha256"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"api-gateway/internal/buffer"
+	"api-gateway/internal/errors"
+	"api-gateway/pkg/signature"
+)
+
+// hashRequestBody はリクエストボディのSHA-256ハッシュを求める（署名に使う）
+// ボディ全体を読み込むため buffer.SpillBuffer に保持し、転送時はバッファから読み直す
+func hashRequestBody(req *http.Request, bufConfig buffer.Config) ([]byte, error) {
+	hash := sha256.New()
+	if req.Body != nil && req.Body != http.NoBody {
+		buf := buffer.New(bufConfig)
+		_, err := copyBuffer(io.MultiWriter(buf, hash), req.Body)
+		req.Body.Close()
+		if err != nil {
+			buf.Close()
+			// リクエストボディが上限を超えた場合（http.MaxBytesReader）はクライアントの問題なので413を返す
+			var maxBytesErr *http.MaxBytesError
+			if stderrors.As(err, &maxBytesErr) {
+				return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit))
+			}
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+
+		// 転送後にボディが Close された時点で一時ファイルも削除される
+		body, err := buf.Reader()
+		if err != nil {
+			buf.Close()
+			return nil, fmt.Errorf("failed to read buffered request body: %w", err)
+		}
+		req.Body = body
+		req.ContentLength = buf.Len()
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// signRequest はボディのハッシュを使ってリクエストに X-Gateway-Signature ヘッダーで署名する
+func signRequest(req *http.Request, signer *signature.Signer, bodyHash []byte) error {
+	if err := signer.Sign(req, bodyHash); err != nil {
+		return errors.NewInternalServerError(err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+)
+
+// AWSCredentials はSigV4の署名に使うAWSの認証情報
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken は一時的な認証情報（STS）の場合のみ設定する
+	SessionToken string
+}
+
+// AWSCredentialsFunc は署名のたびに認証情報を返す（一時的な認証情報の更新に対応するため）
+type AWSCredentialsFunc func(ctx context.Context) (AWSCredentials, error)
+
+// StaticAWSCredentials は固定の認証情報を返す
+func StaticAWSCredentials(creds AWSCredentials) AWSCredentialsFunc {
+	return func(context.Context) (AWSCredentials, error) {
+		return creds, nil
+	}
+}
+
+// EnvAWSCredentials は環境変数 AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY / AWS_SESSION_TOKEN から認証情報を返す
+// 署名のたびに読み込むため、実行中に更新された一時的な認証情報も使える
+func EnvAWSCredentials() AWSCredentialsFunc {
+	return func(context.Context) (AWSCredentials, error) {
+		creds := AWSCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return AWSCredentials{}, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+		}
+		return creds, nil
+	}
+}
+
+// AWSSigner は転送するリクエストにAWS Signature Version 4で署名する
+// API GatewayやLambda関数URL、OpenSearchなどIAM認証が必要なバックエンドの前段に置くために使う
+type AWSSigner struct {
+	Service     string
+	Region      string
+	Credentials AWSCredentialsFunc
+}
+
+// Sign はリクエストに Authorization / X-Amz-Date（一時的な認証情報の場合は X-Amz-Security-Token）ヘッダーを設定する
+// bodyHash はリクエストボディのSHA-256ハッシュ。署名するヘッダーは host と x-amz-* のみ
+func (s *AWSSigner) Sign(ctx context.Context, req *http.Request, bodyHash []byte, now time.Time) error {
+	creds, err := s.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	now = now.UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	payloadHash := hex.EncodeToString(bodyHash)
+
+	// クライアントが送った署名用のヘッダーは使わない
+	req.Header.Del("Authorization")
+	req.Header.Del("X-Amz-Security-Token")
+	req.Header.Del("X-Amz-Content-Sha256")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	// S3はペイロードのハッシュをヘッダーで送る必要がある
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	canonicalHeaders, signedHeaders := s.canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(sigV4DateFormat), s.Region, s.Service, "aws4_request"}, "/")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(sigV4DateFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalHeaders は署名するヘッダーの正規形と、署名したヘッダー名の一覧を返す
+func (s *AWSSigner) canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.Join(values, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(strings.Fields(headers[name]), " "))
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalURI はパスの正規形を返す
+// S3以外のサービスでは、エンコード済みのパスをさらにエンコードする（SigV4の仕様）
+func (s *AWSSigner) canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segment = sigV4Escape(segment)
+		if s.Service != "s3" {
+			segment = sigV4Escape(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery はクエリ文字列の正規形（名前と値で並べ替えてエンコードしたもの）を返す
+func canonicalQuery(u *url.URL) string {
+	type pair struct{ name, value string }
+	var pairs []pair
+	for name, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, pair{sigV4Escape(name), sigV4Escape(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].name != pairs[j].name {
+			return pairs[i].name < pairs[j].name
+		}
+		return pairs[i].value < pairs[j].value
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = p.name + "=" + p.value
+	}
+	return strings.Join(encoded, "&")
+}
+
+// sigV4Escape はSigV4の規則（RFC 3986の非予約文字以外を %XX にする）でエンコードする
+func sigV4Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
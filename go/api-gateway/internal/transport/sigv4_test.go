@@ -0,0 +1,167 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AWSが公開しているSigV4のテストスイート（aws-sig-v4-test-suite）の認証情報と日時
+var (
+	sigV4TestCredentials = AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	sigV4TestTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+)
+
+func TestAWSSigner_Sign(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		wantSignature string
+	}{
+		{
+			name:          "get-vanilla",
+			method:        http.MethodGet,
+			target:        "/",
+			wantSignature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			method:        http.MethodGet,
+			target:        "/?Param2=value2&Param1=value1",
+			wantSignature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:          "post-vanilla",
+			method:        http.MethodPost,
+			target:        "/",
+			wantSignature: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Host = "example.amazonaws.com"
+			bodyHash := sha256.Sum256([]byte(tt.body))
+
+			signer := &AWSSigner{Service: "service", Region: "us-east-1", Credentials: StaticAWSCredentials(sigV4TestCredentials)}
+			if err := signer.Sign(context.Background(), req, bodyHash[:], sigV4TestTime); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tt.wantSignature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization = %q, want %q", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q, want 20150830T123600Z", got)
+			}
+		})
+	}
+
+	t.Run("一時的な認証情報はセッショントークンも署名する", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = "example.amazonaws.com"
+		bodyHash := sha256.Sum256(nil)
+
+		creds := sigV4TestCredentials
+		creds.SessionToken = "session-token"
+		signer := &AWSSigner{Service: "es", Region: "ap-northeast-1", Credentials: StaticAWSCredentials(creds)}
+		if err := signer.Sign(context.Background(), req, bodyHash[:], sigV4TestTime); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := req.Header.Get("X-Amz-Security-Token"); got != "session-token" {
+			t.Errorf("X-Amz-Security-Token = %q, want session-token", got)
+		}
+		if got := req.Header.Get("Authorization"); !strings.Contains(got, "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+			t.Errorf("Authorization should sign x-amz-security-token, got %q", got)
+		}
+	})
+
+	t.Run("S3はペイロードのハッシュをヘッダーで送る", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+		bodyHash := sha256.Sum256(nil)
+
+		signer := &AWSSigner{Service: "s3", Region: "us-east-1", Credentials: StaticAWSCredentials(sigV4TestCredentials)}
+		if err := signer.Sign(context.Background(), req, bodyHash[:], sigV4TestTime); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := req.Header.Get("X-Amz-Content-Sha256"); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+			t.Errorf("X-Amz-Content-Sha256 = %q", got)
+		}
+	})
+
+	t.Run("認証情報を取得できない場合はエラー", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		signer := &AWSSigner{Service: "execute-api", Region: "us-east-1", Credentials: EnvAWSCredentials()}
+		if err := signer.Sign(context.Background(), req, nil, sigV4TestTime); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestAWSSigner_canonicalURI(t *testing.T) {
+	u, _ := url.Parse("https://example.com/documents%20and%20settings/a+b")
+
+	if got := (&AWSSigner{Service: "s3"}).canonicalURI(u); got != "/documents%20and%20settings/a%2Bb" {
+		t.Errorf("s3 canonicalURI = %q", got)
+	}
+	if got := (&AWSSigner{Service: "execute-api"}).canonicalURI(u); got != "/documents%2520and%2520settings/a%252Bb" {
+		t.Errorf("execute-api canonicalURI = %q", got)
+	}
+}
+
+func TestHTTPTransporter_Transport_AWSSigV4(t *testing.T) {
+	var authorization, body string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		b := new(strings.Builder)
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Body.Read(buf)
+			b.Write(buf[:n])
+			if err != nil {
+				break
+			}
+		}
+		body = b.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	backendURL, _ := url.Parse(backendServer.URL)
+	backend := &Backend{
+		URL:       backendURL,
+		AWSSigner: &AWSSigner{Service: "execute-api", Region: "us-east-1", Credentials: StaticAWSCredentials(sigV4TestCredentials)},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"test"}`))
+	req.Header.Set("Authorization", "Bearer user-token")
+	w := httptest.NewRecorder()
+
+	if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		t.Errorf("expected SigV4 Authorization, got %q", authorization)
+	}
+	if body != `{"name":"test"}` {
+		t.Errorf("expected body to be forwarded, got %q", body)
+	}
+}
@@ -0,0 +1,471 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"api-gateway/internal/errors"
+)
+
+const (
+	// SOAP11 と SOAP12 はSOAPのバージョン
+	SOAP11 = "1.1"
+	SOAP12 = "1.2"
+
+	// DefaultSOAPMaxBody は変換するリクエスト・レスポンスのボディの上限バイト数のデフォルト値
+	DefaultSOAPMaxBody = 10 << 20 // 10MiB
+
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+	xsiNamespace    = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// SOAPAdapter はJSONのリクエストをSOAPのエンベロープに包んでレガシーなバックエンドへ送り、SOAPのレスポンスをJSONへ戻す
+// 1つのルートがWSDLの1つのオペレーションに対応する。WSDLは読み込まないため、オペレーション名・名前空間・SOAPActionは設定で指定する
+//
+// リクエストのJSONオブジェクトのフィールドは、受け取った順にオペレーションの要素の子要素になる（配列は同じ名前の要素の繰り返し、nullは xsi:nil）
+// ボディの無いリクエストはクエリパラメータを子要素にする。要素の構造が合わない場合は Template で soap:Body の中身を指定する
+//
+// レスポンスは soap:Body の最初の要素をJSONオブジェクトにする（名前空間の接頭辞は除き、繰り返す要素は配列、テキストのみの要素は文字列）
+// SOAP Fault はクライアント起因（Client / Sender）の場合は400、それ以外は502のエラーレスポンスにする
+type SOAPAdapter struct {
+	// Version はSOAPのバージョン（"1.1" または "1.2"。空は 1.1）
+	Version string
+	// Operation はWSDLのオペレーション名（リクエストの要素名）
+	Operation string
+	// Namespace はオペレーションの要素の名前空間（WSDLの targetNamespace）
+	Namespace string
+	// Action はSOAPAction（1.1はヘッダー、1.2はContent-Typeの action パラメータ）
+	Action string
+	// Template は soap:Body の中身のテンプレート（nilの場合はJSONから生成する）
+	// .Operation, .Namespace, .Params（リクエストのJSONオブジェクト）を参照でき、値は xml 関数でエスケープする
+	Template *template.Template
+	// MaxBody は変換するボディの上限バイト数（0は DefaultSOAPMaxBody）
+	MaxBody int64
+}
+
+// soapTemplateData はテンプレートに渡す値
+type soapTemplateData struct {
+	Operation string
+	Namespace string
+	Params    map[string]any
+}
+
+// NewSOAPTemplate は soap:Body の中身のテンプレートを作成する
+func NewSOAPTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("soap").Option("missingkey=zero").Funcs(template.FuncMap{
+		"xml": func(v any) string {
+			if v == nil {
+				return ""
+			}
+			return xmlEscape(fmt.Sprint(v))
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid soap template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// prepareRequest はリクエストのJSONをSOAPのエンベロープに変換し、バックエンドのエンドポイントへPOSTするリクエストにする
+func (a *SOAPAdapter) prepareRequest(req *http.Request, endpoint *url.URL) error {
+	params, err := a.readParams(req)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if a.Template != nil {
+		data := soapTemplateData{Operation: a.Operation, Namespace: a.Namespace, Params: map[string]any{}}
+		dec := json.NewDecoder(bytes.NewReader(params))
+		dec.UseNumber()
+		if err := dec.Decode(&data.Params); err != nil {
+			return errors.NewBadRequestError("request body must be a JSON object")
+		}
+		if err := a.Template.Execute(&body, data); err != nil {
+			return errors.NewInternalServerError(fmt.Sprintf("failed to render soap template: %v", err))
+		}
+	} else if err := a.writeOperation(&body, params); err != nil {
+		return err
+	}
+
+	envelope := a.envelope(body.Bytes())
+	req.Method = http.MethodPost
+	req.URL.Path = endpoint.Path
+	req.URL.RawPath = endpoint.RawPath
+	req.URL.RawQuery = endpoint.RawQuery
+	req.Body = io.NopCloser(bytes.NewReader(envelope))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(envelope)), nil
+	}
+	req.ContentLength = int64(len(envelope))
+	req.Header.Set("Content-Length", strconv.Itoa(len(envelope)))
+	req.Header.Del("Content-Encoding")
+	// レスポンスを変換するため、圧縮せずに応答させる
+	req.Header.Del("Accept-Encoding")
+
+	if a.Version == SOAP12 {
+		contentType := "application/soap+xml; charset=utf-8"
+		if a.Action != "" {
+			contentType += "; action=" + strconv.Quote(a.Action)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", "application/soap+xml, text/xml")
+		req.Header.Del("SOAPAction")
+	} else {
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		req.Header.Set("Accept", "text/xml")
+		req.Header.Set("SOAPAction", strconv.Quote(a.Action))
+	}
+	return nil
+}
+
+// readParams はリクエストのJSONオブジェクトを読み込む。ボディが無い場合はクエリパラメータから作る
+func (a *SOAPAdapter) readParams(req *http.Request) ([]byte, error) {
+	var data []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		data, err = io.ReadAll(io.LimitReader(req.Body, a.maxBody()+1))
+		req.Body.Close()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if stderrors.As(err, &maxBytesErr) {
+				return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit))
+			}
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if int64(len(data)) > a.maxBody() {
+			return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", a.maxBody()))
+		}
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			return nil, errors.NewError(http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "request body must be JSON")
+		}
+		return data, nil
+	}
+
+	query := make(map[string]string)
+	for key, values := range req.URL.Query() {
+		query[key] = values[0]
+	}
+	return json.Marshal(query)
+}
+
+// writeOperation はJSONオブジェクトをオペレーションの要素として書き出す（フィールドの順序を保つ）
+func (a *SOAPAdapter) writeOperation(w *bytes.Buffer, params []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return errors.NewBadRequestError("request body must be a JSON object")
+	}
+
+	fmt.Fprintf(w, `<%s xmlns="%s">`, a.Operation, xmlEscape(a.Namespace))
+	for dec.More() {
+		if err := writeJSONField(w, dec); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return errors.NewBadRequestError("request body must be a JSON object")
+	}
+	fmt.Fprintf(w, "</%s>", a.Operation)
+	return nil
+}
+
+// writeJSONField はJSONオブジェクトの1つのフィールドを要素として書き出す
+func writeJSONField(w *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return errors.NewBadRequestError("invalid JSON request body")
+	}
+	name, _ := tok.(string)
+	if !isXMLName(name) {
+		return errors.NewBadRequestError(fmt.Sprintf("field %q is not a valid XML element name", name))
+	}
+	return writeJSONValue(w, dec, name)
+}
+
+// writeJSONValue はJSONの値を name の要素として書き出す（配列は要素の繰り返しにする）
+func writeJSONValue(w *bytes.Buffer, dec *json.Decoder, name string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return errors.NewBadRequestError("invalid JSON request body")
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			for dec.More() {
+				if err := writeJSONValue(w, dec, name); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return errors.NewBadRequestError("invalid JSON request body")
+			}
+			return nil
+		}
+		fmt.Fprintf(w, "<%s>", name)
+		for dec.More() {
+			if err := writeJSONField(w, dec); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return errors.NewBadRequestError("invalid JSON request body")
+		}
+		fmt.Fprintf(w, "</%s>", name)
+	case nil:
+		fmt.Fprintf(w, `<%s xsi:nil="true"/>`, name)
+	default:
+		fmt.Fprintf(w, "<%s>%s</%s>", name, xmlEscape(fmt.Sprint(v)), name)
+	}
+	return nil
+}
+
+// envelope は soap:Body の中身をエンベロープで包む
+func (a *SOAPAdapter) envelope(body []byte) []byte {
+	namespace := soap11Namespace
+	if a.Version == SOAP12 {
+		namespace = soap12Namespace
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, `<soap:Envelope xmlns:soap="%s" xmlns:xsi="%s"><soap:Body>`, namespace, xsiNamespace)
+	buf.Write(body)
+	buf.WriteString("</soap:Body></soap:Envelope>")
+	return buf.Bytes()
+}
+
+// adapt はSOAPのレスポンスをJSONに変換する。XML以外のレスポンス（プロキシのエラーページなど）はそのまま返す
+func (a *SOAPAdapter) adapt(resp *http.Response) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isXML := mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml")
+	if !isXML || resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		resp.Body.Close()
+		return fmt.Errorf("cannot convert soap response with content encoding %q", encoding)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, a.maxBody()+1))
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read backend response body: %w", err)
+	}
+	if int64(len(data)) > a.maxBody() {
+		return fmt.Errorf("cannot convert soap response body larger than %d bytes", a.maxBody())
+	}
+
+	root, err := parseXMLNode(data)
+	if err != nil {
+		return fmt.Errorf("invalid soap response: %w", err)
+	}
+	payload := root.child("Body").firstChild()
+	if root.name.Local != "Envelope" || payload == nil {
+		return fmt.Errorf("invalid soap response: soap body is missing")
+	}
+
+	var body []byte
+	if payload.name.Local == "Fault" {
+		fault := soapFaultError(payload)
+		resp.StatusCode = fault.StatusCode()
+		resp.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		body = errors.ToJSON(fault)
+	} else {
+		value := payload.value()
+		if _, ok := value.(map[string]any); !ok {
+			value = map[string]any{payload.name.Local: value}
+		}
+		if body, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("failed to encode soap response: %w", err)
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.Header.Del("ETag")
+	resp.Header.Del("Content-MD5")
+	return nil
+}
+
+// soapFaultError は SOAP Fault（1.1 と 1.2）をエラーレスポンスにする
+func soapFaultError(fault *xmlNode) errors.GatewayError {
+	// 1.1: faultcode / faultstring / detail, 1.2: Code/Value / Reason/Text / Detail
+	code := fault.child("faultcode").text()
+	if code == "" {
+		code = fault.child("Code").child("Value").text()
+	}
+	message := fault.child("faultstring").text()
+	if message == "" {
+		message = fault.child("Reason").child("Text").text()
+	}
+	detail := fault.child("detail")
+	if detail == nil {
+		detail = fault.child("Detail")
+	}
+
+	// 接頭辞（soap:Client など）を除いてクライアント起因のエラーか判定する
+	_, local, ok := strings.Cut(code, ":")
+	if !ok {
+		local = code
+	}
+	status := http.StatusBadGateway
+	if local == "Client" || local == "Sender" {
+		status = http.StatusBadRequest
+	}
+
+	details := map[string]any{"fault_code": code}
+	if detail != nil {
+		details["detail"] = detail.value()
+	}
+	return errors.NewErrorWithDetails(status, "SOAP_FAULT", message, details)
+}
+
+func (a *SOAPAdapter) maxBody() int64 {
+	if a.MaxBody > 0 {
+		return a.MaxBody
+	}
+	return DefaultSOAPMaxBody
+}
+
+// xmlNode はSOAPのレスポンスの要素
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlNode
+	chars    strings.Builder
+}
+
+// parseXMLNode はXMLを要素の木にする
+func parseXMLNode(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].chars.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// child は名前（接頭辞を除く）が一致する最初の子要素を返す
+func (n *xmlNode) child(local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.children {
+		if c.name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+// firstChild は最初の子要素を返す
+func (n *xmlNode) firstChild() *xmlNode {
+	if n == nil || len(n.children) == 0 {
+		return nil
+	}
+	return n.children[0]
+}
+
+// text は要素のテキストを返す
+func (n *xmlNode) text() string {
+	if n == nil {
+		return ""
+	}
+	return strings.TrimSpace(n.chars.String())
+}
+
+// value は要素をJSONの値にする
+// 子要素が無い場合はテキスト（xsi:nil の場合は null）、ある場合はオブジェクト（同じ名前の子要素は配列）にする
+// 1つしかない要素は配列にならないため、バックエンドが繰り返す要素を返す場合はクライアント側で単数も扱う必要がある
+func (n *xmlNode) value() any {
+	if len(n.children) == 0 {
+		if slices.ContainsFunc(n.attrs, func(a xml.Attr) bool {
+			return a.Name.Space == xsiNamespace && a.Name.Local == "nil" && a.Value == "true"
+		}) {
+			return nil
+		}
+		return n.text()
+	}
+
+	counts := make(map[string]int, len(n.children))
+	for _, c := range n.children {
+		counts[c.name.Local]++
+	}
+	obj := make(map[string]any, len(counts))
+	for _, c := range n.children {
+		if counts[c.name.Local] > 1 {
+			items, _ := obj[c.name.Local].([]any)
+			obj[c.name.Local] = append(items, c.value())
+		} else {
+			obj[c.name.Local] = c.value()
+		}
+	}
+	return obj
+}
+
+// xmlEscape は文字列をXMLのテキスト・属性値としてエスケープする
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// isXMLName はJSONのフィールド名がXMLの要素名として使えるか確認する（接頭辞は使えない）
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r > 0x7f:
+		case i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
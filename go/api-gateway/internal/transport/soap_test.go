@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/errors"
+)
+
+func TestHTTPTransporter_Transport_SOAP(t *testing.T) {
+	const quoteResponse = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <soap:Body>
+    <q:GetQuoteResponse xmlns:q="http://legacy.example.com/quotes">
+      <q:Price>123.45</q:Price>
+      <q:Currency>JPY</q:Currency>
+      <q:Tier><q:Name>gold</q:Name></q:Tier>
+      <q:Tier><q:Name>silver</q:Name></q:Tier>
+      <q:Note xsi:nil="true"/>
+    </q:GetQuoteResponse>
+  </soap:Body>
+</soap:Envelope>`
+	const fault = `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>
+  <soap:Fault><faultcode>soap:Client</faultcode><faultstring>Unknown symbol</faultstring><detail><Symbol>XXX</Symbol></detail></soap:Fault>
+</soap:Body></soap:Envelope>`
+
+	var gotMethod, gotPath, gotAction, gotContentType, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotPath, gotAction, gotContentType, gotBody = r.Method, r.URL.Path, r.Header.Get("SOAPAction"), r.Header.Get("Content-Type"), string(body)
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		if strings.Contains(gotBody, "XXX") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fault))
+			return
+		}
+		w.Write([]byte(quoteResponse))
+	}))
+	defer server.Close()
+
+	backendURL, _ := url.Parse(server.URL + "/QuoteService.asmx")
+	adapter := &SOAPAdapter{
+		Operation: "GetQuote",
+		Namespace: "http://legacy.example.com/quotes",
+		Action:    "http://legacy.example.com/quotes/GetQuote",
+	}
+	wantQuote := map[string]any{
+		"Price":    "123.45",
+		"Currency": "JPY",
+		"Tier":     []any{map[string]any{"Name": "gold"}, map[string]any{"Name": "silver"}},
+		"Note":     nil,
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		template    string
+		wantBody    string
+		wantStatus  int
+		wantJSON    map[string]any
+		wantErrCode string
+	}{
+		{
+			name:       "JSONのフィールドを順序どおりに子要素にする",
+			method:     http.MethodPost,
+			target:     "/api/v1/quotes",
+			body:       `{"Symbol":"ACME","Options":{"Currency":"JPY","Amounts":[1,2]},"Memo":null,"Text":"a<b"}`,
+			wantBody:   `<GetQuote xmlns="http://legacy.example.com/quotes"><Symbol>ACME</Symbol><Options><Currency>JPY</Currency><Amounts>1</Amounts><Amounts>2</Amounts></Options><Memo xsi:nil="true"/><Text>a&lt;b</Text></GetQuote>`,
+			wantStatus: http.StatusOK,
+			wantJSON:   wantQuote,
+		},
+		{
+			name:       "ボディが無い場合はクエリパラメータ",
+			method:     http.MethodGet,
+			target:     "/api/v1/quotes?Symbol=ACME",
+			wantBody:   `<GetQuote xmlns="http://legacy.example.com/quotes"><Symbol>ACME</Symbol></GetQuote>`,
+			wantStatus: http.StatusOK,
+			wantJSON:   wantQuote,
+		},
+		{
+			name:       "テンプレート",
+			method:     http.MethodPost,
+			target:     "/api/v1/quotes",
+			body:       `{"symbol":"A&B"}`,
+			template:   `<q:GetQuote xmlns:q="{{.Namespace}}"><q:Symbol>{{xml .Params.symbol}}</q:Symbol></q:GetQuote>`,
+			wantBody:   `<q:GetQuote xmlns:q="http://legacy.example.com/quotes"><q:Symbol>A&amp;B</q:Symbol></q:GetQuote>`,
+			wantStatus: http.StatusOK,
+			wantJSON:   wantQuote,
+		},
+		{
+			name:        "クライアント起因のFaultは400",
+			method:      http.MethodPost,
+			target:      "/api/v1/quotes",
+			body:        `{"Symbol":"XXX"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantErrCode: "SOAP_FAULT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &Backend{URL: backendURL, SOAP: adapter}
+			if tt.template != "" {
+				tmpl, err := NewSOAPTemplate(tt.template)
+				if err != nil {
+					t.Fatalf("NewSOAPTemplate() error = %v", err)
+				}
+				copied := *adapter
+				copied.Template = tmpl
+				backend.SOAP = &copied
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			if err := NewHTTPTransporter().Transport(context.Background(), w, req, backend); err != nil {
+				t.Fatalf("Transport() error = %v", err)
+			}
+
+			if gotMethod != http.MethodPost || gotPath != "/QuoteService.asmx" {
+				t.Errorf("backend request = %s %s, want POST /QuoteService.asmx", gotMethod, gotPath)
+			}
+			if gotAction != `"http://legacy.example.com/quotes/GetQuote"` || !strings.HasPrefix(gotContentType, "text/xml") {
+				t.Errorf("SOAPAction = %s, Content-Type = %s", gotAction, gotContentType)
+			}
+			if tt.wantBody != "" && !strings.Contains(gotBody, "<soap:Body>"+tt.wantBody+"</soap:Body>") {
+				t.Errorf("envelope = %s, want body %s", gotBody, tt.wantBody)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %s", w.Header().Get("Content-Type"))
+			}
+			var got map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON response %s: %v", w.Body.String(), err)
+			}
+			if tt.wantErrCode != "" {
+				errBody, _ := got["error"].(map[string]any)
+				if errBody["code"] != tt.wantErrCode || errBody["message"] != "Unknown symbol" {
+					t.Errorf("response = %v", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.wantJSON) {
+				t.Errorf("response = %v, want %v", got, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestSOAPAdapter_prepareRequest_Invalid(t *testing.T) {
+	adapter := &SOAPAdapter{Operation: "GetQuote", Namespace: "urn:quotes"}
+	endpoint, _ := url.Parse("http://legacy.example.com/QuoteService.asmx")
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "JSON以外", contentType: "application/xml", body: `<a/>`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "配列", contentType: "application/json", body: `[1]`, wantStatus: http.StatusBadRequest},
+		{name: "要素名にできないフィールド", contentType: "application/json", body: `{"1st":"a"}`, wantStatus: http.StatusBadRequest},
+		{name: "不正なJSON", contentType: "application/json", body: `{"a":`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/quotes", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			err := adapter.prepareRequest(req, endpoint)
+			gatewayErr, ok := err.(errors.GatewayError)
+			if !ok || gatewayErr.StatusCode() != tt.wantStatus {
+				t.Errorf("prepareRequest() error = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
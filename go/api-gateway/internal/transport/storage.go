@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-gateway/internal/errors"
+)
+
+// storageForwardHeaders はオブジェクトストレージへ転送するクライアントのヘッダー（範囲指定と条件付きリクエストのみ）
+var storageForwardHeaders = []string{
+	"Range",
+	"If-Range",
+	"If-Match",
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Unmodified-Since",
+}
+
+// ObjectStorage はS3互換のオブジェクトストレージのオブジェクトをクライアントへそのまま配信する
+// ファイル配信のためのサービスを置かずに、ゲートウェイからダウンロードさせるために使う
+//
+// オブジェクトのキーは KeyPrefix とリクエストのパスのうち RoutePrefix より後の部分をつなげたもの
+// GET と HEAD のみ受け付け、Range と条件付きリクエストのヘッダー以外は転送しない（Authorization や Cookie を送らない）
+// バックエンドの認証は aws_sigv4（service: s3）で行い、クライアントの認証は署名付きURL（SignedURLSecret）で行える
+type ObjectStorage struct {
+	// RoutePrefix はキーに含めないリクエストのパスの接頭辞（ルートのパスのうちワイルドカード・パラメータより前）
+	RoutePrefix string
+	// KeyPrefix はオブジェクトのキーの接頭辞（"public/" など）
+	KeyPrefix string
+	// SignedURLSecret は署名付きURLの検証に使う鍵（空の場合は署名付きURLを要求しない）
+	SignedURLSecret []byte
+
+	// now は署名付きURLの期限の判定に使う現在時刻（テスト用）
+	now func() time.Time
+}
+
+// SignObjectURL は path（クエリを含まないリクエストのパス）を expires まで取得できる署名付きURLのクエリを返す
+// クエリは "expires=<unix秒>&signature=<base64url>" の形式で、path に付けてクライアントへ渡す
+func SignObjectURL(secret []byte, path string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return url.Values{"expires": {exp}, "signature": {objectURLSignature(secret, path, exp)}}.Encode()
+}
+
+// objectURLSignature は署名付きURLの署名を計算する
+func objectURLSignature(secret []byte, path, expires string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(path + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// prepareRequest はクライアントのリクエストを検証し、オブジェクトを取得するリクエストにする
+// path はクライアントのリクエストのパス、endpoint はバケットのURL
+func (s *ObjectStorage) prepareRequest(req *http.Request, path string, endpoint *url.URL) error {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return errors.NewError(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET and HEAD are allowed for object storage routes")
+	}
+	if len(s.SignedURLSecret) > 0 {
+		if err := s.verifySignedURL(req.URL.Query(), path); err != nil {
+			return err
+		}
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(path, s.RoutePrefix), "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		return errors.NewNotFoundError("object not found")
+	}
+	key = s.KeyPrefix + key
+
+	header := make(http.Header)
+	for _, name := range storageForwardHeaders {
+		if values := req.Header.Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	req.Header = header
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	req.GetBody = nil
+
+	req.URL.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + key
+	req.URL.RawPath = ""
+	req.URL.RawQuery = ""
+	return nil
+}
+
+// verifySignedURL は署名付きURLの署名と期限を確認する
+func (s *ObjectStorage) verifySignedURL(query url.Values, path string) error {
+	expires, sig := query.Get("expires"), query.Get("signature")
+	if expires == "" || sig == "" {
+		return errors.NewForbiddenError("signed URL is required")
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.NewForbiddenError("invalid signed URL")
+	}
+	want := objectURLSignature(s.SignedURLSecret, path, expires)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return errors.NewForbiddenError("invalid signed URL")
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	if now().Unix() > exp {
+		return errors.NewForbiddenError("signed URL has expired")
+	}
+	return nil
+}
+
+// adapt はストレージ固有のヘッダーを削除し、エラー（S3のXML）をゲートウェイのエラーレスポンスにする
+// ボディはストリーミングのまま返す
+func (s *ObjectStorage) adapt(resp *http.Response) error {
+	for name := range resp.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			resp.Header.Del(name)
+		}
+	}
+
+	var gatewayErr errors.GatewayError
+	switch {
+	case resp.StatusCode < 400, resp.StatusCode == http.StatusPreconditionFailed, resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusForbidden:
+		// ListBucket の権限が無い場合、S3は存在しないオブジェクトに403を返すため、どちらも404にする
+		gatewayErr = errors.NewNotFoundError("object not found")
+	default:
+		gatewayErr = errors.NewBadGatewayError(fmt.Sprintf("object storage returned status %d", resp.StatusCode))
+	}
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	body := errors.ToJSON(gatewayErr)
+	resp.StatusCode = gatewayErr.StatusCode()
+	resp.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header = http.Header{
+		"Content-Type":   {"application/json"},
+		"Content-Length": {strconv.Itoa(len(body))},
+	}
+	return nil
+}
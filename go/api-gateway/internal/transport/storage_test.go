@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/internal/errors"
+)
+
+func TestHTTPTransporter_Transport_ObjectStorage(t *testing.T) {
+	const content = "0123456789"
+	var gotPath, gotQuery string
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotHeader = r.URL.Path, r.URL.RawQuery, r.Header.Clone()
+		if r.URL.Path != "/bucket/public/reports/2026/q1.csv" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<Error><Code>AccessDenied</Code></Error>`))
+			return
+		}
+		w.Header().Set("X-Amz-Request-Id", "abc")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "q1.csv", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	backendURL, _ := url.Parse(server.URL + "/bucket")
+
+	secret := []byte("download-secret")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	signed := "/files/reports/2026/q1.csv?" + SignObjectURL(secret, "/files/reports/2026/q1.csv", now.Add(time.Hour))
+	expired := "/files/reports/2026/q1.csv?" + SignObjectURL(secret, "/files/reports/2026/q1.csv", now.Add(-time.Second))
+	otherPath := "/files/reports/2026/q2.csv?" + SignObjectURL(secret, "/files/reports/2026/q1.csv", now.Add(time.Hour))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		rangeValue string
+		secret     []byte
+		wantStatus int
+		wantBody   string
+		wantErr    int
+	}{
+		{name: "オブジェクトを返す", method: http.MethodGet, target: "/files/reports/2026/q1.csv", wantStatus: http.StatusOK, wantBody: content},
+		{name: "範囲指定", method: http.MethodGet, target: "/files/reports/2026/q1.csv", rangeValue: "bytes=2-4", wantStatus: http.StatusPartialContent, wantBody: "234"},
+		{name: "存在しない場合は404", method: http.MethodGet, target: "/files/reports/missing.csv", wantStatus: http.StatusNotFound},
+		{name: "署名付きURL", method: http.MethodGet, target: signed, secret: secret, wantStatus: http.StatusOK, wantBody: content},
+		{name: "署名が無い", method: http.MethodGet, target: "/files/reports/2026/q1.csv", secret: secret, wantErr: http.StatusForbidden},
+		{name: "期限切れ", method: http.MethodGet, target: expired, secret: secret, wantErr: http.StatusForbidden},
+		{name: "別のパスの署名", method: http.MethodGet, target: otherPath, secret: secret, wantErr: http.StatusForbidden},
+		{name: "GET/HEAD以外", method: http.MethodPut, target: "/files/reports/2026/q1.csv", wantErr: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &ObjectStorage{RoutePrefix: "/files", KeyPrefix: "public/", SignedURLSecret: tt.secret, now: func() time.Time { return now }}
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Authorization", "Bearer client-token")
+			req.Header.Set("Cookie", "session=1")
+			if tt.rangeValue != "" {
+				req.Header.Set("Range", tt.rangeValue)
+			}
+			w := httptest.NewRecorder()
+
+			err := NewHTTPTransporter().Transport(context.Background(), w, req, &Backend{URL: backendURL, Storage: storage})
+			if tt.wantErr != 0 {
+				gatewayErr, ok := err.(errors.GatewayError)
+				if !ok || gatewayErr.StatusCode() != tt.wantErr {
+					t.Fatalf("Transport() error = %v, want status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transport() error = %v", err)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if gotQuery != "" || gotHeader.Get("Authorization") != "" || gotHeader.Get("Cookie") != "" {
+				t.Errorf("client query or credentials were forwarded: query=%q header=%v", gotQuery, gotHeader)
+			}
+			if w.Header().Get("X-Amz-Request-Id") != "" {
+				t.Error("x-amz-* headers should be removed")
+			}
+			if tt.wantBody != "" {
+				if gotPath != "/bucket/public/reports/2026/q1.csv" {
+					t.Errorf("object path = %s", gotPath)
+				}
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,38 @@ package transport
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"time"
 
+	"api-gateway/internal/buffer"
 	"api-gateway/internal/errors"
+	"api-gateway/internal/healthcheck"
+	"api-gateway/internal/metrics"
+	"api-gateway/pkg/signature"
+)
+
+// ErrClientAborted はバックエンドの応答を返し終える前にクライアントが切断した場合のエラー
+// レスポンスは書き込めないため、呼び出し元はエラーレスポンスを返さずに処理を終える
+var ErrClientAborted = stderrors.New("client aborted request")
+
+// ErrRequestTimeout はバックエンドの応答を返し終える前に ctx の期限（timeout ミドルウェア）を過ぎた場合のエラー
+// バックエンドの Timeout を過ぎた場合は含まない（502として扱う）
+var ErrRequestTimeout = stderrors.New("request timed out")
+
+// HeaderRequestTimeout はバックエンドへ期限までの残り時間（ミリ秒）を伝えるリクエストヘッダー
+// バックエンドは期限までに終わらない処理を始めずに打ち切れる
+const HeaderRequestTimeout = "X-Request-Timeout-Ms"
+
+// clientAbortedTotal はクライアントの切断により中断したリクエスト数
+var clientAbortedTotal = metrics.NewCounter(
+	"gateway_client_aborted_requests_total",
+	"Number of requests aborted because the client disconnected before the response completed.",
 )
 
 // Transporter はバックエンドへのHTTPリクエスト転送を行うインターフェース
@@ -26,33 +52,142 @@ type Backend struct {
 
 	// Headers はバックエンドに追加するヘッダー
 	Headers map[string]string
+
+	// Protocol はバックエンドとの通信プロトコル
+	Protocol Protocol
+
+	// Pool はバックエンドへの接続プールの設定（同じプロトコルと設定のバックエンドで接続プールを共有する）
+	Pool ConnectionPool
+
+	// MaxResponseBody はレスポンスボディの上限バイト数（0は無制限）
+	MaxResponseBody int64
+
+	// DecompressResponses はクライアントが受け付けない gzip のレスポンスを展開し、長さの分からないレスポンスに Content-Length を設定する
+	DecompressResponses bool
+
+	// Signer は転送するリクエストに署名する（nilの場合は署名しない）
+	Signer *signature.Signer
+
+	// Retry はバックエンドが502/504を返した場合の再試行の方針（nilの場合は再試行しない）
+	Retry *RetryPolicy
+
+	// Credentials はバックエンド用のアクセストークンを取得する（nilの場合はクライアントの Authorization をそのまま転送する）
+	Credentials *ClientCredentials
+
+	// AWSSigner は転送するリクエストにAWS SigV4で署名する（nilの場合は署名しない）
+	AWSSigner *AWSSigner
+
+	// Shadow は移行先のバックエンドにも同じリクエストを送り、レスポンスを比較する（nilの場合は比較しない）
+	Shadow *ShadowCompare
+
+	// Mirror はリクエストの複製をミラーのバックエンドへ非同期に送る（nilの場合は送らない）
+	Mirror *Mirror
+
+	// Masker はレスポンスに含まれる個人情報をマスクする（nilの場合はマスクしない）
+	Masker *ResponseMasker
+
+	// Validator はレスポンスをルートのOpenAPIドキュメントで検証する（nilの場合は検証しない）
+	Validator *ResponseValidator
+
+	// SOAP はJSONのリクエストとSOAPのバックエンドの間で変換する（nilの場合は変換しない）
+	// 設定されたバックエンドへは URL のエンドポイントへ常にPOSTする
+	SOAP *SOAPAdapter
+
+	// Storage はオブジェクトストレージのオブジェクトを配信する設定（nilの場合はリクエストをそのまま転送する）
+	Storage *ObjectStorage
+
+	// LongPoll はロングポーリングのクライアントをSSE・WebSocketのバックエンドへ中継する設定（nilの場合は中継しない）
+	LongPoll *LongPollOptions
+
+	// GRPC はgRPCのバックエンドへの転送の設定（nilの場合はgRPCのバックエンドではない）
+	// 設定されたバックエンドへは GRPCTransporter で転送する
+	GRPC *GRPCOptions
+
+	// Aggregate は複数のバックエンドを呼び出してレスポンスをまとめる設定（nilの場合は URL へ転送する）
+	Aggregate *Aggregator
+
+	// PathParams はルートのパスパラメータ（Aggregate のURLの {name} を置き換える）
+	PathParams map[string]string
 }
 
 // HTTPTransporter は標準的なHTTPリバースプロキシによる転送を行う
 type HTTPTransporter struct {
 	// ErrorHandler はプロキシエラー時のハンドラ
 	ErrorHandler func(w http.ResponseWriter, req *http.Request, err error)
+
+	// Buffer はレスポンスボディ全体を読み込む場合のバッファ設定
+	// メモリ上限を超えた分は一時ファイルへ書き出す
+	Buffer buffer.Config
+
+	// Logger はシャドー比較の差分などを記録するロガー（nilの場合は slog.Default）
+	Logger *slog.Logger
+
+	// Outliers はバックエンドごとの5xxとタイムアウトを記録する外れ値検出（nilの場合は記録しない）
+	Outliers *healthcheck.OutlierDetector
+
+	// transports はプロトコルと接続プールの設定ごとのRoundTripper
+	transports *roundTrippers
 }
 
 // NewHTTPTransporter は新しいHTTPTransporterを作成する
 func NewHTTPTransporter() *HTTPTransporter {
 	return &HTTPTransporter{
 		ErrorHandler: defaultErrorHandler,
+		transports:   newRoundTrippers(),
 	}
 }
 
 // Transport はリクエストをバックエンドに転送する
-func (t *HTTPTransporter) Transport(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *Backend) error {
+// ctx がキャンセルされる（クライアントが切断する）とバックエンドへのリクエストも中断し、ErrClientAborted を返す
+func (t *HTTPTransporter) Transport(ctx context.Context, w http.ResponseWriter, req *http.Request, backend *Backend) (err error) {
 	if backend == nil || backend.URL == nil {
 		return errors.NewBadGatewayError("invalid backend configuration")
 	}
 
+	// まとめるバックエンドの呼び出しには Aggregate.Timeout をまとめて適用する
+	if backend.Aggregate != nil {
+		return t.aggregate(ctx, w, req, backend)
+	}
+
+	// ロングポーリングはイベントを待つ時間（LongPoll.Timeout）で打ち切るため、バックエンドのタイムアウトは適用しない
+	if backend.LongPoll != nil {
+		return t.bridgeLongPoll(ctx, w, req, backend)
+	}
+
+	// クライアント側のキャンセルとバックエンドのタイムアウトを区別するため、タイムアウト設定前のctxを保持する
+	clientCtx := ctx
+
 	// タイムアウト設定
 	if backend.Timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, backend.Timeout)
 		defer cancel()
-		req = req.WithContext(ctx)
+	}
+	// 転送するリクエストは常に ctx に紐付け、キャンセルをバックエンドへ伝搬させる
+	req = req.WithContext(ctx)
+
+	// レスポンスボディのコピー中にクライアントが切断すると ReverseProxy は http.ErrAbortHandler で panic する
+	defer func() {
+		if rec := recover(); rec != nil {
+			if rec != http.ErrAbortHandler || clientCtx.Err() == nil {
+				panic(rec)
+			}
+			if stderrors.Is(clientCtx.Err(), context.DeadlineExceeded) {
+				err = ErrRequestTimeout
+				return
+			}
+			clientAbortedTotal.Inc()
+			err = ErrClientAborted
+		}
+	}()
+
+	// 移行先とミラーへのリクエストは、バックエンド向けの認証や署名を加える前のリクエストから作る
+	var shadow *shadowExchange
+	if backend.Shadow != nil && backend.Shadow.applies(req) {
+		shadow = t.startShadow(clientCtx, req, backend)
+	}
+	if backend.Mirror != nil && backend.Mirror.applies(req) {
+		t.startMirror(clientCtx, req, backend)
 	}
 
 	// リクエストURLをバックエンドURLに変更
@@ -65,27 +200,225 @@ func (t *HTTPTransporter) Transport(ctx context.Context, w http.ResponseWriter,
 	}
 	req.Host = backend.URL.Host
 
+	// オブジェクトストレージへはオブジェクトのキーのパスと範囲指定のヘッダーのみ送る
+	if backend.Storage != nil {
+		if err := backend.Storage.prepareRequest(req, originalURL.Path, backend.URL); err != nil {
+			return err
+		}
+	}
+
 	// カスタムヘッダーを追加
 	for key, value := range backend.Headers {
 		req.Header.Set(key, value)
 	}
 
+	// 期限までの残り時間を伝える（署名の対象に含めるため署名より前に設定する）
+	// オブジェクトストレージへは範囲指定以外のヘッダーを送らない
+	if backend.Storage == nil {
+		setRequestTimeout(ctx, req.Header)
+	}
+
+	// SOAPのエンベロープへ変換する（署名はエンベロープに対して行う）
+	if backend.SOAP != nil {
+		if err := backend.SOAP.prepareRequest(req, backend.URL); err != nil {
+			return err
+		}
+	}
+
+	// マスク・検証するレスポンスは展開済みで受け取る（署名の対象のヘッダーが確定する前に行う）
+	if backend.Masker != nil {
+		backend.Masker.prepareRequest(req)
+	}
+	if backend.Validator != nil {
+		backend.Validator.prepareRequest(req)
+	}
+
+	// バックエンド用のアクセストークンでクライアントの Authorization を置き換える
+	if backend.Credentials != nil {
+		token, err := backend.Credentials.Token(ctx)
+		if err != nil {
+			return errors.NewBadGatewayError(fmt.Sprintf("failed to obtain backend access token: %v", err))
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// バックエンドへ送るメソッド・パス・ボディが確定した後に署名する（ボディは一度だけ読み込んでハッシュを共有する）
+	if backend.Signer != nil || backend.AWSSigner != nil {
+		bodyHash, err := hashRequestBody(req, t.Buffer)
+		if err != nil {
+			return err
+		}
+		if backend.Signer != nil {
+			if err := signRequest(req, backend.Signer, bodyHash); err != nil {
+				return err
+			}
+		}
+		if backend.AWSSigner != nil {
+			if err := backend.AWSSigner.Sign(ctx, req, bodyHash, time.Now()); err != nil {
+				return errors.NewInternalServerError(fmt.Sprintf("failed to sign request with AWS SigV4: %v", err))
+			}
+		}
+	}
+
+	// 再試行はリクエストごとのRoundTripperで行い、回数と予算の残りをレスポンスヘッダーで返す
+	roundTripper := t.roundTripper(backend.Protocol, backend.Pool)
+	var retry *retryTransport
+	if backend.Retry != nil && backend.Retry.Attempts > 0 && backend.Retry.Budget != nil {
+		retry = &retryTransport{next: roundTripper, policy: backend.Retry}
+		roundTripper = retry
+	}
+
 	// リバースプロキシで転送
+	// responded はバックエンドがレスポンスを返したか（以降のエラーはゲートウェイでのレスポンスの変換の失敗）
+	aborted, timedOut, responded := false, false, false
 	proxy := &httputil.ReverseProxy{
 		Director: func(r *http.Request) {
 			// Director内では何もしない（事前にreqを設定済み）
 		},
-		ErrorHandler: t.ErrorHandler,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, proxyErr error) {
+			// クライアントが切断済み、または期限を過ぎた場合は応答を書き込まない（期限切れの応答は呼び出し元が返す）
+			if ctxErr := clientCtx.Err(); ctxErr != nil {
+				if stderrors.Is(ctxErr, context.DeadlineExceeded) {
+					timedOut = true
+					t.Outliers.Record(clientCtx, backend.URL, healthcheck.OutcomeTimeout)
+				} else {
+					aborted = true
+				}
+				return
+			}
+			if outcome, ok := backendFailure(ctx, proxyErr); ok && !responded {
+				t.Outliers.Record(clientCtx, backend.URL, outcome)
+			}
+			if shadow != nil {
+				shadow.fail(proxyErr)
+			}
+			if retry != nil {
+				retry.setHeaders(w.Header())
+			}
+			t.errorHandler()(w, r, proxyErr)
+		},
+		Transport:  roundTripper,
+		BufferPool: proxyBuffers,
+	}
+	if retry != nil || backend.MaxResponseBody > 0 || backend.DecompressResponses || backend.Storage != nil || shadow != nil || backend.SOAP != nil || backend.Validator != nil || backend.Masker != nil || t.Outliers != nil {
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			responded = true
+			t.Outliers.Record(clientCtx, backend.URL, healthcheck.OutcomeFromStatus(resp.StatusCode))
+			if shadow != nil {
+				shadow.capture(resp)
+			}
+			if retry != nil {
+				retry.setHeaders(resp.Header)
+			}
+			if backend.Storage != nil {
+				if err := backend.Storage.adapt(resp); err != nil {
+					return err
+				}
+			}
+			// 展開した後のボディに上限を適用する（展開すると大きくなるレスポンスも制限する）
+			if backend.DecompressResponses {
+				if err := decompressResponse(resp); err != nil {
+					return err
+				}
+			}
+			if backend.MaxResponseBody > 0 {
+				if err := limitResponseBody(resp, backend.MaxResponseBody, t.Buffer); err != nil {
+					return err
+				}
+			}
+			if backend.DecompressResponses {
+				if err := normalizeResponse(resp, t.Buffer); err != nil {
+					return err
+				}
+			}
+			// 検証とマスクはJSONへ変換した後のレスポンスに適用する
+			if backend.SOAP != nil {
+				if err := backend.SOAP.adapt(resp); err != nil {
+					return err
+				}
+			}
+			// 契約はバックエンドのレスポンスを検証し、マスクした値は検証しない
+			if backend.Validator != nil {
+				if err := backend.Validator.validate(resp, t.logger()); err != nil {
+					return err
+				}
+			}
+			if backend.Masker != nil {
+				return backend.Masker.mask(resp)
+			}
+			return nil
+		}
 	}
 
 	proxy.ServeHTTP(w, req)
 
+	if timedOut {
+		return ErrRequestTimeout
+	}
+	if aborted {
+		clientAbortedTotal.Inc()
+		return ErrClientAborted
+	}
 	return nil
 }
 
+// errorHandler はプロキシエラー時のハンドラを返す
+func (t *HTTPTransporter) errorHandler() func(w http.ResponseWriter, req *http.Request, err error) {
+	if t.ErrorHandler != nil {
+		return t.ErrorHandler
+	}
+	return defaultErrorHandler
+}
+
+// logger はロガーを返す
+func (t *HTTPTransporter) logger() *slog.Logger {
+	if t.Logger != nil {
+		return t.Logger
+	}
+	return slog.Default()
+}
+
+// roundTripper はバックエンドのプロトコルと接続プールの設定に対応するRoundTripperを返す
+func (t *HTTPTransporter) roundTripper(protocol Protocol, pool ConnectionPool) http.RoundTripper {
+	return t.transports.get(protocol, pool)
+}
+
+// backendFailure はバックエンドへ転送できなかった理由を外れ値検出の結果に変換する
+// バックエンドの Timeout を過ぎた場合はタイムアウトとし、リクエストボディが上限を超えた場合はクライアントの問題なので記録しない（false）
+func backendFailure(ctx context.Context, err error) (healthcheck.Outcome, bool) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case stderrors.As(err, &maxBytesErr):
+		return healthcheck.OutcomeSuccess, false
+	case stderrors.Is(ctx.Err(), context.DeadlineExceeded):
+		return healthcheck.OutcomeTimeout, true
+	default:
+		return healthcheck.OutcomeError, true
+	}
+}
+
+// setRequestTimeout は ctx の期限までの残り時間を HeaderRequestTimeout に設定する
+// 期限はルートの timeout ミドルウェアとバックエンドの timeout のうち早い方で、ミドルウェアなどで経過した時間は差し引かれている
+// クライアントが送った値は信頼せず、期限が無い場合は削除する
+func setRequestTimeout(ctx context.Context, h http.Header) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		h.Del(HeaderRequestTimeout)
+		return
+	}
+	h.Set(HeaderRequestTimeout, strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 0), 10))
+}
+
 // defaultErrorHandler はデフォルトのエラーハンドラ
 func defaultErrorHandler(w http.ResponseWriter, req *http.Request, err error) {
 	gatewayErr := errors.NewBadGatewayError(err.Error())
+
+	// リクエストボディが上限を超えた場合（http.MaxBytesReader）はクライアントの問題なので413を返す
+	var maxBytesErr *http.MaxBytesError
+	if stderrors.As(err, &maxBytesErr) {
+		gatewayErr = errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(gatewayErr.StatusCode())
 	w.Write(errors.ToJSON(gatewayErr))
@@ -2,12 +2,17 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"api-gateway/internal/healthcheck"
 )
 
 func TestNewHTTPTransporter(t *testing.T) {
@@ -164,6 +169,68 @@ func TestHTTPTransporter_Transport_WithCustomHeaders(t *testing.T) {
 	}
 }
 
+func TestHTTPTransporter_Transport_RequestTimeoutHeader(t *testing.T) {
+	tests := []struct {
+		name           string
+		backendTimeout time.Duration
+		ctxTimeout     time.Duration
+		clientValue    string
+		wantMin        int64
+		wantMax        int64
+		wantAbsent     bool
+	}{
+		{name: "バックエンドのタイムアウト", backendTimeout: 30 * time.Second, wantMin: 29000, wantMax: 30000},
+		{name: "ルートの期限の方が早い", backendTimeout: 30 * time.Second, ctxTimeout: 2 * time.Second, wantMin: 1000, wantMax: 2000},
+		{name: "クライアントが送った値は期限で置き換える", backendTimeout: 5 * time.Second, clientValue: "600000", wantMin: 4000, wantMax: 5000},
+		{name: "期限が無い場合はクライアントが送った値を削除する", clientValue: "600000", wantAbsent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Values(HeaderRequestTimeout)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer backendServer.Close()
+
+			backend, err := NewBackend(backendServer.URL, tt.backendTimeout)
+			if err != nil {
+				t.Fatalf("failed to create backend: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.clientValue != "" {
+				req.Header.Set(HeaderRequestTimeout, tt.clientValue)
+			}
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+
+			if err := NewHTTPTransporter().Transport(ctx, httptest.NewRecorder(), req, backend); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantAbsent {
+				if len(got) != 0 {
+					t.Errorf("%s = %v, want absent", HeaderRequestTimeout, got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("%s = %v, want a single value", HeaderRequestTimeout, got)
+			}
+			ms, err := strconv.ParseInt(got[0], 10, 64)
+			if err != nil || ms < tt.wantMin || ms > tt.wantMax {
+				t.Errorf("%s = %s, want between %d and %d", HeaderRequestTimeout, got[0], tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
 func TestHTTPTransporter_Transport_WithTimeout(t *testing.T) {
 	// 遅いバックエンドサーバー
 	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -381,3 +448,142 @@ func TestHTTPTransporter_CustomErrorHandler(t *testing.T) {
 		t.Errorf("unexpected body: %s", string(body))
 	}
 }
+
+func TestHTTPTransporter_Transport_ClientCanceled(t *testing.T) {
+	// クライアントの切断がバックエンドへのリクエストに伝搬することを確認する
+	backendCanceled := make(chan struct{})
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(backendCanceled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer backendServer.Close()
+
+	backend, err := NewBackend(backendServer.URL, 30*time.Second)
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+
+	errorHandlerCalled := false
+	transporter := NewHTTPTransporter()
+	transporter.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		errorHandlerCalled = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	before := clientAbortedTotal.Value()
+
+	err = transporter.Transport(ctx, w, req, backend)
+
+	if !errors.Is(err, ErrClientAborted) {
+		t.Fatalf("expected ErrClientAborted, got %v", err)
+	}
+	select {
+	case <-backendCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("backend request was not canceled")
+	}
+	if errorHandlerCalled {
+		t.Error("error handler should not be called for aborted client")
+	}
+	if got := clientAbortedTotal.Value() - before; got != 1 {
+		t.Errorf("client aborted counter increased by %d, want 1", got)
+	}
+}
+
+// abortingWriter はボディの書き込み時にクライアントの切断を再現するResponseWriter
+type abortingWriter struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (w *abortingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return 0, errors.New("write: broken pipe")
+}
+
+func TestHTTPTransporter_Transport_ClientCanceledDuringBody(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer backendServer.Close()
+
+	backend, err := NewBackend(backendServer.URL, 30*time.Second)
+	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+
+	// http.Server 配下で動いている場合のみ ReverseProxy はコピー失敗時に panic するため、ServerContextKey を設定する
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), http.ServerContextKey, &http.Server{}))
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	w := &abortingWriter{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+	before := clientAbortedTotal.Value()
+
+	err = NewHTTPTransporter().Transport(ctx, w, req, backend)
+
+	if !errors.Is(err, ErrClientAborted) {
+		t.Fatalf("expected ErrClientAborted, got %v", err)
+	}
+	if got := clientAbortedTotal.Value() - before; got != 1 {
+		t.Errorf("client aborted counter increased by %d, want 1", got)
+	}
+}
+
+func TestHTTPTransporter_Transport_OutlierDetection(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer backendServer.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		wantEjected bool
+	}{
+		{name: "5xxが続くと除外する", path: "/error", wantEjected: true},
+		{name: "タイムアウトが続くと除外する", path: "/slow", wantEjected: true},
+		{name: "4xxは除外しない", path: "/not-found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := NewBackend(backendServer.URL, 50*time.Millisecond)
+			if err != nil {
+				t.Fatalf("failed to create backend: %v", err)
+			}
+			transporter := NewHTTPTransporter()
+			transporter.Outliers = healthcheck.NewOutlierDetector(healthcheck.OutlierConfig{
+				MinRequests: 2,
+				Logger:      slog.New(slog.DiscardHandler),
+			})
+
+			for range 2 {
+				req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+				if err := transporter.Transport(context.Background(), httptest.NewRecorder(), req, backend); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if got := transporter.Outliers.IsEjected(context.Background(), backend.URL); got != tt.wantEjected {
+				t.Errorf("IsEjected() = %v, want %v", got, tt.wantEjected)
+			}
+		})
+	}
+}
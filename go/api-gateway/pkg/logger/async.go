@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAsyncQueueSize は非同期ハンドラのキューの長さのデフォルト値
+const DefaultAsyncQueueSize = 4096
+
+// AsyncHandler はログの書き込みをバックグラウンドで行うslog.Handler
+// 書き込みのI/Oが詰まってもリクエストの処理を待たせないよう、キューが一杯の場合は最も古いレコードを捨てる
+// 捨てたレコードの数は Dropped で取得できる。終了時は Close で残りのレコードを書き出す
+type AsyncHandler struct {
+	next  slog.Handler
+	queue *asyncQueue
+}
+
+// asyncQueue は WithAttrs / WithGroup で作成したハンドラ間で共有するキューと書き込み処理
+type asyncQueue struct {
+	records chan asyncRecord
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex // closed と records への送信を保護する
+	closed bool
+	done   chan struct{}
+}
+
+// asyncRecord は書き込み先のハンドラとレコードの組
+type asyncRecord struct {
+	handler slog.Handler
+	record  slog.Record
+}
+
+// NewAsyncHandler はnextへの書き込みをバックグラウンドで行うハンドラを作成する
+// queueSize が0以下の場合は DefaultAsyncQueueSize を使う
+func NewAsyncHandler(next slog.Handler, queueSize int) *AsyncHandler {
+	if queueSize <= 0 {
+		queueSize = DefaultAsyncQueueSize
+	}
+	q := &asyncQueue{
+		records: make(chan asyncRecord, queueSize),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return &AsyncHandler{next: next, queue: q}
+}
+
+// run はキューのレコードを順に書き出す
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for rec := range q.records {
+		// 呼び出し元のコンテキストは既に終了している可能性があるため使わない
+		rec.handler.Handle(context.Background(), rec.record)
+	}
+}
+
+// Enabled はログレベルが有効か確認する
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle はレコードをキューに追加する（書き込みは待たない）
+// Close の後に呼ばれた場合は同期的に書き込む
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	// LogValuer はバックグラウンドで評価すると値が変わっている可能性があるため、ここで評価する
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		a.Value = a.Value.Resolve()
+		out.AddAttrs(a)
+		return true
+	})
+
+	q := h.queue
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return h.next.Handle(ctx, out)
+	}
+
+	rec := asyncRecord{handler: h.next, record: out}
+	for {
+		select {
+		case q.records <- rec:
+			return nil
+		default:
+		}
+		// キューが一杯の場合は最も古いレコードを捨てて空きを作る
+		select {
+		case <-q.records:
+			q.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// WithAttrs は属性を追加したハンドラを返す（キューは共有する）
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{next: h.next.WithAttrs(attrs), queue: h.queue}
+}
+
+// WithGroup はグループを追加したハンドラを返す（キューは共有する）
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{next: h.next.WithGroup(name), queue: h.queue}
+}
+
+// Dropped はキューが一杯で捨てたレコードの数を返す
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.queue.dropped.Load()
+}
+
+// Close はキューに残ったレコードを書き出すか ctx が終了するまで待つ
+// Close の後のログは同期的に書き込む
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	q := h.queue
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.records)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
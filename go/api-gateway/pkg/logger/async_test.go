@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer は並行して書き込めるバッファ
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncHandler_Close_Flushes(t *testing.T) {
+	var out syncBuffer
+	async := NewAsyncHandler(slog.NewJSONHandler(&out, nil), 0)
+	log := slog.New(async).With("component", "gateway")
+
+	for i := range 10 {
+		log.Info("access", "n", i)
+	}
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		// 順序と WithAttrs の属性が保たれる
+		if entry["n"] != float64(i) || entry["component"] != "gateway" {
+			t.Errorf("line %d = %v", i, entry)
+		}
+	}
+
+	// Close の後は同期的に書き込む
+	log.Info("after close")
+	if !strings.Contains(out.String(), "after close") {
+		t.Error("expected log written synchronously after Close")
+	}
+}
+
+// blockingHandler は release が閉じられるまで書き込みを止める
+type blockingHandler struct {
+	slog.Handler
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, r slog.Record) error {
+	<-h.release
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestAsyncHandler_DropOldest(t *testing.T) {
+	var out syncBuffer
+	release := make(chan struct{})
+	async := NewAsyncHandler(&blockingHandler{Handler: slog.NewJSONHandler(&out, nil), release: release}, 2)
+	log := slog.New(async)
+
+	// 書き込みが止まっていてもログの呼び出しは待たされない
+	done := make(chan struct{})
+	go func() {
+		for i := range 20 {
+			log.Info("access", "n", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging blocked while the writer was stalled")
+	}
+
+	close(release)
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if got := uint64(len(lines)) + async.Dropped(); got != 20 {
+		t.Errorf("written %d + dropped %d = %d, want 20", len(lines), async.Dropped(), got)
+	}
+	if async.Dropped() == 0 {
+		t.Error("expected records to be dropped")
+	}
+	// 最も新しいレコードは残る
+	if !strings.Contains(lines[len(lines)-1], `"n":19`) {
+		t.Errorf("last line = %s, want n=19", lines[len(lines)-1])
+	}
+}
+
+func TestAsyncHandler_ResolvesLogValuer(t *testing.T) {
+	var out syncBuffer
+	async := NewAsyncHandler(slog.NewJSONHandler(&out, nil), 0)
+
+	v := &mutableValuer{value: "before"}
+	slog.New(async).Info("access", "v", v)
+	v.set("after")
+
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !strings.Contains(out.String(), `"v":"before"`) {
+		t.Errorf("expected value resolved at log time, got %s", out.String())
+	}
+}
+
+type mutableValuer struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (m *mutableValuer) set(v string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.value = v
+}
+
+func (m *mutableValuer) LogValue() slog.Value {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slog.StringValue(m.value)
+}
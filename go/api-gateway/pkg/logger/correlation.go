@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// サービス間（gateway → logout / REST）でリクエストを突き合わせるための共通規約
+// ヘッダー名とログのフィールド名は全サービスで同じ値を使う
+const (
+	// HeaderRequestID はリクエストIDを伝搬するHTTPヘッダー
+	HeaderRequestID = "X-Request-ID"
+	// HeaderTraceParent はトレースIDを伝搬するW3C Trace ContextのHTTPヘッダー
+	HeaderTraceParent = "traceparent"
+
+	// FieldRequestID はリクエストIDを出力するログのフィールド名
+	FieldRequestID = "request_id"
+	// FieldTraceID はトレースIDを出力するログのフィールド名
+	FieldTraceID = "trace_id"
+)
+
+// Correlation はログに付与する相関ID
+type Correlation struct {
+	RequestID string
+	TraceID   string
+}
+
+// Attrs はログに付与する属性を返す（空の値は出力しない）
+func (c Correlation) Attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, 2)
+	if c.RequestID != "" {
+		attrs = append(attrs, slog.String(FieldRequestID, c.RequestID))
+	}
+	if c.TraceID != "" {
+		attrs = append(attrs, slog.String(FieldTraceID, c.TraceID))
+	}
+	return attrs
+}
+
+type correlationKey struct{}
+
+// WithCorrelation は相関IDをコンテキストに保存する
+func WithCorrelation(ctx context.Context, c Correlation) context.Context {
+	return context.WithValue(ctx, correlationKey{}, c)
+}
+
+// CorrelationFromContext はコンテキストから相関IDを取得する
+func CorrelationFromContext(ctx context.Context) (Correlation, bool) {
+	if ctx == nil {
+		return Correlation{}, false
+	}
+	c, ok := ctx.Value(correlationKey{}).(Correlation)
+	return c, ok
+}
+
+// CorrelationFromRequest は上流（gateway）が付与したヘッダーから相関IDを取得する
+func CorrelationFromRequest(req *http.Request) Correlation {
+	traceID, _ := ParseTraceParent(req.Header.Get(HeaderTraceParent))
+	return Correlation{
+		RequestID: req.Header.Get(HeaderRequestID),
+		TraceID:   traceID,
+	}
+}
+
+// maxRequestIDLength はクライアントから受け入れるリクエストIDの最大長
+const maxRequestIDLength = 128
+
+// ValidRequestID はクライアントが指定したリクエストIDをそのまま引き継げるか確認する
+// ログやヘッダーへの注入を防ぐため、英数字と - _ . : のみを許可する
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTraceParent はtraceparentヘッダー（version-traceid-parentid-flags）からトレースIDを取り出す
+func ParseTraceParent(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+	traceID := strings.ToLower(parts[1])
+	if !isHex(traceID) || traceID == strings.Repeat("0", 32) {
+		return "", false
+	}
+	return traceID, true
+}
+
+// NewTraceParent は新しいトレースを開始するtraceparentヘッダーの値とトレースIDを生成する
+func NewTraceParent() (header string, traceID string) {
+	traceID = randomHex(16)
+	return "00-" + traceID + "-" + randomHex(8) + "-01", traceID
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// correlationHandler はコンテキストの相関IDをログレコードに付与するslog.Handler
+// InfoContext などコンテキスト付きで出力したログに request_id / trace_id が載る
+type correlationHandler struct {
+	slog.Handler
+}
+
+// NewCorrelationHandler はhandlerを相関ID付与でラップする
+func NewCorrelationHandler(handler slog.Handler) slog.Handler {
+	return &correlationHandler{Handler: handler}
+}
+
+// Handle はログレコードに相関IDを付与して出力する
+// 呼び出し側が同じフィールドを明示的に渡している場合はそちらを優先する
+func (h *correlationHandler) Handle(ctx context.Context, r slog.Record) error {
+	c, ok := CorrelationFromContext(ctx)
+	if !ok {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	present := make(map[string]bool, 2)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == FieldRequestID || a.Key == FieldTraceID {
+			present[a.Key] = true
+		}
+		return true
+	})
+	for _, a := range c.Attrs() {
+		if !present[a.Key] {
+			r.AddAttrs(a)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs は属性を追加したハンドラを返す
+func (h *correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &correlationHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup はグループを追加したハンドラを返す
+func (h *correlationHandler) WithGroup(name string) slog.Handler {
+	return &correlationHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "valid",
+			header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:   "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantOK: true,
+		},
+		{
+			name:   "upper case is normalized",
+			header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			want:   "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantOK: true,
+		},
+		{name: "empty", header: ""},
+		{name: "all zero trace id", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "not hex", header: "00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "missing parts", header: "00-4bf92f3577b34da6a3ce929d0e0e4736"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTraceParent(tt.header)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseTraceParent() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewTraceParent(t *testing.T) {
+	header, traceID := NewTraceParent()
+
+	got, ok := ParseTraceParent(header)
+	if !ok {
+		t.Fatalf("NewTraceParent() generated invalid header %q", header)
+	}
+	if got != traceID {
+		t.Errorf("trace id in header = %s, want %s", got, traceID)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "uuid", id: "3f2b8c1e-6a4d-4e2f-9b1a-0c5d7e8f9a0b", want: true},
+		{name: "allowed symbols", id: "svc_a.req:42", want: true},
+		{name: "empty", id: ""},
+		{name: "too long", id: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "space", id: "req 1"},
+		{name: "header injection", id: "req\r\nX-Injected: 1"},
+		{name: "non ascii", id: "リクエスト"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidRequestID(tt.id); got != tt.want {
+				t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelationFromRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "req-123")
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	got := CorrelationFromRequest(req)
+	want := Correlation{RequestID: "req-123", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}
+	if got != want {
+		t.Errorf("CorrelationFromRequest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCorrelationHandler(t *testing.T) {
+	ctx := WithCorrelation(context.Background(), Correlation{RequestID: "req-123", TraceID: "trace-456"})
+
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want map[string]any
+	}{
+		{
+			name: "fields are added from context",
+			log:  func(l *slog.Logger) { l.InfoContext(ctx, "test") },
+			want: map[string]any{FieldRequestID: "req-123", FieldTraceID: "trace-456"},
+		},
+		{
+			name: "explicit field takes precedence",
+			log:  func(l *slog.Logger) { l.InfoContext(ctx, "test", slog.String(FieldRequestID, "explicit")) },
+			want: map[string]any{FieldRequestID: "explicit", FieldTraceID: "trace-456"},
+		},
+		{
+			name: "no context",
+			log:  func(l *slog.Logger) { l.Info("test") },
+			want: map[string]any{FieldRequestID: nil, FieldTraceID: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(NewCorrelationHandler(slog.NewJSONHandler(&buf, nil))))
+
+			if n := strings.Count(buf.String(), FieldRequestID); n > 1 {
+				t.Errorf("request_id written %d times", n)
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse log output: %v", err)
+			}
+			for key, want := range tt.want {
+				if entry[key] != want {
+					t.Errorf("%s = %v, want %v", key, entry[key], want)
+				}
+			}
+		})
+	}
+}
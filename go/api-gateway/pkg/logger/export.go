@@ -0,0 +1,634 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ExportProtocol はログを送信するプロトコル
+type ExportProtocol string
+
+const (
+	// ExportOTLP はOTLP/HTTP（JSONエンコーディング）で送信する。Endpoint は http://otel-collector:4318/v1/logs など
+	ExportOTLP ExportProtocol = "otlp"
+	// ExportLoki はLokiのpush APIで送信する。Endpoint は http://loki:3100/loki/api/v1/push など
+	ExportLoki ExportProtocol = "loki"
+)
+
+const (
+	// DefaultExportBatchSize は1回のリクエストで送信するレコード数のデフォルト値
+	DefaultExportBatchSize = 512
+	// DefaultExportFlushInterval はバッファしたレコードを送信する間隔のデフォルト値
+	DefaultExportFlushInterval = 2 * time.Second
+	// DefaultExportQueueSize は送信を待つレコード数の上限のデフォルト値
+	DefaultExportQueueSize = 8192
+	// DefaultExportTimeout は1回の送信のタイムアウトのデフォルト値
+	DefaultExportTimeout = 10 * time.Second
+
+	// maxExportBackoff は再送を待つ間隔の上限（FlushInterval から失敗するたびに倍にする）
+	maxExportBackoff = time.Minute
+)
+
+// ExportConfig はログの送信先の設定
+type ExportConfig struct {
+	// Protocol は送信するプロトコル（空の場合は ExportOTLP）
+	Protocol ExportProtocol
+	// Endpoint は送信先のURL
+	Endpoint string
+	// Headers は送信するリクエストに付与するヘッダー（認証トークンやLokiのテナントIDなど）
+	Headers map[string]string
+	// ServiceName はOTLPのリソース属性 service.name（Lokiでは service_name ラベル）
+	ServiceName string
+	// Labels はOTLPのリソース属性（Lokiではストリームのラベル）に追加する値
+	Labels map[string]string
+	// BatchSize は1回のリクエストで送信する最大のレコード数（0は DefaultExportBatchSize）
+	BatchSize int
+	// FlushInterval はバッファしたレコードを送信する間隔（0は DefaultExportFlushInterval）
+	FlushInterval time.Duration
+	// QueueSize は送信を待つレコード数の上限（0は DefaultExportQueueSize）。超えた場合は新しいレコードを捨てる
+	QueueSize int
+	// Timeout は1回の送信のタイムアウト（0は DefaultExportTimeout）
+	Timeout time.Duration
+	// Level は送信するログレベル（nilの場合は New で作成したロガーと同じく SetLevel で変更できるレベル）
+	Level slog.Leveler
+	// Client は送信に使うHTTPクライアント（nilの場合は http.DefaultClient）
+	Client *http.Client
+	// ErrorLog はバックグラウンドでの送信の失敗を記録するロガー（nilの場合は標準エラー出力）
+	// 送信するロガー自身に記録すると、送信先の障害時に失敗のログが送信待ちのレコードを押し出すため分ける
+	ErrorLog *slog.Logger
+}
+
+// ExportHandler はログをOTLPコレクターまたはLokiに直接送信するslog.Handler
+// ログ収集のサイドカーやエージェントが無い環境で使う。レコードはバッファし、一定間隔または BatchSize ごとにバックグラウンドで送信する
+// 送信先の障害（5xx・408・429・通信エラー）で失敗したレコードはバッファに残し、間隔を倍にしながら再送する
+// 送信先が受け付けないレコード（それ以外の4xx）は再送しても失敗するため捨てる
+// 捨てたレコードの数は Dropped（バッファが一杯）と Rejected（送信先が拒否）、失敗した送信の数は Failures で取得できる
+// 終了時は Close で残りのレコードを送信する
+type ExportHandler struct {
+	attrs    []slog.Attr // WithAttrs で追加した属性（グループ名で修飾済み）
+	prefix   string      // WithGroup で追加したグループ名（"group." の形式）
+	exporter *logExporter
+}
+
+// logExporter は WithAttrs / WithGroup で作成したハンドラ間で共有するバッファと送信処理
+type logExporter struct {
+	cfg    ExportConfig
+	encode func(records []exportRecord) ([]byte, error)
+
+	mu      sync.Mutex
+	pending []exportRecord
+
+	dropped  atomic.Uint64
+	rejected atomic.Uint64
+	failures atomic.Uint64
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// exportRecord は送信するレコード（属性はグループ名で修飾し、値は文字列・数値・真偽値に変換済み）
+type exportRecord struct {
+	time    time.Time
+	level   slog.Level
+	message string
+	attrs   []slog.Attr
+}
+
+// NewExportHandler は新しいExportHandlerを作成し、バックグラウンドでの送信を開始する
+func NewExportHandler(cfg ExportConfig) (*ExportHandler, error) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = ExportOTLP
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultExportBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultExportFlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultExportQueueSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultExportTimeout
+	}
+	if cfg.Level == nil {
+		cfg.Level = level
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.ErrorLog == nil {
+		cfg.ErrorLog = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+
+	e := &logExporter{
+		cfg:   cfg,
+		flush: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	switch cfg.Protocol {
+	case ExportOTLP:
+		e.encode = e.encodeOTLP
+	case ExportLoki:
+		e.encode = e.encodeLoki
+	default:
+		return nil, fmt.Errorf("invalid log export protocol: %s", cfg.Protocol)
+	}
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("log export endpoint is required")
+	}
+
+	go e.run()
+	return &ExportHandler{exporter: e}, nil
+}
+
+// run は一定間隔、またはレコード数が BatchSize に達した時点で送信する
+// 再送が必要な失敗の後は、送信先の復旧を待つため間隔を倍にしながら maxExportBackoff まで送信を控える
+func (e *logExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var backoff time.Duration
+	var retryAt time.Time
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flush:
+		case <-e.stop:
+			return
+		}
+		if time.Now().Before(retryAt) {
+			continue
+		}
+
+		retry, err := e.export(context.Background())
+		if err != nil {
+			e.cfg.ErrorLog.Error("failed to export logs", slog.String("error", err.Error()), slog.Bool("retry", retry))
+		}
+		if !retry {
+			backoff = 0
+			continue
+		}
+		backoff = min(max(2*backoff, e.cfg.FlushInterval), maxExportBackoff)
+		retryAt = time.Now().Add(backoff)
+	}
+}
+
+// Enabled はログレベルが有効か確認する
+func (h *ExportHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.exporter.cfg.Level.Level()
+}
+
+// Handle はレコードをバッファする（送信は待たない）
+func (h *ExportHandler) Handle(_ context.Context, r slog.Record) error {
+	// LogValuer はバックグラウンドで評価すると値が変わっている可能性があるため、ここで評価する
+	rec := exportRecord{
+		time:    r.Time,
+		level:   r.Level,
+		message: r.Message,
+		attrs:   slices.Clone(h.attrs),
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rec.attrs = appendExportAttr(rec.attrs, h.prefix, a)
+		return true
+	})
+	h.exporter.add(rec)
+	return nil
+}
+
+// WithAttrs は属性を追加したハンドラを返す（バッファは共有する）
+func (h *ExportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := slices.Clone(h.attrs)
+	for _, a := range attrs {
+		next = appendExportAttr(next, h.prefix, a)
+	}
+	return &ExportHandler{attrs: next, prefix: h.prefix, exporter: h.exporter}
+}
+
+// WithGroup はグループを追加したハンドラを返す（バッファは共有する）
+func (h *ExportHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &ExportHandler{attrs: h.attrs, prefix: h.prefix + name + ".", exporter: h.exporter}
+}
+
+// Dropped は送信を待つレコード数が QueueSize を超えて捨てたレコードの数を返す
+func (h *ExportHandler) Dropped() uint64 {
+	return h.exporter.dropped.Load()
+}
+
+// Rejected は送信先が受け付けず（408・429以外の4xx）、再送せずに捨てたレコードの数を返す
+func (h *ExportHandler) Rejected() uint64 {
+	return h.exporter.rejected.Load()
+}
+
+// Failures は失敗した送信の数を返す
+func (h *ExportHandler) Failures() uint64 {
+	return h.exporter.failures.Load()
+}
+
+// Flush はバッファしたレコードを全て送信する（再送の間隔は待たない）
+func (h *ExportHandler) Flush(ctx context.Context) error {
+	_, err := h.exporter.export(ctx)
+	return err
+}
+
+// Close はバックグラウンドでの送信を止め、残りのレコードを送信するか ctx が終了するまで待つ
+// Close の後のログはバッファするだけで送信しない
+func (h *ExportHandler) Close(ctx context.Context) error {
+	close(h.exporter.stop)
+	select {
+	case <-h.exporter.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	_, err := h.exporter.export(ctx)
+	return err
+}
+
+// add はレコードをバッファし、BatchSize に達した場合は送信を通知する
+func (e *logExporter) add(rec exportRecord) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.pending) >= e.cfg.QueueSize {
+		// 送信先の障害時にメモリを使い切らないよう、新しいレコードを捨てる（ログの書き込みのたびにバッファを詰め直さない）
+		e.dropped.Add(1)
+		return
+	}
+	e.pending = append(e.pending, rec)
+
+	if len(e.pending) >= e.cfg.BatchSize {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// export はバッファしたレコードを BatchSize ずつ送信し、再送が必要な失敗で止めた場合は retry に true を返す
+// 送信先が拒否したバッチは捨てて残りの送信を続ける
+// 送信中もログを書き込めるよう、ロックはバッファからの取り出しと戻す間だけ取る
+func (e *logExporter) export(ctx context.Context) (retry bool, err error) {
+	var errs []error
+	for {
+		e.mu.Lock()
+		n := min(len(e.pending), e.cfg.BatchSize)
+		if n == 0 {
+			e.mu.Unlock()
+			return false, errors.Join(errs...)
+		}
+		batch := slices.Clone(e.pending[:n])
+		e.pending = slices.Delete(e.pending, 0, n)
+		e.mu.Unlock()
+
+		retry, err := e.send(ctx, batch)
+		if err == nil {
+			continue
+		}
+		e.failures.Add(1)
+		if retry {
+			e.requeue(batch)
+			return true, errors.Join(append(errs, err)...)
+		}
+		e.rejected.Add(uint64(len(batch)))
+		errs = append(errs, err)
+	}
+}
+
+// requeue は送信に失敗したレコードをバッファの先頭に戻す（QueueSize を超えた分は新しい順に捨てる）
+func (e *logExporter) requeue(batch []exportRecord) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pending = append(batch, e.pending...)
+	if over := len(e.pending) - e.cfg.QueueSize; over > 0 {
+		e.pending = e.pending[:e.cfg.QueueSize]
+		e.dropped.Add(uint64(over))
+	}
+}
+
+// send はレコードをエンコードして送信先にPOSTし、失敗した場合は再送すべきかを返す
+// 送信先の障害（5xx・408・429・通信エラー）のみ再送し、送信先が受け付けない内容（それ以外の4xx・エンコードの失敗）は再送しない
+func (e *logExporter) send(ctx context.Context, batch []exportRecord) (retry bool, err error) {
+	body, err := e.encode(batch)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode logs: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send logs to %s: %w", e.cfg.Endpoint, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected status sending logs to %s: %d", e.cfg.Endpoint, resp.StatusCode)
+	}
+	return false, nil
+}
+
+// appendExportAttr は属性をグループ名で修飾したキーに展開して追加する
+// 送信先で扱えるよう、値は文字列・数値・真偽値に変換する
+func appendExportAttr(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, child := range a.Value.Group() {
+			attrs = appendExportAttr(attrs, groupPrefix, child)
+		}
+		return attrs
+	case slog.KindString, slog.KindInt64, slog.KindFloat64, slog.KindBool:
+	case slog.KindUint64:
+		if v := a.Value.Uint64(); v <= math.MaxInt64 {
+			a.Value = slog.Int64Value(int64(v))
+		} else {
+			a.Value = slog.StringValue(strconv.FormatUint(v, 10))
+		}
+	case slog.KindTime:
+		a.Value = slog.StringValue(a.Value.Time().Format(time.RFC3339Nano))
+	case slog.KindDuration:
+		a.Value = slog.StringValue(a.Value.Duration().String())
+	default:
+		a.Value = slog.StringValue(exportString(a.Value.Any()))
+	}
+	return append(attrs, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+}
+
+// exportString は任意の値を文字列に変換する（スライスや構造体はJSONにする）
+func exportString(v any) string {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// OTLP/HTTP のJSONエンコーディングのリクエスト（opentelemetry-proto の ExportLogsServiceRequest）
+// 64ビット整数はproto3のJSONマッピングに従い文字列で送る
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// otlpScopeName はOTLPの計装スコープ名
+const otlpScopeName = "api-gateway/pkg/logger"
+
+// encodeOTLP はレコードをOTLP/HTTPのJSONエンコーディングのリクエストにする
+func (e *logExporter) encodeOTLP(records []exportRecord) ([]byte, error) {
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	logRecords := make([]otlpLogRecord, len(records))
+	for i, rec := range records {
+		out := otlpLogRecord{
+			TimeUnixNano:         strconv.FormatInt(rec.time.UnixNano(), 10),
+			ObservedTimeUnixNano: observed,
+			SeverityNumber:       otlpSeverity(rec.level),
+			SeverityText:         rec.level.String(),
+			Body:                 otlpValue(slog.StringValue(rec.message)),
+			Attributes:           make([]otlpKeyValue, 0, len(rec.attrs)),
+		}
+		for _, a := range rec.attrs {
+			out.Attributes = append(out.Attributes, otlpKeyValue{Key: a.Key, Value: otlpValue(a.Value)})
+			// CorrelationHandler が付与したトレースIDはトレースと突き合わせられるよう traceId にも設定する
+			if a.Key == FieldTraceID && validTraceID(a.Value.String()) {
+				out.TraceID = a.Value.String()
+			}
+		}
+		logRecords[i] = out
+	}
+
+	resource := []otlpKeyValue{{Key: "service.name", Value: otlpValue(slog.StringValue(e.cfg.ServiceName))}}
+	for _, key := range slices.Sorted(maps.Keys(e.cfg.Labels)) {
+		resource = append(resource, otlpKeyValue{Key: key, Value: otlpValue(slog.StringValue(e.cfg.Labels[key]))})
+	}
+
+	return json.Marshal(otlpLogsRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: resource},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: otlpScopeName}, LogRecords: logRecords}},
+	}}})
+}
+
+// otlpSeverity はslogのレベルをOTLPのSeverityNumberに変換する（DEBUG=5, INFO=9, WARN=13, ERROR=17）
+func otlpSeverity(l slog.Level) int {
+	return min(max(int(l)+9, 1), 24)
+}
+
+// otlpValue は変換済みの属性の値をOTLPのAnyValueにする
+func otlpValue(v slog.Value) otlpAnyValue {
+	switch v.Kind() {
+	case slog.KindInt64:
+		s := strconv.FormatInt(v.Int64(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case slog.KindFloat64:
+		f := v.Float64()
+		return otlpAnyValue{DoubleValue: &f}
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	default:
+		s := v.String()
+		return otlpAnyValue{StringValue: &s}
+	}
+}
+
+// validTraceID はW3C Trace Contextのトレースフィールドの形式（32桁の16進数）か確認する
+func validTraceID(id string) bool {
+	_, err := hex.DecodeString(id)
+	return len(id) == 32 && err == nil
+}
+
+// Loki push API のリクエスト
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// encodeLoki はレコードをLoki push APIのリクエストにする
+// ラベルのカーディナリティを抑えるため、ストリームはレベルごとに分け、属性はJSONの行に含める
+func (e *logExporter) encodeLoki(records []exportRecord) ([]byte, error) {
+	var streams []lokiStream
+	byLevel := make(map[slog.Level]int)
+	for _, rec := range records {
+		line, err := lokiLine(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := byLevel[rec.level]
+		if !ok {
+			labels := maps.Clone(e.cfg.Labels)
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels["service_name"] = e.cfg.ServiceName
+			labels["level"] = strings.ToLower(rec.level.String())
+			i = len(streams)
+			byLevel[rec.level] = i
+			streams = append(streams, lokiStream{Stream: labels})
+		}
+		streams[i].Values = append(streams[i].Values, [2]string{strconv.FormatInt(rec.time.UnixNano(), 10), line})
+	}
+	return json.Marshal(lokiPushRequest{Streams: streams})
+}
+
+// lokiLine はメッセージと属性を属性の順に並べたJSONの1行にする
+func lokiLine(rec exportRecord) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"msg":`)
+	msg, err := json.Marshal(rec.message)
+	if err != nil {
+		return "", err
+	}
+	buf.Write(msg)
+	for _, a := range rec.attrs {
+		key, err := json.Marshal(a.Key)
+		if err != nil {
+			return "", err
+		}
+		value, err := json.Marshal(a.Value.Any())
+		if err != nil {
+			// NaN などJSONで表せない値は文字列にする
+			value, _ = json.Marshal(a.Value.String())
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+// teeHandler はレコードを複数のハンドラに書き込むslog.Handler
+// 標準出力に加えて ExportHandler で送信するために使う
+type teeHandler []slog.Handler
+
+// Enabled はいずれかのハンドラでログレベルが有効か確認する
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle はログレベルが有効な全てのハンドラに書き込む
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs は全てのハンドラに属性を追加したハンドラを返す
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(teeHandler, len(t))
+	for i, h := range t {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return next
+}
+
+// WithGroup は全てのハンドラにグループを追加したハンドラを返す
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	next := make(teeHandler, len(t))
+	for i, h := range t {
+		next[i] = h.WithGroup(name)
+	}
+	return next
+}
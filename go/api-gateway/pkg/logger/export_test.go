@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// exportServer は受け取ったリクエストの本文を記録するテスト用の送信先
+type exportServer struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	header http.Header
+	status int
+}
+
+func (s *exportServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	s.bodies = append(s.bodies, body)
+	s.header = req.Header.Clone()
+}
+
+func newTestExportHandler(t *testing.T, protocol ExportProtocol) (*ExportHandler, *exportServer) {
+	t.Helper()
+	srv := &exportServer{}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	h, err := NewExportHandler(ExportConfig{
+		Protocol:      protocol,
+		Endpoint:      ts.URL,
+		Headers:       map[string]string{"X-Scope-OrgID": "tenant-1"},
+		ServiceName:   "api-gateway",
+		Labels:        map[string]string{"env": "test"},
+		FlushInterval: time.Hour,
+		Level:         slog.LevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("NewExportHandler() error = %v", err)
+	}
+	return h, srv
+}
+
+func TestExportHandler_OTLP(t *testing.T) {
+	h, srv := newTestExportHandler(t, ExportOTLP)
+	log := slog.New(h).With("component", "gateway", FieldTraceID, "4bf92f3577b34da6a3ce929d0e0e4736").WithGroup(HTTPGroup)
+
+	log.Info("access", "status_code", 200)
+	log.Debug("not exported")
+	log.Error("backend unavailable", slog.Group("backend", "timeout", time.Second), "error", errors.New("connection refused"))
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(srv.bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(srv.bodies))
+	}
+	if got := srv.header.Get("X-Scope-OrgID"); got != "tenant-1" {
+		t.Errorf("X-Scope-OrgID = %q", got)
+	}
+
+	var req otlpLogsRequest
+	if err := json.Unmarshal(srv.bodies[0], &req); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	resource := req.ResourceLogs[0].Resource.Attributes
+	if len(resource) != 2 || resource[0].Key != "service.name" || *resource[0].Value.StringValue != "api-gateway" || resource[1].Key != "env" {
+		t.Errorf("resource attributes = %+v", resource)
+	}
+
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	access := records[0]
+	if *access.Body.StringValue != "access" || access.SeverityNumber != 9 || access.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("access record = %+v", access)
+	}
+	// WithAttrs の属性はそのまま、WithGroup 以降の属性はグループ名で修飾する
+	wantKeys := []string{"component", FieldTraceID, "http.status_code"}
+	for i, key := range wantKeys {
+		if access.Attributes[i].Key != key {
+			t.Errorf("attribute %d = %s, want %s", i, access.Attributes[i].Key, key)
+		}
+	}
+	if *access.Attributes[2].Value.IntValue != "200" {
+		t.Errorf("status_code = %+v", access.Attributes[2].Value)
+	}
+
+	failed := records[1]
+	if failed.SeverityNumber != 17 || failed.SeverityText != "ERROR" {
+		t.Errorf("error record = %+v", failed)
+	}
+	attrs := map[string]string{}
+	for _, a := range failed.Attributes {
+		if a.Value.StringValue != nil {
+			attrs[a.Key] = *a.Value.StringValue
+		}
+	}
+	if attrs["http.backend.timeout"] != "1s" || attrs["http.error"] != "connection refused" {
+		t.Errorf("error attributes = %v", attrs)
+	}
+}
+
+func TestExportHandler_Loki(t *testing.T) {
+	h, srv := newTestExportHandler(t, ExportLoki)
+	log := slog.New(h)
+
+	log.Info("access", "status_code", 200)
+	log.Warn("slow request", "duration", 3*time.Second)
+	log.Info("access", "status_code", 404)
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var req lokiPushRequest
+	if err := json.Unmarshal(srv.bodies[0], &req); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	// ストリームはレベルごとに分ける
+	if len(req.Streams) != 2 {
+		t.Fatalf("got %d streams, want 2", len(req.Streams))
+	}
+	info := req.Streams[0]
+	if info.Stream["level"] != "info" || info.Stream["service_name"] != "api-gateway" || info.Stream["env"] != "test" {
+		t.Errorf("stream labels = %v", info.Stream)
+	}
+	if len(info.Values) != 2 || info.Values[1][1] != `{"msg":"access","status_code":404}` {
+		t.Errorf("info values = %v", info.Values)
+	}
+	if warn := req.Streams[1]; warn.Stream["level"] != "warn" || warn.Values[0][1] != `{"msg":"slow request","duration":"3s"}` {
+		t.Errorf("warn stream = %+v", warn)
+	}
+}
+
+func TestExportHandler_Flush_Retry(t *testing.T) {
+	srv := &exportServer{status: http.StatusServiceUnavailable}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	h, err := NewExportHandler(ExportConfig{
+		Endpoint:      ts.URL,
+		FlushInterval: time.Hour,
+		QueueSize:     3,
+		Level:         slog.LevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("NewExportHandler() error = %v", err)
+	}
+	log := slog.New(h)
+
+	for range 2 {
+		log.Info("access")
+	}
+	if err := h.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() error = nil, want error")
+	}
+	// 送信に失敗したレコードは残し、QueueSize を超えた分は新しいレコードを捨てる
+	for range 2 {
+		log.Info("access")
+	}
+	if h.Failures() != 1 || h.Dropped() != 1 {
+		t.Errorf("Failures() = %d, Dropped() = %d", h.Failures(), h.Dropped())
+	}
+
+	srv.mu.Lock()
+	srv.status = 0
+	srv.mu.Unlock()
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var req otlpLogsRequest
+	if err := json.Unmarshal(srv.bodies[0], &req); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	if got := len(req.ResourceLogs[0].ScopeLogs[0].LogRecords); got != 3 {
+		t.Errorf("got %d records, want 3", got)
+	}
+}
+
+func TestExportHandler_Flush_Status(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantPending  int
+		wantRejected uint64
+	}{
+		{name: "5xxは再送する", status: http.StatusBadGateway, wantPending: 2},
+		{name: "429は再送する", status: http.StatusTooManyRequests, wantPending: 2},
+		{name: "408は再送する", status: http.StatusRequestTimeout, wantPending: 2},
+		{name: "400は捨てる", status: http.StatusBadRequest, wantRejected: 2},
+		{name: "413は捨てる", status: http.StatusRequestEntityTooLarge, wantRejected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &exportServer{status: tt.status}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			h, err := NewExportHandler(ExportConfig{Endpoint: ts.URL, FlushInterval: time.Hour, Level: slog.LevelInfo})
+			if err != nil {
+				t.Fatalf("NewExportHandler() error = %v", err)
+			}
+			log := slog.New(h)
+
+			for range 2 {
+				log.Info("access")
+			}
+			if err := h.Flush(context.Background()); err == nil {
+				t.Fatal("Flush() error = nil, want error")
+			}
+			h.exporter.mu.Lock()
+			pending := len(h.exporter.pending)
+			h.exporter.mu.Unlock()
+			if pending != tt.wantPending || h.Rejected() != tt.wantRejected || h.Failures() != 1 {
+				t.Errorf("pending = %d, Rejected() = %d, Failures() = %d", pending, h.Rejected(), h.Failures())
+			}
+
+			// 捨てた後のレコードは送信する
+			srv.mu.Lock()
+			srv.status = 0
+			srv.mu.Unlock()
+			log.Info("access")
+			if err := h.Close(context.Background()); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			var req otlpLogsRequest
+			if err := json.Unmarshal(srv.bodies[0], &req); err != nil {
+				t.Fatalf("invalid request: %v", err)
+			}
+			if got := len(req.ResourceLogs[0].ScopeLogs[0].LogRecords); got != tt.wantPending+1 {
+				t.Errorf("got %d records, want %d", got, tt.wantPending+1)
+			}
+		})
+	}
+}
+
+func TestNewExportHandler_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ExportConfig
+	}{
+		{name: "不明なプロトコル", cfg: ExportConfig{Protocol: "syslog", Endpoint: "http://localhost:4318/v1/logs"}},
+		{name: "送信先が無い", cfg: ExportConfig{Protocol: ExportLoki}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewExportHandler(tt.cfg); err == nil {
+				t.Error("NewExportHandler() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNew_Export(t *testing.T) {
+	srv := &exportServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	export, err := NewExportHandler(ExportConfig{Endpoint: ts.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewExportHandler() error = %v", err)
+	}
+	log := New(Config{Level: LevelWarn, Format: "json", Export: export})
+	defer SetLevel(LevelInfo)
+
+	// 標準出力と同じく SetLevel で変更したレベルに従う
+	log.Info("not exported")
+	SetLevel(LevelInfo)
+	log.Info("exported")
+	if err := export.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var req otlpLogsRequest
+	if err := json.Unmarshal(srv.bodies[0], &req); err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 1 || *records[0].Body.StringValue != "exported" {
+		t.Errorf("records = %+v", records)
+	}
+}
@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// HTTPGroup はリクエスト情報をまとめるログのグループ名
+	HTTPGroup = "http"
+
+	// DefaultStackFrames はスタックトレースを出力する既定のフレーム数
+	DefaultStackFrames = 5
+)
+
+// HTTPRequest はログの http グループに出力するリクエスト情報
+type HTTPRequest struct {
+	Method     string
+	Path       string
+	Query      string
+	RemoteAddr string
+	// ClientIP は X-Forwarded-For から求めたクライアントのIPアドレス（求めていない場合は空）
+	ClientIP  string
+	UserAgent string
+}
+
+// NewHTTPRequest はリクエストからログに出力する情報を取り出す
+func NewHTTPRequest(req *http.Request) HTTPRequest {
+	return HTTPRequest{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.RawQuery,
+		RemoteAddr: req.RemoteAddr,
+		UserAgent:  req.UserAgent(),
+	}
+}
+
+// Attr は http グループの属性を返す（空の値は出力しない）
+func (r HTTPRequest) Attr() slog.Attr {
+	attrs := make([]any, 0, 6)
+	for _, a := range []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.Path),
+		slog.String("query", r.Query),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("client_ip", r.ClientIP),
+		slog.String("user_agent", r.UserAgent),
+	} {
+		if a.Value.String() != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	return slog.Group(HTTPGroup, attrs...)
+}
+
+type httpRequestKey struct{}
+
+// WithHTTPRequest はリクエスト情報をコンテキストに保存する
+func WithHTTPRequest(ctx context.Context, req *http.Request) context.Context {
+	return WithHTTPRequestInfo(ctx, NewHTTPRequest(req))
+}
+
+// WithHTTPRequestInfo は作成済みのリクエスト情報をコンテキストに保存する
+func WithHTTPRequestInfo(ctx context.Context, info HTTPRequest) context.Context {
+	return context.WithValue(ctx, httpRequestKey{}, info)
+}
+
+// HTTPRequestFromContext はコンテキストからリクエスト情報を取得する
+func HTTPRequestFromContext(ctx context.Context) (HTTPRequest, bool) {
+	if ctx == nil {
+		return HTTPRequest{}, false
+	}
+	r, ok := ctx.Value(httpRequestKey{}).(HTTPRequest)
+	return r, ok
+}
+
+// Stack はログに出力するスタックトレース（runtime.Callers のプログラムカウンタ）
+// Handler を通すと先頭の数フレームだけを "関数 (ファイル:行)" 形式で出力する
+type Stack []uintptr
+
+// CallerStack は呼び出し元のスタックトレースを取得する
+// skip は CallerStack の呼び出し元から数えて読み飛ばすフレーム数
+func CallerStack(skip int) Stack {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	return Stack(pcs[:n])
+}
+
+// LogValue は Handler を通さずに出力する場合も DefaultStackFrames フレームまで整形する
+func (s Stack) LogValue() slog.Value {
+	return slog.AnyValue(s.frames(DefaultStackFrames))
+}
+
+// stackFrames は整形済みのスタックトレース
+// JSONでは配列、prettyフォーマットでは1フレーム1行で出力する
+type stackFrames []string
+
+// frames はスタックトレースを先頭から最大 limit フレームまで整形する
+// panic処理などのランタイム内部のフレームは読み飛ばす
+func (s Stack) frames(limit int) stackFrames {
+	frames := make(stackFrames, 0, limit)
+	iter := runtime.CallersFrames(s)
+	for len(frames) < limit {
+		frame, more := iter.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			frames = append(frames, fmt.Sprintf("%s (%s:%d)", frame.Function, shortPath(frame.File), frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// shortPath はファイルパスを親ディレクトリ/ファイル名に短縮する
+func shortPath(path string) string {
+	dir, file := filepath.Split(path)
+	return filepath.Join(filepath.Base(dir), file)
+}
+
+// HandlerOptions はHandlerの設定
+type HandlerOptions struct {
+	// StackFrames はスタックトレースを出力するフレーム数（0は DefaultStackFrames）
+	StackFrames int
+}
+
+// Handler はログレコードを整形するslog.Handler
+//   - コンテキストのリクエスト情報を http グループにまとめて付与する
+//   - Stack の値を先頭の数フレームに切り詰めて出力する
+//   - 値を伏せるヘッダーの値を Redacted に置き換える
+type Handler struct {
+	next    slog.Handler
+	opts    HandlerOptions
+	hasHTTP bool // WithAttrs で http グループが付与済みか
+}
+
+// NewHandler はnextをラップしたHandlerを作成する
+func NewHandler(next slog.Handler, opts HandlerOptions) *Handler {
+	if opts.StackFrames <= 0 {
+		opts.StackFrames = DefaultStackFrames
+	}
+	return &Handler{next: next, opts: opts}
+}
+
+// Enabled はログレベルが有効か確認する
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle はログレコードを整形して出力する
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	hasHTTP := h.hasHTTP
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == HTTPGroup {
+			hasHTTP = true
+		}
+		out.AddAttrs(h.format(a))
+		return true
+	})
+
+	// 呼び出し側が http グループを明示的に渡している場合はそちらを優先する
+	if req, ok := HTTPRequestFromContext(ctx); ok && !hasHTTP {
+		out.AddAttrs(req.Attr())
+	}
+
+	return h.next.Handle(ctx, out)
+}
+
+// WithAttrs は属性を追加したハンドラを返す
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hasHTTP := h.hasHTTP
+	formatted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if a.Key == HTTPGroup {
+			hasHTTP = true
+		}
+		formatted[i] = h.format(a)
+	}
+	return &Handler{next: h.next.WithAttrs(formatted), opts: h.opts, hasHTTP: hasHTTP}
+}
+
+// WithGroup はグループを追加したハンドラを返す
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), opts: h.opts, hasHTTP: h.hasHTTP}
+}
+
+// format は属性の値を出力用に整形する
+// 値を伏せるヘッダー（SetSensitiveHeaders）は http.Header の値と、ヘッダー名と同じ名前の属性のどちらでも伏せる
+func (h *Handler) format(a slog.Attr) slog.Attr {
+	switch v := a.Value.Any().(type) {
+	case Stack:
+		return slog.Any(a.Key, v.frames(h.opts.StackFrames))
+	case http.Header:
+		return slog.Any(a.Key, ScrubHeaders(v))
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		formatted := make([]any, len(attrs))
+		for i, attr := range attrs {
+			formatted[i] = h.format(attr)
+		}
+		return slog.Group(a.Key, formatted...)
+	}
+	if IsSensitiveHeader(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_HTTPGroup(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/users?page=1", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	ctx := WithHTTPRequest(context.Background(), req)
+
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want map[string]any
+	}{
+		{
+			name: "request attributes are grouped from context",
+			log:  func(l *slog.Logger) { l.InfoContext(ctx, "test") },
+			want: map[string]any{
+				"method":      "GET",
+				"path":        "/api/v1/users",
+				"query":       "page=1",
+				"remote_addr": "192.0.2.1:1234",
+				"user_agent":  "test-agent",
+			},
+		},
+		{
+			name: "explicit group takes precedence",
+			log:  func(l *slog.Logger) { l.InfoContext(ctx, "test", slog.Group(HTTPGroup, "status_code", 200)) },
+			want: map[string]any{"status_code": float64(200)},
+		},
+		{
+			name: "group added by With takes precedence",
+			log:  func(l *slog.Logger) { l.With(slog.Group(HTTPGroup, "path", "/with")).InfoContext(ctx, "test") },
+			want: map[string]any{"path": "/with"},
+		},
+		{
+			name: "no context",
+			log:  func(l *slog.Logger) { l.Info("test") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(NewHandler(slog.NewJSONHandler(&buf, nil), HandlerOptions{})))
+
+			if n := strings.Count(buf.String(), `"`+HTTPGroup+`"`); n > 1 {
+				t.Errorf("http group written %d times: %s", n, buf.String())
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse log output: %v", err)
+			}
+			group, _ := entry[HTTPGroup].(map[string]any)
+			if tt.want == nil {
+				if group != nil {
+					t.Errorf("http group = %v, want none", group)
+				}
+				return
+			}
+			if len(group) != len(tt.want) {
+				t.Errorf("http group = %v, want %v", group, tt.want)
+			}
+			for key, want := range tt.want {
+				if group[key] != want {
+					t.Errorf("http.%s = %v, want %v", key, group[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_Stack(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   func(buf *bytes.Buffer) slog.Handler
+		maxFrames int
+	}{
+		{
+			name: "stack is limited to configured frames",
+			handler: func(buf *bytes.Buffer) slog.Handler {
+				return NewHandler(slog.NewJSONHandler(buf, nil), HandlerOptions{StackFrames: 2})
+			},
+			maxFrames: 2,
+		},
+		{
+			name: "stack is readable without handler",
+			handler: func(buf *bytes.Buffer) slog.Handler {
+				return slog.NewJSONHandler(buf, nil)
+			},
+			maxFrames: DefaultStackFrames,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(tt.handler(&buf)).Info("test", "stack", CallerStack(0))
+
+			var entry struct {
+				Stack []string `json:"stack"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse log output: %v", err)
+			}
+			if len(entry.Stack) == 0 || len(entry.Stack) > tt.maxFrames {
+				t.Fatalf("stack has %d frames, want 1 to %d: %v", len(entry.Stack), tt.maxFrames, entry.Stack)
+			}
+			// 先頭フレームは CallerStack の呼び出し元
+			if !strings.Contains(entry.Stack[0], "TestHandler_Stack") || !strings.Contains(entry.Stack[0], "logger/handler_test.go:") {
+				t.Errorf("first frame = %s, want caller of CallerStack", entry.Stack[0])
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 )
@@ -18,25 +19,90 @@ const (
 // Config はロガーの設定
 type Config struct {
 	Level  LogLevel
-	Format string // "json" or "text"
+	Format string // "json", "text" or "pretty"
+	// Export は標準出力に加えてログを書き込むハンドラ（ExportHandler など。nilの場合は標準出力のみ）
+	Export slog.Handler
 }
 
+// level は New で作成したロガーが共有するログレベル
+// 実行中に SetLevel で変更すると、作成済みのロガーにも即座に反映される
+var level = new(slog.LevelVar)
+
 // New は新しいロガーを作成する
 func New(cfg Config) *slog.Logger {
-	level := parseLevel(cfg.Level)
+	return slog.New(NewCorrelationHandler(NewHandler(newOutputHandler(cfg), HandlerOptions{})))
+}
+
+// NewAsync は出力をバックグラウンドで行うロガーを作成する
+// アクセスログのように件数の多いログの書き込みがリクエストの処理時間に影響しないようにする
+// 返した AsyncHandler は終了時に Close して残りのログを書き出す
+func NewAsync(cfg Config, queueSize int) (*slog.Logger, *AsyncHandler) {
+	async := NewAsyncHandler(newOutputHandler(cfg), queueSize)
+	return slog.New(NewCorrelationHandler(NewHandler(async, HandlerOptions{}))), async
+}
+
+// newOutputHandler はフォーマットに応じた出力先のハンドラを作成する
+func newOutputHandler(cfg Config) slog.Handler {
+	level.Set(parseLevel(cfg.Level))
 
-	var handler slog.Handler
 	opts := &slog.HandlerOptions{
 		Level: level,
 	}
 
-	if cfg.Format == "json" {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
-	} else {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+	var out slog.Handler
+	switch cfg.Format {
+	case "json":
+		out = slog.NewJSONHandler(os.Stdout, opts)
+	case "pretty":
+		// NO_COLOR（https://no-color.org/）が設定されている場合は色付けしない
+		out = NewPrettyHandler(os.Stdout, opts, os.Getenv("NO_COLOR") == "")
+	default:
+		out = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	return slog.New(handler)
+	if cfg.Export != nil {
+		return teeHandler{out, cfg.Export}
+	}
+	return out
+}
+
+// SetLevel は実行中のログレベルを変更する
+// 障害調査時などに再起動せずdebugログを有効にするために使う
+func SetLevel(l LogLevel) error {
+	switch l {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		level.Set(parseLevel(l))
+		return nil
+	default:
+		return fmt.Errorf("invalid log level: %s", l)
+	}
+}
+
+// ToggleDebug はログレベルをdebugに切り替え、既にdebugの場合は base に戻して、変更後のレベルを返す
+// 障害調査時にシグナル（SIGUSR1）で一時的にdebugログを有効にするために使う
+func ToggleDebug(base LogLevel) (LogLevel, error) {
+	next := LevelDebug
+	if GetLevel() == LevelDebug {
+		next = base
+	}
+	if err := SetLevel(next); err != nil {
+		return GetLevel(), err
+	}
+	return next, nil
+}
+
+// GetLevel は現在のログレベルを返す
+func GetLevel() LogLevel {
+	switch level.Level() {
+	case slog.LevelDebug:
+		return LevelDebug
+	case slog.LevelWarn:
+		return LevelWarn
+	case slog.LevelError:
+		return LevelError
+	default:
+		return LevelInfo
+	}
 }
 
 func parseLevel(level LogLevel) slog.Level {
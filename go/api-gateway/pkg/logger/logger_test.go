@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"strings"
@@ -295,3 +296,58 @@ func TestLogLevelFiltering(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	logger := New(Config{Level: LevelInfo, Format: "json"})
+	defer SetLevel(LevelInfo)
+
+	ctx := context.Background()
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("debug should be disabled at info level")
+	}
+
+	// 作成済みのロガーにも変更が反映されること
+	if err := SetLevel(LevelDebug); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		t.Error("debug should be enabled after SetLevel(debug)")
+	}
+	if got := GetLevel(); got != LevelDebug {
+		t.Errorf("GetLevel() = %s, want %s", got, LevelDebug)
+	}
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("SetLevel() with invalid level should return error")
+	}
+	if got := GetLevel(); got != LevelDebug {
+		t.Errorf("invalid level should not change level, GetLevel() = %s", got)
+	}
+}
+
+func TestToggleDebug(t *testing.T) {
+	logger := New(Config{Level: LevelWarn, Format: "json"})
+	defer SetLevel(LevelInfo)
+
+	if got, err := ToggleDebug(LevelWarn); err != nil || got != LevelDebug {
+		t.Errorf("ToggleDebug() = %s, %v, want %s", got, err, LevelDebug)
+	}
+	// 作成済みのロガーにも反映されること
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug should be enabled after toggle")
+	}
+
+	if got, err := ToggleDebug(LevelWarn); err != nil || got != LevelWarn {
+		t.Errorf("ToggleDebug() = %s, %v, want %s", got, err, LevelWarn)
+	}
+	if logger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info should be disabled after restoring warn level")
+	}
+
+	if _, err := ToggleDebug("verbose"); err != nil {
+		t.Errorf("ToggleDebug() to debug error = %v", err)
+	}
+	if _, err := ToggleDebug("verbose"); err == nil {
+		t.Error("ToggleDebug() with invalid base should return error")
+	}
+}
@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ANSIエスケープシーケンス
+const (
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+)
+
+// prettyHandler は開発向けに人が読みやすい形式でログを出力するslog.Handler
+//
+//	15:04:05.000 INFO  incoming request http.method=GET http.path=/api/v1/users
+//
+// スタックトレースは1フレーム1行でレコードの後に出力する
+type prettyHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	color  bool
+	attrs  []prettyAttr // WithAttrs で追加された属性
+	prefix string       // WithGroup で追加されたグループ名（"a.b." 形式）
+}
+
+// prettyAttr はグループ名を展開済みの属性
+type prettyAttr struct {
+	key   string
+	value slog.Value
+}
+
+// NewPrettyHandler は開発向けのフォーマットで出力するハンドラを作成する
+// color が true の場合はレベルやキーを色付けする
+func NewPrettyHandler(w io.Writer, opts *slog.HandlerOptions, color bool) slog.Handler {
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+	return &prettyHandler{mu: &sync.Mutex{}, w: w, level: level, color: color}
+}
+
+// Enabled はログレベルが有効か確認する
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle はログレコードを1行（スタックトレースがあれば複数行）で出力する
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]prettyAttr(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendPrettyAttr(attrs, h.prefix, a)
+		return true
+	})
+
+	var buf bytes.Buffer
+	if !r.Time.IsZero() {
+		h.paint(&buf, colorDim, r.Time.Format("15:04:05.000"))
+		buf.WriteByte(' ')
+	}
+	h.paint(&buf, levelColor(r.Level), padLevel(r.Level))
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+
+	var stacks []prettyAttr
+	for _, a := range attrs {
+		if _, ok := a.value.Any().(stackFrames); ok {
+			stacks = append(stacks, a)
+			continue
+		}
+		buf.WriteByte(' ')
+		h.paint(&buf, colorCyan, a.key+"=")
+		buf.WriteString(quoteIfNeeded(a.value.String()))
+	}
+	buf.WriteByte('\n')
+
+	for _, a := range stacks {
+		buf.WriteString("    ")
+		h.paint(&buf, colorCyan, a.key+":")
+		buf.WriteByte('\n')
+		for _, frame := range a.value.Any().(stackFrames) {
+			buf.WriteString("      ")
+			h.paint(&buf, colorDim, frame)
+			buf.WriteByte('\n')
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// WithAttrs は属性を追加したハンドラを返す
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]prettyAttr(nil), h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = appendPrettyAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+// WithGroup はグループを追加したハンドラを返す
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendPrettyAttr はグループを "group.key" 形式に展開して属性を追加する
+func appendPrettyAttr(attrs []prettyAttr, prefix string, a slog.Attr) []prettyAttr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendPrettyAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	return append(attrs, prettyAttr{key: prefix + a.Key, value: a.Value})
+}
+
+// paint は色付けが有効な場合にエスケープシーケンスで囲んで書き込む
+func (h *prettyHandler) paint(buf *bytes.Buffer, color, s string) {
+	if !h.color {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return colorRed
+	case level >= slog.LevelWarn:
+		return colorYellow
+	case level >= slog.LevelInfo:
+		return colorGreen
+	default:
+		return colorBlue
+	}
+}
+
+// padLevel はレベル表記の幅を揃える
+func padLevel(level slog.Level) string {
+	s := level.String()
+	if len(s) < 5 {
+		s += strings.Repeat(" ", 5-len(s))
+	}
+	return s
+}
+
+// quoteIfNeeded は空白などを含む値を引用符で囲む
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrettyHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewPrettyHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}, false))
+
+	logger.With("service", "gateway").WithGroup("req").Debug("incoming request",
+		slog.Group(HTTPGroup, "method", "GET", "path", "/api/v1/users"),
+		"error", errors.New("connection refused"),
+		"empty", "",
+	)
+
+	got := buf.String()
+	want := "DEBUG incoming request service=gateway req.http.method=GET req.http.path=/api/v1/users req.error=\"connection refused\" req.empty=\"\"\n"
+	// 先頭の時刻（15:04:05.000 ）を除いて比較する
+	if len(got) < 13 || got[13:] != want {
+		t.Errorf("output = %q, want time + %q", got, want)
+	}
+	if _, err := time.Parse("15:04:05.000", got[:12]); err != nil {
+		t.Errorf("output does not start with time: %q", got)
+	}
+}
+
+func TestPrettyHandler_Stack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(NewPrettyHandler(&buf, nil, false), HandlerOptions{StackFrames: 2}))
+
+	logger.Error("panic recovered", "stack", CallerStack(0))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want message, stack header and 2 frames: %s", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "ERROR panic recovered") {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if strings.TrimSpace(lines[1]) != "stack:" {
+		t.Errorf("stack header = %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "TestPrettyHandler_Stack") {
+		t.Errorf("first frame = %q", lines[2])
+	}
+}
+
+func TestPrettyHandler_Color(t *testing.T) {
+	tests := []struct {
+		name      string
+		color     bool
+		wantColor bool
+	}{
+		{name: "color enabled", color: true, wantColor: true},
+		{name: "color disabled", color: false, wantColor: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewPrettyHandler(&buf, nil, tt.color)).Warn("test", "key", "value")
+
+			if got := strings.Contains(buf.String(), colorYellow+"WARN "+colorReset); got != tt.wantColor {
+				t.Errorf("colored level = %v, want %v: %q", got, tt.wantColor, buf.String())
+			}
+		})
+	}
+}
+
+func TestPrettyHandler_Enabled(t *testing.T) {
+	h := NewPrettyHandler(&bytes.Buffer{}, nil, false)
+
+	if h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug should be disabled by default")
+	}
+	if !h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info should be enabled by default")
+	}
+}
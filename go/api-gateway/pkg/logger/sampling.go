@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSamplingInterval はサンプリングと件数の制限を数える期間のデフォルト値
+const DefaultSamplingInterval = time.Second
+
+// SamplingConfig はログのサンプリングとメッセージごとの件数の制限の設定
+// どちらもレベルとメッセージの組ごとに Interval の期間で数え、期間が変わると数え直す
+type SamplingConfig struct {
+	// Every はINFO以下のログを Every 件に1件だけ記録する（0・1は全て記録）。期間の最初の1件は常に記録する
+	// WARN以上のログと Keep が true を返すログは間引かない
+	Every int
+	// Keep は間引かずに記録するログを選ぶ（エラーのアクセスログなど。nilの場合はレベルのみで判定する）
+	Keep func(r slog.Record) bool
+	// RateLimit は Interval あたりに記録する件数の上限（0は無制限）。全てのレベルのログに適用する
+	RateLimit int
+	// Interval は数える期間（0は DefaultSamplingInterval）
+	Interval time.Duration
+	// Now は現在時刻を返す（nilの場合は time.Now）
+	Now func() time.Time
+}
+
+// SamplingHandler は件数の多いログを間引くslog.Handler
+// 間引いたレコードの数はサンプリングと件数の制限に分けて Sampled と RateLimited で取得できる
+type SamplingHandler struct {
+	next  slog.Handler
+	state *samplingState
+}
+
+// samplingState は WithAttrs / WithGroup で作成したハンドラ間で共有する件数
+type samplingState struct {
+	config SamplingConfig
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[samplingKey]int
+
+	sampled     atomic.Uint64
+	rateLimited atomic.Uint64
+}
+
+// samplingKey は件数を数える単位（レベルとメッセージ、間引く対象か）
+// 同じメッセージでも Keep で選んだレコードは間引く対象とは別に数える
+type samplingKey struct {
+	level   slog.Level
+	message string
+	sample  bool
+}
+
+// NewSamplingHandler はnextへの書き込みを cfg に従って間引くハンドラを作成する
+func NewSamplingHandler(next slog.Handler, cfg SamplingConfig) *SamplingHandler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultSamplingInterval
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &SamplingHandler{
+		next:  next,
+		state: &samplingState{config: cfg, counts: make(map[samplingKey]int)},
+	}
+}
+
+// WithSampling は log の出力を cfg に従って間引くロガーを作成する
+func WithSampling(log *slog.Logger, cfg SamplingConfig) (*slog.Logger, *SamplingHandler) {
+	sampling := NewSamplingHandler(log.Handler(), cfg)
+	return slog.New(sampling), sampling
+}
+
+// Enabled はログレベルが有効か確認する
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle は間引かないレコードのみをnextに書き込む
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.state.allow(r) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs は属性を追加したハンドラを返す（件数は共有する）
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+// WithGroup はグループを追加したハンドラを返す（件数は共有する）
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// Sampled はサンプリングで間引いたレコードの数を返す
+func (h *SamplingHandler) Sampled() uint64 {
+	return h.state.sampled.Load()
+}
+
+// RateLimited は件数の上限を超えて捨てたレコードの数を返す
+func (h *SamplingHandler) RateLimited() uint64 {
+	return h.state.rateLimited.Load()
+}
+
+// allow はレコードを記録するか判定し、件数を数える
+func (s *samplingState) allow(r slog.Record) bool {
+	cfg := s.config
+	sample := cfg.Every > 1 && r.Level < slog.LevelWarn && (cfg.Keep == nil || !cfg.Keep(r))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := cfg.Now()
+	if now.Sub(s.windowStart) >= cfg.Interval {
+		s.windowStart = now
+		clear(s.counts)
+	}
+	key := samplingKey{level: r.Level, message: r.Message, sample: sample}
+	n := s.counts[key]
+	s.counts[key] = n + 1
+
+	if sample && n%cfg.Every != 0 {
+		s.sampled.Add(1)
+		return false
+	}
+	if cfg.RateLimit > 0 {
+		// この期間に記録した件数（サンプリングで間引いた分を除く）で上限を判定する
+		written := n
+		if sample {
+			written = n / cfg.Every
+		}
+		if written >= cfg.RateLimit {
+			s.rateLimited.Add(1)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	isError := func(r slog.Record) bool {
+		keep := false
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "status" {
+				keep = a.Value.Int64() >= 500
+			}
+			return true
+		})
+		return keep
+	}
+
+	tests := []struct {
+		name            string
+		config          SamplingConfig
+		log             func(log *slog.Logger)
+		wantLines       int
+		wantSampled     uint64
+		wantRateLimited uint64
+	}{
+		{
+			name:   "INFOは Every 件に1件だけ記録する",
+			config: SamplingConfig{Every: 3},
+			log: func(log *slog.Logger) {
+				for range 10 {
+					log.Info("access", "status", 200)
+				}
+			},
+			wantLines:   4,
+			wantSampled: 6,
+		},
+		{
+			name:   "WARN以上と Keep で選んだログは間引かない",
+			config: SamplingConfig{Every: 10, Keep: isError},
+			log: func(log *slog.Logger) {
+				for range 5 {
+					log.Info("access", "status", 200)
+					log.Info("access", "status", 502)
+					log.Warn("slow request")
+				}
+			},
+			wantLines:   11,
+			wantSampled: 4,
+		},
+		{
+			name:   "メッセージごとに件数を数える",
+			config: SamplingConfig{Every: 2},
+			log: func(log *slog.Logger) {
+				for range 2 {
+					log.Info("access")
+					log.Info("routes reloaded")
+				}
+			},
+			wantLines:   2,
+			wantSampled: 2,
+		},
+		{
+			name:   "全てのレベルで期間あたりの件数を制限する",
+			config: SamplingConfig{RateLimit: 2},
+			log: func(log *slog.Logger) {
+				for range 5 {
+					log.Error("backend unavailable")
+				}
+				log.Info("access")
+			},
+			wantLines:       3,
+			wantRateLimited: 3,
+		},
+		{
+			name:   "件数の制限はサンプリングで記録したログを数える",
+			config: SamplingConfig{Every: 2, RateLimit: 2},
+			log: func(log *slog.Logger) {
+				for range 10 {
+					log.Info("access")
+				}
+			},
+			wantLines:       2,
+			wantSampled:     5,
+			wantRateLimited: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			now := time.Now()
+			tt.config.Now = func() time.Time { return now }
+			log, sampling := WithSampling(slog.New(slog.NewJSONHandler(&out, nil)), tt.config)
+
+			tt.log(log.With("component", "gateway"))
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("got %d lines, want %d: %s", len(lines), tt.wantLines, out.String())
+			}
+			if got := sampling.Sampled(); got != tt.wantSampled {
+				t.Errorf("Sampled() = %d, want %d", got, tt.wantSampled)
+			}
+			if got := sampling.RateLimited(); got != tt.wantRateLimited {
+				t.Errorf("RateLimited() = %d, want %d", got, tt.wantRateLimited)
+			}
+		})
+	}
+}
+
+func TestSamplingHandler_Interval(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Now()
+	log, sampling := WithSampling(slog.New(slog.NewJSONHandler(&out, nil)), SamplingConfig{
+		RateLimit: 1,
+		Interval:  time.Minute,
+		Now:       func() time.Time { return now },
+	})
+
+	log.Error("backend unavailable")
+	log.Error("backend unavailable")
+	// 期間が変わると数え直す
+	now = now.Add(time.Minute)
+	log.Error("backend unavailable")
+
+	if got := strings.Count(out.String(), "backend unavailable"); got != 2 {
+		t.Errorf("got %d lines, want 2: %s", got, out.String())
+	}
+	if got := sampling.RateLimited(); got != 1 {
+		t.Errorf("RateLimited() = %d, want 1", got)
+	}
+}
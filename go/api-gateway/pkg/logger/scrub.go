@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Redacted は伏せた値の置き換え後の文字列
+const Redacted = "[REDACTED]"
+
+// DefaultSensitiveHeaders は常に値をログに出力しないヘッダー
+var DefaultSensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+
+// minSecretLength はエラーメッセージなどから伏せる値の最短の長さ（短い値は無関係な文字列と一致しやすいため伏せない）
+const minSecretLength = 4
+
+// sensitiveHeaders は値を伏せるヘッダー（正規化した名前）
+// 実行中に SetSensitiveHeaders で追加すると、作成済みのロガーにも即座に反映される
+var sensitiveHeaders atomic.Pointer[map[string]bool]
+
+func init() {
+	SetSensitiveHeaders(nil)
+}
+
+// SetSensitiveHeaders は DefaultSensitiveHeaders に加えて値を伏せるヘッダーを設定する
+func SetSensitiveHeaders(additional []string) {
+	headers := make(map[string]bool, len(DefaultSensitiveHeaders)+len(additional))
+	for _, name := range slices.Concat(DefaultSensitiveHeaders, additional) {
+		headers[canonicalHeader(name)] = true
+	}
+	sensitiveHeaders.Store(&headers)
+}
+
+// IsSensitiveHeader は値を伏せるヘッダーか確認する（大文字小文字と "_" / "-" を区別しない）
+func IsSensitiveHeader(name string) bool {
+	return (*sensitiveHeaders.Load())[canonicalHeader(name)]
+}
+
+// canonicalHeader はログの属性名（"x_api_key" など）もヘッダー名として比較できるよう正規化する
+func canonicalHeader(name string) string {
+	return http.CanonicalHeaderKey(strings.ReplaceAll(name, "_", "-"))
+}
+
+// ScrubHeaders は値を伏せるヘッダーの値を Redacted に置き換えたコピーを返す（h は変更しない）
+func ScrubHeaders(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	scrubbed := make(http.Header, len(h))
+	for name, values := range h {
+		if IsSensitiveHeader(name) {
+			values = slices.Repeat([]string{Redacted}, len(values))
+		}
+		scrubbed[name] = values
+	}
+	return scrubbed
+}
+
+// ScrubSecrets は s に含まれる、h のうち値を伏せるヘッダーの値を Redacted に置き換える
+// バックエンドやミドルウェアのエラーメッセージにトークンなどがそのまま含まれる場合に使う
+// Authorization のような "<scheme> <credentials>" の値は credentials、Cookie はそれぞれのクッキーの値も伏せる
+func ScrubSecrets(s string, h http.Header) string {
+	var secrets []string
+	for name, values := range h {
+		if !IsSensitiveHeader(name) {
+			continue
+		}
+		for _, value := range values {
+			secrets = append(secrets, value)
+			if _, credentials, ok := strings.Cut(value, " "); ok {
+				secrets = append(secrets, strings.TrimSpace(credentials))
+			}
+			if strings.Contains(value, "=") {
+				for cookie := range strings.SplitSeq(value, ";") {
+					if _, v, ok := strings.Cut(cookie, "="); ok {
+						secrets = append(secrets, strings.TrimSpace(v))
+					}
+				}
+			}
+		}
+	}
+	// 長い値を先に置き換え、一部だけが伏せられた値が残らないようにする
+	slices.SortFunc(secrets, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
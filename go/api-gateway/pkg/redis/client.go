@@ -17,15 +17,22 @@ type Config struct {
 	DialTimeout  time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// Keyspace はキーの名前空間。リポジトリとキャッシュは Key で全てのキーに付ける
+	Keyspace Keyspace
 }
 
 // Client はRedisクライアントのラッパー
 type Client struct {
-	client *redis.Client
+	client   *redis.Client
+	keyspace Keyspace
 }
 
 // NewClient は新しいRedisクライアントを作成する
 func NewClient(cfg Config) (*Client, error) {
+	if err := cfg.Keyspace.Validate(); err != nil {
+		return nil, err
+	}
+
 	opts := &redis.Options{
 		Addr:         cfg.Host,
 		Password:     cfg.Password,
@@ -46,7 +53,21 @@ func NewClient(cfg Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	return &Client{client: client}, nil
+	return &Client{client: client, keyspace: cfg.Keyspace}, nil
+}
+
+// Key は key をクライアントの名前空間のキーにする
+// Get などは key をそのまま使うため、呼び出し側でキーとチャンネル名を作る時に使う
+func (c *Client) Key(key string) string {
+	if c == nil {
+		return key
+	}
+	return c.keyspace.Key(key)
+}
+
+// Keyspace はクライアントの名前空間を返す
+func (c *Client) Keyspace() Keyspace {
+	return c.keyspace
 }
 
 // Get は指定されたキーの値を取得する
@@ -77,6 +98,97 @@ func (c *Client) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// Pipelined は fn で積んだコマンドを1回のパイプラインで実行し、コマンドごとの結果を返す
+// 一部のコマンドが失敗してもエラーは返さないため、各コマンドの Err() を確認すること
+// パイプライン自体を実行できなかった場合（接続エラーなど）は全てのコマンドが同じエラーを持つ
+func (c *Client) Pipelined(ctx context.Context, fn func(pipe redis.Pipeliner)) []redis.Cmder {
+	pipe := c.client.Pipeline()
+	fn(pipe)
+	cmds, err := pipe.Exec(ctx)
+	if err == nil {
+		return cmds
+	}
+
+	// コマンドのエラーの場合は失敗したコマンドに設定されている
+	// どのコマンドにも設定されていない場合は、接続できずにパイプラインを実行できなかったため全てのコマンドに設定する
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			return cmds
+		}
+	}
+	for _, cmd := range cmds {
+		cmd.SetErr(err)
+	}
+	return cmds
+}
+
+// Publish は指定されたチャンネルにメッセージを送信する
+func (c *Client) Publish(ctx context.Context, channel string, message string) error {
+	if err := c.client.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+// 購読中に受信できなくなった場合に再接続を待つ時間
+const (
+	subscribeMinBackoff = 100 * time.Millisecond
+	subscribeMaxBackoff = 5 * time.Second
+)
+
+// SubscribeHandlers は購読したチャンネルの通知を受け取る関数
+type SubscribeHandlers struct {
+	// OnMessage は受信したメッセージごとに呼び出す
+	OnMessage func(message string)
+	// OnSubscribe は購読を開始した時（再接続して購読し直した時を含む）に呼び出す（任意）
+	// 切断中に送信されたメッセージは受信できないため、受信側のキャッシュを破棄するなどに使う
+	OnSubscribe func()
+	// OnError は受信に失敗した時に呼び出す（任意）。失敗した後は待機してから再接続する
+	OnError func(err error)
+}
+
+// Subscribe は指定されたチャンネルを購読し、受信したメッセージごとに handlers.OnMessage を呼び出す
+// ctx がキャンセルされるまでブロックし、接続が切れた場合は再接続して購読し直す
+func (c *Client) Subscribe(ctx context.Context, channel string, handlers SubscribeHandlers) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+	// Receive はキャンセルでは戻らないため、接続を閉じて受信を打ち切る
+	stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+	defer stop()
+
+	backoff := subscribeMinBackoff
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if handlers.OnError != nil {
+				handlers.OnError(fmt.Errorf("failed to receive from channel %s: %w", channel, err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, subscribeMaxBackoff)
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				backoff = subscribeMinBackoff
+				if handlers.OnSubscribe != nil {
+					handlers.OnSubscribe()
+				}
+			}
+		case *redis.Message:
+			handlers.OnMessage(msg.Payload)
+		}
+	}
+}
+
 // Ping はRedis接続の健全性を確認する
 func (c *Client) Ping(ctx context.Context) error {
 	if err := c.client.Ping(ctx).Err(); err != nil {
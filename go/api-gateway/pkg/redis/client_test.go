@@ -360,3 +360,57 @@ func TestClient_GetClient(t *testing.T) {
 		t.Error("GetClient() returned nil")
 	}
 }
+
+func TestClient_PublishSubscribe(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{
+		Host: mr.Addr(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subscribed := make(chan struct{}, 1)
+	messages := make(chan string, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Subscribe(ctx, "test-channel", redisclient.SubscribeHandlers{
+			OnMessage:   func(message string) { messages <- message },
+			OnSubscribe: func() { subscribed <- struct{}{} },
+		})
+	}()
+
+	select {
+	case <-subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnSubscribe was not called")
+	}
+
+	if err := client.Publish(ctx, "test-channel", "hello"); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	select {
+	case got := <-messages:
+		if got != "hello" {
+			t.Errorf("received %q, want %q", got, "hello")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not received")
+	}
+
+	// キャンセルすると購読を終了する
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Subscribe() did not return after cancel")
+	}
+}
@@ -0,0 +1,223 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Keyspace はRedisのキー（pub/subのチャンネル名を含む）の名前空間
+// 複数のゲートウェイ（デプロイメントやバージョン）が同じRedisを共有する場合に、全てのキーの先頭に "<namespace>:v<version>:" を付けて分ける
+// キーの形式（値のエンコードなど）を互換性の無い形に変える場合は Version を上げ、ローリングアップデート中に新旧のゲートウェイが互いのデータを壊さないようにする
+type Keyspace struct {
+	// Namespace はデプロイメントの名前（空の場合は付けない）。設定のハッシュなどデプロイ時に決まる値を使うこともできる
+	Namespace string
+	// Version はキーの形式のバージョン（0の場合は付けない）
+	Version int
+}
+
+// Validate は名前空間をキーに使えるか確認する
+func (k Keyspace) Validate() error {
+	if k.Version < 0 {
+		return fmt.Errorf("keyspace version must be non-negative")
+	}
+	// 区切り文字とSCANのパターンで特別な意味を持つ文字を含むと、移行で別の名前空間のキーまで対象になる
+	if strings.ContainsAny(k.Namespace, ":*?[]\\") {
+		return fmt.Errorf("keyspace namespace %q must not contain ':' or glob characters", k.Namespace)
+	}
+	return nil
+}
+
+// Prefix は名前空間のキープレフィックスを返す（名前空間もバージョンも無い場合は空文字列）
+func (k Keyspace) Prefix() string {
+	var b strings.Builder
+	if k.Namespace != "" {
+		b.WriteString(k.Namespace)
+		b.WriteByte(':')
+	}
+	if k.Version > 0 {
+		b.WriteByte('v')
+		b.WriteString(strconv.Itoa(k.Version))
+		b.WriteByte(':')
+	}
+	return b.String()
+}
+
+// Key は key を名前空間のキーにする
+func (k Keyspace) Key(key string) string {
+	return k.Prefix() + key
+}
+
+// String はログ出力用に名前空間を返す
+func (k Keyspace) String() string {
+	if prefix := k.Prefix(); prefix != "" {
+		return prefix
+	}
+	return "(none)"
+}
+
+// migrateScanCount はキーの移行でSCANの1回に走査するキー数の目安
+const migrateScanCount = 100
+
+// MigrateOptions はキーの移行の設定
+type MigrateOptions struct {
+	// Match は移行するキーのパターン（名前空間より後の部分。空の場合は全て）。SCANのMATCHと同じ書式
+	Match string
+	// Convert は値を新しい形式に変換する（nilの場合はそのまま複製する）
+	// skip が true の場合は複製しない（古い形式のまま使えないキーなど）
+	Convert func(key, value string) (converted string, skip bool, err error)
+	// DryRun は対象のキーを数えるだけで書き込まない
+	DryRun bool
+}
+
+// MigrateResult はキーの移行の結果
+type MigrateResult struct {
+	Copied  int // 新しい名前空間へ複製したキー数
+	Skipped int // 新しい名前空間に既にある・Convert で除外した・文字列型ではないキー数
+}
+
+// Migrate は from の名前空間のキーを to の名前空間へ複製する
+// 残りの有効期限は引き継ぐ。移行中に新しいバージョンのゲートウェイが書き込んだキーは上書きしない
+// ローリングアップデート中は古いバージョンも from のキーを使うため、元のキーは削除しない（全て切り替えた後に Purge で削除する）
+// 値が文字列型のキーだけを対象にする（失効時刻、OIDCのセッション、レスポンスキャッシュは全て文字列型）
+func (c *Client) Migrate(ctx context.Context, from, to Keyspace, opts MigrateOptions) (MigrateResult, error) {
+	var result MigrateResult
+	fromPrefix, toPrefix := from.Prefix(), to.Prefix()
+	if fromPrefix == toPrefix {
+		return result, fmt.Errorf("source and destination keyspaces are the same: %s", from)
+	}
+
+	err := c.scan(ctx, from, opts.Match, func(keys []string) error {
+		for _, key := range keys {
+			// 名前空間の無いキーから移行する場合、移行先のキーも一致する
+			if strings.HasPrefix(key, toPrefix) && len(toPrefix) > len(fromPrefix) {
+				continue
+			}
+			copied, err := c.migrateKey(ctx, key, toPrefix+strings.TrimPrefix(key, fromPrefix), opts)
+			if err != nil {
+				return err
+			}
+			if copied {
+				result.Copied++
+			} else {
+				result.Skipped++
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// migrateKey は1つのキーを複製し、複製したかどうかを返す
+func (c *Client) migrateKey(ctx context.Context, key, dest string, opts MigrateOptions) (bool, error) {
+	var get *redis.StringCmd
+	var ttl *redis.DurationCmd
+	c.Pipelined(ctx, func(pipe redis.Pipeliner) {
+		get = pipe.Get(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+	})
+
+	value, err := get.Result()
+	// SCANの後に期限切れになったキーと文字列型ではないキーは対象外
+	if err == redis.Nil || (err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE")) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	if ttl.Err() != nil {
+		return false, fmt.Errorf("failed to get ttl of key %s: %w", key, ttl.Err())
+	}
+
+	if opts.Convert != nil {
+		converted, skip, err := opts.Convert(key, value)
+		if err != nil {
+			return false, fmt.Errorf("failed to convert key %s: %w", key, err)
+		}
+		if skip {
+			return false, nil
+		}
+		value = converted
+	}
+
+	// 有効期限がない場合（-1）は期限なしで複製し、GETの後に期限切れになった場合（-2）は複製しない
+	expiration := ttl.Val()
+	switch {
+	case expiration == -1:
+		expiration = 0
+	case expiration <= 0:
+		return false, nil
+	}
+	if opts.DryRun {
+		return true, nil
+	}
+
+	ok, err := c.client.SetNX(ctx, dest, value, expiration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set key %s: %w", dest, err)
+	}
+	return ok, nil
+}
+
+// Purge は名前空間のキーのうち match に一致するものを削除し、削除したキー数を返す
+// 移行を終えて古いバージョンのゲートウェイが無くなった後に使う
+// 名前空間もバージョンも無い場合は match を指定しないとエラーにする（Redisの全てのキーが対象になるため）
+func (c *Client) Purge(ctx context.Context, keyspace Keyspace, match string) (int, error) {
+	if keyspace.Prefix() == "" && (match == "" || match == "*") {
+		return 0, errors.New("refusing to purge all keys: specify a keyspace or a match pattern")
+	}
+
+	purged := 0
+	err := c.scan(ctx, keyspace, match, func(keys []string) error {
+		n, err := c.client.Del(ctx, keys...).Result()
+		if err != nil {
+			return fmt.Errorf("failed to delete keys: %w", err)
+		}
+		purged += int(n)
+		return nil
+	})
+	return purged, err
+}
+
+// scan は名前空間のキーのうち match に一致するものをSCANで少しずつ走査し、fn を呼び出す
+// KEYSはRedisをブロックするため使わない
+func (c *Client) scan(ctx context.Context, keyspace Keyspace, match string, fn func(keys []string) error) error {
+	if match == "" {
+		match = "*"
+	}
+	pattern := escapeGlob(keyspace.Prefix()) + match
+
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, migrateScanCount).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan keys: %w", err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// escapeGlob はSCANのMATCHパターンで特別な意味を持つ文字をエスケープする
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package redis_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	redisclient "api-gateway/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestKeyspace_Prefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyspace redisclient.Keyspace
+		want     string
+	}{
+		{name: "名前空間なし", keyspace: redisclient.Keyspace{}, want: ""},
+		{name: "名前空間のみ", keyspace: redisclient.Keyspace{Namespace: "gw"}, want: "gw:"},
+		{name: "バージョンのみ", keyspace: redisclient.Keyspace{Version: 2}, want: "v2:"},
+		{name: "名前空間とバージョン", keyspace: redisclient.Keyspace{Namespace: "gw", Version: 2}, want: "gw:v2:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keyspace.Prefix(); got != tt.want {
+				t.Errorf("Prefix() = %q, want %q", got, tt.want)
+			}
+			if got := tt.keyspace.Key("revoke:user123"); got != tt.want+"revoke:user123" {
+				t.Errorf("Key() = %q, want %q", got, tt.want+"revoke:user123")
+			}
+		})
+	}
+}
+
+func TestKeyspace_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyspace redisclient.Keyspace
+		wantErr  bool
+	}{
+		{name: "正常", keyspace: redisclient.Keyspace{Namespace: "gw-blue", Version: 1}},
+		{name: "負のバージョン", keyspace: redisclient.Keyspace{Version: -1}, wantErr: true},
+		{name: "区切り文字を含む", keyspace: redisclient.Keyspace{Namespace: "gw:blue"}, wantErr: true},
+		{name: "globの文字を含む", keyspace: redisclient.Keyspace{Namespace: "gw*"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.keyspace.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Migrate(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{Host: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// 名前空間の無い古いキー
+	mr.Set("revoke:user1", "2024-01-01T00:00:00Z")
+	mr.SetTTL("revoke:user1", time.Hour)
+	mr.Set("revoke:user2", "old")
+	mr.Set("cache:GET:/api", "{}")
+	mr.HSet("revoke:hash", "field", "value")
+	// 新しいバージョンのゲートウェイが書き込んだキーは上書きしない
+	mr.Set("gw:v2:revoke:user2", "new")
+
+	to := redisclient.Keyspace{Namespace: "gw", Version: 2}
+	result, err := client.Migrate(ctx, redisclient.Keyspace{}, to, redisclient.MigrateOptions{Match: "revoke:*"})
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if result.Copied != 1 || result.Skipped != 2 {
+		t.Errorf("Migrate() = %+v, want 1 copied and 2 skipped", result)
+	}
+
+	if got, _ := mr.Get("gw:v2:revoke:user1"); got != "2024-01-01T00:00:00Z" {
+		t.Errorf("gw:v2:revoke:user1 = %q, want 2024-01-01T00:00:00Z", got)
+	}
+	if ttl := mr.TTL("gw:v2:revoke:user1"); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("ttl of gw:v2:revoke:user1 = %v, want up to 1h", ttl)
+	}
+	if got, _ := mr.Get("gw:v2:revoke:user2"); got != "new" {
+		t.Errorf("gw:v2:revoke:user2 = %q, want new", got)
+	}
+	if mr.Exists("gw:v2:cache:GET:/api") {
+		t.Error("keys not matching the pattern should not be migrated")
+	}
+	// 古いバージョンのゲートウェイのため元のキーは残す
+	if !mr.Exists("revoke:user1") {
+		t.Error("source key should be kept")
+	}
+
+	t.Run("値を変換する", func(t *testing.T) {
+		result, err := client.Migrate(ctx, redisclient.Keyspace{}, redisclient.Keyspace{Version: 3}, redisclient.MigrateOptions{
+			Match: "revoke:user*",
+			Convert: func(key, value string) (string, bool, error) {
+				return strings.ToUpper(value), strings.HasSuffix(key, "user2"), nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Migrate() error = %v", err)
+		}
+		if result.Copied != 1 || result.Skipped != 1 {
+			t.Errorf("Migrate() = %+v, want 1 copied and 1 skipped", result)
+		}
+		if got, _ := mr.Get("v3:revoke:user1"); got != "2024-01-01T00:00:00Z" {
+			t.Errorf("v3:revoke:user1 = %q", got)
+		}
+		if mr.Exists("v3:revoke:user2") {
+			t.Error("skipped key should not be migrated")
+		}
+	})
+
+	t.Run("ドライランは書き込まない", func(t *testing.T) {
+		result, err := client.Migrate(ctx, to, redisclient.Keyspace{Namespace: "gw", Version: 4}, redisclient.MigrateOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("Migrate() error = %v", err)
+		}
+		if result.Copied != 2 {
+			t.Errorf("Copied = %d, want 2", result.Copied)
+		}
+		if mr.Exists("gw:v4:revoke:user1") {
+			t.Error("dry run should not write keys")
+		}
+	})
+
+	t.Run("同じ名前空間はエラー", func(t *testing.T) {
+		if _, err := client.Migrate(ctx, to, to, redisclient.MigrateOptions{}); err == nil {
+			t.Error("Migrate() error = nil, want error")
+		}
+	})
+}
+
+func TestClient_Purge(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	client, err := redisclient.NewClient(redisclient.Config{Host: mr.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	mr.Set("gw:v1:revoke:user1", "a")
+	mr.Set("gw:v1:cache:GET:/api", "b")
+	mr.Set("gw:v2:revoke:user1", "c")
+
+	purged, err := client.Purge(context.Background(), redisclient.Keyspace{Namespace: "gw", Version: 1}, "")
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("Purge() = %d, want 2", purged)
+	}
+	if !mr.Exists("gw:v2:revoke:user1") {
+		t.Error("keys in other keyspaces should be kept")
+	}
+
+	if _, err := client.Purge(context.Background(), redisclient.Keyspace{}, "*"); err == nil {
+		t.Error("Purge() error = nil, want error for purging all keys")
+	}
+}
@@ -0,0 +1,164 @@
+// Package signature はゲートウェイが転送するリクエストへの署名と、バックエンドでの検証を提供する
+//
+// 署名は X-Gateway-Signature ヘッダーに "kid=<kid>,ts=<unix秒>,sig=<base64url>" の形式で設定する
+// 署名対象はメソッド、リクエストURI（パスとクエリ）、ボディのSHA-256、タイムスタンプで、
+// RSA-SHA256 (PKCS#1 v1.5) で署名する。公開鍵はゲートウェイの /.well-known/jwks.json から取得できる
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header は署名を設定するヘッダー名
+const Header = "X-Gateway-Signature"
+
+// DefaultMaxSkew はタイムスタンプとして許容する時刻のずれのデフォルト値
+const DefaultMaxSkew = 5 * time.Minute
+
+// version は署名対象の文字列の形式のバージョン
+const version = "v1"
+
+// KeyFunc は署名に使う鍵とそのkidを返す
+// 鍵のローテーションに追従できるよう、署名のたびに呼び出す
+type KeyFunc func() (kid string, key *rsa.PrivateKey)
+
+// PublicKeyFunc はkidに対応する検証用の公開鍵を返す
+type PublicKeyFunc func(kid string) (*rsa.PublicKey, error)
+
+// Signer は転送するリクエストに署名する
+type Signer struct {
+	key KeyFunc
+	now func() time.Time
+}
+
+// NewSigner は新しいSignerを作成する
+func NewSigner(key KeyFunc) *Signer {
+	return &Signer{
+		key: key,
+		now: time.Now,
+	}
+}
+
+// Sign はリクエストに署名し、署名ヘッダーを設定する（既存の値は上書きする）
+// bodySHA256 は転送するボディのSHA-256ハッシュ（ボディが無い場合は空データのハッシュ）
+func (s *Signer) Sign(req *http.Request, bodySHA256 []byte) error {
+	kid, key := s.key()
+	if key == nil {
+		return fmt.Errorf("signing key not found for kid: %s", kid)
+	}
+
+	ts := s.now().Unix()
+	digest := sha256.Sum256([]byte(StringToSign(req.Method, req.URL.RequestURI(), bodySHA256, ts)))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request with kid=%s: %w", kid, err)
+	}
+
+	req.Header.Set(Header, fmt.Sprintf("kid=%s,ts=%d,sig=%s", kid, ts, base64.RawURLEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// StringToSign は署名対象の文字列を返す
+func StringToSign(method, requestURI string, bodySHA256 []byte, timestamp int64) string {
+	return strings.Join([]string{
+		version,
+		method,
+		requestURI,
+		hex.EncodeToString(bodySHA256),
+		strconv.FormatInt(timestamp, 10),
+	}, "\n")
+}
+
+// Verify はバックエンドが受け取ったリクエストの署名を検証する
+// body はリクエストボディ全体（r.Body は読み込まない）。maxSkew が0以下の場合は DefaultMaxSkew を使う
+func Verify(r *http.Request, body []byte, publicKey PublicKeyFunc, maxSkew time.Duration, now time.Time) error {
+	value := r.Header.Get(Header)
+	if value == "" {
+		return fmt.Errorf("missing %s header", Header)
+	}
+	kid, ts, sig, err := parseHeader(value)
+	if err != nil {
+		return err
+	}
+
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("signature timestamp is outside the allowed skew: %d", ts)
+	}
+
+	key, err := publicKey(kid)
+	if err != nil {
+		return fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	// クライアントが送ったリクエストターゲットをそのまま使い、エスケープの違いで検証に失敗しないようにする
+	requestURI := r.RequestURI
+	if requestURI == "" {
+		requestURI = r.URL.RequestURI()
+	}
+	bodySHA256 := sha256.Sum256(body)
+	digest := sha256.Sum256([]byte(StringToSign(r.Method, requestURI, bodySHA256[:], ts)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	return nil
+}
+
+// VerifyRequest は r.Body を読み込んで署名を検証し、後続の処理が読めるようボディを戻す
+func VerifyRequest(r *http.Request, publicKey PublicKeyFunc, maxSkew time.Duration) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return Verify(r, body, publicKey, maxSkew, time.Now())
+}
+
+// parseHeader は署名ヘッダーの値を解析する
+func parseHeader(value string) (kid string, ts int64, sig []byte, err error) {
+	var tsSet bool
+	for _, part := range strings.Split(value, ",") {
+		name, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", 0, nil, fmt.Errorf("malformed %s header", Header)
+		}
+		switch name {
+		case "kid":
+			kid = v
+		case "ts":
+			ts, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", 0, nil, fmt.Errorf("invalid signature timestamp: %w", err)
+			}
+			tsSet = true
+		case "sig":
+			sig, err = base64.RawURLEncoding.DecodeString(v)
+			if err != nil {
+				return "", 0, nil, fmt.Errorf("invalid signature encoding: %w", err)
+			}
+		}
+	}
+
+	if kid == "" || !tsSet || len(sig) == 0 {
+		return "", 0, nil, fmt.Errorf("malformed %s header", Header)
+	}
+	return kid, ts, sig, nil
+}
@@ -0,0 +1,160 @@
+package signature_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-gateway/pkg/signature"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	publicKeys := map[string]*rsa.PublicKey{"gw-1": &key.PublicKey, "gw-2": &otherKey.PublicKey}
+	publicKey := func(kid string) (*rsa.PublicKey, error) {
+		k, ok := publicKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %s", kid)
+		}
+		return k, nil
+	}
+
+	signer := signature.NewSigner(func() (string, *rsa.PrivateKey) { return "gw-1", key })
+	body := `{"amount":100}`
+	sign := func(t *testing.T) *http.Request {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader(body))
+		if err := signer.Sign(req, sha256Sum(body)); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *http.Request) string
+		now     time.Time
+		wantErr bool
+	}{
+		{
+			name:   "正しい署名",
+			modify: func(r *http.Request) string { return body },
+			now:    time.Now(),
+		},
+		{
+			name:    "ボディの改ざん",
+			modify:  func(r *http.Request) string { return `{"amount":1000}` },
+			now:     time.Now(),
+			wantErr: true,
+		},
+		{
+			name: "パスの改ざん",
+			modify: func(r *http.Request) string {
+				r.RequestURI = "/orders?id=2"
+				return body
+			},
+			now:     time.Now(),
+			wantErr: true,
+		},
+		{
+			name: "メソッドの改ざん",
+			modify: func(r *http.Request) string {
+				r.Method = http.MethodPut
+				return body
+			},
+			now:     time.Now(),
+			wantErr: true,
+		},
+		{
+			name:    "許容範囲を超えた古いタイムスタンプ",
+			modify:  func(r *http.Request) string { return body },
+			now:     time.Now().Add(signature.DefaultMaxSkew + time.Minute),
+			wantErr: true,
+		},
+		{
+			name: "異なる鍵のkid",
+			modify: func(r *http.Request) string {
+				r.Header.Set(signature.Header, strings.Replace(r.Header.Get(signature.Header), "kid=gw-1", "kid=gw-2", 1))
+				return body
+			},
+			now:     time.Now(),
+			wantErr: true,
+		},
+		{
+			name: "署名ヘッダーが無い",
+			modify: func(r *http.Request) string {
+				r.Header.Del(signature.Header)
+				return body
+			},
+			now:     time.Now(),
+			wantErr: true,
+		},
+		{
+			name: "不正な形式の署名ヘッダー",
+			modify: func(r *http.Request) string {
+				r.Header.Set(signature.Header, "garbage")
+				return body
+			},
+			now:     time.Now(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sign(t)
+			gotBody := tt.modify(req)
+
+			err := signature.Verify(req, []byte(gotBody), publicKey, 0, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest_RestoresBody(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer := signature.NewSigner(func() (string, *rsa.PrivateKey) { return "gw-1", key })
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("payload"))
+	if err := signer.Sign(req, sha256Sum("payload")); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	err = signature.VerifyRequest(req, func(string) (*rsa.PublicKey, error) { return &key.PublicKey, nil }, time.Minute)
+	if err != nil {
+		t.Fatalf("VerifyRequest() error = %v", err)
+	}
+
+	// 検証後もボディを読めること
+	buf := new(strings.Builder)
+	if _, err := io.Copy(buf, req.Body); err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if buf.String() != "payload" {
+		t.Errorf("body = %q, want %q", buf.String(), "payload")
+	}
+}
+
+func sha256Sum(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
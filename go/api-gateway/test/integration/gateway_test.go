@@ -137,15 +137,15 @@ func TestGateway_EndToEnd(t *testing.T) {
 		}
 	})
 
-	// テストケース5: OPTIONSリクエスト
+	// テストケース5: OPTIONSリクエスト（CORSポリシーの無いルートは通常のリクエストとして扱う）
 	t.Run("OPTIONS /api/v1/users", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodOptions, "/api/v1/users", nil)
 		w := httptest.NewRecorder()
 
 		gateway.ServeHTTP(w, req)
 
-		if w.Code != http.StatusNoContent {
-			t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
 		}
 	})
 }
@@ -0,0 +1,29 @@
+// config-reference writes the configuration reference of the service as JSON.
+// internal/config の go generate から実行し、docs/config-reference.json を更新する
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/kaitoimai/go-sample/rest/internal/config"
+)
+
+func main() {
+	out := flag.String("out", "", "Output file (default: stdout)")
+	flag.Parse()
+
+	data, err := config.ReferenceJSON()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("failed to write %s: %v", *out, err)
+	}
+}
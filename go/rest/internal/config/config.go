@@ -6,9 +6,11 @@ import (
 	"strconv"
 )
 
+// Config is the service configuration loaded from environment variables.
+// タグはリファレンス（Reference）に使う。変数名とデフォルトを変える場合は New と合わせて変更する
 type Config struct {
-	Port     uint
-	LogLevel string
+	Port     uint   `env:"PORT" default:"8080" description:"HTTP server listen port"`
+	LogLevel string `env:"LOG_LEVEL" default:"INFO" description:"Minimum log level (DEBUG, INFO, WARN, ERROR)"`
 }
 
 func New() (*Config, error) {
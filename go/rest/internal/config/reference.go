@@ -0,0 +1,45 @@
+package config
+
+//go:generate go run ../../cmd/cli/config-reference -out ../../docs/config-reference.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ReferenceField describes one configuration item for operators.
+// /admin/config/schema と docs/config-reference.json で同じ形式を使う（API Gateway のリファレンスとも揃える）
+type ReferenceField struct {
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Env         string `json:"env,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Reference returns the reference of every Config field, built from the struct tags.
+func Reference() []ReferenceField {
+	t := reflect.TypeOf(Config{})
+	fields := make([]ReferenceField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fields = append(fields, ReferenceField{
+			Path:        f.Name,
+			Type:        f.Type.Kind().String(),
+			Default:     f.Tag.Get("default"),
+			Env:         f.Tag.Get("env"),
+			Description: f.Tag.Get("description"),
+		})
+	}
+	return fields
+}
+
+// ReferenceJSON returns the reference encoded as indented JSON.
+func ReferenceJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Reference(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config reference: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReference_Defaults verifies that the default tags match the values New actually uses
+func TestReference_Defaults(t *testing.T) {
+	os.Clearenv()
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := map[string]string{
+		"Port":     fmt.Sprint(cfg.Port),
+		"LogLevel": cfg.LogLevel,
+	}
+	for _, f := range Reference() {
+		want, ok := got[f.Path]
+		if !ok {
+			t.Errorf("%s is not checked by this test", f.Path)
+			continue
+		}
+		if f.Default != want {
+			t.Errorf("%s default = %q, want %q", f.Path, f.Default, want)
+		}
+		if f.Env == "" || f.Description == "" {
+			t.Errorf("%s should have env and description tags: %+v", f.Path, f)
+		}
+	}
+}
+
+// TestReferenceJSON_InSync verifies that docs/config-reference.json is up to date
+func TestReferenceJSON_InSync(t *testing.T) {
+	want, err := ReferenceJSON()
+	if err != nil {
+		t.Fatalf("ReferenceJSON() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "docs", "config-reference.json"))
+	if err != nil {
+		t.Fatalf("failed to read config-reference.json: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs/config-reference.json is out of date; run go generate ./internal/config")
+	}
+}
@@ -9,13 +9,16 @@ import (
 	"github.com/kaitoimai/go-sample/rest/internal/oas"
 	logx "github.com/kaitoimai/go-sample/rest/internal/pkg/logger"
 	"github.com/kaitoimai/go-sample/rest/internal/pkg/myerrors"
+	"github.com/kaitoimai/go-sample/rest/internal/usecase"
 )
 
 // OASHandler implements the oas.Handler interface
-type OASHandler struct{}
+type OASHandler struct {
+	hello *usecase.HelloUsecase
+}
 
 // NewOASHandler creates a new OAS handler
-func NewOASHandler() *OASHandler { return &OASHandler{} }
+func NewOASHandler(hello *usecase.HelloUsecase) *OASHandler { return &OASHandler{hello: hello} }
 
 // GetRoot implements oas.Handler
 func (h *OASHandler) GetRoot(ctx context.Context) (oas.GetRootOK, error) {
@@ -35,29 +38,38 @@ func (h *OASHandler) GetHealth(ctx context.Context) (oas.GetHealthOK, error) {
 
 // V1GetHello implements oas.Handler
 func (h *OASHandler) V1GetHello(ctx context.Context, params oas.V1GetHelloParams) (oas.V1GetHelloRes, error) {
-	name := "World"
-	if params.Name.IsSet() {
-		name = params.Name.Value
-		// Example validation: reject if name is "error"
-		if name == "error" {
-			return nil, myerrors.NewInvalidArgument("名前に'error'は使用できません")
-		}
-		// Example server error: reject if name is "panic"
-		if name == "panic" {
-			return nil, myerrors.NewSystemError(
-				"サーバーエラーが発生しました",
-				"panic triggered by test input",
-				nil,
-			)
-		}
+	greeting, err := h.hello.Greet(ctx, usecase.HelloInput{Name: params.Name.Value, NameSet: params.Name.Set})
+	if err != nil {
+		return nil, err
 	}
 
 	response := &oas.HelloResponse{
-		Message:   fmt.Sprintf("Hello, %s!", name),
-		Timestamp: time.Now(),
+		Message:   greeting.Message,
+		Timestamp: greeting.GeneratedAt,
 	}
 
-	logx.FromContext(ctx).Info("v1GetHello called", "name", name, "timestamp", response.Timestamp)
+	logx.FromContext(ctx).Info("v1GetHello called", "name", greeting.Name, "timestamp", response.Timestamp)
 
 	return response, nil
 }
+
+// GetLogLevel implements oas.Handler
+func (h *OASHandler) GetLogLevel(ctx context.Context) (oas.GetLogLevelRes, error) {
+	return &oas.LogLevel{Level: oas.LogLevelLevel(logx.GetLevel().String())}, nil
+}
+
+// PutLogLevel implements oas.Handler
+// 障害調査時などに再起動せずdebugログを有効にするために使う（再起動すると LOG_LEVEL に戻る）
+func (h *OASHandler) PutLogLevel(ctx context.Context, req *oas.LogLevel) (oas.PutLogLevelRes, error) {
+	var level logx.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		return nil, myerrors.NewInvalidArgument("ログレベルが不正です", err)
+	}
+
+	previous := logx.GetLevel()
+	logx.SetLevel(level)
+	// 変更後のレベルに関わらず記録されるようWarnで出力する
+	logx.FromContext(ctx).Warn("log level changed by admin", "from", previous.String(), "to", level.String())
+
+	return &oas.LogLevel{Level: oas.LogLevelLevel(level.String())}, nil
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/kaitoimai/go-sample/rest/internal/oasv2"
+	logx "github.com/kaitoimai/go-sample/rest/internal/pkg/logger"
+	"github.com/kaitoimai/go-sample/rest/internal/usecase"
+)
+
+// V2Handler implements the oasv2.Handler interface
+// /v1 と同じユースケースを使い、レスポンスの形式のみ v2 の仕様書に合わせる
+type V2Handler struct {
+	hello *usecase.HelloUsecase
+}
+
+// NewV2Handler creates a new v2 OAS handler
+func NewV2Handler(hello *usecase.HelloUsecase) *V2Handler { return &V2Handler{hello: hello} }
+
+// V2GetHello implements oasv2.Handler
+func (h *V2Handler) V2GetHello(ctx context.Context, params oasv2.V2GetHelloParams) (oasv2.V2GetHelloRes, error) {
+	greeting, err := h.hello.Greet(ctx, usecase.HelloInput{
+		Name:      params.Name.Value,
+		NameSet:   params.Name.Set,
+		Repeat:    params.Repeat.Value,
+		RepeatSet: params.Repeat.Set,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &oasv2.HelloResponse{
+		Message:     greeting.Message,
+		Name:        greeting.Name,
+		GeneratedAt: greeting.GeneratedAt,
+	}
+
+	logx.FromContext(ctx).Info("v2GetHello called", "name", greeting.Name, "generated_at", response.GeneratedAt)
+
+	return response, nil
+}
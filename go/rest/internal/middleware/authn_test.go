@@ -350,6 +350,31 @@ func TestExtractClaims(t *testing.T) {
 	}
 }
 
+// FuzzExtractClaims checks that malformed tokens are rejected with 401 instead of panicking
+// go test -fuzz FuzzExtractClaims ./internal/middleware/
+func FuzzExtractClaims(f *testing.F) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"user_id":"user123","role":"user","sub":"user123","exp":1700000000}`))
+	f.Add("eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9." + payload + ".signature")
+	f.Add("header.payload")
+	f.Add("header.!!!.signature")
+	f.Add("..")
+	f.Add("a." + base64.RawURLEncoding.EncodeToString([]byte(`{"role":["admin"],"exp":"never","aud":{}}`)) + ".c")
+	f.Add("a." + base64.RawURLEncoding.EncodeToString([]byte(`null`)) + ".c")
+
+	f.Fuzz(func(t *testing.T, token string) {
+		claims, err := extractClaims(token)
+		if err != nil {
+			if status := myerrors.ToHTTPStatus(err); status != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d for error %v", status, http.StatusUnauthorized, err)
+			}
+			return
+		}
+		if claims == nil {
+			t.Fatal("expected claims, got nil")
+		}
+	})
+}
+
 // --- Helper functions ---
 
 // generateTestJWT creates a test JWT token using testutil
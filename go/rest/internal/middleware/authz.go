@@ -10,6 +10,11 @@ import (
 // 新しいエンドポイントを追加する際は、ここに必ずマッピングを追加する
 var authorizeRoleMap = map[string][]string{
 	"v1GetHello": {auth.RoleUser, auth.RoleAdmin}, // user または admin が必要
+	"v2GetHello": {auth.RoleUser, auth.RoleAdmin}, // operationIdはバージョン間で重複させない（v1/v2 のプレフィックスを付ける）
+
+	// 管理API（バージョンを持たない）
+	"getLogLevel": {auth.RoleAdmin},
+	"putLogLevel": {auth.RoleAdmin},
 
 	// 将来的なエンドポイント追加例:
 	// "v1PostItems":   {auth.RoleUser, auth.RoleAdmin},
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"net/http"
+	"sync"
 
 	"github.com/cockroachdb/errors"
 	"github.com/ogen-go/ogen/ogenerrors"
@@ -26,19 +27,36 @@ func ErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, e
 	// 単一の分類ポイントで正規化（status, title, detail, extensions）
 	statusCode, title, detail, rawMessage := classify(err)
 
+	// Accept-Language で選んだ言語に翻訳する
+	locale := requestLocale(r)
+	fieldErrs := myerrors.GetFieldErrors(err)
+	title, detail, fieldErrs = localize(locale, title, detail, fieldErrs)
+
 	// Problem Details: title=要約（ユーザー向け）, detail=詳細（ユーザー向け）
 	pd := buildProblemDetails(r, statusCode, title, detail)
+	defer releaseProblemDetails(pd)
+	// 複数のフィールドをまとめて検証した場合は、フィールドごとのエラーを拡張メンバー errors で返す
+	if len(fieldErrs) > 0 {
+		pd["errors"] = fieldErrs
+	}
 
 	// ログ出力（Problem Detailsと補助情報）
 	log := logger.FromContext(ctx)
-	logErr := make(ProblemDetails, len(pd)+2)
+	if _, ok := logger.CorrelationFromContext(ctx); !ok {
+		// ミドルウェアでエラーになった場合はrequest-scoped loggerが無いため、ヘッダーから相関IDとリクエスト情報を付与する
+		log = log.With(append([]any{logger.NewHTTPRequest(r).Attr()}, logger.CorrelationFromRequest(r).Args()...)...)
+	}
+	// ログのハンドラは同期的に書き出すため、ログ出力後にプールへ戻せる
+	logErr := acquireProblemDetails()
+	defer releaseProblemDetails(logErr)
 	maps.Copy(logErr, pd)
 	if rawMessage != "" {
 		logErr["raw_err"] = rawMessage
 	}
 	logFields := []any{"err", logErr}
 	if statusCode >= http.StatusInternalServerError {
-		logFields = append(logFields, "stack", fmt.Sprintf("%+v", err))
+		// logger.Handler がスタックトレースを先頭の数フレームに整形して出力する
+		logFields = append(logFields, "error", err)
 		log.Error("", logFields...)
 	} else {
 		log.Warn("", logFields...)
@@ -46,6 +64,8 @@ func ErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, e
 
 	// RFC 9457 Problem Details (application/problem+json) で応答
 	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Add("Vary", "Accept-Language")
 	w.WriteHeader(statusCode)
 	if encErr := json.NewEncoder(w).Encode(pd); encErr != nil {
 		log.Error("failed to write error response", "err", encErr)
@@ -55,7 +75,26 @@ func ErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, e
 // ProblemDetails represents RFC 9457 Problem Details.
 type ProblemDetails map[string]any
 
+// problemDetailsPool reuses Problem Details maps across error responses.
+// エラーが多発した場合（認証エラーの連続など）にリクエストごとのマップの割り当てを減らす
+var problemDetailsPool = sync.Pool{
+	New: func() any { return make(ProblemDetails, 8) },
+}
+
+// acquireProblemDetails returns an empty Problem Details map from the pool.
+func acquireProblemDetails() ProblemDetails {
+	return problemDetailsPool.Get().(ProblemDetails)
+}
+
+// releaseProblemDetails clears pd and returns it to the pool.
+// 応答の書き込みとログ出力が終わった後に呼ぶこと（以降 pd を参照してはならない）
+func releaseProblemDetails(pd ProblemDetails) {
+	clear(pd)
+	problemDetailsPool.Put(pd)
+}
+
 // buildProblemDetails builds a RFC 9457 Problem Details payload.
+// 返したマップはプールから取得したもので、使用後は releaseProblemDetails で戻す
 // Standard members: type, title(要約/ユーザー向け), status, detail(詳細/ユーザー向け), instance
 func buildProblemDetails(r *http.Request, status int, title string, detail string) ProblemDetails {
 	if title == "" {
@@ -64,12 +103,11 @@ func buildProblemDetails(r *http.Request, status int, title string, detail strin
 	if detail == "" || detail == "An unexpected error occurred" {
 		detail = title
 	}
-	pd := ProblemDetails{
-		"type":   "about:blank",
-		"title":  title,
-		"status": status,
-		"detail": detail,
-	}
+	pd := acquireProblemDetails()
+	pd["type"] = "about:blank"
+	pd["title"] = title
+	pd["status"] = status
+	pd["detail"] = detail
 	if r != nil && r.URL != nil {
 		pd["instance"] = r.URL.Path
 	}
@@ -97,6 +135,36 @@ func classify(err error) (status int, title string, detail string, rawMessage st
 	return status, title, detail, rawMessage
 }
 
+// requestLocale returns the locale negotiated from the Accept-Language header of r.
+func requestLocale(r *http.Request) myerrors.Locale {
+	if r == nil {
+		return myerrors.DefaultLocale
+	}
+	return myerrors.NegotiateLocale(r.Header.Get("Accept-Language"))
+}
+
+// localize translates the client-facing messages of an error response to locale.
+// フィールドエラーは元のエラーが保持するスライスを変更しないよう、コピーして翻訳する
+func localize(locale myerrors.Locale, title, detail string, fieldErrs []myerrors.FieldError) (string, string, []myerrors.FieldError) {
+	title = myerrors.Localize(locale, title)
+	if len(fieldErrs) > 1 {
+		// 複数件の detail は件数を含むため、翻訳ではなく組み立て直す
+		detail = myerrors.InvalidFieldsMessage(locale, len(fieldErrs))
+	} else {
+		detail = myerrors.Localize(locale, detail)
+	}
+
+	if len(fieldErrs) > 0 {
+		localized := make([]myerrors.FieldError, len(fieldErrs))
+		for i, fe := range fieldErrs {
+			fe.Message = myerrors.Localize(locale, fe.Message)
+			localized[i] = fe
+		}
+		fieldErrs = localized
+	}
+	return title, detail, fieldErrs
+}
+
 // ConvertOgenError converts ogen-specific errors to myerrors types
 func ConvertOgenError(err error) error {
 	if err == nil {
@@ -119,6 +187,7 @@ func ConvertOgenError(err error) error {
 		decParamsErr *ogenerrors.DecodeParamsError
 		decParamErr  *ogenerrors.DecodeParamError
 		decBodyErr   *ogenerrors.DecodeBodyError
+		decReqErr    *ogenerrors.DecodeRequestError
 	)
 	switch {
 	case errors.As(err, &decParamsErr):
@@ -130,6 +199,10 @@ func ConvertOgenError(err error) error {
 	case errors.As(err, &decBodyErr):
 		code, rawMsg := mapOgenBodyError(decBodyErr)
 		return myerrors.NewInvalidArgumentWithCode(code, rawMsg)
+	case errors.As(err, &decReqErr):
+		// デコード後のリクエストボディの検証エラー（enum の範囲外など）
+		rawMsg := fmt.Sprintf("invalid request for operation %s: %s", decReqErr.Name, innerMessage(decReqErr.Err))
+		return myerrors.NewInvalidArgumentWithCode(myerrors.ValidationBodyInvalidFormat, rawMsg)
 	}
 
 	// Default to wrapping with system error
@@ -138,10 +211,9 @@ func ConvertOgenError(err error) error {
 
 // mapOgenParamsError maps DecodeParamsError to validation code and raw message
 func mapOgenParamsError(err *ogenerrors.DecodeParamsError) (myerrors.ValidationErrorCode, string) {
-	rawMsg := fmt.Sprintf("invalid parameters for operation %s: %s", err.Name, err.Err.Error())
-
 	// 内部エラーメッセージを解析してフィールド名とエラー内容を特定
-	innerMsg := err.Err.Error()
+	innerMsg := innerMessage(err.Err)
+	rawMsg := fmt.Sprintf("invalid parameters for operation %s: %s", err.Name, innerMsg)
 
 	// "query: \"name\": ..." のパターンをチェック
 	if contains(innerMsg, "query: \"name\"") {
@@ -161,10 +233,9 @@ func mapOgenParamsError(err *ogenerrors.DecodeParamsError) (myerrors.ValidationE
 
 // mapOgenParamError maps DecodeParamError to validation code and raw message
 func mapOgenParamError(err *ogenerrors.DecodeParamError) (myerrors.ValidationErrorCode, string) {
-	rawMsg := fmt.Sprintf("invalid parameter: %s (%s): %s", err.Name, err.In, err.Err.Error())
-
 	// フィールド名とエラー内容から適切なコードを判定
-	errMsg := err.Err.Error()
+	errMsg := innerMessage(err.Err)
+	rawMsg := fmt.Sprintf("invalid parameter: %s (%s): %s", err.Name, err.In, errMsg)
 	paramName := err.Name
 
 	if paramName == "name" {
@@ -189,8 +260,8 @@ func mapOgenParamError(err *ogenerrors.DecodeParamError) (myerrors.ValidationErr
 
 // mapOgenBodyError maps DecodeBodyError to validation code and raw message
 func mapOgenBodyError(err *ogenerrors.DecodeBodyError) (myerrors.ValidationErrorCode, string) {
-	rawMsg := fmt.Sprintf("invalid request body: %s", err.Err.Error())
-	errMsg := err.Err.Error()
+	errMsg := innerMessage(err.Err)
+	rawMsg := fmt.Sprintf("invalid request body: %s", errMsg)
 
 	if contains(errMsg, "required") {
 		return myerrors.ValidationBodyRequired, rawMsg
@@ -198,6 +269,15 @@ func mapOgenBodyError(err *ogenerrors.DecodeBodyError) (myerrors.ValidationError
 	return myerrors.ValidationBodyInvalidFormat, rawMsg
 }
 
+// innerMessage returns the message of the error wrapped by an ogen error.
+// ogen のエラーは内部エラーが nil の場合があるため、その場合は空文字を返す
+func innerMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
 // contains checks if a string contains a substring
 func contains(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
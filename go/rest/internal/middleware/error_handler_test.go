@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -156,6 +158,24 @@ func TestConvertOgenError_DecodeBodyError(t *testing.T) {
 	}
 }
 
+// TestConvertOgenError_DecodeRequestError tests DecodeRequestError conversion
+func TestConvertOgenError_DecodeRequestError(t *testing.T) {
+	decReqErr := &ogenerrors.DecodeRequestError{
+		Err: fmt.Errorf("validate: invalid: level (invalid value: VERBOSE)"),
+	}
+
+	result := ConvertOgenError(decReqErr)
+
+	var invalidArg *myerrors.InvalidArgumentError
+	if !errors.As(result, &invalidArg) {
+		t.Fatalf("expected InvalidArgumentError, got %T", result)
+	}
+
+	if invalidArg.ValidationCode() != myerrors.ValidationBodyInvalidFormat {
+		t.Errorf("expected code ValidationBodyInvalidFormat, got %s", invalidArg.ValidationCode())
+	}
+}
+
 // TestConvertOgenError_UnknownError tests that unknown errors are wrapped with stack
 func TestConvertOgenError_UnknownError(t *testing.T) {
 	originalErr := fmt.Errorf("unknown error")
@@ -411,6 +431,130 @@ func TestErrorHandler_InvalidArgumentError(t *testing.T) {
 	// In a real scenario, you would use a custom slog.Handler to capture log entries
 }
 
+// TestErrorHandler_FieldErrors tests that aggregated field errors are returned in the errors member
+func TestErrorHandler_FieldErrors(t *testing.T) {
+	log := logger.New(logger.LevelWarn)
+	ctx := logger.NewContext(context.Background(), log)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/hello?name=error&repeat=0", nil)
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	var v myerrors.Validator
+	v.Add("name", myerrors.ValidationNameReserved)
+	v.Add("repeat", myerrors.ValidationRepeatOutOfRange)
+
+	ErrorHandler(ctx, w, req, v.Err())
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+
+	var respPD struct {
+		Detail string                `json:"detail"`
+		Errors []myerrors.FieldError `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&respPD); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if respPD.Detail != "2件の入力内容に誤りがあります" {
+		t.Errorf("expected detail '2件の入力内容に誤りがあります', got %v", respPD.Detail)
+	}
+	want := []myerrors.FieldError{
+		{Field: "name", Code: myerrors.ValidationNameReserved, Message: "名前に'error'は使用できません"},
+		{Field: "repeat", Code: myerrors.ValidationRepeatOutOfRange, Message: "繰り返し回数は1〜5で指定してください"},
+	}
+	if len(respPD.Errors) != len(want) {
+		t.Fatalf("expected errors %+v, got %+v", want, respPD.Errors)
+	}
+	for i := range want {
+		if respPD.Errors[i] != want[i] {
+			t.Errorf("errors[%d]: expected %+v, got %+v", i, want[i], respPD.Errors[i])
+		}
+	}
+}
+
+// TestErrorHandler_Localized tests that ErrorHandler responds in the language negotiated from Accept-Language
+func TestErrorHandler_Localized(t *testing.T) {
+	log := logger.New(logger.LevelWarn)
+	ctx := logger.NewContext(context.Background(), log)
+
+	var v myerrors.Validator
+	v.Add("name", myerrors.ValidationNameReserved)
+	v.Add("repeat", myerrors.ValidationRepeatOutOfRange)
+	err := v.Err()
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantLanguage   string
+		wantTitle      string
+		wantDetail     string
+		wantMessages   []string
+	}{
+		{
+			name:           "英語",
+			acceptLanguage: "en-US,en;q=0.9,ja;q=0.8",
+			wantLanguage:   "en",
+			wantTitle:      "The request contains invalid input",
+			wantDetail:     "2 fields contain invalid input",
+			wantMessages:   []string{"Name must not be 'error'", "Repeat must be between 1 and 5"},
+		},
+		{
+			name:         "未指定は日本語",
+			wantLanguage: "ja",
+			wantTitle:    "入力内容に誤りがあります",
+			wantDetail:   "2件の入力内容に誤りがあります",
+			wantMessages: []string{"名前に'error'は使用できません", "繰り返し回数は1〜5で指定してください"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v2/hello?name=error&repeat=0", nil)
+			req = req.WithContext(ctx)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			ErrorHandler(ctx, w, req, err)
+
+			if got := w.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+			var respPD struct {
+				Title  string                `json:"title"`
+				Detail string                `json:"detail"`
+				Errors []myerrors.FieldError `json:"errors"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&respPD); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if respPD.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", respPD.Title, tt.wantTitle)
+			}
+			if respPD.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", respPD.Detail, tt.wantDetail)
+			}
+			if len(respPD.Errors) != len(tt.wantMessages) {
+				t.Fatalf("errors = %+v, want %d entries", respPD.Errors, len(tt.wantMessages))
+			}
+			for i, want := range tt.wantMessages {
+				if respPD.Errors[i].Message != want {
+					t.Errorf("errors[%d].message = %q, want %q", i, respPD.Errors[i].Message, want)
+				}
+			}
+		})
+	}
+
+	// 翻訳しても元のエラーのフィールドエラーは変更しない
+	if got := myerrors.GetFieldErrors(err)[0].Message; got != "名前に'error'は使用できません" {
+		t.Errorf("original field error message = %q, want it unchanged", got)
+	}
+}
+
 // TestErrorHandler_SystemError tests ErrorHandler with SystemError
 func TestErrorHandler_SystemError(t *testing.T) {
 	ctx := logger.NewContext(context.Background(), logger.New(logger.LevelError))
@@ -504,6 +648,120 @@ func TestErrorHandler_NilError(t *testing.T) {
 	}
 }
 
+// BenchmarkErrorHandler measures allocations per error response.
+// go test -bench ErrorHandler -benchmem -memprofile mem.out ./internal/middleware/
+func BenchmarkErrorHandler(b *testing.B) {
+	ctx := logger.NewContext(context.Background(), slog.New(slog.DiscardHandler))
+	req := httptest.NewRequest(http.MethodGet, "/v1/hello?name=toolongname", nil)
+	req = req.WithContext(ctx)
+	err := myerrors.NewInvalidArgumentWithCode(myerrors.ValidationNameTooLong, "query: \"name\": string: len 101 greater than maximum 100")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		ErrorHandler(ctx, discardResponseWriter{}, req, err)
+	}
+}
+
+// discardResponseWriter discards the response so that the benchmark measures only ErrorHandler.
+type discardResponseWriter struct{}
+
+var discardHeader = http.Header{}
+
+func (discardResponseWriter) Header() http.Header         { return discardHeader }
+func (discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardResponseWriter) WriteHeader(int)             {}
+
+// FuzzConvertOgenError checks that ogen errors with arbitrary names and messages are converted without panicking
+// go test -fuzz FuzzConvertOgenError ./internal/middleware/
+func FuzzConvertOgenError(f *testing.F) {
+	f.Add(uint8(0), "bearer", "missing authorization header", false)
+	f.Add(uint8(1), "name", "string: len 101 greater than maximum 100", false)
+	f.Add(uint8(2), "v1GetHello", "query: \"name\": required", false)
+	f.Add(uint8(3), "", "unexpected EOF", false)
+	f.Add(uint8(4), "", "", true)
+
+	f.Fuzz(func(t *testing.T, kind uint8, name, msg string, nilInner bool) {
+		var inner error
+		if !nilInner {
+			inner = errors.New(msg)
+		}
+
+		var err error
+		switch kind % 5 {
+		case 0:
+			err = &ogenerrors.SecurityError{Security: name, Err: inner}
+		case 1:
+			err = &ogenerrors.DecodeParamError{Name: name, In: "query", Err: inner}
+		case 2:
+			err = &ogenerrors.DecodeParamsError{OperationContext: ogenerrors.OperationContext{Name: name}, Err: inner}
+		case 3:
+			err = &ogenerrors.DecodeBodyError{ContentType: name, Body: []byte(msg), Err: inner}
+		default:
+			err = errors.Wrap(errors.New(msg), name)
+		}
+
+		converted := ConvertOgenError(err)
+		if converted == nil {
+			t.Fatal("expected error, got nil")
+		}
+		switch status := myerrors.ToHTTPStatus(converted); status {
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError:
+		default:
+			t.Errorf("unexpected status %d for %v", status, converted)
+		}
+	})
+}
+
+// FuzzErrorHandler checks that pathological messages and paths always produce a valid Problem Details response
+// go test -fuzz FuzzErrorHandler ./internal/middleware/
+func FuzzErrorHandler(f *testing.F) {
+	f.Add(uint8(0), "/v1/hello", "名前は1文字以上で入力してください")
+	f.Add(uint8(1), "/v2/hello", "database connection failed: timeout")
+	f.Add(uint8(2), "", "\xff\xfe")
+	f.Add(uint8(3), "/%00", "</script>")
+
+	ctx := logger.NewContext(context.Background(), slog.New(slog.DiscardHandler))
+
+	f.Fuzz(func(t *testing.T, kind uint8, path, msg string) {
+		var err error
+		switch kind % 4 {
+		case 0:
+			err = myerrors.NewInvalidArgumentWithCode(myerrors.ValidationErrorCode(msg), msg)
+		case 1:
+			err = myerrors.NewSystemError(msg, msg, errors.New(msg))
+		case 2:
+			err = myerrors.NewInvalidFields([]myerrors.FieldError{
+				{Field: msg, Code: myerrors.ValidationParameterInvalid, Message: msg},
+				{Field: path, Code: myerrors.ValidationErrorCode(msg), Message: path},
+			})
+		default:
+			err = &ogenerrors.DecodeParamError{Name: msg, In: "query", Err: errors.New(path)}
+		}
+
+		// httptest.NewRequest は不正なパスで panic するため直接組み立てる
+		req := (&http.Request{Method: http.MethodGet, URL: &url.URL{Path: path}}).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		ErrorHandler(ctx, w, req, err)
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("Content-Type = %q, want application/problem+json", ct)
+		}
+		var respPD ProblemDetails
+		if err := json.Unmarshal(w.Body.Bytes(), &respPD); err != nil {
+			t.Fatalf("invalid Problem Details %q: %v", w.Body.String(), err)
+		}
+		if status, _ := respPD["status"].(float64); int(status) != w.Code {
+			t.Errorf("status member = %v, want %d", respPD["status"], w.Code)
+		}
+		for _, member := range []string{"type", "title", "detail"} {
+			if v, _ := respPD[member].(string); v == "" {
+				t.Errorf("%s is empty in %v", member, respPD)
+			}
+		}
+	})
+}
+
 // TestContains tests the contains helper function
 func TestContains(t *testing.T) {
 	tests := []struct {
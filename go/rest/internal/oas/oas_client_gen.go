@@ -34,12 +34,24 @@ type Invoker interface {
 	//
 	// GET /healthz
 	GetHealth(ctx context.Context) (GetHealthOK, error)
+	// GetLogLevel invokes getLogLevel operation.
+	//
+	// Current log level.
+	//
+	// GET /admin/log-level
+	GetLogLevel(ctx context.Context) (GetLogLevelRes, error)
 	// GetRoot invokes getRoot operation.
 	//
 	// Root endpoint.
 	//
 	// GET /
 	GetRoot(ctx context.Context) (GetRootOK, error)
+	// PutLogLevel invokes putLogLevel operation.
+	//
+	// Change the log level without restart.
+	//
+	// PUT /admin/log-level
+	PutLogLevel(ctx context.Context, request *LogLevel) (PutLogLevelRes, error)
 	// V1GetHello invokes v1GetHello operation.
 	//
 	// Sample endpoint with structured response.
@@ -163,6 +175,78 @@ func (c *Client) sendGetHealth(ctx context.Context) (res GetHealthOK, err error)
 	return result, nil
 }
 
+// GetLogLevel invokes getLogLevel operation.
+//
+// Current log level.
+//
+// GET /admin/log-level
+func (c *Client) GetLogLevel(ctx context.Context) (GetLogLevelRes, error) {
+	res, err := c.sendGetLogLevel(ctx)
+	return res, err
+}
+
+func (c *Client) sendGetLogLevel(ctx context.Context) (res GetLogLevelRes, err error) {
+	otelAttrs := []attribute.KeyValue{
+		otelogen.OperationID("getLogLevel"),
+		semconv.HTTPRequestMethodKey.String("GET"),
+		semconv.HTTPRouteKey.String("/admin/log-level"),
+	}
+
+	// Run stopwatch.
+	startTime := time.Now()
+	defer func() {
+		// Use floating point division here for higher precision (instead of Millisecond method).
+		elapsedDuration := time.Since(startTime)
+		c.duration.Record(ctx, float64(elapsedDuration)/float64(time.Millisecond), metric.WithAttributes(otelAttrs...))
+	}()
+
+	// Increment request counter.
+	c.requests.Add(ctx, 1, metric.WithAttributes(otelAttrs...))
+
+	// Start a span for this request.
+	ctx, span := c.cfg.Tracer.Start(ctx, GetLogLevelOperation,
+		trace.WithAttributes(otelAttrs...),
+		clientSpanKind,
+	)
+	// Track stage for error reporting.
+	var stage string
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, stage)
+			c.errors.Add(ctx, 1, metric.WithAttributes(otelAttrs...))
+		}
+		span.End()
+	}()
+
+	stage = "BuildURL"
+	u := uri.Clone(c.requestURL(ctx))
+	var pathParts [1]string
+	pathParts[0] = "/admin/log-level"
+	uri.AddPathParts(u, pathParts[:]...)
+
+	stage = "EncodeRequest"
+	r, err := ht.NewRequest(ctx, "GET", u)
+	if err != nil {
+		return res, errors.Wrap(err, "create request")
+	}
+
+	stage = "SendRequest"
+	resp, err := c.cfg.Client.Do(r)
+	if err != nil {
+		return res, errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+
+	stage = "DecodeResponse"
+	result, err := decodeGetLogLevelResponse(resp)
+	if err != nil {
+		return res, errors.Wrap(err, "decode response")
+	}
+
+	return result, nil
+}
+
 // GetRoot invokes getRoot operation.
 //
 // Root endpoint.
@@ -235,6 +319,81 @@ func (c *Client) sendGetRoot(ctx context.Context) (res GetRootOK, err error) {
 	return result, nil
 }
 
+// PutLogLevel invokes putLogLevel operation.
+//
+// Change the log level without restart.
+//
+// PUT /admin/log-level
+func (c *Client) PutLogLevel(ctx context.Context, request *LogLevel) (PutLogLevelRes, error) {
+	res, err := c.sendPutLogLevel(ctx, request)
+	return res, err
+}
+
+func (c *Client) sendPutLogLevel(ctx context.Context, request *LogLevel) (res PutLogLevelRes, err error) {
+	otelAttrs := []attribute.KeyValue{
+		otelogen.OperationID("putLogLevel"),
+		semconv.HTTPRequestMethodKey.String("PUT"),
+		semconv.HTTPRouteKey.String("/admin/log-level"),
+	}
+
+	// Run stopwatch.
+	startTime := time.Now()
+	defer func() {
+		// Use floating point division here for higher precision (instead of Millisecond method).
+		elapsedDuration := time.Since(startTime)
+		c.duration.Record(ctx, float64(elapsedDuration)/float64(time.Millisecond), metric.WithAttributes(otelAttrs...))
+	}()
+
+	// Increment request counter.
+	c.requests.Add(ctx, 1, metric.WithAttributes(otelAttrs...))
+
+	// Start a span for this request.
+	ctx, span := c.cfg.Tracer.Start(ctx, PutLogLevelOperation,
+		trace.WithAttributes(otelAttrs...),
+		clientSpanKind,
+	)
+	// Track stage for error reporting.
+	var stage string
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, stage)
+			c.errors.Add(ctx, 1, metric.WithAttributes(otelAttrs...))
+		}
+		span.End()
+	}()
+
+	stage = "BuildURL"
+	u := uri.Clone(c.requestURL(ctx))
+	var pathParts [1]string
+	pathParts[0] = "/admin/log-level"
+	uri.AddPathParts(u, pathParts[:]...)
+
+	stage = "EncodeRequest"
+	r, err := ht.NewRequest(ctx, "PUT", u)
+	if err != nil {
+		return res, errors.Wrap(err, "create request")
+	}
+	if err := encodePutLogLevelRequest(request, r); err != nil {
+		return res, errors.Wrap(err, "encode request")
+	}
+
+	stage = "SendRequest"
+	resp, err := c.cfg.Client.Do(r)
+	if err != nil {
+		return res, errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+
+	stage = "DecodeResponse"
+	result, err := decodePutLogLevelResponse(resp)
+	if err != nil {
+		return res, errors.Wrap(err, "decode response")
+	}
+
+	return result, nil
+}
+
 // V1GetHello invokes v1GetHello operation.
 //
 // Sample endpoint with structured response.
@@ -1,6 +1,14 @@
 // Code generated by ogen, DO NOT EDIT.
 package oas
 
+type GetLogLevelRes interface {
+	getLogLevelRes()
+}
+
+type PutLogLevelRes interface {
+	putLogLevelRes()
+}
+
 type V1GetHelloRes interface {
 	v1GetHelloRes()
 }
@@ -6,7 +6,9 @@ package oas
 type OperationName = string
 
 const (
-	GetHealthOperation  OperationName = "GetHealth"
-	GetRootOperation    OperationName = "GetRoot"
-	V1GetHelloOperation OperationName = "V1GetHello"
+	GetHealthOperation   OperationName = "GetHealth"
+	GetLogLevelOperation OperationName = "GetLogLevel"
+	GetRootOperation     OperationName = "GetRoot"
+	PutLogLevelOperation OperationName = "PutLogLevel"
+	V1GetHelloOperation  OperationName = "V1GetHello"
 )
@@ -67,6 +67,28 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			switch elem[0] {
+			case 'a': // Prefix: "admin/log-level"
+
+				if l := len("admin/log-level"); len(elem) >= l && elem[0:l] == "admin/log-level" {
+					elem = elem[l:]
+				} else {
+					break
+				}
+
+				if len(elem) == 0 {
+					// Leaf node.
+					switch r.Method {
+					case "GET":
+						s.handleGetLogLevelRequest([0]string{}, elemIsEscaped, w, r)
+					case "PUT":
+						s.handlePutLogLevelRequest([0]string{}, elemIsEscaped, w, r)
+					default:
+						s.notAllowed(w, r, "GET,PUT")
+					}
+
+					return
+				}
+
 			case 'h': // Prefix: "healthz"
 
 				if l := len("healthz"); len(elem) >= l && elem[0:l] == "healthz" {
@@ -212,6 +234,38 @@ func (s *Server) FindPath(method string, u *url.URL) (r Route, _ bool) {
 				}
 			}
 			switch elem[0] {
+			case 'a': // Prefix: "admin/log-level"
+
+				if l := len("admin/log-level"); len(elem) >= l && elem[0:l] == "admin/log-level" {
+					elem = elem[l:]
+				} else {
+					break
+				}
+
+				if len(elem) == 0 {
+					// Leaf node.
+					switch method {
+					case "GET":
+						r.name = GetLogLevelOperation
+						r.summary = "Current log level"
+						r.operationID = "getLogLevel"
+						r.pathPattern = "/admin/log-level"
+						r.args = args
+						r.count = 0
+						return r, true
+					case "PUT":
+						r.name = PutLogLevelOperation
+						r.summary = "Change the log level without restart"
+						r.operationID = "putLogLevel"
+						r.pathPattern = "/admin/log-level"
+						r.args = args
+						r.count = 0
+						return r, true
+					default:
+						return
+					}
+				}
+
 			case 'h': // Prefix: "healthz"
 
 				if l := len("healthz"); len(elem) >= l && elem[0:l] == "healthz" {
@@ -6,8 +6,50 @@ import (
 	"io"
 	"net/url"
 	"time"
+
+	"github.com/go-faster/errors"
 )
 
+// Ref: #/components/schemas/FieldError
+type FieldError struct {
+	// エラーのあるフィールド名（クエリパラメータ名やボディのプロパティ名）.
+	Field string `json:"field"`
+	// 入力エラーの種類を識別するコード.
+	Code string `json:"code"`
+	// 人間が読むことのできるエラー内容（ユーザー/クライアント向け）.
+	Message string `json:"message"`
+}
+
+// GetField returns the value of Field.
+func (s *FieldError) GetField() string {
+	return s.Field
+}
+
+// GetCode returns the value of Code.
+func (s *FieldError) GetCode() string {
+	return s.Code
+}
+
+// GetMessage returns the value of Message.
+func (s *FieldError) GetMessage() string {
+	return s.Message
+}
+
+// SetField sets the value of Field.
+func (s *FieldError) SetField(val string) {
+	s.Field = val
+}
+
+// SetCode sets the value of Code.
+func (s *FieldError) SetCode(val string) {
+	s.Code = val
+}
+
+// SetMessage sets the value of Message.
+func (s *FieldError) SetMessage(val string) {
+	s.Message = val
+}
+
 type GetHealthOK struct {
 	Data io.Reader
 }
@@ -22,6 +64,14 @@ func (s GetHealthOK) Read(p []byte) (n int, err error) {
 	return s.Data.Read(p)
 }
 
+type GetLogLevelForbidden ProblemDetails
+
+func (*GetLogLevelForbidden) getLogLevelRes() {}
+
+type GetLogLevelUnauthorized ProblemDetails
+
+func (*GetLogLevelUnauthorized) getLogLevelRes() {}
+
 type GetRootOK struct {
 	Data io.Reader
 }
@@ -64,6 +114,81 @@ func (s *HelloResponse) SetTimestamp(val time.Time) {
 
 func (*HelloResponse) v1GetHelloRes() {}
 
+// Ref: #/components/schemas/LogLevel
+type LogLevel struct {
+	// ログレベル（LOG_LEVEL と同じ値）.
+	Level LogLevelLevel `json:"level"`
+}
+
+// GetLevel returns the value of Level.
+func (s *LogLevel) GetLevel() LogLevelLevel {
+	return s.Level
+}
+
+// SetLevel sets the value of Level.
+func (s *LogLevel) SetLevel(val LogLevelLevel) {
+	s.Level = val
+}
+
+func (*LogLevel) getLogLevelRes() {}
+func (*LogLevel) putLogLevelRes() {}
+
+// ログレベル（LOG_LEVEL と同じ値）.
+type LogLevelLevel string
+
+const (
+	LogLevelLevelDEBUG LogLevelLevel = "DEBUG"
+	LogLevelLevelINFO  LogLevelLevel = "INFO"
+	LogLevelLevelWARN  LogLevelLevel = "WARN"
+	LogLevelLevelERROR LogLevelLevel = "ERROR"
+)
+
+// AllValues returns all LogLevelLevel values.
+func (LogLevelLevel) AllValues() []LogLevelLevel {
+	return []LogLevelLevel{
+		LogLevelLevelDEBUG,
+		LogLevelLevelINFO,
+		LogLevelLevelWARN,
+		LogLevelLevelERROR,
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s LogLevelLevel) MarshalText() ([]byte, error) {
+	switch s {
+	case LogLevelLevelDEBUG:
+		return []byte(s), nil
+	case LogLevelLevelINFO:
+		return []byte(s), nil
+	case LogLevelLevelWARN:
+		return []byte(s), nil
+	case LogLevelLevelERROR:
+		return []byte(s), nil
+	default:
+		return nil, errors.Errorf("invalid value: %q", s)
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *LogLevelLevel) UnmarshalText(data []byte) error {
+	switch LogLevelLevel(data) {
+	case LogLevelLevelDEBUG:
+		*s = LogLevelLevelDEBUG
+		return nil
+	case LogLevelLevelINFO:
+		*s = LogLevelLevelINFO
+		return nil
+	case LogLevelLevelWARN:
+		*s = LogLevelLevelWARN
+		return nil
+	case LogLevelLevelERROR:
+		*s = LogLevelLevelERROR
+		return nil
+	default:
+		return errors.Errorf("invalid value: %q", data)
+	}
+}
+
 // NewOptString returns new OptString with value set to v.
 func NewOptString(v string) OptString {
 	return OptString{
@@ -128,6 +253,8 @@ type ProblemDetails struct {
 	// 用途としては、自社/自組織で管理しているエラー詳細ドキュメントへのURLなどを指定する
 	// - デフォルトはabout:blank.
 	Type url.URL `json:"type"`
+	// フィールドごとの入力エラー（複数のフィールドをまとめて検証した場合のみ）.
+	Errors []FieldError `json:"errors"`
 }
 
 // GetStatus returns the value of Status.
@@ -155,6 +282,11 @@ func (s *ProblemDetails) GetType() url.URL {
 	return s.Type
 }
 
+// GetErrors returns the value of Errors.
+func (s *ProblemDetails) GetErrors() []FieldError {
+	return s.Errors
+}
+
 // SetStatus sets the value of Status.
 func (s *ProblemDetails) SetStatus(val int32) {
 	s.Status = val
@@ -180,6 +312,23 @@ func (s *ProblemDetails) SetType(val url.URL) {
 	s.Type = val
 }
 
+// SetErrors sets the value of Errors.
+func (s *ProblemDetails) SetErrors(val []FieldError) {
+	s.Errors = val
+}
+
+type PutLogLevelBadRequest ProblemDetails
+
+func (*PutLogLevelBadRequest) putLogLevelRes() {}
+
+type PutLogLevelForbidden ProblemDetails
+
+func (*PutLogLevelForbidden) putLogLevelRes() {}
+
+type PutLogLevelUnauthorized ProblemDetails
+
+func (*PutLogLevelUnauthorized) putLogLevelRes() {}
+
 type V1GetHelloBadRequest ProblemDetails
 
 func (*V1GetHelloBadRequest) v1GetHelloRes() {}
@@ -14,12 +14,24 @@ type Handler interface {
 	//
 	// GET /healthz
 	GetHealth(ctx context.Context) (GetHealthOK, error)
+	// GetLogLevel implements getLogLevel operation.
+	//
+	// Current log level.
+	//
+	// GET /admin/log-level
+	GetLogLevel(ctx context.Context) (GetLogLevelRes, error)
 	// GetRoot implements getRoot operation.
 	//
 	// Root endpoint.
 	//
 	// GET /
 	GetRoot(ctx context.Context) (GetRootOK, error)
+	// PutLogLevel implements putLogLevel operation.
+	//
+	// Change the log level without restart.
+	//
+	// PUT /admin/log-level
+	PutLogLevel(ctx context.Context, req *LogLevel) (PutLogLevelRes, error)
 	// V1GetHello implements v1GetHello operation.
 	//
 	// Sample endpoint with structured response.
@@ -22,6 +22,15 @@ func (UnimplementedHandler) GetHealth(ctx context.Context) (r GetHealthOK, _ err
 	return r, ht.ErrNotImplemented
 }
 
+// GetLogLevel implements getLogLevel operation.
+//
+// Current log level.
+//
+// GET /admin/log-level
+func (UnimplementedHandler) GetLogLevel(ctx context.Context) (r GetLogLevelRes, _ error) {
+	return r, ht.ErrNotImplemented
+}
+
 // GetRoot implements getRoot operation.
 //
 // Root endpoint.
@@ -31,6 +40,15 @@ func (UnimplementedHandler) GetRoot(ctx context.Context) (r GetRootOK, _ error)
 	return r, ht.ErrNotImplemented
 }
 
+// PutLogLevel implements putLogLevel operation.
+//
+// Change the log level without restart.
+//
+// PUT /admin/log-level
+func (UnimplementedHandler) PutLogLevel(ctx context.Context, req *LogLevel) (r PutLogLevelRes, _ error) {
+	return r, ht.ErrNotImplemented
+}
+
 // V1GetHello implements v1GetHello operation.
 //
 // Sample endpoint with structured response.
@@ -0,0 +1,129 @@
+// Code generated by ogen, DO NOT EDIT.
+
+package oasv2
+
+import (
+	"net/http"
+
+	"github.com/ogen-go/ogen/conv"
+	"github.com/ogen-go/ogen/middleware"
+	"github.com/ogen-go/ogen/ogenerrors"
+	"github.com/ogen-go/ogen/uri"
+)
+
+// V2GetHelloParams is parameters of v2GetHello operation.
+type V2GetHelloParams struct {
+	// 挨拶する名前（1〜100文字）.
+	Name OptString
+	// 挨拶を繰り返す回数（1〜5、デフォルト1）.
+	Repeat OptInt
+}
+
+func unpackV2GetHelloParams(packed middleware.Parameters) (params V2GetHelloParams) {
+	{
+		key := middleware.ParameterKey{
+			Name: "name",
+			In:   "query",
+		}
+		if v, ok := packed[key]; ok {
+			params.Name = v.(OptString)
+		}
+	}
+	{
+		key := middleware.ParameterKey{
+			Name: "repeat",
+			In:   "query",
+		}
+		if v, ok := packed[key]; ok {
+			params.Repeat = v.(OptInt)
+		}
+	}
+	return params
+}
+
+func decodeV2GetHelloParams(args [0]string, argsEscaped bool, r *http.Request) (params V2GetHelloParams, _ error) {
+	q := uri.NewQueryDecoder(r.URL.Query())
+	// Decode query: name.
+	if err := func() error {
+		cfg := uri.QueryParameterDecodingConfig{
+			Name:    "name",
+			Style:   uri.QueryStyleForm,
+			Explode: true,
+		}
+
+		if err := q.HasParam(cfg); err == nil {
+			if err := q.DecodeParam(cfg, func(d uri.Decoder) error {
+				var paramsDotNameVal string
+				if err := func() error {
+					val, err := d.DecodeValue()
+					if err != nil {
+						return err
+					}
+
+					c, err := conv.ToString(val)
+					if err != nil {
+						return err
+					}
+
+					paramsDotNameVal = c
+					return nil
+				}(); err != nil {
+					return err
+				}
+				params.Name.SetTo(paramsDotNameVal)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}(); err != nil {
+		return params, &ogenerrors.DecodeParamError{
+			Name: "name",
+			In:   "query",
+			Err:  err,
+		}
+	}
+	// Decode query: repeat.
+	if err := func() error {
+		cfg := uri.QueryParameterDecodingConfig{
+			Name:    "repeat",
+			Style:   uri.QueryStyleForm,
+			Explode: true,
+		}
+
+		if err := q.HasParam(cfg); err == nil {
+			if err := q.DecodeParam(cfg, func(d uri.Decoder) error {
+				var paramsDotRepeatVal int
+				if err := func() error {
+					val, err := d.DecodeValue()
+					if err != nil {
+						return err
+					}
+
+					c, err := conv.ToInt(val)
+					if err != nil {
+						return err
+					}
+
+					paramsDotRepeatVal = c
+					return nil
+				}(); err != nil {
+					return err
+				}
+				params.Repeat.SetTo(paramsDotRepeatVal)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}(); err != nil {
+		return params, &ogenerrors.DecodeParamError{
+			Name: "repeat",
+			In:   "query",
+			Err:  err,
+		}
+	}
+	return params, nil
+}
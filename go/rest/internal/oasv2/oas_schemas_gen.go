@@ -0,0 +1,277 @@
+// Code generated by ogen, DO NOT EDIT.
+
+package oasv2
+
+import (
+	"net/url"
+	"time"
+)
+
+// Ref: #/components/schemas/FieldError
+type FieldError struct {
+	// エラーのあるフィールド名（クエリパラメータ名やボディのプロパティ名）.
+	Field string `json:"field"`
+	// 入力エラーの種類を識別するコード.
+	Code string `json:"code"`
+	// 人間が読むことのできるエラー内容（ユーザー/クライアント向け）.
+	Message string `json:"message"`
+}
+
+// GetField returns the value of Field.
+func (s *FieldError) GetField() string {
+	return s.Field
+}
+
+// GetCode returns the value of Code.
+func (s *FieldError) GetCode() string {
+	return s.Code
+}
+
+// GetMessage returns the value of Message.
+func (s *FieldError) GetMessage() string {
+	return s.Message
+}
+
+// SetField sets the value of Field.
+func (s *FieldError) SetField(val string) {
+	s.Field = val
+}
+
+// SetCode sets the value of Code.
+func (s *FieldError) SetCode(val string) {
+	s.Code = val
+}
+
+// SetMessage sets the value of Message.
+func (s *FieldError) SetMessage(val string) {
+	s.Message = val
+}
+
+// Ref: #/components/schemas/HelloResponse
+type HelloResponse struct {
+	Message     string    `json:"message"`
+	Name        string    `json:"name"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// GetMessage returns the value of Message.
+func (s *HelloResponse) GetMessage() string {
+	return s.Message
+}
+
+// GetName returns the value of Name.
+func (s *HelloResponse) GetName() string {
+	return s.Name
+}
+
+// GetGeneratedAt returns the value of GeneratedAt.
+func (s *HelloResponse) GetGeneratedAt() time.Time {
+	return s.GeneratedAt
+}
+
+// SetMessage sets the value of Message.
+func (s *HelloResponse) SetMessage(val string) {
+	s.Message = val
+}
+
+// SetName sets the value of Name.
+func (s *HelloResponse) SetName(val string) {
+	s.Name = val
+}
+
+// SetGeneratedAt sets the value of GeneratedAt.
+func (s *HelloResponse) SetGeneratedAt(val time.Time) {
+	s.GeneratedAt = val
+}
+
+func (*HelloResponse) v2GetHelloRes() {}
+
+// NewOptInt returns new OptInt with value set to v.
+func NewOptInt(v int) OptInt {
+	return OptInt{
+		Value: v,
+		Set:   true,
+	}
+}
+
+// OptInt is optional int.
+type OptInt struct {
+	Value int
+	Set   bool
+}
+
+// IsSet returns true if OptInt was set.
+func (o OptInt) IsSet() bool { return o.Set }
+
+// Reset unsets value.
+func (o *OptInt) Reset() {
+	var v int
+	o.Value = v
+	o.Set = false
+}
+
+// SetTo sets value to v.
+func (o *OptInt) SetTo(v int) {
+	o.Set = true
+	o.Value = v
+}
+
+// Get returns value and boolean that denotes whether value was set.
+func (o OptInt) Get() (v int, ok bool) {
+	if !o.Set {
+		return v, false
+	}
+	return o.Value, true
+}
+
+// Or returns value if set, or given parameter if does not.
+func (o OptInt) Or(d int) int {
+	if v, ok := o.Get(); ok {
+		return v
+	}
+	return d
+}
+
+// NewOptString returns new OptString with value set to v.
+func NewOptString(v string) OptString {
+	return OptString{
+		Value: v,
+		Set:   true,
+	}
+}
+
+// OptString is optional string.
+type OptString struct {
+	Value string
+	Set   bool
+}
+
+// IsSet returns true if OptString was set.
+func (o OptString) IsSet() bool { return o.Set }
+
+// Reset unsets value.
+func (o *OptString) Reset() {
+	var v string
+	o.Value = v
+	o.Set = false
+}
+
+// SetTo sets value to v.
+func (o *OptString) SetTo(v string) {
+	o.Set = true
+	o.Value = v
+}
+
+// Get returns value and boolean that denotes whether value was set.
+func (o OptString) Get() (v string, ok bool) {
+	if !o.Set {
+		return v, false
+	}
+	return o.Value, true
+}
+
+// Or returns value if set, or given parameter if does not.
+func (o OptString) Or(d string) string {
+	if v, ok := o.Get(); ok {
+		return v
+	}
+	return d
+}
+
+// Ref: #/components/schemas/ProblemDetails
+type ProblemDetails struct {
+	// サーバによって生成されたHTTPステータスコード.
+	Status int32 `json:"status"`
+	// - 問題の発生箇所の参照URI
+	// - 用途としては、エンドポイントのパスなどを指定する.
+	Instance OptString `json:"instance"`
+	// 人間が読むことのできるエラー情報の要約（ユーザー/クライアント向け）.
+	Title string `json:"title"`
+	// 人間が読むことのできるエラー情報の詳細（ユーザー/クライアント向け）
+	// - 開発者向けの内部情報は含めない
+	// - エラー解消のヒントや入力ミスの理由を提供する.
+	Detail OptString `json:"detail"`
+	// - 問題の種類を識別するURL
+	// -
+	// 用途としては、自社/自組織で管理しているエラー詳細ドキュメントへのURLなどを指定する
+	// - デフォルトはabout:blank.
+	Type url.URL `json:"type"`
+	// フィールドごとの入力エラー（複数のフィールドをまとめて検証した場合のみ）.
+	Errors []FieldError `json:"errors"`
+}
+
+// GetStatus returns the value of Status.
+func (s *ProblemDetails) GetStatus() int32 {
+	return s.Status
+}
+
+// GetInstance returns the value of Instance.
+func (s *ProblemDetails) GetInstance() OptString {
+	return s.Instance
+}
+
+// GetTitle returns the value of Title.
+func (s *ProblemDetails) GetTitle() string {
+	return s.Title
+}
+
+// GetDetail returns the value of Detail.
+func (s *ProblemDetails) GetDetail() OptString {
+	return s.Detail
+}
+
+// GetType returns the value of Type.
+func (s *ProblemDetails) GetType() url.URL {
+	return s.Type
+}
+
+// GetErrors returns the value of Errors.
+func (s *ProblemDetails) GetErrors() []FieldError {
+	return s.Errors
+}
+
+// SetStatus sets the value of Status.
+func (s *ProblemDetails) SetStatus(val int32) {
+	s.Status = val
+}
+
+// SetInstance sets the value of Instance.
+func (s *ProblemDetails) SetInstance(val OptString) {
+	s.Instance = val
+}
+
+// SetTitle sets the value of Title.
+func (s *ProblemDetails) SetTitle(val string) {
+	s.Title = val
+}
+
+// SetDetail sets the value of Detail.
+func (s *ProblemDetails) SetDetail(val OptString) {
+	s.Detail = val
+}
+
+// SetType sets the value of Type.
+func (s *ProblemDetails) SetType(val url.URL) {
+	s.Type = val
+}
+
+// SetErrors sets the value of Errors.
+func (s *ProblemDetails) SetErrors(val []FieldError) {
+	s.Errors = val
+}
+
+type V2GetHelloBadRequest ProblemDetails
+
+func (*V2GetHelloBadRequest) v2GetHelloRes() {}
+
+type V2GetHelloForbidden ProblemDetails
+
+func (*V2GetHelloForbidden) v2GetHelloRes() {}
+
+type V2GetHelloInternalServerError ProblemDetails
+
+func (*V2GetHelloInternalServerError) v2GetHelloRes() {}
+
+type V2GetHelloUnauthorized ProblemDetails
+
+func (*V2GetHelloUnauthorized) v2GetHelloRes() {}
@@ -0,0 +1,42 @@
+// Code generated by ogen, DO NOT EDIT.
+
+package oasv2
+
+import (
+	"context"
+)
+
+// Handler handles operations described by OpenAPI v3 specification.
+type Handler interface {
+	// V2GetHello implements v2GetHello operation.
+	//
+	// V1からの変更点:
+	// - timestamp を generated_at に変更
+	// - 挨拶した名前を name として返す
+	// - repeat で挨拶を繰り返す回数を指定できる
+	// パラメータの制約はユースケースで検証し、不正な全てのパラメータを
+	// Problem Details の errors でまとめて返す
+	// （スキーマに制約を書くとogenが最初のエラーで検証を打ち切るため、description に記載する）.
+	//
+	// GET /v2/hello
+	V2GetHello(ctx context.Context, params V2GetHelloParams) (V2GetHelloRes, error)
+}
+
+// Server implements http server based on OpenAPI v3 specification and
+// calls Handler to handle requests.
+type Server struct {
+	h Handler
+	baseServer
+}
+
+// NewServer creates new Server.
+func NewServer(h Handler, opts ...ServerOption) (*Server, error) {
+	s, err := newServerConfig(opts...).baseServer()
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		h:          h,
+		baseServer: s,
+	}, nil
+}
@@ -0,0 +1,29 @@
+// Code generated by ogen, DO NOT EDIT.
+
+package oasv2
+
+import (
+	"context"
+
+	ht "github.com/ogen-go/ogen/http"
+)
+
+// UnimplementedHandler is no-op Handler which returns http.ErrNotImplemented.
+type UnimplementedHandler struct{}
+
+var _ Handler = UnimplementedHandler{}
+
+// V2GetHello implements v2GetHello operation.
+//
+// V1からの変更点:
+// - timestamp を generated_at に変更
+// - 挨拶した名前を name として返す
+// - repeat で挨拶を繰り返す回数を指定できる
+// パラメータの制約はユースケースで検証し、不正な全てのパラメータを
+// Problem Details の errors でまとめて返す
+// （スキーマに制約を書くとogenが最初のエラーで検証を打ち切るため、description に記載する）.
+//
+// GET /v2/hello
+func (UnimplementedHandler) V2GetHello(ctx context.Context, params V2GetHelloParams) (r V2GetHelloRes, _ error) {
+	return r, ht.ErrNotImplemented
+}
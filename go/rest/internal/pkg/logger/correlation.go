@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Correlation convention shared with the API gateway and the logout service.
+// The gateway assigns the IDs and forwards them as headers; every service
+// logs them under the same field names so a request can be followed across services.
+const (
+	// HeaderRequestID carries the request ID assigned by the gateway.
+	HeaderRequestID = "X-Request-ID"
+	// HeaderTraceParent carries the W3C Trace Context the trace ID is taken from.
+	HeaderTraceParent = "traceparent"
+
+	// FieldRequestID is the log field name of the request ID.
+	FieldRequestID = "request_id"
+	// FieldTraceID is the log field name of the trace ID.
+	FieldTraceID = "trace_id"
+)
+
+// Correlation holds the IDs that tie log lines of one request together.
+type Correlation struct {
+	RequestID string
+	TraceID   string
+}
+
+// Args returns the correlation fields as logger arguments, omitting empty values.
+func (c Correlation) Args() []any {
+	args := make([]any, 0, 4)
+	if c.RequestID != "" {
+		args = append(args, FieldRequestID, c.RequestID)
+	}
+	if c.TraceID != "" {
+		args = append(args, FieldTraceID, c.TraceID)
+	}
+	return args
+}
+
+type correlationKey struct{}
+
+// WithCorrelation stores the correlation IDs in context.
+func WithCorrelation(ctx context.Context, c Correlation) context.Context {
+	return context.WithValue(ctx, correlationKey{}, c)
+}
+
+// CorrelationFromContext retrieves the correlation IDs from context.
+func CorrelationFromContext(ctx context.Context) (Correlation, bool) {
+	if ctx == nil {
+		return Correlation{}, false
+	}
+	c, ok := ctx.Value(correlationKey{}).(Correlation)
+	return c, ok
+}
+
+// CorrelationFromRequest reads the correlation IDs forwarded by the gateway.
+func CorrelationFromRequest(r *http.Request) Correlation {
+	traceID, _ := ParseTraceParent(r.Header.Get(HeaderTraceParent))
+	return Correlation{
+		RequestID: r.Header.Get(HeaderRequestID),
+		TraceID:   traceID,
+	}
+}
+
+// ParseTraceParent extracts the trace ID from a traceparent header (version-traceid-parentid-flags).
+func ParseTraceParent(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+	traceID := strings.ToLower(parts[1])
+	if _, err := hex.DecodeString(traceID); err != nil || traceID == strings.Repeat("0", 32) {
+		return "", false
+	}
+	return traceID, true
+}
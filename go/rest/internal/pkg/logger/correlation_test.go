@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "valid",
+			header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:   "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantOK: true,
+		},
+		{name: "empty", header: ""},
+		{name: "all zero trace id", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "not hex", header: "00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTraceParent(tt.header)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseTraceParent() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCorrelation_Args(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Correlation
+		want int
+	}{
+		{name: "both", c: Correlation{RequestID: "req-123", TraceID: "trace-456"}, want: 4},
+		{name: "request id only", c: Correlation{RequestID: "req-123"}, want: 2},
+		{name: "empty", c: Correlation{}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.c.Args()); got != tt.want {
+				t.Errorf("len(Args()) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelationFromRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "req-123")
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	got := CorrelationFromRequest(req)
+	want := Correlation{RequestID: "req-123", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}
+	if got != want {
+		t.Errorf("CorrelationFromRequest() = %+v, want %+v", got, want)
+	}
+}
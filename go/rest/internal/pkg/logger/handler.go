@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+const (
+	// HTTPGroup is the log group request attributes are written under.
+	HTTPGroup = "http"
+
+	// DefaultStackFrames is the default number of stack frames written per stack trace.
+	DefaultStackFrames = 5
+)
+
+// HTTPRequest holds the request attributes written under the http group.
+type HTTPRequest struct {
+	Method     string
+	Path       string
+	Query      string
+	RemoteAddr string
+	UserAgent  string
+}
+
+// NewHTTPRequest extracts the attributes to log from a request.
+func NewHTTPRequest(r *http.Request) HTTPRequest {
+	return HTTPRequest{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      r.URL.RawQuery,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+// Attr returns the http group, omitting empty values.
+func (r HTTPRequest) Attr() slog.Attr {
+	attrs := make([]any, 0, 5)
+	for _, a := range []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.Path),
+		slog.String("query", r.Query),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("user_agent", r.UserAgent),
+	} {
+		if a.Value.String() != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	return slog.Group(HTTPGroup, attrs...)
+}
+
+type httpRequestKey struct{}
+
+// WithHTTPRequest stores the request attributes in context.
+func WithHTTPRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, httpRequestKey{}, NewHTTPRequest(r))
+}
+
+// HTTPRequestFromContext returns the request attributes stored in context.
+func HTTPRequestFromContext(ctx context.Context) (HTTPRequest, bool) {
+	if ctx == nil {
+		return HTTPRequest{}, false
+	}
+	r, ok := ctx.Value(httpRequestKey{}).(HTTPRequest)
+	return r, ok
+}
+
+// Stack is a stack trace to log, as program counters returned by runtime.Callers.
+// Handler writes only the first frames as "function (file:line)".
+type Stack []uintptr
+
+// CallerStack captures the stack of its caller, skipping skip additional frames.
+func CallerStack(skip int) Stack {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	return Stack(pcs[:n])
+}
+
+// LogValue formats the first DefaultStackFrames frames when logged without Handler.
+func (s Stack) LogValue() slog.Value {
+	return slog.AnyValue(s.frames(DefaultStackFrames))
+}
+
+// stackFrames is a formatted stack trace.
+// It is written as an array in JSON and one frame per line in the pretty format.
+type stackFrames []string
+
+// frames formats up to limit frames from the top of the stack, skipping runtime internals.
+func (s Stack) frames(limit int) stackFrames {
+	frames := make(stackFrames, 0, limit)
+	iter := runtime.CallersFrames(s)
+	for len(frames) < limit {
+		frame, more := iter.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			frames = append(frames, fmt.Sprintf("%s (%s:%d)", frame.Function, shortPath(frame.File), frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// shortPath shortens a file path to its parent directory and file name.
+func shortPath(path string) string {
+	dir, file := filepath.Split(path)
+	return filepath.Join(filepath.Base(dir), file)
+}
+
+// errorStack returns the innermost stack trace recorded in err by cockroachdb/errors.
+func errorStack(err error) Stack {
+	var stack Stack
+	// 最も内側（エラー発生箇所に近い）のスタックを使うため、チェーンを最後まで辿る
+	for e := err; e != nil; e = errors.UnwrapOnce(e) {
+		if p, ok := e.(errbase.StackTraceProvider); ok {
+			st := p.StackTrace()
+			stack = make(Stack, len(st))
+			for i, f := range st {
+				stack[i] = uintptr(f)
+			}
+		}
+	}
+	return stack
+}
+
+// HandlerOptions configures Handler.
+type HandlerOptions struct {
+	// StackFrames is the number of stack frames to write (DefaultStackFrames if 0).
+	StackFrames int
+}
+
+// Handler is a slog.Handler that formats records before passing them on:
+//   - request attributes stored in context are grouped under http
+//   - errors carrying a cockroachdb/errors stack are written as {msg, stack}
+//   - stack traces are trimmed to the first frames
+type Handler struct {
+	next    slog.Handler
+	opts    HandlerOptions
+	hasHTTP bool // http group already added by WithAttrs
+}
+
+// NewHandler wraps next with Handler.
+func NewHandler(next slog.Handler, opts HandlerOptions) *Handler {
+	if opts.StackFrames <= 0 {
+		opts.StackFrames = DefaultStackFrames
+	}
+	return &Handler{next: next, opts: opts}
+}
+
+// Enabled reports whether next handles records at level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle formats the record and passes it to next.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	hasHTTP := h.hasHTTP
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == HTTPGroup {
+			hasHTTP = true
+		}
+		out.AddAttrs(h.format(a))
+		return true
+	})
+
+	// 呼び出し側が http グループを明示的に渡している場合はそちらを優先する
+	if req, ok := HTTPRequestFromContext(ctx); ok && !hasHTTP {
+		out.AddAttrs(req.Attr())
+	}
+
+	return h.next.Handle(ctx, out)
+}
+
+// WithAttrs returns a handler with the formatted attributes added.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hasHTTP := h.hasHTTP
+	formatted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if a.Key == HTTPGroup {
+			hasHTTP = true
+		}
+		formatted[i] = h.format(a)
+	}
+	return &Handler{next: h.next.WithAttrs(formatted), opts: h.opts, hasHTTP: hasHTTP}
+}
+
+// WithGroup returns a handler with the group added.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), opts: h.opts, hasHTTP: h.hasHTTP}
+}
+
+// format rewrites stack traces and errors with stacks into their compact form.
+func (h *Handler) format(a slog.Attr) slog.Attr {
+	switch v := a.Value.Any().(type) {
+	case Stack:
+		return slog.Any(a.Key, v.frames(h.opts.StackFrames))
+	case error:
+		if stack := errorStack(v); len(stack) > 0 {
+			return slog.Group(a.Key,
+				slog.String("msg", v.Error()),
+				slog.Any("stack", stack.frames(h.opts.StackFrames)),
+			)
+		}
+	}
+	return a
+}
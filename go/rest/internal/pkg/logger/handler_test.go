@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestHandler_HTTPGroup(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/revoke?user_id=1", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	ctx := WithHTTPRequest(context.Background(), req)
+
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want map[string]any
+	}{
+		{
+			name: "request attributes are grouped from context",
+			log:  func(l *slog.Logger) { l.InfoContext(ctx, "test") },
+			want: map[string]any{
+				"method":      "GET",
+				"path":        "/v1/revoke",
+				"query":       "user_id=1",
+				"remote_addr": "192.0.2.1:1234",
+				"user_agent":  "test-agent",
+			},
+		},
+		{
+			name: "group added by With takes precedence",
+			log:  func(l *slog.Logger) { l.With(NewHTTPRequest(req).Attr()).InfoContext(ctx, "test") },
+			want: map[string]any{
+				"method":      "GET",
+				"path":        "/v1/revoke",
+				"query":       "user_id=1",
+				"remote_addr": "192.0.2.1:1234",
+				"user_agent":  "test-agent",
+			},
+		},
+		{
+			name: "no context",
+			log:  func(l *slog.Logger) { l.Info("test") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(NewHandler(slog.NewJSONHandler(&buf, nil), HandlerOptions{})))
+
+			if n := strings.Count(buf.String(), `"`+HTTPGroup+`"`); n > 1 {
+				t.Errorf("http group written %d times: %s", n, buf.String())
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse log output: %v", err)
+			}
+			group, _ := entry[HTTPGroup].(map[string]any)
+			if tt.want == nil {
+				if group != nil {
+					t.Errorf("http group = %v, want none", group)
+				}
+				return
+			}
+			if len(group) != len(tt.want) {
+				t.Errorf("http group = %v, want %v", group, tt.want)
+			}
+			for key, want := range tt.want {
+				if group[key] != want {
+					t.Errorf("http.%s = %v, want %v", key, group[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_ErrorStack(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantMsg   string
+		wantStack bool
+	}{
+		{
+			name:      "wrapped error uses innermost stack",
+			err:       errors.Wrap(errors.New("connection refused"), "failed to revoke"),
+			wantMsg:   "failed to revoke: connection refused",
+			wantStack: true,
+		},
+		{
+			name: "error without stack is logged as string",
+			err:  context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil), HandlerOptions{StackFrames: 2}))
+			l.Error("test", "error", tt.err)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to parse log output: %v", err)
+			}
+
+			if !tt.wantStack {
+				if entry["error"] != tt.err.Error() {
+					t.Errorf("error = %v, want %q", entry["error"], tt.err.Error())
+				}
+				return
+			}
+
+			group, ok := entry["error"].(map[string]any)
+			if !ok {
+				t.Fatalf("error = %v, want group with msg and stack", entry["error"])
+			}
+			if group["msg"] != tt.wantMsg {
+				t.Errorf("error.msg = %v, want %q", group["msg"], tt.wantMsg)
+			}
+			stack, _ := group["stack"].([]any)
+			if len(stack) == 0 || len(stack) > 2 {
+				t.Fatalf("stack has %d frames, want 1 to 2: %v", len(stack), stack)
+			}
+			// 先頭フレームはエラーを生成した関数
+			if first, _ := stack[0].(string); !strings.Contains(first, "TestHandler_ErrorStack") || !strings.Contains(first, "logger/handler_test.go:") {
+				t.Errorf("first frame = %v, want origin of the error", stack[0])
+			}
+		})
+	}
+}
+
+func TestPrettyHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		color bool
+		want  string
+	}{
+		{name: "plain", color: false, want: "WARN  revoke failed user_id=u1 http.method=GET"},
+		{name: "colored", color: true, want: colorYellow + "WARN " + colorReset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(NewPrettyHandler(&buf, nil, tt.color))
+			l.Warn("revoke failed", "user_id", "u1", slog.Group(HTTPGroup, "method", "GET"))
+
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output = %q, want to contain %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
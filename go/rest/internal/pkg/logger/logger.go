@@ -22,18 +22,54 @@ const (
 	LevelError = slog.LevelError
 )
 
-func New(level Level) *slog.Logger {
+// Format is the output format of loggers created by New.
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatPretty Format = "pretty" // human-friendly colored output for local development
+)
+
+// level is shared by every logger created by New so that it can be changed at runtime.
+var level = new(slog.LevelVar)
+
+func New(l Level) *slog.Logger {
+	return NewWithFormat(l, FormatJSON)
+}
+
+// NewWithFormat creates a logger writing to stdout in the given format.
+func NewWithFormat(l Level, format Format) *slog.Logger {
+	level.Set(l)
 	opts := &slog.HandlerOptions{
 		Level: level,
 	}
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	return slog.New(handler)
+
+	var handler slog.Handler
+	switch format {
+	case FormatPretty:
+		// NO_COLOR（https://no-color.org/）が設定されている場合は色付けしない
+		handler = NewPrettyHandler(os.Stdout, opts, os.Getenv("NO_COLOR") == "")
+	default:
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	return slog.New(NewHandler(handler, HandlerOptions{}))
+}
+
+// SetLevel changes the level of all loggers created by New, including ones already in use.
+func SetLevel(l Level) {
+	level.Set(l)
+}
+
+// GetLevel returns the current level of loggers created by New.
+func GetLevel() Level {
+	return level.Level()
 }
 
 func NewFromEnv() *slog.Logger {
 	levelStr := os.Getenv("LOG_LEVEL")
 	level := parseLogLevel(levelStr)
-	return New(level)
+	format := Format(strings.ToLower(os.Getenv("LOG_FORMAT")))
+	return NewWithFormat(level, format)
 }
 
 func parseLogLevel(levelStr string) Level {
@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ANSI escape sequences
+const (
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+)
+
+// prettyHandler is a slog.Handler that writes human-friendly lines for local development.
+//
+//	15:04:05.000 INFO  request http.method=GET http.path=/healthz
+//
+// Stack traces are written after the line, one frame per line.
+type prettyHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	color  bool
+	attrs  []prettyAttr // attributes added by WithAttrs
+	prefix string       // groups added by WithGroup, as "a.b."
+}
+
+// prettyAttr is an attribute with its group names expanded into the key.
+type prettyAttr struct {
+	key   string
+	value slog.Value
+}
+
+// NewPrettyHandler creates a handler writing the development format to w.
+// Levels and keys are colored when color is true.
+func NewPrettyHandler(w io.Writer, opts *slog.HandlerOptions, color bool) slog.Handler {
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+	return &prettyHandler{mu: &sync.Mutex{}, w: w, level: level, color: color}
+}
+
+// Enabled reports whether the level is enabled.
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes the record as one line, followed by stack traces if any.
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]prettyAttr(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendPrettyAttr(attrs, h.prefix, a)
+		return true
+	})
+
+	var buf bytes.Buffer
+	if !r.Time.IsZero() {
+		h.paint(&buf, colorDim, r.Time.Format("15:04:05.000"))
+		buf.WriteByte(' ')
+	}
+	h.paint(&buf, levelColor(r.Level), padLevel(r.Level))
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+
+	var stacks []prettyAttr
+	for _, a := range attrs {
+		if _, ok := a.value.Any().(stackFrames); ok {
+			stacks = append(stacks, a)
+			continue
+		}
+		buf.WriteByte(' ')
+		h.paint(&buf, colorCyan, a.key+"=")
+		buf.WriteString(quoteIfNeeded(a.value.String()))
+	}
+	buf.WriteByte('\n')
+
+	for _, a := range stacks {
+		buf.WriteString("    ")
+		h.paint(&buf, colorCyan, a.key+":")
+		buf.WriteByte('\n')
+		for _, frame := range a.value.Any().(stackFrames) {
+			buf.WriteString("      ")
+			h.paint(&buf, colorDim, frame)
+			buf.WriteByte('\n')
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// WithAttrs returns a handler with the attributes added.
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]prettyAttr(nil), h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = appendPrettyAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+// WithGroup returns a handler with the group added.
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendPrettyAttr appends a, expanding groups into "group.key" attributes.
+func appendPrettyAttr(attrs []prettyAttr, prefix string, a slog.Attr) []prettyAttr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendPrettyAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	return append(attrs, prettyAttr{key: prefix + a.Key, value: a.Value})
+}
+
+// paint writes s, wrapped in the color escape sequence when coloring is enabled.
+func (h *prettyHandler) paint(buf *bytes.Buffer, color, s string) {
+	if !h.color {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return colorRed
+	case level >= slog.LevelWarn:
+		return colorYellow
+	case level >= slog.LevelInfo:
+		return colorGreen
+	default:
+		return colorBlue
+	}
+}
+
+// padLevel pads the level name to a fixed width.
+func padLevel(level slog.Level) string {
+	s := level.String()
+	if len(s) < 5 {
+		s += strings.Repeat(" ", 5-len(s))
+	}
+	return s
+}
+
+// quoteIfNeeded quotes values containing spaces, quotes or "=".
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
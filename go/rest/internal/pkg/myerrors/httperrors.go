@@ -28,6 +28,8 @@ const (
 	ValidationNameTooShort      ValidationErrorCode = "name.too_short"
 	ValidationNameTooLong       ValidationErrorCode = "name.too_long"
 	ValidationNameInvalidFormat ValidationErrorCode = "name.invalid_format"
+	ValidationNameReserved      ValidationErrorCode = "name.reserved"
+	ValidationRepeatOutOfRange  ValidationErrorCode = "repeat.out_of_range"
 
 	// Body validation errors
 	ValidationBodyRequired      ValidationErrorCode = "body.required"
@@ -45,6 +47,8 @@ var ValidationMessages = map[ValidationErrorCode]string{
 	ValidationNameTooShort:      "名前は1文字以上で入力してください",
 	ValidationNameTooLong:       "名前は100文字以内で入力してください",
 	ValidationNameInvalidFormat: "名前の形式が正しくありません",
+	ValidationNameReserved:      "名前に'error'は使用できません",
+	ValidationRepeatOutOfRange:  "繰り返し回数は1〜5で指定してください",
 
 	ValidationBodyRequired:      "リクエストボディを入力してください",
 	ValidationBodyInvalidFormat: "リクエストボディの形式が正しくありません",
@@ -83,7 +87,8 @@ func (e *baseHTTPError) Error() string { return e.userMessage }
 type InvalidArgumentError struct {
 	baseHTTPError
 	validationCode ValidationErrorCode
-	rawMessage     string // ogen生メッセージ（ログ専用）
+	rawMessage     string       // ogen生メッセージ（ログ専用）
+	fieldErrors    []FieldError // フィールドごとのエラー（Problem Details の errors として返す）
 }
 
 // NewInvalidArgument creates a new InvalidArgumentError
@@ -140,6 +145,11 @@ func (e *InvalidArgumentError) RawMessage() string {
 	return e.rawMessage
 }
 
+// FieldErrors returns the per-field validation errors (nil if the error is not field-specific)
+func (e *InvalidArgumentError) FieldErrors() []FieldError {
+	return e.fieldErrors
+}
+
 // UnauthorizedError represents a 401 Unauthorized error
 type UnauthorizedError struct {
 	baseHTTPError
@@ -0,0 +1,111 @@
+package myerrors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Locale is the language of client-facing error messages.
+type Locale string
+
+const (
+	LocaleJa Locale = "ja"
+	LocaleEn Locale = "en"
+)
+
+// DefaultLocale is used when Accept-Language contains no supported language.
+// メッセージは日本語で定義し、それ以外のロケールはカタログで翻訳する
+const DefaultLocale = LocaleJa
+
+// englishMessages translates client-facing messages to English.
+// 日本語のメッセージをキーにする（gettext の msgid と同じ考え方）。メッセージを追加した場合はここにも追加すること
+var englishMessages = map[string]string{
+	// DefaultMessages
+	"入力内容に誤りがあります": "The request contains invalid input",
+	"認証が必要です":      "Authentication is required",
+	"アクセスが許可されていません。再ログインしてください": "Access is not allowed. Please sign in again",
+	"リソースが見つかりません":               "The resource was not found",
+	"リクエストが競合しています":              "The request conflicts with the current state",
+	"処理できないリクエストです":              "The request cannot be processed",
+	"サーバーエラーが発生しました":             "An internal server error occurred",
+	"エラーが発生しました":                 "An error occurred",
+
+	// ValidationMessages
+	"名前を入力してください":          "Please enter a name",
+	"名前は1文字以上で入力してください":    "Name must be at least 1 character",
+	"名前は100文字以内で入力してください":  "Name must be at most 100 characters",
+	"名前の形式が正しくありません":       "Name has an invalid format",
+	"名前に'error'は使用できません":   "Name must not be 'error'",
+	"繰り返し回数は1〜5で指定してください":  "Repeat must be between 1 and 5",
+	"リクエストボディを入力してください":    "Request body is required",
+	"リクエストボディの形式が正しくありません": "Request body has an invalid format",
+	"必須パラメータが不足しています":      "A required parameter is missing",
+	"パラメータの形式が正しくありません":    "A parameter has an invalid format",
+	"ログレベルが不正です":           "The log level is invalid",
+
+	// 認証・認可（middleware）
+	"認証トークンが必要です":       "An authentication token is required",
+	"認証形式が不正です":         "The authorization header format is invalid",
+	"認証トークンが空です":        "The authentication token is empty",
+	"トークンの解析に失敗しました":    "Failed to parse the token",
+	"無効なロールです":          "The role is invalid",
+	"トークン形式が不正です":       "The token format is invalid",
+	"ペイロードのデコードに失敗しました": "Failed to decode the token payload",
+	"Claimsの解析に失敗しました":  "Failed to parse the token claims",
+	"この操作を実行する権限がありません（ロールマッピング未定義）": "You do not have permission to perform this operation (no role mapping)",
+	"認証情報が見つかりません":                   "Credentials were not found",
+	"この操作を実行する権限がありません":              "You do not have permission to perform this operation",
+}
+
+// invalidFieldsMessages are the messages for multiple invalid fields, keyed by locale.
+var invalidFieldsMessages = map[Locale]string{
+	LocaleJa: "%d件の入力内容に誤りがあります",
+	LocaleEn: "%d fields contain invalid input",
+}
+
+// NegotiateLocale returns the supported locale preferred by an Accept-Language header value.
+// 地域のサブタグ（en-US など）は無視して言語で照合し、q値が同じ場合は先に書かれた言語を優先する
+// 対応する言語が無い場合は DefaultLocale を返す
+func NegotiateLocale(acceptLanguage string) Locale {
+	best, bestQ := DefaultLocale, 0.0
+	for part := range strings.SplitSeq(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		switch locale := Locale(strings.ToLower(primary)); locale {
+		case LocaleJa, LocaleEn:
+			if q > bestQ {
+				best, bestQ = locale, q
+			}
+		}
+	}
+	return best
+}
+
+// Localize translates a client-facing message to locale.
+// 翻訳が無い場合（リソース名を含む NotFound のメッセージなど）はそのまま返す
+func Localize(locale Locale, message string) string {
+	if locale == LocaleEn {
+		if translated, ok := englishMessages[message]; ok {
+			return translated
+		}
+	}
+	return message
+}
+
+// InvalidFieldsMessage returns the message for n invalid fields in locale.
+func InvalidFieldsMessage(locale Locale, n int) string {
+	format, ok := invalidFieldsMessages[locale]
+	if !ok {
+		format = invalidFieldsMessages[DefaultLocale]
+	}
+	return fmt.Sprintf(format, n)
+}
@@ -0,0 +1,63 @@
+package myerrors
+
+import "testing"
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           Locale
+	}{
+		{name: "未指定はデフォルト", acceptLanguage: "", want: DefaultLocale},
+		{name: "英語", acceptLanguage: "en", want: LocaleEn},
+		{name: "地域のサブタグは無視する", acceptLanguage: "en-US,en;q=0.9", want: LocaleEn},
+		{name: "大文字小文字を区別しない", acceptLanguage: "EN-gb", want: LocaleEn},
+		{name: "q値の高い言語を優先する", acceptLanguage: "en;q=0.5, ja;q=0.8", want: LocaleJa},
+		{name: "q値が同じ場合は先に書かれた言語", acceptLanguage: "en, ja", want: LocaleEn},
+		{name: "未対応の言語は飛ばす", acceptLanguage: "fr-FR, en;q=0.7", want: LocaleEn},
+		{name: "未対応の言語のみはデフォルト", acceptLanguage: "fr, de;q=0.5", want: DefaultLocale},
+		{name: "q=0は受け付けない", acceptLanguage: "en;q=0", want: DefaultLocale},
+		{name: "不正なq値は無視する", acceptLanguage: "en;q=abc, ja;q=0.1", want: LocaleJa},
+		{name: "ワイルドカードはデフォルト", acceptLanguage: "*", want: DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateLocale(tt.acceptLanguage); got != tt.want {
+				t.Errorf("NegotiateLocale(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEnglishMessages_Complete checks that every predefined message has an English translation
+func TestEnglishMessages_Complete(t *testing.T) {
+	messages := []string{GetDefaultMessage(0)}
+	for _, message := range DefaultMessages {
+		messages = append(messages, message)
+	}
+	for _, message := range ValidationMessages {
+		messages = append(messages, message)
+	}
+
+	for _, message := range messages {
+		if _, ok := englishMessages[message]; !ok {
+			t.Errorf("missing English translation for %q", message)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	if got := Localize(LocaleEn, "認証が必要です"); got != "Authentication is required" {
+		t.Errorf("Localize(en) = %q, want %q", got, "Authentication is required")
+	}
+	if got := Localize(LocaleJa, "認証が必要です"); got != "認証が必要です" {
+		t.Errorf("Localize(ja) = %q, want the message unchanged", got)
+	}
+	if got := Localize(LocaleEn, "user not found: 42"); got != "user not found: 42" {
+		t.Errorf("Localize(en) = %q, want the untranslated message unchanged", got)
+	}
+	if got := InvalidFieldsMessage(LocaleEn, 2); got != "2 fields contain invalid input" {
+		t.Errorf("InvalidFieldsMessage(en, 2) = %q", got)
+	}
+}
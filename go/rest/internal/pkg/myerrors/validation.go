@@ -0,0 +1,74 @@
+package myerrors
+
+import (
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+)
+
+// FieldError represents a validation error for a single request field.
+// Problem Details の拡張メンバー errors の要素としてクライアントへ返す
+type FieldError struct {
+	Field   string              `json:"field"`
+	Code    ValidationErrorCode `json:"code"`
+	Message string              `json:"message"`
+}
+
+// Validator collects field errors so that every invalid field is reported at once.
+// ogen は最初のエラーで検証を打ち切るため、複数のフィールドの検証はハンドラ/ユースケース層で行う
+type Validator struct {
+	errs []FieldError
+}
+
+// Add records a validation error for field with the message mapped from code
+func (v *Validator) Add(field string, code ValidationErrorCode) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: GetValidationMessage(code)})
+}
+
+// Check records a validation error for field when ok is false
+func (v *Validator) Check(ok bool, field string, code ValidationErrorCode) {
+	if !ok {
+		v.Add(field, code)
+	}
+}
+
+// Err returns an InvalidArgumentError holding every collected field error, or nil if there is none
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return NewInvalidFields(v.errs)
+}
+
+// NewInvalidFields creates a new InvalidArgumentError from field errors.
+// detail はエラーが1件の場合はそのメッセージ、複数の場合は件数を示すメッセージになる
+func NewInvalidFields(fieldErrs []FieldError) error {
+	userMessage := GetDefaultMessage(http.StatusBadRequest)
+	switch len(fieldErrs) {
+	case 0:
+	case 1:
+		userMessage = fieldErrs[0].Message
+	default:
+		userMessage = InvalidFieldsMessage(DefaultLocale, len(fieldErrs))
+	}
+
+	err := &InvalidArgumentError{
+		baseHTTPError: baseHTTPError{
+			userMessage: userMessage,
+		},
+		fieldErrors: fieldErrs,
+	}
+	if len(fieldErrs) == 1 {
+		err.validationCode = fieldErrs[0].Code
+	}
+	return errors.WithStack(err)
+}
+
+// GetFieldErrors extracts the per-field validation errors from an error
+func GetFieldErrors(err error) []FieldError {
+	var invalidArg *InvalidArgumentError
+	if errors.As(err, &invalidArg) {
+		return invalidArg.FieldErrors()
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+//go:build integration
+
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kaitoimai/go-sample/rest/internal/auth"
+	"github.com/kaitoimai/go-sample/rest/internal/config"
+	"github.com/kaitoimai/go-sample/rest/internal/testutil"
+)
+
+// 統合テストは実際のTCPリスナーでサーバーを起動し、authn → authz → usecase を通したレスポンスを検証する
+// 実行: make test-integration（go test -tags=integration ./...）
+//
+// 現時点でこのサービスは永続化層（Postgres, Redis）を持たないため、コンテナは起動しない
+// リポジトリを追加する際は testcontainers-go で Postgres と Redis を起動し、マイグレーションを適用してから
+// newIntegrationServer に接続情報を渡すこと
+
+// newIntegrationServer はサーバーを httptest.Server で起動する
+func newIntegrationServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv, err := New(&config.Config{Port: 8080}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ts := httptest.NewServer(srv.httpServer.Handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// TestIntegration_Hello exercises the full HTTP stack with tokens issued for realistic users
+func TestIntegration_Hello(t *testing.T) {
+	ts := newIntegrationServer(t)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	now := time.Now()
+	token := func(userID, role string, duration time.Duration) string {
+		t.Helper()
+		token, _, err := testutil.GenerateJWT(testutil.JWTConfig{
+			UserID:   userID,
+			Role:     role,
+			KID:      "integration-key-id",
+			Duration: duration,
+			Issuer:   "api-gateway",
+			Audience: "go-sample-rest",
+		}, privateKey, now)
+		if err != nil {
+			t.Fatalf("failed to generate JWT: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		token       string
+		wantStatus  int
+		wantMessage string // 200の場合の message
+		wantErrors  int    // Problem Details の errors の件数
+	}{
+		{
+			name:       "トークンなしは401",
+			path:       "/v1/hello?name=Alice",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "未定義のロールは401",
+			path:       "/v1/hello?name=Alice",
+			token:      token("user-guest", "guest", 15*time.Minute),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "一般ユーザーはv1にアクセスできる",
+			path:        "/v1/hello?name=Alice",
+			token:       token("user-alice", auth.RoleUser, 15*time.Minute),
+			wantStatus:  http.StatusOK,
+			wantMessage: "Hello, Alice!",
+		},
+		{
+			name:        "管理者はv2にアクセスできる",
+			path:        "/v2/hello?name=%E5%B1%B1%E7%94%B0&repeat=2",
+			token:       token("admin-1", auth.RoleAdmin, 15*time.Minute),
+			wantStatus:  http.StatusOK,
+			wantMessage: "Hello, 山田! Hello, 山田!",
+		},
+		{
+			name:       "入力エラーはまとめてProblem Detailsで返す",
+			path:       "/v2/hello?name=error&repeat=9",
+			token:      token("user-alice", auth.RoleUser, 15*time.Minute),
+			wantStatus: http.StatusBadRequest,
+			wantErrors: 2,
+		},
+		{
+			name:       "存在しないパスは404",
+			path:       "/v3/hello",
+			token:      token("user-alice", auth.RoleUser, 15*time.Minute),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+
+			switch {
+			case tt.wantMessage != "":
+				var got struct {
+					Message string `json:"message"`
+				}
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("failed to parse body %s: %v", body, err)
+				}
+				if got.Message != tt.wantMessage {
+					t.Errorf("message = %q, want %q", got.Message, tt.wantMessage)
+				}
+			case tt.wantErrors > 0:
+				if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+					t.Errorf("Content-Type = %q, want application/problem+json", ct)
+				}
+				var got struct {
+					Errors []map[string]any `json:"errors"`
+				}
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("failed to parse body %s: %v", body, err)
+				}
+				if len(got.Errors) != tt.wantErrors {
+					t.Errorf("errors = %v, want %d entries", got.Errors, tt.wantErrors)
+				}
+			}
+		})
+	}
+}
@@ -16,7 +16,9 @@ import (
 	"github.com/kaitoimai/go-sample/rest/internal/handler"
 	"github.com/kaitoimai/go-sample/rest/internal/middleware"
 	"github.com/kaitoimai/go-sample/rest/internal/oas"
+	"github.com/kaitoimai/go-sample/rest/internal/oasv2"
 	logx "github.com/kaitoimai/go-sample/rest/internal/pkg/logger"
+	"github.com/kaitoimai/go-sample/rest/internal/usecase"
 )
 
 const (
@@ -40,31 +42,45 @@ func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
 	authnMiddleware := middleware.NewAuthnMiddleware()
 	authzMiddleware := middleware.NewAuthzMiddleware()
 
-	// Create OAS handler
-	oasHandler := handler.NewOASHandler()
-
-	// Create OAS server
-	oasServer, err := oas.NewServer(
-		oasHandler,
-		oas.WithMiddleware(func(req ogenmw.Request, next ogenmw.Next) (ogenmw.Response, error) {
-			// リクエスト固有の情報（method/path）をログに自動付与するため、request-scoped loggerを作成してContextに保存
-			reqLogger := logger.With("method", req.Raw.Method, "path", req.Raw.URL.Path)
-			req.Context = logx.NewContext(req.Context, reqLogger)
-			logger.Info("request", "method", req.Raw.Method, "path", req.Raw.URL.Path)
-			return next(req)
-		}),
-		oas.WithMiddleware(authnMiddleware.Handle), // API Gateway検証済みJWTからClaims抽出
-		oas.WithMiddleware(authzMiddleware.Handle), // RBAC認可（ロールベースアクセス制御）
+	// Create usecases (shared by every API version)
+	helloUsecase := usecase.NewHelloUsecase()
+
+	// WithMiddleware は呼び出すたびに設定を上書きするため、ミドルウェアは1回の呼び出しでまとめて渡す
+	// 生成されたパッケージごとにオプションの型が異なるため、バージョンごとに同じミドルウェアを渡す
+	middlewares := []ogenmw.Middleware{
+		requestLogMiddleware(logger),
+		authnMiddleware.Handle, // API Gateway検証済みJWTからClaims抽出
+		authzMiddleware.Handle, // RBAC認可（ロールベースアクセス制御）
+	}
+
+	// Create OAS servers (v1 と v2 を同時に提供する)
+	v1Server, err := oas.NewServer(
+		handler.NewOASHandler(helloUsecase),
+		oas.WithMiddleware(middlewares...),
 		oas.WithErrorHandler(middleware.ErrorHandler),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OAS server: %w", err)
 	}
+	v2Server, err := oasv2.NewServer(
+		handler.NewV2Handler(helloUsecase),
+		oasv2.WithMiddleware(middlewares...),
+		oasv2.WithErrorHandler(middleware.ErrorHandler),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OAS v2 server: %w", err)
+	}
+
+	// /v2 配下は v2 の仕様書から生成したサーバー、それ以外（/v1 と / や /healthz）は v1 のサーバーで処理する
+	mux := http.NewServeMux()
+	mux.Handle("/v2/", v2Server)
+	mux.HandleFunc("GET /admin/config/schema", configSchemaHandler)
+	mux.Handle("/", v1Server)
 
 	return &Server{
 		httpServer: &http.Server{
 			Addr:              fmt.Sprintf(":%d", cfg.Port),
-			Handler:           oasServer,
+			Handler:           mux,
 			ReadHeaderTimeout: readHeaderTimeout,
 			ReadTimeout:       readTimeout,
 			WriteTimeout:      writeTimeout,
@@ -75,6 +91,33 @@ func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
 	}, nil
 }
 
+// configSchemaHandler returns the configuration reference (fields, defaults and environment variables).
+// 値は含まないため認証なしで公開する
+func configSchemaHandler(w http.ResponseWriter, _ *http.Request) {
+	data, err := config.ReferenceJSON()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// requestLogMiddleware logs each request and stores a request-scoped logger in context.
+func requestLogMiddleware(logger *slog.Logger) oas.Middleware {
+	return func(req ogenmw.Request, next ogenmw.Next) (ogenmw.Response, error) {
+		// リクエスト固有の情報（http.method/http.path など）をログに自動付与するため、request-scoped loggerを作成してContextに保存
+		// Gatewayから伝搬された相関ID（request_id/trace_id）も同じloggerに載せる
+		corr := logx.CorrelationFromRequest(req.Raw)
+		reqLogger := logger.With(append([]any{logx.NewHTTPRequest(req.Raw).Attr()}, corr.Args()...)...)
+		req.Context = logx.WithCorrelation(req.Context, corr)
+		req.Context = logx.WithHTTPRequest(req.Context, req.Raw)
+		req.Context = logx.NewContext(req.Context, reqLogger)
+		reqLogger.Info("request")
+		return next(req)
+	}
+}
+
 func (s *Server) Start() error {
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 	timeoutCh := make(chan struct{}, 1)
@@ -83,6 +126,13 @@ func (s *Server) Start() error {
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sig)
 
+	// SIGUSR1でdebugログを切り替える（障害調査時に再起動せず有効化するため。API Gatewayと同じシグナル）
+	// 以前から使っていたSIGHUPでも切り替える
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1, syscall.SIGHUP)
+	defer signal.Stop(usr1)
+	go s.watchLogLevel(serverCtx, usr1)
+
 	go func() {
 		<-sig
 
@@ -121,3 +171,28 @@ func (s *Server) Start() error {
 	s.logger.Info("server gracefully shutdown")
 	return nil
 }
+
+// watchLogLevel toggles debug logging each time SIGUSR1 (or SIGHUP) is received until ctx is done.
+func (s *Server) watchLogLevel(ctx context.Context, signals <-chan os.Signal) {
+	base := logx.GetLevel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			level := toggleDebugLevel(base)
+			// 変更後のレベルに関わらず記録されるようWarnで出力する
+			s.logger.Warn("log level changed", "level", level.String())
+		}
+	}
+}
+
+// toggleDebugLevel switches the log level to debug, or back to base if debug is already enabled.
+func toggleDebugLevel(base logx.Level) logx.Level {
+	next := logx.LevelDebug
+	if logx.GetLevel() == logx.LevelDebug {
+		next = base
+	}
+	logx.SetLevel(next)
+	return next
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kaitoimai/go-sample/rest/internal/auth"
+	"github.com/kaitoimai/go-sample/rest/internal/config"
+	logx "github.com/kaitoimai/go-sample/rest/internal/pkg/logger"
+	"github.com/kaitoimai/go-sample/rest/internal/testutil"
+)
+
+// TestServer_RequestLogCorrelation verifies that correlation IDs forwarded by the gateway appear in request logs
+func TestServer_RequestLogCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	// ミドルウェアでのエラーはデフォルトロガーに出力されるため差し替える
+	defaultLogger := logx.Default()
+	logx.SetDefault(logger)
+	defer logx.SetDefault(defaultLogger)
+
+	srv, err := New(&config.Config{Port: 8080}, logger)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Gatewayが転送するリクエストと同じヘッダーを付与する
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set(logx.HeaderRequestID, "req-123")
+	req.Header.Set(logx.HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+
+	// 認証エラー時のログも含め、リクエスト中の全ログに相関IDが載ること
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want request and error logs: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("failed to parse log line %s: %v", line, err)
+		}
+		if entry[logx.FieldRequestID] != "req-123" {
+			t.Errorf("log %q request_id = %v, want req-123", entry["msg"], entry[logx.FieldRequestID])
+		}
+		if entry[logx.FieldTraceID] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("log %q trace_id = %v, want 4bf92f3577b34da6a3ce929d0e0e4736", entry["msg"], entry[logx.FieldTraceID])
+		}
+	}
+}
+
+// TestServer_APIVersions verifies that /v1 and /v2 are served side by side with their own response schemas
+func TestServer_APIVersions(t *testing.T) {
+	srv, err := New(&config.Config{Port: 8080}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	token, _, err := testutil.GenerateJWT(testutil.JWTConfig{
+		UserID:   "user-1",
+		Role:     auth.RoleUser,
+		KID:      "test-key-id",
+		Duration: 15 * time.Minute,
+	}, privateKey, time.Now())
+	if err != nil {
+		t.Fatalf("failed to generate JWT: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantFields []string
+		wantErrors int // Problem Details の errors の件数
+	}{
+		{
+			name:       "v1",
+			path:       "/v1/hello?name=Alice",
+			wantStatus: http.StatusOK,
+			wantFields: []string{"message", "timestamp"},
+		},
+		{
+			name:       "v2",
+			path:       "/v2/hello?name=Alice",
+			wantStatus: http.StatusOK,
+			wantFields: []string{"message", "name", "generated_at"},
+		},
+		{
+			name:       "v2のバリデーションエラーもv1と同じユースケースで返す",
+			path:       "/v2/hello?name=error",
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"type", "title", "status", "detail", "instance", "errors"},
+		},
+		{
+			name:       "v2は複数のパラメータのエラーをまとめて返す",
+			path:       "/v2/hello?name=error&repeat=9",
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"type", "title", "status", "detail", "instance", "errors"},
+			wantErrors: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			srv.httpServer.Handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantFields == nil {
+				return
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to parse body %s: %v", rec.Body.String(), err)
+			}
+			if len(body) != len(tt.wantFields) {
+				t.Errorf("body = %v, want fields %v", body, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := body[field]; !ok {
+					t.Errorf("body = %v, missing field %q", body, field)
+				}
+			}
+			if tt.wantStatus != http.StatusOK {
+				if errs, _ := body["errors"].([]any); tt.wantErrors > 0 && len(errs) != tt.wantErrors {
+					t.Errorf("errors = %v, want %d errors", body["errors"], tt.wantErrors)
+				}
+				return
+			}
+			if body["message"] != "Hello, Alice!" {
+				t.Errorf("message = %v, want %q", body["message"], "Hello, Alice!")
+			}
+		})
+	}
+}
+
+// TestServer_LogLevel verifies that admins can read and change the log level without restart
+func TestServer_LogLevel(t *testing.T) {
+	defaultLevel := logx.GetLevel()
+	defer logx.SetLevel(defaultLevel)
+	logx.SetLevel(logx.LevelInfo)
+
+	srv, err := New(&config.Config{Port: 8080}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	tokens := make(map[string]string)
+	for _, role := range []string{auth.RoleAdmin, auth.RoleUser} {
+		token, _, err := testutil.GenerateJWT(testutil.JWTConfig{
+			UserID:   "user-1",
+			Role:     role,
+			KID:      "test-key-id",
+			Duration: 15 * time.Minute,
+		}, privateKey, time.Now())
+		if err != nil {
+			t.Fatalf("failed to generate JWT: %v", err)
+		}
+		tokens[role] = token
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		role       string
+		body       string
+		wantStatus int
+		wantLevel  logx.Level
+	}{
+		{name: "adminは現在のレベルを参照できる", method: http.MethodGet, role: auth.RoleAdmin, wantStatus: http.StatusOK, wantLevel: logx.LevelInfo},
+		{name: "admin以外は変更できない", method: http.MethodPut, role: auth.RoleUser, body: `{"level":"DEBUG"}`, wantStatus: http.StatusForbidden, wantLevel: logx.LevelInfo},
+		{name: "不正なレベル", method: http.MethodPut, role: auth.RoleAdmin, body: `{"level":"VERBOSE"}`, wantStatus: http.StatusBadRequest, wantLevel: logx.LevelInfo},
+		{name: "adminはレベルを変更できる", method: http.MethodPut, role: auth.RoleAdmin, body: `{"level":"DEBUG"}`, wantStatus: http.StatusOK, wantLevel: logx.LevelDebug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/log-level", bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tokens[tt.role])
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			srv.httpServer.Handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := logx.GetLevel(); got != tt.wantLevel {
+				t.Errorf("GetLevel() = %v, want %v", got, tt.wantLevel)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to parse body %s: %v", rec.Body.String(), err)
+			}
+			if body["level"] != tt.wantLevel.String() {
+				t.Errorf("level = %v, want %v", body["level"], tt.wantLevel.String())
+			}
+		})
+	}
+}
+
+// TestServer_ConfigSchema verifies that the configuration reference is served without authentication
+func TestServer_ConfigSchema(t *testing.T) {
+	srv, err := New(&config.Config{Port: 8080}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/config/schema", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var fields []config.ReferenceField
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(fields) != len(config.Reference()) {
+		t.Errorf("got %d fields, want %d", len(fields), len(config.Reference()))
+	}
+}
+
+// TestToggleDebugLevel verifies that SIGUSR1 handling switches between debug and the startup level
+func TestToggleDebugLevel(t *testing.T) {
+	defaultLevel := logx.GetLevel()
+	defer logx.SetLevel(defaultLevel)
+
+	logx.SetLevel(logx.LevelWarn)
+	logger := logx.New(logx.LevelWarn)
+
+	if got := toggleDebugLevel(logx.LevelWarn); got != logx.LevelDebug {
+		t.Errorf("toggleDebugLevel() = %v, want %v", got, logx.LevelDebug)
+	}
+	// 作成済みのロガーにも反映されること
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug should be enabled after toggle")
+	}
+
+	if got := toggleDebugLevel(logx.LevelWarn); got != logx.LevelWarn {
+		t.Errorf("toggleDebugLevel() = %v, want %v", got, logx.LevelWarn)
+	}
+	if logger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info should be disabled after restoring warn level")
+	}
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kaitoimai/go-sample/rest/internal/pkg/myerrors"
+)
+
+const (
+	// defaultHelloName is the name used when the request does not specify one.
+	defaultHelloName = "World"
+	// maxHelloNameLength is the maximum number of characters in a name.
+	maxHelloNameLength = 100
+	// maxHelloRepeat is the maximum number of times a greeting can be repeated.
+	maxHelloRepeat = 5
+)
+
+// HelloInput is the input of HelloUsecase.Greet.
+// 指定されていないフィールドはデフォルト値（Name: "World", Repeat: 1）として扱う
+type HelloInput struct {
+	Name string
+	// NameSet reports whether the request specified a name (an empty name is then invalid).
+	NameSet bool
+	Repeat  int
+	// RepeatSet reports whether the request specified repeat.
+	RepeatSet bool
+}
+
+// Validate checks every field of the input and returns all field errors together.
+func (in HelloInput) Validate() error {
+	var v myerrors.Validator
+	if in.NameSet {
+		length := utf8.RuneCountInString(in.Name)
+		switch {
+		case length == 0:
+			v.Add("name", myerrors.ValidationNameTooShort)
+		case length > maxHelloNameLength:
+			v.Add("name", myerrors.ValidationNameTooLong)
+		case in.Name == "error": // Example validation: reject if name is "error"
+			v.Add("name", myerrors.ValidationNameReserved)
+		}
+	}
+	if in.RepeatSet {
+		v.Check(in.Repeat >= 1 && in.Repeat <= maxHelloRepeat, "repeat", myerrors.ValidationRepeatOutOfRange)
+	}
+	return v.Err()
+}
+
+// Greeting is the result of HelloUsecase.Greet.
+// APIのバージョンごとのレスポンスへは各ハンドラで変換する
+type Greeting struct {
+	Name        string
+	Message     string
+	GeneratedAt time.Time
+}
+
+// HelloUsecase builds greetings shared by every API version.
+type HelloUsecase struct {
+	now func() time.Time
+}
+
+// NewHelloUsecase creates a new HelloUsecase
+func NewHelloUsecase() *HelloUsecase {
+	return &HelloUsecase{now: time.Now}
+}
+
+// Greet returns a greeting for the input after validating every field.
+func (u *HelloUsecase) Greet(ctx context.Context, in HelloInput) (Greeting, error) {
+	if err := in.Validate(); err != nil {
+		return Greeting{}, err
+	}
+
+	name := in.Name
+	if !in.NameSet {
+		name = defaultHelloName
+	}
+	// Example server error: reject if name is "panic"
+	if name == "panic" {
+		return Greeting{}, myerrors.NewSystemError(
+			"サーバーエラーが発生しました",
+			"panic triggered by test input",
+			nil,
+		)
+	}
+
+	repeat := 1
+	if in.RepeatSet {
+		repeat = in.Repeat
+	}
+	message := strings.Repeat(fmt.Sprintf("Hello, %s! ", name), repeat)
+
+	return Greeting{
+		Name:        name,
+		Message:     strings.TrimSuffix(message, " "),
+		GeneratedAt: u.now(),
+	}, nil
+}
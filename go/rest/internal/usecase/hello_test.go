@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/kaitoimai/go-sample/rest/internal/pkg/myerrors"
+)
+
+// TestHelloUsecase_Greet_Validation verifies that every invalid field is reported together
+func TestHelloUsecase_Greet_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       HelloInput
+		wantMessage string
+		wantFields  []myerrors.FieldError
+	}{
+		{
+			name:        "指定なしはデフォルト値",
+			input:       HelloInput{},
+			wantMessage: "Hello, World!",
+		},
+		{
+			name:        "繰り返し",
+			input:       HelloInput{Name: "Alice", NameSet: true, Repeat: 2, RepeatSet: true},
+			wantMessage: "Hello, Alice! Hello, Alice!",
+		},
+		{
+			name:  "名前が長すぎる",
+			input: HelloInput{Name: strings.Repeat("あ", 101), NameSet: true},
+			wantFields: []myerrors.FieldError{
+				{Field: "name", Code: myerrors.ValidationNameTooLong, Message: "名前は100文字以内で入力してください"},
+			},
+		},
+		{
+			name:  "複数のフィールドのエラーをまとめて返す",
+			input: HelloInput{Name: "error", NameSet: true, Repeat: 0, RepeatSet: true},
+			wantFields: []myerrors.FieldError{
+				{Field: "name", Code: myerrors.ValidationNameReserved, Message: "名前に'error'は使用できません"},
+				{Field: "repeat", Code: myerrors.ValidationRepeatOutOfRange, Message: "繰り返し回数は1〜5で指定してください"},
+			},
+		},
+		{
+			name:  "空の名前と上限を超える繰り返し",
+			input: HelloInput{Name: "", NameSet: true, Repeat: 6, RepeatSet: true},
+			wantFields: []myerrors.FieldError{
+				{Field: "name", Code: myerrors.ValidationNameTooShort, Message: "名前は1文字以上で入力してください"},
+				{Field: "repeat", Code: myerrors.ValidationRepeatOutOfRange, Message: "繰り返し回数は1〜5で指定してください"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			greeting, err := NewHelloUsecase().Greet(context.Background(), tt.input)
+
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("Greet() error = %v", err)
+				}
+				if greeting.Message != tt.wantMessage {
+					t.Errorf("Message = %q, want %q", greeting.Message, tt.wantMessage)
+				}
+				return
+			}
+
+			var invalidArg *myerrors.InvalidArgumentError
+			if !errors.As(err, &invalidArg) {
+				t.Fatalf("Greet() error = %v, want InvalidArgumentError", err)
+			}
+			got := invalidArg.FieldErrors()
+			if len(got) != len(tt.wantFields) {
+				t.Fatalf("FieldErrors() = %+v, want %+v", got, tt.wantFields)
+			}
+			for i := range got {
+				if got[i] != tt.wantFields[i] {
+					t.Errorf("FieldErrors()[%d] = %+v, want %+v", i, got[i], tt.wantFields[i])
+				}
+			}
+		})
+	}
+}